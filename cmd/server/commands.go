@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/olekukonko/tablewriter"
+	"github.com/redis/go-redis/v9"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 
@@ -245,3 +247,35 @@ func listNodes(c *cli.Context) error {
 
 	return nil
 }
+
+func migrateStore(c *cli.Context) error {
+	conf, err := getConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// store urls without host use the redis of the config
+	var rc redis.UniversalClient
+	if conf.Redis.IsConfigured() {
+		if rc, err = service.NewRedisClient(&conf.Redis); err != nil {
+			return err
+		}
+	}
+
+	from, err := service.NewStoreFromURL(c.String("from"), conf, rc)
+	if err != nil {
+		return err
+	}
+	to, err := service.NewStoreFromURL(c.String("to"), conf, rc)
+	if err != nil {
+		return err
+	}
+
+	stats, err := service.MigrateStore(context.Background(), conf, from, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Migrated and verified", stats)
+	return nil
+}
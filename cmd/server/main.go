@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net"
@@ -28,7 +29,9 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/telemetry/otlp"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/pkg/telemetry/push"
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
 	"github.com/livekit/protocol/logger"
 
@@ -174,6 +177,23 @@ func main() {
 				Usage:  "list all nodes",
 				Action: listNodes,
 			},
+			{
+				Name:   "migrate-store",
+				Usage:  "copies rooms, participants, egress and ingress from one store to another, i.e. --from redis:// --to postgres://...",
+				Action: migrateStore,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "from",
+						Usage:    "url of the store to copy from",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "to",
+						Usage:    "url of the store to copy to",
+						Required: true,
+					},
+				},
+			},
 			{
 				Name:   "help-verbose",
 				Usage:  "prints app help, including all generated configuration flags",
@@ -257,6 +277,15 @@ func startServer(c *cli.Context) error {
 		return err
 	}
 
+	// secrets referenced by the config are resolved before it is validated and used
+	if err = conf.ValidateSecrets(); err != nil {
+		return err
+	}
+	secrets := service.NewConfigSecrets(conf)
+	if err = secrets.Resolve(conf); err != nil {
+		return err
+	}
+
 	// validate API key length
 	err = conf.ValidateKeys()
 	if err != nil {
@@ -281,7 +310,27 @@ func startServer(c *cli.Context) error {
 		return err
 	}
 
-	prometheus.Init(currentNode.Id, currentNode.Type, conf.Environment)
+	prometheus.Init(currentNode.Id, currentNode.Type, conf.Environment, conf.Telemetry)
+
+	if conf.Telemetry.OTLP.Enabled {
+		meterProvider, err := otlp.NewMeterProvider(context.Background(), conf.Telemetry.OTLP, prometheus.Gatherer(), currentNode.Id, currentNode.Type, conf.Environment)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// flush pending metrics on shutdown
+			_ = meterProvider.Shutdown(context.Background())
+		}()
+	}
+
+	if conf.Telemetry.Push.Enabled {
+		pusher, err := push.NewPusher(conf.Telemetry.Push, prometheus.Gatherer(), currentNode.Id)
+		if err != nil {
+			return err
+		}
+		pusher.Start()
+		defer pusher.Stop()
+	}
 
 	server, err := service.InitializeServer(conf, currentNode)
 	if err != nil {
@@ -297,6 +346,18 @@ func startServer(c *cli.Context) error {
 		server.Stop(false)
 	}()
 
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	go func() {
+		for range hupChan {
+			logger.Infow("rotating API keys")
+			if err := server.RotateSecrets(secrets); err != nil {
+				logger.Errorw("could not rotate API keys", err)
+			}
+		}
+	}()
+
 	return server.Start()
 }
 
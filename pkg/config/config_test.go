@@ -17,6 +17,7 @@ package config
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
@@ -47,6 +48,113 @@ room:
 	require.Error(t, err)
 }
 
+func TestConfig_KeyRotation(t *testing.T) {
+	const content = `keys:
+  key1: secret1
+  key2: secret2
+key_rotation:
+  retired_keys:
+    key1: 2024-01-01T00:00:00Z`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, conf.ValidateKeys())
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), conf.KeyRotation.RetiredKeys["key1"].UTC())
+
+	// retired keys do not sign
+	key, secret, ok := conf.SigningKeyPair()
+	require.True(t, ok)
+	require.Equal(t, "key2", key)
+	require.Equal(t, "secret2", secret)
+
+	conf.KeyRotation.SigningKey = "key1"
+	require.ErrorIs(t, conf.ValidateKeys(), ErrSigningKeyRetired)
+
+	conf.KeyRotation.SigningKey = ""
+	conf.KeyRotation.RetiredKeys["key2"] = time.Now()
+	require.ErrorIs(t, conf.ValidateKeys(), ErrAllKeysRetired)
+}
+
+func TestConfig_VaultSecrets(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+
+	const content = `keys:
+  key1: vault:livekit/api#key1
+secrets:
+  vault:
+    address: https://vault.example.com:8200`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.ErrorIs(t, conf.ValidateSecrets(), ErrVaultTokenNotSet)
+
+	t.Setenv("VAULT_TOKEN", "root")
+	require.NoError(t, conf.ValidateSecrets())
+
+	conf.Secrets.Vault.Address = ""
+	require.ErrorIs(t, conf.ValidateSecrets(), ErrVaultAddressNotSet)
+
+	// vault is only required when secrets are referenced from it
+	conf.Keys["key1"] = "secret1"
+	require.NoError(t, conf.ValidateSecrets())
+}
+
+func TestConfig_KeyIPAllowlists(t *testing.T) {
+	const content = `keys:
+  key1: secret1
+key_ip_allowlists:
+  key1:
+    - 10.0.0.0/8
+    - 203.0.113.7
+    - 2001:db8::/32`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, conf.ValidateKeys())
+	networks := conf.KeyAllowedNetworks()["key1"]
+	require.Len(t, networks, 3)
+	require.Equal(t, "203.0.113.7/32", networks[1].String())
+
+	conf.KeyIPAllowlists["key1"] = []string{"10.0.0.0/33"}
+	require.ErrorIs(t, conf.ValidateKeys(), ErrInvalidIPAllowlist)
+	conf.KeyIPAllowlists = map[string][]string{"key2": {"10.0.0.0/8"}}
+	require.ErrorIs(t, conf.ValidateKeys(), ErrAllowlistKeyNotFound)
+}
+
+func TestConfig_HistogramBuckets(t *testing.T) {
+	const content = `telemetry:
+  histogram_buckets:
+    room_duration_seconds: [5, 30, 60, 300]`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []float64{5, 30, 60, 300}, conf.Telemetry.HistogramBuckets["room_duration_seconds"])
+
+	const unordered = `telemetry:
+  histogram_buckets:
+    room_duration_seconds: [60, 5]`
+	_, err = NewConfig(unordered, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_PushLabels(t *testing.T) {
+	const content = `telemetry:
+  push:
+    enabled: true
+    url: http://pushgateway:9091
+    labels:
+      region: us-east`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "us-east", conf.Telemetry.Push.Labels["region"])
+
+	const reserved = `telemetry:
+  push:
+    enabled: true
+    url: http://pushgateway:9091
+    labels:
+      node_id: other`
+	_, err = NewConfig(reserved, true, nil, nil)
+	require.Error(t, err)
+}
+
 func TestGeneratedFlags(t *testing.T) {
 	generatedFlags, err := GenerateCLIFlags(nil, false)
 	require.NoError(t, err)
@@ -0,0 +1,19 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package roomevents is the gRPC service that streams the webhook events of rooms. it is served by the server only,
+// its messages are not part of the livekit protocol
+package roomevents
+
+//go:generate sh -c "protoc -I .. -I $(go list -m -f {{.Dir}} github.com/livekit/protocol) --go_out=paths=source_relative:.. --go-grpc_out=paths=source_relative:.. roomevents/room_events.proto"
@@ -26,4 +26,5 @@ var (
 	ErrInvalidRouterMessage = errors.New("invalid router message")
 	ErrChannelClosed        = errors.New("channel closed")
 	ErrChannelFull          = errors.New("channel is full")
+	ErrRedisUnavailable     = errors.New("redis is unavailable, operation requires another node")
 )
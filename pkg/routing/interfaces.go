@@ -17,6 +17,7 @@ package routing
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/protobuf/proto"
@@ -61,6 +62,44 @@ type ParticipantInit struct {
 	AdaptiveStream       bool
 	ID                   livekit.ParticipantID
 	SubscriberAllowPause *bool
+	// attributes of the participant set by its token
+	Attributes map[string]string
+	// the participant is disconnected when its session has lasted this long, set by its token
+	MaxSessionDuration time.Duration
+	// video published by the participant is limited to this resolution, set by its token
+	MaxPublishResolution *VideoResolution
+	// the client presents refreshed tokens over the signal connection, set by the token_refresh parameter of the
+	// signal connection
+	TokenRefresh bool
+}
+
+// VideoResolution bounds the dimensions of video, in either orientation
+type VideoResolution struct {
+	Width  uint32 `json:"width"`
+	Height uint32 `json:"height"`
+}
+
+// Allows returns whether video of the dimensions is within the resolution, portrait video is compared with the
+// resolution turned
+func (r *VideoResolution) Allows(width uint32, height uint32) bool {
+	long, short := r.Width, r.Height
+	if short > long {
+		long, short = short, long
+	}
+	if height > width {
+		width, height = height, width
+	}
+	return width <= long && height <= short
+}
+
+// startSessionGrants are the grants of a session start, with the attributes, the limits and the capabilities of the
+// participant. they are sent along with the grants for the start session message to carry them
+type startSessionGrants struct {
+	*auth.ClaimGrants
+	Attributes           map[string]string `json:"participantAttributes,omitempty"`
+	MaxSessionDuration   time.Duration     `json:"maxSessionDuration,omitempty"`
+	MaxPublishResolution *VideoResolution  `json:"maxPublishResolution,omitempty"`
+	TokenRefresh         bool              `json:"tokenRefresh,omitempty"`
 }
 
 type NewParticipantCallback func(
@@ -78,6 +117,8 @@ type RTCMessageCallback func(
 	msg *livekit.RTCNodeMessage,
 )
 
+type RoomCommandCallback func(ctx context.Context, cmd *RoomCommand)
+
 // Router allows multiple nodes to coordinate the participant session
 //
 //counterfeiter:generate . Router
@@ -105,6 +146,9 @@ type Router interface {
 
 	// OnRTCMessage is called to execute actions on the RTC node
 	OnRTCMessage(callback RTCMessageCallback)
+
+	// OnRoomCommand is called to execute commands of the server on the RTC node
+	OnRoomCommand(callback RoomCommandCallback)
 }
 
 type MessageRouter interface {
@@ -114,13 +158,16 @@ type MessageRouter interface {
 	// Write a message to a participant or room
 	WriteParticipantRTC(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, msg *livekit.RTCNodeMessage) error
 	WriteRoomRTC(ctx context.Context, roomName livekit.RoomName, msg *livekit.RTCNodeMessage) error
+
+	// WriteRoomCommand sends a command to the node hosting the room
+	WriteRoomCommand(ctx context.Context, cmd *RoomCommand) error
 }
 
-func CreateRouter(config *config.Config, rc redis.UniversalClient, node LocalNode, signalClient SignalClient) Router {
+func CreateRouter(config *config.Config, rc redis.UniversalClient, health *RedisHealth, node LocalNode, signalClient SignalClient) Router {
 	lr := NewLocalRouter(node, signalClient)
 
 	if rc != nil {
-		return NewRedisRouter(config, lr, rc)
+		return NewRedisRouter(config, lr, rc, health)
 	}
 
 	// local routing and store
@@ -129,7 +176,13 @@ func CreateRouter(config *config.Config, rc redis.UniversalClient, node LocalNod
 }
 
 func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionID livekit.ConnectionID) (*livekit.StartSession, error) {
-	claims, err := json.Marshal(pi.Grants)
+	claims, err := json.Marshal(&startSessionGrants{
+		ClaimGrants:          pi.Grants,
+		Attributes:           pi.Attributes,
+		MaxSessionDuration:   pi.MaxSessionDuration,
+		MaxPublishResolution: pi.MaxPublishResolution,
+		TokenRefresh:         pi.TokenRefresh,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -157,22 +210,26 @@ func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionI
 }
 
 func ParticipantInitFromStartSession(ss *livekit.StartSession, region string) (*ParticipantInit, error) {
-	claims := &auth.ClaimGrants{}
+	claims := &startSessionGrants{ClaimGrants: &auth.ClaimGrants{}}
 	if err := json.Unmarshal([]byte(ss.GrantsJson), claims); err != nil {
 		return nil, err
 	}
 
 	pi := &ParticipantInit{
-		Identity:        livekit.ParticipantIdentity(ss.Identity),
-		Name:            livekit.ParticipantName(ss.Name),
-		Reconnect:       ss.Reconnect,
-		ReconnectReason: ss.ReconnectReason,
-		Client:          ss.Client,
-		AutoSubscribe:   ss.AutoSubscribe,
-		Grants:          claims,
-		Region:          region,
-		AdaptiveStream:  ss.AdaptiveStream,
-		ID:              livekit.ParticipantID(ss.ParticipantId),
+		Identity:             livekit.ParticipantIdentity(ss.Identity),
+		Name:                 livekit.ParticipantName(ss.Name),
+		Reconnect:            ss.Reconnect,
+		ReconnectReason:      ss.ReconnectReason,
+		Client:               ss.Client,
+		AutoSubscribe:        ss.AutoSubscribe,
+		Grants:               claims.ClaimGrants,
+		Region:               region,
+		AdaptiveStream:       ss.AdaptiveStream,
+		ID:                   livekit.ParticipantID(ss.ParticipantId),
+		Attributes:           claims.Attributes,
+		MaxSessionDuration:   claims.MaxSessionDuration,
+		MaxPublishResolution: claims.MaxPublishResolution,
+		TokenRefresh:         claims.TokenRefresh,
 	}
 	if ss.SubscriberAllowPause != nil {
 		subscriberAllowPause := *ss.SubscriberAllowPause
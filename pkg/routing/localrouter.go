@@ -41,9 +41,11 @@ type LocalRouter struct {
 	isStarted        atomic.Bool
 
 	rtcMessageChan *MessageChannel
+	roomCommands   chan *RoomCommand
 
 	onNewParticipant NewParticipantCallback
 	onRTCMessage     RTCMessageCallback
+	onRoomCommand    RoomCommandCallback
 }
 
 func NewLocalRouter(currentNode LocalNode, signalClient SignalClient) *LocalRouter {
@@ -53,6 +55,7 @@ func NewLocalRouter(currentNode LocalNode, signalClient SignalClient) *LocalRout
 		requestChannels:  make(map[string]*MessageChannel),
 		responseChannels: make(map[string]*MessageChannel),
 		rtcMessageChan:   NewMessageChannel(livekit.ConnectionID("local"), localRTCChannelSize),
+		roomCommands:     make(chan *RoomCommand, localRoomCommandQueueSize),
 	}
 }
 
@@ -141,6 +144,15 @@ func (r *LocalRouter) WriteNodeRTC(_ context.Context, _ string, msg *livekit.RTC
 	return r.writeRTCMessage(r.rtcMessageChan, msg)
 }
 
+func (r *LocalRouter) WriteRoomCommand(_ context.Context, cmd *RoomCommand) error {
+	select {
+	case r.roomCommands <- cmd:
+		return nil
+	default:
+		return ErrChannelFull
+	}
+}
+
 func (r *LocalRouter) writeRTCMessage(sink MessageSink, msg *livekit.RTCNodeMessage) error {
 	defer sink.Close()
 	msg.SenderTime = time.Now().Unix()
@@ -155,6 +167,10 @@ func (r *LocalRouter) OnRTCMessage(callback RTCMessageCallback) {
 	r.onRTCMessage = callback
 }
 
+func (r *LocalRouter) OnRoomCommand(callback RoomCommandCallback) {
+	r.onRoomCommand = callback
+}
+
 func (r *LocalRouter) Start() error {
 	if r.isStarted.Swap(true) {
 		return nil
@@ -163,6 +179,7 @@ func (r *LocalRouter) Start() error {
 	// go r.memStatsWorker()
 	// on local routers, Start doesn't do anything, websocket connections initiate the connections
 	go r.rtcMessageWorker()
+	go r.roomCommandWorker()
 	return nil
 }
 
@@ -253,6 +270,15 @@ func (r *LocalRouter) rtcMessageWorker() {
 	}
 }
 
+// executes the commands of rooms hosted by this node, in the order they were written
+func (r *LocalRouter) roomCommandWorker() {
+	for cmd := range r.roomCommands {
+		if r.onRoomCommand != nil {
+			r.onRoomCommand(context.Background(), cmd)
+		}
+	}
+}
+
 func (r *LocalRouter) getMessageChannel(target map[string]*MessageChannel, key string) *MessageChannel {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -34,25 +34,78 @@ const (
 
 var redisCtx = context.Background()
 
+// redisKeys are the keys and channels of the router, prepended with the key prefix of the config
+type redisKeys struct {
+	prefix  string
+	cluster bool
+}
+
+func newRedisKeys(rc redis.UniversalClient, prefix string) redisKeys {
+	return redisKeys{
+		prefix:  prefix,
+		cluster: isRedisCluster(rc),
+	}
+}
+
+func (k redisKeys) nodes() string {
+	return k.prefix + NodesKey
+}
+
+func (k redisKeys) nodeRoom() string {
+	return k.prefix + NodeRoomKey
+}
+
 // location of the participant's RTC connection, hash
-func participantRTCKey(participantKey livekit.ParticipantKey) string {
-	return "participant_rtc:" + string(participantKey)
+func (k redisKeys) participantRTC(participantKey livekit.ParticipantKey) string {
+	return k.prefix + "participant_rtc:" + string(participantKey)
 }
 
 // location of the participant's Signal connection, hash
-func participantSignalKey(connectionID livekit.ConnectionID) string {
-	return "participant_signal:" + string(connectionID)
+func (k redisKeys) participantSignal(connectionID livekit.ConnectionID) string {
+	return k.prefix + "participant_signal:" + string(connectionID)
+}
+
+// isRedisCluster returns true for Redis Cluster clients. Router messages then use sharded pubsub, delivered by the
+// shard owning the channel rather than broadcast to every shard, and channels of a node are hash tagged into one slot.
+func isRedisCluster(rc redis.UniversalClient) bool {
+	_, ok := rc.(*redis.ClusterClient)
+	return ok
 }
 
-func rtcNodeChannel(nodeID livekit.NodeID) string {
-	return "rtc_channel:" + string(nodeID)
+func (k redisKeys) nodeChannelID(nodeID livekit.NodeID) string {
+	if k.cluster {
+		return "{" + string(nodeID) + "}"
+	}
+	return string(nodeID)
+}
+
+func (k redisKeys) rtcNodeChannel(nodeID livekit.NodeID) string {
+	return k.prefix + "rtc_channel:" + k.nodeChannelID(nodeID)
 }
 
-func signalNodeChannel(nodeID livekit.NodeID) string {
-	return "signal_channel:" + string(nodeID)
+func (k redisKeys) roomCommandNodeChannel(nodeID livekit.NodeID) string {
+	return k.prefix + "room_command_channel:" + k.nodeChannelID(nodeID)
+}
+
+func (k redisKeys) signalNodeChannel(nodeID livekit.NodeID) string {
+	return k.prefix + "signal_channel:" + k.nodeChannelID(nodeID)
+}
+
+func publishNodeMessage(rc redis.UniversalClient, channel string, data []byte) error {
+	if isRedisCluster(rc) {
+		return rc.SPublish(redisCtx, channel, data).Err()
+	}
+	return rc.Publish(redisCtx, channel, data).Err()
+}
+
+func subscribeNodeChannels(ctx context.Context, rc redis.UniversalClient, channels ...string) *redis.PubSub {
+	if isRedisCluster(rc) {
+		return rc.SSubscribe(ctx, channels...)
+	}
+	return rc.Subscribe(ctx, channels...)
 }
 
-func publishRTCMessage(rc redis.UniversalClient, nodeID livekit.NodeID, participantKey livekit.ParticipantKey, participantKeyB62 livekit.ParticipantKey, msg proto.Message) error {
+func publishRTCMessage(rc redis.UniversalClient, keys redisKeys, nodeID livekit.NodeID, participantKey livekit.ParticipantKey, participantKeyB62 livekit.ParticipantKey, msg proto.Message) error {
 	rm := &livekit.RTCNodeMessage{
 		ParticipantKey:    string(participantKey),
 		ParticipantKeyB62: string(participantKeyB62),
@@ -78,12 +131,12 @@ func publishRTCMessage(rc redis.UniversalClient, nodeID livekit.NodeID, particip
 		return err
 	}
 
-	// logger.Debugw("publishing to rtc", "rtcChannel", rtcNodeChannel(nodeID),
+	// logger.Debugw("publishing to rtc", "rtcChannel", keys.rtcNodeChannel(nodeID),
 	//	"message", rm.Message)
-	return rc.Publish(redisCtx, rtcNodeChannel(nodeID), data).Err()
+	return publishNodeMessage(rc, keys.rtcNodeChannel(nodeID), data)
 }
 
-func publishSignalMessage(rc redis.UniversalClient, nodeID livekit.NodeID, connectionID livekit.ConnectionID, msg proto.Message) error {
+func publishSignalMessage(rc redis.UniversalClient, keys redisKeys, nodeID livekit.NodeID, connectionID livekit.ConnectionID, msg proto.Message) error {
 	rm := &livekit.SignalNodeMessage{
 		ConnectionId: string(connectionID),
 	}
@@ -104,13 +157,14 @@ func publishSignalMessage(rc redis.UniversalClient, nodeID livekit.NodeID, conne
 		return err
 	}
 
-	// logger.Debugw("publishing to signal", "signalChannel", signalNodeChannel(nodeID),
+	// logger.Debugw("publishing to signal", "signalChannel", keys.signalNodeChannel(nodeID),
 	//	"message", rm.Message)
-	return rc.Publish(redisCtx, signalNodeChannel(nodeID), data).Err()
+	return publishNodeMessage(rc, keys.signalNodeChannel(nodeID), data)
 }
 
 type RTCNodeSink struct {
 	rc                redis.UniversalClient
+	keys              redisKeys
 	nodeID            livekit.NodeID
 	connectionID      livekit.ConnectionID
 	participantKey    livekit.ParticipantKey
@@ -121,6 +175,7 @@ type RTCNodeSink struct {
 
 func NewRTCNodeSink(
 	rc redis.UniversalClient,
+	keyPrefix string,
 	nodeID livekit.NodeID,
 	connectionID livekit.ConnectionID,
 	participantKey livekit.ParticipantKey,
@@ -128,6 +183,7 @@ func NewRTCNodeSink(
 ) *RTCNodeSink {
 	return &RTCNodeSink{
 		rc:                rc,
+		keys:              newRedisKeys(rc, keyPrefix),
 		nodeID:            nodeID,
 		connectionID:      connectionID,
 		participantKey:    participantKey,
@@ -139,7 +195,7 @@ func (s *RTCNodeSink) WriteMessage(msg proto.Message) error {
 	if s.isClosed.Load() {
 		return ErrChannelClosed
 	}
-	return publishRTCMessage(s.rc, s.nodeID, s.participantKey, s.participantKeyB62, msg)
+	return publishRTCMessage(s.rc, s.keys, s.nodeID, s.participantKey, s.participantKeyB62, msg)
 }
 
 func (s *RTCNodeSink) Close() {
@@ -167,15 +223,17 @@ func (s *RTCNodeSink) ConnectionID() livekit.ConnectionID {
 
 type SignalNodeSink struct {
 	rc           redis.UniversalClient
+	keys         redisKeys
 	nodeID       livekit.NodeID
 	connectionID livekit.ConnectionID
 	isClosed     atomic.Bool
 	onClose      func()
 }
 
-func NewSignalNodeSink(rc redis.UniversalClient, nodeID livekit.NodeID, connectionID livekit.ConnectionID) *SignalNodeSink {
+func NewSignalNodeSink(rc redis.UniversalClient, keyPrefix string, nodeID livekit.NodeID, connectionID livekit.ConnectionID) *SignalNodeSink {
 	return &SignalNodeSink{
 		rc:           rc,
+		keys:         newRedisKeys(rc, keyPrefix),
 		nodeID:       nodeID,
 		connectionID: connectionID,
 	}
@@ -185,14 +243,14 @@ func (s *SignalNodeSink) WriteMessage(msg proto.Message) error {
 	if s.isClosed.Load() {
 		return ErrChannelClosed
 	}
-	return publishSignalMessage(s.rc, s.nodeID, s.connectionID, msg)
+	return publishSignalMessage(s.rc, s.keys, s.nodeID, s.connectionID, msg)
 }
 
 func (s *SignalNodeSink) Close() {
 	if s.isClosed.Swap(true) {
 		return
 	}
-	_ = publishSignalMessage(s.rc, s.nodeID, s.connectionID, &livekit.EndSession{})
+	_ = publishSignalMessage(s.rc, s.keys, s.nodeID, s.connectionID, &livekit.EndSession{})
 	if s.onClose != nil {
 		s.onClose()
 	}
@@ -0,0 +1,144 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const defaultRedisFailureThreshold = 3
+
+// RedisHealth is a circuit breaker on redis. The circuit opens after consecutive failed probes, while it is
+// open the node is degraded: the router and store serve the rooms of the node from memory rather than failing
+// every call. It closes with the first successful probe
+type RedisHealth struct {
+	rc   redis.UniversalClient
+	conf config.RedisHealthConfig
+
+	available atomic.Bool
+	failures  int
+
+	lock     sync.Mutex
+	onChange []func(available bool)
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+}
+
+// NewRedisHealth returns nil when redis or probing is not configured, redis is then always considered available
+func NewRedisHealth(conf *config.Config, rc redis.UniversalClient) *RedisHealth {
+	if rc == nil || !conf.Redis.Health.IsConfigured() {
+		return nil
+	}
+	h := &RedisHealth{
+		rc:   rc,
+		conf: conf.Redis.Health,
+		done: make(chan struct{}),
+	}
+	if h.conf.ProbeTimeout == 0 {
+		h.conf.ProbeTimeout = h.conf.ProbeInterval
+	}
+	if h.conf.FailureThreshold == 0 {
+		h.conf.FailureThreshold = defaultRedisFailureThreshold
+	}
+	h.available.Store(true)
+	return h
+}
+
+func (h *RedisHealth) IsAvailable() bool {
+	return h == nil || h.available.Load()
+}
+
+// OnChange is called when the circuit opens, with false, and when it closes again, with true
+func (h *RedisHealth) OnChange(f func(available bool)) {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	h.onChange = append(h.onChange, f)
+	h.lock.Unlock()
+}
+
+func (h *RedisHealth) Start() {
+	if h == nil {
+		return
+	}
+	h.startOnce.Do(func() {
+		go h.probeWorker()
+	})
+}
+
+func (h *RedisHealth) Stop() {
+	if h == nil {
+		return
+	}
+	h.stopOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+func (h *RedisHealth) probeWorker() {
+	ticker := time.NewTicker(h.conf.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.probe()
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *RedisHealth) probe() {
+	ctx, cancel := context.WithTimeout(context.Background(), h.conf.ProbeTimeout)
+	err := h.rc.Ping(ctx).Err()
+	cancel()
+
+	if err == nil {
+		h.failures = 0
+		if !h.available.Swap(true) {
+			logger.Infow("redis is available again, leaving degraded mode")
+			h.notify(true)
+		}
+		return
+	}
+
+	h.failures++
+	if h.failures >= h.conf.FailureThreshold && h.available.Swap(false) {
+		logger.Warnw("redis is unavailable, entering degraded mode", err, "failures", h.failures)
+		h.notify(false)
+	}
+}
+
+func (h *RedisHealth) notify(available bool) {
+	h.lock.Lock()
+	onChange := h.onChange
+	h.lock.Unlock()
+	for _, f := range onChange {
+		f(available)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestRedisHealth(t *testing.T) {
+	require.Nil(t, NewRedisHealth(&config.Config{}, nil))
+	var unconfigured *RedisHealth
+	require.True(t, unconfigured.IsAvailable())
+
+	// nothing listens on the port
+	rc := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+	defer rc.Close()
+	conf := &config.Config{}
+	conf.Redis.Health = config.RedisHealthConfig{ProbeInterval: time.Second, FailureThreshold: 2}
+	h := NewRedisHealth(conf, rc)
+
+	var changes []bool
+	h.OnChange(func(available bool) {
+		changes = append(changes, available)
+	})
+
+	h.probe()
+	require.True(t, h.IsAvailable())
+	h.probe()
+	require.False(t, h.IsAvailable())
+	h.probe()
+	require.Equal(t, []bool{false}, changes)
+}
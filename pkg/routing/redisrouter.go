@@ -17,6 +17,7 @@ package routing
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"runtime/pprof"
 	"sync"
 	"time"
@@ -40,6 +41,9 @@ const (
 	participantMappingTTL = 24 * time.Hour
 	statsUpdateInterval   = 2 * time.Second
 	statsMaxDelaySeconds  = 30
+	// channels of the node are subscribed again when its keep alives have not been received for this long,
+	// i.e. when the subscription was left on a redis master that failed over
+	resubscribeDelaySeconds = 10
 )
 
 // RedisRouter uses Redis pub/sub to route signaling messages across different nodes
@@ -49,6 +53,8 @@ type RedisRouter struct {
 	*LocalRouter
 
 	rc             redis.UniversalClient
+	keys           redisKeys
+	health         *RedisHealth
 	usePSRPCSignal bool
 	ctx            context.Context
 	isStarted      atomic.Bool
@@ -56,15 +62,23 @@ type RedisRouter struct {
 	// previous stats for computing averages
 	prevStats *livekit.NodeStats
 
-	pubsub *redis.PubSub
-	cancel func()
+	pubsubMu sync.Mutex
+	pubsub   *redis.PubSub
+	cancel   func()
+
+	// rooms hosted by this node, served without redis while it is unavailable
+	roomsMu    sync.RWMutex
+	localRooms map[livekit.RoomName]struct{}
 }
 
-func NewRedisRouter(config *config.Config, lr *LocalRouter, rc redis.UniversalClient) *RedisRouter {
+func NewRedisRouter(config *config.Config, lr *LocalRouter, rc redis.UniversalClient, health *RedisHealth) *RedisRouter {
 	rr := &RedisRouter{
 		LocalRouter:    lr,
 		rc:             rc,
+		keys:           newRedisKeys(rc, config.Redis.KeyPrefix),
+		health:         health,
 		usePSRPCSignal: config.SignalRelay.Enabled,
+		localRooms:     make(map[livekit.RoomName]struct{}),
 	}
 	rr.ctx, rr.cancel = context.WithCancel(context.Background())
 	return rr
@@ -77,7 +91,7 @@ func (r *RedisRouter) RegisterNode() error {
 	if err != nil {
 		return err
 	}
-	if err := r.rc.HSet(r.ctx, NodesKey, r.currentNode.Id, data).Err(); err != nil {
+	if err := r.rc.HSet(r.ctx, r.keys.nodes(), r.currentNode.Id, data).Err(); err != nil {
 		return errors.Wrap(err, "could not register node")
 	}
 	return nil
@@ -85,7 +99,7 @@ func (r *RedisRouter) RegisterNode() error {
 
 func (r *RedisRouter) UnregisterNode() error {
 	// could be called after Stop(), so we'd want to use an unrelated context
-	return r.rc.HDel(context.Background(), NodesKey, r.currentNode.Id).Err()
+	return r.rc.HDel(context.Background(), r.keys.nodes(), r.currentNode.Id).Err()
 }
 
 func (r *RedisRouter) RemoveDeadNodes() error {
@@ -95,7 +109,7 @@ func (r *RedisRouter) RemoveDeadNodes() error {
 	}
 	for _, n := range nodes {
 		if !selector.IsAvailable(n) {
-			if err := r.rc.HDel(context.Background(), NodesKey, n.Id).Err(); err != nil {
+			if err := r.rc.HDel(context.Background(), r.keys.nodes(), n.Id).Err(); err != nil {
 				return err
 			}
 		}
@@ -104,7 +118,16 @@ func (r *RedisRouter) RemoveDeadNodes() error {
 }
 
 func (r *RedisRouter) GetNodeForRoom(_ context.Context, roomName livekit.RoomName) (*livekit.Node, error) {
-	nodeID, err := r.rc.HGet(r.ctx, NodeRoomKey, string(roomName)).Result()
+	if !r.health.IsAvailable() {
+		if !r.isLocalRoom(roomName) {
+			return nil, ErrRedisUnavailable
+		}
+		r.nodeMu.RLock()
+		defer r.nodeMu.RUnlock()
+		return proto.Clone((*livekit.Node)(r.currentNode)).(*livekit.Node), nil
+	}
+
+	nodeID, err := r.rc.HGet(r.ctx, r.keys.nodeRoom(), string(roomName)).Result()
 	if err == redis.Nil {
 		return nil, ErrNotFound
 	} else if err != nil {
@@ -115,18 +138,25 @@ func (r *RedisRouter) GetNodeForRoom(_ context.Context, roomName livekit.RoomNam
 }
 
 func (r *RedisRouter) SetNodeForRoom(_ context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
-	return r.rc.HSet(r.ctx, NodeRoomKey, string(roomName), string(nodeID)).Err()
+	if nodeID == livekit.NodeID(r.currentNode.Id) {
+		r.setLocalRoom(roomName)
+	}
+	return r.rc.HSet(r.ctx, r.keys.nodeRoom(), string(roomName), string(nodeID)).Err()
 }
 
 func (r *RedisRouter) ClearRoomState(_ context.Context, roomName livekit.RoomName) error {
-	if err := r.rc.HDel(context.Background(), NodeRoomKey, string(roomName)).Err(); err != nil {
+	r.roomsMu.Lock()
+	delete(r.localRooms, roomName)
+	r.roomsMu.Unlock()
+
+	if err := r.rc.HDel(context.Background(), r.keys.nodeRoom(), string(roomName)).Err(); err != nil {
 		return errors.Wrap(err, "could not clear room state")
 	}
 	return nil
 }
 
 func (r *RedisRouter) GetNode(nodeID livekit.NodeID) (*livekit.Node, error) {
-	data, err := r.rc.HGet(r.ctx, NodesKey, string(nodeID)).Result()
+	data, err := r.rc.HGet(r.ctx, r.keys.nodes(), string(nodeID)).Result()
 	if err == redis.Nil {
 		return nil, ErrNotFound
 	} else if err != nil {
@@ -140,7 +170,7 @@ func (r *RedisRouter) GetNode(nodeID livekit.NodeID) (*livekit.Node, error) {
 }
 
 func (r *RedisRouter) ListNodes() ([]*livekit.Node, error) {
-	items, err := r.rc.HVals(r.ctx, NodesKey).Result()
+	items, err := r.rc.HVals(r.ctx, r.keys.nodes()).Result()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not list nodes")
 	}
@@ -187,7 +217,7 @@ func (r *RedisRouter) StartParticipantSignal(ctx context.Context, roomName livek
 	// set up response channel before sending StartSession and be ready to receive responses.
 	resChan := r.getOrCreateMessageChannel(r.responseChannels, string(connectionID))
 
-	sink := NewRTCNodeSink(r.rc, livekit.NodeID(rtcNode.Id), connectionID, pKey, pKeyB62)
+	sink := NewRTCNodeSink(r.rc, r.keys.prefix, livekit.NodeID(rtcNode.Id), connectionID, pKey, pKeyB62)
 
 	// serialize claims
 	ss, err := pi.ToStartSession(roomName, connectionID)
@@ -204,15 +234,25 @@ func (r *RedisRouter) StartParticipantSignal(ctx context.Context, roomName livek
 	return connectionID, sink, resChan, nil
 }
 
-func (r *RedisRouter) WriteParticipantRTC(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, msg *livekit.RTCNodeMessage) error {
+func (r *RedisRouter) WriteParticipantRTC(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, msg *livekit.RTCNodeMessage) error {
 	pkey := ParticipantKeyLegacy(roomName, identity)
 	pkeyB62 := ParticipantKey(roomName, identity)
+	if !r.health.IsAvailable() {
+		// participants are connected to the node hosting their room
+		if !r.isLocalRoom(roomName) {
+			return ErrRedisUnavailable
+		}
+		msg.ParticipantKey = string(pkey)
+		msg.ParticipantKeyB62 = string(pkeyB62)
+		return r.WriteNodeRTC(ctx, r.currentNode.Id, msg)
+	}
+
 	rtcNode, err := r.getParticipantRTCNode(pkey, pkeyB62)
 	if err != nil {
 		return err
 	}
 
-	rtcSink := NewRTCNodeSink(r.rc, livekit.NodeID(rtcNode), "ephemeral", pkey, pkeyB62)
+	rtcSink := NewRTCNodeSink(r.rc, r.keys.prefix, livekit.NodeID(rtcNode), "ephemeral", pkey, pkeyB62)
 	msg.ParticipantKey = string(ParticipantKeyLegacy(roomName, identity))
 	msg.ParticipantKeyB62 = string(ParticipantKey(roomName, identity))
 	return r.writeRTCMessage(rtcSink, msg)
@@ -229,10 +269,40 @@ func (r *RedisRouter) WriteRoomRTC(ctx context.Context, roomName livekit.RoomNam
 }
 
 func (r *RedisRouter) WriteNodeRTC(_ context.Context, rtcNodeID string, msg *livekit.RTCNodeMessage) error {
-	rtcSink := NewRTCNodeSink(r.rc, livekit.NodeID(rtcNodeID), "ephemeral", livekit.ParticipantKey(msg.ParticipantKey), livekit.ParticipantKey(msg.ParticipantKeyB62))
+	if !r.health.IsAvailable() {
+		if rtcNodeID != r.currentNode.Id {
+			return ErrRedisUnavailable
+		}
+		// handled as if it had been received from redis
+		msg.SenderTime = time.Now().Unix()
+		return r.handleRTCMessage(msg)
+	}
+
+	rtcSink := NewRTCNodeSink(r.rc, r.keys.prefix, livekit.NodeID(rtcNodeID), "ephemeral", livekit.ParticipantKey(msg.ParticipantKey), livekit.ParticipantKey(msg.ParticipantKeyB62))
 	return r.writeRTCMessage(rtcSink, msg)
 }
 
+func (r *RedisRouter) WriteRoomCommand(ctx context.Context, cmd *RoomCommand) error {
+	node, err := r.GetNodeForRoom(ctx, cmd.Room)
+	if err != nil {
+		return err
+	}
+	if !r.health.IsAvailable() {
+		if node.Id != r.currentNode.Id {
+			return ErrRedisUnavailable
+		}
+		// handled as if it had been received from redis
+		r.handleRoomCommand(cmd)
+		return nil
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	return publishNodeMessage(r.rc, r.keys.roomCommandNodeChannel(livekit.NodeID(node.Id)), data)
+}
+
 func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantKey livekit.ParticipantKey, participantKeyB62 livekit.ParticipantKey) error {
 	prometheus.IncrementParticipantRtcInit(1)
 	// find the node where the room is hosted at
@@ -249,6 +319,8 @@ func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantK
 		return err
 	}
 
+	r.setLocalRoom(livekit.RoomName(ss.RoomName))
+
 	if err := r.SetParticipantRTCNode(participantKey, participantKeyB62, rtcNode.Id); err != nil {
 		return err
 	}
@@ -289,7 +361,7 @@ func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantK
 	}
 
 	reqChan := r.getOrCreateMessageChannel(r.requestChannels, string(pkey))
-	resSink := NewSignalNodeSink(r.rc, livekit.NodeID(signalNode), livekit.ConnectionID(ss.ConnectionId))
+	resSink := NewSignalNodeSink(r.rc, r.keys.prefix, livekit.NodeID(signalNode), livekit.ConnectionID(ss.ConnectionId))
 	go func() {
 		err := r.onNewParticipant(
 			r.ctx,
@@ -316,6 +388,8 @@ func (r *RedisRouter) Start() error {
 		return nil
 	}
 
+	r.health.Start()
+
 	workerStarted := make(chan struct{})
 	go r.statsWorker()
 	go r.redisWorker(workerStarted)
@@ -343,21 +417,36 @@ func (r *RedisRouter) Stop() {
 		return
 	}
 	logger.Debugw("stopping RedisRouter")
-	_ = r.pubsub.Close()
-	_ = r.UnregisterNode()
+	// canceled first, for the worker not to subscribe again
 	r.cancel()
+	r.closeSubscription()
+	r.health.Stop()
+	_ = r.UnregisterNode()
+}
+
+func (r *RedisRouter) setLocalRoom(roomName livekit.RoomName) {
+	r.roomsMu.Lock()
+	r.localRooms[roomName] = struct{}{}
+	r.roomsMu.Unlock()
+}
+
+func (r *RedisRouter) isLocalRoom(roomName livekit.RoomName) bool {
+	r.roomsMu.RLock()
+	defer r.roomsMu.RUnlock()
+	_, ok := r.localRooms[roomName]
+	return ok
 }
 
 func (r *RedisRouter) SetParticipantRTCNode(participantKey livekit.ParticipantKey, participantKeyB62 livekit.ParticipantKey, nodeID string) error {
 	var err error
 	if participantKey != "" {
-		err1 := r.rc.Set(r.ctx, participantRTCKey(participantKey), nodeID, participantMappingTTL).Err()
+		err1 := r.rc.Set(r.ctx, r.keys.participantRTC(participantKey), nodeID, participantMappingTTL).Err()
 		if err1 != nil {
 			err = errors.Wrap(err, "could not set rtc node")
 		}
 	}
 	if participantKeyB62 != "" {
-		err2 := r.rc.Set(r.ctx, participantRTCKey(participantKeyB62), nodeID, participantMappingTTL).Err()
+		err2 := r.rc.Set(r.ctx, r.keys.participantRTC(participantKeyB62), nodeID, participantMappingTTL).Err()
 		if err2 != nil {
 			err = errors.Wrap(err, "could not set rtc node")
 		}
@@ -366,7 +455,7 @@ func (r *RedisRouter) SetParticipantRTCNode(participantKey livekit.ParticipantKe
 }
 
 func (r *RedisRouter) setParticipantSignalNode(connectionID livekit.ConnectionID, nodeID string) error {
-	if err := r.rc.Set(r.ctx, participantSignalKey(connectionID), nodeID, participantMappingTTL).Err(); err != nil {
+	if err := r.rc.Set(r.ctx, r.keys.participantSignal(connectionID), nodeID, participantMappingTTL).Err(); err != nil {
 		return errors.Wrap(err, "could not set signal node")
 	}
 	return nil
@@ -376,15 +465,15 @@ func (r *RedisRouter) getParticipantRTCNode(participantKey livekit.ParticipantKe
 	var val string
 	var err error
 	if participantKeyB62 != "" {
-		val, err = r.rc.Get(r.ctx, participantRTCKey(participantKeyB62)).Result()
+		val, err = r.rc.Get(r.ctx, r.keys.participantRTC(participantKeyB62)).Result()
 		if err == redis.Nil {
-			val, err = r.rc.Get(r.ctx, participantRTCKey(participantKey)).Result()
+			val, err = r.rc.Get(r.ctx, r.keys.participantRTC(participantKey)).Result()
 			if err == redis.Nil {
 				err = ErrNodeNotFound
 			}
 		}
 	} else {
-		val, err = r.rc.Get(r.ctx, participantRTCKey(participantKey)).Result()
+		val, err = r.rc.Get(r.ctx, r.keys.participantRTC(participantKey)).Result()
 		if err == redis.Nil {
 			err = ErrNodeNotFound
 		}
@@ -393,7 +482,7 @@ func (r *RedisRouter) getParticipantRTCNode(participantKey livekit.ParticipantKe
 }
 
 func (r *RedisRouter) getParticipantSignalNode(connectionID livekit.ConnectionID) (nodeID string, err error) {
-	val, err := r.rc.Get(r.ctx, participantSignalKey(connectionID)).Result()
+	val, err := r.rc.Get(r.ctx, r.keys.participantSignal(connectionID)).Result()
 	if err == redis.Nil {
 		err = ErrNodeNotFound
 	}
@@ -403,6 +492,7 @@ func (r *RedisRouter) getParticipantSignalNode(connectionID livekit.ConnectionID
 // update node stats and cleanup
 func (r *RedisRouter) statsWorker() {
 	goroutineDumped := false
+	var resubscribedAt time.Time
 	for r.ctx.Err() == nil {
 		// update periodically
 		select {
@@ -415,6 +505,11 @@ func (r *RedisRouter) statsWorker() {
 			r.nodeMu.RUnlock()
 
 			delaySeconds := time.Now().Unix() - stats.UpdatedAt
+			if delaySeconds > resubscribeDelaySeconds && time.Since(resubscribedAt) > resubscribeDelaySeconds*time.Second {
+				resubscribedAt = time.Now()
+				logger.Warnw("keep alives not received, subscribing again", nil, "delay", delaySeconds)
+				r.closeSubscription()
+			}
 			if delaySeconds > statsMaxDelaySeconds {
 				if !goroutineDumped {
 					goroutineDumped = true
@@ -433,19 +528,44 @@ func (r *RedisRouter) statsWorker() {
 	}
 }
 
-// worker that consumes redis messages intended for this node
+// worker that consumes redis messages intended for this node. the channels are subscribed again
+// when the subscription is closed while the router is running
 func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 	defer func() {
 		logger.Debugw("finishing redisWorker", "nodeID", r.currentNode.Id)
 	}()
 	logger.Debugw("starting redisWorker", "nodeID", r.currentNode.Id)
 
-	sigChannel := signalNodeChannel(livekit.NodeID(r.currentNode.Id))
-	rtcChannel := rtcNodeChannel(livekit.NodeID(r.currentNode.Id))
-	r.pubsub = r.rc.Subscribe(r.ctx, sigChannel, rtcChannel)
+	sigChannel := r.keys.signalNodeChannel(livekit.NodeID(r.currentNode.Id))
+	rtcChannel := r.keys.rtcNodeChannel(livekit.NodeID(r.currentNode.Id))
+	cmdChannel := r.keys.roomCommandNodeChannel(livekit.NodeID(r.currentNode.Id))
+	for {
+		r.pubsubMu.Lock()
+		if r.ctx.Err() != nil {
+			r.pubsubMu.Unlock()
+			return
+		}
+		pubsub := subscribeNodeChannels(r.ctx, r.rc, sigChannel, rtcChannel, cmdChannel)
+		r.pubsub = pubsub
+		r.pubsubMu.Unlock()
+
+		if startedChan != nil {
+			close(startedChan)
+			startedChan = nil
+		}
+		r.consumeMessages(pubsub, sigChannel, rtcChannel, cmdChannel)
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(time.Second):
+			logger.Infow("subscribing to router channels again", "nodeID", r.currentNode.Id)
+		}
+	}
+}
 
-	close(startedChan)
-	for msg := range r.pubsub.Channel() {
+func (r *RedisRouter) consumeMessages(pubsub *redis.PubSub, sigChannel, rtcChannel, cmdChannel string) {
+	for msg := range pubsub.Channel() {
 		if msg == nil {
 			return
 		}
@@ -476,10 +596,29 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 				continue
 			}
 			prometheus.MessageCounter.WithLabelValues("rtc", "success").Add(1)
+		} else if msg.Channel == cmdChannel {
+			cmd := RoomCommand{}
+			if err := json.Unmarshal([]byte(msg.Payload), &cmd); err != nil {
+				logger.Errorw("could not unmarshal room command on cmdchan", err)
+				prometheus.MessageCounter.WithLabelValues("command", "failure").Add(1)
+				continue
+			}
+			r.handleRoomCommand(&cmd)
+			prometheus.MessageCounter.WithLabelValues("command", "success").Add(1)
 		}
 	}
 }
 
+// closeSubscription closes the subscription to the channels of the node, the worker subscribes again
+// unless the router is stopped
+func (r *RedisRouter) closeSubscription() {
+	r.pubsubMu.Lock()
+	defer r.pubsubMu.Unlock()
+	if r.pubsub != nil {
+		_ = r.pubsub.Close()
+	}
+}
+
 func (r *RedisRouter) handleSignalMessage(sm *livekit.SignalNodeMessage) error {
 	connectionID := sm.ConnectionId
 
@@ -583,3 +722,9 @@ func (r *RedisRouter) handleRTCMessage(rm *livekit.RTCNodeMessage) error {
 	}
 	return nil
 }
+
+func (r *RedisRouter) handleRoomCommand(cmd *RoomCommand) {
+	if r.onRoomCommand != nil {
+		r.onRoomCommand(r.ctx, cmd)
+	}
+}
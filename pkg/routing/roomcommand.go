@@ -0,0 +1,34 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"github.com/livekit/protocol/livekit"
+)
+
+// commands waiting to be executed on this node
+const localRoomCommandQueueSize = 1000
+
+type RoomCommandType string
+
+// RoomCommand is an action of the server on a room, such as moving a participant, executed by the node hosting it.
+// Commands are routed apart from RTC messages, which carry Room API requests, so no API request can be taken for one.
+type RoomCommand struct {
+	Type RoomCommandType  `json:"type"`
+	Room livekit.RoomName `json:"room"`
+	// participant the command applies to, empty for commands on the room
+	Identity livekit.ParticipantIdentity `json:"identity,omitempty"`
+	Payload  []byte                      `json:"payload,omitempty"`
+}
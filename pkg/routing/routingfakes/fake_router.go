@@ -72,6 +72,11 @@ type FakeRouter struct {
 	onRTCMessageArgsForCall []struct {
 		arg1 routing.RTCMessageCallback
 	}
+	OnRoomCommandStub        func(routing.RoomCommandCallback)
+	onRoomCommandMutex       sync.RWMutex
+	onRoomCommandArgsForCall []struct {
+		arg1 routing.RoomCommandCallback
+	}
 	RegisterNodeStub        func() error
 	registerNodeMutex       sync.RWMutex
 	registerNodeArgsForCall []struct {
@@ -162,6 +167,18 @@ type FakeRouter struct {
 	writeParticipantRTCReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WriteRoomCommandStub        func(context.Context, *routing.RoomCommand) error
+	writeRoomCommandMutex       sync.RWMutex
+	writeRoomCommandArgsForCall []struct {
+		arg1 context.Context
+		arg2 *routing.RoomCommand
+	}
+	writeRoomCommandReturns struct {
+		result1 error
+	}
+	writeRoomCommandReturnsOnCall map[int]struct {
+		result1 error
+	}
 	WriteRoomRTCStub        func(context.Context, livekit.RoomName, *livekit.RTCNodeMessage) error
 	writeRoomRTCMutex       sync.RWMutex
 	writeRoomRTCArgsForCall []struct {
@@ -503,6 +520,38 @@ func (fake *FakeRouter) OnRTCMessageArgsForCall(i int) routing.RTCMessageCallbac
 	return argsForCall.arg1
 }
 
+func (fake *FakeRouter) OnRoomCommand(arg1 routing.RoomCommandCallback) {
+	fake.onRoomCommandMutex.Lock()
+	fake.onRoomCommandArgsForCall = append(fake.onRoomCommandArgsForCall, struct {
+		arg1 routing.RoomCommandCallback
+	}{arg1})
+	stub := fake.OnRoomCommandStub
+	fake.recordInvocation("OnRoomCommand", []interface{}{arg1})
+	fake.onRoomCommandMutex.Unlock()
+	if stub != nil {
+		fake.OnRoomCommandStub(arg1)
+	}
+}
+
+func (fake *FakeRouter) OnRoomCommandCallCount() int {
+	fake.onRoomCommandMutex.RLock()
+	defer fake.onRoomCommandMutex.RUnlock()
+	return len(fake.onRoomCommandArgsForCall)
+}
+
+func (fake *FakeRouter) OnRoomCommandCalls(stub func(routing.RoomCommandCallback)) {
+	fake.onRoomCommandMutex.Lock()
+	defer fake.onRoomCommandMutex.Unlock()
+	fake.OnRoomCommandStub = stub
+}
+
+func (fake *FakeRouter) OnRoomCommandArgsForCall(i int) routing.RoomCommandCallback {
+	fake.onRoomCommandMutex.RLock()
+	defer fake.onRoomCommandMutex.RUnlock()
+	argsForCall := fake.onRoomCommandArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeRouter) RegisterNode() error {
 	fake.registerNodeMutex.Lock()
 	ret, specificReturn := fake.registerNodeReturnsOnCall[len(fake.registerNodeArgsForCall)]
@@ -938,6 +987,68 @@ func (fake *FakeRouter) WriteParticipantRTCReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRouter) WriteRoomCommand(arg1 context.Context, arg2 *routing.RoomCommand) error {
+	fake.writeRoomCommandMutex.Lock()
+	ret, specificReturn := fake.writeRoomCommandReturnsOnCall[len(fake.writeRoomCommandArgsForCall)]
+	fake.writeRoomCommandArgsForCall = append(fake.writeRoomCommandArgsForCall, struct {
+		arg1 context.Context
+		arg2 *routing.RoomCommand
+	}{arg1, arg2})
+	stub := fake.WriteRoomCommandStub
+	fakeReturns := fake.writeRoomCommandReturns
+	fake.recordInvocation("WriteRoomCommand", []interface{}{arg1, arg2})
+	fake.writeRoomCommandMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRouter) WriteRoomCommandCallCount() int {
+	fake.writeRoomCommandMutex.RLock()
+	defer fake.writeRoomCommandMutex.RUnlock()
+	return len(fake.writeRoomCommandArgsForCall)
+}
+
+func (fake *FakeRouter) WriteRoomCommandCalls(stub func(context.Context, *routing.RoomCommand) error) {
+	fake.writeRoomCommandMutex.Lock()
+	defer fake.writeRoomCommandMutex.Unlock()
+	fake.WriteRoomCommandStub = stub
+}
+
+func (fake *FakeRouter) WriteRoomCommandArgsForCall(i int) (context.Context, *routing.RoomCommand) {
+	fake.writeRoomCommandMutex.RLock()
+	defer fake.writeRoomCommandMutex.RUnlock()
+	argsForCall := fake.writeRoomCommandArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRouter) WriteRoomCommandReturns(result1 error) {
+	fake.writeRoomCommandMutex.Lock()
+	defer fake.writeRoomCommandMutex.Unlock()
+	fake.WriteRoomCommandStub = nil
+	fake.writeRoomCommandReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRouter) WriteRoomCommandReturnsOnCall(i int, result1 error) {
+	fake.writeRoomCommandMutex.Lock()
+	defer fake.writeRoomCommandMutex.Unlock()
+	fake.WriteRoomCommandStub = nil
+	if fake.writeRoomCommandReturnsOnCall == nil {
+		fake.writeRoomCommandReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeRoomCommandReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRouter) WriteRoomRTC(arg1 context.Context, arg2 livekit.RoomName, arg3 *livekit.RTCNodeMessage) error {
 	fake.writeRoomRTCMutex.Lock()
 	ret, specificReturn := fake.writeRoomRTCReturnsOnCall[len(fake.writeRoomRTCArgsForCall)]
@@ -1018,6 +1129,8 @@ func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	defer fake.onNewParticipantRTCMutex.RUnlock()
 	fake.onRTCMessageMutex.RLock()
 	defer fake.onRTCMessageMutex.RUnlock()
+	fake.onRoomCommandMutex.RLock()
+	defer fake.onRoomCommandMutex.RUnlock()
 	fake.registerNodeMutex.RLock()
 	defer fake.registerNodeMutex.RUnlock()
 	fake.removeDeadNodesMutex.RLock()
@@ -1034,6 +1147,8 @@ func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	defer fake.unregisterNodeMutex.RUnlock()
 	fake.writeParticipantRTCMutex.RLock()
 	defer fake.writeParticipantRTCMutex.RUnlock()
+	fake.writeRoomCommandMutex.RLock()
+	defer fake.writeRoomCommandMutex.RUnlock()
 	fake.writeRoomRTCMutex.RLock()
 	defer fake.writeRoomRTCMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
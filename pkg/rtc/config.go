@@ -15,6 +15,10 @@
 package rtc
 
 import (
+	"fmt"
+
+	"github.com/pion/dtls/v2"
+	"github.com/pion/dtls/v2/pkg/crypto/elliptic"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
 
@@ -28,6 +32,19 @@ const (
 	frameMarking = "urn:ietf:params:rtp-hdrext:framemarking"
 )
 
+// SRTP profiles and curves that DTLS may be restricted to, by their names in the config
+var (
+	srtpProtectionProfiles = map[string]dtls.SRTPProtectionProfile{
+		"SRTP_AEAD_AES_128_GCM":       dtls.SRTP_AEAD_AES_128_GCM,
+		"SRTP_AES128_CM_HMAC_SHA1_80": dtls.SRTP_AES128_CM_HMAC_SHA1_80,
+	}
+	dtlsEllipticCurves = map[string]elliptic.Curve{
+		"X25519": elliptic.X25519,
+		"P-256":  elliptic.P256,
+		"P-384":  elliptic.P384,
+	}
+)
+
 type WebRTCConfig struct {
 	rtcconfig.WebRTCConfig
 
@@ -35,6 +52,11 @@ type WebRTCConfig struct {
 	Receiver      ReceiverConfig
 	Publisher     DirectionConfig
 	Subscriber    DirectionConfig
+	MediaFlood    config.MediaFloodConfig
+
+	DTLSCertificates *DTLSCertificates
+	// curves of DTLS key exchange, the defaults of transports when empty
+	DTLSEllipticCurves []elliptic.Curve
 }
 
 type ReceiverConfig struct {
@@ -68,6 +90,30 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 	// we don't want to use active TCP on a server, clients should be dialing
 	webRTCConfig.SettingEngine.DisableActiveTCP(true)
 
+	dtlsCertificates, err := NewDTLSCertificates(rtcConf.DTLS)
+	if err != nil {
+		return nil, err
+	}
+	if len(rtcConf.DTLS.SRTPProtectionProfiles) != 0 {
+		profiles := make([]dtls.SRTPProtectionProfile, 0, len(rtcConf.DTLS.SRTPProtectionProfiles))
+		for _, name := range rtcConf.DTLS.SRTPProtectionProfiles {
+			profile, ok := srtpProtectionProfiles[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported srtp protection profile %s", name)
+			}
+			profiles = append(profiles, profile)
+		}
+		webRTCConfig.SettingEngine.SetSRTPProtectionProfiles(profiles...)
+	}
+	var curves []elliptic.Curve
+	for _, name := range rtcConf.DTLS.EllipticCurves {
+		curve, ok := dtlsEllipticCurves[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported dtls elliptic curve %s", name)
+		}
+		curves = append(curves, curve)
+	}
+
 	if rtcConf.PacketBufferSize == 0 {
 		rtcConf.PacketBufferSize = 500
 	}
@@ -129,8 +175,11 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 		Receiver: ReceiverConfig{
 			PacketBufferSize: rtcConf.PacketBufferSize,
 		},
-		Publisher:  publisherConfig,
-		Subscriber: subscriberConfig,
+		Publisher:          publisherConfig,
+		Subscriber:         subscriberConfig,
+		MediaFlood:         rtcConf.MediaFlood,
+		DTLSCertificates:   dtlsCertificates,
+		DTLSEllipticCurves: curves,
 	}, nil
 }
 
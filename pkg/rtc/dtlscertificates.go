@@ -0,0 +1,168 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// DTLSCertificates are the DTLS certificates of new connections of the node, which keep the fingerprints of the
+// node stable. a nil DTLSCertificates has connections generate their own
+type DTLSCertificates struct {
+	scheduled        []scheduledDTLSCertificate
+	rotationInterval time.Duration
+
+	lock        sync.Mutex
+	generated   *webrtc.Certificate
+	generatedAt time.Time
+}
+
+type scheduledDTLSCertificate struct {
+	certificate webrtc.Certificate
+	notBefore   time.Time
+	notAfter    time.Time
+}
+
+func NewDTLSCertificates(conf config.DTLSConfig) (*DTLSCertificates, error) {
+	if len(conf.Certificates) == 0 && conf.RotationInterval == 0 {
+		return nil, nil
+	}
+
+	c := &DTLSCertificates{
+		rotationInterval: conf.RotationInterval,
+	}
+	for _, cert := range conf.Certificates {
+		pair, err := config.LoadX509KeyPair(cert.CertFile, cert.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load dtls certificate %s: %v", cert.CertFile, err)
+		}
+		switch pair.PrivateKey.(type) {
+		case *ecdsa.PrivateKey, *rsa.PrivateKey:
+		default:
+			return nil, fmt.Errorf("dtls certificate %s must have an ECDSA or RSA key", cert.CertFile)
+		}
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse dtls certificate %s: %v", cert.CertFile, err)
+		}
+
+		certificate := webrtc.CertificateFromX509(pair.PrivateKey, leaf)
+		logger.Infow("loaded DTLS certificate",
+			"file", cert.CertFile,
+			"notBefore", leaf.NotBefore,
+			"notAfter", leaf.NotAfter,
+			"fingerprint", dtlsFingerprint(certificate),
+		)
+		c.scheduled = append(c.scheduled, scheduledDTLSCertificate{
+			certificate: certificate,
+			notBefore:   leaf.NotBefore,
+			notAfter:    leaf.NotAfter,
+		})
+	}
+	return c, nil
+}
+
+// Current returns the certificates of connections created at now, the configured certificate valid at now with the
+// latest start, or else the generated certificate. nil has the connection generate its own
+func (c *DTLSCertificates) Current(now time.Time) []webrtc.Certificate {
+	if c == nil {
+		return nil
+	}
+
+	var current *scheduledDTLSCertificate
+	for i := range c.scheduled {
+		s := &c.scheduled[i]
+		if now.Before(s.notBefore) || !now.Before(s.notAfter) {
+			continue
+		}
+		if current == nil || s.notBefore.After(current.notBefore) {
+			current = s
+		}
+	}
+	if current != nil {
+		return []webrtc.Certificate{current.certificate}
+	}
+	if c.rotationInterval == 0 {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.generated == nil || now.Sub(c.generatedAt) >= c.rotationInterval {
+		// valid beyond the interval for connections being created as it rotates
+		generated, err := generateDTLSCertificate(now, 2*c.rotationInterval)
+		if err != nil {
+			logger.Errorw("could not generate DTLS certificate", err)
+			if c.generated == nil {
+				return nil
+			}
+		} else {
+			logger.Infow("generated DTLS certificate", "fingerprint", dtlsFingerprint(*generated))
+			c.generated = generated
+			c.generatedAt = now
+		}
+	}
+	return []webrtc.Certificate{*c.generated}
+}
+
+func generateDTLSCertificate(now time.Time, validFor time.Duration) (*webrtc.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "livekit"},
+		NotBefore:    now,
+		NotAfter:     now.Add(validFor),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	certificate := webrtc.CertificateFromX509(key, leaf)
+	return &certificate, nil
+}
+
+func dtlsFingerprint(certificate webrtc.Certificate) string {
+	fingerprints, err := certificate.GetFingerprints()
+	if err != nil || len(fingerprints) == 0 {
+		return ""
+	}
+	return fingerprints[0].Algorithm + " " + fingerprints[0].Value
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDTLSCertificates(t *testing.T) {
+	t.Run("unconfigured", func(t *testing.T) {
+		c, err := NewDTLSCertificates(config.DTLSConfig{})
+		require.NoError(t, err)
+		require.Nil(t, c)
+		require.Nil(t, c.Current(time.Now()))
+	})
+
+	t.Run("configured certificates by validity", func(t *testing.T) {
+		now := time.Now()
+		dir := t.TempDir()
+		current := writeDTLSCertificate(t, dir, "current", now.Add(-time.Hour), now.Add(48*time.Hour))
+		next := writeDTLSCertificate(t, dir, "next", now.Add(24*time.Hour), now.Add(72*time.Hour))
+
+		c, err := NewDTLSCertificates(config.DTLSConfig{Certificates: []config.DTLSCertificateConfig{current, next}})
+		require.NoError(t, err)
+
+		fingerprint := func(at time.Time) string {
+			certificates := c.Current(at)
+			require.Len(t, certificates, 1)
+			return dtlsFingerprint(certificates[0])
+		}
+		first := fingerprint(now)
+		require.Equal(t, first, fingerprint(now.Add(time.Hour)))
+		// the next certificate takes over once it is valid
+		second := fingerprint(now.Add(25 * time.Hour))
+		require.NotEqual(t, first, second)
+		require.Equal(t, second, fingerprint(now.Add(50*time.Hour)))
+		// connections generate their own once none are valid
+		require.Nil(t, c.Current(now.Add(80*time.Hour)))
+	})
+
+	t.Run("generated certificates rotate", func(t *testing.T) {
+		c, err := NewDTLSCertificates(config.DTLSConfig{RotationInterval: time.Hour})
+		require.NoError(t, err)
+
+		now := time.Now()
+		certificates := c.Current(now)
+		require.Len(t, certificates, 1)
+		first := dtlsFingerprint(certificates[0])
+		require.NotEmpty(t, first)
+		require.Equal(t, first, dtlsFingerprint(c.Current(now.Add(30 * time.Minute))[0]))
+		require.NotEqual(t, first, dtlsFingerprint(c.Current(now.Add(time.Hour))[0]))
+	})
+
+	t.Run("unsupported profiles and curves", func(t *testing.T) {
+		_, err := NewWebRTCConfig(&config.Config{RTC: config.RTCConfig{
+			DTLS: config.DTLSConfig{SRTPProtectionProfiles: []string{"SRTP_NULL"}},
+		}})
+		require.Error(t, err)
+
+		_, err = NewWebRTCConfig(&config.Config{RTC: config.RTCConfig{
+			DTLS: config.DTLSConfig{EllipticCurves: []string{"P-521"}},
+		}})
+		require.Error(t, err)
+	})
+}
+
+func writeDTLSCertificate(t *testing.T, dir string, name string, notBefore, notAfter time.Time) config.DTLSCertificateConfig {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notBefore.Unix()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	conf := config.DTLSCertificateConfig{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+	require.NoError(t, os.WriteFile(conf.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(conf.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return conf
+}
@@ -14,10 +14,18 @@
 
 package rtc
 
-import "errors"
+import (
+	"context"
+	"errors"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
 
 var (
 	ErrRoomClosed              = errors.New("room has already closed")
+	ErrRoomClosing             = errors.New("room is closing")
 	ErrPermissionDenied        = errors.New("no permissions to access the room")
 	ErrMaxParticipantsExceeded = errors.New("room has exceeded its max participants")
 	ErrLimitExceeded           = errors.New("node has exceeded its configured limit")
@@ -27,6 +35,12 @@ var (
 	ErrEmptyIdentity           = errors.New("participant identity cannot be empty")
 	ErrEmptyParticipantID      = errors.New("participant ID cannot be empty")
 	ErrMissingGrants           = errors.New("VideoGrant is missing")
+	ErrParticipantNotFound     = errors.New("participant is not in the room")
+	ErrInvalidRelay            = errors.New("tracks cannot be relayed into their own room")
+	ErrUnencryptedTracks       = errors.New("room requires end-to-end encryption of published tracks")
+	ErrTrackSourceKind         = errors.New("track kind does not match its source")
+	ErrResolutionExceeded      = errors.New("track resolution exceeds the publish limit of the participant")
+	ErrResolutionUnknown       = errors.New("video tracks must declare their resolution under a publish limit")
 
 	// Track subscription related
 	ErrNoTrackPermission         = errors.New("participant is not allowed to subscribe to this track")
@@ -36,3 +50,36 @@ var (
 	ErrTrackNotBound             = errors.New("track not bound")
 	ErrSubscriptionLimitExceeded = errors.New("participant has exceeded its subscription limit")
 )
+
+// SubscribeErrorReason maps a track subscription error to a bounded reason code, so that it can be used as a metric label.
+// Errors may embed IDs, the full error is only kept in logs and analytics events.
+func SubscribeErrorReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNoTrackPermission):
+		return "no_track_permission"
+	case errors.Is(err, ErrNoSubscribePermission):
+		return "no_subscribe_permission"
+	case errors.Is(err, ErrTrackNotFound):
+		return "track_not_found"
+	case errors.Is(err, ErrTrackNotAttached):
+		return "track_not_attached"
+	case errors.Is(err, ErrTrackNotBound):
+		return "track_not_bound"
+	case errors.Is(err, ErrSubscriptionLimitExceeded):
+		return "subscription_limit_exceeded"
+	case errors.Is(err, ErrNoReceiver):
+		return "no_receiver"
+	case errors.Is(err, ErrNotOpen):
+		return "track_not_open"
+	case errors.Is(err, webrtc.ErrUnsupportedCodec):
+		return "unsupported_codec"
+	case errors.Is(err, sfu.ErrDownTrackAlreadyBound):
+		return "already_bound"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
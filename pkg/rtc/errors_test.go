@@ -0,0 +1,31 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeErrorReason(t *testing.T) {
+	require.Equal(t, "", SubscribeErrorReason(nil))
+	require.Equal(t, "track_not_found", SubscribeErrorReason(ErrTrackNotFound))
+	require.Equal(t, "no_track_permission", SubscribeErrorReason(fmt.Errorf("track TR_1234: %w", ErrNoTrackPermission)))
+	// errors embedding IDs must not leak into the reason
+	require.Equal(t, "other", SubscribeErrorReason(errors.New("could not subscribe to TR_1234")))
+}
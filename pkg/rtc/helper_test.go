@@ -18,13 +18,14 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 func init() {
-	prometheus.Init("test", livekit.NodeType_SERVER, "test")
+	prometheus.Init("test", livekit.NodeType_SERVER, "test", config.TelemetryConfig{})
 }
 
 func newMockParticipant(identity livekit.ParticipantIdentity, protocol types.ProtocolVersion, hidden bool, publisher bool) *typesfakes.FakeLocalParticipant {
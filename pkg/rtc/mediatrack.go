@@ -34,6 +34,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 // MediaTrack represents a WebRTC track that needs to be forwarded
@@ -272,6 +273,7 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			// LK-TODO: this needs to be receiver/mime aware
 			key := telemetry.StatsKeyForTrack(livekit.StreamType_UPSTREAM, t.PublisherID(), t.ID(), t.params.TrackInfo.Source, t.params.TrackInfo.Type)
 			t.params.Telemetry.TrackStats(key, stat)
+			t.recordPublishedRate(stat)
 		})
 		if t.PrimaryReceiver() == nil {
 			// primary codec published, set potential codecs
@@ -398,3 +400,23 @@ func (t *MediaTrack) SetMuted(muted bool) {
 
 	t.MediaTrackReceiver.SetMuted(muted)
 }
+
+// recordPublishedRate observes the bitrate and framerate of a receiver stats update,
+// which covers one connection stats update interval
+func (t *MediaTrack) recordPublishedRate(stat *livekit.AnalyticsStat) {
+	seconds := connectionquality.UpdateInterval.Seconds()
+
+	var bytes uint64
+	var maxFrames uint32
+	for _, stream := range stat.Streams {
+		bytes += stream.PrimaryBytes
+		if stream.Frames > maxFrames {
+			maxFrames = stream.Frames
+		}
+	}
+
+	prometheus.RecordPublishedTrackBitrate(t.params.TrackInfo.Type, t.params.TrackInfo.Source, float64(bytes*8)/seconds)
+	if t.params.TrackInfo.Type == livekit.TrackType_VIDEO {
+		prometheus.RecordPublishedTrackFramerate(t.params.TrackInfo.Source, float64(maxFrames)/seconds)
+	}
+}
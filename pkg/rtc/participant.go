@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
@@ -123,6 +124,12 @@ type ParticipantParams struct {
 	SubscriptionLimitVideo       int32
 	PlayoutDelay                 *livekit.PlayoutDelay
 	SyncStreams                  bool
+	SRTPKeyLifetime              time.Duration
+	MaxSessionDuration           time.Duration
+	SessionWarning               time.Duration
+	MaxPublishResolution         *routing.VideoResolution
+	// the client presents refreshed tokens over the signal connection
+	TokenRefresh bool
 }
 
 type ParticipantImpl struct {
@@ -134,12 +141,19 @@ type ParticipantImpl struct {
 	resSink     routing.MessageSink
 	grants      *auth.ClaimGrants
 	isPublisher atomic.Bool
+	// tracks that are not end-to-end encrypted are rejected, set by the room
+	e2eeRequired atomic.Bool
 
 	// when first connected
 	connectedAt time.Time
 	// timer that's set when disconnect is detected on primary PC
 	disconnectTimer *time.Timer
 	migrationTimer  *time.Timer
+	// timer of the full reconnect rotating the SRTP keys of the session
+	keyRotationTimer *time.Timer
+	// timers warning of and ending sessions limited by the token of the participant
+	sessionWarningTimer *time.Timer
+	sessionLimitTimer   *time.Timer
 
 	rtcpCh chan []rtcp.Packet
 
@@ -201,6 +215,12 @@ type ParticipantImpl struct {
 	supervisor *supervisor.ParticipantSupervisor
 
 	tracksQuality map[livekit.TrackID]livekit.ConnectionQuality
+	// last quality counted in the participant quality metric
+	reportedQuality   livekit.ConnectionQuality
+	isQualityReported bool
+
+	// when the pending subscriber offer was sent, to measure the offer/answer round trip
+	subscriberOfferSentAt time.Time
 
 	// loggers for publisher and subscriber
 	pubLogger logger.Logger
@@ -256,6 +276,8 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 
 	p.setupUpTrackManager()
 	p.setupSubscriptionManager()
+	p.setupKeyRotationTimer()
+	p.setupSessionLimitTimers()
 
 	return p, nil
 }
@@ -342,6 +364,10 @@ func (p *ParticipantImpl) GetICEConnectionType() types.ICEConnectionType {
 	return p.TransportManager.GetICEConnectionType()
 }
 
+func (p *ParticipantImpl) GetSelectedICECandidatePair() *webrtc.ICECandidatePair {
+	return p.TransportManager.GetSelectedICECandidatePair()
+}
+
 func (p *ParticipantImpl) GetBufferFactory() *buffer.Factory {
 	return p.params.Config.BufferFactory
 }
@@ -400,6 +426,27 @@ func (p *ParticipantImpl) ClaimGrants() *auth.ClaimGrants {
 	return p.grants.Clone()
 }
 
+// MoveToRoom grants the participant the room it has been moved to, tokens refreshed from then on reconnect it there
+func (p *ParticipantImpl) SetE2EERequired(required bool) {
+	p.e2eeRequired.Store(required)
+}
+
+func (p *ParticipantImpl) MoveToRoom(roomName livekit.RoomName) {
+	p.lock.Lock()
+	if p.grants.Video == nil || p.grants.Video.Room == string(roomName) {
+		p.lock.Unlock()
+		return
+	}
+
+	p.grants.Video.Room = string(roomName)
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+}
+
 func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermission) bool {
 	if permission == nil {
 		return false
@@ -433,6 +480,7 @@ func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermissio
 			p.removePublishedTrack(track)
 		}
 	}
+	p.removeDisallowedPendingTracks(video)
 
 	if canSubscribe {
 		// reconcile everything
@@ -594,6 +642,13 @@ func (p *ParticipantImpl) HandleAnswer(answer webrtc.SessionDescription) {
 	signalConnCost := time.Since(p.ConnectedAt()).Milliseconds()
 	p.TransportManager.UpdateSignalingRTT(uint32(signalConnCost))
 
+	p.lock.Lock()
+	if !p.subscriberOfferSentAt.IsZero() {
+		prometheus.RecordOfferAnswerTime(time.Since(p.subscriberOfferSentAt))
+		p.subscriberOfferSentAt = time.Time{}
+	}
+	p.lock.Unlock()
+
 	p.TransportManager.HandleAnswer(answer)
 }
 
@@ -676,6 +731,14 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		p.pubLogger.Warnw("no permission to publish track", nil)
 		return
 	}
+	if err := p.checkPublishLimits(req); err != nil {
+		p.pubLogger.Warnw("track exceeds publish limits", err, "name", req.Name, "source", req.Source)
+		return
+	}
+	if req.Encryption == livekit.Encryption_NONE && p.e2eeRequired.Load() {
+		p.pubLogger.Warnw("track is not end-to-end encrypted, room requires encryption", nil, "name", req.Name)
+		return
+	}
 
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -728,6 +791,8 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 	)
 	p.clearDisconnectTimer()
 	p.clearMigrationTimer()
+	p.clearKeyRotationTimer()
+	p.clearSessionLimitTimers()
 
 	// send leave message
 	if sendLeave {
@@ -754,6 +819,13 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 
 	p.UpTrackManager.Close(isExpectedToResume)
 
+	p.lock.Lock()
+	if p.isQualityReported {
+		prometheus.SubParticipantQuality(p.reportedQuality)
+		p.isQualityReported = false
+	}
+	p.lock.Unlock()
+
 	p.updateState(livekit.ParticipantInfo_DISCONNECTED)
 
 	// ensure this is synchronized
@@ -976,6 +1048,14 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 			delete(p.tracksQuality, trackID)
 		}
 	}
+	if !p.isClosed.Load() && (!p.isQualityReported || p.reportedQuality != minQuality) {
+		if p.isQualityReported {
+			prometheus.SubParticipantQuality(p.reportedQuality)
+		}
+		prometheus.AddParticipantQuality(minQuality)
+		p.reportedQuality = minQuality
+		p.isQualityReported = true
+	}
 	p.lock.Unlock()
 
 	return &livekit.ConnectionQualityInfo{
@@ -1053,9 +1133,23 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 			subTrack.DownTrack().SetConnected()
 		}
 		p.TransportManager.AddSubscribedTrack(subTrack)
+		if subTrack.PinnedQuality() != nil {
+			p.TransportManager.SetSubscribedTrackPinned(subTrack, true)
+		}
 	})
 }
 
+func (p *ParticipantImpl) PinSubscribedQuality(trackID livekit.TrackID, quality *livekit.VideoQuality) {
+	subTrack := p.SubscriptionManager.PinSubscribedTrackQuality(trackID, quality)
+	if subTrack == nil || !subTrack.IsBound() {
+		// applied once bound
+		return
+	}
+
+	p.subLogger.Infow("pinning subscribed quality", "trackID", trackID, "pinned", quality != nil)
+	p.TransportManager.SetSubscribedTrackPinned(subTrack, quality != nil)
+}
+
 // onTrackUnsubscribed handles post-processing after a track is unsubscribed
 func (p *ParticipantImpl) onTrackUnsubscribed(subTrack types.SubscribedTrack) {
 	p.TransportManager.RemoveSubscribedTrack(subTrack)
@@ -1253,6 +1347,10 @@ func (p *ParticipantImpl) setIsPublisher(isPublisher bool) {
 // when the server has an offer for participant
 func (p *ParticipantImpl) onSubscriberOffer(offer webrtc.SessionDescription) error {
 	p.subLogger.Debugw("sending offer", "transport", livekit.SignalTarget_SUBSCRIBER)
+	p.lock.Lock()
+	p.subscriberOfferSentAt = time.Now()
+	p.lock.Unlock()
+
 	return p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Offer{
 			Offer: ToProtoSessionDescription(offer),
@@ -1270,6 +1368,39 @@ func (p *ParticipantImpl) removePublishedTrack(track types.MediaTrack) {
 	}
 }
 
+// removeDisallowedPendingTracks drops the tracks that have been requested but not published yet, whose source
+// is no longer allowed. the client is told that they are unpublished, as it is for published tracks
+func (p *ParticipantImpl) removeDisallowedPendingTracks(video *auth.VideoGrant) {
+	var removed []livekit.TrackID
+	p.pendingTracksLock.Lock()
+	for cid, pti := range p.pendingTracks {
+		trackInfos := pti.trackInfos[:0]
+		for _, ti := range pti.trackInfos {
+			if video.GetCanPublishSource(ti.Source) {
+				trackInfos = append(trackInfos, ti)
+			} else {
+				removed = append(removed, livekit.TrackID(ti.Sid))
+			}
+		}
+		if len(trackInfos) == 0 {
+			delete(p.pendingTracks, cid)
+		} else {
+			pti.trackInfos = trackInfos
+		}
+	}
+	p.pendingTracksLock.Unlock()
+
+	for _, trackID := range removed {
+		p.pubLogger.Infow("removing pending track, no permission to publish", "trackID", trackID)
+		p.supervisor.RemovePublication(trackID)
+		if p.ProtocolVersion().SupportsUnpublish() {
+			p.sendTrackUnpublished(trackID)
+		} else {
+			p.sendTrackMuted(trackID, true)
+		}
+	}
+}
+
 // when a new remoteTrack is created, creates a Track and adds it to room
 func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *webrtc.RTPReceiver) {
 	if p.IsDisconnected() {
@@ -1295,6 +1426,15 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 		p.removePublishedTrack(publishedTrack)
 		return
 	}
+	// media of another kind than the track was published with would get around limits of its source
+	if kind := ToProtoTrackKind(track.Kind()); kind != publishedTrack.Kind() {
+		p.pubLogger.Warnw("mediaTrack kind does not match published track", nil,
+			"kind", kind,
+			"publishedKind", publishedTrack.Kind(),
+		)
+		p.removePublishedTrack(publishedTrack)
+		return
+	}
 
 	p.setIsPublisher(true)
 	p.dirty.Store(true)
@@ -2161,6 +2301,8 @@ func (p *ParticipantImpl) GetCachedDownTrack(trackID livekit.TrackID) (*webrtc.R
 }
 
 func (p *ParticipantImpl) IssueFullReconnect(reason types.ParticipantCloseReason) {
+	prometheus.RecordReconnect(prometheus.ReconnectTypeFull, "requested", reason.String())
+
 	_ = p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Leave{
 			Leave: &livekit.LeaveRequest{
@@ -2180,6 +2322,8 @@ func (p *ParticipantImpl) IssueFullReconnect(reason types.ParticipantCloseReason
 		scr = types.SignallingCloseReasonFullReconnectDataChannelError
 	case types.ParticipantCloseReasonNegotiateFailed:
 		scr = types.SignallingCloseReasonFullReconnectNegotiateFailed
+	case types.ParticipantCloseReasonSRTPKeyRotation:
+		scr = types.SignallingCloseReasonFullReconnectSRTPKeyRotation
 	}
 	p.CloseSignalConnection(scr)
 
@@ -2187,6 +2331,37 @@ func (p *ParticipantImpl) IssueFullReconnect(reason types.ParticipantCloseReason
 	p.Close(false, reason, false)
 }
 
+// SRTP keys are derived from the DTLS handshakes of the peer connections, which ICE restarts keep. keys are rotated
+// by a full reconnect, the client connects back with new peer connections. the reconnect is at a random point of the
+// last tenth of the lifetime, so that participants who joined together do not reconnect together
+func (p *ParticipantImpl) setupKeyRotationTimer() {
+	lifetime := p.params.SRTPKeyLifetime
+	if lifetime <= 0 {
+		return
+	}
+	rotateAfter := lifetime - time.Duration(rand.Int63n(int64(lifetime/10)+1))
+
+	p.lock.Lock()
+	p.keyRotationTimer = time.AfterFunc(rotateAfter, func() {
+		p.clearKeyRotationTimer()
+		if p.IsClosed() || p.IsDisconnected() {
+			return
+		}
+		p.params.Logger.Infow("issuing full reconnect to rotate SRTP keys", "lifetime", lifetime)
+		p.IssueFullReconnect(types.ParticipantCloseReasonSRTPKeyRotation)
+	})
+	p.lock.Unlock()
+}
+
+func (p *ParticipantImpl) clearKeyRotationTimer() {
+	p.lock.Lock()
+	if p.keyRotationTimer != nil {
+		p.keyRotationTimer.Stop()
+		p.keyRotationTimer = nil
+	}
+	p.lock.Unlock()
+}
+
 func (p *ParticipantImpl) onPublicationError(trackID livekit.TrackID) {
 	if p.params.ReconnectOnPublicationError {
 		p.pubLogger.Infow("issuing full reconnect on publication error", "trackID", trackID)
@@ -2255,6 +2430,10 @@ func (p *ParticipantImpl) SupportsSyncStreamID() bool {
 	return p.ProtocolVersion().SupportSyncStreamID() && !p.params.ClientInfo.isFirefox() && p.params.SyncStreams
 }
 
+func (p *ParticipantImpl) SupportsTokenRefresh() bool {
+	return p.params.TokenRefresh
+}
+
 func (p *ParticipantImpl) SupportsTransceiverReuse() bool {
 	return p.ProtocolVersion().SupportsTransceiverReuse() && !p.SupportsSyncStreamID()
 }
@@ -216,6 +216,108 @@ func TestTrackPublishing(t *testing.T) {
 		})
 		require.Equal(t, 1, sink.WriteMessageCallCount())
 	})
+
+	t.Run("should not allow adding tracks of another kind than their source", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.SetPermission(&livekit.ParticipantPermission{
+			CanPublish: true,
+			CanPublishSources: []livekit.TrackSource{
+				livekit.TrackSource_MICROPHONE,
+			},
+		})
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:    "cid",
+			Name:   "video as microphone",
+			Type:   livekit.TrackType_VIDEO,
+			Source: livekit.TrackSource_MICROPHONE,
+		})
+		require.Equal(t, 0, sink.WriteMessageCallCount())
+
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:    "cid2",
+			Name:   "microphone",
+			Type:   livekit.TrackType_AUDIO,
+			Source: livekit.TrackSource_MICROPHONE,
+		})
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+	})
+
+	t.Run("should not allow adding video beyond the resolution limit", func(t *testing.T) {
+		p := newParticipantForTestWithOpts("test", &participantOpts{
+			maxResolution: &routing.VideoResolution{Width: 1280, Height: 720},
+		})
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		addVideo := func(cid string, width, height uint32, layers ...*livekit.VideoLayer) {
+			p.AddTrack(&livekit.AddTrackRequest{
+				Cid:    cid,
+				Name:   cid,
+				Type:   livekit.TrackType_VIDEO,
+				Source: livekit.TrackSource_CAMERA,
+				Width:  width,
+				Height: height,
+				Layers: layers,
+			})
+		}
+		addVideo("fullhd", 1920, 1080)
+		addVideo("undeclared", 0, 0)
+		addVideo("simulcast", 1280, 720, &livekit.VideoLayer{Width: 1920, Height: 1080})
+		require.Equal(t, 0, sink.WriteMessageCallCount())
+
+		addVideo("hd", 1280, 720, &livekit.VideoLayer{Width: 640, Height: 360})
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		// portrait video is within the limit turned
+		addVideo("portrait", 720, 1280)
+		require.Equal(t, 2, sink.WriteMessageCallCount())
+		// audio is not limited
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "audio", Type: livekit.TrackType_AUDIO, Source: livekit.TrackSource_MICROPHONE})
+		require.Equal(t, 3, sink.WriteMessageCallCount())
+
+		// layers updated after the track is added are held to the limit too
+		err := p.UpdateVideoLayers(&livekit.UpdateVideoLayers{
+			TrackSid: "hd",
+			Layers:   []*livekit.VideoLayer{{Width: 640, Height: 360}, {Width: 1920, Height: 1080}},
+		})
+		require.ErrorIs(t, err, ErrResolutionExceeded)
+		err = p.UpdateVideoLayers(&livekit.UpdateVideoLayers{
+			TrackSid: "hd",
+			Layers:   []*livekit.VideoLayer{{Width: 640, Height: 360}},
+		})
+		require.NotErrorIs(t, err, ErrResolutionExceeded)
+	})
+
+	t.Run("revoking a source removes its pending tracks", func(t *testing.T) {
+		p := newParticipantForTestWithOpts("test", &participantOpts{protocolVersion: types.CurrentProtocol})
+		p.SetPermission(&livekit.ParticipantPermission{CanPublish: true})
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:    "cid",
+			Name:   "webcam",
+			Source: livekit.TrackSource_CAMERA,
+			Type:   livekit.TrackType_VIDEO,
+		})
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:    "cid2",
+			Name:   "microphone",
+			Source: livekit.TrackSource_MICROPHONE,
+			Type:   livekit.TrackType_AUDIO,
+		})
+		require.Equal(t, 2, sink.WriteMessageCallCount())
+		micTrack := p.pendingTracks["cid2"].trackInfos[0]
+
+		p.SetPermission(&livekit.ParticipantPermission{
+			CanPublish: true,
+			CanPublishSources: []livekit.TrackSource{
+				livekit.TrackSource_CAMERA,
+			},
+		})
+		require.NotNil(t, p.pendingTracks["cid"])
+		require.Nil(t, p.pendingTracks["cid2"])
+
+		res := sink.WriteMessageArgsForCall(sink.WriteMessageCallCount() - 1).(*livekit.SignalResponse)
+		require.IsType(t, &livekit.SignalResponse_TrackUnpublished{}, res.Message)
+		require.Equal(t, micTrack.Sid, res.Message.(*livekit.SignalResponse_TrackUnpublished).TrackUnpublished.TrackSid)
+	})
 }
 
 func TestOutOfOrderUpdates(t *testing.T) {
@@ -259,6 +361,43 @@ func TestDisconnectTiming(t *testing.T) {
 	})
 }
 
+func TestSRTPKeyRotation(t *testing.T) {
+	p := newParticipantForTestWithOpts("test", &participantOpts{srtpKeyLifetime: 100 * time.Millisecond})
+	sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+
+	// the participant is asked to connect back with new peer connections
+	require.Eventually(t, func() bool {
+		return p.IsClosed()
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+	res := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
+	require.True(t, res.GetLeave().GetCanReconnect())
+
+	// sessions keep their keys without a lifetime
+	p = newParticipantForTest("test")
+	require.Nil(t, p.keyRotationTimer)
+}
+
+func TestSessionLimit(t *testing.T) {
+	p := newParticipantForTestWithOpts("test", &participantOpts{sessionLimit: 100 * time.Millisecond})
+	sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+
+	// the participant is disconnected, without reconnecting
+	require.Eventually(t, func() bool {
+		return p.IsClosed()
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+	res := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
+	require.False(t, res.GetLeave().GetCanReconnect())
+	require.Equal(t, livekit.DisconnectReason_PARTICIPANT_REMOVED, res.GetLeave().GetReason())
+	require.Nil(t, p.sessionLimitTimer)
+
+	// sessions are not limited without a limit
+	p = newParticipantForTest("test")
+	require.Nil(t, p.sessionLimitTimer)
+	require.Nil(t, p.sessionWarningTimer)
+}
+
 func TestCorrectJoinedAt(t *testing.T) {
 	p := newParticipantForTest("test")
 	info := p.ToProto()
@@ -650,6 +789,9 @@ type participantOpts struct {
 	publisher       bool
 	clientConf      *livekit.ClientConfiguration
 	clientInfo      *livekit.ClientInfo
+	srtpKeyLifetime time.Duration
+	sessionLimit    time.Duration
+	maxResolution   *routing.VideoResolution
 }
 
 func newParticipantForTestWithOpts(identity livekit.ParticipantIdentity, opts *participantOpts) *ParticipantImpl {
@@ -684,19 +826,22 @@ func newParticipantForTestWithOpts(identity livekit.ParticipantIdentity, opts *p
 	}
 	sid := livekit.ParticipantID(utils.NewGuid(utils.ParticipantPrefix))
 	p, _ := NewParticipant(ParticipantParams{
-		SID:               sid,
-		Identity:          identity,
-		Config:            rtcConf,
-		Sink:              &routingfakes.FakeMessageSink{},
-		ProtocolVersion:   opts.protocolVersion,
-		PLIThrottleConfig: conf.RTC.PLIThrottle,
-		Grants:            grants,
-		EnabledCodecs:     enabledCodecs,
-		ClientConf:        opts.clientConf,
-		ClientInfo:        ClientInfo{ClientInfo: opts.clientInfo},
-		Logger:            LoggerWithParticipant(logger.GetLogger(), identity, sid, false),
-		Telemetry:         &telemetryfakes.FakeTelemetryService{},
-		VersionGenerator:  utils.NewDefaultTimedVersionGenerator(),
+		SID:                  sid,
+		Identity:             identity,
+		Config:               rtcConf,
+		Sink:                 &routingfakes.FakeMessageSink{},
+		ProtocolVersion:      opts.protocolVersion,
+		PLIThrottleConfig:    conf.RTC.PLIThrottle,
+		Grants:               grants,
+		EnabledCodecs:        enabledCodecs,
+		ClientConf:           opts.clientConf,
+		ClientInfo:           ClientInfo{ClientInfo: opts.clientInfo},
+		Logger:               LoggerWithParticipant(logger.GetLogger(), identity, sid, false),
+		Telemetry:            &telemetryfakes.FakeTelemetryService{},
+		VersionGenerator:     utils.NewDefaultTimedVersionGenerator(),
+		SRTPKeyLifetime:      opts.srtpKeyLifetime,
+		MaxSessionDuration:   opts.sessionLimit,
+		MaxPublishResolution: opts.maxResolution,
 	})
 	p.isPublisher.Store(opts.publisher)
 	p.updateState(livekit.ParticipantInfo_ACTIVE)
@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"github.com/livekit/protocol/livekit"
+)
+
+// kinds of the tracks of sources, sources allowed by the grants of a participant only publish media of their kind
+var sourceTrackTypes = map[livekit.TrackSource]livekit.TrackType{
+	livekit.TrackSource_CAMERA:             livekit.TrackType_VIDEO,
+	livekit.TrackSource_MICROPHONE:         livekit.TrackType_AUDIO,
+	livekit.TrackSource_SCREEN_SHARE:       livekit.TrackType_VIDEO,
+	livekit.TrackSource_SCREEN_SHARE_AUDIO: livekit.TrackType_AUDIO,
+}
+
+// checkPublishLimits rejects tracks of another kind than their source, and video tracks beyond the resolution the
+// token of the participant limits it to. the resolution is of the track and each of its layers as declared by the
+// request, video tracks must declare it under a limit. layers received later are checked by UpdateVideoLayers
+func (p *ParticipantImpl) checkPublishLimits(req *livekit.AddTrackRequest) error {
+	if kind, ok := sourceTrackTypes[req.Source]; ok && kind != req.Type {
+		return ErrTrackSourceKind
+	}
+
+	limit := p.params.MaxPublishResolution
+	if limit == nil || req.Type != livekit.TrackType_VIDEO {
+		return nil
+	}
+	if req.Width == 0 || req.Height == 0 {
+		return ErrResolutionUnknown
+	}
+	if !limit.Allows(req.Width, req.Height) {
+		return ErrResolutionExceeded
+	}
+	return p.checkLayerLimits(req.Layers)
+}
+
+// checkLayerLimits rejects video layers beyond the resolution the token of the participant limits it to
+func (p *ParticipantImpl) checkLayerLimits(layers []*livekit.VideoLayer) error {
+	limit := p.params.MaxPublishResolution
+	if limit == nil {
+		return nil
+	}
+	for _, layer := range layers {
+		if !limit.Allows(layer.Width, layer.Height) {
+			return ErrResolutionExceeded
+		}
+	}
+	return nil
+}
+
+// UpdateVideoLayers updates the layers of a published track, as long as they are within the publish limits. layers
+// are updated by the publisher after the track is added, they are held to the limits the track is added under
+func (p *ParticipantImpl) UpdateVideoLayers(updateVideoLayers *livekit.UpdateVideoLayers) error {
+	if err := p.checkLayerLimits(updateVideoLayers.Layers); err != nil {
+		p.pubLogger.Warnw("video layers exceed publish limits", err, "trackID", updateVideoLayers.TrackSid)
+		return err
+	}
+	return p.UpTrackManager.UpdateVideoLayers(updateVideoLayers)
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SessionLimitTopic warns participants whose token limits the duration of their session that it is ending. only the
+// server sends data packets of this topic
+const SessionLimitTopic = "livekit.session_limit"
+
+// SessionLimitWarning is the payload of data packets on SessionLimitTopic
+type SessionLimitWarning struct {
+	// unix time the participant is disconnected at
+	EndsAt           int64 `json:"ends_at"`
+	RemainingSeconds int64 `json:"remaining_seconds"`
+}
+
+// sessions limited by the token of the participant end when they have lasted the limit, regardless of activity, with
+// a warning before. the limit is of the session on this participant, which resumes and moves between rooms keep
+func (p *ParticipantImpl) setupSessionLimitTimers() {
+	limit := p.params.MaxSessionDuration
+	if limit <= 0 {
+		return
+	}
+	endsAt := time.Now().Add(limit)
+
+	p.lock.Lock()
+	if warnAfter := limit - p.params.SessionWarning; warnAfter > 0 && p.params.SessionWarning > 0 {
+		p.sessionWarningTimer = time.AfterFunc(warnAfter, func() {
+			p.sendSessionLimitWarning(endsAt)
+		})
+	}
+	p.sessionLimitTimer = time.AfterFunc(limit, func() {
+		p.clearSessionLimitTimers()
+		if p.IsClosed() {
+			return
+		}
+		p.params.Logger.Infow("closing participant at session limit", "limit", limit)
+		_ = p.Close(true, types.ParticipantCloseReasonSessionLimit, false)
+	})
+	p.lock.Unlock()
+}
+
+func (p *ParticipantImpl) clearSessionLimitTimers() {
+	p.lock.Lock()
+	if p.sessionWarningTimer != nil {
+		p.sessionWarningTimer.Stop()
+		p.sessionWarningTimer = nil
+	}
+	if p.sessionLimitTimer != nil {
+		p.sessionLimitTimer.Stop()
+		p.sessionLimitTimer = nil
+	}
+	p.lock.Unlock()
+}
+
+func (p *ParticipantImpl) sendSessionLimitWarning(endsAt time.Time) {
+	payload, err := json.Marshal(&SessionLimitWarning{
+		EndsAt:           endsAt.Unix(),
+		RemainingSeconds: int64(time.Until(endsAt).Round(time.Second).Seconds()),
+	})
+	if err != nil {
+		p.params.Logger.Errorw("could not encode session limit warning", err)
+		return
+	}
+	topic := SessionLimitTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload:               payload,
+				Topic:                 &topic,
+				DestinationIdentities: []string{string(p.params.Identity)},
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		p.params.Logger.Errorw("could not marshal session limit warning", err)
+		return
+	}
+	if err = p.SendDataPacket(dp, data); err != nil {
+		p.params.Logger.Warnw("could not send session limit warning", err)
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 func (p *ParticipantImpl) getResponseSink() routing.MessageSink {
@@ -181,6 +182,9 @@ func (p *ParticipantImpl) SendRefreshToken(token string) error {
 }
 
 func (p *ParticipantImpl) HandleReconnectAndSendResponse(reconnectReason livekit.ReconnectReason, reconnectResponse *livekit.ReconnectResponse) error {
+	if lastSignalAt := p.TransportManager.LastSeenSignalAt(); !lastSignalAt.IsZero() {
+		prometheus.RecordResumeTime(time.Since(lastSignalAt))
+	}
 	p.TransportManager.HandleClientReconnect(reconnectReason)
 
 	if !p.params.ClientInfo.CanHandleReconnectResponse() {
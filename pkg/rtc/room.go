@@ -93,10 +93,27 @@ type Room struct {
 	holds    atomic.Int32
 	// time that the last participant left the room
 	leftAt atomic.Int64
-	closed chan struct{}
+	// time the room is scheduled to start, the empty timeout counts from then
+	startsAt atomic.Int64
+	// time the room closes after it has been deleted with a grace period, joins are rejected until then
+	closesAt atomic.Int64
+	// participants publish end-to-end encrypted tracks only
+	e2eeRequired atomic.Bool
+	// most participants in the room at once
+	peakParticipants atomic.Uint32
+	closeReason      types.RoomCloseReason
+	closed           chan struct{}
 
 	trailer []byte
 
+	// mutes tracks published from now on, set by moderation
+	muteRule *MuteRule
+
+	// relays of tracks of participants of the room into other rooms, by identity of the publisher
+	relaysOut map[livekit.ParticipantIdentity][]*trackRelay
+	// relays of tracks of other rooms into the room, by ID of the publisher
+	relaysIn map[livekit.ParticipantID]*trackRelay
+
 	onParticipantChanged func(p types.LocalParticipant)
 	onRoomUpdated        func()
 	onClose              func()
@@ -104,6 +121,35 @@ type Room struct {
 
 type ParticipantOptions struct {
 	AutoSubscribe bool
+	// attributes of the participant, set by its token and the API, and synchronized to room members
+	Attributes map[string]string
+}
+
+// MuteRule selects the tracks muted by moderation, a track of one of Sources or Kinds is muted
+// unless its publisher is one of Except
+type MuteRule struct {
+	Sources []livekit.TrackSource
+	Kinds   []livekit.TrackType
+	Except  []livekit.ParticipantIdentity
+}
+
+func (m *MuteRule) matches(identity livekit.ParticipantIdentity, track types.MediaTrack) bool {
+	for _, except := range m.Except {
+		if except == identity {
+			return false
+		}
+	}
+	for _, source := range m.Sources {
+		if source == track.Source() {
+			return true
+		}
+	}
+	for _, kind := range m.Kinds {
+		if kind == track.Kind() {
+			return true
+		}
+	}
+	return false
 }
 
 func NewRoom(
@@ -134,6 +180,8 @@ func NewRoom(
 		participantRequestSources: make(map[livekit.ParticipantIdentity]routing.MessageSource),
 		bufferFactory:             buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSize),
 		batchedUpdates:            make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		relaysOut:                 make(map[livekit.ParticipantIdentity][]*trackRelay),
+		relaysIn:                  make(map[livekit.ParticipantID]*trackRelay),
 		closed:                    make(chan struct{}),
 		trailer:                   []byte(utils.RandomSecret()),
 	}
@@ -148,6 +196,9 @@ func NewRoom(
 	go r.audioUpdateWorker()
 	go r.connectionQualityWorker()
 	go r.changeUpdateWorker()
+	if config.MediaFlood.Enabled {
+		go r.mediaFloodWorker()
+	}
 
 	return r
 }
@@ -202,6 +253,37 @@ func (r *Room) GetParticipants() []types.LocalParticipant {
 	return participants
 }
 
+// MuteTracks mutes the published tracks matching the rule, their publishers are asked to mute them.
+// with muteFuture, tracks published later on are muted as well until the rule is cleared.
+// returns the number of tracks muted
+func (r *Room) MuteTracks(rule *MuteRule, muteFuture bool) int {
+	if muteFuture {
+		r.lock.Lock()
+		r.muteRule = rule
+		r.lock.Unlock()
+	}
+
+	muted := 0
+	for _, p := range r.GetParticipants() {
+		for _, track := range p.GetPublishedTracks() {
+			if track.IsMuted() || !rule.matches(p.Identity(), track) {
+				continue
+			}
+			p.SetTrackMuted(track.ID(), true, true)
+			muted++
+		}
+	}
+	r.Logger.Infow("muted tracks", "muted", muted, "muteFuture", muteFuture)
+	return muted
+}
+
+// ClearMuteRule stops muting tracks published from now on, tracks that have been muted stay muted
+func (r *Room) ClearMuteRule() {
+	r.lock.Lock()
+	r.muteRule = nil
+	r.lock.Unlock()
+}
+
 func (r *Room) GetLocalParticipants() []types.LocalParticipant {
 	return r.GetParticipants()
 }
@@ -245,10 +327,47 @@ func (r *Room) LastLeftAt() int64 {
 	return r.leftAt.Load()
 }
 
+func (r *Room) PeakParticipants() uint32 {
+	return r.peakParticipants.Load()
+}
+
+func (r *Room) CloseReason() types.RoomCloseReason {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.closeReason
+}
+
 func (r *Room) Internal() *livekit.RoomInternal {
 	return r.internal
 }
 
+// SetStartsAt schedules the start of the room, an empty room is kept open until its empty timeout after the start
+func (r *Room) SetStartsAt(startsAt time.Time) {
+	r.startsAt.Store(startsAt.Unix())
+}
+
+// SetClosesAt schedules the room to close, joins are rejected from now on. a room that is already scheduled to close
+// only closes earlier, set is false when the schedule is unchanged
+func (r *Room) SetClosesAt(closesAt time.Time) bool {
+	for {
+		current := r.closesAt.Load()
+		if current != 0 && current <= closesAt.Unix() {
+			return false
+		}
+		if r.closesAt.CompareAndSwap(current, closesAt.Unix()) {
+			return true
+		}
+	}
+}
+
+// ClosesAt returns the time the room is scheduled to close, zero when it is not
+func (r *Room) ClosesAt() time.Time {
+	if closesAt := r.closesAt.Load(); closesAt != 0 {
+		return time.Unix(closesAt, 0)
+	}
+	return time.Time{}
+}
+
 func (r *Room) Hold() bool {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -269,24 +388,8 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	if r.IsClosed() {
-		return ErrRoomClosed
-	}
-
-	if r.participants[participant.Identity()] != nil {
-		return ErrAlreadyJoined
-	}
-
-	if r.protoRoom.MaxParticipants > 0 && !participant.IsRecorder() {
-		numParticipants := uint32(0)
-		for _, p := range r.participants {
-			if !p.IsRecorder() {
-				numParticipants++
-			}
-		}
-		if numParticipants >= r.protoRoom.MaxParticipants {
-			return ErrMaxParticipantsExceeded
-		}
+	if err := r.canJoinLocked(participant); err != nil {
+		return err
 	}
 
 	if r.FirstJoinedAt() == 0 {
@@ -294,79 +397,9 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	}
 
 	// it's important to set this before connection, we don't want to miss out on any published tracks
-	participant.OnTrackPublished(r.onTrackPublished)
-	participant.OnStateChange(func(p types.LocalParticipant, oldState livekit.ParticipantInfo_State) {
-		r.Logger.Infow("participant state changed",
-			"state", p.State(),
-			"participant", p.Identity(),
-			"pID", p.ID(),
-			"oldState", oldState)
-		if r.onParticipantChanged != nil {
-			r.onParticipantChanged(participant)
-		}
-		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
-
-		state := p.State()
-		if state == livekit.ParticipantInfo_ACTIVE {
-			// subscribe participant to existing published tracks
-			r.subscribeToExistingTracks(p)
-
-			// start the workers once connectivity is established
-			p.Start()
+	r.setParticipantCallbacks(participant)
+	participant.SetE2EERequired(r.e2eeRequired.Load())
 
-			r.telemetry.ParticipantActive(context.Background(),
-				r.ToProto(),
-				p.ToProto(),
-				&livekit.AnalyticsClientMeta{
-					ClientConnectTime: uint32(time.Since(p.ConnectedAt()).Milliseconds()),
-					ConnectionType:    string(p.GetICEConnectionType()),
-				},
-				false,
-			)
-		} else if state == livekit.ParticipantInfo_DISCONNECTED {
-			// remove participant from room
-			go r.RemoveParticipant(p.Identity(), p.ID(), types.ParticipantCloseReasonStateDisconnected)
-		}
-	})
-	participant.OnTrackUpdated(r.onTrackUpdated)
-	participant.OnTrackUnpublished(r.onTrackUnpublished)
-	participant.OnParticipantUpdate(r.onParticipantUpdate)
-	participant.OnDataPacket(r.onDataPacket)
-	participant.OnSubscribeStatusChanged(func(publisherID livekit.ParticipantID, subscribed bool) {
-		if subscribed {
-			pub := r.GetParticipantByID(publisherID)
-			if pub != nil && pub.State() == livekit.ParticipantInfo_ACTIVE {
-				// when a participant subscribes to another participant,
-				// send speaker update if the subscribed to participant is active.
-				level, active := pub.GetAudioLevel()
-				if active {
-					_ = participant.SendSpeakerUpdate([]*livekit.SpeakerInfo{
-						{
-							Sid:    string(pub.ID()),
-							Level:  float32(level),
-							Active: active,
-						},
-					}, false)
-				}
-
-				if cq := pub.GetConnectionQuality(); cq != nil {
-					update := &livekit.ConnectionQualityUpdate{}
-					update.Updates = append(update.Updates, cq)
-					_ = participant.SendConnectionQualityUpdate(update)
-				}
-			}
-		} else {
-			// no longer subscribed to the publisher, clear speaker status
-			_ = participant.SendSpeakerUpdate([]*livekit.SpeakerInfo{
-				{
-					Sid:    string(publisherID),
-					Level:  0,
-					Active: false,
-				},
-			}, true)
-		}
-
-	})
 	r.Logger.Infow("new participant joined",
 		"pID", participant.ID(),
 		"participant", participant.Identity(),
@@ -383,6 +416,9 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	r.participants[participant.Identity()] = participant
 	r.participantOpts[participant.Identity()] = opts
 	r.participantRequestSources[participant.Identity()] = requestSource
+	if numParticipants := uint32(len(r.participants)); numParticipants > r.peakParticipants.Load() {
+		r.peakParticipants.Store(numParticipants)
+	}
 
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(participant)
@@ -420,6 +456,55 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	return nil
 }
 
+// canJoinLocked checks that the participant can be added to the room, assumes lock is already acquired
+func (r *Room) canJoinLocked(participant types.LocalParticipant) error {
+	if r.IsClosed() {
+		return ErrRoomClosed
+	}
+	if r.closesAt.Load() != 0 {
+		return ErrRoomClosing
+	}
+
+	if r.participants[participant.Identity()] != nil {
+		return ErrAlreadyJoined
+	}
+
+	if r.protoRoom.MaxParticipants > 0 && !participant.IsRecorder() {
+		numParticipants := uint32(0)
+		for _, p := range r.participants {
+			if !p.IsRecorder() {
+				numParticipants++
+			}
+		}
+		if numParticipants >= r.protoRoom.MaxParticipants {
+			return ErrMaxParticipantsExceeded
+		}
+	}
+	return nil
+}
+
+// updateActiveRecordingLocked updates whether a recorder is in the room after participant joined or left,
+// returns true when it changed. assumes lock is already acquired
+func (r *Room) updateActiveRecordingLocked(participant types.LocalParticipant) bool {
+	if (participant == nil || !participant.IsRecorder()) && !r.protoRoom.ActiveRecording {
+		return false
+	}
+
+	activeRecording := false
+	for _, op := range r.participants {
+		if op.IsRecorder() {
+			activeRecording = true
+			break
+		}
+	}
+
+	if r.protoRoom.ActiveRecording == activeRecording {
+		return false
+	}
+	r.protoRoom.ActiveRecording = activeRecording
+	return true
+}
+
 func (r *Room) ReplaceParticipantRequestSource(identity livekit.ParticipantIdentity, reqSource routing.MessageSource) {
 	r.lock.Lock()
 	if rs, ok := r.participantRequestSources[identity]; ok {
@@ -479,22 +564,7 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		}
 	}
 
-	immediateChange := false
-	if (p != nil && p.IsRecorder()) || r.protoRoom.ActiveRecording {
-		activeRecording := false
-		for _, op := range r.participants {
-			if op.IsRecorder() {
-				activeRecording = true
-				break
-			}
-		}
-
-		if r.protoRoom.ActiveRecording != activeRecording {
-			r.protoRoom.ActiveRecording = activeRecording
-			immediateChange = true
-
-		}
-	}
+	immediateChange := r.updateActiveRecordingLocked(p)
 	r.lock.Unlock()
 	r.protoProxy.MarkDirty(immediateChange)
 
@@ -505,19 +575,15 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 	// send broadcast only if it's not already closed
 	sendUpdates := !p.IsDisconnected()
 
+	r.stopRelays(p.Identity())
+
 	// remove all published tracks
 	for _, t := range p.GetPublishedTracks() {
 		r.trackManager.RemoveTrack(t)
 	}
 	r.hasPublished.Delete(p.Identity())
 
-	p.OnTrackUpdated(nil)
-	p.OnTrackPublished(nil)
-	p.OnTrackUnpublished(nil)
-	p.OnStateChange(nil)
-	p.OnParticipantUpdate(nil)
-	p.OnDataPacket(nil)
-	p.OnSubscribeStatusChanged(nil)
+	r.clearParticipantCallbacks(p)
 
 	// close participant as well
 	r.Logger.Debugw("closing participant for removal", "pID", p.ID(), "participant", p.Identity())
@@ -533,6 +599,215 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 	}
 }
 
+// setParticipantCallbacks has the room handle the tracks, state changes and updates of a participant
+func (r *Room) setParticipantCallbacks(participant types.LocalParticipant) {
+	participant.OnTrackPublished(r.onTrackPublished)
+	participant.OnStateChange(func(p types.LocalParticipant, oldState livekit.ParticipantInfo_State) {
+		r.Logger.Infow("participant state changed",
+			"state", p.State(),
+			"participant", p.Identity(),
+			"pID", p.ID(),
+			"oldState", oldState)
+		if r.onParticipantChanged != nil {
+			r.onParticipantChanged(participant)
+		}
+		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
+
+		state := p.State()
+		if state == livekit.ParticipantInfo_ACTIVE {
+			// subscribe participant to existing published tracks
+			r.subscribeToExistingTracks(p)
+
+			// start the workers once connectivity is established
+			p.Start()
+
+			r.syncParticipantAttributes(p)
+			r.sendE2EEState(p)
+
+			prometheus.RecordJoinConnectedTime(r.ID(), p.ID(), time.Since(p.ConnectedAt()))
+
+			r.telemetry.ParticipantActive(context.Background(),
+				r.ToProto(),
+				p.ToProto(),
+				&livekit.AnalyticsClientMeta{
+					ClientConnectTime: uint32(time.Since(p.ConnectedAt()).Milliseconds()),
+					ConnectionType:    string(p.GetICEConnectionType()),
+				},
+				false,
+			)
+		} else if state == livekit.ParticipantInfo_DISCONNECTED {
+			// remove participant from room
+			go r.RemoveParticipant(p.Identity(), p.ID(), types.ParticipantCloseReasonStateDisconnected)
+		}
+	})
+	participant.OnTrackUpdated(r.onTrackUpdated)
+	participant.OnTrackUnpublished(r.onTrackUnpublished)
+	participant.OnParticipantUpdate(r.onParticipantUpdate)
+	participant.OnDataPacket(r.onDataPacket)
+	participant.OnSubscribeStatusChanged(func(publisherID livekit.ParticipantID, subscribed bool) {
+		if subscribed {
+			pub := r.GetParticipantByID(publisherID)
+			if pub != nil && pub.State() == livekit.ParticipantInfo_ACTIVE {
+				// when a participant subscribes to another participant,
+				// send speaker update if the subscribed to participant is active.
+				level, active := pub.GetAudioLevel()
+				if active {
+					_ = participant.SendSpeakerUpdate([]*livekit.SpeakerInfo{
+						{
+							Sid:    string(pub.ID()),
+							Level:  float32(level),
+							Active: active,
+						},
+					}, false)
+				}
+
+				if cq := pub.GetConnectionQuality(); cq != nil {
+					update := &livekit.ConnectionQualityUpdate{}
+					update.Updates = append(update.Updates, cq)
+					_ = participant.SendConnectionQualityUpdate(update)
+				}
+			}
+		} else {
+			// no longer subscribed to the publisher, clear speaker status
+			_ = participant.SendSpeakerUpdate([]*livekit.SpeakerInfo{
+				{
+					Sid:    string(publisherID),
+					Level:  0,
+					Active: false,
+				},
+			}, true)
+		}
+	})
+}
+
+// clearParticipantCallbacks stops the room from handling a participant
+func (r *Room) clearParticipantCallbacks(p types.LocalParticipant) {
+	p.OnTrackUpdated(nil)
+	p.OnTrackPublished(nil)
+	p.OnTrackUnpublished(nil)
+	p.OnStateChange(nil)
+	p.OnParticipantUpdate(nil)
+	p.OnDataPacket(nil)
+	p.OnSubscribeStatusChanged(nil)
+}
+
+// DetachParticipant takes a participant out of the room without closing it, so that it can be attached to another
+// room. tracks published by the participant stay published, subscriptions to and from the room's tracks are ended
+func (r *Room) DetachParticipant(identity livekit.ParticipantIdentity) (types.LocalParticipant, routing.MessageSource, *ParticipantOptions, error) {
+	r.lock.Lock()
+	p, ok := r.participants[identity]
+	if !ok {
+		r.lock.Unlock()
+		return nil, nil, nil, ErrParticipantNotFound
+	}
+	requestSource := r.participantRequestSources[identity]
+	opts := r.participantOpts[identity]
+
+	delete(r.participants, identity)
+	delete(r.participantOpts, identity)
+	delete(r.participantRequestSources, identity)
+	if !p.Hidden() {
+		r.protoRoom.NumParticipants--
+	}
+	immediateChange := r.updateActiveRecordingLocked(p)
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(immediateChange)
+
+	r.clearParticipantCallbacks(p)
+	r.stopRelays(p.Identity())
+
+	// the published tracks move with the participant, subscribers of this room are removed from them
+	for _, t := range p.GetPublishedTracks() {
+		r.trackManager.RemoveTrack(t)
+		t.RevokeDisallowedSubscribers(nil)
+	}
+	r.hasPublished.Delete(p.Identity())
+
+	for _, st := range p.GetSubscribedTracks() {
+		p.UnsubscribeFromTrack(st.ID())
+	}
+
+	r.leftAt.Store(time.Now().Unix())
+	r.Logger.Infow("participant detached", "pID", p.ID(), "participant", p.Identity())
+
+	// to the other participants the participant has left, and they have left to the participant
+	if !p.Hidden() {
+		pi := proto.Clone(p.ToProto()).(*livekit.ParticipantInfo)
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+		r.sendParticipantUpdates(r.pushAndDequeueUpdates(pi, true))
+	}
+	var updates []*livekit.ParticipantInfo
+	for _, pi := range r.getOtherParticipantInfo(p.Identity()) {
+		pi = proto.Clone(pi).(*livekit.ParticipantInfo)
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+		updates = append(updates, pi)
+	}
+	if len(updates) > 0 {
+		if err := p.SendParticipantUpdate(updates); err != nil {
+			r.Logger.Warnw("could not send update to detached participant", err, "participant", p.Identity(), "pID", p.ID())
+		}
+	}
+
+	return p, requestSource, opts, nil
+}
+
+// AttachParticipant adds a connected participant detached from another room. unlike Join, the participant keeps its
+// connection, it learns about the room and its participants through updates
+func (r *Room) AttachParticipant(participant types.LocalParticipant, requestSource routing.MessageSource, opts *ParticipantOptions) error {
+	r.lock.Lock()
+	if err := r.canJoinLocked(participant); err != nil {
+		r.lock.Unlock()
+		return err
+	}
+	if err := r.canAttachE2EELocked(participant); err != nil {
+		r.lock.Unlock()
+		return err
+	}
+
+	if r.FirstJoinedAt() == 0 {
+		r.joinedAt.Store(time.Now().Unix())
+	}
+
+	r.setParticipantCallbacks(participant)
+	participant.SetE2EERequired(r.e2eeRequired.Load())
+
+	r.participants[participant.Identity()] = participant
+	r.participantOpts[participant.Identity()] = opts
+	r.participantRequestSources[participant.Identity()] = requestSource
+	if numParticipants := uint32(len(r.participants)); numParticipants > r.peakParticipants.Load() {
+		r.peakParticipants.Store(numParticipants)
+	}
+	immediateChange := r.updateActiveRecordingLocked(participant)
+	onParticipantChanged := r.onParticipantChanged
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(immediateChange)
+
+	r.Logger.Infow("participant attached", "pID", participant.ID(), "participant", participant.Identity())
+
+	if onParticipantChanged != nil {
+		onParticipantChanged(participant)
+	}
+
+	if err := participant.SendRoomUpdate(r.ToProto()); err != nil {
+		return err
+	}
+	if err := participant.SendParticipantUpdate(r.getOtherParticipantInfo(participant.Identity())); err != nil {
+		return err
+	}
+	r.broadcastParticipantState(participant, broadcastOptions{skipSource: true, immediate: true})
+
+	// tracks published in the previous room are made available to this room
+	for _, track := range participant.GetPublishedTracks() {
+		r.onTrackPublished(participant, track)
+	}
+	if participant.State() == livekit.ParticipantInfo_ACTIVE {
+		r.subscribeToExistingTracks(participant)
+		r.syncParticipantAttributes(participant)
+		r.sendE2EEState(participant)
+	}
+	return nil
+}
+
 func (r *Room) UpdateSubscriptions(
 	participant types.LocalParticipant,
 	trackIDs []livekit.TrackID,
@@ -637,6 +912,12 @@ func (r *Room) ResolveMediaTrackForSubscriber(subIdentity livekit.ParticipantIde
 	res.PublisherID = info.PublisherID
 
 	pub := r.GetParticipantByID(info.PublisherID)
+	if pub == nil {
+		// tracks relayed from another room keep the permissions of their publisher
+		if relay := r.relayOf(info.PublisherID); relay != nil {
+			pub = relay.publisher
+		}
+	}
 	// when publisher is not found, we will assume it doesn't have permission to access
 	if pub != nil {
 		res.HasPermission = pub.HasPermission(trackID, subIdentity)
@@ -678,16 +959,23 @@ func (r *Room) CloseIfEmpty() {
 		timeout = RoomDepartureGrace
 	} else {
 		elapsed = time.Now().Unix() - r.protoRoom.CreationTime
+		if startsAt := r.startsAt.Load(); startsAt > r.protoRoom.CreationTime {
+			elapsed = time.Now().Unix() - startsAt
+		}
 		timeout = r.protoRoom.EmptyTimeout
 	}
 	r.lock.Unlock()
 
 	if elapsed >= int64(timeout) {
-		r.Close()
+		r.CloseWithReason(types.RoomCloseReasonEmpty)
 	}
 }
 
 func (r *Room) Close() {
+	r.CloseWithReason(types.RoomCloseReasonUnknown)
+}
+
+func (r *Room) CloseWithReason(reason types.RoomCloseReason) {
 	r.lock.Lock()
 	select {
 	case <-r.closed:
@@ -697,8 +985,10 @@ func (r *Room) Close() {
 		// fall through
 	}
 	close(r.closed)
+	r.closeReason = reason
 	r.lock.Unlock()
-	r.Logger.Infow("closing room")
+	r.Logger.Infow("closing room", "reason", reason)
+	r.stopAllRelays()
 	for _, p := range r.GetParticipants() {
 		_ = p.Close(true, types.ParticipantCloseReasonRoomClose, false)
 	}
@@ -733,6 +1023,28 @@ func (r *Room) SetMetadata(metadata string) {
 	r.protoProxy.MarkDirty(true)
 }
 
+// SetMaxParticipants changes the participant cap of the room, participants already in the room are kept
+func (r *Room) SetMaxParticipants(maxParticipants uint32) {
+	r.lock.Lock()
+	r.protoRoom.MaxParticipants = maxParticipants
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(true)
+}
+
+// PatchMetadata replaces the metadata with the result of patch, atomically with other metadata updates
+func (r *Room) PatchMetadata(patch func(metadata string) (string, error)) error {
+	r.lock.Lock()
+	metadata, err := patch(r.protoRoom.Metadata)
+	if err != nil {
+		r.lock.Unlock()
+		return err
+	}
+	r.protoRoom.Metadata = metadata
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(true)
+	return nil
+}
+
 func (r *Room) UpdateParticipantMetadata(participant types.LocalParticipant, name string, metadata string) {
 	if metadata != "" {
 		participant.SetMetadata(metadata)
@@ -821,7 +1133,7 @@ func (r *Room) getOtherParticipantInfo(identity livekit.ParticipantIdentity) []*
 		}
 	}
 
-	return pi
+	return append(pi, r.GetRelays()...)
 }
 
 // checks if participant should be autosubscribed to new tracks, assumes lock is already acquired
@@ -842,6 +1154,7 @@ func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceS
 			otherParticipants = append(otherParticipants, p.ToProto())
 		}
 	}
+	otherParticipants = append(otherParticipants, r.relayInfoLocked()...)
 
 	return &livekit.JoinResponse{
 		Room:              r.ToProto(),
@@ -890,13 +1203,25 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 		existingParticipant.SubscribeToTrack(track.ID())
 	}
 	onParticipantChanged := r.onParticipantChanged
+	muteRule := r.muteRule
 	r.lock.RUnlock()
 
+	if muteRule != nil && !track.IsMuted() && muteRule.matches(participant.Identity(), track) {
+		r.Logger.Infow("muting track published under mute rule",
+			"participant", participant.Identity(),
+			"pID", participant.ID(),
+			"trackID", track.ID())
+		participant.SetTrackMuted(track.ID(), true, true)
+	}
+
 	if onParticipantChanged != nil {
 		onParticipantChanged(participant)
 	}
 
 	r.trackManager.AddTrack(track, participant.Identity(), participant.ID())
+	for _, relay := range r.relaysOf(participant.Identity()) {
+		relay.addTrack(track)
+	}
 
 	// auto egress
 	if r.internal != nil {
@@ -931,9 +1256,12 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 	}
 }
 
-func (r *Room) onTrackUpdated(p types.LocalParticipant, _ types.MediaTrack) {
+func (r *Room) onTrackUpdated(p types.LocalParticipant, track types.MediaTrack) {
 	// send track updates to everyone, especially if track was updated by admin
 	r.broadcastParticipantState(p, broadcastOptions{})
+	for _, relay := range r.relaysOf(p.Identity()) {
+		relay.trackUpdated(track)
+	}
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(p)
 	}
@@ -941,6 +1269,9 @@ func (r *Room) onTrackUpdated(p types.LocalParticipant, _ types.MediaTrack) {
 
 func (r *Room) onTrackUnpublished(p types.LocalParticipant, track types.MediaTrack) {
 	r.trackManager.RemoveTrack(track)
+	for _, relay := range r.relaysOf(p.Identity()) {
+		relay.removeTrack(track)
+	}
 	if !p.IsClosed() {
 		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
 	}
@@ -959,6 +1290,14 @@ func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 }
 
 func (r *Room) onDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) {
+	switch topic := dp.GetUser().GetTopic(); {
+	case source != nil && (topic == ParticipantAttributesTopic || topic == SessionLimitTopic || topic == E2EETopic):
+		// attributes, session limit warnings and encryption state are only sent by the server
+		return
+	case topic == E2EEKeysTopic:
+		// keys lost on the way would leave subscribers unable to decrypt
+		dp.Kind = livekit.DataPacket_RELIABLE
+	}
 	BroadcastDataPacketForRoom(r, source, dp, r.Logger)
 }
 
@@ -983,6 +1322,15 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 			p.SubscribeToTrack(track.ID())
 		}
 	}
+	for _, pi := range r.GetRelays() {
+		if livekit.ParticipantID(pi.Sid) == p.ID() {
+			continue
+		}
+		for _, ti := range pi.Tracks {
+			trackIDs = append(trackIDs, livekit.TrackID(ti.Sid))
+			p.SubscribeToTrack(livekit.TrackID(ti.Sid))
+		}
+	}
 	if len(trackIDs) > 0 {
 		r.Logger.Debugw("subscribed participant to existing tracks", "trackID", trackIDs)
 	}
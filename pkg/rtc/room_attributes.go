@@ -0,0 +1,154 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// ParticipantAttributesTopic carries the attributes of participants to room members. only the server sends data
+// packets of this topic, those of participants are dropped
+const ParticipantAttributesTopic = "livekit.participant_attributes"
+
+// ParticipantAttributesUpdate is the payload of data packets on ParticipantAttributesTopic, with all of the
+// attributes of each of the participants
+type ParticipantAttributesUpdate struct {
+	Participants []*ParticipantAttributes `json:"participants"`
+}
+
+type ParticipantAttributes struct {
+	Identity   string            `json:"identity"`
+	Sid        string            `json:"sid"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// UpdateParticipantAttributes sets attributes of the participant, attributes with empty values are removed. room
+// members are sent the attributes of the participant when they change
+func (r *Room) UpdateParticipantAttributes(participant types.LocalParticipant, attributes map[string]string) {
+	r.lock.Lock()
+	opts := r.participantOpts[participant.Identity()]
+	if opts == nil || r.participants[participant.Identity()] != participant {
+		r.lock.Unlock()
+		return
+	}
+	changed := false
+	for key, value := range attributes {
+		if value == "" {
+			if _, ok := opts.Attributes[key]; ok {
+				delete(opts.Attributes, key)
+				changed = true
+			}
+			continue
+		}
+		if opts.Attributes == nil {
+			opts.Attributes = make(map[string]string)
+		}
+		if opts.Attributes[key] != value {
+			opts.Attributes[key] = value
+			changed = true
+		}
+	}
+	pa := participantAttributes(participant, opts)
+	r.lock.Unlock()
+
+	if !changed || participant.State() != livekit.ParticipantInfo_ACTIVE {
+		// participants are sent the attributes of room members once they are active
+		return
+	}
+	if participant.Hidden() {
+		r.sendParticipantAttributes([]*ParticipantAttributes{pa}, participant)
+	} else {
+		r.sendParticipantAttributes([]*ParticipantAttributes{pa}, nil)
+	}
+}
+
+// GetParticipantAttributes returns the attributes of the participant, nil when it has none
+func (r *Room) GetParticipantAttributes(identity livekit.ParticipantIdentity) map[string]string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	opts := r.participantOpts[identity]
+	if opts == nil || len(opts.Attributes) == 0 {
+		return nil
+	}
+	attributes := make(map[string]string, len(opts.Attributes))
+	for key, value := range opts.Attributes {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// syncParticipantAttributes sends the attributes of room members to a participant that has become active in the
+// room, and the attributes of the participant to room members
+func (r *Room) syncParticipantAttributes(participant types.LocalParticipant) {
+	r.lock.RLock()
+	var all []*ParticipantAttributes
+	var own *ParticipantAttributes
+	for identity, p := range r.participants {
+		opts := r.participantOpts[identity]
+		if opts == nil || len(opts.Attributes) == 0 {
+			continue
+		}
+		if p == participant {
+			own = participantAttributes(p, opts)
+			all = append(all, own)
+		} else if !p.Hidden() {
+			all = append(all, participantAttributes(p, opts))
+		}
+	}
+	r.lock.RUnlock()
+
+	if len(all) != 0 {
+		r.sendParticipantAttributes(all, participant)
+	}
+	if own != nil && !participant.Hidden() {
+		r.sendParticipantAttributes([]*ParticipantAttributes{own}, nil)
+	}
+}
+
+// sendParticipantAttributes sends the attributes to the destination, or to all room members when it is nil
+func (r *Room) sendParticipantAttributes(participants []*ParticipantAttributes, destination types.LocalParticipant) {
+	payload, err := json.Marshal(&ParticipantAttributesUpdate{Participants: participants})
+	if err != nil {
+		r.Logger.Errorw("could not encode participant attributes", err)
+		return
+	}
+	topic := ParticipantAttributesTopic
+	up := &livekit.UserPacket{
+		Payload: payload,
+		Topic:   &topic,
+	}
+	if destination != nil {
+		up.DestinationIdentities = []string{string(destination.Identity())}
+	}
+	r.SendDataPacket(up, livekit.DataPacket_RELIABLE)
+}
+
+// participantAttributes returns a copy of the attributes of the participant, assumes lock is already acquired
+func participantAttributes(participant types.LocalParticipant, opts *ParticipantOptions) *ParticipantAttributes {
+	attributes := make(map[string]string, len(opts.Attributes))
+	for key, value := range opts.Attributes {
+		attributes[key] = value
+	}
+	return &ParticipantAttributes{
+		Identity:   string(participant.Identity()),
+		Sid:        string(participant.ID()),
+		Attributes: attributes,
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// E2EETopic tells participants of rooms requiring end-to-end encryption that it is required, once they are
+	// active in the room. only the server sends data packets of this topic
+	E2EETopic = "livekit.e2ee"
+
+	// E2EEKeysTopic carries the keys of end-to-end encryption between participants. the server forwards data packets
+	// of this topic as they are, without reading their payload, and always reliably
+	E2EEKeysTopic = "livekit.e2ee_keys"
+)
+
+// E2EEState is the payload of data packets on E2EETopic
+type E2EEState struct {
+	Required bool `json:"required"`
+}
+
+// SetE2EERequired has participants of the room publish end-to-end encrypted tracks only, tracks published already
+// are kept
+func (r *Room) SetE2EERequired(required bool) {
+	if r.e2eeRequired.Swap(required) == required {
+		return
+	}
+	r.Logger.Infow("setting end-to-end encryption", "required", required)
+	for _, p := range r.GetParticipants() {
+		p.SetE2EERequired(required)
+		if required && p.State() == livekit.ParticipantInfo_ACTIVE {
+			r.sendE2EEState(p)
+		}
+	}
+}
+
+func (r *Room) E2EERequired() bool {
+	return r.e2eeRequired.Load()
+}
+
+// sendE2EEState tells a participant that has become active that the room requires end-to-end encryption
+func (r *Room) sendE2EEState(participant types.LocalParticipant) {
+	if !r.e2eeRequired.Load() {
+		return
+	}
+	payload, err := json.Marshal(&E2EEState{Required: true})
+	if err != nil {
+		return
+	}
+	topic := E2EETopic
+	r.SendDataPacket(&livekit.UserPacket{
+		Payload:               payload,
+		Topic:                 &topic,
+		DestinationIdentities: []string{string(participant.Identity())},
+	}, livekit.DataPacket_RELIABLE)
+}
+
+// canAttachE2EELocked returns ErrUnencryptedTracks when a participant moving into a room requiring end-to-end
+// encryption publishes tracks that are not encrypted, assumes lock is already acquired
+func (r *Room) canAttachE2EELocked(participant types.LocalParticipant) error {
+	if !r.e2eeRequired.Load() {
+		return nil
+	}
+	for _, track := range participant.GetPublishedTracks() {
+		if !track.IsEncrypted() {
+			return ErrUnencryptedTracks
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	mediaFloodCheckInterval = time.Second
+
+	// kinds of flood
+	mediaFloodBitrate      = "bitrate"
+	mediaFloodMalformedRTP = "malformed_rtp"
+	mediaFloodStreams      = "streams"
+)
+
+type mediaFloodAction int
+
+const (
+	mediaFloodActionNone mediaFloodAction = iota
+	mediaFloodActionThrottle
+	mediaFloodActionDisconnect
+)
+
+// mediaFloodSample is the media received from a publisher up to a point in time
+type mediaFloodSample struct {
+	bytes     uint64
+	malformed uint64
+	streams   int
+	// bitrate of the published tracks, in bits per second
+	trackBitrate uint64
+}
+
+type mediaFloodState struct {
+	bytes       uint64
+	malformed   uint64
+	at          time.Time
+	throttledAt time.Time
+}
+
+// mediaFloodDetector finds publishers flooding the node from samples of their media taken periodically
+type mediaFloodDetector struct {
+	config config.MediaFloodConfig
+	states map[livekit.ParticipantID]*mediaFloodState
+}
+
+func newMediaFloodDetector(conf config.MediaFloodConfig) *mediaFloodDetector {
+	return &mediaFloodDetector{
+		config: conf,
+		states: make(map[livekit.ParticipantID]*mediaFloodState),
+	}
+}
+
+// observe returns the kind of flood of the publisher since its previous sample, and whether it is to be throttled
+// or disconnected. a throttled publisher that stops flooding is no longer throttled
+func (d *mediaFloodDetector) observe(pID livekit.ParticipantID, sample mediaFloodSample, now time.Time) (string, mediaFloodAction) {
+	state := d.states[pID]
+	if state == nil {
+		state = &mediaFloodState{bytes: sample.bytes, malformed: sample.malformed, at: now}
+		d.states[pID] = state
+	}
+
+	var kind string
+	if sample.streams > d.config.MaxStreams {
+		kind = mediaFloodStreams
+	}
+	if elapsed := now.Sub(state.at).Seconds(); elapsed > 0 {
+		allowed := sample.trackBitrate
+		if allowed < d.config.VideoBitrate {
+			allowed = d.config.VideoBitrate
+		}
+		bitrate := float64(sample.bytes-state.bytes) * 8 / elapsed
+		malformed := float64(sample.malformed-state.malformed) / elapsed
+		switch {
+		case bitrate > float64(allowed)*d.config.BitrateMultiplier:
+			kind = mediaFloodBitrate
+		case malformed > d.config.MalformedPacketsPerSec:
+			kind = mediaFloodMalformedRTP
+		}
+	}
+	state.bytes, state.malformed, state.at = sample.bytes, sample.malformed, now
+
+	switch {
+	case kind == "":
+		state.throttledAt = time.Time{}
+		return "", mediaFloodActionNone
+	case state.throttledAt.IsZero():
+		state.throttledAt = now
+		return kind, mediaFloodActionThrottle
+	case now.Sub(state.throttledAt) >= d.config.ThrottlePeriod:
+		return kind, mediaFloodActionDisconnect
+	default:
+		return kind, mediaFloodActionNone
+	}
+}
+
+// prune drops the states of publishers that are not in the room anymore
+func (d *mediaFloodDetector) prune(participants []types.LocalParticipant) {
+	present := make(map[livekit.ParticipantID]bool, len(participants))
+	for _, p := range participants {
+		present[p.ID()] = true
+	}
+	for pID := range d.states {
+		if !present[pID] {
+			delete(d.states, pID)
+		}
+	}
+}
+
+// publishedTrackBitrate returns the bitrate of the tracks of a publisher, video tracks are the sum of their layers
+func (d *mediaFloodDetector) publishedTrackBitrate(p types.LocalParticipant) uint64 {
+	var bitrate uint64
+	for _, track := range p.GetPublishedTracks() {
+		if track.Kind() == livekit.TrackType_AUDIO {
+			bitrate += d.config.AudioBitrate
+			continue
+		}
+		var layers uint64
+		for _, layer := range track.ToProto().Layers {
+			layers += uint64(layer.Bitrate)
+		}
+		if layers == 0 {
+			layers = d.config.VideoBitrate
+		}
+		bitrate += layers
+	}
+	return bitrate
+}
+
+// mediaFloodWorker throttles publishers flooding the node with media by muting their tracks, and disconnects those
+// that keep flooding
+func (r *Room) mediaFloodWorker() {
+	ticker := time.NewTicker(mediaFloodCheckInterval)
+	defer ticker.Stop()
+
+	detector := newMediaFloodDetector(r.config.MediaFlood)
+	for !r.IsClosed() {
+		<-ticker.C
+
+		participants := r.GetParticipants()
+		now := time.Now()
+		for _, p := range participants {
+			factory := p.GetBufferFactory()
+			if factory == nil || p.State() != livekit.ParticipantInfo_ACTIVE {
+				continue
+			}
+			bytes, _, malformed := factory.Traffic().Load()
+			kind, action := detector.observe(p.ID(), mediaFloodSample{
+				bytes:        bytes,
+				malformed:    malformed,
+				streams:      factory.NumRTPStreams(),
+				trackBitrate: detector.publishedTrackBitrate(p),
+			}, now)
+			switch action {
+			case mediaFloodActionThrottle:
+				r.throttleMediaFlood(p, kind)
+			case mediaFloodActionDisconnect:
+				r.disconnectMediaFlood(p, kind)
+			}
+		}
+		detector.prune(participants)
+	}
+}
+
+func (r *Room) throttleMediaFlood(p types.LocalParticipant, kind string) {
+	r.Logger.Infow("throttling participant flooding media", "participant", p.Identity(), "pID", p.ID(), "kind", kind)
+	for _, track := range p.GetPublishedTracks() {
+		if !track.IsMuted() {
+			p.SetTrackMuted(track.ID(), true, true)
+		}
+	}
+	prometheus.RecordMediaFlood(kind, false)
+	r.telemetry.ParticipantMediaFlood(context.Background(), r.ToProto(), p.ToProto(), false)
+}
+
+func (r *Room) disconnectMediaFlood(p types.LocalParticipant, kind string) {
+	r.Logger.Infow("disconnecting participant flooding media", "participant", p.Identity(), "pID", p.ID(), "kind", kind)
+	prometheus.RecordMediaFlood(kind, true)
+	r.telemetry.ParticipantMediaFlood(context.Background(), r.ToProto(), p.ToProto(), true)
+	r.RemoveParticipant(p.Identity(), p.ID(), types.ParticipantCloseReasonMediaFlood)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestMediaFloodDetector(t *testing.T) {
+	d := newMediaFloodDetector(config.MediaFloodConfig{
+		Enabled:                true,
+		BitrateMultiplier:      2,
+		AudioBitrate:           100_000,
+		VideoBitrate:           1_000_000,
+		MalformedPacketsPerSec: 10,
+		MaxStreams:             4,
+		ThrottlePeriod:         2 * time.Second,
+	})
+	start := time.Now()
+	// publisher sends for another second, the sample has the media received so far
+	publisher := func(pID livekit.ParticipantID) func(bytes uint64, malformed uint64, streams int) (string, mediaFloodAction) {
+		sample := mediaFloodSample{trackBitrate: 1_500_000}
+		now := start
+		return func(bytes uint64, malformed uint64, streams int) (string, mediaFloodAction) {
+			sample.bytes += bytes
+			sample.malformed += malformed
+			sample.streams = streams
+			now = now.Add(time.Second)
+			return d.observe(pID, sample, now)
+		}
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		send := publisher("PA_within")
+		for i := 0; i < 5; i++ {
+			kind, action := send(300_000, 1, 3)
+			require.Empty(t, kind)
+			require.Equal(t, mediaFloodActionNone, action)
+		}
+	})
+
+	t.Run("throttled, then disconnected", func(t *testing.T) {
+		send := publisher("PA_bitrate")
+		_, action := send(0, 0, 1)
+		require.Equal(t, mediaFloodActionNone, action)
+		// 4Mbps is above twice the bitrate of the tracks
+		kind, action := send(500_000, 0, 1)
+		require.Equal(t, mediaFloodBitrate, kind)
+		require.Equal(t, mediaFloodActionThrottle, action)
+		_, action = send(500_000, 0, 1)
+		require.Equal(t, mediaFloodActionNone, action)
+		_, action = send(500_000, 0, 1)
+		require.Equal(t, mediaFloodActionDisconnect, action)
+	})
+
+	t.Run("malformed packets and streams", func(t *testing.T) {
+		send := publisher("PA_malformed")
+		send(0, 0, 1)
+		kind, action := send(1000, 50, 1)
+		require.Equal(t, mediaFloodMalformedRTP, kind)
+		require.Equal(t, mediaFloodActionThrottle, action)
+
+		kind, action = publisher("PA_streams")(0, 0, 5)
+		require.Equal(t, mediaFloodStreams, kind)
+		require.Equal(t, mediaFloodActionThrottle, action)
+	})
+
+	t.Run("throttle ends when the flood stops", func(t *testing.T) {
+		send := publisher("PA_recovers")
+		send(0, 0, 1)
+		_, action := send(500_000, 0, 1)
+		require.Equal(t, mediaFloodActionThrottle, action)
+		_, action = send(100_000, 0, 1)
+		require.Equal(t, mediaFloodActionNone, action)
+		_, action = send(500_000, 0, 1)
+		require.Equal(t, mediaFloodActionThrottle, action)
+	})
+}
@@ -0,0 +1,306 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
+)
+
+// RelayIdentity is the identity of the participant publishing the tracks of a publisher of another room relayed
+// into a room
+func RelayIdentity(sourceRoom livekit.RoomName, identity livekit.ParticipantIdentity) livekit.ParticipantIdentity {
+	_, name := sutils.SplitProjectRoomName(sourceRoom)
+	return livekit.ParticipantIdentity("relay/" + string(name) + "/" + string(identity))
+}
+
+// trackRelay mirrors tracks of a publisher of the source room into the destination room. subscribers of the
+// destination room subscribe to the tracks of the publisher, which appear to them as published by a participant of
+// RelayIdentity with the session ID of the publisher
+type trackRelay struct {
+	source      *Room
+	destination *Room
+	publisher   types.LocalParticipant
+	identity    livekit.ParticipantIdentity
+	name        string
+	createdAt   int64
+
+	lock sync.Mutex
+	// relayed tracks, all tracks of the publisher are relayed when trackIDs is empty
+	trackIDs map[livekit.TrackID]bool
+	tracks   map[livekit.TrackID]types.MediaTrack
+	version  uint32
+	stopped  bool
+}
+
+// RelayTracks relays tracks published by the participant into the destination room, all of its tracks when
+// trackIDs is empty. a relay of the participant into the room that exists is replaced
+func (r *Room) RelayTracks(destination *Room, identity livekit.ParticipantIdentity, trackIDs []livekit.TrackID) error {
+	if destination == r {
+		return ErrInvalidRelay
+	}
+	if r.IsClosed() || destination.IsClosed() {
+		return ErrRoomClosed
+	}
+	publisher := r.GetParticipant(identity)
+	if publisher == nil {
+		return ErrParticipantNotFound
+	}
+	r.StopRelay(destination, identity)
+
+	relay := &trackRelay{
+		source:      r,
+		destination: destination,
+		publisher:   publisher,
+		identity:    RelayIdentity(r.Name(), identity),
+		name:        publisher.ToProto().Name,
+		createdAt:   time.Now().Unix(),
+		trackIDs:    make(map[livekit.TrackID]bool, len(trackIDs)),
+		tracks:      make(map[livekit.TrackID]types.MediaTrack),
+	}
+	for _, trackID := range trackIDs {
+		relay.trackIDs[trackID] = true
+	}
+
+	r.lock.Lock()
+	r.relaysOut[identity] = append(r.relaysOut[identity], relay)
+	r.lock.Unlock()
+	destination.lock.Lock()
+	destination.relaysIn[publisher.ID()] = relay
+	destination.lock.Unlock()
+
+	r.Logger.Infow("relaying tracks", "participant", identity, "destinationRoom", destination.Name(), "trackIDs", trackIDs)
+	destination.sendRelayUpdate(relay)
+	for _, track := range publisher.GetPublishedTracks() {
+		relay.addTrack(track)
+	}
+	return nil
+}
+
+// StopRelay stops relaying the tracks of the participant into the destination room
+func (r *Room) StopRelay(destination *Room, identity livekit.ParticipantIdentity) bool {
+	r.lock.RLock()
+	var stopping *trackRelay
+	for _, relay := range r.relaysOut[identity] {
+		if relay.destination == destination {
+			stopping = relay
+		}
+	}
+	r.lock.RUnlock()
+
+	if stopping == nil {
+		return false
+	}
+	stopping.stop()
+	return true
+}
+
+// GetRelays returns the participant info of the publishers of other rooms relayed into the room
+func (r *Room) GetRelays() []*livekit.ParticipantInfo {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.relayInfoLocked()
+}
+
+// relayInfoLocked returns participant info of relays into the room, assumes lock is already acquired
+func (r *Room) relayInfoLocked() []*livekit.ParticipantInfo {
+	infos := make([]*livekit.ParticipantInfo, 0, len(r.relaysIn))
+	for _, relay := range r.relaysIn {
+		infos = append(infos, relay.toProto())
+	}
+	return infos
+}
+
+// relaysOf returns the relays of tracks of the participant into other rooms
+func (r *Room) relaysOf(identity livekit.ParticipantIdentity) []*trackRelay {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return append([]*trackRelay(nil), r.relaysOut[identity]...)
+}
+
+// relayOf returns the relay publishing tracks of the participant of another room into the room
+func (r *Room) relayOf(publisherID livekit.ParticipantID) *trackRelay {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.relaysIn[publisherID]
+}
+
+// stopRelays stops the relays of the participant into other rooms
+func (r *Room) stopRelays(identity livekit.ParticipantIdentity) {
+	for _, relay := range r.relaysOf(identity) {
+		relay.stop()
+	}
+}
+
+// stopAllRelays stops relays out of and into the room when it closes
+func (r *Room) stopAllRelays() {
+	r.lock.RLock()
+	var relays []*trackRelay
+	for _, out := range r.relaysOut {
+		relays = append(relays, out...)
+	}
+	for _, in := range r.relaysIn {
+		relays = append(relays, in)
+	}
+	r.lock.RUnlock()
+
+	for _, relay := range relays {
+		relay.stop()
+	}
+}
+
+func (r *Room) sendRelayUpdate(relay *trackRelay) {
+	r.sendParticipantUpdates(r.pushAndDequeueUpdates(relay.toProto(), true))
+}
+
+// subscribeToRelayedTrack subscribes the active participants of the room to a relayed track
+func (r *Room) subscribeToRelayedTrack(publisherID livekit.ParticipantID, trackID livekit.TrackID) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, p := range r.participants {
+		if p.ID() == publisherID || p.State() != livekit.ParticipantInfo_ACTIVE || !r.autoSubscribe(p) {
+			continue
+		}
+		p.SubscribeToTrack(trackID)
+	}
+}
+
+func (t *trackRelay) relays(trackID livekit.TrackID) bool {
+	return len(t.trackIDs) == 0 || t.trackIDs[trackID]
+}
+
+// addTrack relays a track published by the publisher
+func (t *trackRelay) addTrack(track types.MediaTrack) {
+	t.lock.Lock()
+	if t.stopped || !t.relays(track.ID()) || t.tracks[track.ID()] == track {
+		t.lock.Unlock()
+		return
+	}
+	if t.destination.E2EERequired() && !track.IsEncrypted() {
+		t.lock.Unlock()
+		t.source.Logger.Infow("not relaying unencrypted track into room requiring end-to-end encryption",
+			"trackID", track.ID(), "destinationRoom", t.destination.Name())
+		return
+	}
+	t.tracks[track.ID()] = track
+	t.version++
+	t.lock.Unlock()
+
+	t.destination.trackManager.AddTrack(track, t.identity, t.publisher.ID())
+	t.destination.sendRelayUpdate(t)
+	t.destination.subscribeToRelayedTrack(t.publisher.ID(), track.ID())
+}
+
+// removeTrack stops relaying a track that the publisher has unpublished
+func (t *trackRelay) removeTrack(track types.MediaTrack) {
+	t.lock.Lock()
+	if t.stopped || t.tracks[track.ID()] != track {
+		t.lock.Unlock()
+		return
+	}
+	delete(t.tracks, track.ID())
+	t.version++
+	t.lock.Unlock()
+
+	t.destination.trackManager.RemoveTrack(track)
+	t.destination.sendRelayUpdate(t)
+}
+
+// trackUpdated sends updated track info of a relayed track, i.e. when it is muted
+func (t *trackRelay) trackUpdated(track types.MediaTrack) {
+	t.lock.Lock()
+	if t.stopped || t.tracks[track.ID()] != track {
+		t.lock.Unlock()
+		return
+	}
+	t.version++
+	t.lock.Unlock()
+
+	t.destination.sendRelayUpdate(t)
+}
+
+func (t *trackRelay) stop() {
+	t.lock.Lock()
+	if t.stopped {
+		t.lock.Unlock()
+		return
+	}
+	t.stopped = true
+	t.version++
+	tracks := t.tracks
+	t.tracks = make(map[livekit.TrackID]types.MediaTrack)
+	t.lock.Unlock()
+
+	identity := t.publisher.Identity()
+	t.source.lock.Lock()
+	relays := t.source.relaysOut[identity]
+	for i, relay := range relays {
+		if relay == t {
+			relays = append(relays[:i:i], relays[i+1:]...)
+			break
+		}
+	}
+	if len(relays) == 0 {
+		delete(t.source.relaysOut, identity)
+	} else {
+		t.source.relaysOut[identity] = relays
+	}
+	t.source.lock.Unlock()
+
+	t.destination.lock.Lock()
+	if t.destination.relaysIn[t.publisher.ID()] == t {
+		delete(t.destination.relaysIn, t.publisher.ID())
+	}
+	t.destination.lock.Unlock()
+
+	for _, track := range tracks {
+		t.destination.trackManager.RemoveTrack(track)
+	}
+	t.source.Logger.Infow("stopped relaying tracks", "participant", identity, "destinationRoom", t.destination.Name())
+	t.destination.sendRelayUpdate(t)
+}
+
+// toProto returns the info of the participant publishing the relayed tracks in the destination room
+func (t *trackRelay) toProto() *livekit.ParticipantInfo {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	pi := &livekit.ParticipantInfo{
+		Sid:         string(t.publisher.ID()),
+		Identity:    string(t.identity),
+		Name:        t.name,
+		State:       livekit.ParticipantInfo_ACTIVE,
+		JoinedAt:    t.createdAt,
+		Version:     t.version,
+		IsPublisher: len(t.tracks) != 0,
+		Permission:  &livekit.ParticipantPermission{CanPublish: true},
+	}
+	if t.stopped {
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+	}
+	for _, track := range t.tracks {
+		pi.Tracks = append(pi.Tracks, track.ToProto())
+	}
+	return pi
+}
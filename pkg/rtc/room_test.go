@@ -15,6 +15,8 @@
 package rtc
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -31,6 +33,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
 	"github.com/livekit/livekit-server/pkg/sfu/audio"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
 	"github.com/livekit/livekit-server/pkg/testutils"
 )
@@ -93,6 +96,7 @@ func TestRoomJoin(t *testing.T) {
 		require.Len(t, res.OtherParticipants, numParticipants)
 		require.Len(t, rm.GetParticipants(), numParticipants+1)
 		require.NotEmpty(t, res.IceServers)
+		require.Equal(t, uint32(numParticipants+1), rm.PeakParticipants())
 	})
 
 	t.Run("subscribe to existing channels upon join", func(t *testing.T) {
@@ -159,6 +163,19 @@ func TestRoomJoin(t *testing.T) {
 		err := rm.Join(p, nil, nil, iceServersForRoom)
 		require.Equal(t, ErrMaxParticipantsExceeded, err)
 	})
+
+	t.Run("cannot join a closing room", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		closesAt := time.Now().Add(time.Minute).Truncate(time.Second)
+		require.True(t, rm.SetClosesAt(closesAt))
+		// the room only closes earlier
+		require.False(t, rm.SetClosesAt(closesAt.Add(time.Minute)))
+		require.True(t, rm.SetClosesAt(closesAt.Add(-30*time.Second)))
+		require.Equal(t, closesAt.Add(-30*time.Second), rm.ClosesAt())
+
+		p := newMockParticipant("second", types.ProtocolVersion(0), false, false)
+		require.Equal(t, ErrRoomClosing, rm.Join(p, nil, nil, iceServersForRoom))
+	})
 }
 
 // various state changes to participant and that others are receiving update
@@ -352,6 +369,117 @@ func TestPushAndDequeueUpdates(t *testing.T) {
 	}
 }
 
+func TestMoveParticipant(t *testing.T) {
+	t.Run("participant is moved with its tracks", func(t *testing.T) {
+		src := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		dst := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		p := src.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		track := p.GetPublishedTracks()[0].(*typesfakes.FakeMediaTrack)
+		subTrack := &typesfakes.FakeSubscribedTrack{}
+		subTrack.IDReturns("TR_subscribed")
+		p.GetSubscribedTracksReturns([]types.SubscribedTrack{subTrack})
+
+		detached, requestSource, opts, err := src.DetachParticipant(p.Identity())
+		require.NoError(t, err)
+		require.Equal(t, p, detached)
+		require.Nil(t, src.GetParticipant(p.Identity()))
+		require.Len(t, src.GetParticipants(), 2)
+		require.Equal(t, 1, track.RevokeDisallowedSubscribersCallCount())
+		require.Equal(t, livekit.TrackID("TR_subscribed"), p.UnsubscribeFromTrackArgsForCall(0))
+		require.Zero(t, p.CloseCallCount())
+
+		// the participant learns that the participants of the room have left
+		updates := p.SendParticipantUpdateArgsForCall(p.SendParticipantUpdateCallCount() - 1)
+		require.Len(t, updates, 2)
+		for _, pi := range updates {
+			require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, pi.State)
+		}
+
+		require.NoError(t, dst.AttachParticipant(detached, requestSource, opts))
+		require.Equal(t, p, dst.GetParticipant(p.Identity()))
+		require.Len(t, dst.GetParticipants(), 3)
+		require.Equal(t, 1, p.SendRoomUpdateCallCount())
+		// subscribed to the tracks of the destination room, and its track to participants of the room
+		require.Equal(t, 2, p.SubscribeToTrackCallCount())
+		for _, op := range dst.GetParticipants() {
+			if op == p {
+				continue
+			}
+			require.Equal(t, 1, op.(*typesfakes.FakeLocalParticipant).SubscribeToTrackCallCount())
+		}
+	})
+
+	t.Run("participant must be in the room", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		_, _, _, err := rm.DetachParticipant("unknown")
+		require.Equal(t, ErrParticipantNotFound, err)
+	})
+
+	t.Run("cannot attach to a full room", func(t *testing.T) {
+		src := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		dst := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		dst.lock.Lock()
+		dst.protoRoom.MaxParticipants = 1
+		dst.lock.Unlock()
+
+		p, requestSource, opts, err := src.DetachParticipant("p0")
+		require.NoError(t, err)
+		require.Equal(t, ErrMaxParticipantsExceeded, dst.AttachParticipant(p, requestSource, opts))
+		require.Len(t, dst.GetParticipants(), 1)
+	})
+}
+
+func TestMuteTracks(t *testing.T) {
+	t.Run("mutes matching tracks except those of allowed participants", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		tracks := make(map[livekit.ParticipantIdentity]*typesfakes.FakeMediaTrack)
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeLocalParticipant)
+			track := newMockTrack(livekit.TrackType_AUDIO, "mic")
+			track.SourceReturns(livekit.TrackSource_MICROPHONE)
+			camera := newMockTrack(livekit.TrackType_VIDEO, "camera")
+			camera.SourceReturns(livekit.TrackSource_CAMERA)
+			fp.GetPublishedTracksReturns([]types.MediaTrack{track, camera})
+			tracks[p.Identity()] = track
+		}
+		tracks["p2"].IsMutedReturns(true)
+
+		muted := rm.MuteTracks(&MuteRule{
+			Sources: []livekit.TrackSource{livekit.TrackSource_MICROPHONE},
+			Except:  []livekit.ParticipantIdentity{"p0"},
+		}, false)
+		require.Equal(t, 1, muted)
+
+		require.Zero(t, rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant).SetTrackMutedCallCount())
+		require.Zero(t, rm.GetParticipant("p2").(*typesfakes.FakeLocalParticipant).SetTrackMutedCallCount())
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+		require.Equal(t, 1, p1.SetTrackMutedCallCount())
+		trackID, isMuted, fromAdmin := p1.SetTrackMutedArgsForCall(0)
+		require.Equal(t, tracks["p1"].ID(), trackID)
+		require.True(t, isMuted)
+		require.True(t, fromAdmin)
+	})
+
+	t.Run("mutes tracks published later on until cleared", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		pub := rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+		trackCB := pub.OnTrackPublishedArgsForCall(0)
+
+		rm.MuteTracks(&MuteRule{Kinds: []livekit.TrackType{livekit.TrackType_AUDIO}}, true)
+		// tracks of the test participants are audio tracks
+		require.Equal(t, 1, pub.SetTrackMutedCallCount())
+
+		trackCB(pub, newMockTrack(livekit.TrackType_VIDEO, "camera"))
+		require.Equal(t, 1, pub.SetTrackMutedCallCount())
+		trackCB(pub, newMockTrack(livekit.TrackType_AUDIO, "mic"))
+		require.Equal(t, 2, pub.SetTrackMutedCallCount())
+
+		rm.ClearMuteRule()
+		trackCB(pub, newMockTrack(livekit.TrackType_AUDIO, "mic"))
+		require.Equal(t, 2, pub.SetTrackMutedCallCount())
+	})
+}
+
 func TestRoomClosure(t *testing.T) {
 	t.Run("room closes after participant leaves", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
@@ -371,6 +499,9 @@ func TestRoomClosure(t *testing.T) {
 		rm.CloseIfEmpty()
 		require.Len(t, rm.GetParticipants(), 0)
 		require.True(t, isClosed)
+		require.Equal(t, types.RoomCloseReasonEmpty, rm.CloseReason())
+		// peak is kept after participants leave
+		require.Equal(t, uint32(1), rm.PeakParticipants())
 
 		require.Equal(t, ErrRoomClosed, rm.Join(p, nil, nil, iceServersForRoom))
 	})
@@ -400,6 +531,22 @@ func TestRoomClosure(t *testing.T) {
 		rm.CloseIfEmpty()
 		require.True(t, isClosed)
 	})
+
+	t.Run("scheduled room does not close before its start", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		isClosed := false
+		rm.OnClose(func() {
+			isClosed = true
+		})
+		rm.lock.Lock()
+		rm.protoRoom.EmptyTimeout = 1
+		rm.protoRoom.CreationTime = time.Now().Add(-time.Hour).Unix()
+		rm.lock.Unlock()
+		rm.SetStartsAt(time.Now().Add(time.Minute))
+
+		rm.CloseIfEmpty()
+		require.False(t, isClosed)
+	})
 }
 
 func TestNewTrack(t *testing.T) {
@@ -648,6 +795,186 @@ func TestDataChannel(t *testing.T) {
 	})
 }
 
+func TestParticipantAttributes(t *testing.T) {
+	receivedAttributes := func(t *testing.T, fp *typesfakes.FakeLocalParticipant, i int) *ParticipantAttributesUpdate {
+		dp, _ := fp.SendDataPacketArgsForCall(i)
+		require.Equal(t, ParticipantAttributesTopic, dp.GetUser().GetTopic())
+		var update ParticipantAttributesUpdate
+		require.NoError(t, json.Unmarshal(dp.GetUser().Payload, &update))
+		return &update
+	}
+
+	t.Run("changed attributes are sent to room members", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		defer rm.Close()
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+
+		rm.UpdateParticipantAttributes(p, map[string]string{"hand": "raised", "role": "speaker"})
+		require.Equal(t, map[string]string{"hand": "raised", "role": "speaker"}, rm.GetParticipantAttributes(p.Identity()))
+		for _, op := range participants {
+			fp := op.(*typesfakes.FakeLocalParticipant)
+			require.Equal(t, 1, fp.SendDataPacketCallCount())
+			update := receivedAttributes(t, fp, 0)
+			require.Len(t, update.Participants, 1)
+			require.Equal(t, string(p.Identity()), update.Participants[0].Identity)
+			require.Equal(t, map[string]string{"hand": "raised", "role": "speaker"}, update.Participants[0].Attributes)
+		}
+
+		// unchanged attributes are not sent again
+		rm.UpdateParticipantAttributes(p, map[string]string{"hand": "raised"})
+		require.Equal(t, 1, p.SendDataPacketCallCount())
+
+		rm.UpdateParticipantAttributes(p, map[string]string{"hand": ""})
+		require.Equal(t, map[string]string{"role": "speaker"}, rm.GetParticipantAttributes(p.Identity()))
+		require.Equal(t, 2, p.SendDataPacketCallCount())
+		require.Equal(t, map[string]string{"role": "speaker"}, receivedAttributes(t, p, 1).Participants[0].Attributes)
+	})
+
+	t.Run("participants cannot send attributes", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close()
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+
+		topic := ParticipantAttributesTopic
+		p.OnDataPacketArgsForCall(0)(p, &livekit.DataPacket{
+			Kind: livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					Payload: []byte(`{"participants":[]}`),
+					Topic:   &topic,
+				},
+			},
+		})
+		for _, op := range participants {
+			require.Zero(t, op.(*typesfakes.FakeLocalParticipant).SendDataPacketCallCount())
+		}
+	})
+}
+
+func TestE2EE(t *testing.T) {
+	t.Run("participants are required to encrypt", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close()
+		participants := rm.GetParticipants()
+		active := participants[0].(*typesfakes.FakeLocalParticipant)
+		joining := participants[1].(*typesfakes.FakeLocalParticipant)
+		joining.StateReturns(livekit.ParticipantInfo_JOINED)
+
+		rm.SetE2EERequired(true)
+		require.True(t, rm.E2EERequired())
+		for _, op := range participants {
+			fp := op.(*typesfakes.FakeLocalParticipant)
+			require.True(t, fp.SetE2EERequiredArgsForCall(fp.SetE2EERequiredCallCount()-1))
+		}
+		// active participants are told of the requirement
+		require.Equal(t, 1, active.SendDataPacketCallCount())
+		dp, _ := active.SendDataPacketArgsForCall(0)
+		require.Equal(t, E2EETopic, dp.GetUser().GetTopic())
+		var state E2EEState
+		require.NoError(t, json.Unmarshal(dp.GetUser().Payload, &state))
+		require.True(t, state.Required)
+		require.Zero(t, joining.SendDataPacketCallCount())
+	})
+
+	t.Run("keys are forwarded reliably", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close()
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+		op := participants[1].(*typesfakes.FakeLocalParticipant)
+
+		topic := E2EEKeysTopic
+		p.OnDataPacketArgsForCall(0)(p, &livekit.DataPacket{
+			Kind: livekit.DataPacket_LOSSY,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					Payload: []byte("encrypted key"),
+					Topic:   &topic,
+				},
+			},
+		})
+		require.Equal(t, 1, op.SendDataPacketCallCount())
+		dp, _ := op.SendDataPacketArgsForCall(0)
+		require.Equal(t, livekit.DataPacket_RELIABLE, dp.Kind)
+		require.Equal(t, []byte("encrypted key"), dp.GetUser().Payload)
+
+		// the encryption state is only sent by the server
+		topic = E2EETopic
+		p.OnDataPacketArgsForCall(0)(p, &livekit.DataPacket{
+			Kind: livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					Payload: []byte(`{"required":false}`),
+					Topic:   &topic,
+				},
+			},
+		})
+		require.Equal(t, 1, op.SendDataPacketCallCount())
+	})
+
+	t.Run("participants with unencrypted tracks cannot move in", func(t *testing.T) {
+		src := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		dst := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		dst.SetE2EERequired(true)
+
+		p, requestSource, opts, err := src.DetachParticipant("p0")
+		require.NoError(t, err)
+		require.Equal(t, ErrUnencryptedTracks, dst.AttachParticipant(p, requestSource, opts))
+		require.Len(t, dst.GetParticipants(), 1)
+
+		track := p.GetPublishedTracks()[0].(*typesfakes.FakeMediaTrack)
+		track.IsEncryptedReturns(true)
+		require.NoError(t, dst.AttachParticipant(p, requestSource, opts))
+	})
+}
+
+func TestTrackRelay(t *testing.T) {
+	src := newRoomWithParticipants(t, testRoomOpts{num: 1})
+	defer src.Close()
+	dst := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	defer dst.Close()
+
+	pub := src.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+	pub.HasPermissionReturns(true)
+	track := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+	track.IsOpenReturns(true)
+	track.ToProtoReturns(&livekit.TrackInfo{Sid: string(track.ID()), Type: livekit.TrackType_VIDEO})
+	pub.GetPublishedTracksReturns([]types.MediaTrack{track})
+
+	require.ErrorIs(t, src.RelayTracks(src, pub.Identity(), nil), ErrInvalidRelay)
+	require.ErrorIs(t, src.RelayTracks(dst, "unknown", nil), ErrParticipantNotFound)
+	require.NoError(t, src.RelayTracks(dst, pub.Identity(), nil))
+
+	// participants of the destination room subscribe to the track of the relay
+	for _, p := range dst.GetParticipants() {
+		fp := p.(*typesfakes.FakeLocalParticipant)
+		require.Equal(t, 1, fp.SubscribeToTrackCallCount())
+		require.Equal(t, track.ID(), fp.SubscribeToTrackArgsForCall(0))
+	}
+	relays := dst.GetRelays()
+	require.Len(t, relays, 1)
+	require.Equal(t, string(RelayIdentity(src.Name(), pub.Identity())), relays[0].Identity)
+	require.Equal(t, string(pub.ID()), relays[0].Sid)
+	require.Len(t, relays[0].Tracks, 1)
+
+	res := dst.ResolveMediaTrackForSubscriber("p1", track.ID())
+	require.Equal(t, track, res.Track)
+	require.Equal(t, pub.ID(), res.PublisherID)
+	require.True(t, res.HasPermission)
+
+	// unpublished tracks are no longer relayed
+	src.onTrackUnpublished(pub, track)
+	require.Nil(t, dst.ResolveMediaTrackForSubscriber("p1", track.ID()).Track)
+	require.Empty(t, dst.GetRelays()[0].Tracks)
+
+	// relays stop when the publisher leaves
+	src.RemoveParticipant(pub.Identity(), pub.ID(), types.ParticipantCloseReasonClientRequestLeave)
+	require.Empty(t, dst.GetRelays())
+	require.False(t, src.StopRelay(dst, pub.Identity()))
+}
+
 func TestHiddenParticipants(t *testing.T) {
 	t.Run("other participants don't receive hidden updates", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 2, numHidden: 1})
@@ -713,6 +1040,33 @@ func TestRoomUpdate(t *testing.T) {
 			require.GreaterOrEqual(t, fp.SendRoomUpdateCallCount(), 1)
 		}
 	})
+
+	t.Run("patched metadata is sent once", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close()
+		rm.SetMetadata("a")
+		time.Sleep(2 * defaultDelay)
+
+		sent := make(map[livekit.ParticipantIdentity]int)
+		for _, op := range rm.GetParticipants() {
+			sent[op.Identity()] = op.(*typesfakes.FakeLocalParticipant).SendRoomUpdateCallCount()
+		}
+
+		require.NoError(t, rm.PatchMetadata(func(metadata string) (string, error) {
+			return metadata + "b", nil
+		}))
+		require.Error(t, rm.PatchMetadata(func(metadata string) (string, error) {
+			return "", errors.New("invalid patch")
+		}))
+		require.Equal(t, "ab", rm.ToProto().Metadata)
+
+		time.Sleep(2 * defaultDelay)
+		for _, op := range rm.GetParticipants() {
+			fp := op.(*typesfakes.FakeLocalParticipant)
+			require.Equal(t, sent[op.Identity()]+1, fp.SendRoomUpdateCallCount())
+			require.Equal(t, "ab", fp.SendRoomUpdateArgsForCall(fp.SendRoomUpdateCallCount()-1).Metadata)
+		}
+	})
 }
 
 type testRoomOpts struct {
@@ -738,7 +1092,7 @@ func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *Room {
 			NodeId:   "testnode",
 			Region:   "testregion",
 		},
-		telemetry.NewTelemetryService(webhook.NewDefaultNotifier("", "", nil), &telemetryfakes.FakeAnalyticsService{}),
+		telemetry.NewTelemetryService(webhook.NewDefaultNotifier("", "", nil), &telemetryfakes.FakeAnalyticsService{}, prometheus.StatsReporter{}),
 		nil,
 	)
 	for i := 0; i < opts.num+opts.numHidden; i++ {
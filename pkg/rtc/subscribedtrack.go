@@ -50,6 +50,7 @@ type SubscribedTrack struct {
 	subMuted         atomic.Bool
 	pubMuted         atomic.Bool
 	settings         atomic.Pointer[livekit.UpdateTrackSettings]
+	pinnedQuality    atomic.Pointer[livekit.VideoQuality]
 	logger           logger.Logger
 	sender           atomic.Pointer[webrtc.RTPSender]
 	needsNegotiation atomic.Bool
@@ -205,12 +206,29 @@ func (t *SubscribedTrack) UpdateSubscriberSettings(settings *livekit.UpdateTrack
 	}
 }
 
+// PinQuality forwards the given quality regardless of subscriber preferences, nil removes the pin
+func (t *SubscribedTrack) PinQuality(quality *livekit.VideoQuality) {
+	t.pinnedQuality.Store(quality)
+	t.UpdateVideoLayer()
+}
+
+func (t *SubscribedTrack) PinnedQuality() *livekit.VideoQuality {
+	return t.pinnedQuality.Load()
+}
+
 func (t *SubscribedTrack) UpdateVideoLayer() {
 	t.updateDownTrackMute()
 	if t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo {
 		return
 	}
 
+	if quality := t.pinnedQuality.Load(); quality != nil {
+		t.logger.Debugw("updating video layer to pinned quality", "quality", *quality)
+		t.DownTrack().SetMaxSpatialLayer(buffer.VideoQualityToSpatialLayer(*quality, t.params.MediaTrack.ToProto()))
+		t.DownTrack().SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+		return
+	}
+
 	settings := t.settings.Load()
 	if settings == nil || settings.Disabled {
 		return
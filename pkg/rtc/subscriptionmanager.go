@@ -27,6 +27,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
@@ -224,6 +225,27 @@ func (m *SubscriptionManager) UpdateSubscribedTrackSettings(trackID livekit.Trac
 	sub.setSettings(settings)
 }
 
+// PinSubscribedTrackQuality pins (or with a nil quality, unpins) the quality forwarded for a track,
+// like subscriber settings it is restored when the track is resubscribed. Returns the currently subscribed track, if any.
+func (m *SubscriptionManager) PinSubscribedTrackQuality(trackID livekit.TrackID, quality *livekit.VideoQuality) types.SubscribedTrack {
+	m.lock.Lock()
+	sub, ok := m.subscriptions[trackID]
+	if !ok {
+		if quality == nil {
+			m.lock.Unlock()
+			return nil
+		}
+		sLogger := m.params.Logger.WithValues(
+			"trackID", trackID,
+		)
+		sub = newTrackSubscription(m.params.Participant.ID(), trackID, sLogger)
+		m.subscriptions[trackID] = sub
+	}
+	m.lock.Unlock()
+
+	return sub.setPinnedQuality(quality)
+}
+
 // OnSubscribeStatusChanged callback will be notified when a participant subscribes or unsubscribes to another participant
 // it will only fire once per publisher. If current participant is subscribed to multiple tracks from another, this
 // callback will only fire once.
@@ -522,6 +544,13 @@ func (m *SubscriptionManager) subscribe(s *trackSubscription) error {
 			s.setBound()
 			s.maybeRecordSuccess(m.params.Telemetry, m.params.Participant.ID())
 		})
+		if dt := subTrack.DownTrack(); dt != nil {
+			dt.OnFirstPacketSent(func(_ *sfu.DownTrack) {
+				if requestedAt := s.getRequestedAt(); !requestedAt.IsZero() {
+					prometheus.RecordSubscribeFirstFrameTime(track.Kind(), m.params.Participant.ID(), trackID, time.Since(requestedAt))
+				}
+			})
+		}
 		s.setSubscribedTrack(subTrack)
 
 		switch track.Kind() {
@@ -702,6 +731,7 @@ type trackSubscription struct {
 	publisherID       livekit.ParticipantID
 	publisherIdentity livekit.ParticipantIdentity
 	settings          *livekit.UpdateTrackSettings
+	pinnedQuality     *livekit.VideoQuality
 	changedNotifier   types.ChangeNotifier
 	removedNotifier   types.ChangeNotifier
 	hasPermission     bool
@@ -714,6 +744,8 @@ type trackSubscription struct {
 	// the later of when subscription was requested OR when the first failure was encountered OR when permission is granted
 	// this timestamp determines when failures are reported
 	subStartedAt atomic.Pointer[time.Time]
+	// when the subscription last became desired
+	requestedAt atomic.Pointer[time.Time]
 }
 
 func newTrackSubscription(subscriberID livekit.ParticipantID, trackID livekit.TrackID, l logger.Logger) *trackSubscription {
@@ -760,6 +792,8 @@ func (s *trackSubscription) setDesired(desired bool) bool {
 	if desired {
 		// reset attempts
 		s.numAttempts.Store(0)
+		t := time.Now()
+		s.requestedAt.Store(&t)
 	} else {
 		s.setChangedNotifierLocked(nil)
 		s.setRemovedNotifierLocked(nil)
@@ -783,6 +817,13 @@ func (s *trackSubscription) setHasPermission(perm bool) bool {
 	return true
 }
 
+func (s *trackSubscription) getRequestedAt() time.Time {
+	if t := s.requestedAt.Load(); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
 func (s *trackSubscription) getHasPermission() bool {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -801,8 +842,13 @@ func (s *trackSubscription) setSubscribedTrack(track types.SubscribedTrack) {
 	s.subscribedTrack = track
 	s.bound = false
 	settings := s.settings
+	pinnedQuality := s.pinnedQuality
 	s.lock.Unlock()
 
+	if pinnedQuality != nil && track != nil {
+		s.logger.Debugw("restoring pinned quality", "quality", *pinnedQuality)
+		track.PinQuality(pinnedQuality)
+	}
 	if settings != nil && track != nil {
 		s.logger.Debugw("restoring subscriber settings", "settings", settings)
 		track.UpdateSubscriberSettings(settings)
@@ -880,6 +926,17 @@ func (s *trackSubscription) setSettings(settings *livekit.UpdateTrackSettings) {
 	}
 }
 
+func (s *trackSubscription) setPinnedQuality(quality *livekit.VideoQuality) types.SubscribedTrack {
+	s.lock.Lock()
+	s.pinnedQuality = quality
+	subTrack := s.subscribedTrack
+	s.lock.Unlock()
+	if subTrack != nil {
+		subTrack.PinQuality(quality)
+	}
+	return subTrack
+}
+
 // mark the subscription as bound - when we've received the client's answer
 func (s *trackSubscription) setBound() {
 	s.lock.Lock()
@@ -936,7 +993,7 @@ func (s *trackSubscription) maybeRecordError(ts telemetry.TelemetryService, pID
 		return
 	}
 
-	ts.TrackSubscribeFailed(context.Background(), pID, s.trackID, err, isUserError)
+	ts.TrackSubscribeFailed(context.Background(), pID, s.trackID, err, SubscribeErrorReason(err), isUserError)
 }
 
 func (s *trackSubscription) maybeRecordSuccess(ts telemetry.TelemetryService, pID livekit.ParticipantID) {
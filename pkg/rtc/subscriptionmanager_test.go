@@ -350,6 +350,43 @@ func TestUpdateSettingsBeforeSubscription(t *testing.T) {
 	require.Equal(t, settings.Height, applied.Height)
 }
 
+func TestPinQuality(t *testing.T) {
+	sm := newTestSubscriptionManager(t)
+	defer sm.Close(false)
+	resolver := newTestResolver(true, true, "pub", "pubID")
+	sm.params.TrackResolver = resolver.Resolve
+
+	t.Run("unpinning without a subscription is a no-op", func(t *testing.T) {
+		require.Nil(t, sm.PinSubscribedTrackQuality("other", nil))
+		require.NotContains(t, sm.subscriptions, livekit.TrackID("other"))
+	})
+
+	t.Run("pin before subscription is restored", func(t *testing.T) {
+		quality := livekit.VideoQuality_HIGH
+		require.Nil(t, sm.PinSubscribedTrackQuality("track", &quality))
+
+		sm.SubscribeToTrack("track")
+
+		s := sm.subscriptions["track"]
+		require.Eventually(t, func() bool {
+			return !s.needsSubscribe()
+		}, subSettleTimeout, subCheckInterval, "Track should be subscribed")
+
+		st := s.getSubscribedTrack().(*typesfakes.FakeSubscribedTrack)
+		require.Equal(t, 1, st.PinQualityCallCount())
+		require.Equal(t, livekit.VideoQuality_HIGH, *st.PinQualityArgsForCall(0))
+	})
+
+	t.Run("pin is applied to the subscribed track", func(t *testing.T) {
+		subTrack := sm.PinSubscribedTrackQuality("track", nil)
+		require.NotNil(t, subTrack)
+
+		st := subTrack.(*typesfakes.FakeSubscribedTrack)
+		require.Equal(t, 2, st.PinQualityCallCount())
+		require.Nil(t, st.PinQualityArgsForCall(1))
+	})
+}
+
 func TestSubscriptionLimits(t *testing.T) {
 	sm := newTestSubscriptionManagerWithParams(t, testSubscriptionParams{
 		SubscriptionLimitAudio: 1,
@@ -277,7 +277,11 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 
 	// Change elliptic curve to improve connectivity
 	// https://github.com/pion/dtls/pull/474
-	se.SetDTLSEllipticCurves(elliptic.X25519, elliptic.P384, elliptic.P256)
+	if len(params.Config.DTLSEllipticCurves) != 0 {
+		se.SetDTLSEllipticCurves(params.Config.DTLSEllipticCurves...)
+	} else {
+		se.SetDTLSEllipticCurves(elliptic.X25519, elliptic.P384, elliptic.P256)
+	}
 
 	//
 	// Disable SRTP replay protection (https://datatracker.ietf.org/doc/html/rfc3711#page-15).
@@ -373,7 +377,11 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 		webrtc.WithSettingEngine(se),
 		webrtc.WithInterceptorRegistry(ir),
 	)
-	pc, err := api.NewPeerConnection(params.Config.Configuration)
+	configuration := params.Config.Configuration
+	if certificates := params.Config.DTLSCertificates.Current(time.Now()); len(certificates) != 0 {
+		configuration.Certificates = certificates
+	}
+	pc, err := api.NewPeerConnection(configuration)
 	return pc, me, err
 }
 
@@ -653,6 +661,7 @@ func (t *PCTransport) onPeerConnectionStateChange(state webrtc.PeerConnectionSta
 
 			t.maybeNotifyFullyEstablished()
 			t.logICECandidates()
+			t.recordSelectedCandidatePair()
 		}
 	case webrtc.PeerConnectionStateFailed:
 		t.params.Logger.Infow("peer connection failed")
@@ -1174,6 +1183,14 @@ func (t *PCTransport) RemoveTrackFromStreamAllocator(subTrack types.SubscribedTr
 	t.streamAllocator.RemoveTrack(subTrack.DownTrack())
 }
 
+func (t *PCTransport) SetTrackPinnedInStreamAllocator(subTrack types.SubscribedTrack, pinned bool) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.SetTrackPinned(subTrack.DownTrack(), pinned)
+}
+
 func (t *PCTransport) SetAllowPauseOfStreamAllocator(allowPause bool) {
 	if t.streamAllocator == nil {
 		return
@@ -1190,6 +1207,18 @@ func (t *PCTransport) SetChannelCapacityOfStreamAllocator(channelCapacity int64)
 	t.streamAllocator.SetChannelCapacity(channelCapacity)
 }
 
+// GetSelectedICECandidatePair returns the candidate pair in use, nil when the transport is not connected
+func (t *PCTransport) GetSelectedICECandidatePair() *webrtc.ICECandidatePair {
+	if t.pc == nil {
+		return nil
+	}
+	p, err := t.getSelectedPair()
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
 func (t *PCTransport) GetICEConnectionType() types.ICEConnectionType {
 	unknown := types.ICEConnectionTypeUnknown
 	if t.pc == nil {
@@ -1200,9 +1229,17 @@ func (t *PCTransport) GetICEConnectionType() types.ICEConnectionType {
 		return unknown
 	}
 
-	if p.Remote.Typ == webrtc.ICECandidateTypeRelay {
+	if t.getRemoteCandidateType(p) == webrtc.ICECandidateTypeRelay {
 		return types.ICEConnectionTypeTURN
-	} else if p.Remote.Typ == webrtc.ICECandidateTypePrflx {
+	}
+	if p.Remote.Protocol == webrtc.ICEProtocolTCP {
+		return types.ICEConnectionTypeTCP
+	}
+	return types.ICEConnectionTypeUDP
+}
+
+func (t *PCTransport) getRemoteCandidateType(p *webrtc.ICECandidatePair) webrtc.ICECandidateType {
+	if p.Remote.Typ == webrtc.ICECandidateTypePrflx {
 		// if the remote relay candidate pings us *before* we get a relay candidate,
 		// Pion would have created a prflx candidate with the same address as the relay candidate.
 		// to report an accurate connection type, we'll compare to see if existing relay candidates match
@@ -1217,15 +1254,26 @@ func (t *PCTransport) GetICEConnectionType() types.ICEConnectionType {
 				if p.Remote.Address == candidate.Address() &&
 					p.Remote.Port == uint16(candidate.Port()) &&
 					p.Remote.Protocol.String() == candidate.NetworkType().NetworkShort() {
-					return types.ICEConnectionTypeTURN
+					return webrtc.ICECandidateTypeRelay
 				}
 			}
 		}
 	}
-	if p.Remote.Protocol == webrtc.ICEProtocolTCP {
-		return types.ICEConnectionTypeTCP
+	return p.Remote.Typ
+}
+
+func (t *PCTransport) recordSelectedCandidatePair() {
+	transport := "publisher"
+	if t.params.IsOfferer {
+		transport = "subscriber"
 	}
-	return types.ICEConnectionTypeUDP
+
+	p, err := t.getSelectedPair()
+	if err != nil || p == nil {
+		prometheus.IncrementICEConnection(transport, string(types.ICEConnectionTypeUnknown), string(types.ICEConnectionTypeUnknown))
+		return
+	}
+	prometheus.IncrementICEConnection(transport, t.getRemoteCandidateType(p).String(), p.Remote.Protocol.String())
 }
 
 func (t *PCTransport) preparePC(previousAnswer webrtc.SessionDescription) error {
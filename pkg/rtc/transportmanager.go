@@ -329,6 +329,10 @@ func (t *TransportManager) RemoveSubscribedTrack(subTrack types.SubscribedTrack)
 	t.subscriber.RemoveTrackFromStreamAllocator(subTrack)
 }
 
+func (t *TransportManager) SetSubscribedTrackPinned(subTrack types.SubscribedTrack, pinned bool) {
+	t.subscriber.SetTrackPinnedInStreamAllocator(subTrack, pinned)
+}
+
 func (t *TransportManager) OnDataMessage(f func(kind livekit.DataPacket_Kind, data []byte)) {
 	// upstream data is always comes in via publisher peer connection irrespective of which is primary
 	t.publisher.OnDataPacket(f)
@@ -585,6 +589,10 @@ func (t *TransportManager) GetICEConnectionType() types.ICEConnectionType {
 	return t.getTransport(true).GetICEConnectionType()
 }
 
+func (t *TransportManager) GetSelectedICECandidatePair() *webrtc.ICECandidatePair {
+	return t.getTransport(true).GetSelectedICECandidatePair()
+}
+
 func (t *TransportManager) getTransport(isPrimary bool) *PCTransport {
 	pcTransport := t.publisher
 	if (isPrimary && t.params.SubscriberAsPrimary) || (!isPrimary && !t.params.SubscriberAsPrimary) {
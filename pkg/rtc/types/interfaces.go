@@ -78,6 +78,36 @@ type SubscribedCodecQuality struct {
 
 // ---------------------------------------------
 
+type RoomCloseReason int
+
+const (
+	RoomCloseReasonUnknown RoomCloseReason = iota
+	RoomCloseReasonEmpty
+	RoomCloseReasonRoomManagerStop
+	RoomCloseReasonServiceRequestDeleteRoom
+	RoomCloseReasonExpired
+	RoomCloseReasonParentClosed
+)
+
+func (r RoomCloseReason) String() string {
+	switch r {
+	case RoomCloseReasonEmpty:
+		return "EMPTY"
+	case RoomCloseReasonRoomManagerStop:
+		return "ROOM_MANAGER_STOP"
+	case RoomCloseReasonServiceRequestDeleteRoom:
+		return "SERVICE_REQUEST_DELETE_ROOM"
+	case RoomCloseReasonExpired:
+		return "EXPIRED"
+	case RoomCloseReasonParentClosed:
+		return "PARENT_CLOSED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ---------------------------------------------
+
 type ParticipantCloseReason int
 
 const (
@@ -103,6 +133,9 @@ const (
 	ParticipantCloseReasonPublicationError
 	ParticipantCloseReasonSubscriptionError
 	ParticipantCloseReasonDataChannelError
+	ParticipantCloseReasonMediaFlood
+	ParticipantCloseReasonSRTPKeyRotation
+	ParticipantCloseReasonSessionLimit
 )
 
 func (p ParticipantCloseReason) String() string {
@@ -151,6 +184,12 @@ func (p ParticipantCloseReason) String() string {
 		return "SUBSCRIPTION_ERROR"
 	case ParticipantCloseReasonDataChannelError:
 		return "DATA_CHANNEL_ERROR"
+	case ParticipantCloseReasonMediaFlood:
+		return "MEDIA_FLOOD"
+	case ParticipantCloseReasonSRTPKeyRotation:
+		return "SRTP_KEY_ROTATION"
+	case ParticipantCloseReasonSessionLimit:
+		return "SESSION_LIMIT"
 	default:
 		return fmt.Sprintf("%d", int(p))
 	}
@@ -169,7 +208,7 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 		return livekit.DisconnectReason_STATE_MISMATCH
 	case ParticipantCloseReasonDuplicateIdentity, ParticipantCloseReasonMigrationComplete, ParticipantCloseReasonStale:
 		return livekit.DisconnectReason_DUPLICATE_IDENTITY
-	case ParticipantCloseReasonServiceRequestRemoveParticipant:
+	case ParticipantCloseReasonServiceRequestRemoveParticipant, ParticipantCloseReasonMediaFlood, ParticipantCloseReasonSessionLimit:
 		return livekit.DisconnectReason_PARTICIPANT_REMOVED
 	case ParticipantCloseReasonServiceRequestDeleteRoom:
 		return livekit.DisconnectReason_ROOM_DELETED
@@ -203,6 +242,8 @@ const (
 	SignallingCloseReasonFullReconnectDataChannelError
 	SignallingCloseReasonFullReconnectNegotiateFailed
 	SignallingCloseReasonParticipantClose
+	SignallingCloseReasonICERestart
+	SignallingCloseReasonFullReconnectSRTPKeyRotation
 )
 
 func (s SignallingCloseReason) String() string {
@@ -225,6 +266,10 @@ func (s SignallingCloseReason) String() string {
 		return "FULL_RECONNECT_NEGOTIATE_FAILED"
 	case SignallingCloseReasonParticipantClose:
 		return "PARTICIPANT_CLOSE"
+	case SignallingCloseReasonICERestart:
+		return "ICE_RESTART"
+	case SignallingCloseReasonFullReconnectSRTPKeyRotation:
+		return "FULL_RECONNECT_SRTP_KEY_ROTATION"
 	default:
 		return fmt.Sprintf("%d", int(s))
 	}
@@ -304,6 +349,9 @@ type LocalParticipant interface {
 	GetAdaptiveStream() bool
 	ProtocolVersion() ProtocolVersion
 	SupportsSyncStreamID() bool
+	// SupportsTokenRefresh - if client presents refreshed tokens over the signal connection, rather than relying on
+	// the server to refresh them
+	SupportsTokenRefresh() bool
 	SupportsTransceiverReuse() bool
 	ConnectedAt() time.Time
 	IsClosed() bool
@@ -314,6 +362,7 @@ type LocalParticipant interface {
 	GetClientInfo() *livekit.ClientInfo
 	GetClientConfiguration() *livekit.ClientConfiguration
 	GetICEConnectionType() ICEConnectionType
+	GetSelectedICECandidatePair() *webrtc.ICECandidatePair
 	GetBufferFactory() *buffer.Factory
 	GetPlayoutDelayConfig() *livekit.PlayoutDelay
 	GetPendingTrack(trackID livekit.TrackID) *livekit.TrackInfo
@@ -327,6 +376,9 @@ type LocalParticipant interface {
 	// permissions
 	ClaimGrants() *auth.ClaimGrants
 	SetPermission(permission *livekit.ParticipantPermission) bool
+	MoveToRoom(roomName livekit.RoomName)
+	// SetE2EERequired rejects publishing tracks that are not end-to-end encrypted, set by the room of the participant
+	SetE2EERequired(required bool)
 	CanPublishSource(source livekit.TrackSource) bool
 	CanSubscribe() bool
 	CanPublishData() bool
@@ -348,6 +400,8 @@ type LocalParticipant interface {
 	SubscribeToTrack(trackID livekit.TrackID)
 	UnsubscribeFromTrack(trackID livekit.TrackID)
 	UpdateSubscribedTrackSettings(trackID livekit.TrackID, settings *livekit.UpdateTrackSettings)
+	// pins the quality of a subscribed video track outside of stream allocation, nil removes the pin
+	PinSubscribedQuality(trackID livekit.TrackID, quality *livekit.VideoQuality)
 	GetSubscribedTracks() []SubscribedTrack
 	VerifySubscribeParticipantInfo(pID livekit.ParticipantID, version uint32)
 	// WaitUntilSubscribed waits until all subscriptions have been settled, or if the timeout
@@ -518,6 +572,8 @@ type SubscribedTrack interface {
 	IsMuted() bool
 	SetPublisherMuted(muted bool)
 	UpdateSubscriberSettings(settings *livekit.UpdateTrackSettings)
+	PinQuality(quality *livekit.VideoQuality)
+	PinnedQuality() *livekit.VideoQuality
 	// selects appropriate video layer according to subscriber preferences
 	UpdateVideoLayer()
 	NeedsNegotiation() bool
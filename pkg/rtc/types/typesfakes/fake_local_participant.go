@@ -305,6 +305,16 @@ type FakeLocalParticipant struct {
 	getPublishedTracksReturnsOnCall map[int]struct {
 		result1 []types.MediaTrack
 	}
+	GetSelectedICECandidatePairStub        func() *webrtc.ICECandidatePair
+	getSelectedICECandidatePairMutex       sync.RWMutex
+	getSelectedICECandidatePairArgsForCall []struct {
+	}
+	getSelectedICECandidatePairReturns struct {
+		result1 *webrtc.ICECandidatePair
+	}
+	getSelectedICECandidatePairReturnsOnCall map[int]struct {
+		result1 *webrtc.ICECandidatePair
+	}
 	GetSubscribedParticipantsStub        func() []livekit.ParticipantID
 	getSubscribedParticipantsMutex       sync.RWMutex
 	getSubscribedParticipantsArgsForCall []struct {
@@ -506,6 +516,11 @@ type FakeLocalParticipant struct {
 	migrateStateReturnsOnCall map[int]struct {
 		result1 types.MigrateState
 	}
+	MoveToRoomStub        func(livekit.RoomName)
+	moveToRoomMutex       sync.RWMutex
+	moveToRoomArgsForCall []struct {
+		arg1 livekit.RoomName
+	}
 	NegotiateStub        func(bool)
 	negotiateMutex       sync.RWMutex
 	negotiateArgsForCall []struct {
@@ -572,6 +587,12 @@ type FakeLocalParticipant struct {
 	onTrackUpdatedArgsForCall []struct {
 		arg1 func(types.LocalParticipant, types.MediaTrack)
 	}
+	PinSubscribedQualityStub        func(livekit.TrackID, *livekit.VideoQuality)
+	pinSubscribedQualityMutex       sync.RWMutex
+	pinSubscribedQualityArgsForCall []struct {
+		arg1 livekit.TrackID
+		arg2 *livekit.VideoQuality
+	}
 	ProtocolVersionStub        func() types.ProtocolVersion
 	protocolVersionMutex       sync.RWMutex
 	protocolVersionArgsForCall []struct {
@@ -679,6 +700,11 @@ type FakeLocalParticipant struct {
 	sendSpeakerUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetE2EERequiredStub        func(bool)
+	setE2EERequiredMutex       sync.RWMutex
+	setE2EERequiredArgsForCall []struct {
+		arg1 bool
+	}
 	SetICEConfigStub        func(*livekit.ICEConfig)
 	setICEConfigMutex       sync.RWMutex
 	setICEConfigArgsForCall []struct {
@@ -803,6 +829,16 @@ type FakeLocalParticipant struct {
 	supportsSyncStreamIDReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	SupportsTokenRefreshStub        func() bool
+	supportsTokenRefreshMutex       sync.RWMutex
+	supportsTokenRefreshArgsForCall []struct {
+	}
+	supportsTokenRefreshReturns struct {
+		result1 bool
+	}
+	supportsTokenRefreshReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	SupportsTransceiverReuseStub        func() bool
 	supportsTransceiverReuseMutex       sync.RWMutex
 	supportsTransceiverReuseArgsForCall []struct {
@@ -2418,6 +2454,59 @@ func (fake *FakeLocalParticipant) GetPublishedTracksReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetSelectedICECandidatePair() *webrtc.ICECandidatePair {
+	fake.getSelectedICECandidatePairMutex.Lock()
+	ret, specificReturn := fake.getSelectedICECandidatePairReturnsOnCall[len(fake.getSelectedICECandidatePairArgsForCall)]
+	fake.getSelectedICECandidatePairArgsForCall = append(fake.getSelectedICECandidatePairArgsForCall, struct {
+	}{})
+	stub := fake.GetSelectedICECandidatePairStub
+	fakeReturns := fake.getSelectedICECandidatePairReturns
+	fake.recordInvocation("GetSelectedICECandidatePair", []interface{}{})
+	fake.getSelectedICECandidatePairMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetSelectedICECandidatePairCallCount() int {
+	fake.getSelectedICECandidatePairMutex.RLock()
+	defer fake.getSelectedICECandidatePairMutex.RUnlock()
+	return len(fake.getSelectedICECandidatePairArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetSelectedICECandidatePairCalls(stub func() *webrtc.ICECandidatePair) {
+	fake.getSelectedICECandidatePairMutex.Lock()
+	defer fake.getSelectedICECandidatePairMutex.Unlock()
+	fake.GetSelectedICECandidatePairStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetSelectedICECandidatePairReturns(result1 *webrtc.ICECandidatePair) {
+	fake.getSelectedICECandidatePairMutex.Lock()
+	defer fake.getSelectedICECandidatePairMutex.Unlock()
+	fake.GetSelectedICECandidatePairStub = nil
+	fake.getSelectedICECandidatePairReturns = struct {
+		result1 *webrtc.ICECandidatePair
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSelectedICECandidatePairReturnsOnCall(i int, result1 *webrtc.ICECandidatePair) {
+	fake.getSelectedICECandidatePairMutex.Lock()
+	defer fake.getSelectedICECandidatePairMutex.Unlock()
+	fake.GetSelectedICECandidatePairStub = nil
+	if fake.getSelectedICECandidatePairReturnsOnCall == nil {
+		fake.getSelectedICECandidatePairReturnsOnCall = make(map[int]struct {
+			result1 *webrtc.ICECandidatePair
+		})
+	}
+	fake.getSelectedICECandidatePairReturnsOnCall[i] = struct {
+		result1 *webrtc.ICECandidatePair
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetSubscribedParticipants() []livekit.ParticipantID {
 	fake.getSubscribedParticipantsMutex.Lock()
 	ret, specificReturn := fake.getSubscribedParticipantsReturnsOnCall[len(fake.getSubscribedParticipantsArgsForCall)]
@@ -3506,6 +3595,38 @@ func (fake *FakeLocalParticipant) MigrateStateReturnsOnCall(i int, result1 types
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) MoveToRoom(arg1 livekit.RoomName) {
+	fake.moveToRoomMutex.Lock()
+	fake.moveToRoomArgsForCall = append(fake.moveToRoomArgsForCall, struct {
+		arg1 livekit.RoomName
+	}{arg1})
+	stub := fake.MoveToRoomStub
+	fake.recordInvocation("MoveToRoom", []interface{}{arg1})
+	fake.moveToRoomMutex.Unlock()
+	if stub != nil {
+		fake.MoveToRoomStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCallCount() int {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	return len(fake.moveToRoomArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCalls(stub func(livekit.RoomName)) {
+	fake.moveToRoomMutex.Lock()
+	defer fake.moveToRoomMutex.Unlock()
+	fake.MoveToRoomStub = stub
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomArgsForCall(i int) livekit.RoomName {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	argsForCall := fake.moveToRoomArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) Negotiate(arg1 bool) {
 	fake.negotiateMutex.Lock()
 	fake.negotiateArgsForCall = append(fake.negotiateArgsForCall, struct {
@@ -3923,6 +4044,39 @@ func (fake *FakeLocalParticipant) OnTrackUpdatedArgsForCall(i int) func(types.Lo
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) PinSubscribedQuality(arg1 livekit.TrackID, arg2 *livekit.VideoQuality) {
+	fake.pinSubscribedQualityMutex.Lock()
+	fake.pinSubscribedQualityArgsForCall = append(fake.pinSubscribedQualityArgsForCall, struct {
+		arg1 livekit.TrackID
+		arg2 *livekit.VideoQuality
+	}{arg1, arg2})
+	stub := fake.PinSubscribedQualityStub
+	fake.recordInvocation("PinSubscribedQuality", []interface{}{arg1, arg2})
+	fake.pinSubscribedQualityMutex.Unlock()
+	if stub != nil {
+		fake.PinSubscribedQualityStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLocalParticipant) PinSubscribedQualityCallCount() int {
+	fake.pinSubscribedQualityMutex.RLock()
+	defer fake.pinSubscribedQualityMutex.RUnlock()
+	return len(fake.pinSubscribedQualityArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) PinSubscribedQualityCalls(stub func(livekit.TrackID, *livekit.VideoQuality)) {
+	fake.pinSubscribedQualityMutex.Lock()
+	defer fake.pinSubscribedQualityMutex.Unlock()
+	fake.PinSubscribedQualityStub = stub
+}
+
+func (fake *FakeLocalParticipant) PinSubscribedQualityArgsForCall(i int) (livekit.TrackID, *livekit.VideoQuality) {
+	fake.pinSubscribedQualityMutex.RLock()
+	defer fake.pinSubscribedQualityMutex.RUnlock()
+	argsForCall := fake.pinSubscribedQualityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeLocalParticipant) ProtocolVersion() types.ProtocolVersion {
 	fake.protocolVersionMutex.Lock()
 	ret, specificReturn := fake.protocolVersionReturnsOnCall[len(fake.protocolVersionArgsForCall)]
@@ -4515,6 +4669,38 @@ func (fake *FakeLocalParticipant) SendSpeakerUpdateReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SetE2EERequired(arg1 bool) {
+	fake.setE2EERequiredMutex.Lock()
+	fake.setE2EERequiredArgsForCall = append(fake.setE2EERequiredArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.SetE2EERequiredStub
+	fake.recordInvocation("SetE2EERequired", []interface{}{arg1})
+	fake.setE2EERequiredMutex.Unlock()
+	if stub != nil {
+		fake.SetE2EERequiredStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetE2EERequiredCallCount() int {
+	fake.setE2EERequiredMutex.RLock()
+	defer fake.setE2EERequiredMutex.RUnlock()
+	return len(fake.setE2EERequiredArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetE2EERequiredCalls(stub func(bool)) {
+	fake.setE2EERequiredMutex.Lock()
+	defer fake.setE2EERequiredMutex.Unlock()
+	fake.SetE2EERequiredStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetE2EERequiredArgsForCall(i int) bool {
+	fake.setE2EERequiredMutex.RLock()
+	defer fake.setE2EERequiredMutex.RUnlock()
+	argsForCall := fake.setE2EERequiredArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetICEConfig(arg1 *livekit.ICEConfig) {
 	fake.setICEConfigMutex.Lock()
 	fake.setICEConfigArgsForCall = append(fake.setICEConfigArgsForCall, struct {
@@ -5216,6 +5402,59 @@ func (fake *FakeLocalParticipant) SupportsSyncStreamIDReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SupportsTokenRefresh() bool {
+	fake.supportsTokenRefreshMutex.Lock()
+	ret, specificReturn := fake.supportsTokenRefreshReturnsOnCall[len(fake.supportsTokenRefreshArgsForCall)]
+	fake.supportsTokenRefreshArgsForCall = append(fake.supportsTokenRefreshArgsForCall, struct {
+	}{})
+	stub := fake.SupportsTokenRefreshStub
+	fakeReturns := fake.supportsTokenRefreshReturns
+	fake.recordInvocation("SupportsTokenRefresh", []interface{}{})
+	fake.supportsTokenRefreshMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) SupportsTokenRefreshCallCount() int {
+	fake.supportsTokenRefreshMutex.RLock()
+	defer fake.supportsTokenRefreshMutex.RUnlock()
+	return len(fake.supportsTokenRefreshArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SupportsTokenRefreshCalls(stub func() bool) {
+	fake.supportsTokenRefreshMutex.Lock()
+	defer fake.supportsTokenRefreshMutex.Unlock()
+	fake.SupportsTokenRefreshStub = stub
+}
+
+func (fake *FakeLocalParticipant) SupportsTokenRefreshReturns(result1 bool) {
+	fake.supportsTokenRefreshMutex.Lock()
+	defer fake.supportsTokenRefreshMutex.Unlock()
+	fake.SupportsTokenRefreshStub = nil
+	fake.supportsTokenRefreshReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) SupportsTokenRefreshReturnsOnCall(i int, result1 bool) {
+	fake.supportsTokenRefreshMutex.Lock()
+	defer fake.supportsTokenRefreshMutex.Unlock()
+	fake.SupportsTokenRefreshStub = nil
+	if fake.supportsTokenRefreshReturnsOnCall == nil {
+		fake.supportsTokenRefreshReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.supportsTokenRefreshReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) SupportsTransceiverReuse() bool {
 	fake.supportsTransceiverReuseMutex.Lock()
 	ret, specificReturn := fake.supportsTransceiverReuseReturnsOnCall[len(fake.supportsTransceiverReuseArgsForCall)]
@@ -5972,6 +6211,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getPublishedTrackMutex.RUnlock()
 	fake.getPublishedTracksMutex.RLock()
 	defer fake.getPublishedTracksMutex.RUnlock()
+	fake.getSelectedICECandidatePairMutex.RLock()
+	defer fake.getSelectedICECandidatePairMutex.RUnlock()
 	fake.getSubscribedParticipantsMutex.RLock()
 	defer fake.getSubscribedParticipantsMutex.RUnlock()
 	fake.getSubscribedTracksMutex.RLock()
@@ -6016,6 +6257,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.maybeStartMigrationMutex.RUnlock()
 	fake.migrateStateMutex.RLock()
 	defer fake.migrateStateMutex.RUnlock()
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
 	fake.negotiateMutex.RLock()
 	defer fake.negotiateMutex.RUnlock()
 	fake.onClaimsChangedMutex.RLock()
@@ -6042,6 +6285,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.onTrackUnpublishedMutex.RUnlock()
 	fake.onTrackUpdatedMutex.RLock()
 	defer fake.onTrackUpdatedMutex.RUnlock()
+	fake.pinSubscribedQualityMutex.RLock()
+	defer fake.pinSubscribedQualityMutex.RUnlock()
 	fake.protocolVersionMutex.RLock()
 	defer fake.protocolVersionMutex.RUnlock()
 	fake.removePublishedTrackMutex.RLock()
@@ -6062,6 +6307,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.sendRoomUpdateMutex.RUnlock()
 	fake.sendSpeakerUpdateMutex.RLock()
 	defer fake.sendSpeakerUpdateMutex.RUnlock()
+	fake.setE2EERequiredMutex.RLock()
+	defer fake.setE2EERequiredMutex.RUnlock()
 	fake.setICEConfigMutex.RLock()
 	defer fake.setICEConfigMutex.RUnlock()
 	fake.setMetadataMutex.RLock()
@@ -6098,6 +6345,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.subscriptionPermissionUpdateMutex.RUnlock()
 	fake.supportsSyncStreamIDMutex.RLock()
 	defer fake.supportsSyncStreamIDMutex.RUnlock()
+	fake.supportsTokenRefreshMutex.RLock()
+	defer fake.supportsTokenRefreshMutex.RUnlock()
 	fake.supportsTransceiverReuseMutex.RLock()
 	defer fake.supportsTransceiverReuseMutex.RUnlock()
 	fake.toProtoMutex.RLock()
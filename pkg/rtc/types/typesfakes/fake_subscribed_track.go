@@ -86,6 +86,21 @@ type FakeSubscribedTrack struct {
 	onCloseArgsForCall []struct {
 		arg1 func(willBeResumed bool)
 	}
+	PinQualityStub        func(*livekit.VideoQuality)
+	pinQualityMutex       sync.RWMutex
+	pinQualityArgsForCall []struct {
+		arg1 *livekit.VideoQuality
+	}
+	PinnedQualityStub        func() *livekit.VideoQuality
+	pinnedQualityMutex       sync.RWMutex
+	pinnedQualityArgsForCall []struct {
+	}
+	pinnedQualityReturns struct {
+		result1 *livekit.VideoQuality
+	}
+	pinnedQualityReturnsOnCall map[int]struct {
+		result1 *livekit.VideoQuality
+	}
 	PublisherIDStub        func() livekit.ParticipantID
 	publisherIDMutex       sync.RWMutex
 	publisherIDArgsForCall []struct {
@@ -588,6 +603,91 @@ func (fake *FakeSubscribedTrack) OnCloseArgsForCall(i int) func(willBeResumed bo
 	return argsForCall.arg1
 }
 
+func (fake *FakeSubscribedTrack) PinQuality(arg1 *livekit.VideoQuality) {
+	fake.pinQualityMutex.Lock()
+	fake.pinQualityArgsForCall = append(fake.pinQualityArgsForCall, struct {
+		arg1 *livekit.VideoQuality
+	}{arg1})
+	stub := fake.PinQualityStub
+	fake.recordInvocation("PinQuality", []interface{}{arg1})
+	fake.pinQualityMutex.Unlock()
+	if stub != nil {
+		fake.PinQualityStub(arg1)
+	}
+}
+
+func (fake *FakeSubscribedTrack) PinQualityCallCount() int {
+	fake.pinQualityMutex.RLock()
+	defer fake.pinQualityMutex.RUnlock()
+	return len(fake.pinQualityArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) PinQualityCalls(stub func(*livekit.VideoQuality)) {
+	fake.pinQualityMutex.Lock()
+	defer fake.pinQualityMutex.Unlock()
+	fake.PinQualityStub = stub
+}
+
+func (fake *FakeSubscribedTrack) PinQualityArgsForCall(i int) *livekit.VideoQuality {
+	fake.pinQualityMutex.RLock()
+	defer fake.pinQualityMutex.RUnlock()
+	argsForCall := fake.pinQualityArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSubscribedTrack) PinnedQuality() *livekit.VideoQuality {
+	fake.pinnedQualityMutex.Lock()
+	ret, specificReturn := fake.pinnedQualityReturnsOnCall[len(fake.pinnedQualityArgsForCall)]
+	fake.pinnedQualityArgsForCall = append(fake.pinnedQualityArgsForCall, struct {
+	}{})
+	stub := fake.PinnedQualityStub
+	fakeReturns := fake.pinnedQualityReturns
+	fake.recordInvocation("PinnedQuality", []interface{}{})
+	fake.pinnedQualityMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) PinnedQualityCallCount() int {
+	fake.pinnedQualityMutex.RLock()
+	defer fake.pinnedQualityMutex.RUnlock()
+	return len(fake.pinnedQualityArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) PinnedQualityCalls(stub func() *livekit.VideoQuality) {
+	fake.pinnedQualityMutex.Lock()
+	defer fake.pinnedQualityMutex.Unlock()
+	fake.PinnedQualityStub = stub
+}
+
+func (fake *FakeSubscribedTrack) PinnedQualityReturns(result1 *livekit.VideoQuality) {
+	fake.pinnedQualityMutex.Lock()
+	defer fake.pinnedQualityMutex.Unlock()
+	fake.PinnedQualityStub = nil
+	fake.pinnedQualityReturns = struct {
+		result1 *livekit.VideoQuality
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) PinnedQualityReturnsOnCall(i int, result1 *livekit.VideoQuality) {
+	fake.pinnedQualityMutex.Lock()
+	defer fake.pinnedQualityMutex.Unlock()
+	fake.PinnedQualityStub = nil
+	if fake.pinnedQualityReturnsOnCall == nil {
+		fake.pinnedQualityReturnsOnCall = make(map[int]struct {
+			result1 *livekit.VideoQuality
+		})
+	}
+	fake.pinnedQualityReturnsOnCall[i] = struct {
+		result1 *livekit.VideoQuality
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) PublisherID() livekit.ParticipantID {
 	fake.publisherIDMutex.Lock()
 	ret, specificReturn := fake.publisherIDReturnsOnCall[len(fake.publisherIDArgsForCall)]
@@ -1068,6 +1168,10 @@ func (fake *FakeSubscribedTrack) Invocations() map[string][][]interface{} {
 	defer fake.needsNegotiationMutex.RUnlock()
 	fake.onCloseMutex.RLock()
 	defer fake.onCloseMutex.RUnlock()
+	fake.pinQualityMutex.RLock()
+	defer fake.pinQualityMutex.RUnlock()
+	fake.pinnedQualityMutex.RLock()
+	defer fake.pinnedQualityMutex.RUnlock()
 	fake.publisherIDMutex.RLock()
 	defer fake.publisherIDMutex.RUnlock()
 	fake.publisherIdentityMutex.RLock()
@@ -0,0 +1,161 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// scopeRequests are the requests a scope allows, requests that are not listed are denied
+type scopeRequests struct {
+	// Twirp and gRPC methods, by service and method. REST gateway requests are matched by the method they serve
+	rpcs map[string]bool
+	// HTTP APIs and their sub paths, by the HTTP methods allowed
+	paths map[string][]string
+}
+
+var allHTTPMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+var readMethods = []string{http.MethodGet, http.MethodHead}
+
+var scopeAllowlists = map[config.APIKeyScope]scopeRequests{
+	config.APIKeyScopeRoomAdmin: {
+		rpcs: map[string]bool{
+			"livekit.RoomService/CreateRoom":                 true,
+			"livekit.RoomService/ListRooms":                  true,
+			"livekit.RoomService/DeleteRoom":                 true,
+			"livekit.RoomService/ListParticipants":           true,
+			"livekit.RoomService/GetParticipant":             true,
+			"livekit.RoomService/RemoveParticipant":          true,
+			"livekit.RoomService/MutePublishedTrack":         true,
+			"livekit.RoomService/UpdateParticipant":          true,
+			"livekit.RoomService/UpdateSubscriptions":        true,
+			"livekit.RoomService/SendData":                   true,
+			"livekit.RoomService/UpdateRoomMetadata":         true,
+			"livekit.server.roomevents.RoomEvents/Subscribe": true,
+		},
+		paths: map[string][]string{
+			"/rtc":                    allHTTPMethods,
+			"/room_history":           allHTTPMethods,
+			"/room_templates":         allHTTPMethods,
+			"/room_batch":             allHTTPMethods,
+			"/provision_rooms":        allHTTPMethods,
+			"/move_participant":       allHTTPMethods,
+			"/breakout_rooms":         allHTTPMethods,
+			"/remove_participants":    allHTTPMethods,
+			"/pin_subscribed_quality": allHTTPMethods,
+			"/restart_ice":            allHTTPMethods,
+			"/relay_tracks":           allHTTPMethods,
+			"/mute_room":              allHTTPMethods,
+			"/lock_room":              allHTTPMethods,
+			"/participants":           allHTTPMethods,
+			"/participant_stats":      allHTTPMethods,
+			"/events":                 allHTTPMethods,
+			restOpenAPIPath:           readMethods,
+		},
+	},
+	config.APIKeyScopeEgressOnly: {
+		rpcs: map[string]bool{
+			"livekit.Egress/StartRoomCompositeEgress":  true,
+			"livekit.Egress/StartWebEgress":            true,
+			"livekit.Egress/StartParticipantEgress":    true,
+			"livekit.Egress/StartTrackCompositeEgress": true,
+			"livekit.Egress/StartTrackEgress":          true,
+			"livekit.Egress/UpdateLayout":              true,
+			"livekit.Egress/UpdateStream":              true,
+			"livekit.Egress/ListEgress":                true,
+			"livekit.Egress/StopEgress":                true,
+		},
+	},
+	config.APIKeyScopeIngestOnly: {
+		rpcs: map[string]bool{
+			"livekit.Ingress/CreateIngress": true,
+			"livekit.Ingress/UpdateIngress": true,
+			"livekit.Ingress/ListIngress":   true,
+			"livekit.Ingress/DeleteIngress": true,
+		},
+	},
+	config.APIKeyScopeReadOnly: {
+		rpcs: map[string]bool{
+			"livekit.RoomService/ListRooms":                  true,
+			"livekit.RoomService/ListParticipants":           true,
+			"livekit.RoomService/GetParticipant":             true,
+			"livekit.Egress/ListEgress":                      true,
+			"livekit.Ingress/ListIngress":                    true,
+			"livekit.server.roomevents.RoomEvents/Subscribe": true,
+		},
+		paths: map[string][]string{
+			"/room_history":      readMethods,
+			"/room_templates":    readMethods,
+			"/breakout_rooms":    readMethods,
+			"/participants":      readMethods,
+			"/participant_stats": readMethods,
+			"/events":            readMethods,
+			restOpenAPIPath:      readMethods,
+		},
+	},
+}
+
+// scopesAllow returns whether any of the scopes allows requests of the method to the path, gRPC requests are
+// matched by the path of their Twirp method. keys without scopes are allowed all requests
+func scopesAllow(scopes []config.APIKeyScope, method string, urlPath string) bool {
+	if scopes == nil {
+		return true
+	}
+	for _, scope := range scopes {
+		if scopeAllows(scope, method, urlPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeAllows(scope config.APIKeyScope, method string, urlPath string) bool {
+	if scope == config.APIKeyScopeAdmin {
+		return true
+	}
+	allowed, ok := scopeAllowlists[scope]
+	if !ok {
+		return false
+	}
+	if rpc, ok := strings.CutPrefix(urlPath, twirpPathPrefix); ok {
+		return allowed.rpcs[rpc]
+	}
+	if strings.HasPrefix(urlPath, restGatewayPrefix) && urlPath != restOpenAPIPath {
+		rpc, ok := restRouteRPC(method, urlPath)
+		return ok && allowed.rpcs["livekit.RoomService/"+rpc]
+	}
+	for apiPath, methods := range allowed.paths {
+		if isPathOf(urlPath, apiPath) {
+			for _, m := range methods {
+				if m == method {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// isPathOf returns whether the path is the API path or one of its sub paths
+func isPathOf(urlPath string, apiPath string) bool {
+	return urlPath == apiPath || strings.HasPrefix(urlPath, apiPath+"/")
+}
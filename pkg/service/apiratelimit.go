@@ -0,0 +1,155 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	twirpPathPrefix = "/twirp/"
+
+	// requests of RPCs without their own limit share the default limit of the API key
+	defaultRateLimit = "default"
+)
+
+type rateLimitBucketKey struct {
+	apiKey string
+	limit  string
+}
+
+// rate limiting middleware, it follows the authentication middleware and limits requests by the API key of their
+// token. requests without a token are left to the services to reject
+type APIRateLimitMiddleware struct {
+	config config.APIRateLimitConfig
+
+	lock    sync.Mutex
+	buckets map[rateLimitBucketKey]*tokenBucket
+}
+
+func NewAPIRateLimitMiddleware(conf config.APIRateLimitConfig) *APIRateLimitMiddleware {
+	return &APIRateLimitMiddleware{
+		config:  conf,
+		buckets: make(map[rateLimitBucketKey]*tokenBucket),
+	}
+}
+
+func (m *APIRateLimitMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	apiKey, ok := GetAPIKey(r.Context())
+	if !ok || r.URL == nil || r.URL.Path == "/rtc" || strings.HasPrefix(r.URL.Path, "/rtc/") {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	rpc := rpcName(r.Method, r.URL.Path)
+	if retryAfter, ok := m.allow(apiKey, rpc, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		if strings.HasPrefix(r.URL.Path, twirpPathPrefix) || strings.HasPrefix(r.URL.Path, restGatewayPrefix) {
+			logger.Infow("API rate limit exceeded", "apiKey", apiKey, "rpc", rpc)
+			_ = twirp.WriteError(w, twirp.NewError(twirp.ResourceExhausted, ErrRateLimited.Error()))
+		} else {
+			handleError(w, http.StatusTooManyRequests, ErrRateLimited, "apiKey", apiKey, "rpc", rpc)
+		}
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// allow takes a request from the bucket of the API key and RPC, or returns how long until a request is allowed
+func (m *APIRateLimitMiddleware) allow(apiKey string, rpc string, now time.Time) (time.Duration, bool) {
+	limit, name := m.limitFor(apiKey, rpc)
+	if limit.RequestsPerSec <= 0 {
+		return 0, true
+	}
+
+	m.lock.Lock()
+	key := rateLimitBucketKey{apiKey: apiKey, limit: name}
+	bucket := m.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{}
+		m.buckets[key] = bucket
+	}
+	retryAfter, ok := bucket.take(limit, now)
+	m.lock.Unlock()
+
+	prometheus.RecordAPIRateLimit(apiKey, name, ok)
+	return retryAfter, ok
+}
+
+// limitFor returns the limit of the request and the name of its bucket, RPCs with their own limit have their own
+// bucket for each API key
+func (m *APIRateLimitMiddleware) limitFor(apiKey string, rpc string) (config.RateLimit, string) {
+	if limit, ok := m.config.RPCs[rpc]; ok {
+		return limit, rpc
+	}
+	if limit, ok := m.config.Keys[apiKey]; ok {
+		return limit, defaultRateLimit
+	}
+	return config.RateLimit{RequestsPerSec: m.config.RequestsPerSec, Burst: m.config.Burst}, defaultRateLimit
+}
+
+// rpcName returns the Twirp method of the request, i.e. CreateRoom, or the path of other APIs
+func rpcName(method string, urlPath string) string {
+	if strings.HasPrefix(urlPath, twirpPathPrefix) {
+		return path.Base(urlPath)
+	}
+	if strings.HasPrefix(urlPath, restGatewayPrefix) {
+		for _, route := range restRoutes {
+			if _, ok := route.match(method, urlPath); ok {
+				return route.rpc
+			}
+		}
+	}
+	return urlPath
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take removes a token from the bucket, or returns how long until a token is available. the bucket starts full
+func (b *tokenBucket) take(limit config.RateLimit, now time.Time) (time.Duration, bool) {
+	burst := float64(limit.Burst)
+	if burst == 0 {
+		burst = math.Max(limit.RequestsPerSec, 1)
+	}
+	if b.updated.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*limit.RequestsPerSec)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / limit.RequestsPerSec * float64(time.Second)), false
+}
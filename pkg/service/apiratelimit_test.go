@@ -0,0 +1,92 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestAPIRateLimitMiddleware(t *testing.T) {
+	m := NewAPIRateLimitMiddleware(config.APIRateLimitConfig{
+		RequestsPerSec: 0.001,
+		Burst:          2,
+		RPCs:           map[string]config.RateLimit{"CreateRoom": {RequestsPerSec: 0.001, Burst: 1}},
+		Keys:           map[string]config.RateLimit{"unlimited": {}},
+	})
+	serve := func(apiKey string, method string, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if apiKey != "" {
+			r = r.WithContext(WithAPIKey(context.Background(), apiKey))
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, serve("key", http.MethodPost, "/twirp/livekit.RoomService/ListRooms").Code)
+	require.Equal(t, http.StatusOK, serve("key", http.MethodPost, "/room_batch").Code)
+	w := serve("key", http.MethodGet, "/v1/rooms")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+	require.Contains(t, w.Body.String(), "resource_exhausted")
+	require.Equal(t, http.StatusTooManyRequests, serve("key", http.MethodPost, "/room_batch").Code)
+
+	// RPCs with their own limit, other keys, signal connections and requests without a token are not affected
+	require.Equal(t, http.StatusOK, serve("key", http.MethodPost, "/v1/rooms").Code)
+	require.Equal(t, http.StatusTooManyRequests, serve("key", http.MethodPost, "/twirp/livekit.RoomService/CreateRoom").Code)
+	require.Equal(t, http.StatusOK, serve("other", http.MethodPost, "/twirp/livekit.RoomService/ListRooms").Code)
+	require.Equal(t, http.StatusOK, serve("key", http.MethodGet, "/rtc").Code)
+	require.Equal(t, http.StatusOK, serve("", http.MethodPost, "/twirp/livekit.RoomService/ListRooms").Code)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, serve("unlimited", http.MethodPost, "/twirp/livekit.RoomService/ListRooms").Code)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	limit := config.RateLimit{RequestsPerSec: 2}
+	var b tokenBucket
+	now := time.Now()
+
+	// burst defaults to one second of requests
+	for i := 0; i < 2; i++ {
+		_, ok := b.take(limit, now)
+		require.True(t, ok)
+	}
+	retryAfter, ok := b.take(limit, now)
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	_, ok = b.take(limit, now.Add(500*time.Millisecond))
+	require.True(t, ok)
+
+	// refills up to the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		_, ok = b.take(limit, now)
+		require.True(t, ok)
+	}
+	_, ok = b.take(limit, now)
+	require.False(t, ok)
+}
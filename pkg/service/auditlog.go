@@ -0,0 +1,526 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/urfave/negroni/v3"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	defaultAuditLogSize = 10000
+	auditLogQueueSize   = 1000
+	// entries are read from the redis stream in pages of this size when querying
+	auditLogReadSize = 500
+
+	defaultAuditLogQueryLimit = 100
+	maxAuditLogQueryLimit     = 1000
+
+	AuditLogKey = "{audit_log}:log"
+)
+
+// AuditEntry records a call of the admin APIs
+type AuditEntry struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	NodeID string    `json:"node_id"`
+	// the caller, by the API key and identity of its token or the identity of its client certificate
+	APIKey   string `json:"api_key,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	ClientIP string `json:"client_ip,omitempty"`
+	// http or grpc. the method of HTTP calls is their HTTP method and path, such as
+	// POST /twirp/livekit.RoomService/CreateRoom, that of gRPC calls is their full method
+	Protocol    string `json:"protocol"`
+	Method      string `json:"method"`
+	Room        string `json:"room,omitempty"`
+	Participant string `json:"participant,omitempty"`
+	// HTTP status of the response, gRPC calls have the HTTP status of their code as Twirp calls
+	Status     int   `json:"status"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// AuditFilter selects entries of the audit log, empty fields select all entries
+type AuditFilter struct {
+	APIKey      string
+	Room        string
+	Participant string
+	Method      string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+func (f *AuditFilter) matches(entry *AuditEntry) bool {
+	return (f.APIKey == "" || entry.APIKey == f.APIKey) &&
+		(f.Room == "" || entry.Room == f.Room) &&
+		(f.Participant == "" || entry.Participant == f.Participant) &&
+		(f.Method == "" || strings.Contains(entry.Method, f.Method)) &&
+		(f.Since.IsZero() || !entry.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || !entry.Time.After(f.Until))
+}
+
+// AuditSink receives the entries of the audit log
+type AuditSink interface {
+	Write(ctx context.Context, entry *AuditEntry) error
+}
+
+// auditStore keeps the latest entries of the audit log for queries
+type auditStore interface {
+	AuditSink
+	// Query returns up to filter.Limit entries selected by the filter, the latest first
+	Query(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error)
+}
+
+// AuditLog records the calls of the admin APIs to the audit sinks, and serves queries of the latest entries at
+// /audit_log. entries are kept by each node, or in a redis stream shared by all nodes.
+// GET ?api_key=&room=&participant=&method=&since=&until=&limit= responds with the latest entries selected by the
+// query, since and until are unix timestamps
+type AuditLog struct {
+	nodeID livekit.NodeID
+	store  auditStore
+	sinks  []AuditSink
+	queue  chan *AuditEntry
+}
+
+func NewAuditLog(conf *config.Config, provider auth.KeyProvider, rc redis.UniversalClient, nodeID livekit.NodeID) (*AuditLog, error) {
+	ac := conf.AuditLog
+	if !ac.Enabled {
+		return &AuditLog{}, nil
+	}
+	size := ac.MaxEntries
+	if size <= 0 {
+		size = defaultAuditLogSize
+	}
+
+	var store auditStore = newLocalAuditStore(size)
+	if ac.RedisStream {
+		if rc == nil {
+			return nil, ErrAuditLogRedisRequired
+		}
+		store = newRedisAuditStore(rc, conf.Redis.KeyPrefix, size)
+	}
+	var sinks []AuditSink
+	if ac.File != "" {
+		sink, err := NewFileAuditSink(ac.File)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(ac.WebhookURLs) != 0 {
+		if conf.WebHook.APIKey != "" && provider.GetSecret(conf.WebHook.APIKey) == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+		sinks = append(sinks, NewWebhookAuditSink(conf.WebHook.APIKey, provider, ac.WebhookURLs))
+	}
+	return NewAuditLogWithSinks(nodeID, store, sinks...), nil
+}
+
+// NewAuditLogWithSinks records entries to other sinks
+func NewAuditLogWithSinks(nodeID livekit.NodeID, store auditStore, sinks ...AuditSink) *AuditLog {
+	l := &AuditLog{
+		nodeID: nodeID,
+		store:  store,
+		sinks:  sinks,
+		queue:  make(chan *AuditEntry, auditLogQueueSize),
+	}
+	go l.worker()
+	return l
+}
+
+func (l *AuditLog) Enabled() bool {
+	return l != nil && l.store != nil
+}
+
+// Record queues the entry for the store and the sinks, entries are dropped while the queue is full
+func (l *AuditLog) Record(entry *AuditEntry) {
+	if !l.Enabled() {
+		return
+	}
+	entry.ID = utils.NewGuid("AU_")
+	entry.NodeID = string(l.nodeID)
+	select {
+	case l.queue <- entry:
+	default:
+		logger.Warnw("audit log queue is full, dropping entry", nil, "method", entry.Method, "apiKey", entry.APIKey)
+	}
+}
+
+func (l *AuditLog) worker() {
+	for entry := range l.queue {
+		ctx := context.Background()
+		if err := l.store.Write(ctx, entry); err != nil {
+			logger.Warnw("could not store audit entry", err, "method", entry.Method)
+		}
+		for _, sink := range l.sinks {
+			if err := sink.Write(ctx, entry); err != nil {
+				logger.Warnw("could not write audit entry", err, "method", entry.Method, "sink", fmt.Sprintf("%T", sink))
+			}
+		}
+	}
+}
+
+// Query returns the latest entries selected by the filter
+func (l *AuditLog) Query(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	// the audit log is of the server, it is limited as webhook deliveries
+	if err := ensureWebhookPermission(ctx); err != nil {
+		return nil, err
+	}
+	if !l.Enabled() {
+		return nil, ErrAuditLogNotEnabled
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLogQueryLimit
+	} else if filter.Limit > maxAuditLogQueryLimit {
+		filter.Limit = maxAuditLogQueryLimit
+	}
+	return l.store.Query(ctx, filter)
+}
+
+type auditLogResponse struct {
+	Entries []*AuditEntry `json:"entries"`
+}
+
+func (l *AuditLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			filter := AuditFilter{
+				APIKey:      query.Get("api_key"),
+				Room:        query.Get("room"),
+				Participant: query.Get("participant"),
+				Method:      query.Get("method"),
+			}
+			for param, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+				if v := query.Get(param); v != "" {
+					unix, err := strconv.ParseInt(v, 10, 64)
+					if err != nil {
+						return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+					}
+					*t = time.Unix(unix, 0)
+				}
+			}
+			if v := query.Get("limit"); v != "" {
+				var err error
+				if filter.Limit, err = strconv.Atoi(v); err != nil {
+					return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+				}
+			}
+
+			entries, err := l.Query(r.Context(), filter)
+			if err != nil {
+				return nil, err
+			}
+			if entries == nil {
+				entries = []*AuditEntry{}
+			}
+			return &auditLogResponse{Entries: entries}, nil
+		},
+	}.ServeHTTP(w, r)
+}
+
+type auditEntryKey struct{}
+
+// startAuditCall returns the context of a call with its entry, the authentication adds the caller with
+// setAuditIdentity or setAuditCaller and handlers add the room and participant with AppendLogFields
+func startAuditCall(ctx context.Context, protocol string, method string, clientIP string) (context.Context, *AuditEntry) {
+	entry := &AuditEntry{
+		Time:     time.Now(),
+		ClientIP: clientIP,
+		Protocol: protocol,
+		Method:   method,
+	}
+	return context.WithValue(ctx, auditEntryKey{}, entry), entry
+}
+
+// setAuditIdentity adds the identity of the client certificate to the entry of the call
+func setAuditIdentity(ctx context.Context, identity string) {
+	if entry, ok := ctx.Value(auditEntryKey{}).(*AuditEntry); ok {
+		entry.Identity = identity
+	}
+}
+
+// setAuditCaller adds the API key and identity of the authenticated token to the entry of the call
+func setAuditCaller(ctx context.Context) {
+	entry, ok := ctx.Value(auditEntryKey{}).(*AuditEntry)
+	if !ok {
+		return
+	}
+	entry.APIKey, _ = GetAPIKey(ctx)
+	if claims := GetGrants(ctx); claims != nil {
+		entry.Identity = claims.Identity
+	}
+}
+
+// appendAuditFields adds the room and participant of log fields to the entry of the call
+func appendAuditFields(ctx context.Context, fields ...interface{}) {
+	entry, ok := ctx.Value(auditEntryKey{}).(*AuditEntry)
+	if !ok {
+		return
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		var value string
+		switch v := fields[i+1].(type) {
+		case []string:
+			value = strings.Join(v, ",")
+		default:
+			value = fmt.Sprint(v)
+		}
+		switch fields[i] {
+		case "room":
+			entry.Room = value
+		case "participant":
+			entry.Participant = value
+		}
+	}
+}
+
+// AuditMiddleware records the calls of the admin APIs. it precedes the authentication middlewares so that rejected
+// calls are recorded too
+type AuditMiddleware struct {
+	log *AuditLog
+}
+
+func NewAuditMiddleware(l *AuditLog) *AuditMiddleware {
+	return &AuditMiddleware{log: l}
+}
+
+func (m *AuditMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL == nil || !isAdminPath(r.URL.Path) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	clientIP, _, _ := strings.Cut(GetClientIP(r), ",")
+	ctx, entry := startAuditCall(r.Context(), "http", r.Method+" "+r.URL.Path, strings.TrimSpace(clientIP))
+	rw, ok := w.(negroni.ResponseWriter)
+	if !ok {
+		rw = negroni.NewResponseWriter(w)
+	}
+	next.ServeHTTP(rw, r.WithContext(ctx))
+
+	entry.Status = rw.Status()
+	entry.DurationMs = time.Since(entry.Time).Milliseconds()
+	m.log.Record(entry)
+}
+
+// localAuditStore keeps the latest entries of a single node in memory
+type localAuditStore struct {
+	lock    sync.RWMutex
+	entries []*AuditEntry
+	next    int
+	size    int
+}
+
+func newLocalAuditStore(size int) *localAuditStore {
+	return &localAuditStore{size: size}
+}
+
+func (s *localAuditStore) Write(_ context.Context, entry *AuditEntry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.entries) < s.size {
+		s.entries = append(s.entries, entry)
+	} else {
+		s.entries[s.next] = entry
+	}
+	s.next = (s.next + 1) % s.size
+	return nil
+}
+
+func (s *localAuditStore) Query(_ context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var entries []*AuditEntry
+	for i := 1; i <= len(s.entries) && len(entries) < filter.Limit; i++ {
+		entry := s.entries[(s.next-i+len(s.entries))%len(s.entries)]
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// redisAuditStore keeps the entries of all nodes in a redis stream, the IDs of stream entries are their times
+type redisAuditStore struct {
+	rc   redis.UniversalClient
+	key  string
+	size int
+}
+
+func newRedisAuditStore(rc redis.UniversalClient, prefix string, size int) *redisAuditStore {
+	return &redisAuditStore{
+		rc:   rc,
+		key:  prefix + AuditLogKey,
+		size: size,
+	}
+}
+
+func (s *redisAuditStore) Write(ctx context.Context, entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.rc.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.key,
+		MaxLen: int64(s.size),
+		Approx: true,
+		Values: []interface{}{"entry", data},
+	}).Err()
+}
+
+func (s *redisAuditStore) Query(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	end, start := "+", "-"
+	if !filter.Until.IsZero() {
+		end = strconv.FormatInt(filter.Until.UnixMilli(), 10)
+	}
+	if !filter.Since.IsZero() {
+		start = strconv.FormatInt(filter.Since.UnixMilli(), 10)
+	}
+
+	var entries []*AuditEntry
+	for len(entries) < filter.Limit {
+		msgs, err := s.rc.XRevRangeN(ctx, s.key, end, start, auditLogReadSize).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			data, _ := msg.Values["entry"].(string)
+			entry := &AuditEntry{}
+			if err = json.Unmarshal([]byte(data), entry); err != nil {
+				return nil, err
+			}
+			if filter.matches(entry) && len(entries) < filter.Limit {
+				entries = append(entries, entry)
+			}
+		}
+		if len(msgs) < auditLogReadSize {
+			break
+		}
+		// the next page is before the last entry read
+		end = "(" + msgs[len(msgs)-1].ID
+	}
+	return entries, nil
+}
+
+// FileAuditSink appends entries to a file as JSON lines
+type FileAuditSink struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log file: %v", err)
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+func (s *FileAuditSink) Write(_ context.Context, entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// WebhookAuditSink posts entries to URLs, with tokens of the API key that have the hash of the entry as webhook
+// requests
+type WebhookAuditSink struct {
+	apiKey      string
+	keyProvider auth.KeyProvider
+	urls        []string
+	client      *http.Client
+}
+
+func NewWebhookAuditSink(apiKey string, keyProvider auth.KeyProvider, urls []string) *WebhookAuditSink {
+	return &WebhookAuditSink{
+		apiKey:      apiKey,
+		keyProvider: keyProvider,
+		urls:        urls,
+		client:      &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *WebhookAuditSink) Write(ctx context.Context, entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	var token string
+	if s.apiKey != "" {
+		sum := sha256.Sum256(data)
+		if token, err = auth.NewAccessToken(s.apiKey, s.keyProvider.GetSecret(s.apiKey)).
+			SetValidFor(webhookTokenValidity).
+			SetSha256(base64.StdEncoding.EncodeToString(sum[:])).
+			ToJWT(); err != nil {
+			return err
+		}
+	}
+
+	var errs []string
+	for _, url := range s.urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(authorizationHeader, token)
+		}
+		res, err := s.client.Do(req)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			errs = append(errs, fmt.Sprintf("%s responded with %s", url, res.Status))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("could not post audit entry: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
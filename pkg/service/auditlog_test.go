@@ -0,0 +1,131 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+)
+
+type auditTestSink struct {
+	entries chan *AuditEntry
+}
+
+func (s *auditTestSink) Write(_ context.Context, entry *AuditEntry) error {
+	s.entries <- entry
+	return nil
+}
+
+func TestAuditLog(t *testing.T) {
+	sink := &auditTestSink{entries: make(chan *AuditEntry, 10)}
+	store := newLocalAuditStore(3)
+	l := NewAuditLogWithSinks("ND_audit", store, sink)
+	provider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"APIadmin": "secret"})
+	authMiddleware := NewAPIKeyAuthMiddleware(provider, nil, nil, nil, nil, nil, nil)
+	m := NewAuditMiddleware(l)
+
+	serve := func(path string, token string) {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = "203.0.113.1:4000"
+		if token != "" {
+			r.Header.Set(authorizationHeader, bearerPrefix+token)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			authMiddleware.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+				AppendLogFields(r.Context(), "room", "standup", "participant", []string{"alice", "bob"})
+				w.WriteHeader(http.StatusNotFound)
+			})
+		})
+	}
+	token, err := auth.NewAccessToken("APIadmin", "secret").
+		AddGrant(&auth.VideoGrant{RoomAdmin: true, RoomList: true}).
+		SetIdentity("ops").
+		ToJWT()
+	require.NoError(t, err)
+
+	t.Run("calls are recorded", func(t *testing.T) {
+		serve("/twirp/livekit.RoomService/RemoveParticipant", token)
+		entry := <-sink.entries
+		require.NotEmpty(t, entry.ID)
+		require.Equal(t, "ND_audit", entry.NodeID)
+		require.Equal(t, "APIadmin", entry.APIKey)
+		require.Equal(t, "ops", entry.Identity)
+		require.Equal(t, "203.0.113.1", entry.ClientIP)
+		require.Equal(t, "POST /twirp/livekit.RoomService/RemoveParticipant", entry.Method)
+		require.Equal(t, "standup", entry.Room)
+		require.Equal(t, "alice,bob", entry.Participant)
+		require.Equal(t, http.StatusNotFound, entry.Status)
+
+		// rejected calls are recorded too, signal connections are not
+		serve("/twirp/livekit.RoomService/DeleteRoom", "invalid")
+		entry = <-sink.entries
+		require.Empty(t, entry.APIKey)
+		require.Equal(t, http.StatusUnauthorized, entry.Status)
+		serve("/rtc", "")
+		require.Never(t, func() bool { return len(sink.entries) != 0 }, 50*time.Millisecond, 10*time.Millisecond)
+	})
+
+	t.Run("queries", func(t *testing.T) {
+		serve("/twirp/livekit.RoomService/ListRooms", token)
+		<-sink.entries
+		serve("/twirp/livekit.RoomService/DeleteRoom", token)
+		<-sink.entries
+
+		ctx := WithAPIKey(WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true, RoomList: true}}), "APIadmin")
+		// the store keeps the latest entries, the latest first
+		entries, err := l.Query(ctx, AuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		require.Equal(t, "POST /twirp/livekit.RoomService/DeleteRoom", entries[0].Method)
+		require.Equal(t, "POST /twirp/livekit.RoomService/ListRooms", entries[1].Method)
+
+		entries, err = l.Query(ctx, AuditFilter{APIKey: "APIadmin", Method: "DeleteRoom"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		entries, err = l.Query(ctx, AuditFilter{Since: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		// keys scoped to a project cannot query the log
+		_, err = l.Query(WithProject(ctx, "customer"), AuditFilter{})
+		require.ErrorIs(t, err, ErrPermissionDenied)
+		_, err = (&AuditLog{}).Query(ctx, AuditFilter{})
+		require.ErrorIs(t, err, ErrAuditLogNotEnabled)
+	})
+
+	t.Run("gRPC status", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, grpcHTTPStatus(nil))
+		require.Equal(t, http.StatusNotFound, grpcHTTPStatus(ErrRoomNotFound))
+		require.Equal(t, http.StatusNotImplemented, grpcHTTPStatus(ErrAuditLogNotEnabled))
+	})
+}
+
+func TestAuditLogDisabled(t *testing.T) {
+	l := &AuditLog{}
+	require.False(t, l.Enabled())
+	// entries are dropped
+	l.Record(&AuditEntry{Method: "POST /twirp/livekit.RoomService/DeleteRoom"})
+
+	var nilLog *AuditLog
+	require.False(t, nilLog.Enabled())
+}
@@ -17,13 +17,20 @@ package service
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/pkg/utils"
 )
 
 const (
@@ -34,21 +41,54 @@ const (
 
 type grantsKey struct{}
 
+type projectKey struct{}
+
+type apiKeyKey struct{}
+
 var (
 	ErrPermissionDenied          = errors.New("permissions denied")
 	ErrMissingAuthorization      = errors.New("invalid authorization header. Must start with " + bearerPrefix)
 	ErrInvalidAuthorizationToken = errors.New("invalid authorization token")
 	ErrInvalidAPIKey             = errors.New("invalid API key")
+	ErrRetiredAPIKey             = errors.New("API key is retired, its tokens are no longer accepted")
+	ErrInvalidProjectRoomName    = errors.New("room name cannot contain " + utils.ProjectSeparator)
+	ErrIPNotAllowed              = errors.New("API key is not allowed from this address")
 )
 
 // authentication middleware
 type APIKeyAuthMiddleware struct {
 	provider auth.KeyProvider
+	// project of API keys, nil when projects are not configured
+	projects map[string]string
+	// scopes of API keys, keys without scopes are allowed all requests
+	scopes map[string][]config.APIKeyScope
+	// retirement times of API keys being rotated out
+	retiredKeys map[string]time.Time
+	// verifies tokens of OIDC issuers, nil when none are trusted
+	oidc *OIDCVerifier
+	// networks API keys are allowed from, keys without networks are allowed from any address
+	allowedNetworks map[string][]*net.IPNet
+	// revoked tokens are rejected, nil when revocation is not enabled
+	revocations TokenRevocationStore
 }
 
-func NewAPIKeyAuthMiddleware(provider auth.KeyProvider) *APIKeyAuthMiddleware {
+func NewAPIKeyAuthMiddleware(
+	provider auth.KeyProvider,
+	projects map[string]string,
+	scopes map[string][]config.APIKeyScope,
+	retiredKeys map[string]time.Time,
+	oidc *OIDCVerifier,
+	allowedNetworks map[string][]*net.IPNet,
+	revocations TokenRevocationStore,
+) *APIKeyAuthMiddleware {
 	return &APIKeyAuthMiddleware{
-		provider: provider,
+		provider:        provider,
+		projects:        projects,
+		scopes:          scopes,
+		retiredKeys:     retiredKeys,
+		oidc:            oidc,
+		allowedNetworks: allowedNetworks,
+		revocations:     revocations,
 	}
 }
 
@@ -57,6 +97,13 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
 
+	if _, ok := GetClientCertIdentity(r.Context()); ok {
+		// authenticated by its client certificate, whose middleware has checked the scopes, the project and the IP
+		// allowlist of its identity
+		next.ServeHTTP(w, r)
+		return
+	}
+
 	authHeader := r.Header.Get(authorizationHeader)
 	var authToken string
 
@@ -73,30 +120,141 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 	}
 
 	if authToken != "" {
-		v, err := auth.ParseAPIToken(authToken)
+		ctx, err := m.authenticate(r.Context(), authToken)
 		if err != nil {
-			handleError(w, http.StatusUnauthorized, ErrInvalidAuthorizationToken)
+			handleError(w, http.StatusUnauthorized, err)
 			return
 		}
+		if !m.ipAllowed(ctx, GetClientIP(r), r.URL != nil && isPathOf(r.URL.Path, "/rtc")) {
+			if r.URL != nil && (strings.HasPrefix(r.URL.Path, twirpPathPrefix) || strings.HasPrefix(r.URL.Path, restGatewayPrefix)) {
+				_ = twirp.WriteError(w, twirp.NewError(twirp.PermissionDenied, ErrIPNotAllowed.Error()))
+			} else {
+				handleError(w, http.StatusForbidden, ErrIPNotAllowed)
+			}
+			return
+		}
+		if r.URL != nil && !m.authorize(ctx, r.Method, r.URL.Path) {
+			apiKey, _ := GetAPIKey(ctx)
+			if strings.HasPrefix(r.URL.Path, twirpPathPrefix) || strings.HasPrefix(r.URL.Path, restGatewayPrefix) {
+				logger.Infow("request not in scopes of API key", "apiKey", apiKey, "path", r.URL.Path)
+				_ = twirp.WriteError(w, twirp.NewError(twirp.PermissionDenied, ErrPermissionDenied.Error()))
+			} else {
+				handleError(w, http.StatusForbidden, ErrPermissionDenied, "apiKey", apiKey, "path", r.URL.Path)
+			}
+			return
+		}
+		r = r.WithContext(ctx)
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// authenticate verifies the token and returns the context with its grants
+func (m *APIKeyAuthMiddleware) authenticate(ctx context.Context, authToken string) (context.Context, error) {
+	// the claims are decoded before the token is verified only to tell its issuer, they are trusted once verified
+	claims, ok := parseTokenClaims(authToken)
+	if !ok {
+		return nil, ErrInvalidAuthorizationToken
+	}
+	var grants *auth.ClaimGrants
+	var project string
+	if m.oidc.IsTrusted(claims.Issuer) {
+		// tokens of OIDC issuers have join grants, without an API key
+		var err error
+		if grants, err = m.oidc.Verify(ctx, authToken); err != nil {
+			return nil, err
+		}
+		project = m.oidc.Project(claims.Issuer)
+	} else {
+		v, err := auth.ParseAPIToken(authToken)
+		if err != nil {
+			return nil, ErrInvalidAuthorizationToken
+		}
 
 		secret := m.provider.GetSecret(v.APIKey())
 		if secret == "" {
-			handleError(w, http.StatusUnauthorized, errors.New("invalid API key: "+v.APIKey()))
-			return
+			return nil, errors.New("invalid API key: " + v.APIKey())
 		}
 
-		grants, err := v.Verify(secret)
+		grants, err = v.Verify(secret)
 		if err != nil {
-			handleError(w, http.StatusUnauthorized, errors.New("invalid token: "+authToken+", error: "+err.Error()))
-			return
+			return nil, errors.New("invalid token: " + authToken + ", error: " + err.Error())
+		}
+		// tokens of a retired key are not verified once its grace period has ended, whatever they claim
+		if deadline, ok := m.retiredKeys[v.APIKey()]; ok && !time.Now().Before(deadline) {
+			return nil, ErrRetiredAPIKey
+		}
+		project = m.projects[v.APIKey()]
+		ctx = WithAPIKey(ctx, v.APIKey())
+	}
+	return m.withGrants(ctx, grants, claims, project)
+}
+
+// withGrants returns the context with the verified grants of a token, scoped to its project, and its extended claims
+func (m *APIKeyAuthMiddleware) withGrants(ctx context.Context, grants *auth.ClaimGrants, claims *tokenClaims, project string) (context.Context, error) {
+	if m.projects != nil {
+		// scope the room of the token to its project
+		if grants.Video != nil && grants.Video.Room != "" {
+			if strings.Contains(grants.Video.Room, utils.ProjectSeparator) {
+				return nil, ErrInvalidProjectRoomName
+			}
+			grants.Video.Room = string(utils.ProjectRoomName(project, livekit.RoomName(grants.Video.Room)))
 		}
+		ctx = WithProject(ctx, project)
+	}
+	ctx = withTokenClaims(ctx, claims)
+	if attributes := claims.participantAttributes(); attributes != nil {
+		ctx = withTokenAttributes(ctx, attributes)
+	}
+	if claims.RoomPasscode != "" {
+		ctx = withTokenRoomPasscode(ctx, claims.RoomPasscode)
+	}
+	if binding := claims.tokenBinding(); binding != nil {
+		ctx = withTokenBinding(ctx, binding)
+	}
+	if limit := claims.maxSessionDuration(); limit > 0 {
+		ctx = withTokenMaxSessionDuration(ctx, limit)
+	}
+	if resolution := claims.maxPublishResolution(); resolution != nil {
+		ctx = withTokenMaxPublishResolution(ctx, resolution)
+	}
+	ctx = WithGrants(ctx, grants)
+	if err := ensureTokenNotRevoked(ctx, m.revocations, grants.Identity); err != nil {
+		return nil, err
+	}
+	setAuditCaller(ctx)
+	return ctx, nil
+}
 
-		// set grants in context
-		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey{}, grants))
+// authorize returns whether the scopes of the API key of the authenticated request allow it
+func (m *APIKeyAuthMiddleware) authorize(ctx context.Context, method string, urlPath string) bool {
+	apiKey, _ := GetAPIKey(ctx)
+	return scopesAllow(m.scopes[apiKey], method, urlPath)
+}
+
+// ipAllowed returns whether the API key of the authenticated request is allowed from the client address, requests
+// rejected are counted as joins or RPCs
+func (m *APIKeyAuthMiddleware) ipAllowed(ctx context.Context, clientIP string, join bool) bool {
+	apiKey, _ := GetAPIKey(ctx)
+	networks, ok := m.allowedNetworks[apiKey]
+	if !ok {
+		return true
+	}
+	if ip := net.ParseIP(clientIP); ip != nil {
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
 	}
 
-	next.ServeHTTP(w, r)
+	request := "rpc"
+	if join {
+		request = "join"
+	}
+	logger.Infow("API key is not allowed from address", "apiKey", apiKey, "clientIP", clientIP, "request", request)
+	prometheus.RecordAPIKeyIPRejected(apiKey, request)
+	return false
 }
 
 func GetGrants(ctx context.Context) *auth.ClaimGrants {
@@ -112,6 +270,26 @@ func WithGrants(ctx context.Context, grants *auth.ClaimGrants) context.Context {
 	return context.WithValue(ctx, grantsKey{}, grants)
 }
 
+// GetProject returns the project of the request's API key, ok is false when projects are not configured
+func GetProject(ctx context.Context) (project string, ok bool) {
+	project, ok = ctx.Value(projectKey{}).(string)
+	return
+}
+
+func WithProject(ctx context.Context, project string) context.Context {
+	return context.WithValue(ctx, projectKey{}, project)
+}
+
+// GetAPIKey returns the API key of the request's token, ok is false for requests without a token
+func GetAPIKey(ctx context.Context) (apiKey string, ok bool) {
+	apiKey, ok = ctx.Value(apiKeyKey{}).(string)
+	return
+}
+
+func WithAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, apiKey)
+}
+
 func SetAuthorizationToken(r *http.Request, token string) {
 	r.Header.Set(authorizationHeader, bearerPrefix+token)
 }
@@ -137,7 +315,19 @@ func EnsureAdminPermission(ctx context.Context, room livekit.RoomName) error {
 		return ErrPermissionDenied
 	}
 
-	if !claims.Video.RoomAdmin || room != livekit.RoomName(claims.Video.Room) {
+	if !claims.Video.RoomAdmin {
+		return ErrPermissionDenied
+	}
+	// client certificates are admins of the rooms of their project, room names are scoped to it
+	if _, ok := GetClientCertIdentity(ctx); ok {
+		if project, scoped := GetProject(ctx); scoped {
+			if roomProject, _ := utils.SplitProjectRoomName(room); roomProject != project {
+				return ErrPermissionDenied
+			}
+		}
+		return nil
+	}
+	if room != livekit.RoomName(claims.Video.Room) {
 		return ErrPermissionDenied
 	}
 
@@ -15,15 +15,18 @@
 package service_test
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/auth/authfakes"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/service"
 )
 
@@ -33,7 +36,7 @@ func TestAuthMiddleware(t *testing.T) {
 	provider := &authfakes.FakeKeyProvider{}
 	provider.GetSecretReturns(secret)
 
-	m := service.NewAPIKeyAuthMiddleware(provider)
+	m := service.NewAPIKeyAuthMiddleware(provider, nil, nil, nil, nil, nil, nil)
 	var grants *auth.ClaimGrants
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		grants = service.GetGrants(r.Context())
@@ -72,3 +75,135 @@ func TestAuthMiddleware(t *testing.T) {
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthMiddlewareScopes(t *testing.T) {
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider, nil, map[string][]config.APIKeyScope{
+		"APIreader":   {config.APIKeyScopeReadOnly},
+		"APIrecorder": {config.APIKeyScopeEgressOnly, config.APIKeyScopeReadOnly},
+		"APIadmin":    {config.APIKeyScopeRoomAdmin},
+	}, nil, nil, nil, nil)
+	serve := func(apiKey string, method string, path string) int {
+		token, err := auth.NewAccessToken(apiKey, secret).AddGrant(&auth.VideoGrant{RoomList: true}).ToJWT()
+		require.NoError(t, err)
+		r := httptest.NewRequest(method, path, nil)
+		service.SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve("APIreader", http.MethodPost, "/twirp/livekit.RoomService/ListRooms"))
+	require.Equal(t, http.StatusOK, serve("APIreader", http.MethodGet, "/v1/rooms"))
+	require.Equal(t, http.StatusOK, serve("APIreader", http.MethodGet, "/participants"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodPost, "/twirp/livekit.RoomService/CreateRoom"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodPost, "/v1/rooms"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodPost, "/room_batch"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodGet, "/rtc"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodGet, "/audit_log"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodGet, "/debug/stats"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodGet, "/webhook_deliveries"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodGet, "/turn_credentials"))
+	require.Equal(t, http.StatusForbidden, serve("APIreader", http.MethodDelete, "/v1/rooms/room"))
+
+	// a key is allowed the requests of any of its scopes
+	require.Equal(t, http.StatusOK, serve("APIrecorder", http.MethodPost, "/twirp/livekit.Egress/StartRoomCompositeEgress"))
+	require.Equal(t, http.StatusOK, serve("APIrecorder", http.MethodPost, "/twirp/livekit.RoomService/ListParticipants"))
+	require.Equal(t, http.StatusForbidden, serve("APIrecorder", http.MethodPost, "/twirp/livekit.Ingress/CreateIngress"))
+
+	require.Equal(t, http.StatusOK, serve("APIadmin", http.MethodPost, "/twirp/livekit.RoomService/DeleteRoom"))
+	require.Equal(t, http.StatusOK, serve("APIadmin", http.MethodPost, "/move_participant"))
+	require.Equal(t, http.StatusOK, serve("APIadmin", http.MethodGet, "/rtc"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodPost, "/twirp/livekit.Egress/StopEgress"))
+	require.Equal(t, http.StatusOK, serve("APIadmin", http.MethodDelete, "/v1/rooms/room"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodGet, "/webhook_deliveries"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodPost, "/revoke_tokens"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodGet, "/audit_log"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodPost, "/turn_credentials"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodGet, "/debug/rooms"))
+	// requests that are not listed are denied
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodPost, "/twirp/livekit.RoomService/Unknown"))
+	require.Equal(t, http.StatusForbidden, serve("APIadmin", http.MethodGet, "/unknown"))
+
+	// keys without scopes have full access
+	require.Equal(t, http.StatusOK, serve("APIother", http.MethodPost, "/twirp/livekit.Ingress/CreateIngress"))
+}
+
+func TestAuthMiddlewareRetiredKeys(t *testing.T) {
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider, nil, nil, map[string]time.Time{
+		"APIretired":  time.Now().Add(-time.Hour),
+		"APIretiring": time.Now().Add(time.Hour),
+	}, nil, nil, nil)
+	serve := func(apiKey string) int {
+		token, err := auth.NewAccessToken(apiKey, secret).AddGrant(&auth.VideoGrant{RoomList: true}).ToJWT()
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/ListRooms", nil)
+		service.SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return w.Code
+	}
+
+	// tokens of a retired key are accepted until its grace period ends
+	require.Equal(t, http.StatusOK, serve("APIretiring"))
+	require.Equal(t, http.StatusUnauthorized, serve("APIretired"))
+	require.Equal(t, http.StatusOK, serve("APIcurrent"))
+}
+
+func TestAuthMiddlewareIPAllowlist(t *testing.T) {
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	_, office, err := net.ParseCIDR("203.0.113.0/24")
+	require.NoError(t, err)
+	m := service.NewAPIKeyAuthMiddleware(provider, nil, nil, nil, nil, map[string][]*net.IPNet{
+		"APIoffice": {office},
+	}, nil)
+	_, proxy, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	proxies := service.NewClientIPMiddleware([]*net.IPNet{proxy})
+	serve := func(apiKey string, path string, remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+		token, err := auth.NewAccessToken(apiKey, secret).AddGrant(&auth.VideoGrant{RoomList: true, RoomJoin: true}).ToJWT()
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		service.SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		proxies.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, serve("APIoffice", "/twirp/livekit.RoomService/ListRooms", "203.0.113.7:4000", "").Code)
+	w := serve("APIoffice", "/twirp/livekit.RoomService/ListRooms", "198.51.100.1:4000", "")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Contains(t, w.Body.String(), "permission_denied")
+	require.Equal(t, http.StatusForbidden, serve("APIoffice", "/rtc", "198.51.100.1:4000", "").Code)
+	// the client of requests forwarded by trusted proxies is the right-most address that is not a proxy
+	require.Equal(t, http.StatusOK, serve("APIoffice", "/rtc", "10.0.0.1:4000", "203.0.113.7, 10.0.0.2").Code)
+	require.Equal(t, http.StatusForbidden, serve("APIoffice", "/rtc", "10.0.0.1:4000", "203.0.113.7, 198.51.100.1").Code)
+	// forwarded headers of other peers are spoofed
+	require.Equal(t, http.StatusForbidden, serve("APIoffice", "/rtc", "198.51.100.1:4000", "203.0.113.7").Code)
+	require.Equal(t, http.StatusForbidden, serve("APIoffice", "/twirp/livekit.RoomService/ListRooms", "198.51.100.1:4000", "203.0.113.7").Code)
+	require.Equal(t, http.StatusOK, serve("APIoffice", "/rtc", "203.0.113.7:4000", "198.51.100.1").Code)
+	// keys without an allowlist are allowed from any address
+	require.Equal(t, http.StatusOK, serve("APIother", "/twirp/livekit.RoomService/ListRooms", "198.51.100.1:4000", "").Code)
+}
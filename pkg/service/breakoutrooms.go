@@ -0,0 +1,480 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	// the parent of a breakout room and the breakout rooms of a parent room are kept with their labels
+	breakoutParentLabel = reservedLabelPrefix + "breakout-parent"
+	breakoutRoomsLabel  = reservedLabelPrefix + "breakout-rooms"
+
+	// the breakout rooms of a parent room are exposed to its participants under this key of its metadata, an object
+	// with the names of the breakout rooms as keys
+	breakoutRoomsMetadataKey = reservedLabelPrefix + "breakout_rooms"
+
+	maxBreakoutRoomsRequestSize = 1024 * 1024
+)
+
+// BreakoutRoomParent returns the parent room of a breakout room
+func BreakoutRoomParent(labels RoomLabels) (livekit.RoomName, bool) {
+	parent, ok := labels[breakoutParentLabel]
+	return livekit.RoomName(parent), ok
+}
+
+// BreakoutRooms returns the breakout rooms of a parent room
+func BreakoutRooms(labels RoomLabels) []livekit.RoomName {
+	var rooms []livekit.RoomName
+	if err := json.Unmarshal([]byte(labels[breakoutRoomsLabel]), &rooms); err != nil {
+		return nil
+	}
+	return rooms
+}
+
+// linkBreakoutRooms adds the breakout rooms to the labels of the parent room, or removes them when linked is false
+func linkBreakoutRooms(ctx context.Context, store ObjectStore, parent livekit.RoomName, children []livekit.RoomName, linked bool) error {
+	_, err := changeRoomLabels(ctx, store, parent, func(labels RoomLabels) (bool, error) {
+		rooms := make([]livekit.RoomName, 0, len(children))
+		for _, room := range BreakoutRooms(labels) {
+			if !containsRoomName(children, room) {
+				rooms = append(rooms, room)
+			}
+		}
+		if linked {
+			rooms = append(rooms, children...)
+		}
+
+		if len(rooms) == 0 {
+			delete(labels, breakoutRoomsLabel)
+			return true, nil
+		}
+		data, err := json.Marshal(rooms)
+		if err != nil {
+			return false, err
+		}
+		labels[breakoutRoomsLabel] = string(data)
+		return true, nil
+	})
+	return err
+}
+
+// breakoutRoomsPatch returns the merge patch of the metadata of a parent room adding or removing the breakout rooms.
+// metadata that isn't a JSON object is not patched, it would be replaced
+func breakoutRoomsPatch(metadata string, children []livekit.RoomName, linked bool) (string, bool) {
+	if metadata != "" {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(metadata), &m); err != nil || m == nil {
+			return "", false
+		}
+	}
+
+	rooms := make(map[string]interface{}, len(children))
+	for _, child := range children {
+		// participants know rooms by the names of their project
+		_, name := utils.SplitProjectRoomName(child)
+		if linked {
+			rooms[string(name)] = true
+		} else {
+			rooms[string(name)] = nil
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{breakoutRoomsMetadataKey: rooms})
+	if err != nil {
+		return "", false
+	}
+	return string(patch), true
+}
+
+func containsRoomName(rooms []livekit.RoomName, room livekit.RoomName) bool {
+	for _, r := range rooms {
+		if r == room {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakoutRoomService manages breakout rooms of a parent room at /breakout_rooms. breakout rooms are closed when
+// their parent room ends, and participants can be moved between a parent room and its breakout rooms. the breakout
+// rooms of a parent room are listed in its metadata, when it is a JSON object.
+// GET ?room= responds with the parent room and its breakout rooms.
+// POST {"room": "", "rooms": [CreateRoomRequest]} creates breakout rooms of the room, as a batch.
+// DELETE ?room= closes the breakout rooms of the room.
+// POST /breakout_rooms/move {"room": "", "identity": "", "destination_room": ""} moves a participant between a
+// room and its breakout rooms, or between breakout rooms of the same room
+type BreakoutRoomService struct {
+	roomService  *RoomService
+	batchService *RoomBatchService
+	moveService  *ParticipantMoveService
+	router       routing.Router
+	store        ObjectStore
+}
+
+func NewBreakoutRoomService(
+	roomService *RoomService,
+	batchService *RoomBatchService,
+	moveService *ParticipantMoveService,
+	router routing.Router,
+	store ObjectStore,
+) *BreakoutRoomService {
+	return &BreakoutRoomService{
+		roomService:  roomService,
+		batchService: batchService,
+		moveService:  moveService,
+		router:       router,
+		store:        store,
+	}
+}
+
+// CreateBreakoutRooms creates the rooms as breakout rooms of the room. breakout rooms are hosted by the node of
+// their parent room, for participants to be moved between them
+func (s *BreakoutRoomService) CreateBreakoutRooms(ctx context.Context, room string, reqs []*livekit.CreateRoomRequest) ([]*livekit.Room, error) {
+	AppendLogFields(ctx, "room", room)
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, err
+	}
+	parent, err := s.scopeRoomName(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+	parentRoom, _, err := s.store.LoadRoom(ctx, parent, false)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := s.store.LoadRoomLabels(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := BreakoutRoomParent(labels); ok {
+		return nil, ErrInvalidBreakoutRoom
+	}
+
+	rooms, err := s.batchService.CreateRooms(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]livekit.RoomName, 0, len(rooms))
+	for _, rm := range rooms {
+		child, err := s.scopeRoomName(ctx, rm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err = s.setParent(ctx, child, parent); err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if err = linkBreakoutRooms(ctx, s.store, parent, children, true); err != nil {
+		return nil, err
+	}
+
+	if patch, ok := breakoutRoomsPatch(parentRoom.Metadata, children, true); ok {
+		// request options of the caller don't apply to the patch
+		patchCtx := withoutRequestOptions(ctx)
+		if _, err = s.roomService.patchRoomMetadata(patchCtx, &livekit.UpdateRoomMetadataRequest{
+			Room:     string(parent),
+			Metadata: patch,
+		}, nil); err != nil {
+			logger.Warnw("could not list breakout rooms in metadata", err, "room", parent)
+		}
+	}
+
+	// the parent room has been started by the metadata patch
+	if node, err := s.router.GetNodeForRoom(ctx, parent); err == nil {
+		for _, child := range children {
+			if err = s.router.SetNodeForRoom(ctx, child, livekit.NodeID(node.Id)); err != nil {
+				logger.Warnw("could not host breakout room on node of parent", err, "room", child, "parent", parent)
+			}
+		}
+	}
+	return rooms, nil
+}
+
+// CloseBreakoutRooms closes and deletes the breakout rooms of the room
+func (s *BreakoutRoomService) CloseBreakoutRooms(ctx context.Context, room string) error {
+	AppendLogFields(ctx, "room", room)
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return err
+	}
+	parent, err := s.scopeRoomName(ctx, room)
+	if err != nil {
+		return err
+	}
+	parentRoom, _, err := s.store.LoadRoom(ctx, parent, false)
+	if err != nil {
+		return err
+	}
+	labels, err := s.store.LoadRoomLabels(ctx, parent)
+	if err != nil {
+		return err
+	}
+	children := BreakoutRooms(labels)
+	if len(children) == 0 {
+		return nil
+	}
+
+	// breakout rooms that have ended are no longer kept
+	existing := make([]livekit.RoomName, 0, len(children))
+	for _, child := range children {
+		if _, _, err = s.store.LoadRoom(ctx, child, false); err == nil {
+			existing = append(existing, child)
+		} else if err != ErrRoomNotFound {
+			return err
+		}
+	}
+	if len(existing) != 0 {
+		if err = s.batchService.deleteRooms(ctx, existing); err != nil {
+			return err
+		}
+	}
+	if err = linkBreakoutRooms(ctx, s.store, parent, children, false); err != nil {
+		return err
+	}
+
+	if patch, ok := breakoutRoomsPatch(parentRoom.Metadata, children, false); ok {
+		patchCtx := withoutRequestOptions(ctx)
+		if _, err = s.roomService.patchRoomMetadata(patchCtx, &livekit.UpdateRoomMetadataRequest{
+			Room:     string(parent),
+			Metadata: patch,
+		}, nil); err != nil {
+			logger.Warnw("could not remove breakout rooms from metadata", err, "room", parent)
+		}
+	}
+	return nil
+}
+
+// GetBreakoutRooms returns the room and its breakout rooms
+func (s *BreakoutRoomService) GetBreakoutRooms(ctx context.Context, room string) (*livekit.Room, []*livekit.Room, error) {
+	AppendLogFields(ctx, "room", room)
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, nil, err
+	}
+	parent, err := s.scopeRoomName(ctx, room)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentRoom, _, err := s.store.LoadRoom(ctx, parent, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	labels, err := s.store.LoadRoomLabels(ctx, parent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	children := BreakoutRooms(labels)
+	rooms := make([]*livekit.Room, 0, len(children))
+	if len(children) != 0 {
+		if rooms, err = s.store.ListRooms(ctx, children); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, rm := range rooms {
+		rooms[i] = unscopeRoom(rm)
+	}
+	return unscopeRoom(parentRoom), rooms, nil
+}
+
+// MoveParticipant moves the participant between a room and its breakout rooms, or between breakout rooms of a room
+func (s *BreakoutRoomService) MoveParticipant(ctx context.Context, room string, identity string, destinationRoom string) (*livekit.ParticipantInfo, error) {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, err
+	}
+	roomName, err := s.scopeRoomName(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+	dstRoomName, err := s.scopeRoomName(ctx, destinationRoom)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.store.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	dstLabels, err := s.store.LoadRoomLabels(ctx, dstRoomName)
+	if err != nil {
+		return nil, err
+	}
+	parent, isBreakout := BreakoutRoomParent(labels)
+	dstParent, dstIsBreakout := BreakoutRoomParent(dstLabels)
+	switch {
+	case isBreakout && parent == dstRoomName:
+	case dstIsBreakout && dstParent == roomName:
+	case isBreakout && dstIsBreakout && parent == dstParent:
+	default:
+		return nil, ErrNotBreakoutRoom
+	}
+
+	participant, err := s.moveService.MoveParticipant(ctx, room, identity, destinationRoom)
+	if err != nil {
+		return nil, err
+	}
+	return participant, nil
+}
+
+func (s *BreakoutRoomService) scopeRoomName(ctx context.Context, room string) (livekit.RoomName, error) {
+	if room == "" {
+		return "", ErrRoomNotFound
+	}
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return "", err
+		}
+	}
+	return livekit.RoomName(room), nil
+}
+
+func (s *BreakoutRoomService) setParent(ctx context.Context, child livekit.RoomName, parent livekit.RoomName) error {
+	_, err := changeRoomLabels(ctx, s.store, child, func(labels RoomLabels) (bool, error) {
+		labels[breakoutParentLabel] = string(parent)
+		return true, nil
+	})
+	return err
+}
+
+type breakoutRoomsRequest struct {
+	Room  string            `json:"room"`
+	Rooms []json.RawMessage `json:"rooms"`
+}
+
+type breakoutRoomsResponse struct {
+	Room          json.RawMessage   `json:"room,omitempty"`
+	BreakoutRooms []json.RawMessage `json:"breakout_rooms"`
+}
+
+func (s *BreakoutRoomService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			parent, rooms, err := s.GetBreakoutRooms(r.Context(), r.URL.Query().Get("room"))
+			if err != nil {
+				return nil, err
+			}
+			return newBreakoutRoomsResponse(parent, rooms)
+		},
+		http.MethodPost: jsonRequest(maxBreakoutRoomsRequestSize, func(ctx context.Context, req *breakoutRoomsRequest) (interface{}, error) {
+			reqs, options, err := parseCreateRoomRequests(req.Rooms)
+			if err != nil {
+				return nil, err
+			}
+			rooms, err := s.CreateBreakoutRooms(withRoomRequestFields(ctx, options), req.Room, reqs)
+			if err != nil {
+				return nil, err
+			}
+			return newBreakoutRoomsResponse(nil, rooms)
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			return nil, s.CloseBreakoutRooms(r.Context(), r.URL.Query().Get("room"))
+		},
+	}.ServeHTTP(w, r)
+}
+
+func newBreakoutRoomsResponse(parent *livekit.Room, rooms []*livekit.Room) (*breakoutRoomsResponse, error) {
+	res := &breakoutRoomsResponse{}
+	var err error
+	if parent != nil {
+		if res.Room, err = protojson.Marshal(parent); err != nil {
+			return nil, err
+		}
+	}
+	res.BreakoutRooms, err = protoJSONList(rooms)
+	return res, err
+}
+
+// ServeMove moves participants at /breakout_rooms/move
+func (s *BreakoutRoomService) ServeMove(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxMoveParticipantRequestSize, func(ctx context.Context, req *moveParticipantRequest) (interface{}, error) {
+			return s.MoveParticipant(ctx, req.Room, req.Identity, req.DestinationRoom)
+		}),
+	}.ServeHTTP(w, r)
+}
+
+// closeBreakoutRooms closes the breakout rooms of a room that has ended, and removes a breakout room that has ended
+// from its parent room
+func (r *RoomManager) closeBreakoutRooms(ctx context.Context, roomName livekit.RoomName) {
+	labels, err := r.roomStore.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		return
+	}
+
+	for _, child := range BreakoutRooms(labels) {
+		if room := r.GetRoom(ctx, child); room != nil {
+			room.Logger.Infow("closing breakout room of ended room", "parent", roomName)
+			for _, p := range room.GetParticipants() {
+				_ = p.Close(true, types.ParticipantCloseReasonServiceRequestDeleteRoom, false)
+			}
+			room.CloseWithReason(types.RoomCloseReasonParentClosed)
+			continue
+		}
+
+		err = r.router.WriteRoomRTC(ctx, child, &livekit.RTCNodeMessage{
+			Message: &livekit.RTCNodeMessage_DeleteRoom{
+				DeleteRoom: &livekit.DeleteRoomRequest{Room: string(child)},
+			},
+		})
+		if err != nil {
+			// not hosted by any node
+			if err = r.roomStore.DeleteRoom(ctx, child); err != nil && err != ErrRoomNotFound {
+				logger.Warnw("could not delete breakout room of ended room", err, "room", child, "parent", roomName)
+			}
+		}
+	}
+
+	if parent, ok := BreakoutRoomParent(labels); ok {
+		r.unlinkBreakoutRoom(ctx, parent, roomName)
+	}
+}
+
+// unlinkBreakoutRoom removes a breakout room that has ended from the labels and metadata of its parent room
+func (r *RoomManager) unlinkBreakoutRoom(ctx context.Context, parent livekit.RoomName, child livekit.RoomName) {
+	parentRoom, _, err := r.roomStore.LoadRoom(ctx, parent, false)
+	if err != nil {
+		// the parent has ended
+		return
+	}
+	if err = linkBreakoutRooms(ctx, r.roomStore, parent, []livekit.RoomName{child}, false); err != nil {
+		logger.Warnw("could not remove breakout room from parent", err, "room", child, "parent", parent)
+		return
+	}
+
+	patch, ok := breakoutRoomsPatch(parentRoom.Metadata, []livekit.RoomName{child}, false)
+	if !ok {
+		return
+	}
+	err = r.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:    patchRoomMetadataCommand,
+		Room:    parent,
+		Payload: []byte(patch),
+	})
+	if err != nil {
+		logger.Warnw("could not remove breakout room from metadata of parent", err, "room", child, "parent", parent)
+	}
+}
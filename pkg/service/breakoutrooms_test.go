@@ -0,0 +1,144 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestBreakoutRoomService(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomCreate: true, RoomList: true},
+	})
+
+	store := service.NewLocalStore()
+	router := &routingfakes.FakeRouter{}
+	router.StartParticipantSignalReturns("", &routingfakes.FakeMessageSink{}, &routingfakes.FakeMessageSource{}, nil)
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "ND_main"}, nil)
+	// applies metadata patches of the breakout rooms as the node hosting the room would
+	router.WriteRoomCommandStub = func(ctx context.Context, cmd *routing.RoomCommand) error {
+		rm, _, err := store.LoadRoom(ctx, cmd.Room, false)
+		if err != nil {
+			return err
+		}
+		metadata := map[string]map[string]interface{}{}
+		if rm.Metadata != "" {
+			require.NoError(t, json.Unmarshal([]byte(rm.Metadata), &metadata))
+		}
+		patch := map[string]map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(cmd.Payload, &patch))
+		for key, rooms := range patch {
+			if metadata[key] == nil {
+				metadata[key] = map[string]interface{}{}
+			}
+			for name, value := range rooms {
+				if value == nil {
+					delete(metadata[key], name)
+				} else {
+					metadata[key][name] = value
+				}
+			}
+		}
+		data, err := json.Marshal(metadata)
+		require.NoError(t, err)
+		rm.Metadata = string(data)
+		return store.StoreRoom(ctx, rm, nil)
+	}
+	allocator := &servicefakes.FakeRoomAllocator{}
+	allocator.CreateRoomStub = func(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+		if rm, _, err := store.LoadRoom(ctx, livekit.RoomName(req.Name), false); err == nil {
+			return rm, nil
+		}
+		rm := &livekit.Room{Name: req.Name}
+		return rm, store.StoreRoom(ctx, rm, nil)
+	}
+	roomService, err := service.NewRoomService(config.RoomConfig{}, config.APIConfig{ExecutionTimeout: time.Second, CheckInterval: 10 * time.Millisecond},
+		router, allocator, store, nil, nil)
+	require.NoError(t, err)
+	batchService := service.NewRoomBatchService(roomService, store)
+	moveService := service.NewParticipantMoveService(roomService, router, store)
+	svc := service.NewBreakoutRoomService(roomService, batchService, moveService, router, store)
+
+	_, err = roomService.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "main"})
+	require.NoError(t, err)
+	_, err = roomService.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "lobby"})
+	require.NoError(t, err)
+
+	t.Run("creates breakout rooms of a room", func(t *testing.T) {
+		rooms, err := svc.CreateBreakoutRooms(ctx, "main", []*livekit.CreateRoomRequest{{Name: "breakout-1"}, {Name: "breakout-2"}})
+		require.NoError(t, err)
+		require.Len(t, rooms, 2)
+		require.Equal(t, 2, router.SetNodeForRoomCallCount())
+
+		parent, children, err := svc.GetBreakoutRooms(ctx, "main")
+		require.NoError(t, err)
+		require.Len(t, children, 2)
+		require.JSONEq(t, `{"livekit.io/breakout_rooms":{"breakout-1":true,"breakout-2":true}}`, parent.Metadata)
+
+		labels, err := store.LoadRoomLabels(ctx, "breakout-1")
+		require.NoError(t, err)
+		p, ok := service.BreakoutRoomParent(labels)
+		require.True(t, ok)
+		require.Equal(t, livekit.RoomName("main"), p)
+
+		_, err = svc.CreateBreakoutRooms(ctx, "breakout-1", []*livekit.CreateRoomRequest{{Name: "breakout-3"}})
+		require.ErrorIs(t, err, service.ErrInvalidBreakoutRoom)
+		_, err = svc.CreateBreakoutRooms(ctx, "retro", []*livekit.CreateRoomRequest{{Name: "breakout-3"}})
+		require.ErrorIs(t, err, service.ErrRoomNotFound)
+	})
+
+	t.Run("moves participants between related rooms only", func(t *testing.T) {
+		_, err := svc.MoveParticipant(ctx, "lobby", "alice", "breakout-1")
+		require.ErrorIs(t, err, service.ErrNotBreakoutRoom)
+		_, err = svc.MoveParticipant(ctx, "main", "alice", "lobby")
+		require.ErrorIs(t, err, service.ErrNotBreakoutRoom)
+
+		// alice is not in the room
+		_, err = svc.MoveParticipant(ctx, "breakout-1", "alice", "breakout-2")
+		require.ErrorIs(t, err, service.ErrParticipantNotFound)
+	})
+
+	t.Run("closes breakout rooms of a room", func(t *testing.T) {
+		require.NoError(t, svc.CloseBreakoutRooms(ctx, "main"))
+
+		parent, children, err := svc.GetBreakoutRooms(ctx, "main")
+		require.NoError(t, err)
+		require.Empty(t, children)
+		require.JSONEq(t, `{"livekit.io/breakout_rooms":{}}`, parent.Metadata)
+		_, _, err = store.LoadRoom(ctx, "breakout-1", false)
+		require.ErrorIs(t, err, service.ErrRoomNotFound)
+	})
+
+	t.Run("requires permissions", func(t *testing.T) {
+		_, err := svc.CreateBreakoutRooms(context.Background(), "main", []*livekit.CreateRoomRequest{{Name: "breakout-3"}})
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+		_, _, err = svc.GetBreakoutRooms(context.Background(), "main")
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+}
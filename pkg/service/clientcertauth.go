@@ -0,0 +1,195 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+var (
+	ErrClientCertRequired   = errors.New("client certificate required")
+	ErrClientCertNotAllowed = errors.New("client certificate identity is not allowed")
+)
+
+type clientCertIdentityKey struct{}
+
+// ClientCertAuthMiddleware authenticates admin API requests with the verified client certificate of their TLS
+// connection. it precedes the authentication middleware, which does not look for a token on requests
+// authenticated by their certificate
+type ClientCertAuthMiddleware struct {
+	config config.AdminMTLSConfig
+	// whether projects are configured, identities are then bound to the project of their config
+	projects bool
+	// networks identities are allowed from, identities without networks are allowed from any address
+	allowedNetworks map[string][]*net.IPNet
+}
+
+func NewClientCertAuthMiddleware(conf config.AdminMTLSConfig, projects bool) *ClientCertAuthMiddleware {
+	allowedNetworks := make(map[string][]*net.IPNet)
+	for identity, ic := range conf.Identities {
+		if len(ic.IPAllowlist) != 0 {
+			// entries are validated with the config
+			allowedNetworks[identity], _ = config.ParseIPNetworks(ic.IPAllowlist)
+		}
+	}
+	return &ClientCertAuthMiddleware{
+		config:          conf,
+		projects:        projects,
+		allowedNetworks: allowedNetworks,
+	}
+}
+
+func (m *ClientCertAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL == nil || !isAdminPath(r.URL.Path) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		if m.config.Required {
+			writeClientCertError(w, r, twirp.Unauthenticated, http.StatusUnauthorized, ErrClientCertRequired)
+			return
+		}
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	identity, ok := m.identity(r.TLS.VerifiedChains[0][0])
+	setAuditIdentity(r.Context(), identity)
+	if !ok {
+		logger.Infow("client certificate identity is not allowed", "identity", identity, "path", r.URL.Path)
+		writeClientCertError(w, r, twirp.PermissionDenied, http.StatusForbidden, ErrClientCertNotAllowed)
+		return
+	}
+	if !m.ipAllowed(identity, GetClientIP(r)) {
+		logger.Infow("client certificate identity is not allowed from address", "identity", identity, "clientIP", GetClientIP(r))
+		writeClientCertError(w, r, twirp.PermissionDenied, http.StatusForbidden, ErrIPNotAllowed)
+		return
+	}
+	// identities without scopes are not allowed any request
+	ic := m.config.Identities[identity]
+	if len(ic.Scopes) == 0 || !scopesAllow(ic.Scopes, r.Method, r.URL.Path) {
+		logger.Infow("request not in scopes of client certificate", "identity", identity, "path", r.URL.Path)
+		writeClientCertError(w, r, twirp.PermissionDenied, http.StatusForbidden, ErrPermissionDenied)
+		return
+	}
+
+	ctx := WithGrants(r.Context(), clientCertGrants(identity))
+	if m.projects {
+		ctx = WithProject(ctx, ic.Project)
+	}
+	next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, clientCertIdentityKey{}, identity)))
+}
+
+func (m *ClientCertAuthMiddleware) ipAllowed(identity string, clientIP string) bool {
+	networks, ok := m.allowedNetworks[identity]
+	if !ok {
+		return true
+	}
+	if ip := net.ParseIP(clientIP); ip != nil {
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// identity returns the identity of the certificate that is listed in the config, its URI SANs are matched before its
+// common name. ok is false when none are listed, identity is then the common name
+func (m *ClientCertAuthMiddleware) identity(cert *x509.Certificate) (identity string, ok bool) {
+	for _, uri := range cert.URIs {
+		if _, ok = m.config.Identities[uri.String()]; ok {
+			return uri.String(), true
+		}
+	}
+	_, ok = m.config.Identities[cert.Subject.CommonName]
+	return cert.Subject.CommonName, ok
+}
+
+// clientCertGrants are the grants of requests authenticated by a client certificate, they are limited by the scopes and
+// the project of its identity rather than by grants
+func clientCertGrants(identity string) *auth.ClaimGrants {
+	return &auth.ClaimGrants{
+		Identity: identity,
+		Video: &auth.VideoGrant{
+			RoomCreate:   true,
+			RoomList:     true,
+			RoomRecord:   true,
+			RoomAdmin:    true,
+			IngressAdmin: true,
+		},
+	}
+}
+
+// GetClientCertIdentity returns the identity of the client certificate the request is authenticated by, ok is false
+// for requests that are not
+func GetClientCertIdentity(ctx context.Context) (identity string, ok bool) {
+	identity, ok = ctx.Value(clientCertIdentityKey{}).(string)
+	return
+}
+
+// isAdminPath returns whether the path is of the Twirp or HTTP admin APIs. signal connections, health checks, debug
+// handlers and public keys are not
+func isAdminPath(urlPath string) bool {
+	return urlPath != "/" && !isPathOf(urlPath, "/rtc") && !isPathOf(urlPath, "/debug") &&
+		!isPathOf(urlPath, "/.well-known")
+}
+
+func writeClientCertError(w http.ResponseWriter, r *http.Request, code twirp.ErrorCode, status int, err error) {
+	if strings.HasPrefix(r.URL.Path, twirpPathPrefix) || strings.HasPrefix(r.URL.Path, restGatewayPrefix) {
+		_ = twirp.WriteError(w, twirp.NewError(code, err.Error()))
+		return
+	}
+	handleError(w, status, err)
+}
+
+// newAdminTLSConfig serves TLS with the certificate of the config, and verifies the client certificates that are
+// given. requests are left to the middleware to reject without a certificate, clients joining rooms have none
+func newAdminTLSConfig(conf config.AdminMTLSConfig) (*tls.Config, error) {
+	cert, err := config.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load admin mtls cert")
+	}
+	pem, err := config.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read admin mtls client ca")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in admin mtls client ca file %s", conf.ClientCAFile)
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}, nil
+}
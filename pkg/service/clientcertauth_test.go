@@ -0,0 +1,153 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestClientCertAuthMiddleware(t *testing.T) {
+	spiffeID, err := url.Parse("spiffe://example.org/egress-worker")
+	require.NoError(t, err)
+	conf := config.AdminMTLSConfig{
+		CertFile:     "server.pem",
+		KeyFile:      "server-key.pem",
+		ClientCAFile: "ca.pem",
+		Required:     true,
+		Identities: map[string]config.ClientCertIdentityConfig{
+			spiffeID.String(): {Scopes: []config.APIKeyScope{config.APIKeyScopeEgressOnly}},
+			"deploy-bot":      {Scopes: []config.APIKeyScope{config.APIKeyScopeAdmin}},
+			"customer-bot":    {Scopes: []config.APIKeyScope{config.APIKeyScopeAdmin}, Project: "customer-a"},
+			"office-bot": {
+				Scopes:      []config.APIKeyScope{config.APIKeyScopeAdmin},
+				IPAllowlist: []string{"192.168.1.0/24"},
+			},
+			"disabled": {},
+		},
+	}
+	m := service.NewClientCertAuthMiddleware(conf, false)
+
+	var identity string
+	var authenticated bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, authenticated = service.GetClientCertIdentity(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(path string, cert *x509.Certificate) int {
+		identity, authenticated = "", false
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = "192.168.1.10:50000"
+		if cert != nil {
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		return w.Code
+	}
+	workerCert := &x509.Certificate{Subject: pkix.Name{CommonName: "worker"}, URIs: []*url.URL{spiffeID}}
+	botCert := &x509.Certificate{Subject: pkix.Name{CommonName: "deploy-bot"}}
+
+	t.Run("certificate required", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve("/room_batch", nil))
+		require.False(t, authenticated)
+		// signal connections and health checks do not have certificates
+		require.Equal(t, http.StatusOK, serve("/rtc/validate", nil))
+		require.Equal(t, http.StatusOK, serve("/", nil))
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve("/room_batch", botCert))
+		require.True(t, authenticated)
+		require.Equal(t, "deploy-bot", identity)
+
+		require.Equal(t, http.StatusForbidden, serve("/room_batch", &x509.Certificate{Subject: pkix.Name{CommonName: "other"}}))
+		require.False(t, authenticated)
+	})
+
+	t.Run("identity without scopes", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, serve("/room_batch", &x509.Certificate{Subject: pkix.Name{CommonName: "disabled"}}))
+		require.False(t, authenticated)
+	})
+
+	t.Run("IP allowlist", func(t *testing.T) {
+		officeCert := &x509.Certificate{Subject: pkix.Name{CommonName: "office-bot"}}
+		require.Equal(t, http.StatusOK, serve("/room_batch", officeCert))
+
+		r := httptest.NewRequest(http.MethodPost, "/room_batch", nil)
+		r.RemoteAddr = "10.1.2.3:50000"
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{officeCert}}}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve("/twirp/livekit.Egress/ListEgress", workerCert))
+		require.Equal(t, spiffeID.String(), identity)
+		require.NotEqual(t, http.StatusOK, serve("/twirp/livekit.RoomService/CreateRoom", workerCert))
+		require.False(t, authenticated)
+	})
+
+	t.Run("admin of all rooms", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/room_batch", nil)
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{botCert}}}
+		m.ServeHTTP(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, service.EnsureAdminPermission(r.Context(), livekit.RoomName("any-room")))
+			require.NoError(t, service.EnsureCreatePermission(r.Context()))
+		})
+	})
+
+	t.Run("admin of the rooms of its project", func(t *testing.T) {
+		m := service.NewClientCertAuthMiddleware(conf, true)
+		serveAs := func(commonName string, check func(r *http.Request)) {
+			r := httptest.NewRequest(http.MethodPost, "/room_batch", nil)
+			r.RemoteAddr = "192.168.1.10:50000"
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}}}
+			called := false
+			m.ServeHTTP(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				check(r)
+			})
+			require.True(t, called)
+		}
+
+		serveAs("customer-bot", func(r *http.Request) {
+			project, ok := service.GetProject(r.Context())
+			require.True(t, ok)
+			require.Equal(t, "customer-a", project)
+			require.NoError(t, service.EnsureAdminPermission(r.Context(), livekit.RoomName("customer-a|room")))
+			require.ErrorIs(t, service.EnsureAdminPermission(r.Context(), livekit.RoomName("customer-b|room")), service.ErrPermissionDenied)
+			require.ErrorIs(t, service.EnsureAdminPermission(r.Context(), livekit.RoomName("room")), service.ErrPermissionDenied)
+		})
+		// identities without a project are of the default project
+		serveAs("deploy-bot", func(r *http.Request) {
+			require.NoError(t, service.EnsureAdminPermission(r.Context(), livekit.RoomName("room")))
+			require.ErrorIs(t, service.EnsureAdminPermission(r.Context(), livekit.RoomName("customer-a|room")), service.ErrPermissionDenied)
+		})
+	})
+}
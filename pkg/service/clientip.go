@@ -0,0 +1,96 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ClientIPMiddleware resolves the address of the client of requests, for the middlewares and handlers after it.
+// forwarded headers are only honored on requests of trusted proxies, as any client can set them
+type ClientIPMiddleware struct {
+	trustedProxies []*net.IPNet
+}
+
+func NewClientIPMiddleware(trustedProxies []*net.IPNet) *ClientIPMiddleware {
+	return &ClientIPMiddleware{
+		trustedProxies: trustedProxies,
+	}
+}
+
+func (m *ClientIPMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := context.WithValue(r.Context(), clientIPKey{}, m.clientIP(r))
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// clientIP returns the peer of the request, or when it is a trusted proxy, the address it forwards the request of.
+// of X-Forwarded-For, that is the right-most address that is not a trusted proxy, as proxies append the address of
+// their peer to what the client sent
+func (m *ClientIPMiddleware) clientIP(r *http.Request) string {
+	peer := remoteIP(r)
+	if !m.isTrusted(peer) {
+		return peer
+	}
+
+	// CF proxy typically is first thing the user reaches
+	if ip := r.Header.Get("CF-Connecting-IP"); net.ParseIP(ip) != nil {
+		return ip
+	}
+	if forwardedFor := r.Header.Values("X-Forwarded-For"); len(forwardedFor) != 0 {
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			client = hop
+			if !m.isTrusted(hop) {
+				break
+			}
+		}
+		return client
+	}
+	if ip := r.Header.Get("X-Real-IP"); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return peer
+}
+
+func (m *ClientIPMiddleware) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range m.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
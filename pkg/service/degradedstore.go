@@ -0,0 +1,287 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+// degradedStore keeps the rooms and participants written by this node in memory. While redis is unavailable
+// they are read from and written to memory only, rooms written meanwhile are written back once redis is
+// available again.
+type degradedStore struct {
+	ObjectStore
+
+	health *routing.RedisHealth
+	local  *LocalStore
+
+	lock sync.Mutex
+	// rooms written to memory only, true when the room has been deleted
+	dirty map[livekit.RoomName]bool
+}
+
+func newDegradedStore(store ObjectStore, health *routing.RedisHealth) *degradedStore {
+	s := &degradedStore{
+		ObjectStore: store,
+		health:      health,
+		local:       NewLocalStore(),
+		dirty:       make(map[livekit.RoomName]bool),
+	}
+	health.OnChange(func(available bool) {
+		if available {
+			s.writeBack(context.Background())
+		}
+	})
+	return s
+}
+
+func (s *degradedStore) unwrap() ObjectStore {
+	return s.ObjectStore
+}
+
+func (s *degradedStore) markDirty(roomName livekit.RoomName, deleted bool) {
+	s.lock.Lock()
+	// a room deleted and written again is written back, a room deleted and then only written to is deleted
+	s.dirty[roomName] = s.dirty[roomName] || deleted
+	s.lock.Unlock()
+}
+
+// write writes to memory, then to the store unless redis is unavailable. Memory only holds rooms written by
+// this node, writes of other rooms go to the store alone. Rooms that could not be written to the store are
+// written back once it is available.
+func (s *degradedStore) write(roomName livekit.RoomName, deleted bool, writeLocal func() error, writeStore func() error) error {
+	localErr := writeLocal()
+	if !s.health.IsAvailable() {
+		if localErr != nil {
+			return localErr
+		}
+		s.markDirty(roomName, deleted)
+		return nil
+	}
+	if err := writeStore(); err != nil {
+		if localErr == nil {
+			s.markDirty(roomName, deleted)
+		}
+		return err
+	}
+	return nil
+}
+
+// writeBack replaces rooms written while redis was unavailable with their state in memory
+func (s *degradedStore) writeBack(ctx context.Context) {
+	s.lock.Lock()
+	dirty := s.dirty
+	s.dirty = make(map[livekit.RoomName]bool)
+	s.lock.Unlock()
+
+	for roomName, deleted := range dirty {
+		if err := s.writeBackRoom(ctx, roomName, deleted); err != nil {
+			logger.Warnw("could not write back room", err, "room", roomName)
+			s.markDirty(roomName, deleted)
+		}
+	}
+	if len(dirty) > 0 {
+		logger.Infow("wrote back rooms changed while redis was unavailable", "rooms", len(dirty))
+	}
+}
+
+func (s *degradedStore) writeBackRoom(ctx context.Context, roomName livekit.RoomName, deleted bool) error {
+	room, internal, err := s.local.LoadRoom(ctx, roomName, true)
+	if err == ErrRoomNotFound {
+		// rooms not written by this node are left as they are
+		if deleted {
+			return s.ObjectStore.DeleteRoom(ctx, roomName)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = s.ObjectStore.StoreRoom(ctx, room, internal); err != nil {
+		return err
+	}
+	if labels, err := s.local.LoadRoomLabels(ctx, roomName); err == nil && labels != nil {
+		if err = s.ObjectStore.StoreRoomLabels(ctx, roomName, labels); err != nil {
+			return err
+		}
+	}
+
+	participants, err := s.local.ListParticipants(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool, len(participants))
+	for _, p := range participants {
+		current[p.Identity] = true
+		if err = s.ObjectStore.StoreParticipant(ctx, roomName, p); err != nil {
+			return err
+		}
+	}
+	stored, err := s.ObjectStore.ListParticipants(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	for _, p := range stored {
+		if !current[p.Identity] {
+			if err = s.ObjectStore.DeleteParticipant(ctx, roomName, livekit.ParticipantIdentity(p.Identity)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *degradedStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	if !s.health.IsAvailable() {
+		return s.local.LoadRoom(ctx, roomName, includeInternal)
+	}
+	return s.ObjectStore.LoadRoom(ctx, roomName, includeInternal)
+}
+
+func (s *degradedStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	if !s.health.IsAvailable() {
+		return s.local.ListRooms(ctx, roomNames)
+	}
+	return s.ObjectStore.ListRooms(ctx, roomNames)
+}
+
+func (s *degradedStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	if !s.health.IsAvailable() {
+		return s.local.ListRoomsPage(ctx, opts)
+	}
+	return s.ObjectStore.ListRoomsPage(ctx, opts)
+}
+
+func (s *degradedStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	if !s.health.IsAvailable() {
+		return s.local.LoadRoomLabels(ctx, roomName)
+	}
+	return s.ObjectStore.LoadRoomLabels(ctx, roomName)
+}
+
+func (s *degradedStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	return s.write(roomName, false, func() error {
+		return s.local.StoreRoomLabels(ctx, roomName, labels)
+	}, func() error {
+		return s.ObjectStore.StoreRoomLabels(ctx, roomName, labels)
+	})
+}
+
+func (s *degradedStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	if !s.health.IsAvailable() {
+		return s.local.LoadRoomVersion(ctx, roomName)
+	}
+	return s.ObjectStore.LoadRoomVersion(ctx, roomName)
+}
+
+func (s *degradedStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	roomName := livekit.RoomName(room.Name)
+	if !s.health.IsAvailable() {
+		version, err := s.local.UpdateRoom(ctx, room, expectedVersion)
+		if err == nil {
+			s.markDirty(roomName, false)
+		}
+		return version, err
+	}
+
+	// versions of the store and memory differ, memory only follows the room
+	version, err := s.ObjectStore.UpdateRoom(ctx, room, expectedVersion)
+	if err != nil {
+		return version, err
+	}
+	if _, internal, err := s.local.LoadRoom(ctx, roomName, true); err == nil {
+		_ = s.local.StoreRoom(ctx, room, internal)
+	}
+	return version, nil
+}
+
+func (s *degradedStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	if !s.health.IsAvailable() {
+		return s.local.LoadParticipant(ctx, roomName, identity)
+	}
+	return s.ObjectStore.LoadParticipant(ctx, roomName, identity)
+}
+
+func (s *degradedStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	if !s.health.IsAvailable() {
+		return s.local.ListParticipants(ctx, roomName)
+	}
+	return s.ObjectStore.ListParticipants(ctx, roomName)
+}
+
+func (s *degradedStore) LockRoom(ctx context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	if !s.health.IsAvailable() {
+		return s.local.LockRoom(ctx, roomName, duration)
+	}
+	return s.ObjectStore.LockRoom(ctx, roomName, duration)
+}
+
+func (s *degradedStore) UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error {
+	if !s.health.IsAvailable() {
+		return s.local.UnlockRoom(ctx, roomName, uid)
+	}
+	return s.ObjectStore.UnlockRoom(ctx, roomName, uid)
+}
+
+func (s *degradedStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	return s.write(livekit.RoomName(room.Name), false, func() error {
+		return s.local.StoreRoom(ctx, room, internal)
+	}, func() error {
+		return s.ObjectStore.StoreRoom(ctx, room, internal)
+	})
+}
+
+func (s *degradedStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	return s.write(roomName, true, func() error {
+		return s.local.DeleteRoom(ctx, roomName)
+	}, func() error {
+		return s.ObjectStore.DeleteRoom(ctx, roomName)
+	})
+}
+
+func (s *degradedStore) DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	if err := s.local.DeleteRooms(ctx, roomNames); err != nil {
+		return err
+	}
+	if !s.health.IsAvailable() {
+		for _, roomName := range roomNames {
+			s.markDirty(roomName, true)
+		}
+		return nil
+	}
+	return deleteRooms(ctx, s.ObjectStore, roomNames)
+}
+
+func (s *degradedStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	return s.write(roomName, false, func() error {
+		return s.local.StoreParticipant(ctx, roomName, participant)
+	}, func() error {
+		return s.ObjectStore.StoreParticipant(ctx, roomName, participant)
+	})
+}
+
+func (s *degradedStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	return s.write(roomName, false, func() error {
+		return s.local.DeleteParticipant(ctx, roomName, identity)
+	}, func() error {
+		return s.ObjectStore.DeleteParticipant(ctx, roomName, identity)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+func TestDegradedStore(t *testing.T) {
+	ctx := context.Background()
+	backend := NewLocalStore()
+	require.NoError(t, backend.StoreRoom(ctx, &livekit.Room{Name: "other"}, nil))
+
+	// nothing listens on the port, the first probe fails
+	rc := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+	defer rc.Close()
+	conf := &config.Config{}
+	conf.Redis.Health = config.RedisHealthConfig{ProbeInterval: 10 * time.Millisecond, FailureThreshold: 1}
+	health := routing.NewRedisHealth(conf, rc)
+	store := newDegradedStore(backend, health)
+
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "local"}, nil))
+	require.NoError(t, store.StoreParticipant(ctx, "local", &livekit.ParticipantInfo{Identity: "alice"}))
+
+	health.Start()
+	defer health.Stop()
+	require.Eventually(t, func() bool {
+		return !health.IsAvailable()
+	}, time.Second, 10*time.Millisecond)
+
+	// rooms of this node are served from memory
+	rooms, err := store.ListRooms(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	require.Equal(t, "local", rooms[0].Name)
+
+	require.NoError(t, store.StoreParticipant(ctx, "local", &livekit.ParticipantInfo{Identity: "bob"}))
+	require.NoError(t, store.DeleteParticipant(ctx, "local", "alice"))
+	participants, err := store.ListParticipants(ctx, "local")
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+	require.Equal(t, "bob", participants[0].Identity)
+
+	// and written back once redis is available
+	participants, err = backend.ListParticipants(ctx, "local")
+	require.NoError(t, err)
+	require.Equal(t, "alice", participants[0].Identity)
+	store.writeBack(ctx)
+	participants, err = backend.ListParticipants(ctx, "local")
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+	require.Equal(t, "bob", participants[0].Identity)
+
+	// rooms of other nodes are left as they are
+	_, _, err = backend.LoadRoom(ctx, "other", false)
+	require.NoError(t, err)
+}
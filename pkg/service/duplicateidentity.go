@@ -0,0 +1,172 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+var (
+	ErrDuplicateIdentity        = psrpc.NewErrorf(psrpc.AlreadyExists, "a participant with the identity is already in the room")
+	ErrInvalidDuplicateIdentity = psrpc.NewErrorf(psrpc.InvalidArgument, "duplicate identity policy must be replace, reject or suffix")
+)
+
+// DuplicateIdentityPolicy is what happens when a participant joins a room with the identity of a participant in it
+type DuplicateIdentityPolicy string
+
+const (
+	// the participant in the room is removed, the default
+	DuplicateIdentityReplace DuplicateIdentityPolicy = "replace"
+	// the join is rejected while the participant in the room is connected, such as with kiosks
+	DuplicateIdentityReject DuplicateIdentityPolicy = "reject"
+	// the participant joins with the identity suffixed by "_2", "_3" and so on, the first suffix not in the room.
+	// such as with accounts shared by several people
+	DuplicateIdentitySuffix DuplicateIdentityPolicy = "suffix"
+)
+
+const (
+	// sets the policy of the room, on CreateRoom requests and their responses. the policy of a room is read by the
+	// node hosting it as participants join, it can be changed by later CreateRoom requests
+	roomDuplicateIdentityHeader = "X-Livekit-Room-Duplicate-Identity"
+
+	roomDuplicateIdentityLabel = reservedLabelPrefix + "duplicate-identity"
+
+	duplicateIdentitySeparator = "_"
+)
+
+func roomDuplicateIdentityFromRequest(ctx context.Context) (DuplicateIdentityPolicy, error) {
+	value, ok := lookupRequestOption(ctx, roomDuplicateIdentityOption)
+	if !ok {
+		return "", nil
+	}
+	switch policy := DuplicateIdentityPolicy(strings.ToLower(value)); policy {
+	case DuplicateIdentityReplace, DuplicateIdentityReject, DuplicateIdentitySuffix:
+		return policy, nil
+	default:
+		return "", ErrInvalidDuplicateIdentity
+	}
+}
+
+// RoomDuplicateIdentity returns the duplicate identity policy of the labels of a room
+func RoomDuplicateIdentity(labels RoomLabels) DuplicateIdentityPolicy {
+	if policy, ok := labels[roomDuplicateIdentityLabel]; ok {
+		return DuplicateIdentityPolicy(policy)
+	}
+	return DuplicateIdentityReplace
+}
+
+// setRoomDuplicateIdentity keeps the policy with the labels of the room, the default policy is not kept
+func (s *RoomService) setRoomDuplicateIdentity(ctx context.Context, roomName livekit.RoomName, policy DuplicateIdentityPolicy) error {
+	_, err := changeRoomLabels(ctx, s.roomStore, roomName, func(labels RoomLabels) (bool, error) {
+		if RoomDuplicateIdentity(labels) == policy {
+			return false, nil
+		}
+		if policy == DuplicateIdentityReplace {
+			delete(labels, roomDuplicateIdentityLabel)
+		} else {
+			labels[roomDuplicateIdentityLabel] = string(policy)
+		}
+		return true, nil
+	})
+	return err
+}
+
+// ensureIdentityAvailable rejects joins with the identity of a participant in a room that rejects duplicates, before
+// the signal connection is started. the node hosting the room enforces the policy as the participant joins
+func (s *RTCService) ensureIdentityAvailable(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, reconnect bool) error {
+	if reconnect {
+		return nil
+	}
+	labels, err := s.store.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		if err == ErrRoomNotFound {
+			return nil
+		}
+		return err
+	}
+	if RoomDuplicateIdentity(labels) != DuplicateIdentityReject {
+		return nil
+	}
+	if _, err = s.store.LoadParticipant(ctx, roomName, identity); err == nil {
+		return ErrDuplicateIdentity
+	}
+	return nil
+}
+
+// suffixedIdentity returns the identity with the first suffix not in the room
+func suffixedIdentity(room *rtc.Room, identity livekit.ParticipantIdentity) livekit.ParticipantIdentity {
+	for n := 2; ; n++ {
+		suffixed := identity + livekit.ParticipantIdentity(duplicateIdentitySeparator+strconv.Itoa(n))
+		if room.GetParticipant(suffixed) == nil {
+			return suffixed
+		}
+	}
+}
+
+// isSuffixedIdentity returns whether identity is a suffixed identity of base
+func isSuffixedIdentity(identity livekit.ParticipantIdentity, base livekit.ParticipantIdentity) bool {
+	suffix, ok := strings.CutPrefix(string(identity), string(base)+duplicateIdentitySeparator)
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(suffix)
+	return err == nil && n >= 2
+}
+
+// resumedParticipant returns the participant a reconnecting session resumes, participants joined with a suffixed
+// identity are found by their ID as the token has the identity without the suffix
+func resumedParticipant(room *rtc.Room, identity livekit.ParticipantIdentity, participantID livekit.ParticipantID) types.LocalParticipant {
+	if participantID != "" {
+		if p := room.GetParticipantByID(participantID); p != nil && isSuffixedIdentity(p.Identity(), identity) {
+			return p
+		}
+	}
+	return room.GetParticipant(identity)
+}
+
+// resumedIdentity returns the identity of the participant a reconnecting session resumed, which has a suffix when
+// the participant joined a room with the identity of another
+func (s *RTCService) resumedIdentity(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, participantID livekit.ParticipantID) livekit.ParticipantIdentity {
+	if participantID == "" {
+		return identity
+	}
+	participants, err := s.store.ListParticipants(ctx, roomName)
+	if err != nil {
+		return identity
+	}
+	for _, p := range participants {
+		if livekit.ParticipantID(p.Sid) == participantID && isSuffixedIdentity(livekit.ParticipantIdentity(p.Identity), identity) {
+			return livekit.ParticipantIdentity(p.Identity)
+		}
+	}
+	return identity
+}
+
+// duplicateIdentityPolicy returns the policy of a room hosted by the node
+func (r *RoomManager) duplicateIdentityPolicy(ctx context.Context, roomName livekit.RoomName) (DuplicateIdentityPolicy, error) {
+	labels, err := r.roomStore.LoadRoomLabels(ctx, roomName)
+	if err != nil && err != ErrRoomNotFound {
+		return "", err
+	}
+	return RoomDuplicateIdentity(labels), nil
+}
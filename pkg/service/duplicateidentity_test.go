@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestDuplicateIdentity(t *testing.T) {
+	header := func(value string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{"X-Livekit-Room-Duplicate-Identity": {value}})
+	}
+	policy, err := roomDuplicateIdentityFromRequest(header("Reject"))
+	require.NoError(t, err)
+	require.Equal(t, DuplicateIdentityReject, policy)
+
+	_, err = roomDuplicateIdentityFromRequest(header("ignore"))
+	require.ErrorIs(t, err, ErrInvalidDuplicateIdentity)
+
+	policy, err = roomDuplicateIdentityFromRequest(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, policy)
+
+	t.Run("policy is kept with the labels of the room", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewLocalStore()
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "kiosk"}, nil))
+		s := &RoomService{roomStore: store}
+
+		require.NoError(t, s.setRoomDuplicateIdentity(ctx, "kiosk", DuplicateIdentityReject))
+		labels, err := store.LoadRoomLabels(ctx, "kiosk")
+		require.NoError(t, err)
+		require.Equal(t, DuplicateIdentityReject, RoomDuplicateIdentity(labels))
+		require.Empty(t, labels.withoutReserved())
+
+		require.NoError(t, s.setRoomDuplicateIdentity(ctx, "kiosk", DuplicateIdentityReplace))
+		labels, err = store.LoadRoomLabels(ctx, "kiosk")
+		require.NoError(t, err)
+		require.Equal(t, DuplicateIdentityReplace, RoomDuplicateIdentity(labels))
+		require.NotContains(t, labels, roomDuplicateIdentityLabel)
+	})
+
+	t.Run("joins with the identity of a participant are rejected", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewLocalStore()
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "kiosk"}, nil))
+		require.NoError(t, store.StoreParticipant(ctx, "kiosk", &livekit.ParticipantInfo{Sid: "PA_lobby", Identity: "lobby"}))
+		rs := &RoomService{roomStore: store}
+		s := &RTCService{store: store}
+
+		// duplicates replace participants by default
+		require.NoError(t, s.ensureIdentityAvailable(ctx, "kiosk", "lobby", false))
+
+		require.NoError(t, rs.setRoomDuplicateIdentity(ctx, "kiosk", DuplicateIdentityReject))
+		require.ErrorIs(t, s.ensureIdentityAvailable(ctx, "kiosk", "lobby", false), ErrDuplicateIdentity)
+		require.NoError(t, s.ensureIdentityAvailable(ctx, "kiosk", "lobby", true))
+		require.NoError(t, s.ensureIdentityAvailable(ctx, "kiosk", "entrance", false))
+	})
+
+	t.Run("suffixed identities", func(t *testing.T) {
+		require.True(t, isSuffixedIdentity("alice_2", "alice"))
+		require.True(t, isSuffixedIdentity("alice_12", "alice"))
+		require.False(t, isSuffixedIdentity("alice", "alice"))
+		require.False(t, isSuffixedIdentity("alice_1", "alice"))
+		require.False(t, isSuffixedIdentity("alice_bob", "alice"))
+		require.False(t, isSuffixedIdentity("bob_2", "alice"))
+
+		ctx := context.Background()
+		store := NewLocalStore()
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "shared"}, nil))
+		require.NoError(t, store.StoreParticipant(ctx, "shared", &livekit.ParticipantInfo{Sid: "PA_first", Identity: "alice"}))
+		require.NoError(t, store.StoreParticipant(ctx, "shared", &livekit.ParticipantInfo{Sid: "PA_second", Identity: "alice_2"}))
+		s := &RTCService{store: store}
+		// reconnecting sessions resume the participant of their ID
+		require.Equal(t, livekit.ParticipantIdentity("alice_2"), s.resumedIdentity(ctx, "shared", "alice", "PA_second"))
+		require.Equal(t, livekit.ParticipantIdentity("alice"), s.resumedIdentity(ctx, "shared", "alice", "PA_first"))
+		require.Equal(t, livekit.ParticipantIdentity("bob"), s.resumedIdentity(ctx, "shared", "bob", "PA_second"))
+	})
+}
@@ -0,0 +1,672 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+)
+
+// single table design: a room and its participants share the partition of the room,
+// and rooms are listed in name order through the rooms index
+const (
+	dynamoPartitionKey = "pk"
+	dynamoSortKey      = "sk"
+
+	dynamoRoomPrefix        = "ROOM#"
+	dynamoLockPrefix        = "LOCK#"
+	dynamoRoomSortKey       = "ROOM"
+	dynamoLockSortKey       = "LOCK"
+	dynamoParticipantPrefix = "PARTICIPANT#"
+
+	dynamoRoomData        = "room_data"
+	dynamoRoomInternal    = "room_internal"
+	dynamoRoomLabels      = "room_labels"
+	dynamoRoomVersion     = "room_version"
+	dynamoRoomMetadata    = "room_metadata"
+	dynamoParticipantData = "participant_data"
+	dynamoLockToken       = "lock_token"
+	// TTL attribute of the table, in unix seconds
+	dynamoExpiresAt = "expires_at"
+
+	dynamoRoomsIndex     = "rooms"
+	dynamoRoomsIndexKey  = "room_list"
+	dynamoRoomsIndexSort = "room_name"
+	dynamoRoomsIndexPart = "rooms"
+
+	// limits of BatchGetItem and BatchWriteItem
+	dynamoMaxBatchGet   = 100
+	dynamoMaxBatchWrite = 25
+)
+
+// DynamoDBStore persists rooms and participants in a DynamoDB table. Writes of a room are conditional
+// on its version for compare and swap updates, and items expire with the TTL attribute of the table.
+type DynamoDBStore struct {
+	client *dynamodb.Client
+	table  string
+	ttl    time.Duration
+}
+
+func NewDynamoDBStore(conf config.DynamoDBConfig) (*DynamoDBStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if conf.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(conf.Region))
+	}
+	awsConf, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load aws config")
+	}
+
+	client := dynamodb.NewFromConfig(awsConf, func(o *dynamodb.Options) {
+		if conf.Endpoint != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(conf.Endpoint)
+		}
+	})
+	s := &DynamoDBStore{
+		client: client,
+		table:  conf.Table,
+		ttl:    conf.ItemTTL,
+	}
+
+	if conf.CreateTable {
+		if err = s.createTable(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if _, err = client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}); err != nil {
+		return nil, errors.Wrap(err, "could not describe dynamodb table")
+	}
+	return s, nil
+}
+
+func (s *DynamoDBStore) createTable(ctx context.Context) error {
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	var notFound *types.ResourceNotFoundException
+	if err == nil || !errors.As(err, &notFound) {
+		return err
+	}
+
+	logger.Infow("creating dynamodb table", "table", s.table)
+	_, err = s.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String(s.table),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(dynamoPartitionKey), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String(dynamoSortKey), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String(dynamoRoomsIndexKey), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String(dynamoRoomsIndexSort), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(dynamoPartitionKey), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(dynamoSortKey), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName: aws.String(dynamoRoomsIndex),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String(dynamoRoomsIndexKey), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String(dynamoRoomsIndexSort), KeyType: types.KeyTypeRange},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not create dynamodb table")
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(s.client)
+	if err = waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}, time.Minute); err != nil {
+		return errors.Wrap(err, "dynamodb table was not created")
+	}
+
+	_, err = s.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(s.table),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(dynamoExpiresAt),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return errors.Wrap(err, "could not enable dynamodb ttl")
+}
+
+func dynamoString(v string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: v}
+}
+
+func dynamoNumber(v int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(v, 10)}
+}
+
+func dynamoBinary(v []byte) types.AttributeValue {
+	return &types.AttributeValueMemberB{Value: v}
+}
+
+func dynamoKey(pk, sk string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		dynamoPartitionKey: dynamoString(pk),
+		dynamoSortKey:      dynamoString(sk),
+	}
+}
+
+func dynamoRoomKey(roomName livekit.RoomName) map[string]types.AttributeValue {
+	return dynamoKey(dynamoRoomPrefix+string(roomName), dynamoRoomSortKey)
+}
+
+func dynamoParticipantKey(roomName livekit.RoomName, identity livekit.ParticipantIdentity) map[string]types.AttributeValue {
+	return dynamoKey(dynamoRoomPrefix+string(roomName), dynamoParticipantPrefix+string(identity))
+}
+
+func dynamoLockKey(roomName livekit.RoomName) map[string]types.AttributeValue {
+	return dynamoKey(dynamoLockPrefix+string(roomName), dynamoLockSortKey)
+}
+
+func getDynamoString(item map[string]types.AttributeValue, name string) string {
+	if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func getDynamoNumber(item map[string]types.AttributeValue, name string) int64 {
+	if v, ok := item[name].(*types.AttributeValueMemberN); ok {
+		n, _ := strconv.ParseInt(v.Value, 10, 64)
+		return n
+	}
+	return 0
+}
+
+func getDynamoBinary(item map[string]types.AttributeValue, name string) []byte {
+	if v, ok := item[name].(*types.AttributeValueMemberB); ok {
+		return v.Value
+	}
+	return nil
+}
+
+// isDynamoItemExpired reports items past their TTL, DynamoDB removes expired items with a delay
+func isDynamoItemExpired(item map[string]types.AttributeValue) bool {
+	expiresAt := getDynamoNumber(item, dynamoExpiresAt)
+	return expiresAt != 0 && expiresAt <= time.Now().Unix()
+}
+
+func isDynamoConditionFailed(err error) bool {
+	var conditionFailed *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionFailed)
+}
+
+// expiresAt is the value of the TTL attribute for items written now
+func (s *DynamoDBStore) expiresAt() types.AttributeValue {
+	if s.ttl <= 0 {
+		return nil
+	}
+	return dynamoNumber(time.Now().Add(s.ttl).Unix())
+}
+
+func (s *DynamoDBStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+
+	// labels are kept
+	update := "SET " + dynamoRoomData + " = :data, " +
+		dynamoRoomsIndexKey + " = :list, " +
+		dynamoRoomsIndexSort + " = :name"
+	values := map[string]types.AttributeValue{
+		":data":     dynamoBinary(roomData),
+		":list":     dynamoString(dynamoRoomsIndexPart),
+		":name":     dynamoString(room.Name),
+		":metadata": dynamoString(room.Metadata),
+	}
+	if internal != nil {
+		internalData, err := proto.Marshal(internal)
+		if err != nil {
+			return err
+		}
+		update += ", " + dynamoRoomInternal + " = :internal"
+		values[":internal"] = dynamoBinary(internalData)
+	}
+	if expiresAt := s.expiresAt(); expiresAt != nil {
+		update += ", " + dynamoExpiresAt + " = :expires"
+		values[":expires"] = expiresAt
+	}
+
+	var remove []string
+	if internal == nil {
+		remove = append(remove, dynamoRoomInternal)
+	}
+	if s.ttl <= 0 {
+		remove = append(remove, dynamoExpiresAt)
+	}
+	var removeUpdate string
+	if len(remove) != 0 {
+		removeUpdate = " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	// the version only changes with the metadata, writes of the number of participants by nodes keep it
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoRoomKey(livekit.RoomName(room.Name)),
+		UpdateExpression:          aws.String(update + removeUpdate),
+		ConditionExpression:       aws.String(dynamoRoomMetadata + " = :metadata"),
+		ExpressionAttributeValues: values,
+	})
+	if !isDynamoConditionFailed(err) {
+		if err != nil {
+			return errors.Wrap(err, "could not create room")
+		}
+		return nil
+	}
+
+	update += ", " + dynamoRoomMetadata + " = :metadata, " +
+		dynamoRoomVersion + " = if_not_exists(" + dynamoRoomVersion + ", :zero) + :one"
+	values[":zero"] = dynamoNumber(0)
+	values[":one"] = dynamoNumber(1)
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoRoomKey(livekit.RoomName(room.Name)),
+		UpdateExpression:          aws.String(update + removeUpdate),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) loadRoomItem(ctx context.Context, roomName livekit.RoomName) (map[string]types.AttributeValue, error) {
+	res, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            dynamoRoomKey(roomName),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Item == nil || isDynamoItemExpired(res.Item) {
+		return nil, ErrRoomNotFound
+	}
+	return res.Item, nil
+}
+
+func (s *DynamoDBStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	item, err := s.loadRoomItem(ctx, roomName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(getDynamoBinary(item, dynamoRoomData), room); err != nil {
+		return nil, nil, err
+	}
+
+	var internal *livekit.RoomInternal
+	if data := getDynamoBinary(item, dynamoRoomInternal); includeInternal && data != nil {
+		internal = &livekit.RoomInternal{}
+		if err = proto.Unmarshal(data, internal); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return room, internal, nil
+}
+
+// listRoomItems returns the items of all rooms through the rooms index, or of the named rooms
+func (s *DynamoDBStore) listRoomItems(ctx context.Context, roomNames []livekit.RoomName) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+	if roomNames == nil {
+		paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+			TableName:                 aws.String(s.table),
+			IndexName:                 aws.String(dynamoRoomsIndex),
+			KeyConditionExpression:    aws.String(dynamoRoomsIndexKey + " = :list"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":list": dynamoString(dynamoRoomsIndexPart)},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not get rooms")
+			}
+			items = append(items, page.Items...)
+		}
+	} else {
+		for start := 0; start < len(roomNames); start += dynamoMaxBatchGet {
+			end := start + dynamoMaxBatchGet
+			if end > len(roomNames) {
+				end = len(roomNames)
+			}
+			keys := make([]map[string]types.AttributeValue, 0, end-start)
+			for _, roomName := range roomNames[start:end] {
+				keys = append(keys, dynamoRoomKey(roomName))
+			}
+
+			request := map[string]types.KeysAndAttributes{s.table: {Keys: keys}}
+			for len(request) != 0 {
+				res, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+				if err != nil {
+					return nil, errors.Wrap(err, "could not get rooms by names")
+				}
+				items = append(items, res.Responses[s.table]...)
+				request = res.UnprocessedKeys
+			}
+		}
+	}
+
+	live := items[:0]
+	for _, item := range items {
+		if !isDynamoItemExpired(item) {
+			live = append(live, item)
+		}
+	}
+	return live, nil
+}
+
+func (s *DynamoDBStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	items, err := s.listRoomItems(ctx, roomNames)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make([]*livekit.Room, 0, len(items))
+	for _, item := range items {
+		room := &livekit.Room{}
+		if err = proto.Unmarshal(getDynamoBinary(item, dynamoRoomData), room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+func (s *DynamoDBStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	items, err := s.listRoomItems(ctx, opts.Names)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rooms := make([]*livekit.Room, 0, len(items))
+	labels := make(map[livekit.RoomName]RoomLabels, len(items))
+	for _, item := range items {
+		room := &livekit.Room{}
+		if err = proto.Unmarshal(getDynamoBinary(item, dynamoRoomData), room); err != nil {
+			return nil, "", err
+		}
+		rooms = append(rooms, room)
+		if labels[livekit.RoomName(room.Name)], err = UnmarshalRoomLabels([]byte(getDynamoString(item, dynamoRoomLabels))); err != nil {
+			return nil, "", err
+		}
+	}
+	return pageRooms(rooms, labels, opts)
+}
+
+func (s *DynamoDBStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	item, err := s.loadRoomItem(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalRoomLabels([]byte(getDynamoString(item, dynamoRoomLabels)))
+}
+
+func (s *DynamoDBStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	data, err := labels.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoRoomKey(roomName),
+		UpdateExpression:          aws.String("SET " + dynamoRoomLabels + " = :labels"),
+		ConditionExpression:       aws.String("attribute_exists(" + dynamoPartitionKey + ")"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":labels": dynamoString(string(data))},
+	})
+	if isDynamoConditionFailed(err) {
+		return ErrRoomNotFound
+	}
+	return err
+}
+
+func (s *DynamoDBStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	item, err := s.loadRoomItem(ctx, roomName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(getDynamoBinary(item, dynamoRoomData), room); err != nil {
+		return nil, 0, err
+	}
+	return room, getDynamoNumber(item, dynamoRoomVersion), nil
+}
+
+func (s *DynamoDBStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return 0, err
+	}
+
+	update := "SET " + dynamoRoomData + " = :data, " + dynamoRoomMetadata + " = :metadata, " + dynamoRoomVersion + " = :version"
+	values := map[string]types.AttributeValue{
+		":data":     dynamoBinary(data),
+		":metadata": dynamoString(room.Metadata),
+		":version":  dynamoNumber(expectedVersion + 1),
+		":expected": dynamoNumber(expectedVersion),
+	}
+	if expiresAt := s.expiresAt(); expiresAt != nil {
+		update += ", " + dynamoExpiresAt + " = :expires"
+		values[":expires"] = expiresAt
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoRoomKey(livekit.RoomName(room.Name)),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(dynamoRoomVersion + " = :expected"),
+		ExpressionAttributeValues: values,
+	})
+	if isDynamoConditionFailed(err) {
+		// the condition also fails when the room does not exist
+		if _, err = s.loadRoomItem(ctx, livekit.RoomName(room.Name)); err != nil {
+			return 0, err
+		}
+		return 0, ErrRoomVersionConflict
+	} else if err != nil {
+		return 0, errors.Wrap(err, "could not update room")
+	}
+	return expectedVersion + 1, nil
+}
+
+// DeleteRoom removes the room with its participants
+func (s *DynamoDBStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	var keys []map[string]types.AttributeValue
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:                 aws.String(s.table),
+		KeyConditionExpression:    aws.String(dynamoPartitionKey + " = :pk"),
+		ProjectionExpression:      aws.String(dynamoPartitionKey + ", " + dynamoSortKey),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pk": dynamoString(dynamoRoomPrefix + string(roomName))},
+		ConsistentRead:            aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, page.Items...)
+	}
+
+	for start := 0; start < len(keys); start += dynamoMaxBatchWrite {
+		end := start + dynamoMaxBatchWrite
+		if end > len(keys) {
+			end = len(keys)
+		}
+		requests := make([]types.WriteRequest, 0, end-start)
+		for _, key := range keys[start:end] {
+			requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}})
+		}
+
+		request := map[string][]types.WriteRequest{s.table: requests}
+		for len(request) != 0 {
+			res, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: request})
+			if err != nil {
+				return err
+			}
+			request = res.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) LockRoom(ctx context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+
+	startTime := time.Now()
+	for {
+		// a lock past its expiry may not have been removed by DynamoDB yet
+		now := time.Now()
+		item := dynamoLockKey(roomName)
+		item[dynamoLockToken] = dynamoString(token)
+		item[dynamoExpiresAt] = dynamoNumber(now.Add(duration).Unix())
+		_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 aws.String(s.table),
+			Item:                      item,
+			ConditionExpression:       aws.String("attribute_not_exists(" + dynamoPartitionKey + ") OR " + dynamoExpiresAt + " <= :now"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":now": dynamoNumber(now.Unix())},
+		})
+		if err == nil {
+			return token, nil
+		}
+		if !isDynamoConditionFailed(err) {
+			return "", err
+		}
+
+		// stop waiting past lock duration
+		if time.Since(startTime) > duration {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *DynamoDBStore) UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoLockKey(roomName),
+		ConditionExpression:       aws.String(dynamoLockToken + " = :token"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":token": dynamoString(uid)},
+	})
+	// uid does not match
+	if isDynamoConditionFailed(err) {
+		return ErrRoomUnlockFailed
+	}
+	return err
+}
+
+func (s *DynamoDBStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+
+	item := dynamoParticipantKey(roomName, livekit.ParticipantIdentity(participant.Identity))
+	item[dynamoParticipantData] = dynamoBinary(data)
+	if expiresAt := s.expiresAt(); expiresAt != nil {
+		item[dynamoExpiresAt] = expiresAt
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	})
+	return err
+}
+
+func (s *DynamoDBStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	res, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            dynamoParticipantKey(roomName, identity),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Item == nil || isDynamoItemExpired(res.Item) {
+		return nil, ErrParticipantNotFound
+	}
+
+	pi := livekit.ParticipantInfo{}
+	if err = proto.Unmarshal(getDynamoBinary(res.Item, dynamoParticipantData), &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+func (s *DynamoDBStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String(dynamoPartitionKey + " = :pk AND begins_with(" + dynamoSortKey + ", :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     dynamoString(dynamoRoomPrefix + string(roomName)),
+			":prefix": dynamoString(dynamoParticipantPrefix),
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	participants := make([]*livekit.ParticipantInfo, 0)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if isDynamoItemExpired(item) {
+				continue
+			}
+			pi := livekit.ParticipantInfo{}
+			if err = proto.Unmarshal(getDynamoBinary(item, dynamoParticipantData), &pi); err != nil {
+				return nil, err
+			}
+			participants = append(participants, &pi)
+		}
+	}
+	return participants, nil
+}
+
+func (s *DynamoDBStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       dynamoParticipantKey(roomName, identity),
+	})
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+// runs against DynamoDB local, i.e. docker run -p 8000:8000 amazon/dynamodb-local
+func TestDynamoDBStore(t *testing.T) {
+	endpoint := os.Getenv("LIVEKIT_TEST_DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("LIVEKIT_TEST_DYNAMODB_ENDPOINT is not set")
+	}
+
+	s, err := service.NewDynamoDBStore(config.DynamoDBConfig{
+		Table:       "livekit-test",
+		Region:      "us-east-1",
+		Endpoint:    endpoint,
+		CreateTable: true,
+		ItemTTL:     time.Hour,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	t.Cleanup(func() {
+		_ = s.DeleteRoom(ctx, "dynamo-room")
+	})
+
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_dynamo", Name: "dynamo-room"}, &livekit.RoomInternal{}))
+	room, internal, err := s.LoadRoom(ctx, "dynamo-room", true)
+	require.NoError(t, err)
+	require.Equal(t, "RM_dynamo", room.Sid)
+	require.NotNil(t, internal)
+
+	// labels are kept when the room is written again
+	require.NoError(t, s.StoreRoomLabels(ctx, "dynamo-room", service.RoomLabels{"product": "meet"}))
+	require.NoError(t, s.StoreRoom(ctx, room, nil))
+	labels, err := s.LoadRoomLabels(ctx, "dynamo-room")
+	require.NoError(t, err)
+	require.Equal(t, service.RoomLabels{"product": "meet"}, labels)
+
+	_, version, err := s.LoadRoomVersion(ctx, "dynamo-room")
+	require.NoError(t, err)
+	newVersion, err := s.UpdateRoom(ctx, &livekit.Room{Sid: "RM_dynamo", Name: "dynamo-room", Metadata: "first"}, version)
+	require.NoError(t, err)
+	_, err = s.UpdateRoom(ctx, &livekit.Room{Sid: "RM_dynamo", Name: "dynamo-room", Metadata: "second"}, version)
+	require.ErrorIs(t, err, service.ErrRoomVersionConflict)
+	_, err = s.UpdateRoom(ctx, &livekit.Room{Name: "missing-room"}, newVersion)
+	require.ErrorIs(t, err, service.ErrRoomNotFound)
+
+	require.NoError(t, s.StoreParticipant(ctx, "dynamo-room", &livekit.ParticipantInfo{Sid: "PA_dynamo", Identity: "user"}))
+	participants, err := s.ListParticipants(ctx, "dynamo-room")
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+	require.Equal(t, "PA_dynamo", participants[0].Sid)
+
+	rooms, err := s.ListRooms(ctx, []livekit.RoomName{"dynamo-room", "missing-room"})
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	require.Equal(t, "first", rooms[0].Metadata)
+
+	token, err := s.LockRoom(ctx, "dynamo-room", 5*time.Second)
+	require.NoError(t, err)
+	_, err = s.LockRoom(ctx, "dynamo-room", 100*time.Millisecond)
+	require.ErrorIs(t, err, service.ErrRoomLockFailed)
+	require.ErrorIs(t, s.UnlockRoom(ctx, "dynamo-room", "LOCK_other"), service.ErrRoomUnlockFailed)
+	require.NoError(t, s.UnlockRoom(ctx, "dynamo-room", token))
+
+	// participants are removed with the room
+	require.NoError(t, s.DeleteRoom(ctx, "dynamo-room"))
+	_, _, err = s.LoadRoom(ctx, "dynamo-room", false)
+	require.ErrorIs(t, err, service.ErrRoomNotFound)
+	_, err = s.LoadParticipant(ctx, "dynamo-room", "user")
+	require.ErrorIs(t, err, service.ErrParticipantNotFound)
+}
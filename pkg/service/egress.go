@@ -22,6 +22,7 @@ import (
 
 	"github.com/twitchtv/twirp"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/egress"
@@ -32,9 +33,11 @@ import (
 )
 
 type EgressService struct {
+	quotas      map[string]config.KeyQuota
 	client      rpc.EgressClient
 	store       ServiceStore
 	es          EgressStore
+	keyUsage    KeyUsageStore
 	roomService livekit.RoomService
 	telemetry   telemetry.TelemetryService
 	launcher    rtc.EgressLauncher
@@ -62,17 +65,21 @@ func NewEgressLauncher(
 }
 
 func NewEgressService(
+	conf *config.Config,
 	client rpc.EgressClient,
 	store ServiceStore,
 	es EgressStore,
+	keyUsage KeyUsageStore,
 	rs livekit.RoomService,
 	ts telemetry.TelemetryService,
 	launcher rtc.EgressLauncher,
 ) *EgressService {
 	return &EgressService{
+		quotas:      conf.KeyQuotas,
 		client:      client,
 		store:       store,
 		es:          es,
+		keyUsage:    keyUsage,
 		roomService: rs,
 		telemetry:   ts,
 		launcher:    launcher,
@@ -197,8 +204,19 @@ func (s *EgressService) startEgress(ctx context.Context, roomName livekit.RoomNa
 		}
 		req.RoomId = room.Sid
 	}
+	if req.EgressId == "" {
+		req.EgressId = utils.NewGuid(utils.EgressPrefix)
+	}
+	if err := s.ensureEgressQuota(ctx, roomName, req.EgressId); err != nil {
+		return nil, err
+	}
 
-	return s.launcher.StartEgress(ctx, req)
+	info, err := s.launcher.StartEgress(ctx, req)
+	if err != nil {
+		s.releaseEgressQuota(ctx, roomName, req.EgressId)
+		return nil, err
+	}
+	return info, nil
 }
 
 func (s *egressLauncher) StartEgress(ctx context.Context, req *rpc.StartEgressRequest) (*livekit.EgressInfo, error) {
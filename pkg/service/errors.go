@@ -15,22 +15,98 @@
 package service
 
 import (
+	"errors"
+	"net/http"
+
+	"github.com/twitchtv/twirp"
+
 	"github.com/livekit/psrpc"
 )
 
 var (
-	ErrEgressNotFound        = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
-	ErrEgressNotConnected    = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
-	ErrIdentityEmpty         = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
-	ErrIngressNotConnected   = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
-	ErrIngressNotFound       = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
-	ErrIngressNonReusable    = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
-	ErrMetadataExceedsLimits = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
-	ErrOperationFailed       = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
-	ErrParticipantNotFound   = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
-	ErrRoomNotFound          = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
-	ErrRoomLockFailed        = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
-	ErrRoomUnlockFailed      = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
-	ErrTrackNotFound         = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
-	ErrWebHookMissingAPIKey  = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
+	ErrAuditLogNotEnabled      = psrpc.NewErrorf(psrpc.Unimplemented, "audit log is not enabled")
+	ErrAuditLogRedisRequired   = psrpc.NewErrorf(psrpc.InvalidArgument, "audit log redis_stream requires redis")
+	ErrDataExceedsLimits       = psrpc.NewErrorf(psrpc.InvalidArgument, "data packet size exceeds limits")
+	ErrEgressNotFound          = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
+	ErrEgressNotConnected      = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
+	ErrEventCursorExpired      = psrpc.NewErrorf(psrpc.OutOfRange, "events after the resume cursor are no longer retained")
+	ErrGeoIPNotConfigured      = psrpc.NewErrorf(psrpc.FailedPrecondition, "geo restrictions require a GeoIP database")
+	ErrIdempotencyKeyPending   = psrpc.NewErrorf(psrpc.Aborted, "request with the idempotency key is in progress")
+	ErrIdempotencyKeyReused    = psrpc.NewErrorf(psrpc.InvalidArgument, "idempotency key has been used for a different request")
+	ErrIdentityEmpty           = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
+	ErrIngressNotConnected     = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
+	ErrIngressNotFound         = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
+	ErrIngressNonReusable      = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
+	ErrInvalidAttributes       = psrpc.NewErrorf(psrpc.InvalidArgument, "participant attributes must be a JSON object of strings")
+	ErrInvalidBreakoutRoom     = psrpc.NewErrorf(psrpc.InvalidArgument, "breakout rooms cannot have breakout rooms")
+	ErrInvalidCountries        = psrpc.NewErrorf(psrpc.InvalidArgument, "countries must be ISO 3166-1 alpha-2 codes")
+	ErrInvalidGracePeriod      = psrpc.NewErrorf(psrpc.InvalidArgument, "delete grace period must be seconds up to an hour")
+	ErrInvalidIdempotencyKey   = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid idempotency key")
+	ErrInvalidLabelSelector    = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid label selector")
+	ErrInvalidListOptions      = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid list options")
+	ErrInvalidLockReason       = psrpc.NewErrorf(psrpc.InvalidArgument, "room lock reason exceeds limits")
+	ErrInvalidMaxParticipants  = psrpc.NewErrorf(psrpc.InvalidArgument, "max participants must be a non-negative integer")
+	ErrInvalidMuteRule         = psrpc.NewErrorf(psrpc.InvalidArgument, "mute rule requires known track sources or kinds")
+	ErrInvalidPageToken        = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid page token")
+	ErrInvalidParticipantMove  = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid participant move")
+	ErrInvalidQualityPin       = psrpc.NewErrorf(psrpc.InvalidArgument, "quality can only be pinned to LOW, MEDIUM or HIGH of a video track")
+	ErrInvalidRedelivery       = psrpc.NewErrorf(psrpc.InvalidArgument, "redelivery requires an event ID or a time range")
+	ErrInvalidRemovalFilter    = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid participant removal filter")
+	ErrInvalidRevocation       = psrpc.NewErrorf(psrpc.InvalidArgument, "revocation requires a token ID or an identity, and a ttl up to 30 days")
+	ErrInvalidRoomExpiry       = psrpc.NewErrorf(psrpc.InvalidArgument, "room expiry must be a future unix timestamp")
+	ErrInvalidRoomE2EE         = psrpc.NewErrorf(psrpc.InvalidArgument, "room e2ee requirement must be true or false")
+	ErrInvalidRoomBatch        = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid room batch")
+	ErrInvalidRoomStart        = psrpc.NewErrorf(psrpc.InvalidArgument, "room start must be a future unix timestamp before its expiry")
+	ErrInvalidRoomLabels       = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid room labels")
+	ErrInvalidRoomTemplate     = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid room template")
+	ErrInvalidSignalTarget     = psrpc.NewErrorf(psrpc.InvalidArgument, "ICE can only be restarted for PUBLISHER or SUBSCRIBER")
+	ErrInvalidTrackRelay       = psrpc.NewErrorf(psrpc.InvalidArgument, "tracks can only be relayed into another room")
+	ErrJoinGeoRestricted       = psrpc.NewErrorf(psrpc.PermissionDenied, "joins are not allowed from this country")
+	ErrMetadataExceedsLimits   = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
+	ErrMoveAcrossNodes         = psrpc.NewErrorf(psrpc.FailedPrecondition, "participants can only be moved between rooms hosted by the same node")
+	ErrNotBreakoutRoom         = psrpc.NewErrorf(psrpc.FailedPrecondition, "participants can only be moved between a room and its breakout rooms")
+	ErrOperationFailed         = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
+	ErrParticipantExists       = psrpc.NewErrorf(psrpc.AlreadyExists, "participant is already in the destination room")
+	ErrParticipantNotFound     = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
+	ErrRateLimited             = psrpc.NewErrorf(psrpc.ResourceExhausted, "API rate limit exceeded")
+	ErrRelayAcrossNodes        = psrpc.NewErrorf(psrpc.FailedPrecondition, "tracks can only be relayed between rooms hosted by the same node")
+	ErrRevocationNotEnabled    = psrpc.NewErrorf(psrpc.Unimplemented, "token revocation is not supported by the store")
+	ErrRoomAlreadyExists       = psrpc.NewErrorf(psrpc.AlreadyExists, "room already exists")
+	ErrRoomFull                = psrpc.NewErrorf(psrpc.ResourceExhausted, "room is full")
+	ErrRoomHistoryNotEnabled   = psrpc.NewErrorf(psrpc.Unimplemented, "room history is not enabled")
+	ErrRoomNotStarted          = psrpc.NewErrorf(psrpc.FailedPrecondition, "room has not started")
+	ErrRoomNotFound            = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
+	ErrRoomTemplateNotFound    = psrpc.NewErrorf(psrpc.NotFound, "room template does not exist")
+	ErrRoomTemplatesNotEnabled = psrpc.NewErrorf(psrpc.Unimplemented, "room templates are not kept by the store")
+	ErrRoomLocked              = psrpc.NewErrorf(psrpc.PermissionDenied, "room is locked")
+	ErrRoomLockFailed          = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
+	ErrRoomUnlockFailed        = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
+	ErrRoomVersionConflict     = psrpc.NewErrorf(psrpc.Aborted, "room has been updated since the expected version")
+	ErrSignalRateLimited       = psrpc.NewErrorf(psrpc.ResourceExhausted, "signal rate limit exceeded")
+	ErrStatsUnavailable        = psrpc.NewErrorf(psrpc.Unavailable, "participant stats are not available from the node hosting the room")
+	ErrTokenBindingMismatch    = psrpc.NewErrorf(psrpc.PermissionDenied, "token is bound to another network or device")
+	ErrTokenRevoked            = psrpc.NewErrorf(psrpc.Unauthenticated, "token has been revoked")
+	ErrTrackNotFound           = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
+	ErrWebhookEventNotFound    = psrpc.NewErrorf(psrpc.NotFound, "webhook event is no longer kept")
+	ErrWebhookQueueFull        = psrpc.NewErrorf(psrpc.ResourceExhausted, "webhook queue is full")
+	ErrWebhooksNotEnabled      = psrpc.NewErrorf(psrpc.Unimplemented, "webhooks are not configured")
+	ErrWebHookMissingAPIKey    = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key or signing_key_file is required to use webhooks")
 )
+
+// errorStatus returns the HTTP status of an error of the HTTP APIs, by the code of psrpc and twirp errors
+func errorStatus(err error) int {
+	var psrpcErr psrpc.Error
+	var twirpErr twirp.Error
+	switch {
+	case err == ErrPermissionDenied:
+		return http.StatusUnauthorized
+	case err == ErrInvalidProjectRoomName:
+		return http.StatusBadRequest
+	case errors.As(err, &psrpcErr):
+		return psrpcErr.ToHttp()
+	case errors.As(err, &twirpErr):
+		return twirp.ServerHTTPStatusFromErrorCode(twirpErr.Code())
+	default:
+		return http.StatusInternalServerError
+	}
+}
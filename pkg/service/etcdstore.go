@@ -0,0 +1,566 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+)
+
+const (
+	etcdRoomsPrefix            = "rooms/"
+	etcdRoomInternalPrefix     = "room_internal/"
+	etcdRoomLabelsPrefix       = "room_labels/"
+	etcdRoomParticipantsPrefix = "room_participants/"
+	etcdRoomLockPrefix         = "room_lock/"
+	// metadata of the room at its version, the version is the revision of its last modification
+	etcdRoomMetadataPrefix = "room_metadata/"
+)
+
+// RoomUpdate is a change to a room in the store, Room is nil when the room was deleted
+type RoomUpdate struct {
+	Name livekit.RoomName
+	Room *livekit.Room
+}
+
+// EtcdStore persists rooms and participants in etcd. Keys are attached to a lease of the node,
+// so that the state written by a node expires when the node goes away.
+type EtcdStore struct {
+	client *clientv3.Client
+	prefix string
+	ttl    time.Duration
+
+	leaseLock sync.RWMutex
+	leaseID   clientv3.LeaseID
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewEtcdStore(conf config.EtcdConfig) (*EtcdStore, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   conf.Endpoints,
+		Username:    conf.Username,
+		Password:    conf.Password,
+		DialTimeout: conf.DialTimeout,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to etcd")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &EtcdStore{
+		client: client,
+		prefix: conf.KeyPrefix,
+		ttl:    conf.LeaseTTL,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	keepAlive, err := s.grantLease()
+	if err != nil {
+		cancel()
+		_ = client.Close()
+		return nil, err
+	}
+	go s.leaseWorker(keepAlive)
+
+	return s, nil
+}
+
+// Close revokes the lease of the node, removing the rooms and participants it has written
+func (s *EtcdStore) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.cancel()
+	if _, err := s.client.Revoke(ctx, s.getLease()); err != nil {
+		logger.Warnw("could not revoke etcd lease", err)
+	}
+	return s.client.Close()
+}
+
+func (s *EtcdStore) grantLease() (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	lease, err := s.client.Grant(s.ctx, leaseSeconds(s.ttl))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not grant etcd lease")
+	}
+	keepAlive, err := s.client.KeepAlive(s.ctx, lease.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not keep etcd lease alive")
+	}
+
+	s.leaseLock.Lock()
+	s.leaseID = lease.ID
+	s.leaseLock.Unlock()
+	return keepAlive, nil
+}
+
+// leaseWorker drains keep alive responses, and takes a new lease when the current one is lost.
+// Keys written with the lost lease are gone, they are written again on the next update.
+func (s *EtcdStore) leaseWorker(keepAlive <-chan *clientv3.LeaseKeepAliveResponse) {
+	for {
+		for range keepAlive {
+		}
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			default:
+			}
+
+			logger.Infow("etcd lease lost, granting a new lease")
+			var err error
+			if keepAlive, err = s.grantLease(); err == nil {
+				break
+			}
+			logger.Warnw("could not renew etcd lease", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (s *EtcdStore) getLease() clientv3.LeaseID {
+	s.leaseLock.RLock()
+	defer s.leaseLock.RUnlock()
+	return s.leaseID
+}
+
+func (s *EtcdStore) roomKey(roomName livekit.RoomName) string {
+	return s.prefix + etcdRoomsPrefix + url.PathEscape(string(roomName))
+}
+
+func (s *EtcdStore) roomInternalKey(roomName livekit.RoomName) string {
+	return s.prefix + etcdRoomInternalPrefix + url.PathEscape(string(roomName))
+}
+
+func (s *EtcdStore) roomLabelsKey(roomName livekit.RoomName) string {
+	return s.prefix + etcdRoomLabelsPrefix + url.PathEscape(string(roomName))
+}
+
+func (s *EtcdStore) roomMetadataKey(roomName livekit.RoomName) string {
+	return s.prefix + etcdRoomMetadataPrefix + url.PathEscape(string(roomName))
+}
+
+func (s *EtcdStore) participantsKey(roomName livekit.RoomName) string {
+	return s.prefix + etcdRoomParticipantsPrefix + url.PathEscape(string(roomName)) + "/"
+}
+
+func (s *EtcdStore) participantKey(roomName livekit.RoomName, identity livekit.ParticipantIdentity) string {
+	return s.participantsKey(roomName) + url.PathEscape(string(identity))
+}
+
+func (s *EtcdStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+	roomName := livekit.RoomName(room.Name)
+
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+
+	lease := clientv3.WithLease(s.getLease())
+	ops := []clientv3.Op{clientv3.OpPut(s.roomKey(roomName), string(roomData), lease)}
+	if internal != nil {
+		internalData, err := proto.Marshal(internal)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, clientv3.OpPut(s.roomInternalKey(roomName), string(internalData), lease))
+	} else {
+		ops = append(ops, clientv3.OpDelete(s.roomInternalKey(roomName)))
+	}
+
+	// the version only changes with the metadata, writes of the number of participants by nodes keep it
+	metadataKey := s.roomMetadataKey(roomName)
+	_, err = s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(metadataKey), "=", room.Metadata)).
+		Then(ops...).
+		Else(append(ops, clientv3.OpPut(metadataKey, room.Metadata, lease))...).
+		Commit()
+	if err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *EtcdStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	ops := []clientv3.Op{clientv3.OpGet(s.roomKey(roomName))}
+	if includeInternal {
+		ops = append(ops, clientv3.OpGet(s.roomInternalKey(roomName)))
+	}
+
+	res, err := s.client.Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kvs := res.Responses[0].GetResponseRange().GetKvs()
+	if len(kvs) == 0 {
+		return nil, nil, ErrRoomNotFound
+	}
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(kvs[0].Value, room); err != nil {
+		return nil, nil, err
+	}
+
+	var internal *livekit.RoomInternal
+	if includeInternal {
+		if kvs = res.Responses[1].GetResponseRange().GetKvs(); len(kvs) != 0 {
+			internal = &livekit.RoomInternal{}
+			if err = proto.Unmarshal(kvs[0].Value, internal); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return room, internal, nil
+}
+
+func (s *EtcdStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	var values [][]byte
+	if roomNames == nil {
+		res, err := s.client.Get(ctx, s.prefix+etcdRoomsPrefix, clientv3.WithPrefix())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get rooms")
+		}
+		for _, kv := range res.Kvs {
+			values = append(values, kv.Value)
+		}
+	} else {
+		ops := make([]clientv3.Op, 0, len(roomNames))
+		for _, roomName := range roomNames {
+			ops = append(ops, clientv3.OpGet(s.roomKey(roomName)))
+		}
+		res, err := s.client.Txn(ctx).Then(ops...).Commit()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get rooms by names")
+		}
+		for _, r := range res.Responses {
+			for _, kv := range r.GetResponseRange().GetKvs() {
+				values = append(values, kv.Value)
+			}
+		}
+	}
+
+	rooms := make([]*livekit.Room, 0, len(values))
+	for _, value := range values {
+		room := &livekit.Room{}
+		if err := proto.Unmarshal(value, room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+func (s *EtcdStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	rooms, err := s.ListRooms(ctx, opts.Names)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var labels map[livekit.RoomName]RoomLabels
+	if len(opts.LabelSelector) > 0 {
+		prefix := s.prefix + etcdRoomLabelsPrefix
+		res, err := s.client.Get(ctx, prefix, clientv3.WithPrefix())
+		if err != nil {
+			return nil, "", errors.Wrap(err, "could not get room labels")
+		}
+		labels = make(map[livekit.RoomName]RoomLabels, len(res.Kvs))
+		for _, kv := range res.Kvs {
+			name, err := url.PathUnescape(strings.TrimPrefix(string(kv.Key), prefix))
+			if err != nil {
+				continue
+			}
+			if labels[livekit.RoomName(name)], err = UnmarshalRoomLabels(kv.Value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return pageRooms(rooms, labels, opts)
+}
+
+func (s *EtcdStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	res, err := s.client.Txn(ctx).Then(
+		clientv3.OpGet(s.roomKey(roomName), clientv3.WithCountOnly()),
+		clientv3.OpGet(s.roomLabelsKey(roomName)),
+	).Commit()
+	if err != nil {
+		return nil, err
+	}
+	if res.Responses[0].GetResponseRange().GetCount() == 0 {
+		return nil, ErrRoomNotFound
+	}
+
+	var data []byte
+	if kvs := res.Responses[1].GetResponseRange().GetKvs(); len(kvs) != 0 {
+		data = kvs[0].Value
+	}
+	return UnmarshalRoomLabels(data)
+}
+
+func (s *EtcdStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	data, err := labels.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// labels expire with the node lease like the room
+	res, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(s.roomKey(roomName)), ">", 0)).
+		Then(clientv3.OpPut(s.roomLabelsKey(roomName), string(data), clientv3.WithLease(s.getLease()))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !res.Succeeded {
+		return ErrRoomNotFound
+	}
+	return nil
+}
+
+// LoadRoomVersion uses the revision of the last modification of the metadata key of the room as version
+func (s *EtcdStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	res, err := s.client.Txn(ctx).Then(
+		clientv3.OpGet(s.roomKey(roomName)),
+		clientv3.OpGet(s.roomMetadataKey(roomName)),
+	).Commit()
+	if err != nil {
+		return nil, 0, err
+	}
+	kvs := res.Responses[0].GetResponseRange().GetKvs()
+	if len(kvs) == 0 {
+		return nil, 0, ErrRoomNotFound
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(kvs[0].Value, room); err != nil {
+		return nil, 0, err
+	}
+	var version int64
+	if kvs = res.Responses[1].GetResponseRange().GetKvs(); len(kvs) != 0 {
+		version = kvs[0].ModRevision
+	}
+	return room, version, nil
+}
+
+func (s *EtcdStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return 0, err
+	}
+
+	key := s.roomKey(livekit.RoomName(room.Name))
+	metadataKey := s.roomMetadataKey(livekit.RoomName(room.Name))
+	lease := clientv3.WithLease(s.getLease())
+	res, err := s.client.Txn(ctx).
+		If(
+			clientv3.Compare(clientv3.CreateRevision(key), ">", 0),
+			clientv3.Compare(clientv3.ModRevision(metadataKey), "=", expectedVersion),
+		).
+		Then(
+			clientv3.OpPut(key, string(data), lease),
+			clientv3.OpPut(metadataKey, room.Metadata, lease),
+		).
+		Else(clientv3.OpGet(key, clientv3.WithCountOnly())).
+		Commit()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not update room")
+	}
+	if !res.Succeeded {
+		if res.Responses[0].GetResponseRange().GetCount() == 0 {
+			return 0, ErrRoomNotFound
+		}
+		return 0, ErrRoomVersionConflict
+	}
+	return res.Header.Revision, nil
+}
+
+func (s *EtcdStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	_, err := s.client.Txn(ctx).Then(
+		clientv3.OpDelete(s.roomKey(roomName)),
+		clientv3.OpDelete(s.roomInternalKey(roomName)),
+		clientv3.OpDelete(s.roomLabelsKey(roomName)),
+		clientv3.OpDelete(s.roomMetadataKey(roomName)),
+		clientv3.OpDelete(s.participantsKey(roomName), clientv3.WithPrefix()),
+	).Commit()
+	return err
+}
+
+func (s *EtcdStore) LockRoom(ctx context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+	key := s.prefix + etcdRoomLockPrefix + url.PathEscape(string(roomName))
+
+	// the lock expires with its own lease
+	lease, err := s.client.Grant(ctx, leaseSeconds(duration))
+	if err != nil {
+		return "", err
+	}
+
+	startTime := time.Now()
+	for {
+		res, err := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, token, clientv3.WithLease(lease.ID))).
+			Commit()
+		if err != nil {
+			return "", err
+		}
+		if res.Succeeded {
+			return token, nil
+		}
+
+		// stop waiting past lock duration
+		if time.Since(startTime) > duration {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	_, _ = s.client.Revoke(ctx, lease.ID)
+	return "", ErrRoomLockFailed
+}
+
+func (s *EtcdStore) UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error {
+	key := s.prefix + etcdRoomLockPrefix + url.PathEscape(string(roomName))
+	res, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", uid)).
+		Then(clientv3.OpDelete(key)).
+		Commit()
+	if err != nil {
+		return err
+	}
+
+	// uid does not match
+	if !res.Succeeded {
+		return ErrRoomUnlockFailed
+	}
+
+	return nil
+}
+
+func (s *EtcdStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+
+	key := s.participantKey(roomName, livekit.ParticipantIdentity(participant.Identity))
+	_, err = s.client.Put(ctx, key, string(data), clientv3.WithLease(s.getLease()))
+	return err
+}
+
+func (s *EtcdStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	res, err := s.client.Get(ctx, s.participantKey(roomName, identity))
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Kvs) == 0 {
+		return nil, ErrParticipantNotFound
+	}
+
+	pi := livekit.ParticipantInfo{}
+	if err = proto.Unmarshal(res.Kvs[0].Value, &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+func (s *EtcdStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	res, err := s.client.Get(ctx, s.participantsKey(roomName), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	participants := make([]*livekit.ParticipantInfo, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		pi := livekit.ParticipantInfo{}
+		if err = proto.Unmarshal(kv.Value, &pi); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &pi)
+	}
+	return participants, nil
+}
+
+func (s *EtcdStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	_, err := s.client.Delete(ctx, s.participantKey(roomName, identity))
+	return err
+}
+
+// WatchRooms notifies of room changes made by any node, including rooms expiring with the lease of their node.
+// The channel is closed when the context is done.
+func (s *EtcdStore) WatchRooms(ctx context.Context) <-chan RoomUpdate {
+	updates := make(chan RoomUpdate, 100)
+	prefix := s.prefix + etcdRoomsPrefix
+
+	go func() {
+		defer close(updates)
+
+		for res := range s.client.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix()) {
+			if err := res.Err(); err != nil {
+				logger.Warnw("etcd room watch failed", err)
+				continue
+			}
+
+			for _, ev := range res.Events {
+				name, err := url.PathUnescape(strings.TrimPrefix(string(ev.Kv.Key), prefix))
+				if err != nil {
+					continue
+				}
+
+				update := RoomUpdate{Name: livekit.RoomName(name)}
+				if ev.Type == clientv3.EventTypePut {
+					room := &livekit.Room{}
+					if err = proto.Unmarshal(ev.Kv.Value, room); err != nil {
+						logger.Warnw("could not unmarshal room", err, "room", name)
+						continue
+					}
+					update.Room = room
+				}
+
+				select {
+				case updates <- update:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return updates
+}
+
+func leaseSeconds(d time.Duration) int64 {
+	return int64(math.Max(1, math.Ceil(d.Seconds())))
+}
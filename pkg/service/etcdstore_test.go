@@ -0,0 +1,66 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestEtcdStoreWatchRooms(t *testing.T) {
+	endpoint := os.Getenv("LIVEKIT_TEST_ETCD_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("LIVEKIT_TEST_ETCD_ENDPOINT is not set")
+	}
+
+	conf := config.DefaultConfig.Store.Etcd
+	conf.Endpoints = []string{endpoint}
+	conf.KeyPrefix = "/livekit-test/"
+	s, err := service.NewEtcdStore(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := s.WatchRooms(ctx)
+
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_etcd", Name: "etcd/room"}, nil))
+	room, _, err := s.LoadRoom(ctx, "etcd/room", true)
+	require.NoError(t, err)
+	require.Equal(t, "RM_etcd", room.Sid)
+
+	require.NoError(t, s.DeleteRoom(ctx, "etcd/room"))
+
+	for _, deleted := range []bool{false, true} {
+		select {
+		case update := <-updates:
+			require.Equal(t, livekit.RoomName("etcd/room"), update.Name)
+			require.Equal(t, deleted, update.Room == nil)
+		case <-time.After(5 * time.Second):
+			t.Fatal("room update not received")
+		}
+	}
+}
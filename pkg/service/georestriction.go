@@ -0,0 +1,235 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// countries joins of the room are allowed from, or denied from. comma separated ISO 3166-1 alpha-2 codes, set on
+	// CreateRoom requests
+	roomAllowedCountriesHeader = "X-Livekit-Room-Allowed-Countries"
+	roomDeniedCountriesHeader  = "X-Livekit-Room-Denied-Countries"
+
+	// the countries are kept with the labels of the room
+	roomAllowedCountriesLabel = reservedLabelPrefix + "allowed-countries"
+	roomDeniedCountriesLabel  = reservedLabelPrefix + "denied-countries"
+)
+
+// GeoIPLookup returns the ISO 3166-1 alpha-2 code of the country of an address, or "" when the address has none
+type GeoIPLookup interface {
+	Country(ip net.IP) (string, error)
+}
+
+// GeoRestrictions restrict joins by the country of the client address, with the restrictions of the API key of the
+// token and of the room
+type GeoRestrictions struct {
+	lookup GeoIPLookup
+	keys   map[string]config.CountryRestriction
+}
+
+func NewGeoRestrictions(conf *config.Config) (*GeoRestrictions, error) {
+	if !conf.GeoRestrictions.Enabled() {
+		return &GeoRestrictions{}, nil
+	}
+	lookup, err := NewMaxMindGeoIPLookup(conf.GeoRestrictions.MaxMindDBPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewGeoRestrictionsWithLookup(lookup, conf.GeoRestrictions.Keys), nil
+}
+
+// NewGeoRestrictionsWithLookup restricts joins with the countries of another lookup provider
+func NewGeoRestrictionsWithLookup(lookup GeoIPLookup, keys map[string]config.CountryRestriction) *GeoRestrictions {
+	return &GeoRestrictions{
+		lookup: lookup,
+		keys:   keys,
+	}
+}
+
+func (g *GeoRestrictions) Enabled() bool {
+	return g != nil && g.lookup != nil
+}
+
+// Check returns ErrJoinGeoRestricted when the client address is not allowed by the restrictions of the API key or
+// of the room, whose labels may be nil
+func (g *GeoRestrictions) Check(apiKey string, labels RoomLabels, clientIP string) error {
+	restrictions := make([]config.CountryRestriction, 0, 2)
+	if g != nil {
+		if restriction, ok := g.keys[apiKey]; ok {
+			restrictions = append(restrictions, restriction)
+		}
+	}
+	if restriction, ok := roomCountryRestriction(labels); ok {
+		restrictions = append(restrictions, restriction)
+	}
+	if len(restrictions) == 0 {
+		return nil
+	}
+	if !g.Enabled() {
+		return ErrGeoIPNotConfigured
+	}
+
+	var country string
+	// the client address is as resolved from trusted proxies, clients could otherwise claim the country of any address
+	if ip := net.ParseIP(clientIP); ip != nil {
+		var err error
+		if country, err = g.lookup.Country(ip); err != nil {
+			return err
+		}
+	}
+	for _, restriction := range restrictions {
+		if !countryAllowed(restriction, country) {
+			return fmt.Errorf("%w: %s", ErrJoinGeoRestricted, countryName(country))
+		}
+	}
+	return nil
+}
+
+func countryAllowed(restriction config.CountryRestriction, country string) bool {
+	for _, denied := range restriction.Deny {
+		if denied == country {
+			return false
+		}
+	}
+	if len(restriction.Allow) == 0 {
+		return true
+	}
+	for _, allowed := range restriction.Allow {
+		if allowed == country {
+			return true
+		}
+	}
+	return false
+}
+
+func countryName(country string) string {
+	if country == "" {
+		return "unknown country"
+	}
+	return country
+}
+
+// roomCountryRestriction returns the restriction kept with the labels of the room
+func roomCountryRestriction(labels RoomLabels) (config.CountryRestriction, bool) {
+	allow, hasAllow := labels[roomAllowedCountriesLabel]
+	deny, hasDeny := labels[roomDeniedCountriesLabel]
+	if !hasAllow && !hasDeny {
+		return config.CountryRestriction{}, false
+	}
+	return config.CountryRestriction{
+		Allow: splitCountries(allow),
+		Deny:  splitCountries(deny),
+	}, true
+}
+
+func splitCountries(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// roomCountryRestrictionFromRequest returns the countries set by the request, normalized to upper case
+func roomCountryRestrictionFromRequest(ctx context.Context) (config.CountryRestriction, bool, error) {
+	var restriction config.CountryRestriction
+	allow, hasAllow := lookupRequestOption(ctx, roomAllowedCountriesOption)
+	deny, hasDeny := lookupRequestOption(ctx, roomDeniedCountriesOption)
+	if !hasAllow && !hasDeny {
+		return restriction, false, nil
+	}
+	for _, country := range strings.Split(allow, ",") {
+		if country = strings.ToUpper(strings.TrimSpace(country)); country != "" {
+			restriction.Allow = append(restriction.Allow, country)
+		}
+	}
+	for _, country := range strings.Split(deny, ",") {
+		if country = strings.ToUpper(strings.TrimSpace(country)); country != "" {
+			restriction.Deny = append(restriction.Deny, country)
+		}
+	}
+	if err := restriction.Validate(); err != nil {
+		return restriction, false, ErrInvalidCountries
+	}
+	return restriction, true, nil
+}
+
+// setRoomCountryRestriction keeps the countries with the labels of the room, before it is started
+func (s *RoomService) setRoomCountryRestriction(ctx context.Context, roomName livekit.RoomName, restriction config.CountryRestriction) error {
+	_, err := changeRoomLabels(ctx, s.roomStore, roomName, func(labels RoomLabels) (bool, error) {
+		delete(labels, roomAllowedCountriesLabel)
+		delete(labels, roomDeniedCountriesLabel)
+		if len(restriction.Allow) != 0 {
+			labels[roomAllowedCountriesLabel] = strings.Join(restriction.Allow, ",")
+		}
+		if len(restriction.Deny) != 0 {
+			labels[roomDeniedCountriesLabel] = strings.Join(restriction.Deny, ",")
+		}
+		return true, nil
+	})
+	return err
+}
+
+// ensureJoinGeoAllowed rejects joins from countries the API key of the token or the room does not allow
+func (s *RTCService) ensureJoinGeoAllowed(ctx context.Context, roomName livekit.RoomName, clientIP string) error {
+	labels, err := s.store.LoadRoomLabels(ctx, roomName)
+	if err != nil && err != ErrRoomNotFound {
+		return err
+	}
+	apiKey, _ := GetAPIKey(ctx)
+	if err = s.geoAccess.Check(apiKey, labels, clientIP); err != nil {
+		logger.Infow("join rejected by geo restriction", "room", roomName, "apiKey", apiKey, "clientIP", clientIP, "error", err)
+		return err
+	}
+	return nil
+}
+
+// MaxMindGeoIPLookup looks up countries in a MaxMind GeoIP2 or GeoLite2 database
+type MaxMindGeoIPLookup struct {
+	reader *maxminddb.Reader
+}
+
+type maxMindCountryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+func NewMaxMindGeoIPLookup(path string) (*MaxMindGeoIPLookup, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open maxmind database: %v", err)
+	}
+	return &MaxMindGeoIPLookup{reader: reader}, nil
+}
+
+func (l *MaxMindGeoIPLookup) Country(ip net.IP) (string, error) {
+	var record maxMindCountryRecord
+	if err := l.reader.Lookup(ip, &record); err != nil {
+		return "", err
+	}
+	return record.Country.ISOCode, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type geoIPTestLookup map[string]string
+
+func (l geoIPTestLookup) Country(ip net.IP) (string, error) {
+	return l[ip.String()], nil
+}
+
+func TestGeoRestrictions(t *testing.T) {
+	lookup := geoIPTestLookup{"203.0.113.1": "US", "198.51.100.1": "DE", "192.0.2.1": "FR"}
+	g := NewGeoRestrictionsWithLookup(lookup, map[string]config.CountryRestriction{
+		"APIus":     {Allow: []string{"US"}},
+		"APInotfr":  {Deny: []string{"FR"}},
+		"APIeurope": {Allow: []string{"DE", "FR"}, Deny: []string{"FR"}},
+	})
+
+	t.Run("API keys", func(t *testing.T) {
+		require.NoError(t, g.Check("APIus", nil, "203.0.113.1"))
+		require.ErrorIs(t, g.Check("APIus", nil, "198.51.100.1"), ErrJoinGeoRestricted)
+		require.NoError(t, g.Check("APInotfr", nil, "198.51.100.1"))
+		require.ErrorIs(t, g.Check("APInotfr", nil, "192.0.2.1"), ErrJoinGeoRestricted)
+		require.NoError(t, g.Check("APIeurope", nil, "198.51.100.1"))
+		require.ErrorIs(t, g.Check("APIeurope", nil, "192.0.2.1"), ErrJoinGeoRestricted)
+		require.NoError(t, g.Check("APIother", nil, "192.0.2.1"))
+	})
+
+	t.Run("unknown countries", func(t *testing.T) {
+		err := g.Check("APIus", nil, "10.0.0.1")
+		require.ErrorIs(t, err, ErrJoinGeoRestricted)
+		require.EqualError(t, err, "joins are not allowed from this country: unknown country")
+		require.NoError(t, g.Check("APInotfr", nil, "10.0.0.1"))
+	})
+
+	t.Run("rooms", func(t *testing.T) {
+		labels := RoomLabels{roomDeniedCountriesLabel: "DE"}
+		require.ErrorIs(t, g.Check("APIother", labels, "198.51.100.1"), ErrJoinGeoRestricted)
+		// restrictions of the key and the room both apply
+		require.ErrorIs(t, g.Check("APIeurope", labels, "198.51.100.1"), ErrJoinGeoRestricted)
+		require.NoError(t, g.Check("APIus", labels, "203.0.113.1"))
+	})
+
+	t.Run("forwarded addresses", func(t *testing.T) {
+		_, proxy, err := net.ParseCIDR("10.0.0.0/8")
+		require.NoError(t, err)
+		clientIP := func(remoteAddr string, header http.Header) string {
+			r := httptest.NewRequest(http.MethodGet, "/rtc", nil)
+			r.RemoteAddr = remoteAddr
+			r.Header = header
+			var ip string
+			NewClientIPMiddleware([]*net.IPNet{proxy}).ServeHTTP(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+				ip = GetClientIP(r)
+			})
+			return ip
+		}
+		require.NoError(t, g.Check("APIus", nil, clientIP("10.0.0.1:4000", http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}})))
+		// headers of clients do not pick their country
+		require.ErrorIs(t, g.Check("APIus", nil, clientIP("198.51.100.1:4000", http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}})), ErrJoinGeoRestricted)
+		require.ErrorIs(t, g.Check("APIus", nil, clientIP("198.51.100.1:4000", http.Header{"X-Forwarded-For": {"203.0.113.1"}})), ErrJoinGeoRestricted)
+	})
+
+	t.Run("without a database", func(t *testing.T) {
+		disabled := &GeoRestrictions{}
+		require.NoError(t, disabled.Check("APIus", nil, "198.51.100.1"))
+		require.ErrorIs(t, disabled.Check("APIus", RoomLabels{roomAllowedCountriesLabel: "US"}, "203.0.113.1"), ErrGeoIPNotConfigured)
+	})
+}
+
+func TestRoomCountryRestrictionFromRequest(t *testing.T) {
+	header := func(h http.Header) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, h)
+	}
+	restriction, ok, err := roomCountryRestrictionFromRequest(header(http.Header{
+		"X-Livekit-Room-Allowed-Countries": {"us, ca"},
+	}))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"US", "CA"}, restriction.Allow)
+
+	_, _, err = roomCountryRestrictionFromRequest(header(http.Header{"X-Livekit-Room-Denied-Countries": {"USA"}}))
+	require.ErrorIs(t, err, ErrInvalidCountries)
+
+	_, ok, err = roomCountryRestrictionFromRequest(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// the restriction is kept with the labels of the room
+	ctx := context.Background()
+	store := NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "webinar"}, nil))
+	s := &RoomService{roomStore: store}
+	require.NoError(t, s.setRoomCountryRestriction(ctx, "webinar", restriction))
+	labels, err := store.LoadRoomLabels(ctx, "webinar")
+	require.NoError(t, err)
+	kept, ok := roomCountryRestriction(labels)
+	require.True(t, ok)
+	require.Equal(t, restriction, kept)
+	require.Empty(t, labels.withoutReserved())
+}
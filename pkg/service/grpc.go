@@ -0,0 +1,416 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/roomevents"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+// the authorization metadata of gRPC requests, gRPC metadata keys are lower case
+var grpcAuthorizationKey = strings.ToLower(authorizationHeader)
+
+// NewGRPCServer serves the Twirp services as gRPC services of the same protos, with reflection. requests are
+// authenticated, limited to the scopes and IP allowlist of their API key and scoped to projects as Twirp requests,
+// and their metadata is available as request headers.
+// room events are streamed by livekit.server.roomevents.RoomEvents
+func NewGRPCServer(
+	roomService livekit.RoomService,
+	egressService livekit.Egress,
+	ingressService livekit.Ingress,
+	roomEventsService *RoomEventsService,
+	keyProvider auth.KeyProvider,
+	projects map[string]string,
+	scopes map[string][]config.APIKeyScope,
+	retiredKeys map[string]time.Time,
+	allowedNetworks map[string][]*net.IPNet,
+	revocations TokenRevocationStore,
+	auditLog *AuditLog,
+) (*grpc.Server, error) {
+	l := logger.GetLogger().WithComponent(utils.ComponentAPI)
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpcRequestHeaders,
+		GRPCLogger(l),
+		grpcProjectScope(ProjectScopeInterceptor()),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		grpcStreamRequestHeaders,
+		GRPCStreamLogger(l),
+	}
+	if keyProvider != nil {
+		// tokens of OIDC issuers can only join rooms, which is not served over gRPC
+		m := NewAPIKeyAuthMiddleware(keyProvider, projects, scopes, retiredKeys, nil, allowedNetworks, revocations)
+		interceptors = append([]grpc.UnaryServerInterceptor{grpcAuth(m)}, interceptors...)
+		streamInterceptors = append([]grpc.StreamServerInterceptor{grpcStreamAuth(m)}, streamInterceptors...)
+	}
+	if auditLog.Enabled() {
+		// calls are recorded before authentication, so that rejected calls are recorded too
+		interceptors = append([]grpc.UnaryServerInterceptor{grpcAudit(auditLog)}, interceptors...)
+		streamInterceptors = append([]grpc.StreamServerInterceptor{grpcStreamAudit(auditLog)}, streamInterceptors...)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
+
+	for _, svc := range []struct {
+		name        protoreflect.FullName
+		handlerType interface{}
+		impl        interface{}
+	}{
+		{"livekit.RoomService", (*livekit.RoomService)(nil), roomService},
+		{"livekit.Egress", (*livekit.Egress)(nil), egressService},
+		{"livekit.Ingress", (*livekit.Ingress)(nil), ingressService},
+	} {
+		desc, err := newGRPCServiceDesc(svc.name, svc.handlerType, svc.impl)
+		if err != nil {
+			return nil, err
+		}
+		server.RegisterService(desc, svc.impl)
+	}
+	if roomEventsService != nil {
+		roomevents.RegisterRoomEventsServer(server, &roomEventsGRPCServer{service: roomEventsService})
+	}
+	reflection.Register(server)
+	return server, nil
+}
+
+// newGRPCServiceDesc describes the service of the registered proto with the methods of its Twirp implementation
+func newGRPCServiceDesc(name protoreflect.FullName, handlerType interface{}, impl interface{}) (*grpc.ServiceDesc, error) {
+	methods, err := newProtoMethods(name, impl)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &grpc.ServiceDesc{
+		ServiceName: string(name),
+		HandlerType: handlerType,
+	}
+	for _, m := range methods {
+		if m.desc.IsStreamingClient() || m.desc.IsStreamingServer() {
+			return nil, fmt.Errorf("%s is a streaming method", m.desc.FullName())
+		}
+		desc.Metadata = m.desc.ParentFile().Path()
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: string(m.desc.Name()),
+			Handler:    newGRPCMethodHandler("/"+string(name)+"/"+string(m.desc.Name()), m),
+		})
+	}
+	return desc, nil
+}
+
+func newGRPCMethodHandler(fullMethod string, m *protoMethod) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := m.input.New().Interface()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			res, err := m.call(ctx, req)
+			return res, toGRPCError(err)
+		}
+		res, err := interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, m.call)
+		return res, toGRPCError(err)
+	}
+}
+
+// protoMethod calls the method of a Twirp service implementation with a request of the method's input message
+type protoMethod struct {
+	desc  protoreflect.MethodDescriptor
+	input protoreflect.MessageType
+	call  func(ctx context.Context, req interface{}) (interface{}, error)
+}
+
+// newProtoMethods returns the methods of the registered proto service, implemented by impl
+func newProtoMethods(name protoreflect.FullName, impl interface{}) ([]*protoMethod, error) {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", name)
+	}
+
+	methods := make([]*protoMethod, 0, sd.Methods().Len())
+	for i := 0; i < sd.Methods().Len(); i++ {
+		md := sd.Methods().Get(i)
+		input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+		if err != nil {
+			return nil, err
+		}
+		method := reflect.ValueOf(impl).MethodByName(string(md.Name()))
+		if !method.IsValid() {
+			return nil, fmt.Errorf("%s is not implemented", md.FullName())
+		}
+		methods = append(methods, &protoMethod{
+			desc:  md,
+			input: input,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				out := method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+				if err, _ := out[1].Interface().(error); err != nil {
+					return nil, err
+				}
+				return out[0].Interface(), nil
+			},
+		})
+	}
+	return methods, nil
+}
+
+// grpcAuth authenticates requests with the token of their authorization metadata
+func grpcAuth(m *APIKeyAuthMiddleware) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := grpcAuthenticate(ctx, m, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// grpcStreamAuth authenticates streams as grpcAuth authenticates requests
+func grpcStreamAuth(m *APIKeyAuthMiddleware) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := grpcAuthenticate(ss.Context(), m, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &grpcServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// grpcAuthenticate authenticates the request, and authorizes its method as the Twirp method of the same name
+func grpcAuthenticate(ctx context.Context, m *APIKeyAuthMiddleware, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(grpcAuthorizationKey)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	if !strings.HasPrefix(values[0], bearerPrefix) {
+		return nil, status.Error(codes.Unauthenticated, ErrMissingAuthorization.Error())
+	}
+
+	ctx, err := m.authenticate(ctx, values[0][len(bearerPrefix):])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !m.ipAllowed(ctx, grpcClientIP(ctx), false) {
+		return nil, status.Error(codes.PermissionDenied, ErrIPNotAllowed.Error())
+	}
+	if !m.authorize(ctx, http.MethodPost, twirpPathPrefix+strings.TrimPrefix(fullMethod, "/")) {
+		return nil, status.Error(codes.PermissionDenied, ErrPermissionDenied.Error())
+	}
+	return ctx, nil
+}
+
+func grpcClientIP(ctx context.Context) string {
+	var clientIP string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		clientIP, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+	return clientIP
+}
+
+// grpcAudit records requests in the audit log, with the HTTP status of their code
+func grpcAudit(l *AuditLog) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, entry := startAuditCall(ctx, "grpc", info.FullMethod, grpcClientIP(ctx))
+		res, err := handler(ctx, req)
+		entry.Status = grpcHTTPStatus(err)
+		entry.DurationMs = time.Since(entry.Time).Milliseconds()
+		l.Record(entry)
+		return res, err
+	}
+}
+
+// grpcStreamAudit records streams in the audit log when they end
+func grpcStreamAudit(l *AuditLog) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, entry := startAuditCall(ss.Context(), "grpc", info.FullMethod, grpcClientIP(ss.Context()))
+		err := handler(srv, &grpcServerStream{ServerStream: ss, ctx: ctx})
+		entry.Status = grpcHTTPStatus(err)
+		entry.DurationMs = time.Since(entry.Time).Milliseconds()
+		l.Record(entry)
+		return err
+	}
+}
+
+// grpcHTTPStatus returns the HTTP status of the Twirp code of the error
+func grpcHTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	code := status.Code(toGRPCError(err))
+	for twirpCode, grpcCode := range twirpGRPCCodes {
+		// BadRoute and Malformed share their gRPC codes with Unimplemented and InvalidArgument
+		if grpcCode == code && twirpCode != twirp.BadRoute && twirpCode != twirp.Malformed {
+			return twirp.ServerHTTPStatusFromErrorCode(twirpCode)
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// grpcRequestHeaders has the metadata of requests available as the headers Twirp requests are extended with
+func grpcRequestHeaders(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withGRPCRequestHeaders(ctx), req)
+}
+
+func grpcStreamRequestHeaders(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &grpcServerStream{ServerStream: ss, ctx: withGRPCRequestHeaders(ss.Context())})
+}
+
+func withGRPCRequestHeaders(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+	return context.WithValue(ctx, requestHeaderKey{}, header)
+}
+
+// grpcServerStream is a stream with the context of its interceptors
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcProjectScope applies the project scope of Twirp RoomService requests to gRPC RoomService requests
+func grpcProjectScope(scope twirp.Interceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, "/livekit.RoomService/") {
+			return handler(ctx, req)
+		}
+		return scope(twirp.Method(handler))(ctx, req)
+	}
+}
+
+// GRPCLogger logs gRPC requests as TwirpLogger logs Twirp requests
+func GRPCLogger(l logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+		return logAPIRequest(ctx, l, service[strings.LastIndex(service, ".")+1:], method, "grpc", func(ctx context.Context) (interface{}, error) {
+			return handler(ctx, req)
+		})
+	}
+}
+
+// GRPCStreamLogger logs gRPC streams when they end
+func GRPCStreamLogger(l logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+		_, err := logAPIRequest(ss.Context(), l, service[strings.LastIndex(service, ".")+1:], method, "grpc", func(ctx context.Context) (interface{}, error) {
+			return nil, handler(srv, &grpcServerStream{ServerStream: ss, ctx: ctx})
+		})
+		return err
+	}
+}
+
+// logAPIRequest logs requests of API protocols other than Twirp, fields appended by the handler are logged
+func logAPIRequest(ctx context.Context, l logger.Logger, service string, method string, protocol string, handler func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	r := &requestLogger{
+		logger:    l,
+		startedAt: time.Now(),
+	}
+	r.service = service
+	r.method = method
+	r.fields = append(r.fields, "service", service, "method", method, "protocol", protocol)
+
+	res, err := handler(context.WithValue(ctx, loggerKey, r))
+
+	r.fields = append(r.fields, "duration", time.Since(r.startedAt))
+	if err != nil {
+		st := status.Convert(toGRPCError(err))
+		r.fields = append(r.fields, "error", st.Message(), "code", st.Code())
+	}
+	l.Infow("API "+service+"."+method, r.fields...)
+	return res, err
+}
+
+var twirpGRPCCodes = map[twirp.ErrorCode]codes.Code{
+	twirp.Canceled:           codes.Canceled,
+	twirp.Unknown:            codes.Unknown,
+	twirp.InvalidArgument:    codes.InvalidArgument,
+	twirp.Malformed:          codes.InvalidArgument,
+	twirp.DeadlineExceeded:   codes.DeadlineExceeded,
+	twirp.NotFound:           codes.NotFound,
+	twirp.BadRoute:           codes.Unimplemented,
+	twirp.AlreadyExists:      codes.AlreadyExists,
+	twirp.PermissionDenied:   codes.PermissionDenied,
+	twirp.Unauthenticated:    codes.Unauthenticated,
+	twirp.ResourceExhausted:  codes.ResourceExhausted,
+	twirp.FailedPrecondition: codes.FailedPrecondition,
+	twirp.Aborted:            codes.Aborted,
+	twirp.OutOfRange:         codes.OutOfRange,
+	twirp.Unimplemented:      codes.Unimplemented,
+	twirp.Internal:           codes.Internal,
+	twirp.Unavailable:        codes.Unavailable,
+	twirp.DataLoss:           codes.DataLoss,
+}
+
+// toGRPCError converts the errors of Twirp services to gRPC status errors
+func toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	// includes psrpc errors
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var twirpErr twirp.Error
+	switch {
+	case errors.As(err, &twirpErr):
+		code, ok := twirpGRPCCodes[twirpErr.Code()]
+		if !ok {
+			code = codes.Unknown
+		}
+		return status.Error(code, twirpErr.Msg())
+	case errors.Is(err, ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrInvalidProjectRoomName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/roomevents"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+type grpcTestRoomService struct {
+	livekit.RoomService
+	ctx context.Context
+}
+
+func (s *grpcTestRoomService) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error) {
+	s.ctx = ctx
+	if err := service.EnsureListPermission(ctx); err != nil {
+		return nil, twirp.NewError(twirp.Unauthenticated, err.Error())
+	}
+	rooms := make([]*livekit.Room, 0, len(req.Names))
+	for _, name := range req.Names {
+		rooms = append(rooms, &livekit.Room{Name: name})
+	}
+	return &livekit.ListRoomsResponse{Rooms: rooms}, nil
+}
+
+func (s *grpcTestRoomService) DeleteRoom(context.Context, *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
+	return nil, service.ErrRoomNotFound
+}
+
+type grpcTestEgressService struct {
+	livekit.Egress
+}
+
+type grpcTestIngressService struct {
+	livekit.Ingress
+}
+
+func TestGRPCServer(t *testing.T) {
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	roomService := &grpcTestRoomService{}
+	broker, err := service.NewRoomEventBroker(&config.Config{}, nil, nil)
+	require.NoError(t, err)
+	server, err := service.NewGRPCServer(roomService, &grpcTestEgressService{}, &grpcTestIngressService{}, service.NewRoomEventsService(broker), provider, map[string]string{"APIcustomer": "customer"}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	ln := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return ln.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	withToken := func(t *testing.T, grant *auth.VideoGrant) context.Context {
+		token, err := auth.NewAccessToken("APIcustomer", secret).AddGrant(grant).ToJWT()
+		require.NoError(t, err)
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	t.Run("registers services with reflection", func(t *testing.T) {
+		info := server.GetServiceInfo()
+		for _, name := range []string{"livekit.RoomService", "livekit.Egress", "livekit.Ingress", "livekit.server.roomevents.RoomEvents", "grpc.reflection.v1alpha.ServerReflection"} {
+			require.Contains(t, info, name)
+		}
+		require.Len(t, info["livekit.RoomService"].Methods, 11)
+	})
+
+	t.Run("authenticates and scopes requests", func(t *testing.T) {
+		res := &livekit.ListRoomsResponse{}
+		err := conn.Invoke(withToken(t, &auth.VideoGrant{RoomList: true}), "/livekit.RoomService/ListRooms", &livekit.ListRoomsRequest{Names: []string{"standup"}}, res)
+		require.NoError(t, err)
+		require.Len(t, res.Rooms, 1)
+		require.Equal(t, "standup", res.Rooms[0].Name)
+
+		project, ok := service.GetProject(roomService.ctx)
+		require.True(t, ok)
+		require.Equal(t, "customer", project)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+		err := conn.Invoke(ctx, "/livekit.RoomService/ListRooms", &livekit.ListRoomsRequest{}, &livekit.ListRoomsResponse{})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("converts service errors", func(t *testing.T) {
+		err := conn.Invoke(withToken(t, &auth.VideoGrant{}), "/livekit.RoomService/ListRooms", &livekit.ListRoomsRequest{}, &livekit.ListRoomsResponse{})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		err = conn.Invoke(withToken(t, &auth.VideoGrant{RoomCreate: true}), "/livekit.RoomService/DeleteRoom", &livekit.DeleteRoomRequest{Room: "standup"}, &livekit.DeleteRoomResponse{})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+	t.Run("streams room events after resume cursors", func(t *testing.T) {
+		ctx := context.Background()
+		for _, event := range []*livekit.WebhookEvent{
+			{Event: webhook.EventRoomStarted, Room: &livekit.Room{Name: string(utils.ProjectRoomName("customer", "standup"))}},
+			{Event: webhook.EventRoomStarted, Room: &livekit.Room{Name: "standup"}},
+			{Event: webhook.EventParticipantJoined, Room: &livekit.Room{Name: string(utils.ProjectRoomName("customer", "standup"))}},
+		} {
+			require.NoError(t, broker.QueueNotify(ctx, event))
+		}
+
+		client := roomevents.NewRoomEventsClient(conn)
+		subscribe := func(t *testing.T, grant *auth.VideoGrant, resumeAfter uint64) roomevents.RoomEvents_SubscribeClient {
+			stream, err := client.Subscribe(withToken(t, grant), &roomevents.SubscribeRequest{ResumeAfter: resumeAfter})
+			require.NoError(t, err)
+			return stream
+		}
+		recv := func(t *testing.T, stream roomevents.RoomEvents_SubscribeClient) (uint64, *livekit.WebhookEvent) {
+			res, err := stream.Recv()
+			require.NoError(t, err)
+			return res.Sequence, res.Event
+		}
+
+		stream := subscribe(t, &auth.VideoGrant{RoomList: true, RoomAdmin: true}, 0)
+		require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventRoomFinished, Room: &livekit.Room{Name: string(utils.ProjectRoomName("customer", "standup"))}}))
+		seq, event := recv(t, stream)
+		require.Equal(t, uint64(4), seq)
+		require.Equal(t, webhook.EventRoomFinished, event.Event)
+
+		// events of other projects are skipped, room names are those of the project
+		stream = subscribe(t, &auth.VideoGrant{RoomList: true, RoomAdmin: true}, 1)
+		seq, event = recv(t, stream)
+		require.Equal(t, uint64(3), seq)
+		require.Equal(t, webhook.EventParticipantJoined, event.Event)
+		require.Equal(t, "standup", event.Room.Name)
+
+		stream = subscribe(t, &auth.VideoGrant{RoomList: true}, 1)
+		_, err := stream.Recv()
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
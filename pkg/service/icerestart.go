@@ -0,0 +1,103 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// the command restarting ICE, its payload is the name of the signal target
+	restartICECommand routing.RoomCommandType = "restart-ice"
+
+	maxRestartICERequestSize = 64 * 1024
+)
+
+// ICERestartService forces an ICE restart of a peer connection of a participant at /restart_ice, for when issues
+// with the network path are detected server side before the client recovers by itself.
+// the subscriber peer connection is restarted by the server offering with new credentials. the publisher peer
+// connection can only be restarted by the client, its signal connection is closed for the client to resume, which
+// restarts ICE of both peer connections.
+// POST {"room": "", "identity": "", "target": "PUBLISHER"} restarts ICE, target defaults to SUBSCRIBER
+type ICERestartService struct {
+	router routing.MessageRouter
+	store  ObjectStore
+}
+
+func NewICERestartService(router routing.MessageRouter, store ObjectStore) *ICERestartService {
+	return &ICERestartService{
+		router: router,
+		store:  store,
+	}
+}
+
+// RestartICE restarts ICE of the peer connection of the participant
+func (s *ICERestartService) RestartICE(ctx context.Context, room string, identity string, target livekit.SignalTarget) error {
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return err
+		}
+	}
+	roomName := livekit.RoomName(room)
+	AppendLogFields(ctx, "room", roomName, "participant", identity, "target", target)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return err
+	}
+	if identity == "" {
+		return ErrIdentityEmpty
+	}
+	if _, ok := livekit.SignalTarget_name[int32(target)]; !ok {
+		return ErrInvalidSignalTarget
+	}
+
+	if _, err := s.store.LoadParticipant(ctx, roomName, livekit.ParticipantIdentity(identity)); err != nil {
+		return err
+	}
+
+	return s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:     restartICECommand,
+		Room:     roomName,
+		Identity: livekit.ParticipantIdentity(identity),
+		Payload:  []byte(target.String()),
+	})
+}
+
+type restartICERequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Target   string `json:"target"`
+}
+
+func (s *ICERestartService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxRestartICERequestSize, func(ctx context.Context, req *restartICERequest) (interface{}, error) {
+			target := livekit.SignalTarget_SUBSCRIBER
+			if req.Target != "" {
+				t, ok := livekit.SignalTarget_value[req.Target]
+				if !ok {
+					return nil, ErrInvalidSignalTarget
+				}
+				target = livekit.SignalTarget(t)
+			}
+			return nil, s.RestartICE(ctx, req.Room, req.Identity, target)
+		}),
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRestartICE(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "stage"},
+	})
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreParticipant(ctx, "stage", &livekit.ParticipantInfo{Identity: "speaker", Sid: "PA_speaker"}))
+	router := &routingfakes.FakeRouter{}
+	svc := service.NewICERestartService(router, store)
+
+	t.Run("requires admin permission of the room", func(t *testing.T) {
+		err := svc.RestartICE(ctx, "other", "speaker", livekit.SignalTarget_SUBSCRIBER)
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		err := svc.RestartICE(ctx, "stage", "speaker", livekit.SignalTarget(5))
+		require.ErrorIs(t, err, service.ErrInvalidSignalTarget)
+		err = svc.RestartICE(ctx, "stage", "", livekit.SignalTarget_SUBSCRIBER)
+		require.ErrorIs(t, err, service.ErrIdentityEmpty)
+		err = svc.RestartICE(ctx, "stage", "missing", livekit.SignalTarget_SUBSCRIBER)
+		require.ErrorIs(t, err, service.ErrParticipantNotFound)
+		require.Equal(t, 0, router.WriteRoomCommandCallCount())
+	})
+
+	t.Run("restarts through the participant", func(t *testing.T) {
+		require.NoError(t, svc.RestartICE(ctx, "stage", "speaker", livekit.SignalTarget_SUBSCRIBER))
+		require.NoError(t, svc.RestartICE(ctx, "stage", "speaker", livekit.SignalTarget_PUBLISHER))
+		require.Equal(t, 2, router.WriteRoomCommandCallCount())
+
+		for i, target := range []livekit.SignalTarget{livekit.SignalTarget_SUBSCRIBER, livekit.SignalTarget_PUBLISHER} {
+			_, cmd := router.WriteRoomCommandArgsForCall(i)
+			require.Equal(t, livekit.RoomName("stage"), cmd.Room)
+			require.Equal(t, livekit.ParticipantIdentity("speaker"), cmd.Identity)
+			require.Equal(t, target.String(), string(cmd.Payload))
+		}
+	})
+}
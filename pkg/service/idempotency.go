@@ -0,0 +1,229 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	idempotencyKeyHeader  = "Idempotency-Key"
+	maxIdempotencyKeySize = 255
+)
+
+// IdempotencyRecord is the outcome of the request an idempotency key was first used for
+type IdempotencyRecord struct {
+	Key string `json:"key"`
+	// hash of the method and request, retries with the key must send the same request
+	RequestHash string `json:"request_hash"`
+	// the response and the full name of its message, empty while the request is in progress
+	ResponseType string `json:"response_type,omitempty"`
+	Response     []byte `json:"response,omitempty"`
+}
+
+// IdempotentRoomService applies mutating RoomService requests with an Idempotency-Key header once, for clients to
+// retry requests that timed out. retries with the key receive the response of the first request for as long as the
+// store keeps it. failed requests are not kept, they can be retried with the same key
+type IdempotentRoomService struct {
+	livekit.RoomService
+	store  IdempotencyStore
+	window time.Duration
+}
+
+// NewIdempotentRoomService returns roomService when the store doesn't keep idempotency keys
+func NewIdempotentRoomService(roomService livekit.RoomService, store IdempotencyStore, window time.Duration) livekit.RoomService {
+	if store == nil || window <= 0 {
+		return roomService
+	}
+	return &IdempotentRoomService{
+		RoomService: roomService,
+		store:       store,
+		window:      window,
+	}
+}
+
+func (s *IdempotentRoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	res, err := s.apply(ctx, "CreateRoom", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.CreateRoom(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.Room), nil
+}
+
+func (s *IdempotentRoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
+	res, err := s.apply(ctx, "DeleteRoom", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.DeleteRoom(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.DeleteRoomResponse), nil
+}
+
+func (s *IdempotentRoomService) RemoveParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	res, err := s.apply(ctx, "RemoveParticipant", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.RemoveParticipant(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.RemoveParticipantResponse), nil
+}
+
+func (s *IdempotentRoomService) MutePublishedTrack(ctx context.Context, req *livekit.MuteRoomTrackRequest) (*livekit.MuteRoomTrackResponse, error) {
+	res, err := s.apply(ctx, "MutePublishedTrack", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.MutePublishedTrack(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.MuteRoomTrackResponse), nil
+}
+
+func (s *IdempotentRoomService) UpdateParticipant(ctx context.Context, req *livekit.UpdateParticipantRequest) (*livekit.ParticipantInfo, error) {
+	res, err := s.apply(ctx, "UpdateParticipant", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.UpdateParticipant(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.ParticipantInfo), nil
+}
+
+func (s *IdempotentRoomService) UpdateSubscriptions(ctx context.Context, req *livekit.UpdateSubscriptionsRequest) (*livekit.UpdateSubscriptionsResponse, error) {
+	res, err := s.apply(ctx, "UpdateSubscriptions", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.UpdateSubscriptions(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.UpdateSubscriptionsResponse), nil
+}
+
+func (s *IdempotentRoomService) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
+	res, err := s.apply(ctx, "SendData", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.SendData(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.SendDataResponse), nil
+}
+
+func (s *IdempotentRoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (*livekit.Room, error) {
+	res, err := s.apply(ctx, "UpdateRoomMetadata", req, func(ctx context.Context) (proto.Message, error) {
+		return s.RoomService.UpdateRoomMetadata(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*livekit.Room), nil
+}
+
+// apply calls the method unless a request with the idempotency key of the request has been applied, and returns the
+// response of that request instead
+func (s *IdempotentRoomService) apply(ctx context.Context, method string, req proto.Message, call func(ctx context.Context) (proto.Message, error)) (proto.Message, error) {
+	key, ok := lookupRequestHeader(ctx, idempotencyKeyHeader)
+	if !ok {
+		return call(ctx)
+	}
+	if key == "" || len(key) > maxIdempotencyKeySize {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	AppendLogFields(ctx, "idempotencyKey", key)
+
+	// keys are unique to a project
+	if project, scoped := GetProject(ctx); scoped {
+		key = project + "/" + key
+	}
+	hash, err := idempotencyRequestHash(method, req)
+	if err != nil {
+		return nil, err
+	}
+	record := &IdempotencyRecord{
+		Key:         key,
+		RequestHash: hash,
+	}
+	existing, err := s.store.ReserveIdempotencyKey(ctx, record, s.window)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		AppendLogFields(ctx, "idempotentReplay", true)
+		return existing.replay(hash)
+	}
+
+	res, err := call(ctx)
+	if err != nil {
+		// released for the request to be retried
+		if derr := s.store.DeleteIdempotencyRecord(ctx, key); derr != nil {
+			logger.Warnw("could not release idempotency key", derr, "key", key)
+		}
+		return nil, err
+	}
+
+	record.ResponseType = string(proto.MessageName(res))
+	if record.Response, err = proto.Marshal(res); err == nil {
+		err = s.store.StoreIdempotencyRecord(ctx, record)
+	}
+	if err != nil {
+		// the request has been applied, retries are rejected as in progress until the key expires
+		logger.Warnw("could not store idempotent response", err, "key", key)
+	}
+	return res, nil
+}
+
+// replay returns the response of the record to a retry of the request with the hash
+func (r *IdempotencyRecord) replay(requestHash string) (proto.Message, error) {
+	if r.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if r.ResponseType == "" {
+		return nil, ErrIdempotencyKeyPending
+	}
+
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(r.ResponseType))
+	if err != nil {
+		return nil, err
+	}
+	res := mt.New().Interface()
+	if err = proto.Unmarshal(r.Response, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func idempotencyRequestHash(method string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type idempotencyTestRoomService struct {
+	livekit.RoomService
+	calls int
+	err   error
+}
+
+func (s *idempotencyTestRoomService) CreateRoom(_ context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &livekit.Room{Sid: fmt.Sprintf("RM_%d", s.calls), Name: req.Name}, nil
+}
+
+func TestIdempotentRoomService(t *testing.T) {
+	withKey := func(key string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{idempotencyKeyHeader: []string{key}})
+	}
+
+	t.Run("applies requests once", func(t *testing.T) {
+		rooms := &idempotencyTestRoomService{}
+		svc := NewIdempotentRoomService(rooms, NewLocalStore(), time.Minute)
+
+		first, err := svc.CreateRoom(withKey("create-standup"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		retry, err := svc.CreateRoom(withKey("create-standup"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		require.Equal(t, 1, rooms.calls)
+		require.Equal(t, first.Sid, retry.Sid)
+
+		_, err = svc.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		require.Equal(t, 2, rooms.calls)
+	})
+
+	t.Run("rejects keys reused for other requests", func(t *testing.T) {
+		svc := NewIdempotentRoomService(&idempotencyTestRoomService{}, NewLocalStore(), time.Minute)
+
+		_, err := svc.CreateRoom(withKey("create"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		_, err = svc.CreateRoom(withKey("create"), &livekit.CreateRoomRequest{Name: "retro"})
+		require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+		_, err = svc.CreateRoom(withKey(""), &livekit.CreateRoomRequest{Name: "standup"})
+		require.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+	})
+
+	t.Run("releases keys of failed requests", func(t *testing.T) {
+		rooms := &idempotencyTestRoomService{err: errors.New("timeout")}
+		svc := NewIdempotentRoomService(rooms, NewLocalStore(), time.Minute)
+
+		_, err := svc.CreateRoom(withKey("create-standup"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.Error(t, err)
+
+		rooms.err = nil
+		_, err = svc.CreateRoom(withKey("create-standup"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		require.Equal(t, 2, rooms.calls)
+	})
+
+	t.Run("expires keys after the window", func(t *testing.T) {
+		rooms := &idempotencyTestRoomService{}
+		svc := NewIdempotentRoomService(rooms, NewLocalStore(), 10*time.Millisecond)
+
+		_, err := svc.CreateRoom(withKey("create-standup"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = svc.CreateRoom(withKey("create-standup"), &livekit.CreateRoomRequest{Name: "standup"})
+		require.NoError(t, err)
+		require.Equal(t, 2, rooms.calls)
+	})
+
+	t.Run("is disabled without a store", func(t *testing.T) {
+		rooms := &idempotencyTestRoomService{}
+		require.Same(t, rooms, NewIdempotentRoomService(rooms, nil, time.Minute))
+	})
+}
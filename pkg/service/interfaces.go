@@ -48,10 +48,102 @@ type ServiceStore interface {
 	// ListRooms returns currently active rooms. if names is not nil, it'll filter and return
 	// only rooms that match
 	ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error)
+	// ListRoomsPage returns a page of rooms matching the options in name order,
+	// and the token of the next page when there are more rooms
+	ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error)
+
+	// labels are managed by the API rather than by RTC nodes, and are stored apart from the room
+	LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error)
+	StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error
+
+	// LoadRoomVersion returns the room with its version. the version changes with the metadata of the room, writes
+	// of nodes that leave the metadata unchanged, such as of the number of participants, keep the version
+	LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error)
+	// UpdateRoom stores the room only if it is still at expectedVersion, and returns its new version.
+	// returns ErrRoomVersionConflict when the metadata of the room has changed since
+	UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error)
+
 	LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error)
 	ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 }
 
+// persists the sessions of participants, after they have left the room
+//
+//counterfeiter:generate . ParticipantStore
+type ParticipantStore interface {
+	// StoreParticipantSession creates or replaces the session with the participant ID of the session
+	StoreParticipantSession(ctx context.Context, session *ParticipantSession) error
+	LoadParticipantSession(ctx context.Context, participantID livekit.ParticipantID) (*ParticipantSession, error)
+	// ListParticipantSessions returns sessions of the room, only of participants still in the room when active is set
+	ListParticipantSessions(ctx context.Context, roomName livekit.RoomName, active bool) ([]*ParticipantSession, error)
+}
+
+// keeps records of rooms that have ended
+//
+//counterfeiter:generate . RoomHistoryStore
+type RoomHistoryStore interface {
+	// StoreRoomHistory records the room, and removes records that ended longer than retention ago
+	StoreRoomHistory(ctx context.Context, history *RoomHistory, retention time.Duration) error
+	// ListRoomHistory returns up to limit records, the latest first. records of all rooms are returned when roomName is empty
+	ListRoomHistory(ctx context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error)
+}
+
+// keeps room templates by name
+//
+//counterfeiter:generate . RoomTemplateStore
+type RoomTemplateStore interface {
+	// StoreRoomTemplate creates or replaces the template with the name of the template
+	StoreRoomTemplate(ctx context.Context, template *RoomTemplate) error
+	LoadRoomTemplate(ctx context.Context, name string) (*RoomTemplate, error)
+	ListRoomTemplates(ctx context.Context) ([]*RoomTemplate, error)
+	DeleteRoomTemplate(ctx context.Context, name string) error
+}
+
+// deletes sets of related rooms together
+//
+//counterfeiter:generate . RoomBatchStore
+type RoomBatchStore interface {
+	// DeleteRooms deletes all of the rooms, or none of them when it fails
+	DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error
+}
+
+// keeps the outcome of requests by their idempotency keys, until the keys expire
+//
+//counterfeiter:generate . IdempotencyStore
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey stores the record for ttl when its key is not taken, and returns the record of the key
+	// otherwise
+	ReserveIdempotencyKey(ctx context.Context, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// StoreIdempotencyRecord replaces the record of a reserved key, it expires with the reservation
+	StoreIdempotencyRecord(ctx context.Context, record *IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, key string) error
+}
+
+// keeps revoked tokens until they would have expired
+//
+//counterfeiter:generate . TokenRevocationStore
+type TokenRevocationStore interface {
+	// RevokeToken revokes the token of the ID for ttl
+	RevokeToken(ctx context.Context, tokenID string, ttl time.Duration) error
+	// RevokeIdentityTokens revokes the tokens of the identity issued up to revokedAt, for ttl
+	RevokeIdentityTokens(ctx context.Context, identity string, revokedAt time.Time, ttl time.Duration) error
+	// IsTokenRevoked returns whether the token of the ID, or the tokens of the identity issued at issuedAt, are
+	// revoked. tokenID may be empty for tokens without an ID
+	IsTokenRevoked(ctx context.Context, tokenID string, identity string, issuedAt time.Time) (bool, error)
+}
+
+// counts the usage of the quotas of API keys by their members, rooms, participants or egress of a room. members
+// of a room are released as the room is deleted, participants as they are deleted and egress as it ends
+//
+//counterfeiter:generate . KeyUsageStore
+type KeyUsageStore interface {
+	// AcquireKeyUsage counts the member of the room against the quota of the key unless the usage is at limit, and
+	// returns the usage. members that are already counted are acquired again
+	AcquireKeyUsage(ctx context.Context, apiKey string, quota string, roomName livekit.RoomName, member string, limit int) (int, bool, error)
+	// ReleaseKeyUsage no longer counts the member against the quota of the key
+	ReleaseKeyUsage(ctx context.Context, apiKey string, quota string, member string) error
+}
+
 //counterfeiter:generate . EgressStore
 type EgressStore interface {
 	StoreEgress(ctx context.Context, info *livekit.EgressInfo) error
@@ -0,0 +1,209 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+var ErrJoinDenied = errors.New("join denied by policy")
+
+// JoinPolicies evaluate the join policies of the config when participants join a room. expressions have these
+// variables:
+//
+//	token: identity, name, metadata, attributes, issuer, and grants with room, room_join, room_admin, can_publish,
+//	       can_subscribe, can_publish_data, hidden and recorder
+//	room: name, exists, metadata, num_participants, created_at, and participants with identity, name, metadata,
+//	      joined_at, can_publish, can_subscribe, hidden and recorder
+//	request: ip
+//	now: the time of the join
+//
+// i.e. `!token.grants.can_publish || room.participants.filter(p, p.can_publish).size() < 2`. expressions that fail
+// to evaluate, like selecting a key a map does not have, deny the join
+type JoinPolicies struct {
+	policies []*joinPolicy
+}
+
+type joinPolicy struct {
+	name    string
+	program cel.Program
+}
+
+// joinPolicyInput is the state a join is evaluated with
+type joinPolicyInput struct {
+	grants     *auth.ClaimGrants
+	attributes map[string]string
+	issuer     string
+
+	roomName     livekit.RoomName
+	room         *livekit.Room
+	participants []*livekit.ParticipantInfo
+
+	clientIP string
+	now      time.Time
+}
+
+func NewJoinPolicies(conf *config.Config) (*JoinPolicies, error) {
+	p := &JoinPolicies{}
+	if len(conf.JoinPolicies) == 0 {
+		return p, nil
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("token", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("room", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("now", cel.TimestampType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, policyConf := range conf.JoinPolicies {
+		ast, issues := env.Compile(policyConf.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("could not compile join policy %s: %v", policyConf.Name, issues.Err())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile join policy %s: %v", policyConf.Name, err)
+		}
+		p.policies = append(p.policies, &joinPolicy{
+			name:    policyConf.Name,
+			program: program,
+		})
+	}
+	return p, nil
+}
+
+func (p *JoinPolicies) Enabled() bool {
+	return p != nil && len(p.policies) != 0
+}
+
+// Evaluate returns ErrJoinDenied, naming the policy, unless all policies allow the join
+func (p *JoinPolicies) Evaluate(in *joinPolicyInput) error {
+	if !p.Enabled() {
+		return nil
+	}
+	vars := in.variables()
+	for _, policy := range p.policies {
+		out, _, err := policy.program.Eval(vars)
+		if err != nil {
+			logger.Infow("could not evaluate join policy", "policy", policy.name, "error", err)
+			return fmt.Errorf("%w: %s", ErrJoinDenied, policy.name)
+		}
+		if allowed, ok := out.Value().(bool); !ok || !allowed {
+			return fmt.Errorf("%w: %s", ErrJoinDenied, policy.name)
+		}
+	}
+	return nil
+}
+
+func (in *joinPolicyInput) variables() map[string]interface{} {
+	grants := in.grants
+	video := grants.Video
+	if video == nil {
+		video = &auth.VideoGrant{}
+	}
+	attributes := in.attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	token := map[string]interface{}{
+		"identity":   grants.Identity,
+		"name":       grants.Name,
+		"metadata":   grants.Metadata,
+		"attributes": attributes,
+		"issuer":     in.issuer,
+		"grants": map[string]interface{}{
+			"room":             video.Room,
+			"room_join":        video.RoomJoin,
+			"room_admin":       video.RoomAdmin,
+			"can_publish":      video.GetCanPublish(),
+			"can_subscribe":    video.GetCanSubscribe(),
+			"can_publish_data": video.GetCanPublishData(),
+			"hidden":           video.Hidden,
+			"recorder":         video.Recorder,
+		},
+	}
+
+	participants := make([]interface{}, 0, len(in.participants))
+	for _, pi := range in.participants {
+		participants = append(participants, map[string]interface{}{
+			"identity":      pi.Identity,
+			"name":          pi.Name,
+			"metadata":      pi.Metadata,
+			"joined_at":     time.Unix(pi.JoinedAt, 0),
+			"can_publish":   pi.GetPermission().GetCanPublish(),
+			"can_subscribe": pi.GetPermission().GetCanSubscribe(),
+			"hidden":        pi.GetPermission().GetHidden(),
+			"recorder":      pi.GetPermission().GetRecorder(),
+		})
+	}
+	room := map[string]interface{}{
+		"name":             string(in.roomName),
+		"exists":           in.room != nil,
+		"metadata":         in.room.GetMetadata(),
+		"num_participants": int64(len(in.participants)),
+		"created_at":       time.Unix(in.room.GetCreationTime(), 0),
+		"participants":     participants,
+	}
+
+	return map[string]interface{}{
+		"token":   token,
+		"room":    room,
+		"request": map[string]interface{}{"ip": in.clientIP},
+		"now":     in.now,
+	}
+}
+
+// ensureJoinAllowed evaluates the join policies, with the state of the room being joined
+func (s *RTCService) ensureJoinAllowed(ctx context.Context, roomName livekit.RoomName, clientIP string) error {
+	if !s.joinPolicies.Enabled() {
+		return nil
+	}
+	in := &joinPolicyInput{
+		grants:     GetGrants(ctx),
+		attributes: tokenAttributes(ctx),
+		roomName:   roomName,
+		clientIP:   clientIP,
+		now:        time.Now(),
+	}
+	if claims := getTokenClaims(ctx); claims != nil {
+		in.issuer = claims.Issuer
+	}
+
+	room, _, err := s.store.LoadRoom(ctx, roomName, false)
+	switch {
+	case err == nil:
+		in.room = room
+		if in.participants, err = s.store.ListParticipants(ctx, roomName); err != nil {
+			return err
+		}
+	case err != ErrRoomNotFound:
+		return err
+	}
+	return s.joinPolicies.Evaluate(in)
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestJoinPolicies(t *testing.T) {
+	newPolicies := func(t *testing.T, expressions ...string) *JoinPolicies {
+		conf := &config.Config{}
+		for i, expression := range expressions {
+			conf.JoinPolicies = append(conf.JoinPolicies, config.JoinPolicyConfig{
+				Name:       fmt.Sprintf("policy-%d", i),
+				Expression: expression,
+			})
+		}
+		p, err := NewJoinPolicies(conf)
+		require.NoError(t, err)
+		return p
+	}
+	publisher := func() *auth.VideoGrant {
+		video := &auth.VideoGrant{RoomJoin: true}
+		video.SetCanPublish(true)
+		return video
+	}
+	viewer := func() *auth.VideoGrant {
+		video := &auth.VideoGrant{RoomJoin: true}
+		video.SetCanPublish(false)
+		return video
+	}
+	newInput := func(video *auth.VideoGrant, participants ...*livekit.ParticipantInfo) *joinPolicyInput {
+		return &joinPolicyInput{
+			grants:       &auth.ClaimGrants{Identity: "alice", Video: video},
+			attributes:   map[string]string{"role": "host"},
+			roomName:     "room1",
+			room:         &livekit.Room{Name: "room1", CreationTime: time.Now().Add(-time.Hour).Unix()},
+			participants: participants,
+			clientIP:     "10.0.0.1",
+			now:          time.Now(),
+		}
+	}
+	host := &livekit.ParticipantInfo{Identity: "host", Permission: &livekit.ParticipantPermission{CanPublish: true}}
+
+	t.Run("none configured", func(t *testing.T) {
+		p := newPolicies(t)
+		require.False(t, p.Enabled())
+		require.NoError(t, p.Evaluate(newInput(publisher())))
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := NewJoinPolicies(&config.Config{JoinPolicies: []config.JoinPolicyConfig{{Name: "a", Expression: "room.("}}})
+		require.Error(t, err)
+	})
+
+	t.Run("hosts per room", func(t *testing.T) {
+		p := newPolicies(t, `!token.grants.can_publish || room.participants.filter(x, x.can_publish).size() < 2`)
+		require.NoError(t, p.Evaluate(newInput(publisher(), host)))
+		require.ErrorIs(t, p.Evaluate(newInput(publisher(), host, host)), ErrJoinDenied)
+		require.NoError(t, p.Evaluate(newInput(viewer(), host, host)))
+	})
+
+	t.Run("room live for too long", func(t *testing.T) {
+		p := newPolicies(t, `!room.exists || now - room.created_at < duration("2h")`)
+		require.NoError(t, p.Evaluate(newInput(publisher())))
+		in := newInput(publisher())
+		in.room.CreationTime = time.Now().Add(-3 * time.Hour).Unix()
+		require.ErrorIs(t, p.Evaluate(in), ErrJoinDenied)
+		in.room = nil
+		require.NoError(t, p.Evaluate(in))
+	})
+
+	t.Run("all policies allow", func(t *testing.T) {
+		p := newPolicies(t, `token.attributes.role == "host"`, `request.ip.startsWith("192.168.")`)
+		err := p.Evaluate(newInput(publisher()))
+		require.ErrorIs(t, err, ErrJoinDenied)
+		require.EqualError(t, err, "join denied by policy: policy-1")
+	})
+
+	t.Run("errors deny", func(t *testing.T) {
+		// missing key
+		p := newPolicies(t, `token.attributes.team == "red"`)
+		require.ErrorIs(t, p.Evaluate(newInput(publisher())), ErrJoinDenied)
+		p = newPolicies(t, `!has(token.attributes.team) || token.attributes.team == "red"`)
+		require.NoError(t, p.Evaluate(newInput(publisher())))
+		// not a bool
+		p = newPolicies(t, `token.identity`)
+		require.ErrorIs(t, p.Evaluate(newInput(publisher())), ErrJoinDenied)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/psrpc"
+)
+
+// jsonHandler serves an HTTP API with JSON requests and responses, by method. the response a method returns is
+// written as JSON, protobuf messages as their protojson, nil responses as 204 No Content. errors are written with
+// the HTTP status of their code
+type jsonHandler map[string]func(r *http.Request) (interface{}, error)
+
+func (h jsonHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle, ok := h[r.Method]
+	if !ok {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	res, err := handle(r)
+	if err != nil {
+		handleError(w, errorStatus(err), err)
+		return
+	}
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var b []byte
+	if msg, ok := res.(proto.Message); ok {
+		b, err = protojson.Marshal(msg)
+	} else {
+		b, err = json.Marshal(res)
+	}
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// jsonRequest handles requests with a JSON body of Req, bodies are read up to maxSize
+func jsonRequest[Req any](maxSize int64, handle func(ctx context.Context, req *Req) (interface{}, error)) func(r *http.Request) (interface{}, error) {
+	return func(r *http.Request) (interface{}, error) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSize))
+		if err != nil {
+			return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+		}
+		req := new(Req)
+		if err = json.Unmarshal(body, req); err != nil {
+			return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+		}
+		return handle(r.Context(), req)
+	}
+}
+
+// protoJSONList returns the protojson of each of the messages, for responses with lists of messages
+func protoJSONList[T proto.Message](msgs []T) ([]json.RawMessage, error) {
+	list := make([]json.RawMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, data)
+	}
+	return list, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+var (
+	ErrEgressQuotaExceeded      = psrpc.NewErrorf(psrpc.ResourceExhausted, "egress quota of the API key is exceeded")
+	ErrParticipantQuotaExceeded = psrpc.NewErrorf(psrpc.ResourceExhausted, "participant quota of the API key is exceeded")
+	ErrRoomQuotaExceeded        = psrpc.NewErrorf(psrpc.ResourceExhausted, "room quota of the API key is exceeded")
+)
+
+const (
+	// the API key a room counts against is kept with the labels of the room, rooms of keys without quotas have none
+	roomAPIKeyLabel = reservedLabelPrefix + "api-key"
+
+	keyQuotaRooms        = "rooms"
+	keyQuotaParticipants = "participants"
+	keyQuotaEgress       = "egress"
+)
+
+var keyQuotas = []string{keyQuotaRooms, keyQuotaParticipants, keyQuotaEgress}
+
+// participantUsageMember is the member of a participant in the usage of the participant quota of a key
+func participantUsageMember(roomName livekit.RoomName, identity livekit.ParticipantIdentity) string {
+	return string(roomName) + ":" + string(identity)
+}
+
+// roomQuotaKey returns the API key the room counts against, the key of the request for rooms not created yet. ok is
+// false when the key has no quotas
+func roomQuotaKey(ctx context.Context, quotas map[string]config.KeyQuota, store ServiceStore, roomName livekit.RoomName) (string, config.KeyQuota, bool, error) {
+	if len(quotas) == 0 {
+		return "", config.KeyQuota{}, false, nil
+	}
+	labels, err := store.LoadRoomLabels(ctx, roomName)
+	if err != nil && err != ErrRoomNotFound {
+		return "", config.KeyQuota{}, false, err
+	}
+	apiKey, ok := labels[roomAPIKeyLabel]
+	if !ok {
+		if _, _, err = store.LoadRoom(ctx, roomName, false); err == nil {
+			// created by a key without quotas
+			return "", config.KeyQuota{}, false, nil
+		} else if err != ErrRoomNotFound {
+			return "", config.KeyQuota{}, false, err
+		}
+		apiKey, _ = GetAPIKey(ctx)
+	}
+	quota, ok := quotas[apiKey]
+	return apiKey, quota, ok, nil
+}
+
+// acquireRoomQuota counts a room created by the API key of the request against its room quota. it is called as the
+// room is created, under the lock of the room
+func acquireRoomQuota(ctx context.Context, quotas map[string]config.KeyQuota, usage KeyUsageStore, roomName livekit.RoomName) error {
+	apiKey, _ := GetAPIKey(ctx)
+	quota, ok := quotas[apiKey]
+	if !ok || quota.MaxRooms == 0 || usage == nil {
+		return nil
+	}
+	return acquireKeyQuota(ctx, usage, apiKey, keyQuotaRooms, roomName, string(roomName), quota.MaxRooms, ErrRoomQuotaExceeded)
+}
+
+// releaseRoomQuota releases a room that could not be created from the room quota of the API key of the request
+func releaseRoomQuota(ctx context.Context, quotas map[string]config.KeyQuota, usage KeyUsageStore, roomName livekit.RoomName) {
+	apiKey, _ := GetAPIKey(ctx)
+	if _, ok := quotas[apiKey]; !ok || usage == nil {
+		return
+	}
+	if err := usage.ReleaseKeyUsage(ctx, apiKey, keyQuotaRooms, string(roomName)); err != nil {
+		logger.Errorw("could not release room quota", err, "room", roomName)
+	}
+}
+
+// setRoomQuotaKey has a room created by a key with quotas count against it. it is called as the room is created, under
+// the lock of the room
+func setRoomQuotaKey(ctx context.Context, quotas map[string]config.KeyQuota, store ServiceStore, roomName livekit.RoomName) error {
+	apiKey, _ := GetAPIKey(ctx)
+	if _, ok := quotas[apiKey]; !ok {
+		return nil
+	}
+	labels, err := store.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	if labels[roomAPIKeyLabel] == apiKey {
+		return nil
+	}
+	if labels == nil {
+		labels = RoomLabels{}
+	}
+	labels[roomAPIKeyLabel] = apiKey
+	return store.StoreRoomLabels(ctx, roomName, labels)
+}
+
+// ensureJoinQuotas counts the participant against the participant quota of the key of the room, joins creating the
+// room count against the room quota of the key of their token as the room is created. participants are released as
+// they leave, and with their room
+func (s *RTCService) ensureJoinQuotas(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	if s.keyUsage == nil {
+		return nil
+	}
+	apiKey, quota, ok, err := roomQuotaKey(ctx, s.config.KeyQuotas, s.store, roomName)
+	if err != nil || !ok || quota.MaxParticipants == 0 {
+		return err
+	}
+	member := participantUsageMember(roomName, identity)
+	return acquireKeyQuota(ctx, s.keyUsage, apiKey, keyQuotaParticipants, roomName, member, quota.MaxParticipants, ErrParticipantQuotaExceeded)
+}
+
+// releaseJoinQuotas releases a participant that could not join from the participant quota of the key of the room
+func (s *RTCService) releaseJoinQuotas(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) {
+	if s.keyUsage == nil {
+		return
+	}
+	apiKey, quota, ok, err := roomQuotaKey(ctx, s.config.KeyQuotas, s.store, roomName)
+	if err == nil && ok && quota.MaxParticipants != 0 {
+		err = s.keyUsage.ReleaseKeyUsage(ctx, apiKey, keyQuotaParticipants, participantUsageMember(roomName, identity))
+	}
+	if err != nil {
+		logger.Errorw("could not release participant quota", err, "room", roomName, "participant", identity)
+	}
+}
+
+// ensureEgressQuota counts the egress against the egress quota of the key of the room, it is released as the egress
+// ends or with its room. egress of web pages is not of a room, and is not limited
+func (s *EgressService) ensureEgressQuota(ctx context.Context, roomName livekit.RoomName, egressID string) error {
+	if roomName == "" || s.keyUsage == nil {
+		return nil
+	}
+	apiKey, quota, ok, err := roomQuotaKey(ctx, s.quotas, s.store, roomName)
+	if err != nil || !ok || quota.MaxEgress == 0 {
+		return err
+	}
+	return acquireKeyQuota(ctx, s.keyUsage, apiKey, keyQuotaEgress, roomName, egressID, quota.MaxEgress, ErrEgressQuotaExceeded)
+}
+
+// releaseEgressQuota releases egress that could not be started from the egress quota of the key of the room
+func (s *EgressService) releaseEgressQuota(ctx context.Context, roomName livekit.RoomName, egressID string) {
+	if roomName == "" || s.keyUsage == nil {
+		return
+	}
+	apiKey, quota, ok, err := roomQuotaKey(ctx, s.quotas, s.store, roomName)
+	if err == nil && ok && quota.MaxEgress != 0 {
+		err = s.keyUsage.ReleaseKeyUsage(ctx, apiKey, keyQuotaEgress, egressID)
+	}
+	if err != nil {
+		logger.Errorw("could not release egress quota", err, "room", roomName, "egressID", egressID)
+	}
+}
+
+// acquireKeyQuota counts the member of the room against the quota of the key atomically, exceeded is returned when
+// the quota is used up
+func acquireKeyQuota(ctx context.Context, usage KeyUsageStore, apiKey string, quota string, roomName livekit.RoomName, member string, limit int, exceeded error) error {
+	n, acquired, err := usage.AcquireKeyUsage(ctx, apiKey, quota, roomName, member, limit)
+	if err != nil {
+		return err
+	}
+	prometheus.RecordKeyQuota(apiKey, quota, n, acquired)
+	if !acquired {
+		logger.Infow("API key quota exceeded", "apiKey", apiKey, "quota", quota, "usage", n, "limit", limit)
+		return exceeded
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestKeyQuotas(t *testing.T) {
+	quotas := map[string]config.KeyQuota{
+		"APIlimited": {MaxRooms: 2, MaxParticipants: 3, MaxEgress: 1},
+	}
+	store := NewLocalStore()
+	s := &RTCService{
+		config:   &config.Config{KeyQuotas: quotas},
+		store:    store,
+		keyUsage: store,
+	}
+	es := &EgressService{quotas: quotas, store: store, keyUsage: store}
+	limited := WithAPIKey(context.Background(), "APIlimited")
+	other := WithAPIKey(context.Background(), "APIother")
+
+	createRoom := func(ctx context.Context, name string) error {
+		if err := acquireRoomQuota(ctx, quotas, store, livekit.RoomName(name)); err != nil {
+			return err
+		}
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: name}, nil))
+		return setRoomQuotaKey(ctx, quotas, store, livekit.RoomName(name))
+	}
+
+	t.Run("rooms", func(t *testing.T) {
+		require.NoError(t, createRoom(limited, "first"))
+		require.NoError(t, createRoom(limited, "second"))
+		require.ErrorIs(t, createRoom(limited, "third"), ErrRoomQuotaExceeded)
+		// rooms that are counted already are not counted again
+		require.NoError(t, acquireRoomQuota(limited, quotas, store, "second"))
+
+		// keys without quotas are not limited, and their rooms have no key
+		require.NoError(t, createRoom(other, "other"))
+		labels, err := store.LoadRoomLabels(context.Background(), "other")
+		require.NoError(t, err)
+		require.NotContains(t, labels, roomAPIKeyLabel)
+	})
+
+	t.Run("participants", func(t *testing.T) {
+		// joins count against the key of the room, whichever key signed the token
+		require.NoError(t, s.ensureJoinQuotas(other, "first", "alice"))
+		require.NoError(t, s.ensureJoinQuotas(limited, "second", "bob"))
+		require.NoError(t, s.ensureJoinQuotas(limited, "second", "carol"))
+		require.ErrorIs(t, s.ensureJoinQuotas(other, "first", "dave"), ErrParticipantQuotaExceeded)
+		// participants that are counted already join again
+		require.NoError(t, s.ensureJoinQuotas(other, "first", "alice"))
+
+		// participants are released as they leave, or fail to join
+		require.NoError(t, store.DeleteParticipant(context.Background(), "second", "bob"))
+		require.NoError(t, s.ensureJoinQuotas(other, "first", "dave"))
+		s.releaseJoinQuotas(other, "first", "dave")
+		require.NoError(t, s.ensureJoinQuotas(limited, "second", "erin"))
+		require.ErrorIs(t, s.ensureJoinQuotas(limited, "second", "frank"), ErrParticipantQuotaExceeded)
+
+		// rooms of keys without quotas are not limited
+		require.NoError(t, s.ensureJoinQuotas(limited, "other", "frank"))
+	})
+
+	t.Run("egress", func(t *testing.T) {
+		require.NoError(t, es.ensureEgressQuota(other, "first", "EG_1"))
+		require.ErrorIs(t, es.ensureEgressQuota(limited, "second", "EG_2"), ErrEgressQuotaExceeded)
+		es.releaseEgressQuota(other, "first", "EG_1")
+		require.NoError(t, es.ensureEgressQuota(limited, "second", "EG_2"))
+		// egress of web pages is not limited
+		require.NoError(t, es.ensureEgressQuota(limited, "", "EG_3"))
+	})
+
+	t.Run("rooms release their members as they are deleted", func(t *testing.T) {
+		require.NoError(t, store.DeleteRoom(context.Background(), "second"))
+		require.NoError(t, createRoom(limited, "third"))
+		require.NoError(t, s.ensureJoinQuotas(limited, "third", "bob"))
+		require.NoError(t, s.ensureJoinQuotas(limited, "third", "carol"))
+		require.NoError(t, es.ensureEgressQuota(limited, "third", "EG_4"))
+	})
+}
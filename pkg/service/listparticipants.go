@@ -0,0 +1,226 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thoas/go-funk"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	defaultListParticipantsPageSize = 100
+	maxListParticipantsPageSize     = 1000
+
+	// separates the room and identity of the last participant of a page in page tokens
+	participantPageTokenSeparator = "\x00"
+)
+
+// ListAllParticipantsOptions filters the participants of all rooms, participants are returned in room and
+// identity order. Zero values do not filter
+type ListAllParticipantsOptions struct {
+	Rooms          []livekit.RoomName
+	IdentityPrefix string
+	States         []livekit.ParticipantInfo_State
+
+	PageSize  int
+	PageToken string
+}
+
+func (o *ListAllParticipantsOptions) matches(pi *livekit.ParticipantInfo) bool {
+	if !strings.HasPrefix(pi.Identity, o.IdentityPrefix) {
+		return false
+	}
+	return len(o.States) == 0 || funk.Contains(o.States, pi.State)
+}
+
+// RoomParticipant is a participant listed with the room it is in
+type RoomParticipant struct {
+	Room        livekit.RoomName
+	Participant *livekit.ParticipantInfo
+}
+
+// ParticipantListService lists the participants of all rooms of the cluster at /participants, such as to locate
+// a user for support. participants are read from the store, where the nodes hosting rooms keep them.
+// GET ?room=&identity_prefix=&state=ACTIVE&page_size=&page_token= responds with a page of participants and the
+// token of the next page, room and state can be repeated
+type ParticipantListService struct {
+	store ObjectStore
+}
+
+func NewParticipantListService(store ObjectStore) *ParticipantListService {
+	return &ParticipantListService{
+		store: store,
+	}
+}
+
+// ListAllParticipants returns a page of the participants matching the options, and the token of the next page
+// when there are more participants
+func (s *ParticipantListService) ListAllParticipants(ctx context.Context, opts ListAllParticipantsOptions) ([]RoomParticipant, string, error) {
+	// listing participants of any room requires both list and admin permissions
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, "", err
+	}
+	if claims := GetGrants(ctx); !claims.Video.RoomAdmin {
+		return nil, "", ErrPermissionDenied
+	}
+
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultListParticipantsPageSize
+	}
+	if opts.PageSize > maxListParticipantsPageSize {
+		opts.PageSize = maxListParticipantsPageSize
+	}
+	token, err := decodePageToken(opts.PageToken)
+	if err != nil {
+		return nil, "", err
+	}
+	afterRoom, afterIdentity, _ := strings.Cut(token, participantPageTokenSeparator)
+
+	project, scoped := GetProject(ctx)
+	var names []livekit.RoomName
+	if opts.Rooms != nil {
+		names = make([]livekit.RoomName, 0, len(opts.Rooms))
+		for _, name := range opts.Rooms {
+			if scoped {
+				scopedName, err := scopeRoomName(project, string(name))
+				if err != nil {
+					return nil, "", err
+				}
+				name = livekit.RoomName(scopedName)
+			}
+			names = append(names, name)
+		}
+	}
+	rooms, err := s.store.ListRooms(ctx, names)
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+
+	// one more than the page is listed to know if there is a next page
+	listed := make([]RoomParticipant, 0, opts.PageSize+1)
+	for _, room := range rooms {
+		if room.Name < afterRoom {
+			continue
+		}
+		if scoped {
+			if roomProject, _ := utils.SplitProjectRoomName(livekit.RoomName(room.Name)); roomProject != project {
+				continue
+			}
+		}
+
+		participants, err := s.store.ListParticipants(ctx, livekit.RoomName(room.Name))
+		if err != nil {
+			return nil, "", err
+		}
+		sort.Slice(participants, func(i, j int) bool {
+			return participants[i].Identity < participants[j].Identity
+		})
+		for _, pi := range participants {
+			if room.Name == afterRoom && pi.Identity <= afterIdentity {
+				continue
+			}
+			if !opts.matches(pi) {
+				continue
+			}
+			listed = append(listed, RoomParticipant{Room: livekit.RoomName(room.Name), Participant: pi})
+		}
+		if len(listed) > opts.PageSize {
+			break
+		}
+	}
+
+	var nextPageToken string
+	if len(listed) > opts.PageSize {
+		listed = listed[:opts.PageSize]
+		last := listed[len(listed)-1]
+		nextPageToken = encodePageToken(string(last.Room) + participantPageTokenSeparator + last.Participant.Identity)
+	}
+	if scoped {
+		for i := range listed {
+			_, listed[i].Room = utils.SplitProjectRoomName(listed[i].Room)
+		}
+	}
+	return listed, nextPageToken, nil
+}
+
+type roomParticipantResponse struct {
+	Room        string          `json:"room"`
+	Participant json.RawMessage `json:"participant"`
+}
+
+type listAllParticipantsResponse struct {
+	Participants  []roomParticipantResponse `json:"participants"`
+	NextPageToken string                    `json:"next_page_token,omitempty"`
+}
+
+func (s *ParticipantListService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			opts := ListAllParticipantsOptions{
+				IdentityPrefix: query.Get("identity_prefix"),
+				PageToken:      query.Get("page_token"),
+			}
+			if rooms := query["room"]; len(rooms) > 0 {
+				opts.Rooms = livekit.StringsAsIDs[livekit.RoomName](rooms)
+			}
+			for _, v := range query["state"] {
+				state, ok := livekit.ParticipantInfo_State_value[strings.ToUpper(v)]
+				if !ok {
+					return nil, ErrInvalidListOptions
+				}
+				opts.States = append(opts.States, livekit.ParticipantInfo_State(state))
+			}
+			if v := query.Get("page_size"); v != "" {
+				size, err := strconv.Atoi(v)
+				if err != nil || size < 0 {
+					return nil, ErrInvalidListOptions
+				}
+				opts.PageSize = size
+			}
+
+			participants, nextPageToken, err := s.ListAllParticipants(r.Context(), opts)
+			if err != nil {
+				return nil, err
+			}
+			res := &listAllParticipantsResponse{
+				Participants:  make([]roomParticipantResponse, 0, len(participants)),
+				NextPageToken: nextPageToken,
+			}
+			for _, p := range participants {
+				data, err := protojson.Marshal(p.Participant)
+				if err != nil {
+					return nil, err
+				}
+				res.Participants = append(res.Participants, roomParticipantResponse{Room: string(p.Room), Participant: data})
+			}
+			return res, nil
+		},
+	}.ServeHTTP(w, r)
+}
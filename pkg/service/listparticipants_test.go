@@ -0,0 +1,115 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestListAllParticipants(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomList: true, RoomAdmin: true},
+	})
+
+	store := service.NewLocalStore()
+	for room, participants := range map[livekit.RoomName][]*livekit.ParticipantInfo{
+		"b-room": {
+			{Identity: "support-agent", State: livekit.ParticipantInfo_ACTIVE},
+			{Identity: "user-3", State: livekit.ParticipantInfo_JOINING},
+		},
+		"a-room": {
+			{Identity: "user-2", State: livekit.ParticipantInfo_ACTIVE},
+			{Identity: "user-1", State: livekit.ParticipantInfo_ACTIVE},
+		},
+		"c-room": {
+			{Identity: "user-1", State: livekit.ParticipantInfo_DISCONNECTED},
+		},
+	} {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: string(room)}, nil))
+		for _, pi := range participants {
+			require.NoError(t, store.StoreParticipant(ctx, room, pi))
+		}
+	}
+	svc := service.NewParticipantListService(store)
+
+	list := func(opts service.ListAllParticipantsOptions) ([]string, string) {
+		participants, nextPageToken, err := svc.ListAllParticipants(ctx, opts)
+		require.NoError(t, err)
+		listed := make([]string, 0, len(participants))
+		for _, p := range participants {
+			listed = append(listed, string(p.Room)+"/"+p.Participant.Identity)
+		}
+		return listed, nextPageToken
+	}
+
+	t.Run("requires list and admin permissions", func(t *testing.T) {
+		listOnly := service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomList: true},
+		})
+		_, _, err := svc.ListAllParticipants(listOnly, service.ListAllParticipantsOptions{})
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("lists participants of all rooms in order", func(t *testing.T) {
+		listed, nextPageToken := list(service.ListAllParticipantsOptions{})
+		require.Equal(t, []string{"a-room/user-1", "a-room/user-2", "b-room/support-agent", "b-room/user-3", "c-room/user-1"}, listed)
+		require.Empty(t, nextPageToken)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		listed, _ := list(service.ListAllParticipantsOptions{IdentityPrefix: "user-1"})
+		require.Equal(t, []string{"a-room/user-1", "c-room/user-1"}, listed)
+
+		listed, _ = list(service.ListAllParticipantsOptions{
+			IdentityPrefix: "user-",
+			States:         []livekit.ParticipantInfo_State{livekit.ParticipantInfo_ACTIVE, livekit.ParticipantInfo_JOINING},
+		})
+		require.Equal(t, []string{"a-room/user-1", "a-room/user-2", "b-room/user-3"}, listed)
+
+		listed, _ = list(service.ListAllParticipantsOptions{Rooms: []livekit.RoomName{"b-room", "c-room"}})
+		require.Equal(t, []string{"b-room/support-agent", "b-room/user-3", "c-room/user-1"}, listed)
+	})
+
+	t.Run("pages across rooms", func(t *testing.T) {
+		var pages [][]string
+		opts := service.ListAllParticipantsOptions{PageSize: 2}
+		for {
+			listed, nextPageToken := list(opts)
+			pages = append(pages, listed)
+			if nextPageToken == "" {
+				break
+			}
+			opts.PageToken = nextPageToken
+		}
+		require.Equal(t, [][]string{
+			{"a-room/user-1", "a-room/user-2"},
+			{"b-room/support-agent", "b-room/user-3"},
+			{"c-room/user-1"},
+		}, pages)
+	})
+
+	t.Run("rejects invalid page tokens", func(t *testing.T) {
+		_, _, err := svc.ListAllParticipants(ctx, service.ListAllParticipantsOptions{PageToken: "!"})
+		require.ErrorIs(t, err, service.ErrInvalidPageToken)
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thoas/go-funk"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	// ListRoomsRequest has no paging or filter fields, they are options of the request
+	pageSizeHeader         = "X-Livekit-Page-Size"
+	pageTokenHeader        = "X-Livekit-Page-Token"
+	nextPageTokenHeader    = "X-Livekit-Next-Page-Token"
+	namePrefixHeader       = "X-Livekit-Name-Prefix"
+	createdAfterHeader     = "X-Livekit-Created-After"
+	createdBeforeHeader    = "X-Livekit-Created-Before"
+	metadataContainsHeader = "X-Livekit-Metadata-Contains"
+
+	maxListRoomsPageSize = 1000
+)
+
+// ListRoomsOptions filters rooms, rooms are returned in name order. Zero values do not filter.
+type ListRoomsOptions struct {
+	Names            []livekit.RoomName
+	NamePrefix       string
+	CreatedAfter     time.Time
+	CreatedBefore    time.Time
+	MetadataContains string
+	LabelSelector    LabelSelector
+	// only rooms of Project are listed when ScopeToProject is set, NamePrefix includes the project
+	Project        string
+	ScopeToProject bool
+
+	// all matching rooms are returned when PageSize is 0
+	PageSize  int
+	PageToken string
+}
+
+func (o *ListRoomsOptions) IsFiltered() bool {
+	return o.NamePrefix != "" || !o.CreatedAfter.IsZero() || !o.CreatedBefore.IsZero() || o.MetadataContains != "" || len(o.LabelSelector) > 0 || o.ScopeToProject || o.PageSize > 0 || o.PageToken != ""
+}
+
+func (o *ListRoomsOptions) Matches(room *livekit.Room, labels RoomLabels) bool {
+	if o.Names != nil && !funk.Contains(o.Names, livekit.RoomName(room.Name)) {
+		return false
+	}
+	if !strings.HasPrefix(room.Name, o.NamePrefix) {
+		return false
+	}
+	if o.ScopeToProject {
+		if project, _ := utils.SplitProjectRoomName(livekit.RoomName(room.Name)); project != o.Project {
+			return false
+		}
+	}
+	if !o.CreatedAfter.IsZero() && room.CreationTime < o.CreatedAfter.Unix() {
+		return false
+	}
+	if !o.CreatedBefore.IsZero() && room.CreationTime >= o.CreatedBefore.Unix() {
+		return false
+	}
+	if !strings.Contains(room.Metadata, o.MetadataContains) {
+		return false
+	}
+	return o.LabelSelector.Matches(labels)
+}
+
+// encodePageToken makes the name of the last room of a page into the token of the next page
+func encodePageToken(lastRoomName string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastRoomName))
+}
+
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	name, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", ErrInvalidPageToken
+	}
+	return string(name), nil
+}
+
+// pageRooms filters and pages rooms in memory, for stores that cannot filter on the server.
+// labels are only needed when the options have a label selector
+func pageRooms(rooms []*livekit.Room, labels map[livekit.RoomName]RoomLabels, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	after, err := decodePageToken(opts.PageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	matched := make([]*livekit.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if room.Name > after && opts.Matches(room, labels[livekit.RoomName(room.Name)]) {
+			matched = append(matched, room)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Name < matched[j].Name
+	})
+
+	if opts.PageSize <= 0 || len(matched) <= opts.PageSize {
+		return matched, "", nil
+	}
+	matched = matched[:opts.PageSize]
+	return matched, encodePageToken(matched[len(matched)-1].Name), nil
+}
+
+type requestHeaderKey struct{}
+
+// withRequestHeaders makes request headers available to twirp handlers, for options that requests do not carry
+func withRequestHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestHeaderKey{}, r.Header)))
+	})
+}
+
+func lookupRequestHeader(ctx context.Context, name string) (string, bool) {
+	header, _ := ctx.Value(requestHeaderKey{}).(http.Header)
+	values := header.Values(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+func listRoomsOptionsFromRequest(ctx context.Context, req *livekit.ListRoomsRequest) (ListRoomsOptions, error) {
+	opts := ListRoomsOptions{
+		NamePrefix:       getRequestOption(ctx, namePrefixOption),
+		MetadataContains: getRequestOption(ctx, metadataContainsOption),
+		PageToken:        getRequestOption(ctx, pageTokenOption),
+	}
+	selector, err := ParseLabelSelector(getRequestOption(ctx, labelSelectorOption))
+	if err != nil {
+		return opts, err
+	}
+	opts.LabelSelector = selector
+	if project, ok := GetProject(ctx); ok {
+		if strings.Contains(opts.NamePrefix, utils.ProjectSeparator) {
+			return opts, ErrInvalidListOptions
+		}
+		opts.Project = project
+		opts.ScopeToProject = true
+		opts.NamePrefix = string(utils.ProjectRoomName(project, livekit.RoomName(opts.NamePrefix)))
+	}
+	if len(req.Names) > 0 {
+		opts.Names = livekit.StringsAsIDs[livekit.RoomName](req.Names)
+	}
+
+	if v := getRequestOption(ctx, pageSizeOption); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			return opts, ErrInvalidListOptions
+		}
+		opts.PageSize = size
+	}
+	if opts.PageSize > maxListRoomsPageSize {
+		opts.PageSize = maxListRoomsPageSize
+	}
+
+	for opt, t := range map[*requestOption]*time.Time{
+		&createdAfterOption:  &opts.CreatedAfter,
+		&createdBeforeOption: &opts.CreatedBefore,
+	} {
+		if v := getRequestOption(ctx, *opt); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return opts, ErrInvalidListOptions
+			}
+			*t = time.Unix(unix, 0)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestListRoomsPage(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewLocalStore()
+	for i, name := range []string{"b-room", "a-room", "a-other", "c-room"} {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{
+			Name:         name,
+			CreationTime: int64(1000 + i),
+			Metadata:     "meta-" + name,
+		}, nil))
+	}
+
+	names := func(rooms []*livekit.Room) []string {
+		var res []string
+		for _, room := range rooms {
+			res = append(res, room.Name)
+		}
+		return res
+	}
+
+	t.Run("pages in name order", func(t *testing.T) {
+		rooms, token, err := store.ListRoomsPage(ctx, service.ListRoomsOptions{PageSize: 3})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a-other", "a-room", "b-room"}, names(rooms))
+		require.NotEmpty(t, token)
+
+		rooms, token, err = store.ListRoomsPage(ctx, service.ListRoomsOptions{PageSize: 3, PageToken: token})
+		require.NoError(t, err)
+		require.Equal(t, []string{"c-room"}, names(rooms))
+		require.Empty(t, token)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		rooms, _, err := store.ListRoomsPage(ctx, service.ListRoomsOptions{NamePrefix: "a-"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a-other", "a-room"}, names(rooms))
+
+		rooms, _, err = store.ListRoomsPage(ctx, service.ListRoomsOptions{
+			CreatedAfter:  time.Unix(1001, 0),
+			CreatedBefore: time.Unix(1003, 0),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a-other", "a-room"}, names(rooms))
+
+		rooms, _, err = store.ListRoomsPage(ctx, service.ListRoomsOptions{MetadataContains: "c-ro"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"c-room"}, names(rooms))
+
+		rooms, _, err = store.ListRoomsPage(ctx, service.ListRoomsOptions{
+			Names:      []livekit.RoomName{"a-room", "b-room"},
+			NamePrefix: "b",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b-room"}, names(rooms))
+	})
+
+	t.Run("invalid page token", func(t *testing.T) {
+		_, _, err := store.ListRoomsPage(ctx, service.ListRoomsOptions{PageToken: "!"})
+		require.ErrorIs(t, err, service.ErrInvalidPageToken)
+	})
+}
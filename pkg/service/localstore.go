@@ -29,8 +29,20 @@ type LocalStore struct {
 	// map of roomName => room
 	rooms        map[livekit.RoomName]*livekit.Room
 	roomInternal map[livekit.RoomName]*livekit.RoomInternal
+	roomLabels   map[livekit.RoomName]RoomLabels
+	roomVersions map[livekit.RoomName]int64
 	// map of roomName => { identity: participant }
 	participants map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
+	// ended rooms, in the order they were stored
+	history   []*RoomHistory
+	templates map[string]*RoomTemplate
+	// map of idempotency key => record
+	idempotency map[string]*localIdempotencyRecord
+	// revoked token IDs and identities, until the revocations expire
+	revokedTokens     map[string]time.Time
+	revokedIdentities map[string]*localIdentityRevocation
+	// members counted against the quotas of keys => their room
+	keyUsage map[localKeyUsage]map[string]livekit.RoomName
 
 	lock       sync.RWMutex
 	globalLock sync.Mutex
@@ -38,10 +50,17 @@ type LocalStore struct {
 
 func NewLocalStore() *LocalStore {
 	return &LocalStore{
-		rooms:        make(map[livekit.RoomName]*livekit.Room),
-		roomInternal: make(map[livekit.RoomName]*livekit.RoomInternal),
-		participants: make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
-		lock:         sync.RWMutex{},
+		rooms:             make(map[livekit.RoomName]*livekit.Room),
+		roomInternal:      make(map[livekit.RoomName]*livekit.RoomInternal),
+		roomLabels:        make(map[livekit.RoomName]RoomLabels),
+		roomVersions:      make(map[livekit.RoomName]int64),
+		participants:      make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		templates:         make(map[string]*RoomTemplate),
+		idempotency:       make(map[string]*localIdempotencyRecord),
+		revokedTokens:     make(map[string]time.Time),
+		revokedIdentities: make(map[string]*localIdentityRevocation),
+		keyUsage:          make(map[localKeyUsage]map[string]livekit.RoomName),
+		lock:              sync.RWMutex{},
 	}
 }
 
@@ -52,6 +71,9 @@ func (s *LocalStore) StoreRoom(_ context.Context, room *livekit.Room, internal *
 	roomName := livekit.RoomName(room.Name)
 
 	s.lock.Lock()
+	if existing := s.rooms[roomName]; existing == nil || existing.Metadata != room.Metadata {
+		s.roomVersions[roomName]++
+	}
 	s.rooms[roomName] = room
 	s.roomInternal[roomName] = internal
 	s.lock.Unlock()
@@ -88,6 +110,70 @@ func (s *LocalStore) ListRooms(_ context.Context, roomNames []livekit.RoomName)
 	return rooms, nil
 }
 
+func (s *LocalStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	rooms, err := s.ListRooms(ctx, opts.Names)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return pageRooms(rooms, s.roomLabels, opts)
+}
+
+func (s *LocalStore) LoadRoomLabels(_ context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.rooms[roomName] == nil {
+		return nil, ErrRoomNotFound
+	}
+	labels := RoomLabels{}
+	for key, value := range s.roomLabels[roomName] {
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+func (s *LocalStore) StoreRoomLabels(_ context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.rooms[roomName] == nil {
+		return ErrRoomNotFound
+	}
+	s.roomLabels[roomName] = labels
+	return nil
+}
+
+func (s *LocalStore) LoadRoomVersion(_ context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	room := s.rooms[roomName]
+	if room == nil {
+		return nil, 0, ErrRoomNotFound
+	}
+	return room, s.roomVersions[roomName], nil
+}
+
+func (s *LocalStore) UpdateRoom(_ context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	roomName := livekit.RoomName(room.Name)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.rooms[roomName] == nil {
+		return 0, ErrRoomNotFound
+	}
+	if s.roomVersions[roomName] != expectedVersion {
+		return 0, ErrRoomVersionConflict
+	}
+	s.rooms[roomName] = room
+	s.roomVersions[roomName]++
+	return s.roomVersions[roomName], nil
+}
+
 func (s *LocalStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
 	room, _, err := s.LoadRoom(ctx, roomName, false)
 	if err == ErrRoomNotFound {
@@ -99,12 +185,246 @@ func (s *LocalStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	s.releaseRoomKeyUsageLocked(livekit.RoomName(room.Name))
 	delete(s.participants, livekit.RoomName(room.Name))
 	delete(s.rooms, livekit.RoomName(room.Name))
 	delete(s.roomInternal, livekit.RoomName(room.Name))
+	delete(s.roomLabels, livekit.RoomName(room.Name))
+	delete(s.roomVersions, livekit.RoomName(room.Name))
+	return nil
+}
+
+func (s *LocalStore) DeleteRooms(_ context.Context, roomNames []livekit.RoomName) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, roomName := range roomNames {
+		s.releaseRoomKeyUsageLocked(roomName)
+		delete(s.participants, roomName)
+		delete(s.rooms, roomName)
+		delete(s.roomInternal, roomName)
+		delete(s.roomLabels, roomName)
+		delete(s.roomVersions, roomName)
+	}
+	return nil
+}
+
+func (s *LocalStore) StoreRoomHistory(_ context.Context, history *RoomHistory, retention time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	cutoff := time.Now().Add(-retention)
+	kept := s.history[:0]
+	for _, h := range s.history {
+		if h.EndedAt.After(cutoff) {
+			kept = append(kept, h)
+		}
+	}
+	s.history = append(kept, history)
+	return nil
+}
+
+func (s *LocalStore) ListRoomHistory(_ context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var history []*RoomHistory
+	for i := len(s.history) - 1; i >= 0 && len(history) < limit; i-- {
+		if roomName == "" || s.history[i].Name == string(roomName) {
+			history = append(history, s.history[i])
+		}
+	}
+	return history, nil
+}
+
+func (s *LocalStore) StoreRoomTemplate(_ context.Context, template *RoomTemplate) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.templates[template.Name] = template.clone()
+	return nil
+}
+
+func (s *LocalStore) LoadRoomTemplate(_ context.Context, name string) (*RoomTemplate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	template := s.templates[name]
+	if template == nil {
+		return nil, ErrRoomTemplateNotFound
+	}
+	return template.clone(), nil
+}
+
+func (s *LocalStore) ListRoomTemplates(_ context.Context) ([]*RoomTemplate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	templates := make([]*RoomTemplate, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, template.clone())
+	}
+	return templates, nil
+}
+
+func (s *LocalStore) DeleteRoomTemplate(_ context.Context, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.templates[name] == nil {
+		return ErrRoomTemplateNotFound
+	}
+	delete(s.templates, name)
+	return nil
+}
+
+type localIdempotencyRecord struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+func (s *LocalStore) ReserveIdempotencyKey(_ context.Context, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for key, r := range s.idempotency {
+		if now.After(r.expiresAt) {
+			delete(s.idempotency, key)
+		}
+	}
+	if r := s.idempotency[record.Key]; r != nil {
+		existing := r.record
+		return &existing, nil
+	}
+	s.idempotency[record.Key] = &localIdempotencyRecord{
+		record:    *record,
+		expiresAt: now.Add(ttl),
+	}
+	return nil, nil
+}
+
+func (s *LocalStore) StoreIdempotencyRecord(_ context.Context, record *IdempotencyRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if r := s.idempotency[record.Key]; r != nil {
+		r.record = *record
+	}
+	return nil
+}
+
+func (s *LocalStore) DeleteIdempotencyRecord(_ context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.idempotency, key)
+	return nil
+}
+
+type localIdentityRevocation struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+func (s *LocalStore) RevokeToken(_ context.Context, tokenID string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeExpiredRevocationsLocked()
+	s.revokedTokens[tokenID] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *LocalStore) RevokeIdentityTokens(_ context.Context, identity string, revokedAt time.Time, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeExpiredRevocationsLocked()
+	s.revokedIdentities[identity] = &localIdentityRevocation{
+		revokedAt: revokedAt,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *LocalStore) IsTokenRevoked(_ context.Context, tokenID string, identity string, issuedAt time.Time) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := time.Now()
+	if expiresAt, ok := s.revokedTokens[tokenID]; ok && tokenID != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+	if r := s.revokedIdentities[identity]; r != nil && now.Before(r.expiresAt) {
+		return !issuedAt.After(r.revokedAt), nil
+	}
+	return false, nil
+}
+
+func (s *LocalStore) removeExpiredRevocationsLocked() {
+	now := time.Now()
+	for tokenID, expiresAt := range s.revokedTokens {
+		if now.After(expiresAt) {
+			delete(s.revokedTokens, tokenID)
+		}
+	}
+	for identity, r := range s.revokedIdentities {
+		if now.After(r.expiresAt) {
+			delete(s.revokedIdentities, identity)
+		}
+	}
+}
+
+type localKeyUsage struct {
+	apiKey string
+	quota  string
+}
+
+func (s *LocalStore) AcquireKeyUsage(_ context.Context, apiKey string, quota string, roomName livekit.RoomName, member string, limit int) (int, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	k := localKeyUsage{apiKey, quota}
+	members := s.keyUsage[k]
+	if _, ok := members[member]; ok {
+		return len(members), true, nil
+	}
+	if len(members) >= limit {
+		return len(members), false, nil
+	}
+	if members == nil {
+		members = make(map[string]livekit.RoomName)
+		s.keyUsage[k] = members
+	}
+	members[member] = roomName
+	return len(members), true, nil
+}
+
+func (s *LocalStore) ReleaseKeyUsage(_ context.Context, apiKey string, quota string, member string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.keyUsage[localKeyUsage{apiKey, quota}], member)
 	return nil
 }
 
+// releaseRoomKeyUsageLocked releases the members of the room from the quotas of the key of the room, as it is deleted
+func (s *LocalStore) releaseRoomKeyUsageLocked(roomName livekit.RoomName) {
+	apiKey, ok := s.roomLabels[roomName][roomAPIKeyLabel]
+	if !ok {
+		return
+	}
+	for _, quota := range keyQuotas {
+		members := s.keyUsage[localKeyUsage{apiKey, quota}]
+		for member, r := range members {
+			if r == roomName {
+				delete(members, member)
+			}
+		}
+	}
+}
+
 func (s *LocalStore) LockRoom(_ context.Context, _ livekit.RoomName, _ time.Duration) (string, error) {
 	// local rooms lock & unlock globally
 	s.globalLock.Lock()
@@ -169,5 +489,8 @@ func (s *LocalStore) DeleteParticipant(_ context.Context, roomName livekit.RoomN
 	if roomParticipants != nil {
 		delete(roomParticipants, identity)
 	}
+	if apiKey, ok := s.roomLabels[roomName][roomAPIKeyLabel]; ok {
+		delete(s.keyUsage[localKeyUsage{apiKey, keyQuotaParticipants}], participantUsageMember(roomName, identity))
+	}
 	return nil
 }
@@ -0,0 +1,91 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestLocalStoreUpdateRoom(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewLocalStore()
+
+	_, err := store.UpdateRoom(ctx, &livekit.Room{Name: "room"}, 0)
+	require.ErrorIs(t, err, service.ErrRoomNotFound)
+
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room"}, nil))
+	_, version, err := store.LoadRoomVersion(ctx, "room")
+	require.NoError(t, err)
+
+	newVersion, err := store.UpdateRoom(ctx, &livekit.Room{Name: "room", Metadata: "first"}, version)
+	require.NoError(t, err)
+	require.Greater(t, newVersion, version)
+
+	// a second writer with the old version does not overwrite the first
+	_, err = store.UpdateRoom(ctx, &livekit.Room{Name: "room", Metadata: "second"}, version)
+	require.ErrorIs(t, err, service.ErrRoomVersionConflict)
+
+	room, loadedVersion, err := store.LoadRoomVersion(ctx, "room")
+	require.NoError(t, err)
+	require.Equal(t, "first", room.Metadata)
+	require.Equal(t, newVersion, loadedVersion)
+
+	// writes of the room that keep its metadata keep its version
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room", Metadata: "first", NumParticipants: 2}, nil))
+	_, loadedVersion, err = store.LoadRoomVersion(ctx, "room")
+	require.NoError(t, err)
+	require.Equal(t, newVersion, loadedVersion)
+
+	// while a change of the metadata by a node changes it
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room", Metadata: "patched"}, nil))
+	_, err = store.UpdateRoom(ctx, &livekit.Room{Name: "room", Metadata: "third"}, newVersion)
+	require.ErrorIs(t, err, service.ErrRoomVersionConflict)
+}
+
+func TestLocalStoreRoomHistory(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewLocalStore()
+	now := time.Now()
+
+	for _, h := range []*service.RoomHistory{
+		{Name: "old", Sid: "RM_old", StartedAt: now.Add(-3 * time.Hour), EndedAt: now.Add(-2 * time.Hour)},
+		{Name: "room", Sid: "RM_1", StartedAt: now.Add(-time.Hour), EndedAt: now.Add(-30 * time.Minute)},
+		{Name: "other", Sid: "RM_2", StartedAt: now.Add(-time.Hour), EndedAt: now.Add(-20 * time.Minute)},
+		{Name: "room", Sid: "RM_3", StartedAt: now.Add(-10 * time.Minute), EndedAt: now, PeakParticipants: 3, EndReason: "EMPTY"},
+	} {
+		require.NoError(t, store.StoreRoomHistory(ctx, h, time.Hour))
+	}
+
+	// latest first, without rooms past retention
+	history, err := store.ListRoomHistory(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, "RM_3", history[0].Sid)
+	require.Equal(t, 10*time.Minute, history[0].Duration())
+	require.Equal(t, uint32(3), history[0].PeakParticipants)
+
+	history, err = store.ListRoomHistory(ctx, "room", 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "RM_3", history[0].Sid)
+}
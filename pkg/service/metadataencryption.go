@@ -0,0 +1,438 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// encrypted values are stored as prefix, id of the key, and the base64 encoded nonce and ciphertext
+const encryptedMetadataPrefix = "lkenc:v1:"
+
+type metadataCipher struct {
+	keyID string
+	// by key id, including the current key
+	aeads map[string]cipher.AEAD
+}
+
+func newMetadataCipher(conf config.MetadataEncryptionConfig) (*metadataCipher, error) {
+	key, err := loadMetadataKey(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &metadataCipher{aeads: make(map[string]cipher.AEAD)}
+	if c.keyID, err = c.addKey(key); err != nil {
+		return nil, err
+	}
+	for _, encoded := range conf.PreviousKeys {
+		previous, err := decodeMetadataKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = c.addKey(previous); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func loadMetadataKey(conf config.MetadataEncryptionConfig) ([]byte, error) {
+	if conf.Key != "" {
+		return decodeMetadataKey(conf.Key)
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(conf.KMSEncryptedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid kms encrypted key")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if conf.KMSRegion != "" {
+		opts = append(opts, awsconfig.WithRegion(conf.KMSRegion))
+	}
+	awsConf, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load aws config")
+	}
+	res, err := kms.NewFromConfig(awsConf).Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: blob})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt metadata key")
+	}
+	if len(res.Plaintext) != 32 {
+		return nil, errors.New("metadata key must be 32 bytes")
+	}
+	return res.Plaintext, nil
+}
+
+func decodeMetadataKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid metadata key")
+	}
+	if len(key) != 32 {
+		return nil, errors.New("metadata key must be 32 bytes")
+	}
+	return key, nil
+}
+
+func (c *metadataCipher) addKey(key []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	keyID := hex.EncodeToString(sum[:4])
+	c.aeads[keyID] = aead
+	return keyID, nil
+}
+
+func (c *metadataCipher) encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead := c.aeads[c.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedMetadataPrefix + c.keyID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *metadataCipher) decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedMetadataPrefix) {
+		// written before encryption was enabled
+		return value, nil
+	}
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedMetadataPrefix), ":")
+	if !ok {
+		return "", errors.New("invalid encrypted metadata")
+	}
+	aead := c.aeads[keyID]
+	if aead == nil {
+		return "", errors.Errorf("metadata encrypted with unknown key %s", keyID)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted metadata")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "could not decrypt metadata")
+	}
+	return string(plaintext), nil
+}
+
+func (c *metadataCipher) encryptRoom(room *livekit.Room) (*livekit.Room, error) {
+	// rooms are not changed in place, callers keep using them
+	room = proto.Clone(room).(*livekit.Room)
+	var err error
+	room.Metadata, err = c.encrypt(room.Metadata)
+	return room, err
+}
+
+func (c *metadataCipher) decryptRoom(room *livekit.Room) (*livekit.Room, error) {
+	// stores may return the rooms they hold
+	room = proto.Clone(room).(*livekit.Room)
+	var err error
+	room.Metadata, err = c.decrypt(room.Metadata)
+	return room, err
+}
+
+func (c *metadataCipher) decryptRooms(rooms []*livekit.Room) ([]*livekit.Room, error) {
+	decrypted := make([]*livekit.Room, 0, len(rooms))
+	for _, room := range rooms {
+		room, err := c.decryptRoom(room)
+		if err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, room)
+	}
+	return decrypted, nil
+}
+
+func (c *metadataCipher) encryptParticipant(participant *livekit.ParticipantInfo) (*livekit.ParticipantInfo, error) {
+	participant = proto.Clone(participant).(*livekit.ParticipantInfo)
+	var err error
+	participant.Metadata, err = c.encrypt(participant.Metadata)
+	return participant, err
+}
+
+func (c *metadataCipher) decryptParticipant(participant *livekit.ParticipantInfo) (*livekit.ParticipantInfo, error) {
+	participant = proto.Clone(participant).(*livekit.ParticipantInfo)
+	var err error
+	participant.Metadata, err = c.decrypt(participant.Metadata)
+	return participant, err
+}
+
+func (c *metadataCipher) decryptSession(session *ParticipantSession) (*ParticipantSession, error) {
+	if session.Info == nil {
+		return session, nil
+	}
+	decrypted := *session
+	var err error
+	decrypted.Info, err = c.decryptParticipant(session.Info)
+	return &decrypted, err
+}
+
+// encryptedStore encrypts metadata of rooms and participants written to the store, and decrypts it when read.
+// rooms cannot be filtered by metadata, the store only holds encrypted metadata
+type encryptedStore struct {
+	ObjectStore
+
+	cipher *metadataCipher
+}
+
+func newEncryptedStore(store ObjectStore, conf config.MetadataEncryptionConfig) (*encryptedStore, error) {
+	c, err := newMetadataCipher(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedStore{
+		ObjectStore: store,
+		cipher:      c,
+	}, nil
+}
+
+// findEncryptedStore returns the encrypted store among the stores wrapped by s, if any
+func findEncryptedStore(s ObjectStore) *encryptedStore {
+	for {
+		switch store := s.(type) {
+		case *encryptedStore:
+			return store
+		case wrappingStore:
+			s = store.unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *encryptedStore) unwrap() ObjectStore {
+	return s.ObjectStore
+}
+
+func (s *encryptedStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	encrypted, err := s.cipher.encryptRoom(room)
+	if err != nil {
+		return err
+	}
+	if err = s.ObjectStore.StoreRoom(ctx, encrypted, internal); err != nil {
+		return err
+	}
+	// stores set the creation time of new rooms
+	room.CreationTime = encrypted.CreationTime
+	return nil
+}
+
+func (s *encryptedStore) DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	return deleteRooms(ctx, s.ObjectStore, roomNames)
+}
+
+func (s *encryptedStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	encrypted, err := s.cipher.encryptParticipant(participant)
+	if err != nil {
+		return err
+	}
+	return s.ObjectStore.StoreParticipant(ctx, roomName, encrypted)
+}
+
+func (s *encryptedStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	room, internal, err := s.ObjectStore.LoadRoom(ctx, roomName, includeInternal)
+	if err != nil {
+		return nil, nil, err
+	}
+	if room, err = s.cipher.decryptRoom(room); err != nil {
+		return nil, nil, err
+	}
+	return room, internal, nil
+}
+
+func (s *encryptedStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	rooms, err := s.ObjectStore.ListRooms(ctx, roomNames)
+	if err != nil {
+		return nil, err
+	}
+	return s.cipher.decryptRooms(rooms)
+}
+
+func (s *encryptedStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	if opts.MetadataContains != "" {
+		return nil, "", ErrInvalidListOptions
+	}
+	rooms, nextPageToken, err := s.ObjectStore.ListRoomsPage(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	if rooms, err = s.cipher.decryptRooms(rooms); err != nil {
+		return nil, "", err
+	}
+	return rooms, nextPageToken, nil
+}
+
+func (s *encryptedStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	room, version, err := s.ObjectStore.LoadRoomVersion(ctx, roomName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if room, err = s.cipher.decryptRoom(room); err != nil {
+		return nil, 0, err
+	}
+	return room, version, nil
+}
+
+func (s *encryptedStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	encrypted, err := s.cipher.encryptRoom(room)
+	if err != nil {
+		return 0, err
+	}
+	return s.ObjectStore.UpdateRoom(ctx, encrypted, expectedVersion)
+}
+
+func (s *encryptedStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	participant, err := s.ObjectStore.LoadParticipant(ctx, roomName, identity)
+	if err != nil {
+		return nil, err
+	}
+	return s.cipher.decryptParticipant(participant)
+}
+
+func (s *encryptedStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	participants, err := s.ObjectStore.ListParticipants(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]*livekit.ParticipantInfo, 0, len(participants))
+	for _, participant := range participants {
+		participant, err := s.cipher.decryptParticipant(participant)
+		if err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, participant)
+	}
+	return decrypted, nil
+}
+
+type encryptedParticipantStore struct {
+	store  ParticipantStore
+	cipher *metadataCipher
+}
+
+// encryptParticipantStore encrypts metadata of sessions kept by ps when s is encrypted
+func encryptParticipantStore(s ObjectStore, ps ParticipantStore) ParticipantStore {
+	if store := findEncryptedStore(s); store != nil {
+		return &encryptedParticipantStore{store: ps, cipher: store.cipher}
+	}
+	return ps
+}
+
+func (s *encryptedParticipantStore) StoreParticipantSession(ctx context.Context, session *ParticipantSession) error {
+	if session.Info != nil {
+		encrypted := *session
+		var err error
+		if encrypted.Info, err = s.cipher.encryptParticipant(session.Info); err != nil {
+			return err
+		}
+		session = &encrypted
+	}
+	return s.store.StoreParticipantSession(ctx, session)
+}
+
+func (s *encryptedParticipantStore) LoadParticipantSession(ctx context.Context, participantID livekit.ParticipantID) (*ParticipantSession, error) {
+	session, err := s.store.LoadParticipantSession(ctx, participantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.cipher.decryptSession(session)
+}
+
+func (s *encryptedParticipantStore) ListParticipantSessions(ctx context.Context, roomName livekit.RoomName, active bool) ([]*ParticipantSession, error) {
+	sessions, err := s.store.ListParticipantSessions(ctx, roomName, active)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]*ParticipantSession, 0, len(sessions))
+	for _, session := range sessions {
+		session, err := s.cipher.decryptSession(session)
+		if err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, session)
+	}
+	return decrypted, nil
+}
+
+type encryptedRoomHistoryStore struct {
+	store  RoomHistoryStore
+	cipher *metadataCipher
+}
+
+// encryptRoomHistoryStore encrypts metadata of rooms kept in history by hs when s is encrypted
+func encryptRoomHistoryStore(s ObjectStore, hs RoomHistoryStore) RoomHistoryStore {
+	if store := findEncryptedStore(s); store != nil {
+		return &encryptedRoomHistoryStore{store: hs, cipher: store.cipher}
+	}
+	return hs
+}
+
+func (s *encryptedRoomHistoryStore) StoreRoomHistory(ctx context.Context, history *RoomHistory, retention time.Duration) error {
+	encrypted := *history
+	var err error
+	if encrypted.Metadata, err = s.cipher.encrypt(history.Metadata); err != nil {
+		return err
+	}
+	return s.store.StoreRoomHistory(ctx, &encrypted, retention)
+}
+
+func (s *encryptedRoomHistoryStore) ListRoomHistory(ctx context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error) {
+	history, err := s.store.ListRoomHistory(ctx, roomName, limit)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]*RoomHistory, 0, len(history))
+	for _, h := range history {
+		c := *h
+		if c.Metadata, err = s.cipher.decrypt(h.Metadata); err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, &c)
+	}
+	return decrypted, nil
+}
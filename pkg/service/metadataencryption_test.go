@@ -0,0 +1,120 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func testMetadataKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestMetadataCipher(t *testing.T) {
+	previous, err := newMetadataCipher(config.MetadataEncryptionConfig{Key: testMetadataKey(1)})
+	require.NoError(t, err)
+	c, err := newMetadataCipher(config.MetadataEncryptionConfig{
+		Key:          testMetadataKey(2),
+		PreviousKeys: []string{testMetadataKey(1)},
+	})
+	require.NoError(t, err)
+
+	encrypted, err := c.encrypt("email=alice@example.com")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encrypted, encryptedMetadataPrefix+c.keyID+":"))
+	require.NotContains(t, encrypted, "alice")
+
+	decrypted, err := c.decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "email=alice@example.com", decrypted)
+
+	// metadata encrypted with a previous key, or written before encryption, is still read
+	old, err := previous.encrypt("old")
+	require.NoError(t, err)
+	decrypted, err = c.decrypt(old)
+	require.NoError(t, err)
+	require.Equal(t, "old", decrypted)
+	decrypted, err = c.decrypt("plain")
+	require.NoError(t, err)
+	require.Equal(t, "plain", decrypted)
+
+	// but not with keys that were dropped
+	_, err = previous.decrypt(encrypted)
+	require.Error(t, err)
+
+	_, err = newMetadataCipher(config.MetadataEncryptionConfig{Key: base64.StdEncoding.EncodeToString([]byte("short"))})
+	require.Error(t, err)
+}
+
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+	backend := NewLocalStore()
+	store, err := newEncryptedStore(backend, config.MetadataEncryptionConfig{Key: testMetadataKey(1)})
+	require.NoError(t, err)
+
+	room := &livekit.Room{Name: "room", Metadata: "secret"}
+	require.NoError(t, store.StoreRoom(ctx, room, nil))
+	require.Equal(t, "secret", room.Metadata)
+	require.NoError(t, store.StoreParticipant(ctx, "room", &livekit.ParticipantInfo{Identity: "alice", Metadata: "pii"}))
+
+	stored, _, err := backend.LoadRoom(ctx, "room", false)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(stored.Metadata, encryptedMetadataPrefix))
+	participant, err := backend.LoadParticipant(ctx, "room", "alice")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(participant.Metadata, encryptedMetadataPrefix))
+
+	loaded, _, err := store.LoadRoom(ctx, "room", false)
+	require.NoError(t, err)
+	require.Equal(t, "secret", loaded.Metadata)
+	participants, err := store.ListParticipants(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+	require.Equal(t, "pii", participants[0].Metadata)
+
+	_, version, err := store.LoadRoomVersion(ctx, "room")
+	require.NoError(t, err)
+	loaded.Metadata = "updated"
+	_, err = store.UpdateRoom(ctx, loaded, version)
+	require.NoError(t, err)
+	rooms, err := store.ListRooms(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	require.Equal(t, "updated", rooms[0].Metadata)
+
+	// the store only holds encrypted metadata
+	_, _, err = store.ListRoomsPage(ctx, ListRoomsOptions{MetadataContains: "upd"})
+	require.ErrorIs(t, err, ErrInvalidListOptions)
+
+	history := encryptRoomHistoryStore(store, backend)
+	require.NoError(t, history.StoreRoomHistory(ctx, &RoomHistory{Name: "room", Metadata: "updated"}, time.Hour))
+	entries, err := history.ListRoomHistory(ctx, "room", 1)
+	require.NoError(t, err)
+	require.Equal(t, "updated", entries[0].Metadata)
+	entries, err = backend.ListRoomHistory(ctx, "room", 1)
+	require.NoError(t, err)
+	require.NotEqual(t, "updated", entries[0].Metadata)
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// UpdateRoomMetadata requests with the option set to merge apply their metadata as a JSON merge patch (RFC 7386)
+	// to the metadata of the room, instead of replacing it
+	roomMetadataPatchHeader = "X-Livekit-Metadata-Patch"
+	roomMetadataPatchMerge  = "merge"
+
+	// the command patching the metadata of a room, its payload is the patch
+	patchRoomMetadataCommand routing.RoomCommandType = "patch-room-metadata"
+)
+
+var errInvalidMetadataPatch = errors.New("metadata patch must be a JSON object")
+
+// mergeRoomMetadataPatch returns the metadata with the merge patch applied. metadata that isn't a JSON object is
+// replaced, as by RFC 7386. patches are idempotent, applying a patch to its result doesn't change it
+func mergeRoomMetadataPatch(metadata string, patch string) (string, error) {
+	var p map[string]interface{}
+	if err := json.Unmarshal([]byte(patch), &p); err != nil || p == nil {
+		return "", errInvalidMetadataPatch
+	}
+
+	var target map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &target); err != nil {
+		target = nil
+	}
+
+	patched, err := json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return "", err
+	}
+	return string(patched), nil
+}
+
+func mergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{}, len(patch))
+	}
+	for key, value := range patch {
+		switch v := value.(type) {
+		case nil:
+			delete(target, key)
+		case map[string]interface{}:
+			t, _ := target[key].(map[string]interface{})
+			target[key] = mergePatch(t, v)
+		default:
+			target[key] = v
+		}
+	}
+	return target
+}
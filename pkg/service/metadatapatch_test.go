@@ -0,0 +1,56 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRoomMetadataPatch(t *testing.T) {
+	t.Run("merges fields", func(t *testing.T) {
+		patched, err := mergeRoomMetadataPatch(
+			`{"title":"standup","stage":{"speaker":"alice","muted":false},"topic":"planning"}`,
+			`{"stage":{"speaker":"bob"},"topic":null,"poll":{"open":true}}`,
+		)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"title":"standup","stage":{"speaker":"bob","muted":false},"poll":{"open":true}}`, patched)
+	})
+
+	t.Run("is idempotent", func(t *testing.T) {
+		patch := `{"stage":{"speaker":"bob"},"topic":null}`
+		patched, err := mergeRoomMetadataPatch(`{"stage":{"speaker":"alice"},"topic":"planning"}`, patch)
+		require.NoError(t, err)
+		again, err := mergeRoomMetadataPatch(patched, patch)
+		require.NoError(t, err)
+		require.Equal(t, patched, again)
+	})
+
+	t.Run("replaces metadata that isn't an object", func(t *testing.T) {
+		for _, metadata := range []string{"", "plain text", `["a"]`} {
+			patched, err := mergeRoomMetadataPatch(metadata, `{"title":"standup","topic":null}`)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"title":"standup"}`, patched)
+		}
+	})
+
+	t.Run("rejects patches that aren't objects", func(t *testing.T) {
+		for _, patch := range []string{"", "null", `"title"`, `["a"]`, `{"title":`} {
+			_, err := mergeRoomMetadataPatch(`{"title":"standup"}`, patch)
+			require.ErrorIs(t, err, errInvalidMetadataPatch)
+		}
+	})
+}
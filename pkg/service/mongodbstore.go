@@ -0,0 +1,517 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+)
+
+const (
+	mongoRoomsCollection        = "rooms"
+	mongoParticipantsCollection = "participants"
+	mongoLocksCollection        = "room_locks"
+)
+
+type mongoRoom struct {
+	Name     string     `bson:"_id"`
+	Data     []byte     `bson:"data"`
+	Internal []byte     `bson:"internal,omitempty"`
+	Labels   RoomLabels `bson:"labels,omitempty"`
+	// metadata of the room at its version
+	Metadata string `bson:"metadata"`
+	Version  int64  `bson:"version"`
+}
+
+type mongoParticipantID struct {
+	Room     string `bson:"room"`
+	Identity string `bson:"identity"`
+}
+
+type mongoParticipant struct {
+	ID   mongoParticipantID `bson:"_id"`
+	Data []byte             `bson:"data"`
+}
+
+type mongoRoomEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		Name string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *mongoRoom `bson:"fullDocument"`
+}
+
+// MongoDBStore persists rooms and participants in MongoDB. Rooms and participants expire through
+// TTL indexes when ItemTTL is set, and room changes of all nodes are followed with a change stream.
+type MongoDBStore struct {
+	client       *mongo.Client
+	rooms        *mongo.Collection
+	participants *mongo.Collection
+	locks        *mongo.Collection
+	ttl          time.Duration
+}
+
+func NewMongoDBStore(conf config.MongoDBConfig) (*MongoDBStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(conf.URI))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to mongodb")
+	}
+	if err = client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, errors.Wrap(err, "could not ping mongodb")
+	}
+
+	db := client.Database(conf.Database)
+	s := &MongoDBStore{
+		client:       client,
+		rooms:        db.Collection(mongoRoomsCollection),
+		participants: db.Collection(mongoParticipantsCollection),
+		locks:        db.Collection(mongoLocksCollection),
+		ttl:          conf.ItemTTL,
+	}
+	if err = s.createIndexes(ctx); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MongoDBStore) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.client.Disconnect(ctx)
+}
+
+// createIndexes adds TTL indexes removing documents once expires_at has passed,
+// documents without expires_at do not expire
+func (s *MongoDBStore) createIndexes(ctx context.Context) error {
+	ttlIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	for _, coll := range []*mongo.Collection{s.rooms, s.participants, s.locks} {
+		if _, err := coll.Indexes().CreateOne(ctx, ttlIndex); err != nil {
+			return errors.Wrap(err, "could not create mongodb ttl index")
+		}
+	}
+	if _, err := s.participants.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "_id.room", Value: 1}},
+	}); err != nil {
+		return errors.Wrap(err, "could not create mongodb participant index")
+	}
+	return nil
+}
+
+// expiry sets expires_at on documents written now when ItemTTL is set, and removes it otherwise
+func (s *MongoDBStore) expiry(set bson.M, unset bson.M) {
+	if s.ttl > 0 {
+		set["expires_at"] = time.Now().Add(s.ttl)
+	} else {
+		unset["expires_at"] = ""
+	}
+}
+
+func mongoUpdate(set bson.M, unset bson.M, inc bson.M) bson.M {
+	update := bson.M{"$set": set}
+	if len(unset) != 0 {
+		update["$unset"] = unset
+	}
+	if len(inc) != 0 {
+		update["$inc"] = inc
+	}
+	return update
+}
+
+func (s *MongoDBStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"data": roomData}
+	unset := bson.M{}
+	if internal != nil {
+		internalData, err := proto.Marshal(internal)
+		if err != nil {
+			return err
+		}
+		set["internal"] = internalData
+	} else {
+		unset["internal"] = ""
+	}
+	s.expiry(set, unset)
+
+	// labels are kept, the version is only incremented when the metadata changes. the update is a pipeline for it
+	// to compare the metadata stored, values are literals as strings starting with $ would be field paths
+	metadata := bson.M{"$literal": room.Metadata}
+	set["metadata"] = metadata
+	set["version"] = bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$metadata", metadata}},
+		"$version",
+		bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", int64(0)}}, int64(1)}},
+	}}
+	pipeline := bson.A{bson.M{"$set": set}}
+	if len(unset) != 0 {
+		fields := make(bson.A, 0, len(unset))
+		for field := range unset {
+			fields = append(fields, field)
+		}
+		pipeline = append(pipeline, bson.M{"$unset": fields})
+	}
+	_, err = s.rooms.UpdateOne(ctx, bson.M{"_id": room.Name}, pipeline, options.Update().SetUpsert(true))
+	if err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *MongoDBStore) loadRoom(ctx context.Context, roomName livekit.RoomName) (*mongoRoom, error) {
+	var doc mongoRoom
+	err := s.rooms.FindOne(ctx, bson.M{"_id": string(roomName)}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (s *MongoDBStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	doc, err := s.loadRoom(ctx, roomName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(doc.Data, room); err != nil {
+		return nil, nil, err
+	}
+
+	var internal *livekit.RoomInternal
+	if includeInternal && doc.Internal != nil {
+		internal = &livekit.RoomInternal{}
+		if err = proto.Unmarshal(doc.Internal, internal); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return room, internal, nil
+}
+
+func (s *MongoDBStore) findRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*mongoRoom, error) {
+	filter := bson.M{}
+	if roomNames != nil {
+		filter["_id"] = bson.M{"$in": livekit.IDsAsStrings(roomNames)}
+	}
+	cursor, err := s.rooms.Find(ctx, filter, options.Find().SetProjection(bson.M{"internal": 0}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get rooms")
+	}
+
+	var docs []*mongoRoom
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "could not get rooms")
+	}
+	return docs, nil
+}
+
+func (s *MongoDBStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	docs, err := s.findRooms(ctx, roomNames)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make([]*livekit.Room, 0, len(docs))
+	for _, doc := range docs {
+		room := &livekit.Room{}
+		if err = proto.Unmarshal(doc.Data, room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+func (s *MongoDBStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	docs, err := s.findRooms(ctx, opts.Names)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rooms := make([]*livekit.Room, 0, len(docs))
+	labels := make(map[livekit.RoomName]RoomLabels, len(docs))
+	for _, doc := range docs {
+		room := &livekit.Room{}
+		if err = proto.Unmarshal(doc.Data, room); err != nil {
+			return nil, "", err
+		}
+		rooms = append(rooms, room)
+		labels[livekit.RoomName(doc.Name)] = doc.Labels
+	}
+	return pageRooms(rooms, labels, opts)
+}
+
+func (s *MongoDBStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	doc, err := s.loadRoom(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Labels == nil {
+		return RoomLabels{}, nil
+	}
+	return doc.Labels, nil
+}
+
+func (s *MongoDBStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	res, err := s.rooms.UpdateOne(ctx, bson.M{"_id": string(roomName)}, bson.M{"$set": bson.M{"labels": labels}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrRoomNotFound
+	}
+	return nil
+}
+
+func (s *MongoDBStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	doc, err := s.loadRoom(ctx, roomName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(doc.Data, room); err != nil {
+		return nil, 0, err
+	}
+	return room, doc.Version, nil
+}
+
+func (s *MongoDBStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return 0, err
+	}
+
+	set := bson.M{"data": data, "metadata": room.Metadata}
+	unset := bson.M{}
+	s.expiry(set, unset)
+
+	res, err := s.rooms.UpdateOne(ctx,
+		bson.M{"_id": room.Name, "version": expectedVersion},
+		mongoUpdate(set, unset, bson.M{"version": int64(1)}),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not update room")
+	}
+	if res.MatchedCount == 0 {
+		count, err := s.rooms.CountDocuments(ctx, bson.M{"_id": room.Name})
+		if err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			return 0, ErrRoomNotFound
+		}
+		return 0, ErrRoomVersionConflict
+	}
+	return expectedVersion + 1, nil
+}
+
+// DeleteRoom removes the room with its participants
+func (s *MongoDBStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	if _, err := s.participants.DeleteMany(ctx, bson.M{"_id.room": string(roomName)}); err != nil {
+		return err
+	}
+	_, err := s.rooms.DeleteOne(ctx, bson.M{"_id": string(roomName)})
+	return err
+}
+
+func (s *MongoDBStore) LockRoom(ctx context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+
+	startTime := time.Now()
+	for {
+		// takes over a lock past its expiry, the TTL monitor removes expired locks only once a minute.
+		// a held lock does not match, and the upsert fails on the existing _id
+		now := time.Now()
+		_, err := s.locks.UpdateOne(ctx,
+			bson.M{"_id": string(roomName), "expires_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"token": token, "expires_at": now.Add(duration)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			return token, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return "", err
+		}
+
+		// stop waiting past lock duration
+		if time.Since(startTime) > duration {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *MongoDBStore) UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error {
+	res, err := s.locks.DeleteOne(ctx, bson.M{"_id": string(roomName), "token": uid})
+	if err != nil {
+		return err
+	}
+
+	// uid does not match
+	if res.DeletedCount == 0 {
+		return ErrRoomUnlockFailed
+	}
+
+	return nil
+}
+
+func (s *MongoDBStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"data": data}
+	unset := bson.M{}
+	s.expiry(set, unset)
+
+	id := mongoParticipantID{Room: string(roomName), Identity: participant.Identity}
+	_, err = s.participants.UpdateOne(ctx, bson.M{"_id": id}, mongoUpdate(set, unset, nil), options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *MongoDBStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	var doc mongoParticipant
+	id := mongoParticipantID{Room: string(roomName), Identity: string(identity)}
+	err := s.participants.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrParticipantNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	pi := livekit.ParticipantInfo{}
+	if err = proto.Unmarshal(doc.Data, &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+func (s *MongoDBStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	cursor, err := s.participants.Find(ctx, bson.M{"_id.room": string(roomName)})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*mongoParticipant
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	participants := make([]*livekit.ParticipantInfo, 0, len(docs))
+	for _, doc := range docs {
+		pi := livekit.ParticipantInfo{}
+		if err = proto.Unmarshal(doc.Data, &pi); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &pi)
+	}
+	return participants, nil
+}
+
+func (s *MongoDBStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	id := mongoParticipantID{Room: string(roomName), Identity: string(identity)}
+	_, err := s.participants.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// WatchRooms notifies of room changes made by any node, including rooms removed by the TTL index.
+// Change streams require a replica set or sharded cluster. The channel is closed when the context is done.
+func (s *MongoDBStore) WatchRooms(ctx context.Context) <-chan RoomUpdate {
+	updates := make(chan RoomUpdate, 100)
+
+	go func() {
+		defer close(updates)
+
+		stream, err := s.rooms.Watch(ctx, mongo.Pipeline{}, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+		if err != nil {
+			logger.Warnw("mongodb room watch failed", err)
+			return
+		}
+		defer func() {
+			_ = stream.Close(context.Background())
+		}()
+
+		for stream.Next(ctx) {
+			var ev mongoRoomEvent
+			if err = stream.Decode(&ev); err != nil {
+				logger.Warnw("could not decode room change", err)
+				continue
+			}
+
+			update := RoomUpdate{Name: livekit.RoomName(ev.DocumentKey.Name)}
+			switch ev.OperationType {
+			case "insert", "update", "replace":
+				// the room may have been deleted by the time the update is looked up
+				if ev.FullDocument == nil {
+					continue
+				}
+				room := &livekit.Room{}
+				if err = proto.Unmarshal(ev.FullDocument.Data, room); err != nil {
+					logger.Warnw("could not unmarshal room", err, "room", update.Name)
+					continue
+				}
+				update.Room = room
+			case "delete":
+			default:
+				continue
+			}
+
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err = stream.Err(); err != nil && ctx.Err() == nil {
+			logger.Warnw("mongodb room watch failed", err)
+		}
+	}()
+
+	return updates
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+// change streams require a replica set, i.e. mongod --replSet rs0
+func TestMongoDBStoreWatchRooms(t *testing.T) {
+	uri := os.Getenv("LIVEKIT_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("LIVEKIT_TEST_MONGODB_URI is not set")
+	}
+
+	s, err := service.NewMongoDBStore(config.MongoDBConfig{
+		URI:      uri,
+		Database: "livekit-test",
+		ItemTTL:  time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := s.WatchRooms(ctx)
+	// the change stream is opened asynchronously
+	time.Sleep(time.Second)
+
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_mongo", Name: "mongo-room"}, nil))
+	room, version, err := s.LoadRoomVersion(ctx, "mongo-room")
+	require.NoError(t, err)
+	require.Equal(t, "RM_mongo", room.Sid)
+
+	_, err = s.UpdateRoom(ctx, &livekit.Room{Sid: "RM_mongo", Name: "mongo-room", Metadata: "first"}, version)
+	require.NoError(t, err)
+	_, err = s.UpdateRoom(ctx, &livekit.Room{Sid: "RM_mongo", Name: "mongo-room", Metadata: "second"}, version)
+	require.ErrorIs(t, err, service.ErrRoomVersionConflict)
+
+	require.NoError(t, s.StoreParticipant(ctx, "mongo-room", &livekit.ParticipantInfo{Sid: "PA_mongo", Identity: "user"}))
+	participants, err := s.ListParticipants(ctx, "mongo-room")
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+
+	token, err := s.LockRoom(ctx, "mongo-room", 5*time.Second)
+	require.NoError(t, err)
+	_, err = s.LockRoom(ctx, "mongo-room", 100*time.Millisecond)
+	require.ErrorIs(t, err, service.ErrRoomLockFailed)
+	require.NoError(t, s.UnlockRoom(ctx, "mongo-room", token))
+
+	require.NoError(t, s.DeleteRoom(ctx, "mongo-room"))
+	_, err = s.LoadParticipant(ctx, "mongo-room", "user")
+	require.ErrorIs(t, err, service.ErrParticipantNotFound)
+
+	// updates are looked up when read, earlier events may carry later states of the room
+	for deleted := false; !deleted; {
+		select {
+		case update := <-updates:
+			require.Equal(t, livekit.RoomName("mongo-room"), update.Name)
+			deleted = update.Room == nil
+		case <-time.After(5 * time.Second):
+			t.Fatal("room deletion not received")
+		}
+	}
+}
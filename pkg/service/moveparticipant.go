@@ -0,0 +1,178 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// the command moving a participant, its payload is the destination room
+	moveParticipantCommand routing.RoomCommandType = "move-participant"
+
+	maxMoveParticipantRequestSize = 64 * 1024
+)
+
+// ParticipantMoveService moves connected participants between rooms at /move_participant, such as into and out of
+// breakout rooms. the participant keeps its connection, identity and published tracks, its subscriptions are moved
+// to the tracks of the destination room.
+// POST {"room": "", "identity": "", "destination_room": ""} moves the participant and responds with it.
+// both rooms have to be hosted by the same node, a destination room that isn't hosted yet is started on the node of
+// the source room
+type ParticipantMoveService struct {
+	roomService *RoomService
+	router      routing.Router
+	store       ObjectStore
+}
+
+func NewParticipantMoveService(roomService *RoomService, router routing.Router, store ObjectStore) *ParticipantMoveService {
+	return &ParticipantMoveService{
+		roomService: roomService,
+		router:      router,
+		store:       store,
+	}
+}
+
+// MoveParticipant moves the participant from room to destination room, and waits until it has joined it
+func (s *ParticipantMoveService) MoveParticipant(ctx context.Context, room string, identity string, destinationRoom string) (*livekit.ParticipantInfo, error) {
+	AppendLogFields(ctx, "room", room, "participant", identity, "destinationRoom", destinationRoom)
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, err
+	}
+	if identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if room == "" || destinationRoom == "" || room == destinationRoom {
+		return nil, ErrInvalidParticipantMove
+	}
+
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return nil, err
+		}
+		if destinationRoom, err = scopeRoomName(project, destinationRoom); err != nil {
+			return nil, err
+		}
+	}
+	roomName, dstRoomName := livekit.RoomName(room), livekit.RoomName(destinationRoom)
+	participantIdentity := livekit.ParticipantIdentity(identity)
+
+	if _, err := s.store.LoadParticipant(ctx, roomName, participantIdentity); err != nil {
+		return nil, err
+	}
+	if _, _, err := s.store.LoadRoom(ctx, dstRoomName, false); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.LoadParticipant(ctx, dstRoomName, participantIdentity); err == nil {
+		return nil, ErrParticipantExists
+	} else if err != ErrParticipantNotFound {
+		return nil, err
+	}
+
+	unassign, err := s.ensureSameNode(ctx, roomName, dstRoomName)
+	if err != nil {
+		return nil, err
+	}
+	participant, err := s.moveParticipant(ctx, roomName, participantIdentity, dstRoomName)
+	if err != nil {
+		unassign()
+		return nil, err
+	}
+	return participant, nil
+}
+
+func (s *ParticipantMoveService) moveParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, dstRoomName livekit.RoomName) (*livekit.ParticipantInfo, error) {
+	err := s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:     moveParticipantCommand,
+		Room:     roomName,
+		Identity: identity,
+		Payload:  []byte(dstRoomName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var participant *livekit.ParticipantInfo
+	err = s.roomService.confirmExecution(func() error {
+		participant, err = s.store.LoadParticipant(ctx, dstRoomName, identity)
+		if err == ErrParticipantNotFound {
+			return ErrOperationFailed
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return participant, nil
+}
+
+// ensureSameNode checks that both rooms are hosted by the same node, which is the only node that can move
+// participants between them
+func (s *ParticipantMoveService) ensureSameNode(ctx context.Context, roomName livekit.RoomName, dstRoomName livekit.RoomName) (func(), error) {
+	sameNode, unassign, err := hostOnSameNode(ctx, s.router, roomName, dstRoomName)
+	if err != nil {
+		return nil, err
+	}
+	if !sameNode {
+		return nil, ErrMoveAcrossNodes
+	}
+	return unassign, nil
+}
+
+// hostOnSameNode returns whether the destination room is hosted by the node of the room, a destination room that
+// isn't hosted yet is assigned to the node of the room. the returned function undoes that assignment, for callers
+// to release the destination room when the operation on it fails
+func hostOnSameNode(ctx context.Context, router routing.Router, roomName livekit.RoomName, dstRoomName livekit.RoomName) (bool, func(), error) {
+	node, err := router.GetNodeForRoom(ctx, roomName)
+	if err != nil {
+		return false, nil, err
+	}
+
+	dstNode, err := router.GetNodeForRoom(ctx, dstRoomName)
+	if err == routing.ErrNotFound {
+		if err = router.SetNodeForRoom(ctx, dstRoomName, livekit.NodeID(node.Id)); err != nil {
+			return false, nil, err
+		}
+		return true, func() {
+			if err := router.ClearRoomState(ctx, dstRoomName); err != nil {
+				logger.Warnw("could not release room", err, "room", dstRoomName)
+			}
+		}, nil
+	} else if err != nil {
+		return false, nil, err
+	}
+	return dstNode.Id == node.Id, func() {}, nil
+}
+
+type moveParticipantRequest struct {
+	Room            string `json:"room"`
+	Identity        string `json:"identity"`
+	DestinationRoom string `json:"destination_room"`
+}
+
+func (s *ParticipantMoveService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxMoveParticipantRequestSize, func(ctx context.Context, req *moveParticipantRequest) (interface{}, error) {
+			return s.MoveParticipant(ctx, req.Room, req.Identity, req.DestinationRoom)
+		}),
+	}.ServeHTTP(w, r)
+}
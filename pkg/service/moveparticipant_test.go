@@ -0,0 +1,128 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestMoveParticipant(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomCreate: true},
+	})
+
+	store := service.NewLocalStore()
+	for _, name := range []string{"main", "breakout", "remote", "idle"} {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: name}, nil))
+	}
+	require.NoError(t, store.StoreParticipant(ctx, "main", &livekit.ParticipantInfo{Identity: "student", Sid: "PA_student"}))
+
+	nodes := map[livekit.RoomName]*livekit.Node{
+		"main":     {Id: "node-1"},
+		"breakout": {Id: "node-1"},
+		"remote":   {Id: "node-2"},
+	}
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomCalls(func(_ context.Context, roomName livekit.RoomName) (*livekit.Node, error) {
+		if node, ok := nodes[roomName]; ok {
+			return node, nil
+		}
+		return nil, routing.ErrNotFound
+	})
+	// the node hosting the rooms moves the participant
+	router.WriteRoomCommandCalls(func(ctx context.Context, cmd *routing.RoomCommand) error {
+		pi, err := store.LoadParticipant(ctx, cmd.Room, cmd.Identity)
+		if err != nil {
+			return err
+		}
+		if err = store.DeleteParticipant(ctx, cmd.Room, cmd.Identity); err != nil {
+			return err
+		}
+		return store.StoreParticipant(ctx, livekit.RoomName(cmd.Payload), pi)
+	})
+	roomService, err := service.NewRoomService(config.RoomConfig{}, config.APIConfig{ExecutionTimeout: time.Second, CheckInterval: time.Millisecond},
+		router, &servicefakes.FakeRoomAllocator{}, store, nil, nil)
+	require.NoError(t, err)
+	svc := service.NewParticipantMoveService(roomService, router, store)
+
+	t.Run("requires create permission", func(t *testing.T) {
+		_, err := svc.MoveParticipant(context.Background(), "main", "student", "breakout")
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		_, err := svc.MoveParticipant(ctx, "main", "", "breakout")
+		require.ErrorIs(t, err, service.ErrIdentityEmpty)
+		_, err = svc.MoveParticipant(ctx, "main", "student", "main")
+		require.ErrorIs(t, err, service.ErrInvalidParticipantMove)
+		_, err = svc.MoveParticipant(ctx, "main", "teacher", "breakout")
+		require.ErrorIs(t, err, service.ErrParticipantNotFound)
+		_, err = svc.MoveParticipant(ctx, "main", "student", "unknown")
+		require.ErrorIs(t, err, service.ErrRoomNotFound)
+		_, err = svc.MoveParticipant(ctx, "main", "student", "remote")
+		require.ErrorIs(t, err, service.ErrMoveAcrossNodes)
+		require.Zero(t, router.WriteRoomCommandCallCount())
+	})
+
+	t.Run("moves participant", func(t *testing.T) {
+		pi, err := svc.MoveParticipant(ctx, "main", "student", "breakout")
+		require.NoError(t, err)
+		require.Equal(t, "PA_student", pi.Sid)
+
+		_, cmd := router.WriteRoomCommandArgsForCall(0)
+		require.Equal(t, livekit.RoomName("main"), cmd.Room)
+		require.Equal(t, livekit.ParticipantIdentity("student"), cmd.Identity)
+		require.Equal(t, "breakout", string(cmd.Payload))
+		require.NotEmpty(t, cmd.Type)
+
+		_, err = store.LoadParticipant(ctx, "main", "student")
+		require.ErrorIs(t, err, service.ErrParticipantNotFound)
+	})
+
+	t.Run("rooms that are not hosted are started on the node of the participant", func(t *testing.T) {
+		_, err := svc.MoveParticipant(ctx, "breakout", "student", "idle")
+		require.NoError(t, err)
+		require.Equal(t, 1, router.SetNodeForRoomCallCount())
+		_, roomName, nodeID := router.SetNodeForRoomArgsForCall(0)
+		require.Equal(t, livekit.RoomName("idle"), roomName)
+		require.Equal(t, livekit.NodeID("node-1"), nodeID)
+		require.Zero(t, router.ClearRoomStateCallCount())
+	})
+
+	t.Run("rooms that were started for a failed move are released", func(t *testing.T) {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "unused"}, nil))
+		require.NoError(t, store.StoreParticipant(ctx, "main", &livekit.ParticipantInfo{Identity: "teacher", Sid: "PA_teacher"}))
+		router.WriteRoomCommandReturns(routing.ErrChannelFull)
+
+		_, err := svc.MoveParticipant(ctx, "main", "teacher", "unused")
+		require.ErrorIs(t, err, routing.ErrChannelFull)
+		require.Equal(t, 1, router.ClearRoomStateCallCount())
+		_, roomName := router.ClearRoomStateArgsForCall(0)
+		require.Equal(t, livekit.RoomName("unused"), roomName)
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	// the command muting a room, its payload is a roomMuteRule
+	muteRoomCommand routing.RoomCommandType = "mute-room"
+
+	maxMuteRoomRequestSize = 64 * 1024
+)
+
+type roomMuteRule struct {
+	Sources    []string `json:"sources,omitempty"`
+	Kinds      []string `json:"kinds,omitempty"`
+	Except     []string `json:"except,omitempty"`
+	MuteFuture bool     `json:"mute_future,omitempty"`
+	// stops muting tracks published from now on
+	Clear bool `json:"clear,omitempty"`
+}
+
+func (m *roomMuteRule) muteRule() (*rtc.MuteRule, error) {
+	if len(m.Sources) == 0 && len(m.Kinds) == 0 {
+		return nil, ErrInvalidMuteRule
+	}
+
+	rule := &rtc.MuteRule{}
+	for _, s := range m.Sources {
+		source, ok := livekit.TrackSource_value[s]
+		if !ok {
+			return nil, ErrInvalidMuteRule
+		}
+		rule.Sources = append(rule.Sources, livekit.TrackSource(source))
+	}
+	for _, k := range m.Kinds {
+		kind, ok := livekit.TrackType_value[k]
+		if !ok {
+			return nil, ErrInvalidMuteRule
+		}
+		rule.Kinds = append(rule.Kinds, livekit.TrackType(kind))
+	}
+	for _, identity := range m.Except {
+		rule.Except = append(rule.Except, livekit.ParticipantIdentity(identity))
+	}
+	return rule, nil
+}
+
+// RoomMuteService mutes the publishers of a room at /mute_room, such as a webinar host muting the audience.
+// publishers are asked to mute their tracks, the same as when a single track is muted through the room service.
+// POST {"room": "", "sources": ["MICROPHONE"], "kinds": ["AUDIO"], "except": [], "mute_future": false} mutes the
+// tracks of any of the sources or kinds, except the tracks of the listed identities. with mute_future, tracks
+// published later on are muted as well.
+// DELETE ?room= stops muting tracks published later on
+type RoomMuteService struct {
+	router routing.MessageRouter
+	store  ObjectStore
+}
+
+func NewRoomMuteService(router routing.MessageRouter, store ObjectStore) *RoomMuteService {
+	return &RoomMuteService{
+		router: router,
+		store:  store,
+	}
+}
+
+// MuteRoom mutes the tracks of the sources and kinds of all participants of the room but those excepted
+func (s *RoomMuteService) MuteRoom(
+	ctx context.Context,
+	room string,
+	sources []livekit.TrackSource,
+	kinds []livekit.TrackType,
+	except []string,
+	muteFuture bool,
+) error {
+	rule := roomMuteRule{Except: except, MuteFuture: muteFuture}
+	for _, source := range sources {
+		rule.Sources = append(rule.Sources, source.String())
+	}
+	for _, kind := range kinds {
+		rule.Kinds = append(rule.Kinds, kind.String())
+	}
+	if _, err := rule.muteRule(); err != nil {
+		return err
+	}
+	return s.writeRule(ctx, room, rule)
+}
+
+// ClearRoomMuteRule stops muting tracks published in the room from now on
+func (s *RoomMuteService) ClearRoomMuteRule(ctx context.Context, room string) error {
+	return s.writeRule(ctx, room, roomMuteRule{Clear: true})
+}
+
+func (s *RoomMuteService) writeRule(ctx context.Context, room string, rule roomMuteRule) error {
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return err
+		}
+	}
+	roomName := livekit.RoomName(room)
+	AppendLogFields(ctx, "room", roomName, "sources", rule.Sources, "kinds", rule.Kinds, "muteFuture", rule.MuteFuture, "clear", rule.Clear)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return err
+	}
+	if _, _, err := s.store.LoadRoom(ctx, roomName, false); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	return s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:    muteRoomCommand,
+		Room:    roomName,
+		Payload: data,
+	})
+}
+
+type muteRoomRequest struct {
+	roomMuteRule
+	Room string `json:"room"`
+}
+
+func (s *RoomMuteService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxMuteRoomRequestSize, func(ctx context.Context, req *muteRoomRequest) (interface{}, error) {
+			req.Clear = false
+			if _, err := req.muteRule(); err != nil {
+				return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+			}
+			return nil, s.writeRule(ctx, req.Room, req.roomMuteRule)
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			return nil, s.ClearRoomMuteRule(r.Context(), r.URL.Query().Get("room"))
+		},
+	}.ServeHTTP(w, r)
+}
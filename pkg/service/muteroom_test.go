@@ -0,0 +1,82 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestMuteRoom(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "webinar"},
+	})
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "webinar"}, nil))
+	router := &routingfakes.FakeRouter{}
+	svc := service.NewRoomMuteService(router, store)
+
+	t.Run("requires admin permission of the room", func(t *testing.T) {
+		err := svc.MuteRoom(ctx, "other", []livekit.TrackSource{livekit.TrackSource_MICROPHONE}, nil, nil, false)
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("requires sources or kinds", func(t *testing.T) {
+		err := svc.MuteRoom(ctx, "webinar", nil, nil, []string{"host"}, true)
+		require.ErrorIs(t, err, service.ErrInvalidMuteRule)
+		require.Zero(t, router.WriteRoomCommandCallCount())
+	})
+
+	t.Run("mutes and clears through the room", func(t *testing.T) {
+		err := svc.MuteRoom(ctx, "webinar", []livekit.TrackSource{livekit.TrackSource_MICROPHONE}, nil, []string{"host"}, true)
+		require.NoError(t, err)
+		require.NoError(t, svc.ClearRoomMuteRule(ctx, "webinar"))
+		require.Equal(t, 2, router.WriteRoomCommandCallCount())
+
+		rules := make([]map[string]interface{}, 0, 2)
+		for i := 0; i < 2; i++ {
+			_, cmd := router.WriteRoomCommandArgsForCall(i)
+			require.Equal(t, livekit.RoomName("webinar"), cmd.Room)
+
+			var rule map[string]interface{}
+			require.NoError(t, json.Unmarshal(cmd.Payload, &rule))
+			rules = append(rules, rule)
+		}
+		require.Equal(t, map[string]interface{}{
+			"sources":     []interface{}{"MICROPHONE"},
+			"except":      []interface{}{"host"},
+			"mute_future": true,
+		}, rules[0])
+		require.Equal(t, map[string]interface{}{"clear": true}, rules[1])
+	})
+
+	t.Run("room must exist", func(t *testing.T) {
+		ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomAdmin: true, Room: "missing"},
+		})
+		err := svc.ClearRoomMuteRule(ctx, "missing")
+		require.ErrorIs(t, err, service.ErrRoomNotFound)
+	})
+}
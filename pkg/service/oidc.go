@@ -0,0 +1,323 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	oidcDiscoveryPath       = "/.well-known/openid-configuration"
+	defaultOIDCKeysCacheTTL = time.Hour
+	oidcFetchTimeout        = 10 * time.Second
+	maxOIDCResponseSize     = 1024 * 1024
+	// JWKS are not fetched again for unknown keys more often than this
+	minOIDCKeysFetchInterval = time.Minute
+	// allowed clock skew between the issuer and the server
+	oidcLeeway = time.Minute
+)
+
+var ErrInvalidOIDCToken = errors.New("invalid OIDC token")
+
+// OIDCVerifier verifies tokens of the OIDC issuers of the config, and maps their claims to join grants. tokens of
+// OIDC issuers can only join rooms, they are not allowed the server APIs
+type OIDCVerifier struct {
+	issuers map[string]*oidcIssuer
+}
+
+type oidcIssuer struct {
+	config.OIDCIssuerConfig
+	client *http.Client
+
+	lock        sync.Mutex
+	jwksURL     string
+	keys        *jose.JSONWebKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewOIDCVerifier returns nil when no issuers are configured
+func NewOIDCVerifier(conf config.OIDCConfig) *OIDCVerifier {
+	if len(conf.Issuers) == 0 {
+		return nil
+	}
+	v := &OIDCVerifier{
+		issuers: make(map[string]*oidcIssuer, len(conf.Issuers)),
+	}
+	for _, issuerConf := range conf.Issuers {
+		if issuerConf.KeysCacheTTL == 0 {
+			issuerConf.KeysCacheTTL = defaultOIDCKeysCacheTTL
+		}
+		v.issuers[issuerConf.Issuer] = &oidcIssuer{
+			OIDCIssuerConfig: issuerConf,
+			client:           &http.Client{Timeout: oidcFetchTimeout},
+			jwksURL:          issuerConf.JWKSURL,
+		}
+	}
+	return v
+}
+
+// IsTrusted returns whether the issuer is an OIDC issuer of the config
+func (v *OIDCVerifier) IsTrusted(issuer string) bool {
+	if v == nil {
+		return false
+	}
+	_, ok := v.issuers[issuer]
+	return ok
+}
+
+// Project returns the project of the rooms tokens of a trusted issuer join
+func (v *OIDCVerifier) Project(issuer string) string {
+	if v == nil || v.issuers[issuer] == nil {
+		return ""
+	}
+	return v.issuers[issuer].Project
+}
+
+// Verify verifies the token of a trusted issuer, and returns the grants of its claims
+func (v *OIDCVerifier) Verify(ctx context.Context, authToken string) (*auth.ClaimGrants, error) {
+	token, err := jwt.ParseSigned(authToken)
+	if err != nil || len(token.Headers) != 1 {
+		return nil, ErrInvalidOIDCToken
+	}
+	var unverified jwt.Claims
+	if err = token.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, ErrInvalidOIDCToken
+	}
+	issuer := v.issuers[unverified.Issuer]
+	if issuer == nil {
+		return nil, ErrInvalidOIDCToken
+	}
+
+	key, err := issuer.key(ctx, token.Headers[0])
+	if err != nil {
+		return nil, err
+	}
+	var registered jwt.Claims
+	claims := make(map[string]interface{})
+	if err = token.Claims(key, &registered, &claims); err != nil {
+		return nil, ErrInvalidOIDCToken
+	}
+	expected := jwt.Expected{
+		Issuer: issuer.Issuer,
+		Time:   time.Now(),
+	}
+	if issuer.Audience != "" {
+		expected.Audience = jwt.Audience{issuer.Audience}
+	}
+	if err = registered.ValidateWithLeeway(expected, oidcLeeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOIDCToken, err)
+	}
+	return issuer.grants(claims)
+}
+
+// key returns the key of the JWKS the token is signed with, the JWKS is fetched when it is not cached or the key is
+// not in it
+func (i *oidcIssuer) key(ctx context.Context, header jose.Header) (*jose.JSONWebKey, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	now := time.Now()
+	stale := i.keys == nil || now.Sub(i.fetchedAt) > i.KeysCacheTTL || findOIDCKey(i.keys, header) == nil
+	if i.keys == nil || (stale && now.Sub(i.attemptedAt) > minOIDCKeysFetchInterval) {
+		i.attemptedAt = now
+		keys, err := i.fetchKeys(ctx)
+		if err != nil {
+			logger.Warnw("could not fetch OIDC keys", err, "issuer", i.Issuer)
+			// keys that were fetched before are used until they can be fetched again
+			if i.keys == nil {
+				return nil, err
+			}
+		} else {
+			i.keys = keys
+			i.fetchedAt = now
+		}
+	}
+
+	key := findOIDCKey(i.keys, header)
+	if key == nil {
+		return nil, fmt.Errorf("%w: unknown key %s", ErrInvalidOIDCToken, header.KeyID)
+	}
+	return key, nil
+}
+
+func findOIDCKey(keys *jose.JSONWebKeySet, header jose.Header) *jose.JSONWebKey {
+	for _, key := range keys.Key(header.KeyID) {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			continue
+		}
+		// symmetric keys are not published by issuers, tokens are never verified with them
+		if _, ok := key.Key.([]byte); ok {
+			continue
+		}
+		return &key
+	}
+	return nil
+}
+
+func (i *oidcIssuer) fetchKeys(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	if i.jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := i.fetchJSON(ctx, strings.TrimSuffix(i.Issuer, "/")+oidcDiscoveryPath, &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("OIDC discovery has no jwks_uri")
+		}
+		i.jwksURL = discovery.JWKSURI
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err := i.fetchJSON(ctx, i.jwksURL, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (i *oidcIssuer) fetchJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", url, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxOIDCResponseSize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// grants maps the claims of a verified token to the participant and its grants
+func (i *oidcIssuer) grants(claims map[string]interface{}) (*auth.ClaimGrants, error) {
+	identityClaim := i.Claims.Identity
+	if identityClaim == "" {
+		identityClaim = "sub"
+	}
+	identity, _ := oidcClaim(claims, identityClaim).(string)
+	if identity == "" {
+		return nil, fmt.Errorf("%w: missing identity claim %s", ErrInvalidOIDCToken, identityClaim)
+	}
+	nameClaim := i.Claims.Name
+	if nameClaim == "" {
+		nameClaim = "name"
+	}
+	name, _ := oidcClaim(claims, nameClaim).(string)
+
+	video := &auth.VideoGrant{RoomJoin: true}
+	if i.Claims.Room != "" {
+		room, _ := oidcClaim(claims, i.Claims.Room).(string)
+		if room == "" {
+			return nil, fmt.Errorf("%w: missing room claim %s", ErrInvalidOIDCToken, i.Claims.Room)
+		}
+		video.Room = room
+	}
+
+	grant := i.Grant
+	for _, role := range oidcRoles(oidcClaim(claims, i.Claims.Roles)) {
+		if roleGrant, ok := i.Roles[role]; ok {
+			grant.CanPublish = grant.CanPublish || roleGrant.CanPublish
+			grant.CanSubscribe = grant.CanSubscribe || roleGrant.CanSubscribe
+			grant.CanPublishData = grant.CanPublishData || roleGrant.CanPublishData
+			grant.Hidden = grant.Hidden || roleGrant.Hidden
+		}
+	}
+	video.SetCanPublish(grant.CanPublish)
+	video.SetCanSubscribe(grant.CanSubscribe)
+	video.SetCanPublishData(grant.CanPublishData)
+	video.Hidden = grant.Hidden
+
+	grants := &auth.ClaimGrants{
+		Identity: identity,
+		Name:     name,
+		Video:    video,
+	}
+	if i.Claims.Metadata != "" {
+		switch metadata := oidcClaim(claims, i.Claims.Metadata).(type) {
+		case nil:
+		case string:
+			grants.Metadata = metadata
+		default:
+			// objects are passed on as JSON
+			b, err := json.Marshal(metadata)
+			if err != nil {
+				return nil, err
+			}
+			grants.Metadata = string(b)
+		}
+	}
+	return grants, nil
+}
+
+// oidcClaim returns the claim of the path, nil when the token doesn't have it
+func oidcClaim(claims map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// oidcRoles returns the roles of a list of strings or a space separated string claim
+func oidcRoles(claim interface{}) []string {
+	switch roles := claim.(type) {
+	case string:
+		return strings.Fields(roles)
+	case []interface{}:
+		var names []string
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth/authfakes"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestOIDCVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"}}}
+
+	var issuerURL string
+	jwksFetches := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuerURL, "jwks_uri": issuerURL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwksFetches++
+		_ = json.NewEncoder(w).Encode(jwks)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	issuerURL = server.URL
+
+	v := NewOIDCVerifier(config.OIDCConfig{
+		Issuers: []config.OIDCIssuerConfig{{
+			Issuer:   issuerURL,
+			Audience: "livekit",
+			Claims: config.OIDCClaimsConfig{
+				Room:     "livekit_room",
+				Roles:    "realm_access.roles",
+				Metadata: "profile",
+			},
+			Grant: config.OIDCGrantConfig{CanSubscribe: true},
+			Roles: map[string]config.OIDCGrantConfig{
+				"presenter": {CanPublish: true, CanPublishData: true},
+			},
+			Project: "customer",
+		}},
+	})
+	sign := func(kid string, claims map[string]interface{}) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", kid))
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+	claims := func(roles ...string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":          issuerURL,
+			"aud":          "livekit",
+			"sub":          "alice",
+			"name":         "Alice",
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"livekit_room": "stage",
+			"realm_access": map[string]interface{}{"roles": roles},
+			"profile":      map[string]interface{}{"team": "blue"},
+		}
+	}
+
+	require.True(t, v.IsTrusted(issuerURL))
+	require.False(t, v.IsTrusted("APIkey"))
+
+	t.Run("claims are mapped to grants", func(t *testing.T) {
+		grants, err := v.Verify(context.Background(), sign("key1", claims()))
+		require.NoError(t, err)
+		require.Equal(t, "alice", grants.Identity)
+		require.Equal(t, "Alice", grants.Name)
+		require.Equal(t, `{"team":"blue"}`, grants.Metadata)
+		require.True(t, grants.Video.RoomJoin)
+		require.Equal(t, "stage", grants.Video.Room)
+		require.True(t, grants.Video.GetCanSubscribe())
+		require.False(t, grants.Video.GetCanPublish())
+		require.False(t, grants.Video.RoomCreate)
+
+		grants, err = v.Verify(context.Background(), sign("key1", claims("presenter", "other")))
+		require.NoError(t, err)
+		require.True(t, grants.Video.GetCanPublish())
+		require.True(t, grants.Video.GetCanPublishData())
+		require.Equal(t, 1, jwksFetches)
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		wrongAudience := claims()
+		wrongAudience["aud"] = "other"
+		expired := claims()
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+		noRoom := claims()
+		delete(noRoom, "livekit_room")
+		for _, c := range []map[string]interface{}{wrongAudience, expired, noRoom} {
+			_, err := v.Verify(context.Background(), sign("key1", c))
+			require.ErrorIs(t, err, ErrInvalidOIDCToken)
+		}
+
+		// tokens signed with other keys
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: other}, (&jose.SignerOptions{}).WithHeader("kid", "key1"))
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(claims()).CompactSerialize()
+		require.NoError(t, err)
+		_, err = v.Verify(context.Background(), token)
+		require.ErrorIs(t, err, ErrInvalidOIDCToken)
+
+		// unknown keys fetch the JWKS again at most once a minute
+		_, err = v.Verify(context.Background(), sign("key2", claims()))
+		require.ErrorIs(t, err, ErrInvalidOIDCToken)
+		require.Equal(t, 1, jwksFetches)
+	})
+
+	t.Run("authenticated by the auth middleware", func(t *testing.T) {
+		m := NewAPIKeyAuthMiddleware(&authfakes.FakeKeyProvider{}, nil, nil, nil, v, nil, nil)
+		ctx, err := m.authenticate(context.Background(), sign("key1", claims()))
+		require.NoError(t, err)
+		require.Equal(t, "alice", GetGrants(ctx).Identity)
+		_, ok := GetAPIKey(ctx)
+		require.False(t, ok)
+		_, ok = GetProject(ctx)
+		require.False(t, ok)
+	})
+
+	t.Run("scoped to the project of the issuer", func(t *testing.T) {
+		m := NewAPIKeyAuthMiddleware(&authfakes.FakeKeyProvider{}, map[string]string{"APIcustomer": "customer"}, nil, nil, v, nil, nil)
+		c := claims()
+		c["room_passcode"] = "1234"
+		ctx, err := m.authenticate(context.Background(), sign("key1", c))
+		require.NoError(t, err)
+		require.Equal(t, "customer|stage", GetGrants(ctx).Video.Room)
+		project, _ := GetProject(ctx)
+		require.Equal(t, "customer", project)
+		require.Equal(t, "1234", tokenRoomPasscode(ctx))
+
+		// rooms of other projects cannot be named
+		c = claims()
+		c["livekit_room"] = "other|stage"
+		_, err = m.authenticate(context.Background(), sign("key1", c))
+		require.ErrorIs(t, err, ErrInvalidProjectRoomName)
+	})
+}
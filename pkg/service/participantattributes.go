@@ -0,0 +1,86 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// sets attributes of the participant on UpdateParticipant requests, a JSON object of string values. attributes
+	// not in the object are kept, those with empty values are removed
+	participantAttributesHeader = "X-Livekit-Participant-Attributes"
+
+	// the command updating attributes, its payload is the JSON object of the option
+	updateParticipantAttributesCommand routing.RoomCommandType = "update-participant-attributes"
+)
+
+type tokenAttributesKey struct{}
+
+// participantAttributes returns the attributes claim of the token, nil when it has none
+func (c *tokenClaims) participantAttributes() map[string]string {
+	if len(c.Attributes) == 0 {
+		return nil
+	}
+	return c.Attributes
+}
+
+func withTokenAttributes(ctx context.Context, attributes map[string]string) context.Context {
+	return context.WithValue(ctx, tokenAttributesKey{}, attributes)
+}
+
+// tokenAttributes returns the attributes claim of the token of the request
+func tokenAttributes(ctx context.Context) map[string]string {
+	attributes, _ := ctx.Value(tokenAttributesKey{}).(map[string]string)
+	return attributes
+}
+
+// participantAttributesFromRequest returns the attributes set by the request, or nil when the request leaves them
+// unchanged
+func participantAttributesFromRequest(ctx context.Context) (map[string]string, string, error) {
+	value, ok := lookupRequestOption(ctx, participantAttributesOption)
+	if !ok {
+		return nil, "", nil
+	}
+	var attributes map[string]string
+	if err := json.Unmarshal([]byte(value), &attributes); err != nil || len(attributes) == 0 {
+		return nil, "", ErrInvalidAttributes
+	}
+	for key := range attributes {
+		if key == "" {
+			return nil, "", ErrInvalidAttributes
+		}
+	}
+	return attributes, value, nil
+}
+
+// updateParticipantAttributes has the node hosting the participant apply the attributes
+func (s *RoomService) updateParticipantAttributes(ctx context.Context, room livekit.RoomName, identity livekit.ParticipantIdentity, attributes string) error {
+	if err := EnsureAdminPermission(ctx, room); err != nil {
+		return twirpAuthError(err)
+	}
+
+	return s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:     updateParticipantAttributesCommand,
+		Room:     room,
+		Identity: identity,
+		Payload:  []byte(attributes),
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParticipantAttributesOfToken(t *testing.T) {
+	require.Equal(t,
+		map[string]string{"role": "speaker"},
+		testTokenClaims(t, `{"sub":"alice","attributes":{"role":"speaker"}}`).participantAttributes(),
+	)
+	require.Nil(t, testTokenClaims(t, `{"sub":"alice"}`).participantAttributes())
+	require.Nil(t, testTokenClaims(t, `{"sub":"alice","attributes":{}}`).participantAttributes())
+}
+
+func TestParticipantAttributesFromRequest(t *testing.T) {
+	ctx := func(value string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{participantAttributesHeader: []string{value}})
+	}
+
+	attributes, _, err := participantAttributesFromRequest(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, attributes)
+
+	attributes, raw, err := participantAttributesFromRequest(ctx(`{"hand":"raised","role":""}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"hand": "raised", "role": ""}, attributes)
+	require.Equal(t, `{"hand":"raised","role":""}`, raw)
+
+	for _, value := range []string{`{}`, `{"":"value"}`, `{"hand":true}`, `raised`} {
+		_, _, err = participantAttributesFromRequest(ctx(value))
+		require.ErrorIs(t, err, ErrInvalidAttributes, value)
+	}
+}
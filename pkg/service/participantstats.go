@@ -0,0 +1,371 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// nodes receive stats requests for the participants they host on this channel suffixed with their node id, replies
+// are published to a channel of the requester
+const ParticipantStatsChannel = "participant_stats"
+
+// ParticipantStats is a snapshot of the RTC statistics of a connected participant
+type ParticipantStats struct {
+	Identity          string                 `json:"identity"`
+	Sid               string                 `json:"sid"`
+	NodeID            string                 `json:"node_id"`
+	TakenAt           time.Time              `json:"taken_at"`
+	ICEConnectionType string                 `json:"ice_connection_type"`
+	ICECandidatePair  *ICECandidatePairStats `json:"ice_candidate_pair,omitempty"`
+	Published         []*TrackStats          `json:"published"`
+	Subscribed        []*TrackStats          `json:"subscribed"`
+	Traffic           *TrafficStats          `json:"traffic,omitempty"`
+}
+
+// TrafficStats is the traffic of the participant on its node since it joined, including data channels. the publisher
+// leg is received from the participant, the subscriber leg is sent to the participant
+type TrafficStats struct {
+	PublisherBytes    uint64 `json:"publisher_bytes"`
+	PublisherPackets  uint64 `json:"publisher_packets"`
+	SubscriberBytes   uint64 `json:"subscriber_bytes"`
+	SubscriberPackets uint64 `json:"subscriber_packets"`
+}
+
+// ICECandidatePairStats is the candidate pair selected for the primary transport of the participant
+type ICECandidatePairStats struct {
+	Local  string `json:"local"`
+	Remote string `json:"remote"`
+}
+
+// TrackStats are the statistics of a published track per codec, or of a subscribed track. bitrate is in bits per
+// second, jitter in microseconds and RTT in milliseconds
+type TrackStats struct {
+	TrackSid             string  `json:"track_sid"`
+	Kind                 string  `json:"kind"`
+	Source               string  `json:"source"`
+	MimeType             string  `json:"mime_type"`
+	Bitrate              float64 `json:"bitrate"`
+	Packets              uint32  `json:"packets"`
+	PacketsLost          uint32  `json:"packets_lost"`
+	PacketLossPercentage float32 `json:"packet_loss_percentage"`
+	Jitter               float64 `json:"jitter"`
+	RTT                  uint32  `json:"rtt"`
+	ConnectionQuality    string  `json:"connection_quality"`
+	// spatial layers currently received of a published video track
+	AvailableLayers []int32 `json:"available_layers,omitempty"`
+	// layers currently forwarded and targeted of a subscribed video track
+	CurrentLayer *VideoLayerStats `json:"current_layer,omitempty"`
+	TargetLayer  *VideoLayerStats `json:"target_layer,omitempty"`
+}
+
+type VideoLayerStats struct {
+	Spatial  int32 `json:"spatial"`
+	Temporal int32 `json:"temporal"`
+}
+
+type participantStatsRequest struct {
+	Room         string `json:"room"`
+	Identity     string `json:"identity"`
+	ReplyChannel string `json:"reply_channel"`
+}
+
+type participantStatsReply struct {
+	// nil when the participant is not connected to the node
+	Stats *ParticipantStats `json:"stats,omitempty"`
+}
+
+// ParticipantStatsService responds with a snapshot of the RTC statistics and traffic of a connected participant at
+// /participant_stats, such as for support engineers debugging call quality or for usage based billing.
+// GET ?room=&identity= responds with the stats, taken on the node hosting the room. rooms hosted by other nodes
+// are asked over redis
+type ParticipantStatsService struct {
+	timeout     time.Duration
+	router      routing.Router
+	roomManager *RoomManager
+	currentNode routing.LocalNode
+	telemetry   telemetry.TelemetryService
+	rc          redis.UniversalClient
+}
+
+func NewParticipantStatsService(
+	apiConf config.APIConfig,
+	router routing.Router,
+	roomManager *RoomManager,
+	currentNode routing.LocalNode,
+	telemetry telemetry.TelemetryService,
+	rc redis.UniversalClient,
+) *ParticipantStatsService {
+	s := &ParticipantStatsService{
+		timeout:     apiConf.ExecutionTimeout,
+		router:      router,
+		roomManager: roomManager,
+		currentNode: currentNode,
+		telemetry:   telemetry,
+		rc:          rc,
+	}
+	if rc != nil {
+		go s.requestWorker(rc.Subscribe(context.Background(), participantStatsRequestChannel(livekit.NodeID(currentNode.Id))))
+	}
+	return s
+}
+
+// GetParticipantStats returns the current stats of the participant from the node hosting its room
+func (s *ParticipantStatsService) GetParticipantStats(ctx context.Context, room string, identity string) (*ParticipantStats, error) {
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return nil, err
+		}
+	}
+	roomName, participantIdentity := livekit.RoomName(room), livekit.ParticipantIdentity(identity)
+	AppendLogFields(ctx, "room", roomName, "participant", identity)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, err
+	}
+	if identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+
+	node, err := s.router.GetNodeForRoom(ctx, roomName)
+	if err == routing.ErrNotFound {
+		return nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var stats *ParticipantStats
+	if node.Id == s.currentNode.Id {
+		stats = s.localStats(ctx, roomName, participantIdentity)
+	} else if stats, err = s.remoteStats(ctx, livekit.NodeID(node.Id), roomName, participantIdentity); err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return nil, ErrParticipantNotFound
+	}
+	return stats, nil
+}
+
+// remoteStats asks the node hosting the room for the stats, waiting for its reply on a channel of this request
+func (s *ParticipantStatsService) remoteStats(ctx context.Context, nodeID livekit.NodeID, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*ParticipantStats, error) {
+	if s.rc == nil {
+		return nil, ErrStatsUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req := participantStatsRequest{
+		Room:         string(roomName),
+		Identity:     string(identity),
+		ReplyChannel: ParticipantStatsChannel + ":" + utils.NewGuid("PS_"),
+	}
+	pubsub := s.rc.Subscribe(ctx, req.ReplyChannel)
+	defer pubsub.Close()
+	// the reply would be missed if the subscription wasn't active yet when it's published
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.rc.Publish(ctx, participantStatsRequestChannel(nodeID), data).Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg, ok := <-pubsub.Channel():
+		if !ok {
+			return nil, ErrStatsUnavailable
+		}
+		var reply participantStatsReply
+		if err = json.Unmarshal([]byte(msg.Payload), &reply); err != nil {
+			return nil, err
+		}
+		return reply.Stats, nil
+	case <-ctx.Done():
+		return nil, ErrStatsUnavailable
+	}
+}
+
+func (s *ParticipantStatsService) requestWorker(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		if msg == nil {
+			return
+		}
+		var req participantStatsRequest
+		if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil {
+			logger.Warnw("could not decode participant stats request", err)
+			continue
+		}
+
+		ctx := context.Background()
+		data, err := json.Marshal(participantStatsReply{
+			Stats: s.localStats(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)),
+		})
+		if err != nil {
+			logger.Warnw("could not encode participant stats", err, "room", req.Room, "participant", req.Identity)
+			continue
+		}
+		if err = s.rc.Publish(ctx, req.ReplyChannel, data).Err(); err != nil {
+			logger.Warnw("could not reply participant stats", err, "room", req.Room, "participant", req.Identity)
+		}
+	}
+}
+
+// localStats returns the stats of a participant of a room hosted by this node, nil when it is not connected
+func (s *ParticipantStatsService) localStats(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) *ParticipantStats {
+	room := s.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil || participant.IsClosed() {
+		return nil
+	}
+	stats := newParticipantStats(participant, livekit.NodeID(s.currentNode.Id))
+	if s.telemetry != nil {
+		if traffic, ok := s.telemetry.GetParticipantTraffic(participant.ID()); ok {
+			stats.Traffic = &TrafficStats{
+				PublisherBytes:    traffic.PublisherBytes,
+				PublisherPackets:  traffic.PublisherPackets,
+				SubscriberBytes:   traffic.SubscriberBytes,
+				SubscriberPackets: traffic.SubscriberPackets,
+			}
+		}
+	}
+	return stats
+}
+
+func newParticipantStats(participant types.LocalParticipant, nodeID livekit.NodeID) *ParticipantStats {
+	stats := &ParticipantStats{
+		Identity:          string(participant.Identity()),
+		Sid:               string(participant.ID()),
+		NodeID:            string(nodeID),
+		TakenAt:           time.Now(),
+		ICEConnectionType: string(participant.GetICEConnectionType()),
+		Published:         []*TrackStats{},
+		Subscribed:        []*TrackStats{},
+	}
+	if pair := participant.GetSelectedICECandidatePair(); pair != nil && pair.Local != nil && pair.Remote != nil {
+		stats.ICECandidatePair = &ICECandidatePairStats{
+			Local:  pair.Local.String(),
+			Remote: pair.Remote.String(),
+		}
+	}
+
+	for _, track := range participant.GetPublishedTracks() {
+		for _, receiver := range track.Receivers() {
+			if dr, ok := receiver.(*rtc.DummyReceiver); ok {
+				if receiver = dr.Receiver(); receiver == nil {
+					continue
+				}
+			}
+			ts := &TrackStats{
+				TrackSid: string(track.ID()),
+				Kind:     track.Kind().String(),
+				Source:   track.Source().String(),
+				MimeType: receiver.Codec().MimeType,
+			}
+			if sr, ok := receiver.(interface{ GetTrackStats() *livekit.RTPStats }); ok {
+				ts.setRTPStats(sr.GetTrackStats())
+			}
+			if lt, ok := track.(types.LocalMediaTrack); ok {
+				_, quality := lt.GetConnectionScoreAndQuality()
+				ts.ConnectionQuality = quality.String()
+			}
+			if track.Kind() == livekit.TrackType_VIDEO {
+				ts.AvailableLayers, _ = receiver.GetLayeredBitrate()
+			}
+			stats.Published = append(stats.Published, ts)
+		}
+	}
+
+	for _, subTrack := range participant.GetSubscribedTracks() {
+		dt := subTrack.DownTrack()
+		if dt == nil {
+			continue
+		}
+		track := subTrack.MediaTrack()
+		ts := &TrackStats{
+			TrackSid: string(subTrack.ID()),
+			Kind:     track.Kind().String(),
+			Source:   track.Source().String(),
+			MimeType: dt.Codec().MimeType,
+		}
+		ts.setRTPStats(dt.GetTrackStats())
+		_, quality := dt.GetConnectionScoreAndQuality()
+		ts.ConnectionQuality = quality.String()
+		if track.Kind() == livekit.TrackType_VIDEO {
+			ts.CurrentLayer = newVideoLayerStats(dt.CurrentLayer())
+			ts.TargetLayer = newVideoLayerStats(dt.TargetLayer())
+		}
+		stats.Subscribed = append(stats.Subscribed, ts)
+	}
+	return stats
+}
+
+func (ts *TrackStats) setRTPStats(stats *livekit.RTPStats) {
+	if stats == nil {
+		return
+	}
+	ts.Bitrate = stats.Bitrate
+	ts.Packets = stats.Packets
+	ts.PacketsLost = stats.PacketsLost
+	ts.PacketLossPercentage = stats.PacketLossPercentage
+	ts.Jitter = stats.JitterCurrent
+	ts.RTT = stats.RttCurrent
+}
+
+func newVideoLayerStats(layer buffer.VideoLayer) *VideoLayerStats {
+	if !layer.IsValid() {
+		return nil
+	}
+	return &VideoLayerStats{
+		Spatial:  layer.Spatial,
+		Temporal: layer.Temporal,
+	}
+}
+
+func participantStatsRequestChannel(nodeID livekit.NodeID) string {
+	return ParticipantStatsChannel + ":" + string(nodeID)
+}
+
+func (s *ParticipantStatsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			return s.GetParticipantStats(r.Context(), query.Get("room"), query.Get("identity"))
+		},
+	}.ServeHTTP(w, r)
+}
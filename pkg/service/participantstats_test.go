@@ -0,0 +1,62 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestGetParticipantStats(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "support"},
+	})
+
+	router := &routingfakes.FakeRouter{}
+	currentNode := routing.LocalNode(&livekit.Node{Id: "ND_local"})
+	svc := service.NewParticipantStatsService(config.DefaultAPIConfig(), router, nil, currentNode, nil, nil)
+
+	t.Run("requires admin permission of the room", func(t *testing.T) {
+		_, err := svc.GetParticipantStats(ctx, "other", "caller")
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("requires identity", func(t *testing.T) {
+		_, err := svc.GetParticipantStats(ctx, "support", "")
+		require.ErrorIs(t, err, service.ErrIdentityEmpty)
+	})
+
+	t.Run("room is not hosted", func(t *testing.T) {
+		router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+		_, err := svc.GetParticipantStats(ctx, "support", "caller")
+		require.ErrorIs(t, err, service.ErrRoomNotFound)
+	})
+
+	t.Run("rooms of other nodes require redis", func(t *testing.T) {
+		router.GetNodeForRoomReturns(&livekit.Node{Id: "ND_remote"}, nil)
+		_, err := svc.GetParticipantStats(ctx, "support", "caller")
+		require.ErrorIs(t, err, service.ErrStatsUnavailable)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// sessions are kept for inspection this long after the participant has left
+const participantSessionRetention = 24 * time.Hour
+
+// ParticipantSession is the record of a participant's stay in a room
+type ParticipantSession struct {
+	RoomName      livekit.RoomName
+	RoomID        livekit.RoomID
+	ParticipantID livekit.ParticipantID
+	Identity      livekit.ParticipantIdentity
+	// node hosting the room while the participant was in it
+	NodeID   livekit.NodeID
+	JoinedAt time.Time
+	// zero while the participant is in the room
+	LeftAt time.Time
+	// last known state of the participant
+	Info *livekit.ParticipantInfo
+}
+
+func (s *ParticipantSession) IsActive() bool {
+	return s.LeftAt.IsZero()
+}
+
+type participantSessionJSON struct {
+	RoomName      string    `json:"room_name"`
+	RoomID        string    `json:"room_id"`
+	ParticipantID string    `json:"participant_id"`
+	Identity      string    `json:"identity"`
+	NodeID        string    `json:"node_id"`
+	JoinedAt      time.Time `json:"joined_at"`
+	LeftAt        time.Time `json:"left_at"`
+	Info          []byte    `json:"info,omitempty"`
+}
+
+func (s *ParticipantSession) MarshalJSON() ([]byte, error) {
+	var info []byte
+	if s.Info != nil {
+		var err error
+		if info, err = proto.Marshal(s.Info); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&participantSessionJSON{
+		RoomName:      string(s.RoomName),
+		RoomID:        string(s.RoomID),
+		ParticipantID: string(s.ParticipantID),
+		Identity:      string(s.Identity),
+		NodeID:        string(s.NodeID),
+		JoinedAt:      s.JoinedAt,
+		LeftAt:        s.LeftAt,
+		Info:          info,
+	})
+}
+
+func (s *ParticipantSession) UnmarshalJSON(data []byte) error {
+	var v participantSessionJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*s = ParticipantSession{
+		RoomName:      livekit.RoomName(v.RoomName),
+		RoomID:        livekit.RoomID(v.RoomID),
+		ParticipantID: livekit.ParticipantID(v.ParticipantID),
+		Identity:      livekit.ParticipantIdentity(v.Identity),
+		NodeID:        livekit.NodeID(v.NodeID),
+		JoinedAt:      v.JoinedAt,
+		LeftAt:        v.LeftAt,
+	}
+	if v.Info != nil {
+		s.Info = &livekit.ParticipantInfo{}
+		if err := proto.Unmarshal(v.Info, s.Info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRedisParticipantStore(t *testing.T) {
+	testParticipantStore(t, service.NewRedisStore(redisClient(), config.RedisConfig{}))
+}
+
+func TestPostgresParticipantStore(t *testing.T) {
+	testParticipantStore(t, postgresStore(t))
+}
+
+func testParticipantStore(t *testing.T, s service.ParticipantStore) {
+	ctx := context.Background()
+	roomName := livekit.RoomName(utils.NewGuid("room_"))
+	joinedAt := time.Now().Truncate(time.Second)
+
+	alice := &service.ParticipantSession{
+		RoomName:      roomName,
+		RoomID:        "RM_sessions",
+		ParticipantID: livekit.ParticipantID(utils.NewGuid(utils.ParticipantPrefix)),
+		Identity:      "alice",
+		NodeID:        "ND_sessions",
+		JoinedAt:      joinedAt,
+		Info:          &livekit.ParticipantInfo{Identity: "alice", Metadata: "first"},
+	}
+	bob := &service.ParticipantSession{
+		RoomName:      roomName,
+		RoomID:        "RM_sessions",
+		ParticipantID: livekit.ParticipantID(utils.NewGuid(utils.ParticipantPrefix)),
+		Identity:      "bob",
+		NodeID:        "ND_sessions",
+		JoinedAt:      joinedAt,
+	}
+	require.NoError(t, s.StoreParticipantSession(ctx, alice))
+	require.NoError(t, s.StoreParticipantSession(ctx, bob))
+
+	// last known state replaces the earlier one
+	alice.Info = &livekit.ParticipantInfo{Identity: "alice", Metadata: "second"}
+	require.NoError(t, s.StoreParticipantSession(ctx, alice))
+
+	// bob leaves
+	bob.LeftAt = joinedAt.Add(time.Minute)
+	require.NoError(t, s.StoreParticipantSession(ctx, bob))
+
+	session, err := s.LoadParticipantSession(ctx, alice.ParticipantID)
+	require.NoError(t, err)
+	require.True(t, session.IsActive())
+	require.Equal(t, "second", session.Info.Metadata)
+	require.True(t, joinedAt.Equal(session.JoinedAt))
+
+	session, err = s.LoadParticipantSession(ctx, bob.ParticipantID)
+	require.NoError(t, err)
+	require.False(t, session.IsActive())
+	require.True(t, bob.LeftAt.Equal(session.LeftAt))
+
+	sessions, err := s.ListParticipantSessions(ctx, roomName, false)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	sessions, err = s.ListParticipantSessions(ctx, roomName, true)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, alice.ParticipantID, sessions[0].ParticipantID)
+
+	_, err = s.LoadParticipantSession(ctx, "PA_unknown")
+	require.ErrorIs(t, err, service.ErrParticipantNotFound)
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type participantWriteKey struct {
+	key      string
+	identity livekit.ParticipantIdentity
+}
+
+// participantWriteBatch collects participant writes between flushes. Only the last write of a participant is
+// kept, a participant changing many times during a flush interval is written once
+type participantWriteBatch struct {
+	lock sync.Mutex
+	// serializes flushes, for a participant's writes to reach the store in order
+	flushLock sync.Mutex
+	// marshalled participant by key of the room and identity, nil for deleted participants
+	pending map[participantWriteKey][]byte
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newParticipantWriteBatch() *participantWriteBatch {
+	return &participantWriteBatch{
+		pending: make(map[participantWriteKey][]byte),
+		done:    make(chan struct{}),
+	}
+}
+
+func (b *participantWriteBatch) stop() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+}
+
+func (b *participantWriteBatch) add(key string, identity livekit.ParticipantIdentity, data []byte) {
+	b.lock.Lock()
+	b.pending[participantWriteKey{key: key, identity: identity}] = data
+	b.lock.Unlock()
+}
+
+// flush passes pending writes to write. when it fails, writes that have not been replaced since are kept
+// for the next flush
+func (b *participantWriteBatch) flush(write func(writes map[participantWriteKey][]byte) error) error {
+	b.flushLock.Lock()
+	defer b.flushLock.Unlock()
+
+	b.lock.Lock()
+	writes := b.pending
+	if len(writes) == 0 {
+		b.lock.Unlock()
+		return nil
+	}
+	b.pending = make(map[participantWriteKey][]byte)
+	b.lock.Unlock()
+
+	err := write(writes)
+	if err != nil {
+		b.lock.Lock()
+		for k, data := range writes {
+			if _, ok := b.pending[k]; !ok {
+				b.pending[k] = data
+			}
+		}
+		b.lock.Unlock()
+	}
+	return err
+}
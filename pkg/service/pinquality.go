@@ -0,0 +1,149 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// the command pinning a subscribed quality, its payload is a pinnedQuality
+	pinSubscribedQualityCommand routing.RoomCommandType = "pin-subscribed-quality"
+
+	maxPinSubscribedQualityRequestSize = 64 * 1024
+)
+
+type pinnedQuality struct {
+	TrackSid string `json:"track_sid"`
+	// empty removes the pin
+	Quality string `json:"quality,omitempty"`
+}
+
+// SubscribedQualityService pins the quality a subscriber receives of a video track at /pin_subscribed_quality,
+// overriding both the subscriber's own settings and the stream allocator, such as to keep a stage speaker in high
+// quality for a recorder. a pinned track isn't paused or downgraded when bandwidth is constrained.
+// POST {"room": "", "identity": "", "track_sid": "", "quality": "HIGH"} pins the quality.
+// DELETE ?room=&identity=&track_sid= removes the pin, returning the track to allocation
+type SubscribedQualityService struct {
+	router routing.MessageRouter
+	store  ObjectStore
+}
+
+func NewSubscribedQualityService(router routing.MessageRouter, store ObjectStore) *SubscribedQualityService {
+	return &SubscribedQualityService{
+		router: router,
+		store:  store,
+	}
+}
+
+// PinSubscribedQuality has the participant receive the track in the given quality until unpinned
+func (s *SubscribedQualityService) PinSubscribedQuality(ctx context.Context, room string, identity string, trackSid string, quality livekit.VideoQuality) error {
+	if quality != livekit.VideoQuality_LOW && quality != livekit.VideoQuality_MEDIUM && quality != livekit.VideoQuality_HIGH {
+		return ErrInvalidQualityPin
+	}
+	return s.writePin(ctx, room, identity, pinnedQuality{TrackSid: trackSid, Quality: quality.String()})
+}
+
+// UnpinSubscribedQuality returns the track of the participant to stream allocation
+func (s *SubscribedQualityService) UnpinSubscribedQuality(ctx context.Context, room string, identity string, trackSid string) error {
+	return s.writePin(ctx, room, identity, pinnedQuality{TrackSid: trackSid})
+}
+
+func (s *SubscribedQualityService) writePin(ctx context.Context, room string, identity string, pin pinnedQuality) error {
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return err
+		}
+	}
+	roomName := livekit.RoomName(room)
+	AppendLogFields(ctx, "room", roomName, "participant", identity, "trackID", pin.TrackSid, "quality", pin.Quality)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return err
+	}
+	if identity == "" {
+		return ErrIdentityEmpty
+	}
+	if pin.TrackSid == "" {
+		return ErrInvalidQualityPin
+	}
+
+	if _, err := s.store.LoadParticipant(ctx, roomName, livekit.ParticipantIdentity(identity)); err != nil {
+		return err
+	}
+	if err := s.ensureVideoTrack(ctx, roomName, pin.TrackSid); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(pin)
+	if err != nil {
+		return err
+	}
+	return s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:     pinSubscribedQualityCommand,
+		Room:     roomName,
+		Identity: livekit.ParticipantIdentity(identity),
+		Payload:  data,
+	})
+}
+
+// ensureVideoTrack checks that the track is a video track published in the room
+func (s *SubscribedQualityService) ensureVideoTrack(ctx context.Context, roomName livekit.RoomName, trackSid string) error {
+	participants, err := s.store.ListParticipants(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	for _, pi := range participants {
+		for _, ti := range pi.Tracks {
+			if ti.Sid != trackSid {
+				continue
+			}
+			if ti.Type != livekit.TrackType_VIDEO {
+				return ErrInvalidQualityPin
+			}
+			return nil
+		}
+	}
+	return ErrTrackNotFound
+}
+
+type pinSubscribedQualityRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	TrackSid string `json:"track_sid"`
+	Quality  string `json:"quality"`
+}
+
+func (s *SubscribedQualityService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxPinSubscribedQualityRequestSize, func(ctx context.Context, req *pinSubscribedQualityRequest) (interface{}, error) {
+			quality, ok := livekit.VideoQuality_value[req.Quality]
+			if !ok {
+				return nil, ErrInvalidQualityPin
+			}
+			return nil, s.PinSubscribedQuality(ctx, req.Room, req.Identity, req.TrackSid, livekit.VideoQuality(quality))
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			return nil, s.UnpinSubscribedQuality(r.Context(), query.Get("room"), query.Get("identity"), query.Get("track_sid"))
+		},
+	}.ServeHTTP(w, r)
+}
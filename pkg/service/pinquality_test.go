@@ -0,0 +1,84 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestPinSubscribedQuality(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "stage"},
+	})
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreParticipant(ctx, "stage", &livekit.ParticipantInfo{
+		Identity: "speaker",
+		Sid:      "PA_speaker",
+		Tracks: []*livekit.TrackInfo{
+			{Sid: "TR_camera", Type: livekit.TrackType_VIDEO},
+			{Sid: "TR_microphone", Type: livekit.TrackType_AUDIO},
+		},
+	}))
+	require.NoError(t, store.StoreParticipant(ctx, "stage", &livekit.ParticipantInfo{Identity: "recorder", Sid: "PA_recorder"}))
+	router := &routingfakes.FakeRouter{}
+	svc := service.NewSubscribedQualityService(router, store)
+
+	t.Run("requires admin permission of the room", func(t *testing.T) {
+		err := svc.PinSubscribedQuality(ctx, "other", "recorder", "TR_camera", livekit.VideoQuality_HIGH)
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("rejects invalid pins", func(t *testing.T) {
+		err := svc.PinSubscribedQuality(ctx, "stage", "recorder", "TR_camera", livekit.VideoQuality_OFF)
+		require.ErrorIs(t, err, service.ErrInvalidQualityPin)
+		err = svc.PinSubscribedQuality(ctx, "stage", "recorder", "TR_microphone", livekit.VideoQuality_HIGH)
+		require.ErrorIs(t, err, service.ErrInvalidQualityPin)
+		err = svc.PinSubscribedQuality(ctx, "stage", "recorder", "TR_missing", livekit.VideoQuality_HIGH)
+		require.ErrorIs(t, err, service.ErrTrackNotFound)
+		err = svc.PinSubscribedQuality(ctx, "stage", "missing", "TR_camera", livekit.VideoQuality_HIGH)
+		require.ErrorIs(t, err, service.ErrParticipantNotFound)
+		require.Equal(t, 0, router.WriteRoomCommandCallCount())
+	})
+
+	t.Run("pins and unpins through the participant", func(t *testing.T) {
+		require.NoError(t, svc.PinSubscribedQuality(ctx, "stage", "recorder", "TR_camera", livekit.VideoQuality_HIGH))
+		require.NoError(t, svc.UnpinSubscribedQuality(ctx, "stage", "recorder", "TR_camera"))
+		require.Equal(t, 2, router.WriteRoomCommandCallCount())
+
+		pins := make([]map[string]string, 0, 2)
+		for i := 0; i < 2; i++ {
+			_, cmd := router.WriteRoomCommandArgsForCall(i)
+			require.Equal(t, livekit.RoomName("stage"), cmd.Room)
+			require.Equal(t, livekit.ParticipantIdentity("recorder"), cmd.Identity)
+
+			var pin map[string]string
+			require.NoError(t, json.Unmarshal(cmd.Payload, &pin))
+			pins = append(pins, pin)
+		}
+		require.Equal(t, map[string]string{"track_sid": "TR_camera", "quality": "HIGH"}, pins[0])
+		require.Equal(t, map[string]string{"track_sid": "TR_camera"}, pins[1])
+	})
+}
@@ -0,0 +1,780 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// postgresMigrationLockID serializes migrations of nodes starting at the same time
+const postgresMigrationLockID = 0x6c6b6d6967
+
+// postgresMigrations are applied in order, each once. Append only, never edit an applied migration.
+var postgresMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS rooms (
+		name TEXT PRIMARY KEY,
+		data BYTEA NOT NULL,
+		internal BYTEA
+	)`,
+	`CREATE TABLE IF NOT EXISTS room_participants (
+		room_name TEXT NOT NULL,
+		identity TEXT NOT NULL,
+		data BYTEA NOT NULL,
+		PRIMARY KEY (room_name, identity)
+	)`,
+	`CREATE TABLE IF NOT EXISTS room_locks (
+		name TEXT PRIMARY KEY,
+		token TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+	// copies of room fields for filtering
+	`ALTER TABLE rooms ADD COLUMN IF NOT EXISTS creation_time BIGINT NOT NULL DEFAULT 0`,
+	`ALTER TABLE rooms ADD COLUMN IF NOT EXISTS metadata TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE rooms ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE rooms ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS participant_sessions (
+		participant_id TEXT PRIMARY KEY,
+		room_name TEXT NOT NULL,
+		room_id TEXT NOT NULL,
+		identity TEXT NOT NULL,
+		node_id TEXT NOT NULL,
+		joined_at TIMESTAMPTZ NOT NULL,
+		left_at TIMESTAMPTZ,
+		info BYTEA
+	)`,
+	`CREATE INDEX IF NOT EXISTS participant_sessions_room_name ON participant_sessions (room_name, joined_at)`,
+	`CREATE TABLE IF NOT EXISTS room_history (
+		sid TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		metadata TEXT NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL,
+		ended_at TIMESTAMPTZ NOT NULL,
+		peak_participants INT NOT NULL,
+		end_reason TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS room_history_ended_at ON room_history (ended_at)`,
+	`CREATE INDEX IF NOT EXISTS room_history_name ON room_history (name, ended_at)`,
+	`CREATE TABLE IF NOT EXISTS room_templates (
+		name TEXT PRIMARY KEY,
+		data JSONB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		data JSONB NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+}
+
+// PostgresStore persists rooms and participants in PostgreSQL
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(conf config.PostgresConfig) (*PostgresStore, error) {
+	db, err := sql.Open("pgx", conf.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open postgres")
+	}
+	db.SetMaxOpenConns(conf.MaxOpenConns)
+	db.SetMaxIdleConns(conf.MaxIdleConns)
+	db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "could not connect to postgres")
+	}
+
+	s := &PostgresStore{db: db}
+	if err = s.migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *PostgresStore) migrate(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, postgresMigrationLockID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for i := current; i < len(postgresMigrations); i++ {
+		if _, err = tx.ExecContext(ctx, postgresMigrations[i]); err != nil {
+			return errors.Wrapf(err, "could not apply migration %d", i+1)
+		}
+		if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+
+	var internalData []byte
+	if internal != nil {
+		if internalData, err = proto.Marshal(internal); err != nil {
+			return err
+		}
+	}
+
+	// the version only changes with the metadata, not with writes of the number of participants by nodes
+	_, err = s.db.ExecContext(ctx, `INSERT INTO rooms (name, data, internal, creation_time, metadata, version) VALUES ($1, $2, $3, $4, $5, 1)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data, internal = EXCLUDED.internal,
+			creation_time = EXCLUDED.creation_time, metadata = EXCLUDED.metadata,
+			version = CASE WHEN rooms.metadata IS DISTINCT FROM EXCLUDED.metadata THEN rooms.version + 1 ELSE rooms.version END`,
+		room.Name, roomData, internalData, room.CreationTime, room.Metadata,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *PostgresStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	var roomData, internalData []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data, internal FROM rooms WHERE name = $1`, string(roomName)).Scan(&roomData, &internalData)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(roomData, room); err != nil {
+		return nil, nil, err
+	}
+
+	var internal *livekit.RoomInternal
+	if includeInternal && internalData != nil {
+		internal = &livekit.RoomInternal{}
+		if err = proto.Unmarshal(internalData, internal); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return room, internal, nil
+}
+
+func (s *PostgresStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	var rows *sql.Rows
+	var err error
+	if roomNames == nil {
+		rows, err = s.db.QueryContext(ctx, `SELECT data FROM rooms`)
+	} else {
+		rows, err = s.db.QueryContext(ctx, `SELECT data FROM rooms WHERE name = ANY($1)`, livekit.IDsAsStrings(roomNames))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get rooms")
+	}
+	defer rows.Close()
+
+	rooms := make([]*livekit.Room, 0)
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		room := &livekit.Room{}
+		if err = proto.Unmarshal(data, room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+func (s *PostgresStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	after, err := decodePageToken(opts.PageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := `SELECT data FROM rooms WHERE name > $1`
+	args := []interface{}{after}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if opts.Names != nil {
+		where("name = ANY($%d)", livekit.IDsAsStrings(opts.Names))
+	}
+	if opts.NamePrefix != "" {
+		where("starts_with(name, $%d)", opts.NamePrefix)
+	}
+	if opts.ScopeToProject {
+		if opts.Project == "" {
+			where("strpos(name, $%d) = 0", sutils.ProjectSeparator)
+		} else {
+			where("starts_with(name, $%d)", opts.Project+sutils.ProjectSeparator)
+		}
+	}
+	if !opts.CreatedAfter.IsZero() {
+		where("creation_time >= $%d", opts.CreatedAfter.Unix())
+	}
+	if !opts.CreatedBefore.IsZero() {
+		where("creation_time < $%d", opts.CreatedBefore.Unix())
+	}
+	if opts.MetadataContains != "" {
+		where("strpos(metadata, $%d) > 0", opts.MetadataContains)
+	}
+	for _, req := range opts.LabelSelector {
+		switch req.Operator {
+		case LabelEquals, LabelNotEquals:
+			data, err := RoomLabels{req.Key: req.Value}.Marshal()
+			if err != nil {
+				return nil, "", err
+			}
+			condition := "labels @> $%d::jsonb"
+			if req.Operator == LabelNotEquals {
+				condition = "NOT " + condition
+			}
+			where(condition, string(data))
+		case LabelExists:
+			where("jsonb_exists(labels, $%d)", req.Key)
+		case LabelDoesNotExist:
+			where("NOT jsonb_exists(labels, $%d)", req.Key)
+		}
+	}
+	query += " ORDER BY name"
+	if opts.PageSize > 0 {
+		// one more to know if there is a next page
+		args = append(args, opts.PageSize+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "could not get rooms")
+	}
+	defer rows.Close()
+
+	rooms := make([]*livekit.Room, 0)
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return nil, "", err
+		}
+		room := &livekit.Room{}
+		if err = proto.Unmarshal(data, room); err != nil {
+			return nil, "", err
+		}
+		rooms = append(rooms, room)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if opts.PageSize <= 0 || len(rooms) <= opts.PageSize {
+		return rooms, "", nil
+	}
+	rooms = rooms[:opts.PageSize]
+	return rooms, encodePageToken(rooms[len(rooms)-1].Name), nil
+}
+
+func (s *PostgresStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT labels FROM rooms WHERE name = $1`, string(roomName)).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return UnmarshalRoomLabels(data)
+}
+
+func (s *PostgresStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	data, err := labels.Marshal()
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, `UPDATE rooms SET labels = $2::jsonb WHERE name = $1`, string(roomName), string(data))
+	if err != nil {
+		return errors.Wrap(err, "could not update room labels")
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRoomNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	var data []byte
+	var version int64
+	err := s.db.QueryRowContext(ctx, `SELECT data, version FROM rooms WHERE name = $1`, string(roomName)).Scan(&data, &version)
+	if err == sql.ErrNoRows {
+		return nil, 0, ErrRoomNotFound
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(data, room); err != nil {
+		return nil, 0, err
+	}
+	return room, version, nil
+}
+
+func (s *PostgresStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err = s.db.QueryRowContext(ctx, `UPDATE rooms SET data = $2, creation_time = $3, metadata = $4, version = version + 1
+		WHERE name = $1 AND version = $5 RETURNING version`,
+		room.Name, data, room.CreationTime, room.Metadata, expectedVersion,
+	).Scan(&version)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err = s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM rooms WHERE name = $1)`, room.Name).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if !exists {
+			return 0, ErrRoomNotFound
+		}
+		return 0, ErrRoomVersionConflict
+	} else if err != nil {
+		return 0, errors.Wrap(err, "could not update room")
+	}
+	return version, nil
+}
+
+func (s *PostgresStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM room_participants WHERE room_name = $1`, string(roomName)); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM rooms WHERE name = $1`, string(roomName)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, roomName := range roomNames {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM room_participants WHERE room_name = $1`, string(roomName)); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM rooms WHERE name = $1`, string(roomName)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) StoreParticipantSession(ctx context.Context, session *ParticipantSession) error {
+	var info []byte
+	if session.Info != nil {
+		var err error
+		if info, err = proto.Marshal(session.Info); err != nil {
+			return err
+		}
+	}
+	var leftAt sql.NullTime
+	if !session.IsActive() {
+		leftAt = sql.NullTime{Time: session.LeftAt, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO participant_sessions
+		(participant_id, room_name, room_id, identity, node_id, joined_at, left_at, info) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (participant_id) DO UPDATE SET room_name = EXCLUDED.room_name, room_id = EXCLUDED.room_id,
+			identity = EXCLUDED.identity, node_id = EXCLUDED.node_id, joined_at = EXCLUDED.joined_at,
+			left_at = EXCLUDED.left_at, info = EXCLUDED.info`,
+		string(session.ParticipantID), string(session.RoomName), string(session.RoomID), string(session.Identity),
+		string(session.NodeID), session.JoinedAt, leftAt, info,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not store participant session")
+	}
+	return nil
+}
+
+const participantSessionColumns = `participant_id, room_name, room_id, identity, node_id, joined_at, left_at, info`
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanParticipantSession(row rowScanner) (*ParticipantSession, error) {
+	var participantID, roomName, roomID, identity, nodeID string
+	var joinedAt time.Time
+	var leftAt sql.NullTime
+	var info []byte
+	if err := row.Scan(&participantID, &roomName, &roomID, &identity, &nodeID, &joinedAt, &leftAt, &info); err != nil {
+		return nil, err
+	}
+
+	session := &ParticipantSession{
+		RoomName:      livekit.RoomName(roomName),
+		RoomID:        livekit.RoomID(roomID),
+		ParticipantID: livekit.ParticipantID(participantID),
+		Identity:      livekit.ParticipantIdentity(identity),
+		NodeID:        livekit.NodeID(nodeID),
+		JoinedAt:      joinedAt,
+		LeftAt:        leftAt.Time,
+	}
+	if info != nil {
+		session.Info = &livekit.ParticipantInfo{}
+		if err := proto.Unmarshal(info, session.Info); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+func (s *PostgresStore) LoadParticipantSession(ctx context.Context, participantID livekit.ParticipantID) (*ParticipantSession, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+participantSessionColumns+` FROM participant_sessions WHERE participant_id = $1`, string(participantID))
+	session, err := scanParticipantSession(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrParticipantNotFound
+	}
+	return session, err
+}
+
+func (s *PostgresStore) ListParticipantSessions(ctx context.Context, roomName livekit.RoomName, active bool) ([]*ParticipantSession, error) {
+	query := `SELECT ` + participantSessionColumns + ` FROM participant_sessions WHERE room_name = $1`
+	if active {
+		query += ` AND left_at IS NULL`
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY joined_at`, string(roomName))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get participant sessions")
+	}
+	defer rows.Close()
+
+	var sessions []*ParticipantSession
+	for rows.Next() {
+		session, err := scanParticipantSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *PostgresStore) StoreRoomHistory(ctx context.Context, history *RoomHistory, retention time.Duration) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO room_history
+		(sid, name, metadata, started_at, ended_at, peak_participants, end_reason) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (sid) DO UPDATE SET name = EXCLUDED.name, metadata = EXCLUDED.metadata, started_at = EXCLUDED.started_at,
+			ended_at = EXCLUDED.ended_at, peak_participants = EXCLUDED.peak_participants, end_reason = EXCLUDED.end_reason`,
+		history.Sid, history.Name, history.Metadata, history.StartedAt, history.EndedAt, int64(history.PeakParticipants), history.EndReason,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not store room history")
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM room_history WHERE ended_at < $1`, time.Now().Add(-retention))
+	return err
+}
+
+func (s *PostgresStore) ListRoomHistory(ctx context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT sid, name, metadata, started_at, ended_at, peak_participants, end_reason
+		FROM room_history WHERE $1 = '' OR name = $1 ORDER BY ended_at DESC LIMIT $2`,
+		string(roomName), limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get room history")
+	}
+	defer rows.Close()
+
+	var history []*RoomHistory
+	for rows.Next() {
+		h := &RoomHistory{}
+		var peakParticipants int64
+		if err = rows.Scan(&h.Sid, &h.Name, &h.Metadata, &h.StartedAt, &h.EndedAt, &peakParticipants, &h.EndReason); err != nil {
+			return nil, err
+		}
+		h.PeakParticipants = uint32(peakParticipants)
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+func (s *PostgresStore) StoreRoomTemplate(ctx context.Context, template *RoomTemplate) error {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO room_templates (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`,
+		template.Name, data,
+	)
+	return errors.Wrap(err, "could not store room template")
+}
+
+func (s *PostgresStore) LoadRoomTemplate(ctx context.Context, name string) (*RoomTemplate, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM room_templates WHERE name = $1`, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomTemplateNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not load room template")
+	}
+
+	template := &RoomTemplate{}
+	if err = json.Unmarshal(data, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *PostgresStore) ListRoomTemplates(ctx context.Context) ([]*RoomTemplate, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM room_templates ORDER BY name`)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list room templates")
+	}
+	defer rows.Close()
+
+	var templates []*RoomTemplate
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		template := &RoomTemplate{}
+		if err = json.Unmarshal(data, template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+func (s *PostgresStore) DeleteRoomTemplate(ctx context.Context, name string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM room_templates WHERE name = $1`, name)
+	if err != nil {
+		return errors.Wrap(err, "could not delete room template")
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRoomTemplateNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) ReserveIdempotencyKey(ctx context.Context, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	// take the key if it is free or expired
+	res, err := s.db.ExecContext(ctx, `INSERT INTO idempotency_keys (key, data, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < now()`,
+		record.Key, data, time.Now().Add(ttl),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not reserve idempotency key")
+	}
+	if reserved, _ := res.RowsAffected(); reserved == 1 {
+		return nil, nil
+	}
+
+	if err = s.db.QueryRowContext(ctx, `SELECT data FROM idempotency_keys WHERE key = $1`, record.Key).Scan(&data); err != nil {
+		return nil, errors.Wrap(err, "could not load idempotency key")
+	}
+	existing := &IdempotencyRecord{}
+	if err = json.Unmarshal(data, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *PostgresStore) StoreIdempotencyRecord(ctx context.Context, record *IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE idempotency_keys SET data = $2 WHERE key = $1`, record.Key, data)
+	return errors.Wrap(err, "could not store idempotency key")
+}
+
+func (s *PostgresStore) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return errors.Wrap(err, "could not delete idempotency key")
+}
+
+func (s *PostgresStore) LockRoom(ctx context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+
+	startTime := time.Now()
+	for {
+		// take the lock if it is free or expired
+		res, err := s.db.ExecContext(ctx, `INSERT INTO room_locks (name, token, expires_at) VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO UPDATE SET token = EXCLUDED.token, expires_at = EXCLUDED.expires_at
+			WHERE room_locks.expires_at < now()`,
+			string(roomName), token, time.Now().Add(duration),
+		)
+		if err != nil {
+			return "", err
+		}
+		if locked, _ := res.RowsAffected(); locked == 1 {
+			return token, nil
+		}
+
+		// stop waiting past lock duration
+		if time.Since(startTime) > duration {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *PostgresStore) UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM room_locks WHERE name = $1 AND token = $2`, string(roomName), uid)
+	if err != nil {
+		return err
+	}
+
+	// uid does not match
+	if unlocked, _ := res.RowsAffected(); unlocked != 1 {
+		return ErrRoomUnlockFailed
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO room_participants (room_name, identity, data) VALUES ($1, $2, $3)
+		ON CONFLICT (room_name, identity) DO UPDATE SET data = EXCLUDED.data`,
+		string(roomName), participant.Identity, data,
+	)
+	return err
+}
+
+func (s *PostgresStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM room_participants WHERE room_name = $1 AND identity = $2`,
+		string(roomName), string(identity),
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrParticipantNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	pi := livekit.ParticipantInfo{}
+	if err = proto.Unmarshal(data, &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+func (s *PostgresStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM room_participants WHERE room_name = $1`, string(roomName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []*livekit.ParticipantInfo
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		pi := livekit.ParticipantInfo{}
+		if err = proto.Unmarshal(data, &pi); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &pi)
+	}
+	return participants, rows.Err()
+}
+
+func (s *PostgresStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM room_participants WHERE room_name = $1 AND identity = $2`,
+		string(roomName), string(identity),
+	)
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func postgresStore(t *testing.T) *service.PostgresStore {
+	url := os.Getenv("LIVEKIT_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("LIVEKIT_TEST_POSTGRES_URL is not set")
+	}
+
+	conf := config.DefaultConfig.Store.Postgres
+	conf.URL = url
+	s, err := service.NewPostgresStore(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return s
+}
+
+func TestPostgresStoreRoom(t *testing.T) {
+	ctx := context.Background()
+	s := postgresStore(t)
+
+	room := &livekit.Room{Sid: "RM_postgres", Name: "postgres_room"}
+	internal := &livekit.RoomInternal{
+		TrackEgress: &livekit.AutoTrackEgress{Filepath: "egress"},
+	}
+	require.NoError(t, s.StoreRoom(ctx, room, internal))
+	t.Cleanup(func() {
+		_ = s.DeleteRoom(ctx, "postgres_room")
+	})
+
+	actualRoom, actualInternal, err := s.LoadRoom(ctx, "postgres_room", true)
+	require.NoError(t, err)
+	require.Equal(t, room.Sid, actualRoom.Sid)
+	require.NotZero(t, actualRoom.CreationTime)
+	require.Equal(t, internal.TrackEgress.Filepath, actualInternal.TrackEgress.Filepath)
+
+	rooms, err := s.ListRooms(ctx, []livekit.RoomName{"postgres_room", "unknown"})
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+
+	require.NoError(t, s.StoreParticipant(ctx, "postgres_room", &livekit.ParticipantInfo{Sid: "PA_1", Identity: "one"}))
+	p, err := s.LoadParticipant(ctx, "postgres_room", "one")
+	require.NoError(t, err)
+	require.Equal(t, "PA_1", p.Sid)
+
+	// deleting the room removes its participants
+	require.NoError(t, s.DeleteRoom(ctx, "postgres_room"))
+	_, _, err = s.LoadRoom(ctx, "postgres_room", false)
+	require.ErrorIs(t, err, service.ErrRoomNotFound)
+	participants, err := s.ListParticipants(ctx, "postgres_room")
+	require.NoError(t, err)
+	require.Empty(t, participants)
+}
+
+func TestPostgresStoreLock(t *testing.T) {
+	ctx := context.Background()
+	s := postgresStore(t)
+
+	token, err := s.LockRoom(ctx, "postgres_lock", 200*time.Millisecond)
+	require.NoError(t, err)
+
+	// lock expires before the second attempt gives up
+	token2, err := s.LockRoom(ctx, "postgres_lock", 500*time.Millisecond)
+	require.NoError(t, err)
+	require.NotEqual(t, token, token2)
+
+	require.ErrorIs(t, s.UnlockRoom(ctx, "postgres_lock", token), service.ErrRoomUnlockFailed)
+	require.NoError(t, s.UnlockRoom(ctx, "postgres_lock", token2))
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+// ProjectScopeInterceptor scopes the room names of RoomService requests to the project of the caller,
+// and returns room names to the caller as it named them. It is a no-op when projects are not configured
+func ProjectScopeInterceptor() twirp.Interceptor {
+	return func(next twirp.Method) twirp.Method {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			project, ok := GetProject(ctx)
+			if !ok {
+				return next(ctx, req)
+			}
+
+			req, err := scopeRequest(project, req)
+			if err != nil {
+				return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+			}
+			res, err := next(ctx, req)
+			if err != nil {
+				return res, err
+			}
+			return unscopeResponse(res), nil
+		}
+	}
+}
+
+func scopeRoomName(project string, name string) (string, error) {
+	if strings.Contains(name, utils.ProjectSeparator) {
+		return "", ErrInvalidProjectRoomName
+	}
+	return string(utils.ProjectRoomName(project, livekit.RoomName(name))), nil
+}
+
+func scopeRequest(project string, req interface{}) (interface{}, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return req, nil
+	}
+	// requests are not changed in place, they may be logged by hooks
+	msg = proto.Clone(msg)
+
+	var err error
+	switch r := msg.(type) {
+	case *livekit.CreateRoomRequest:
+		r.Name, err = scopeRoomName(project, r.Name)
+	case *livekit.ListRoomsRequest:
+		for i := range r.Names {
+			if r.Names[i], err = scopeRoomName(project, r.Names[i]); err != nil {
+				break
+			}
+		}
+	case *livekit.DeleteRoomRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.ListParticipantsRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.RoomParticipantIdentity:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.MuteRoomTrackRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.UpdateParticipantRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.UpdateSubscriptionsRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.SendDataRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	case *livekit.UpdateRoomMetadataRequest:
+		r.Room, err = scopeRoomName(project, r.Room)
+	}
+	return msg, err
+}
+
+func unscopeRoom(room *livekit.Room) *livekit.Room {
+	// stores may return the rooms they hold
+	room = proto.Clone(room).(*livekit.Room)
+	_, name := utils.SplitProjectRoomName(livekit.RoomName(room.Name))
+	room.Name = string(name)
+	return room
+}
+
+func unscopeResponse(res interface{}) interface{} {
+	switch r := res.(type) {
+	case *livekit.Room:
+		return unscopeRoom(r)
+	case *livekit.ListRoomsResponse:
+		rooms := make([]*livekit.Room, 0, len(r.Rooms))
+		for _, room := range r.Rooms {
+			rooms = append(rooms, unscopeRoom(room))
+		}
+		return &livekit.ListRoomsResponse{Rooms: rooms}
+	}
+	return res
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestAuthMiddlewareProjects(t *testing.T) {
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider, map[string]string{"APIcustomer": "customer"}, nil, nil, nil, nil, nil)
+	var ctx context.Context
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(apiKey string, room string) int {
+		ctx = nil
+		token, err := auth.NewAccessToken(apiKey, secret).
+			AddGrant(&auth.VideoGrant{Room: room, RoomJoin: true}).
+			ToJWT()
+		require.NoError(t, err)
+
+		r := &http.Request{Header: http.Header{}}
+		w := httptest.NewRecorder()
+		service.SetAuthorizationToken(r, token)
+		m.ServeHTTP(w, r, handler)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve("APIcustomer", "room"))
+	project, ok := service.GetProject(ctx)
+	require.True(t, ok)
+	require.Equal(t, "customer", project)
+	name, err := service.EnsureJoinPermission(ctx)
+	require.NoError(t, err)
+	require.Equal(t, livekit.RoomName("customer|room"), name)
+
+	// keys without a project use the default project
+	require.Equal(t, http.StatusOK, serve("APIother", "room"))
+	project, ok = service.GetProject(ctx)
+	require.True(t, ok)
+	require.Equal(t, "", project)
+	name, err = service.EnsureJoinPermission(ctx)
+	require.NoError(t, err)
+	require.Equal(t, livekit.RoomName("room"), name)
+
+	// rooms of other projects cannot be named
+	require.Equal(t, http.StatusUnauthorized, serve("APIother", "customer|room"))
+	require.Nil(t, ctx)
+}
+
+func TestProjectScopeInterceptor(t *testing.T) {
+	store := service.NewLocalStore()
+	ctx := context.Background()
+	for _, name := range []string{"room", "customer|room", "customer|meeting", "other|room"} {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: name}, nil))
+	}
+
+	var received interface{}
+	method := service.ProjectScopeInterceptor()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		received = req
+		switch req.(type) {
+		case *livekit.ListRoomsRequest:
+			opts := service.ListRoomsOptions{}
+			if project, ok := service.GetProject(ctx); ok {
+				opts.Project = project
+				opts.ScopeToProject = true
+			}
+			rooms, _, err := store.ListRoomsPage(ctx, opts)
+			return &livekit.ListRoomsResponse{Rooms: rooms}, err
+		case *livekit.DeleteRoomRequest:
+			return &livekit.DeleteRoomResponse{}, nil
+		}
+		return nil, nil
+	})
+
+	res, err := method(service.WithProject(ctx, "customer"), &livekit.DeleteRoomRequest{Room: "room"})
+	require.NoError(t, err)
+	require.Equal(t, "customer|room", received.(*livekit.DeleteRoomRequest).Room)
+	require.NotNil(t, res)
+
+	_, err = method(service.WithProject(ctx, "customer"), &livekit.DeleteRoomRequest{Room: "other|room"})
+	require.Error(t, err)
+
+	res, err = method(service.WithProject(ctx, "customer"), &livekit.ListRoomsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, room := range res.(*livekit.ListRoomsResponse).Rooms {
+		names = append(names, room.Name)
+	}
+	require.Equal(t, []string{"meeting", "room"}, names)
+
+	res, err = method(service.WithProject(ctx, ""), &livekit.ListRoomsRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.(*livekit.ListRoomsResponse).Rooms, 1)
+	require.Equal(t, "room", res.(*livekit.ListRoomsResponse).Rooms[0].Name)
+
+	// stored rooms are not renamed
+	room, _, err := store.LoadRoom(ctx, "customer|room", false)
+	require.NoError(t, err)
+	require.Equal(t, "customer|room", room.Name)
+
+	// requests pass unchanged when projects are not configured
+	_, err = method(ctx, &livekit.DeleteRoomRequest{Room: "other|room"})
+	require.NoError(t, err)
+	require.Equal(t, "other|room", received.(*livekit.DeleteRoomRequest).Room)
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	maxProvisionRooms           = 5000
+	maxProvisionRoomRequestSize = 16 * 1024 * 1024
+	// rooms of a request that are created at once
+	provisionRoomConcurrency = 16
+)
+
+// RoomProvisionResult is the outcome of creating one of the rooms of ProvisionRooms, either the room or the error
+type RoomProvisionResult struct {
+	Name string
+	Room *livekit.Room
+	Err  error
+}
+
+// ProvisionRooms creates each of the rooms independently, unlike CreateRooms rooms that fail do not affect the
+// others. results are in the order of the requests, existing rooms are returned as CreateRoom does
+func (s *RoomBatchService) ProvisionRooms(ctx context.Context, reqs []*livekit.CreateRoomRequest) ([]*RoomProvisionResult, error) {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 || len(reqs) > maxProvisionRooms {
+		return nil, ErrInvalidRoomBatch
+	}
+
+	project, scoped := GetProject(ctx)
+	results := make([]*RoomProvisionResult, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	var pending []int
+	for i, req := range reqs {
+		results[i] = &RoomProvisionResult{Name: req.Name}
+		if req.Name == "" || seen[req.Name] {
+			results[i].Err = ErrInvalidRoomBatch
+			continue
+		}
+		seen[req.Name] = true
+		pending = append(pending, i)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < provisionRoomConcurrency && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i].Room, results[i].Err = s.provisionRoom(ctx, project, scoped, reqs[i])
+			}
+		}()
+	}
+	for _, i := range pending {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
+
+func (s *RoomBatchService) provisionRoom(ctx context.Context, project string, scoped bool, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	name := req.Name
+	req = proto.Clone(req).(*livekit.CreateRoomRequest)
+	if scoped {
+		name, err := scopeRoomName(project, req.Name)
+		if err != nil {
+			return nil, err
+		}
+		req.Name = name
+	}
+
+	rm, err := s.roomService.CreateRoom(roomRequestContext(ctx, name), req)
+	if err != nil {
+		logger.Warnw("could not provision room", err, "room", req.Name)
+		return nil, err
+	}
+	return unscopeRoom(rm), nil
+}
+
+type provisionRoomResult struct {
+	Name   string          `json:"name"`
+	Room   json.RawMessage `json:"room,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Status int             `json:"status,omitempty"`
+}
+
+type provisionRoomsResponse struct {
+	Rooms   []*provisionRoomResult `json:"rooms"`
+	Created int                    `json:"created"`
+	Failed  int                    `json:"failed"`
+}
+
+// ServeProvision creates rooms at /provision_rooms. the body is that of POST /room_batch, the response has the
+// result of each room with the HTTP status of its error
+func (s *RoomBatchService) ServeProvision(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxProvisionRoomRequestSize, func(ctx context.Context, batch *roomBatchRequest) (interface{}, error) {
+			reqs, options, err := parseCreateRoomRequests(batch.Rooms)
+			if err != nil {
+				return nil, err
+			}
+			results, err := s.ProvisionRooms(withRoomRequestFields(ctx, options), reqs)
+			if err != nil {
+				return nil, err
+			}
+			res := &provisionRoomsResponse{Rooms: make([]*provisionRoomResult, 0, len(results))}
+			for _, result := range results {
+				pr := &provisionRoomResult{Name: result.Name}
+				if result.Err != nil {
+					pr.Error = result.Err.Error()
+					pr.Status = errorStatus(result.Err)
+					res.Failed++
+				} else {
+					if pr.Room, err = protojson.Marshal(result.Room); err != nil {
+						return nil, err
+					}
+					res.Created++
+				}
+				res.Rooms = append(res.Rooms, pr)
+			}
+			return res, nil
+		}),
+	}.ServeHTTP(w, r)
+}
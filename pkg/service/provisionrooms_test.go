@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestProvisionRooms(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomCreate: true},
+	})
+
+	store := service.NewLocalStore()
+	router := &routingfakes.FakeRouter{}
+	router.StartParticipantSignalReturns("", &routingfakes.FakeMessageSink{}, &routingfakes.FakeMessageSource{}, nil)
+	allocator := &servicefakes.FakeRoomAllocator{}
+	allocator.CreateRoomStub = func(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+		if req.Name == "class-7" {
+			return nil, errors.New("no available nodes")
+		}
+		rm := &livekit.Room{Name: req.Name, MaxParticipants: req.MaxParticipants}
+		return rm, store.StoreRoom(ctx, rm, nil)
+	}
+	roomService, err := service.NewRoomService(config.RoomConfig{}, config.APIConfig{ExecutionTimeout: time.Second},
+		router, allocator, store, nil, nil)
+	require.NoError(t, err)
+	svc := service.NewRoomBatchService(roomService, store)
+
+	var reqs []*livekit.CreateRoomRequest
+	for i := 0; i < 50; i++ {
+		reqs = append(reqs, &livekit.CreateRoomRequest{Name: fmt.Sprintf("class-%d", i), MaxParticipants: 30})
+	}
+	reqs = append(reqs, &livekit.CreateRoomRequest{Name: "class-0"}, &livekit.CreateRoomRequest{})
+
+	results, err := svc.ProvisionRooms(ctx, reqs)
+	require.NoError(t, err)
+	require.Len(t, results, len(reqs))
+	for i, result := range results[:50] {
+		require.Equal(t, reqs[i].Name, result.Name)
+		if i == 7 {
+			require.Error(t, result.Err)
+			require.Nil(t, result.Room)
+			continue
+		}
+		require.NoError(t, result.Err)
+		require.Equal(t, reqs[i].Name, result.Room.Name)
+		require.Equal(t, uint32(30), result.Room.MaxParticipants)
+	}
+	// duplicate and empty names fail without affecting the other rooms
+	require.ErrorIs(t, results[50].Err, service.ErrInvalidRoomBatch)
+	require.ErrorIs(t, results[51].Err, service.ErrInvalidRoomBatch)
+
+	rooms, err := store.ListRooms(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, rooms, 49)
+
+	_, err = svc.ProvisionRooms(ctx, nil)
+	require.ErrorIs(t, err, service.ErrInvalidRoomBatch)
+	_, err = svc.ProvisionRooms(context.Background(), reqs)
+	require.ErrorIs(t, err, service.ErrPermissionDenied)
+}
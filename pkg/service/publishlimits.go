@@ -0,0 +1,41 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+type tokenMaxPublishResolutionKey struct{}
+
+// maxPublishResolution returns the publish resolution limit claim of the token, nil when it has none
+func (c *tokenClaims) maxPublishResolution() *routing.VideoResolution {
+	if c.MaxPublishResolution == nil || c.MaxPublishResolution.Width == 0 || c.MaxPublishResolution.Height == 0 {
+		return nil
+	}
+	return c.MaxPublishResolution
+}
+
+func withTokenMaxPublishResolution(ctx context.Context, resolution *routing.VideoResolution) context.Context {
+	return context.WithValue(ctx, tokenMaxPublishResolutionKey{}, resolution)
+}
+
+// tokenMaxPublishResolution returns the publish resolution limit claim of the token of the request
+func tokenMaxPublishResolution(ctx context.Context) *routing.VideoResolution {
+	resolution, _ := ctx.Value(tokenMaxPublishResolutionKey{}).(*routing.VideoResolution)
+	return resolution
+}
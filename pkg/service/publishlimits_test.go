@@ -0,0 +1,32 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+func TestMaxPublishResolutionOfToken(t *testing.T) {
+	require.Equal(t,
+		&routing.VideoResolution{Width: 1280, Height: 720},
+		testTokenClaims(t, `{"sub":"alice","max_publish_resolution":{"width":1280,"height":720}}`).maxPublishResolution(),
+	)
+	require.Nil(t, testTokenClaims(t, `{"sub":"alice"}`).maxPublishResolution())
+	require.Nil(t, testTokenClaims(t, `{"max_publish_resolution":{"width":1280}}`).maxPublishResolution())
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/livekit/protocol/logger"
+	redisLiveKit "github.com/livekit/protocol/redis"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// NewRedisClient connects to the redis of the config, used by the store and the router alike
+func NewRedisClient(conf *config.RedisConfig) (redis.UniversalClient, error) {
+	if !conf.TLS.IsConfigured() {
+		return redisLiveKit.GetRedisClient(&conf.RedisConfig)
+	}
+
+	tlsConfig, err := newRedisTLSConfig(conf.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var rcOptions *redis.UniversalOptions
+	switch {
+	case len(conf.SentinelAddresses) > 0:
+		logger.Infow("connecting to redis", "sentinel", true, "addr", conf.SentinelAddresses, "masterName", conf.MasterName, "tls", true)
+		rcOptions = &redis.UniversalOptions{
+			Addrs:            conf.SentinelAddresses,
+			SentinelUsername: conf.SentinelUsername,
+			SentinelPassword: conf.SentinelPassword,
+			MasterName:       conf.MasterName,
+			Username:         conf.Username,
+			Password:         conf.Password,
+			DB:               conf.DB,
+			TLSConfig:        tlsConfig,
+			DialTimeout:      redisTimeout(conf.DialTimeout, 2000),
+			ReadTimeout:      redisTimeout(conf.ReadTimeout, 200),
+			WriteTimeout:     redisTimeout(conf.WriteTimeout, 200),
+		}
+	case len(conf.ClusterAddresses) > 0:
+		logger.Infow("connecting to redis", "cluster", true, "addr", conf.ClusterAddresses, "tls", true)
+		rcOptions = &redis.UniversalOptions{
+			Addrs:        conf.ClusterAddresses,
+			Username:     conf.Username,
+			Password:     conf.Password,
+			TLSConfig:    tlsConfig,
+			MaxRedirects: conf.GetMaxRedirects(),
+		}
+	default:
+		logger.Infow("connecting to redis", "simple", true, "addr", conf.Address, "tls", true)
+		rcOptions = &redis.UniversalOptions{
+			Addrs:     []string{conf.Address},
+			Username:  conf.Username,
+			Password:  conf.Password,
+			DB:        conf.DB,
+			TLSConfig: tlsConfig,
+		}
+	}
+
+	rc := redis.NewUniversalClient(rcOptions)
+	if err = rc.Ping(context.Background()).Err(); err != nil {
+		_ = rc.Close()
+		return nil, errors.Wrap(err, "unable to connect to redis")
+	}
+	return rc, nil
+}
+
+func newRedisTLSConfig(conf config.RedisTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+
+	if conf.CACertFile != "" {
+		pem, err := config.ReadFile(conf.CACertFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read redis ca cert")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in redis ca cert file %s", conf.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.ClientCertFile != "" || conf.ClientKeyFile != "" {
+		if conf.ClientCertFile == "" || conf.ClientKeyFile == "" {
+			return nil, errors.New("redis client_cert_file and client_key_file must be set together")
+		}
+		cert, err := config.LoadX509KeyPair(conf.ClientCertFile, conf.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load redis client cert")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func redisTimeout(ms int, defaultMS int) time.Duration {
+	if ms == 0 {
+		ms = defaultMS
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// prefixedPubSubClient prepends the key prefix to pubsub channels, and to the keys psrpc locks messages of queues
+// with, for the message bus and room cache invalidation of environments sharing a redis to stay apart
+type prefixedPubSubClient struct {
+	redis.UniversalClient
+	prefix string
+}
+
+func newPrefixedPubSubClient(rc redis.UniversalClient, prefix string) redis.UniversalClient {
+	if rc == nil || prefix == "" {
+		return rc
+	}
+	return &prefixedPubSubClient{
+		UniversalClient: rc,
+		prefix:          prefix,
+	}
+}
+
+func (c *prefixedPubSubClient) Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd {
+	return c.UniversalClient.Publish(ctx, c.prefix+channel, message)
+}
+
+func (c *prefixedPubSubClient) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	prefixed := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		prefixed = append(prefixed, c.prefix+channel)
+	}
+	return c.UniversalClient.Subscribe(ctx, prefixed...)
+}
+
+func (c *prefixedPubSubClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	return c.UniversalClient.SetNX(ctx, c.prefix+key, value, expiration)
+}
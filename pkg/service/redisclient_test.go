@@ -0,0 +1,44 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestRedisTLSConfig(t *testing.T) {
+	tlsConfig, err := newRedisTLSConfig(config.RedisTLSConfig{ServerName: "redis.internal"})
+	require.NoError(t, err)
+	require.Equal(t, "redis.internal", tlsConfig.ServerName)
+	require.Nil(t, tlsConfig.RootCAs)
+
+	// client cert and key are required together
+	_, err = newRedisTLSConfig(config.RedisTLSConfig{ClientCertFile: "client.pem"})
+	require.Error(t, err)
+
+	_, err = newRedisTLSConfig(config.RedisTLSConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")})
+	require.Error(t, err)
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0600))
+	_, err = newRedisTLSConfig(config.RedisTLSConfig{CACertFile: notPEM})
+	require.Error(t, err)
+}
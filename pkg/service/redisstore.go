@@ -16,6 +16,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/version"
 	"github.com/livekit/protocol/ingress"
 	"github.com/livekit/protocol/livekit"
@@ -39,6 +41,12 @@ const (
 	// RoomsKey is hash of room_name => Room proto
 	RoomsKey        = "rooms"
 	RoomInternalKey = "room_internal"
+	// RoomLabelsKey is hash of room_name => json of RoomLabels
+	RoomLabelsKey = "room_labels"
+	// RoomVersionsKey is hash of room_name => version, incremented with every change of the metadata of the room
+	RoomVersionsKey = "room_versions"
+	// RoomMetadataKey is hash of room_name => metadata of the room at its version
+	RoomMetadataKey = "room_metadata"
 
 	// EgressKey is a hash of egressID => egress info
 	EgressKey        = "egress"
@@ -54,30 +62,208 @@ const (
 	// RoomParticipantsPrefix is hash of participant_name => ParticipantInfo
 	RoomParticipantsPrefix = "room_participants:"
 
+	// ParticipantSessionPrefix is a key of participant_id containing a json ParticipantSession
+	ParticipantSessionPrefix = "participant_session:"
+	// RoomParticipantSessionsPrefix is a set of participant_id of sessions in the room
+	RoomParticipantSessionsPrefix = "room_participant_sessions:"
+
+	// RoomHistoryKey is a sorted set of room sid, scored by the time the room ended
+	RoomHistoryKey = "room_history"
+	// RoomHistoryRoomPrefix is a sorted set of room sid of the room name, scored by the time the room ended
+	RoomHistoryRoomPrefix = "room_history_room:"
+	// RoomHistoryPrefix is a key of room sid containing a json RoomHistory
+	RoomHistoryPrefix = "room_history:"
+
+	// RoomTemplatesKey is hash of template name => json RoomTemplate
+	RoomTemplatesKey = "room_templates"
+
 	// RoomLockPrefix is a simple key containing a provided lock uid
 	RoomLockPrefix = "room_lock:"
 
+	// IdempotencyKeyPrefix is a key of idempotency key containing a json IdempotencyRecord
+	IdempotencyKeyPrefix = "idempotency_key:"
+
+	// RevokedTokenPrefix is a key of token ID that exists while the token is revoked
+	RevokedTokenPrefix = "revoked_token:"
+	// RevokedIdentityPrefix is a key of identity containing the unix time tokens of the identity are revoked up to
+	RevokedIdentityPrefix = "revoked_identity:"
+
+	// KeyUsagePrefix is hash of member => room_name of the members counted against a quota of the API key
+	KeyUsagePrefix = "key_usage:"
+
 	maxRetries = 5
 )
 
+// redisStoreKeys are the keys of the store, prepended with the key prefix of the config. On a single instance,
+// values of rooms are kept in hashes of room_name => value. In a Redis Cluster, values of a room are fields of a
+// hash of the room instead, hash tagged by the room as are its participants, for scripts across the keys of a room
+// to be allowed while rooms are spread across slots. rooms of a cluster are listed from a set of their names.
+// keys of ingress are hash tagged into the slot of IngressKey
+type redisStoreKeys struct {
+	prefix  string
+	cluster bool
+	// hashes of room_name => value, or fields of the hash of the room in a cluster
+	rooms        string
+	roomInternal string
+	roomLabels   string
+	roomVersions string
+	roomMetadata string
+	// set of the room names in a cluster
+	roomNames string
+	ingress   string
+}
+
+func newRedisStoreKeys(rc redis.UniversalClient, prefix string) redisStoreKeys {
+	if _, ok := rc.(*redis.ClusterClient); ok {
+		return redisStoreKeys{
+			prefix:       prefix,
+			cluster:      true,
+			rooms:        RoomsKey,
+			roomInternal: RoomInternalKey,
+			roomLabels:   RoomLabelsKey,
+			roomVersions: RoomVersionsKey,
+			roomMetadata: RoomMetadataKey,
+			roomNames:    prefix + "{" + RoomsKey + "}",
+			ingress:      prefix + "{" + IngressKey + "}",
+		}
+	}
+	return redisStoreKeys{
+		prefix:       prefix,
+		rooms:        prefix + RoomsKey,
+		roomInternal: prefix + RoomInternalKey,
+		roomLabels:   prefix + RoomLabelsKey,
+		roomVersions: prefix + RoomVersionsKey,
+		roomMetadata: prefix + RoomMetadataKey,
+		ingress:      prefix + IngressKey,
+	}
+}
+
+func (k redisStoreKeys) key(name string) string {
+	return k.prefix + name
+}
+
+// roomKey is the hash of the values of a room in a cluster, its tag is the slot of the keys of the room
+func (k redisStoreKeys) roomKey(roomName string) string {
+	return k.prefix + "{room:" + roomName + "}"
+}
+
+// roomField returns the key and field of a value of the room, value is one of the hashes of the keys
+func (k redisStoreKeys) roomField(value string, roomName string) (key string, field string) {
+	if k.cluster {
+		return k.roomKey(roomName), value
+	}
+	return value, roomName
+}
+
+// roomFields returns the keys and fields of values of the room, in the order of the values
+func (k redisStoreKeys) roomFields(roomName string, values ...string) ([]string, []interface{}) {
+	keys := make([]string, 0, len(values))
+	fields := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		key, field := k.roomField(value, roomName)
+		keys = append(keys, key)
+		fields = append(fields, field)
+	}
+	return keys, fields
+}
+
+// keyUsage is the hash of the usage of a quota of the key, the quotas of a key are hash tagged into a slot of the key
+func (k redisStoreKeys) keyUsage(apiKey string, quota string) string {
+	return k.prefix + "{" + KeyUsagePrefix + apiKey + "}:" + quota
+}
+
+func (k redisStoreKeys) roomParticipants(roomName string) string {
+	if k.cluster {
+		return k.roomKey(roomName) + ":" + RoomParticipantsPrefix
+	}
+	return k.prefix + RoomParticipantsPrefix + roomName
+}
+
 type RedisStore struct {
-	rc           redis.UniversalClient
-	unlockScript *redis.Script
-	ctx          context.Context
-	done         chan struct{}
+	rc   redis.UniversalClient
+	keys redisStoreKeys
+	// nil when participant writes are not batched
+	participantWrites *participantWriteBatch
+	unlockScript      *redis.Script
+	storeScript       *redis.Script
+	updateScript      *redis.Script
+	acquireScript     *redis.Script
+	releaseScript     *redis.Script
+	ctx               context.Context
+	done              chan struct{}
 }
 
-func NewRedisStore(rc redis.UniversalClient) *RedisStore {
+func NewRedisStore(rc redis.UniversalClient, conf config.RedisConfig) *RedisStore {
 	unlockScript := `if redis.call("get", KEYS[1]) == ARGV[1] then
 						return redis.call("del", KEYS[1])
 					 else return 0 
 					 end`
 
-	return &RedisStore{
-		ctx:          context.Background(),
-		rc:           rc,
-		unlockScript: redis.NewScript(unlockScript),
+	// KEYS and ARGV[1..4] are the keys and fields of the room, version, metadata and internal data of the room.
+	// stores the room and its internal data when ARGV[8] is 1, the version is only incremented when the metadata
+	// of the room changes
+	storeScript := `redis.call("hset", KEYS[1], ARGV[1], ARGV[5])
+					if ARGV[8] == "1" then
+						redis.call("hset", KEYS[4], ARGV[4], ARGV[7])
+					else
+						redis.call("hdel", KEYS[4], ARGV[4])
+					end
+					if redis.call("hget", KEYS[3], ARGV[3]) ~= ARGV[6] then
+						redis.call("hset", KEYS[3], ARGV[3], ARGV[6])
+						redis.call("hincrby", KEYS[2], ARGV[2], 1)
+					end
+					return 0`
+
+	// KEYS and ARGV[1..3] are the keys and fields of the room, version and metadata of the room.
+	// returns the new version, -1 when the room does not exist, or -2 when the version does not match
+	updateScript := `if redis.call("hexists", KEYS[1], ARGV[1]) == 0 then
+						return -1
+					 end
+					 if tonumber(redis.call("hget", KEYS[2], ARGV[2]) or "0") ~= tonumber(ARGV[4]) then
+						return -2
+					 end
+					 redis.call("hset", KEYS[1], ARGV[1], ARGV[5])
+					 redis.call("hset", KEYS[3], ARGV[3], ARGV[6])
+					 return redis.call("hincrby", KEYS[2], ARGV[2], 1)`
+
+	// KEYS[1] is the usage of a quota, ARGV the member, its room and the limit of the quota. returns the usage, and 1
+	// when the member is counted
+	acquireScript := `if redis.call("hexists", KEYS[1], ARGV[1]) == 1 then
+						return {redis.call("hlen", KEYS[1]), 1}
+					  end
+					  local usage = redis.call("hlen", KEYS[1])
+					  if usage >= tonumber(ARGV[3]) then
+						return {usage, 0}
+					  end
+					  redis.call("hset", KEYS[1], ARGV[1], ARGV[2])
+					  return {usage + 1, 1}`
+
+	// KEYS are the usage of the quotas of a key, removes the members of the room ARGV[1] from each of them
+	releaseScript := `for _, key in ipairs(KEYS) do
+						local usage = redis.call("hgetall", key)
+						for i = 1, #usage, 2 do
+							if usage[i + 1] == ARGV[1] then
+								redis.call("hdel", key, usage[i])
+							end
+						end
+					  end
+					  return 0`
+
+	s := &RedisStore{
+		ctx:           context.Background(),
+		rc:            rc,
+		keys:          newRedisStoreKeys(rc, conf.KeyPrefix),
+		unlockScript:  redis.NewScript(unlockScript),
+		storeScript:   redis.NewScript(storeScript),
+		updateScript:  redis.NewScript(updateScript),
+		acquireScript: redis.NewScript(acquireScript),
+		releaseScript: redis.NewScript(releaseScript),
+	}
+	if conf.ParticipantFlushInterval > 0 {
+		s.participantWrites = newParticipantWriteBatch()
+		go s.participantFlushWorker(conf.ParticipantFlushInterval)
 	}
+	return s
 }
 
 func (s *RedisStore) Start() error {
@@ -87,7 +273,7 @@ func (s *RedisStore) Start() error {
 
 	s.done = make(chan struct{}, 1)
 
-	v, err := s.rc.Get(s.ctx, VersionKey).Result()
+	v, err := s.rc.Get(s.ctx, s.keys.key(VersionKey)).Result()
 	if err != nil && err != redis.Nil {
 		return err
 	}
@@ -97,7 +283,7 @@ func (s *RedisStore) Start() error {
 	existing, _ := goversion.NewVersion(v)
 	current, _ := goversion.NewVersion(version.Version)
 	if current.GreaterThan(existing) {
-		if err = s.rc.Set(s.ctx, VersionKey, version.Version, 0).Err(); err != nil {
+		if err = s.rc.Set(s.ctx, s.keys.key(VersionKey), version.Version, 0).Err(); err != nil {
 			return err
 		}
 	}
@@ -107,6 +293,13 @@ func (s *RedisStore) Start() error {
 }
 
 func (s *RedisStore) Stop() {
+	if s.participantWrites != nil {
+		s.participantWrites.stop()
+		if err := s.flushParticipantWrites(); err != nil {
+			logger.Errorw("could not flush participant writes", err)
+		}
+	}
+
 	select {
 	case <-s.done:
 	default:
@@ -124,31 +317,34 @@ func (s *RedisStore) StoreRoom(_ context.Context, room *livekit.Room, internal *
 		return err
 	}
 
-	pp := s.rc.Pipeline()
-	pp.HSet(s.ctx, RoomsKey, room.Name, roomData)
-
 	var internalData []byte
+	hasInternal := "0"
 	if internal != nil {
 		internalData, err = proto.Marshal(internal)
 		if err != nil {
 			return err
 		}
-		pp.HSet(s.ctx, RoomInternalKey, room.Name, internalData)
-	} else {
-		pp.HDel(s.ctx, RoomInternalKey, room.Name)
+		hasInternal = "1"
 	}
 
-	if _, err = pp.Exec(s.ctx); err != nil {
+	keys, args := s.keys.roomFields(room.Name, s.keys.rooms, s.keys.roomVersions, s.keys.roomMetadata, s.keys.roomInternal)
+	args = append(args, roomData, room.Metadata, internalData, hasInternal)
+	if err = s.storeScript.Run(s.ctx, s.rc, keys, args...).Err(); err != nil {
 		return errors.Wrap(err, "could not create room")
 	}
+	if s.keys.cluster {
+		if err = s.rc.SAdd(s.ctx, s.keys.roomNames, room.Name).Err(); err != nil {
+			return errors.Wrap(err, "could not create room")
+		}
+	}
 	return nil
 }
 
 func (s *RedisStore) LoadRoom(_ context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
 	pp := s.rc.Pipeline()
-	pp.HGet(s.ctx, RoomsKey, string(roomName))
+	pp.HGet(s.ctx, s.keys.roomField(s.keys.rooms, string(roomName)))
 	if includeInternal {
-		pp.HGet(s.ctx, RoomInternalKey, string(roomName))
+		pp.HGet(s.ctx, s.keys.roomField(s.keys.roomInternal, string(roomName)))
 	}
 
 	res, err := pp.Exec(s.ctx)
@@ -188,16 +384,21 @@ func (s *RedisStore) LoadRoom(_ context.Context, roomName livekit.RoomName, incl
 func (s *RedisStore) ListRooms(_ context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
 	var items []string
 	var err error
-	if roomNames == nil {
-		items, err = s.rc.HVals(s.ctx, RoomsKey).Result()
+	if roomNames == nil && !s.keys.cluster {
+		items, err = s.rc.HVals(s.ctx, s.keys.rooms).Result()
 		if err != nil && err != redis.Nil {
 			return nil, errors.Wrap(err, "could not get rooms")
 		}
 	} else {
 		names := livekit.IDsAsStrings(roomNames)
+		if roomNames == nil {
+			if names, err = s.rc.SMembers(s.ctx, s.keys.roomNames).Result(); err != nil {
+				return nil, errors.Wrap(err, "could not get rooms")
+			}
+		}
 		var results []interface{}
-		results, err = s.rc.HMGet(s.ctx, RoomsKey, names...).Result()
-		if err != nil && err != redis.Nil {
+		results, err = s.loadRoomValues(s.keys.rooms, names)
+		if err != nil {
 			return nil, errors.Wrap(err, "could not get rooms by names")
 		}
 		for _, r := range results {
@@ -220,24 +421,515 @@ func (s *RedisStore) ListRooms(_ context.Context, roomNames []livekit.RoomName)
 	return rooms, nil
 }
 
+// ListRoomsPage filters rooms after loading them, rooms hash is not ordered
+func (s *RedisStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	rooms, err := s.ListRooms(ctx, opts.Names)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var labels map[livekit.RoomName]RoomLabels
+	if len(opts.LabelSelector) > 0 && len(rooms) > 0 {
+		names := make([]string, 0, len(rooms))
+		for _, room := range rooms {
+			names = append(names, room.Name)
+		}
+		results, err := s.loadRoomValues(s.keys.roomLabels, names)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "could not get room labels")
+		}
+		labels = make(map[livekit.RoomName]RoomLabels, len(results))
+		for i, r := range results {
+			if item, ok := r.(string); ok {
+				if labels[livekit.RoomName(names[i])], err = UnmarshalRoomLabels([]byte(item)); err != nil {
+					return nil, "", err
+				}
+			}
+		}
+	}
+	return pageRooms(rooms, labels, opts)
+}
+
+// loadRoomValues returns a value of each of the rooms, nil for rooms without the value
+func (s *RedisStore) loadRoomValues(value string, names []string) ([]interface{}, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if !s.keys.cluster {
+		results, err := s.rc.HMGet(s.ctx, value, names...).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	// values of rooms are in different slots
+	pp := s.rc.Pipeline()
+	cmds := make([]*redis.StringCmd, 0, len(names))
+	for _, name := range names {
+		cmds = append(cmds, pp.HGet(s.ctx, s.keys.roomKey(name), value))
+	}
+	if _, err := pp.Exec(s.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	results := make([]interface{}, 0, len(cmds))
+	for _, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil {
+			results = append(results, v)
+		} else {
+			results = append(results, nil)
+		}
+	}
+	return results, nil
+}
+
+func (s *RedisStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	if _, _, err := s.LoadRoom(ctx, roomName, false); err != nil {
+		return nil, err
+	}
+
+	data, err := s.rc.HGet(s.ctx, s.keys.roomField(s.keys.roomLabels, string(roomName))).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	return UnmarshalRoomLabels([]byte(data))
+}
+
+func (s *RedisStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	if _, _, err := s.LoadRoom(ctx, roomName, false); err != nil {
+		return err
+	}
+
+	data, err := labels.Marshal()
+	if err != nil {
+		return err
+	}
+	key, field := s.keys.roomField(s.keys.roomLabels, string(roomName))
+	return s.rc.HSet(s.ctx, key, field, data).Err()
+}
+
+func (s *RedisStore) LoadRoomVersion(_ context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	pp := s.rc.Pipeline()
+	roomCmd := pp.HGet(s.ctx, s.keys.roomField(s.keys.rooms, string(roomName)))
+	versionCmd := pp.HGet(s.ctx, s.keys.roomField(s.keys.roomVersions, string(roomName)))
+	if _, err := pp.Exec(s.ctx); err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+
+	roomData, err := roomCmd.Result()
+	if err == redis.Nil {
+		return nil, 0, ErrRoomNotFound
+	} else if err != nil {
+		return nil, 0, err
+	}
+	room := &livekit.Room{}
+	if err = proto.Unmarshal([]byte(roomData), room); err != nil {
+		return nil, 0, err
+	}
+
+	// rooms stored before versioning have no version
+	version, err := versionCmd.Int64()
+	if err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+	return room, version, nil
+}
+
+func (s *RedisStore) UpdateRoom(_ context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, args := s.keys.roomFields(room.Name, s.keys.rooms, s.keys.roomVersions, s.keys.roomMetadata)
+	args = append(args, expectedVersion, roomData, room.Metadata)
+	version, err := s.updateScript.Run(s.ctx, s.rc, keys, args...).Int64()
+	if err != nil {
+		return 0, err
+	}
+	switch version {
+	case -1:
+		return 0, ErrRoomNotFound
+	case -2:
+		return 0, ErrRoomVersionConflict
+	}
+	return version, nil
+}
+
 func (s *RedisStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	// pending writes would recreate participants of the room
+	if err := s.flushParticipantWrites(); err != nil {
+		return err
+	}
 	_, _, err := s.LoadRoom(ctx, roomName, false)
 	if err == ErrRoomNotFound {
 		return nil
 	}
+	if err = s.releaseRoomKeyUsage(ctx, roomName); err != nil {
+		return err
+	}
 
 	pp := s.rc.Pipeline()
-	pp.HDel(s.ctx, RoomsKey, string(roomName))
-	pp.HDel(s.ctx, RoomInternalKey, string(roomName))
-	pp.Del(s.ctx, RoomParticipantsPrefix+string(roomName))
+	s.deleteRoom(pp, string(roomName))
+	pp.Del(s.ctx, s.keys.roomParticipants(string(roomName)))
+
+	_, err = pp.Exec(s.ctx)
+	return err
+}
+
+func (s *RedisStore) DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	if err := s.flushParticipantWrites(); err != nil {
+		return err
+	}
+	if len(roomNames) == 0 {
+		return nil
+	}
+	for _, roomName := range roomNames {
+		if err := s.releaseRoomKeyUsage(ctx, roomName); err != nil {
+			return err
+		}
+	}
+
+	// MULTI/EXEC, other clients find either all of the rooms or none of them. in a cluster, a transaction is only
+	// across the keys of a slot, the values of a room are deleted together
+	pp := s.rc.TxPipeline()
+	for _, roomName := range roomNames {
+		s.deleteRoom(pp, string(roomName))
+		pp.Del(s.ctx, s.keys.roomParticipants(string(roomName)))
+	}
+
+	_, err := pp.Exec(s.ctx)
+	return err
+}
+
+// deleteRoom deletes the values of the room, not its participants
+func (s *RedisStore) deleteRoom(pp redis.Pipeliner, roomName string) {
+	if s.keys.cluster {
+		pp.Del(s.ctx, s.keys.roomKey(roomName))
+		pp.SRem(s.ctx, s.keys.roomNames, roomName)
+		return
+	}
+	for _, value := range []string{s.keys.rooms, s.keys.roomInternal, s.keys.roomLabels, s.keys.roomVersions, s.keys.roomMetadata} {
+		pp.HDel(s.ctx, value, roomName)
+	}
+}
+
+func (s *RedisStore) StoreParticipantSession(_ context.Context, session *ParticipantSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// sessions expire once the participant has left
+	var expiration time.Duration
+	if !session.IsActive() {
+		expiration = participantSessionRetention
+	}
+	roomKey := s.keys.key(RoomParticipantSessionsPrefix) + string(session.RoomName)
+
+	pp := s.rc.Pipeline()
+	pp.Set(s.ctx, s.keys.key(ParticipantSessionPrefix)+string(session.ParticipantID), data, expiration)
+	pp.SAdd(s.ctx, roomKey, string(session.ParticipantID))
+	if expiration > 0 {
+		pp.Expire(s.ctx, roomKey, expiration)
+	} else {
+		pp.Persist(s.ctx, roomKey)
+	}
+	_, err = pp.Exec(s.ctx)
+	return err
+}
+
+func (s *RedisStore) LoadParticipantSession(_ context.Context, participantID livekit.ParticipantID) (*ParticipantSession, error) {
+	data, err := s.rc.Get(s.ctx, s.keys.key(ParticipantSessionPrefix)+string(participantID)).Result()
+	if err == redis.Nil {
+		return nil, ErrParticipantNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	session := &ParticipantSession{}
+	if err = json.Unmarshal([]byte(data), session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *RedisStore) ListParticipantSessions(_ context.Context, roomName livekit.RoomName, active bool) ([]*ParticipantSession, error) {
+	roomKey := s.keys.key(RoomParticipantSessionsPrefix) + string(roomName)
+	participantIDs, err := s.rc.SMembers(s.ctx, roomKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if len(participantIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(participantIDs))
+	for _, participantID := range participantIDs {
+		keys = append(keys, s.keys.key(ParticipantSessionPrefix)+participantID)
+	}
+	results, err := s.rc.MGet(s.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
 
+	var sessions []*ParticipantSession
+	var expired []interface{}
+	for i, r := range results {
+		data, ok := r.(string)
+		if !ok {
+			expired = append(expired, participantIDs[i])
+			continue
+		}
+		session := &ParticipantSession{}
+		if err = json.Unmarshal([]byte(data), session); err != nil {
+			return nil, err
+		}
+		if !active || session.IsActive() {
+			sessions = append(sessions, session)
+		}
+	}
+	if len(expired) > 0 {
+		s.rc.SRem(s.ctx, roomKey, expired...)
+	}
+	return sessions, nil
+}
+
+func (s *RedisStore) StoreRoomHistory(_ context.Context, history *RoomHistory, retention time.Duration) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	member := redis.Z{Score: float64(history.EndedAt.UnixMilli()), Member: history.Sid}
+	cutoff := "(" + strconv.FormatInt(time.Now().Add(-retention).UnixMilli(), 10)
+	roomKey := s.keys.key(RoomHistoryRoomPrefix) + history.Name
+
+	pp := s.rc.Pipeline()
+	pp.Set(s.ctx, s.keys.key(RoomHistoryPrefix)+history.Sid, data, retention)
+	pp.ZAdd(s.ctx, s.keys.key(RoomHistoryKey), member)
+	pp.ZRemRangeByScore(s.ctx, s.keys.key(RoomHistoryKey), "-inf", cutoff)
+	pp.ZAdd(s.ctx, roomKey, member)
+	pp.ZRemRangeByScore(s.ctx, roomKey, "-inf", cutoff)
+	pp.Expire(s.ctx, roomKey, retention)
 	_, err = pp.Exec(s.ctx)
 	return err
 }
 
+func (s *RedisStore) ListRoomHistory(_ context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error) {
+	key := s.keys.key(RoomHistoryKey)
+	if roomName != "" {
+		key = s.keys.key(RoomHistoryRoomPrefix) + string(roomName)
+	}
+	sids, err := s.rc.ZRevRange(s.ctx, key, 0, int64(limit-1)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if len(sids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(sids))
+	for _, sid := range sids {
+		keys = append(keys, s.keys.key(RoomHistoryPrefix)+sid)
+	}
+	results, err := s.rc.MGet(s.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*RoomHistory, 0, len(results))
+	for _, r := range results {
+		// records expire before they are removed from the sorted sets
+		data, ok := r.(string)
+		if !ok {
+			continue
+		}
+		h := &RoomHistory{}
+		if err = json.Unmarshal([]byte(data), h); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
+func (s *RedisStore) StoreRoomTemplate(_ context.Context, template *RoomTemplate) error {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	return s.rc.HSet(s.ctx, s.keys.key(RoomTemplatesKey), template.Name, data).Err()
+}
+
+func (s *RedisStore) LoadRoomTemplate(_ context.Context, name string) (*RoomTemplate, error) {
+	data, err := s.rc.HGet(s.ctx, s.keys.key(RoomTemplatesKey), name).Result()
+	if err == redis.Nil {
+		return nil, ErrRoomTemplateNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	template := &RoomTemplate{}
+	if err = json.Unmarshal([]byte(data), template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *RedisStore) ListRoomTemplates(_ context.Context) ([]*RoomTemplate, error) {
+	items, err := s.rc.HVals(s.ctx, s.keys.key(RoomTemplatesKey)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	templates := make([]*RoomTemplate, 0, len(items))
+	for _, data := range items {
+		template := &RoomTemplate{}
+		if err = json.Unmarshal([]byte(data), template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+func (s *RedisStore) DeleteRoomTemplate(_ context.Context, name string) error {
+	deleted, err := s.rc.HDel(s.ctx, s.keys.key(RoomTemplatesKey), name).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrRoomTemplateNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) ReserveIdempotencyKey(_ context.Context, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	key := s.keys.key(IdempotencyKeyPrefix) + record.Key
+	for i := 0; i < maxRetries; i++ {
+		reserved, err := s.rc.SetNX(s.ctx, key, data, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := s.rc.Get(s.ctx, key).Result()
+		if err == redis.Nil {
+			// expired since, reserved again
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		r := &IdempotencyRecord{}
+		if err = json.Unmarshal([]byte(existing), r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	return nil, ErrOperationFailed
+}
+
+func (s *RedisStore) StoreIdempotencyRecord(_ context.Context, record *IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.rc.SetXX(s.ctx, s.keys.key(IdempotencyKeyPrefix)+record.Key, data, redis.KeepTTL).Err()
+}
+
+func (s *RedisStore) DeleteIdempotencyRecord(_ context.Context, key string) error {
+	return s.rc.Del(s.ctx, s.keys.key(IdempotencyKeyPrefix)+key).Err()
+}
+
+func (s *RedisStore) RevokeToken(_ context.Context, tokenID string, ttl time.Duration) error {
+	return s.rc.Set(s.ctx, s.keys.key(RevokedTokenPrefix)+tokenID, 1, ttl).Err()
+}
+
+func (s *RedisStore) RevokeIdentityTokens(_ context.Context, identity string, revokedAt time.Time, ttl time.Duration) error {
+	return s.rc.Set(s.ctx, s.keys.key(RevokedIdentityPrefix)+identity, revokedAt.Unix(), ttl).Err()
+}
+
+func (s *RedisStore) IsTokenRevoked(_ context.Context, tokenID string, identity string, issuedAt time.Time) (bool, error) {
+	var tokenRevoked *redis.IntCmd
+	var identityRevokedAt *redis.StringCmd
+	_, err := s.rc.Pipelined(s.ctx, func(p redis.Pipeliner) error {
+		if tokenID != "" {
+			tokenRevoked = p.Exists(s.ctx, s.keys.key(RevokedTokenPrefix)+tokenID)
+		}
+		identityRevokedAt = p.Get(s.ctx, s.keys.key(RevokedIdentityPrefix)+identity)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+	if tokenRevoked != nil && tokenRevoked.Val() > 0 {
+		return true, nil
+	}
+
+	revokedAt, err := identityRevokedAt.Int64()
+	if err == redis.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return !issuedAt.After(time.Unix(revokedAt, 0)), nil
+}
+
+func (s *RedisStore) AcquireKeyUsage(_ context.Context, apiKey string, quota string, roomName livekit.RoomName, member string, limit int) (int, bool, error) {
+	res, err := s.acquireScript.Run(s.ctx, s.rc, []string{s.keys.keyUsage(apiKey, quota)}, member, string(roomName), limit).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	return int(res[0]), res[1] == 1, nil
+}
+
+func (s *RedisStore) ReleaseKeyUsage(_ context.Context, apiKey string, quota string, member string) error {
+	return s.rc.HDel(s.ctx, s.keys.keyUsage(apiKey, quota), member).Err()
+}
+
+// releaseRoomKeyUsage releases the members of the room from the quotas of the key of the room, as it is deleted
+func (s *RedisStore) releaseRoomKeyUsage(ctx context.Context, roomName livekit.RoomName) error {
+	apiKey, err := s.loadRoomAPIKey(ctx, roomName)
+	if err != nil || apiKey == "" {
+		return err
+	}
+	keys := make([]string, 0, len(keyQuotas))
+	for _, quota := range keyQuotas {
+		keys = append(keys, s.keys.keyUsage(apiKey, quota))
+	}
+	return s.releaseScript.Run(s.ctx, s.rc, keys, string(roomName)).Err()
+}
+
+// releaseRoomMemberKeyUsage releases a member of the room from the quota of the key of the room
+func (s *RedisStore) releaseRoomMemberKeyUsage(ctx context.Context, roomName livekit.RoomName, quota string, member string) error {
+	apiKey, err := s.loadRoomAPIKey(ctx, roomName)
+	if err != nil || apiKey == "" {
+		return err
+	}
+	return s.ReleaseKeyUsage(ctx, apiKey, quota, member)
+}
+
+// loadRoomAPIKey returns the API key the room counts against, empty when the key has no quotas or the room is deleted
+func (s *RedisStore) loadRoomAPIKey(ctx context.Context, roomName livekit.RoomName) (string, error) {
+	labels, err := s.LoadRoomLabels(ctx, roomName)
+	if err == ErrRoomNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return labels[roomAPIKeyLabel], nil
+}
+
 func (s *RedisStore) LockRoom(_ context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
 	token := utils.NewGuid("LOCK")
-	key := RoomLockPrefix + string(roomName)
+	key := s.keys.key(RoomLockPrefix) + string(roomName)
 
 	startTime := time.Now()
 	for {
@@ -261,7 +953,7 @@ func (s *RedisStore) LockRoom(_ context.Context, roomName livekit.RoomName, dura
 }
 
 func (s *RedisStore) UnlockRoom(_ context.Context, roomName livekit.RoomName, uid string) error {
-	key := RoomLockPrefix + string(roomName)
+	key := s.keys.key(RoomLockPrefix) + string(roomName)
 	res, err := s.unlockScript.Run(s.ctx, s.rc, []string{key}, uid).Result()
 	if err != nil {
 		return err
@@ -276,18 +968,26 @@ func (s *RedisStore) UnlockRoom(_ context.Context, roomName livekit.RoomName, ui
 }
 
 func (s *RedisStore) StoreParticipant(_ context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
-	key := RoomParticipantsPrefix + string(roomName)
+	key := s.keys.roomParticipants(string(roomName))
 
 	data, err := proto.Marshal(participant)
 	if err != nil {
 		return err
 	}
 
+	if s.participantWrites != nil {
+		s.participantWrites.add(key, livekit.ParticipantIdentity(participant.Identity), data)
+		return nil
+	}
 	return s.rc.HSet(s.ctx, key, participant.Identity, data).Err()
 }
 
 func (s *RedisStore) LoadParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
-	key := RoomParticipantsPrefix + string(roomName)
+	if err := s.flushParticipantWrites(); err != nil {
+		return nil, err
+	}
+
+	key := s.keys.roomParticipants(string(roomName))
 	data, err := s.rc.HGet(s.ctx, key, string(identity)).Result()
 	if err == redis.Nil {
 		return nil, ErrParticipantNotFound
@@ -303,7 +1003,11 @@ func (s *RedisStore) LoadParticipant(_ context.Context, roomName livekit.RoomNam
 }
 
 func (s *RedisStore) ListParticipants(_ context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
-	key := RoomParticipantsPrefix + string(roomName)
+	if err := s.flushParticipantWrites(); err != nil {
+		return nil, err
+	}
+
+	key := s.keys.roomParticipants(string(roomName))
 	items, err := s.rc.HVals(s.ctx, key).Result()
 	if err == redis.Nil {
 		return nil, nil
@@ -322,12 +1026,55 @@ func (s *RedisStore) ListParticipants(_ context.Context, roomName livekit.RoomNa
 	return participants, nil
 }
 
-func (s *RedisStore) DeleteParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
-	key := RoomParticipantsPrefix + string(roomName)
+func (s *RedisStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	if err := s.releaseRoomMemberKeyUsage(ctx, roomName, keyQuotaParticipants, participantUsageMember(roomName, identity)); err != nil {
+		return err
+	}
+	key := s.keys.roomParticipants(string(roomName))
 
+	if s.participantWrites != nil {
+		s.participantWrites.add(key, identity, nil)
+		return nil
+	}
 	return s.rc.HDel(s.ctx, key, string(identity)).Err()
 }
 
+func (s *RedisStore) participantFlushWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flushParticipantWrites(); err != nil {
+				logger.Errorw("could not flush participant writes", err)
+			}
+		case <-s.participantWrites.done:
+			return
+		}
+	}
+}
+
+// flushParticipantWrites writes pending participant changes in one pipeline. Reads flush first, for
+// participants to be read as they were last written
+func (s *RedisStore) flushParticipantWrites() error {
+	if s.participantWrites == nil {
+		return nil
+	}
+	return s.participantWrites.flush(func(writes map[participantWriteKey][]byte) error {
+		pp := s.rc.Pipeline()
+		for k, data := range writes {
+			if data == nil {
+				pp.HDel(s.ctx, k.key, string(k.identity))
+			} else {
+				pp.HSet(s.ctx, k.key, string(k.identity), data)
+			}
+		}
+		_, err := pp.Exec(s.ctx)
+		return err
+	})
+}
+
 func (s *RedisStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) error {
 	data, err := proto.Marshal(info)
 	if err != nil {
@@ -335,8 +1082,8 @@ func (s *RedisStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) er
 	}
 
 	pp := s.rc.Pipeline()
-	pp.HSet(s.ctx, EgressKey, info.EgressId, data)
-	pp.SAdd(s.ctx, RoomEgressPrefix+info.RoomName, info.EgressId)
+	pp.HSet(s.ctx, s.keys.key(EgressKey), info.EgressId, data)
+	pp.SAdd(s.ctx, s.keys.key(RoomEgressPrefix)+info.RoomName, info.EgressId)
 	if _, err = pp.Exec(s.ctx); err != nil {
 		return errors.Wrap(err, "could not store egress info")
 	}
@@ -345,7 +1092,7 @@ func (s *RedisStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) er
 }
 
 func (s *RedisStore) LoadEgress(_ context.Context, egressID string) (*livekit.EgressInfo, error) {
-	data, err := s.rc.HGet(s.ctx, EgressKey, egressID).Result()
+	data, err := s.rc.HGet(s.ctx, s.keys.key(EgressKey), egressID).Result()
 	switch err {
 	case nil:
 		info := &livekit.EgressInfo{}
@@ -367,7 +1114,7 @@ func (s *RedisStore) ListEgress(_ context.Context, roomName livekit.RoomName, ac
 	var infos []*livekit.EgressInfo
 
 	if roomName == "" {
-		data, err := s.rc.HGetAll(s.ctx, EgressKey).Result()
+		data, err := s.rc.HGetAll(s.ctx, s.keys.key(EgressKey)).Result()
 		if err != nil {
 			if err == redis.Nil {
 				return nil, nil
@@ -388,7 +1135,7 @@ func (s *RedisStore) ListEgress(_ context.Context, roomName livekit.RoomName, ac
 			}
 		}
 	} else {
-		egressIDs, err := s.rc.SMembers(s.ctx, RoomEgressPrefix+string(roomName)).Result()
+		egressIDs, err := s.rc.SMembers(s.ctx, s.keys.key(RoomEgressPrefix)+string(roomName)).Result()
 		if err != nil {
 			if err == redis.Nil {
 				return nil, nil
@@ -396,7 +1143,7 @@ func (s *RedisStore) ListEgress(_ context.Context, roomName livekit.RoomName, ac
 			return nil, err
 		}
 
-		data, _ := s.rc.HMGet(s.ctx, EgressKey, egressIDs...).Result()
+		data, _ := s.rc.HMGet(s.ctx, s.keys.key(EgressKey), egressIDs...).Result()
 		for _, d := range data {
 			if d == nil {
 				continue
@@ -417,19 +1164,22 @@ func (s *RedisStore) ListEgress(_ context.Context, roomName livekit.RoomName, ac
 	return infos, nil
 }
 
-func (s *RedisStore) UpdateEgress(_ context.Context, info *livekit.EgressInfo) error {
+func (s *RedisStore) UpdateEgress(ctx context.Context, info *livekit.EgressInfo) error {
 	data, err := proto.Marshal(info)
 	if err != nil {
 		return err
 	}
 
 	if info.EndedAt != 0 {
+		if err = s.releaseRoomMemberKeyUsage(ctx, livekit.RoomName(info.RoomName), keyQuotaEgress, info.EgressId); err != nil {
+			return err
+		}
 		pp := s.rc.Pipeline()
-		pp.HSet(s.ctx, EgressKey, info.EgressId, data)
-		pp.HSet(s.ctx, EndedEgressKey, info.EgressId, egressEndedValue(info.RoomName, info.EndedAt))
+		pp.HSet(s.ctx, s.keys.key(EgressKey), info.EgressId, data)
+		pp.HSet(s.ctx, s.keys.key(EndedEgressKey), info.EgressId, egressEndedValue(info.RoomName, info.EndedAt))
 		_, err = pp.Exec(s.ctx)
 	} else {
-		err = s.rc.HSet(s.ctx, EgressKey, info.EgressId, data).Err()
+		err = s.rc.HSet(s.ctx, s.keys.key(EgressKey), info.EgressId, data).Err()
 	}
 
 	if err != nil {
@@ -458,7 +1208,7 @@ func (s *RedisStore) egressWorker() {
 }
 
 func (s *RedisStore) CleanEndedEgress() error {
-	values, err := s.rc.HGetAll(s.ctx, EndedEgressKey).Result()
+	values, err := s.rc.HGetAll(s.ctx, s.keys.key(EndedEgressKey)).Result()
 	if err != nil && err != redis.Nil {
 		return err
 	}
@@ -472,10 +1222,10 @@ func (s *RedisStore) CleanEndedEgress() error {
 
 		if endedAt < expiry {
 			pp := s.rc.Pipeline()
-			pp.SRem(s.ctx, RoomEgressPrefix+roomName, egressID)
-			pp.HDel(s.ctx, EgressKey, egressID)
+			pp.SRem(s.ctx, s.keys.key(RoomEgressPrefix)+roomName, egressID)
+			pp.HDel(s.ctx, s.keys.key(EgressKey), egressID)
 			// Delete the EndedEgressKey entry last so that future sweeper runs get another chance to delete dangling data is the deletion partially failed.
-			pp.HDel(s.ctx, EndedEgressKey, egressID)
+			pp.HDel(s.ctx, s.keys.key(EndedEgressKey), egressID)
 			if _, err := pp.Exec(s.ctx); err != nil {
 				return err
 			}
@@ -542,17 +1292,17 @@ func (s *RedisStore) storeIngress(_ context.Context, info *livekit.IngressInfo)
 		}
 
 		results, err := tx.TxPipelined(s.ctx, func(p redis.Pipeliner) error {
-			p.HSet(s.ctx, IngressKey, info.IngressId, data)
+			p.HSet(s.ctx, s.keys.ingress, info.IngressId, data)
 			if info.StreamKey != "" {
-				p.HSet(s.ctx, StreamKeyKey, info.StreamKey, info.IngressId)
+				p.HSet(s.ctx, s.keys.key(StreamKeyKey), info.StreamKey, info.IngressId)
 			}
 
 			if oldRoom != info.RoomName {
 				if oldRoom != "" {
-					p.SRem(s.ctx, RoomIngressPrefix+oldRoom, info.IngressId)
+					p.SRem(s.ctx, s.keys.key(RoomIngressPrefix)+oldRoom, info.IngressId)
 				}
 				if info.RoomName != "" {
-					p.SAdd(s.ctx, RoomIngressPrefix+info.RoomName, info.IngressId)
+					p.SAdd(s.ctx, s.keys.key(RoomIngressPrefix)+info.RoomName, info.IngressId)
 				}
 			}
 
@@ -574,7 +1324,7 @@ func (s *RedisStore) storeIngress(_ context.Context, info *livekit.IngressInfo)
 
 	// Retry if the key has been changed.
 	for i := 0; i < maxRetries; i++ {
-		err := s.rc.Watch(s.ctx, txf, IngressKey)
+		err := s.rc.Watch(s.ctx, txf, s.keys.ingress)
 		switch err {
 		case redis.TxFailedErr:
 			// Optimistic lock lost. Retry.
@@ -621,7 +1371,7 @@ func (s *RedisStore) storeIngressState(_ context.Context, ingressId string, stat
 				return ingress.ErrIngressOutOfDate
 			}
 
-			p.Set(s.ctx, IngressStatePrefix+ingressId, data, 0)
+			p.Set(s.ctx, s.keys.key(IngressStatePrefix)+ingressId, data, 0)
 
 			return nil
 		})
@@ -641,7 +1391,7 @@ func (s *RedisStore) storeIngressState(_ context.Context, ingressId string, stat
 
 	// Retry if the key has been changed.
 	for i := 0; i < maxRetries; i++ {
-		err := s.rc.Watch(s.ctx, txf, IngressStatePrefix+ingressId)
+		err := s.rc.Watch(s.ctx, txf, s.keys.key(IngressStatePrefix)+ingressId)
 		switch err {
 		case redis.TxFailedErr:
 			// Optimistic lock lost. Retry.
@@ -655,7 +1405,7 @@ func (s *RedisStore) storeIngressState(_ context.Context, ingressId string, stat
 }
 
 func (s *RedisStore) loadIngress(c redis.Cmdable, ingressId string) (*livekit.IngressInfo, error) {
-	data, err := c.HGet(s.ctx, IngressKey, ingressId).Result()
+	data, err := c.HGet(s.ctx, s.keys.ingress, ingressId).Result()
 	switch err {
 	case nil:
 		info := &livekit.IngressInfo{}
@@ -674,7 +1424,7 @@ func (s *RedisStore) loadIngress(c redis.Cmdable, ingressId string) (*livekit.In
 }
 
 func (s *RedisStore) loadIngressState(c redis.Cmdable, ingressId string) (*livekit.IngressState, error) {
-	data, err := c.Get(s.ctx, IngressStatePrefix+ingressId).Result()
+	data, err := c.Get(s.ctx, s.keys.key(IngressStatePrefix)+ingressId).Result()
 	switch err {
 	case nil:
 		state := &livekit.IngressState{}
@@ -711,7 +1461,7 @@ func (s *RedisStore) LoadIngress(_ context.Context, ingressId string) (*livekit.
 }
 
 func (s *RedisStore) LoadIngressFromStreamKey(_ context.Context, streamKey string) (*livekit.IngressInfo, error) {
-	ingressID, err := s.rc.HGet(s.ctx, StreamKeyKey, streamKey).Result()
+	ingressID, err := s.rc.HGet(s.ctx, s.keys.key(StreamKeyKey), streamKey).Result()
 	switch err {
 	case nil:
 		return s.LoadIngress(s.ctx, ingressID)
@@ -728,7 +1478,7 @@ func (s *RedisStore) ListIngress(_ context.Context, roomName livekit.RoomName) (
 	var infos []*livekit.IngressInfo
 
 	if roomName == "" {
-		data, err := s.rc.HGetAll(s.ctx, IngressKey).Result()
+		data, err := s.rc.HGetAll(s.ctx, s.keys.ingress).Result()
 		if err != nil {
 			if err == redis.Nil {
 				return nil, nil
@@ -755,7 +1505,7 @@ func (s *RedisStore) ListIngress(_ context.Context, roomName livekit.RoomName) (
 			infos = append(infos, info)
 		}
 	} else {
-		ingressIDs, err := s.rc.SMembers(s.ctx, RoomIngressPrefix+string(roomName)).Result()
+		ingressIDs, err := s.rc.SMembers(s.ctx, s.keys.key(RoomIngressPrefix)+string(roomName)).Result()
 		if err != nil {
 			if err == redis.Nil {
 				return nil, nil
@@ -763,7 +1513,7 @@ func (s *RedisStore) ListIngress(_ context.Context, roomName livekit.RoomName) (
 			return nil, err
 		}
 
-		data, _ := s.rc.HMGet(s.ctx, IngressKey, ingressIDs...).Result()
+		data, _ := s.rc.HMGet(s.ctx, s.keys.ingress, ingressIDs...).Result()
 		for _, d := range data {
 			if d == nil {
 				continue
@@ -800,12 +1550,12 @@ func (s *RedisStore) UpdateIngressState(ctx context.Context, ingressId string, s
 
 func (s *RedisStore) DeleteIngress(_ context.Context, info *livekit.IngressInfo) error {
 	tx := s.rc.TxPipeline()
-	tx.SRem(s.ctx, RoomIngressPrefix+info.RoomName, info.IngressId)
+	tx.SRem(s.ctx, s.keys.key(RoomIngressPrefix)+info.RoomName, info.IngressId)
 	if info.StreamKey != "" {
-		tx.HDel(s.ctx, StreamKeyKey, info.StreamKey)
+		tx.HDel(s.ctx, s.keys.key(StreamKeyKey), info.StreamKey)
 	}
-	tx.HDel(s.ctx, IngressKey, info.IngressId)
-	tx.Del(s.ctx, IngressStatePrefix+info.IngressId)
+	tx.HDel(s.ctx, s.keys.ingress, info.IngressId)
+	tx.Del(s.ctx, s.keys.key(IngressStatePrefix)+info.IngressId)
 	if _, err := tx.Exec(s.ctx); err != nil {
 		return errors.Wrap(err, "could not delete ingress info")
 	}
@@ -0,0 +1,64 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStoreKeys(t *testing.T) {
+	// keys are unchanged on a single instance
+	rc := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer rc.Close()
+	keys := newRedisStoreKeys(rc, "")
+	require.Equal(t, RoomsKey, keys.rooms)
+	require.Equal(t, RoomVersionsKey, keys.roomVersions)
+	require.Equal(t, RoomParticipantsPrefix+"room", keys.roomParticipants("room"))
+	key, field := keys.roomField(keys.roomLabels, "room")
+	require.Equal(t, RoomLabelsKey, key)
+	require.Equal(t, "room", field)
+
+	// in a cluster, keys of a room hash to a slot of the room
+	cc := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"localhost:7000"}})
+	defer cc.Close()
+	keys = newRedisStoreKeys(cc, "")
+	for _, room := range []string{"room1", "room2"} {
+		roomKeys, fields := keys.roomFields(room, keys.rooms, keys.roomInternal, keys.roomLabels, keys.roomVersions, keys.roomMetadata)
+		for _, key := range append(roomKeys, keys.roomParticipants(room)) {
+			require.True(t, strings.HasPrefix(key, "{room:"+room+"}"), key)
+		}
+		require.Equal(t, []interface{}{RoomsKey, RoomInternalKey, RoomLabelsKey, RoomVersionsKey, RoomMetadataKey}, fields)
+	}
+	require.Equal(t, "{"+RoomsKey+"}", keys.roomNames)
+
+	// all keys start with the prefix
+	keys = newRedisStoreKeys(rc, "staging:")
+	for _, key := range []string{keys.rooms, keys.roomInternal, keys.roomParticipants("room"), keys.ingress, keys.key(EgressKey)} {
+		require.True(t, strings.HasPrefix(key, "staging:"), key)
+	}
+
+	// and in a cluster, keep hashing to the slot of the tag
+	keys = newRedisStoreKeys(cc, "staging:")
+	require.Equal(t, "staging:{"+RoomsKey+"}", keys.roomNames)
+	require.Equal(t, "staging:{"+IngressKey+"}", keys.ingress)
+	key, field = keys.roomField(keys.roomVersions, "room")
+	require.Equal(t, "staging:{room:room}", key)
+	require.Equal(t, RoomVersionsKey, field)
+	require.Equal(t, "staging:{room:room}:"+RoomParticipantsPrefix, keys.roomParticipants("room"))
+}
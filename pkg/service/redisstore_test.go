@@ -27,12 +27,13 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/service"
 )
 
 func TestRoomInternal(t *testing.T) {
 	ctx := context.Background()
-	rs := service.NewRedisStore(redisClient())
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{})
 
 	room := &livekit.Room{
 		Sid:  "123",
@@ -58,9 +59,47 @@ func TestRoomInternal(t *testing.T) {
 	require.NoError(t, rs.DeleteRoom(ctx, "test_room"))
 }
 
+func TestRoomUpdateVersion(t *testing.T) {
+	ctx := context.Background()
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{})
+
+	room := &livekit.Room{
+		Sid:  "123",
+		Name: "test_room_version",
+	}
+	require.NoError(t, rs.StoreRoom(ctx, room, nil))
+	_, version, err := rs.LoadRoomVersion(ctx, livekit.RoomName(room.Name))
+	require.NoError(t, err)
+
+	room.Metadata = "first"
+	newVersion, err := rs.UpdateRoom(ctx, room, version)
+	require.NoError(t, err)
+	require.Equal(t, version+1, newVersion)
+
+	room.Metadata = "second"
+	_, err = rs.UpdateRoom(ctx, room, version)
+	require.ErrorIs(t, err, service.ErrRoomVersionConflict)
+
+	actualRoom, _, err := rs.LoadRoomVersion(ctx, livekit.RoomName(room.Name))
+	require.NoError(t, err)
+	require.Equal(t, "first", actualRoom.Metadata)
+
+	// writes of the number of participants keep the version
+	actualRoom.NumParticipants = 2
+	require.NoError(t, rs.StoreRoom(ctx, actualRoom, nil))
+	_, version, err = rs.LoadRoomVersion(ctx, livekit.RoomName(room.Name))
+	require.NoError(t, err)
+	require.Equal(t, newVersion, version)
+
+	// clean up
+	require.NoError(t, rs.DeleteRoom(ctx, livekit.RoomName(room.Name)))
+	_, err = rs.UpdateRoom(ctx, room, newVersion)
+	require.ErrorIs(t, err, service.ErrRoomNotFound)
+}
+
 func TestParticipantPersistence(t *testing.T) {
 	ctx := context.Background()
-	rs := service.NewRedisStore(redisClient())
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{})
 
 	roomName := livekit.RoomName("room1")
 	_ = rs.DeleteRoom(ctx, roomName)
@@ -105,9 +144,46 @@ func TestParticipantPersistence(t *testing.T) {
 	require.Equal(t, err, service.ErrParticipantNotFound)
 }
 
+func TestParticipantWriteBatching(t *testing.T) {
+	ctx := context.Background()
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{ParticipantFlushInterval: time.Hour})
+	direct := service.NewRedisStore(redisClient(), config.RedisConfig{})
+
+	roomName := livekit.RoomName("batched_room")
+	_ = direct.DeleteRoom(ctx, roomName)
+
+	for _, metadata := range []string{"first", "second", "last"} {
+		require.NoError(t, rs.StoreParticipant(ctx, roomName, &livekit.ParticipantInfo{Identity: "alice", Metadata: metadata}))
+	}
+	require.NoError(t, rs.StoreParticipant(ctx, roomName, &livekit.ParticipantInfo{Identity: "bob"}))
+	require.NoError(t, rs.DeleteParticipant(ctx, roomName, "bob"))
+
+	// writes are pending until the next flush
+	participants, err := direct.ListParticipants(ctx, roomName)
+	require.NoError(t, err)
+	require.Empty(t, participants)
+
+	// reads flush pending writes, only the last write of each participant is kept
+	participants, err = rs.ListParticipants(ctx, roomName)
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+	require.Equal(t, "last", participants[0].Metadata)
+	pGet, err := direct.LoadParticipant(ctx, roomName, "alice")
+	require.NoError(t, err)
+	require.Equal(t, "last", pGet.Metadata)
+
+	// pending writes do not recreate participants of deleted rooms
+	require.NoError(t, rs.StoreRoom(ctx, &livekit.Room{Name: string(roomName)}, nil))
+	require.NoError(t, rs.StoreParticipant(ctx, roomName, &livekit.ParticipantInfo{Identity: "carol"}))
+	require.NoError(t, rs.DeleteRoom(ctx, roomName))
+	participants, err = direct.ListParticipants(ctx, roomName)
+	require.NoError(t, err)
+	require.Empty(t, participants)
+}
+
 func TestRoomLock(t *testing.T) {
 	ctx := context.Background()
-	rs := service.NewRedisStore(redisClient())
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{})
 	lockInterval := 5 * time.Millisecond
 	roomName := livekit.RoomName("myroom")
 
@@ -155,10 +231,53 @@ func TestRoomLock(t *testing.T) {
 	})
 }
 
+func TestKeyUsage(t *testing.T) {
+	ctx := context.Background()
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{})
+	apiKey := "APIusage-" + utils.NewGuid("")
+
+	require.NoError(t, rs.StoreRoom(ctx, &livekit.Room{Name: "usage-room"}, nil))
+	require.NoError(t, rs.StoreRoomLabels(ctx, "usage-room", service.RoomLabels{"livekit.io/api-key": apiKey}))
+	defer rs.DeleteRoom(ctx, "usage-room")
+
+	usage, acquired, err := rs.AcquireKeyUsage(ctx, apiKey, "participants", "usage-room", "usage-room:alice", 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.Equal(t, 1, usage)
+
+	// members that are counted are acquired again
+	usage, acquired, err = rs.AcquireKeyUsage(ctx, apiKey, "participants", "usage-room", "usage-room:alice", 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.Equal(t, 1, usage)
+
+	_, acquired, err = rs.AcquireKeyUsage(ctx, apiKey, "participants", "usage-room", "usage-room:bob", 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	usage, acquired, err = rs.AcquireKeyUsage(ctx, apiKey, "participants", "usage-room", "usage-room:carol", 2)
+	require.NoError(t, err)
+	require.False(t, acquired)
+	require.Equal(t, 2, usage)
+
+	// participants are released as they are deleted
+	require.NoError(t, rs.DeleteParticipant(ctx, "usage-room", "bob"))
+	_, acquired, err = rs.AcquireKeyUsage(ctx, apiKey, "participants", "usage-room", "usage-room:carol", 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// members of the room are released as it is deleted
+	require.NoError(t, rs.DeleteRoom(ctx, "usage-room"))
+	usage, acquired, err = rs.AcquireKeyUsage(ctx, apiKey, "participants", "other-room", "other-room:alice", 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.Equal(t, 1, usage)
+	require.NoError(t, rs.ReleaseKeyUsage(ctx, apiKey, "participants", "other-room:alice"))
+}
+
 func TestEgressStore(t *testing.T) {
 	ctx := context.Background()
 	rc := redisClient()
-	rs := service.NewRedisStore(rc)
+	rs := service.NewRedisStore(rc, config.RedisConfig{})
 
 	roomName := "egress-test"
 
@@ -228,7 +347,7 @@ func TestEgressStore(t *testing.T) {
 
 func TestIngressStore(t *testing.T) {
 	ctx := context.Background()
-	rs := service.NewRedisStore(redisClient())
+	rs := service.NewRedisStore(redisClient(), config.RedisConfig{})
 
 	info := &livekit.IngressInfo{
 		IngressId: "ingressId",
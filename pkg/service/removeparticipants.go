@@ -0,0 +1,176 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	maxRemoveParticipantsIdentities  = 1000
+	maxRemoveParticipantsRequestSize = 256 * 1024
+)
+
+// ParticipantFilter selects the participants of a room to remove. participants are selected when their identity is
+// one of Identities, or when they match all of the set filters of IdentityPrefix and MetadataContains.
+// All selects every participant of the room
+type ParticipantFilter struct {
+	Identities       []string `json:"identities"`
+	IdentityPrefix   string   `json:"identity_prefix"`
+	MetadataContains string   `json:"metadata_contains"`
+	All              bool     `json:"all"`
+}
+
+func (f *ParticipantFilter) validate() error {
+	if len(f.Identities) > maxRemoveParticipantsIdentities {
+		return ErrInvalidRemovalFilter
+	}
+	if !f.All && len(f.Identities) == 0 && f.IdentityPrefix == "" && f.MetadataContains == "" {
+		return ErrInvalidRemovalFilter
+	}
+	return nil
+}
+
+func (f *ParticipantFilter) matches(pi *livekit.ParticipantInfo) bool {
+	if f.All {
+		return true
+	}
+	for _, identity := range f.Identities {
+		if pi.Identity == identity {
+			return true
+		}
+	}
+	if f.IdentityPrefix == "" && f.MetadataContains == "" {
+		return false
+	}
+	return strings.HasPrefix(pi.Identity, f.IdentityPrefix) && strings.Contains(pi.Metadata, f.MetadataContains)
+}
+
+// ParticipantRemovalService removes sets of participants of a room in one request at /remove_participants,
+// such as to clear a room or to evict a cohort of participants.
+// POST {"room": "", "identities": [], "identity_prefix": "", "metadata_contains": "", "all": false} removes the
+// participants selected by the filter and responds with them
+type ParticipantRemovalService struct {
+	roomService *RoomService
+	router      routing.MessageRouter
+	store       ObjectStore
+}
+
+func NewParticipantRemovalService(roomService *RoomService, router routing.MessageRouter, store ObjectStore) *ParticipantRemovalService {
+	return &ParticipantRemovalService{
+		roomService: roomService,
+		router:      router,
+		store:       store,
+	}
+}
+
+// RemoveParticipants removes the participants of the room selected by the filter, and waits until they have left
+func (s *ParticipantRemovalService) RemoveParticipants(ctx context.Context, room string, filter ParticipantFilter) ([]*livekit.ParticipantInfo, error) {
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return nil, err
+		}
+	}
+	roomName := livekit.RoomName(room)
+	AppendLogFields(ctx, "room", roomName)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, err
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	participants, err := s.store.ListParticipants(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]*livekit.ParticipantInfo, 0, len(participants))
+	for _, pi := range participants {
+		if filter.matches(pi) {
+			removed = append(removed, pi)
+		}
+	}
+	AppendLogFields(ctx, "removed", len(removed))
+	if len(removed) == 0 {
+		return removed, nil
+	}
+
+	for _, pi := range removed {
+		err = s.router.WriteParticipantRTC(ctx, roomName, livekit.ParticipantIdentity(pi.Identity), &livekit.RTCNodeMessage{
+			Message: &livekit.RTCNodeMessage_RemoveParticipant{
+				RemoveParticipant: &livekit.RoomParticipantIdentity{
+					Room:     room,
+					Identity: pi.Identity,
+				},
+			},
+		})
+		if err != nil {
+			logger.Warnw("could not remove participant", err, "room", roomName, "participant", pi.Identity)
+			return nil, err
+		}
+	}
+
+	err = s.roomService.confirmExecution(func() error {
+		remaining, err := s.store.ListParticipants(ctx, roomName)
+		if err != nil {
+			return err
+		}
+		for _, pi := range remaining {
+			for _, r := range removed {
+				// a participant that rejoined since is a new session
+				if pi.Sid == r.Sid {
+					return ErrOperationFailed
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+type removeParticipantsRequest struct {
+	ParticipantFilter
+	Room string `json:"room"`
+}
+
+type removeParticipantsResponse struct {
+	Participants []json.RawMessage `json:"participants"`
+}
+
+func (s *ParticipantRemovalService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxRemoveParticipantsRequestSize, func(ctx context.Context, req *removeParticipantsRequest) (interface{}, error) {
+			participants, err := s.RemoveParticipants(ctx, req.Room, req.ParticipantFilter)
+			if err != nil {
+				return nil, err
+			}
+			res := &removeParticipantsResponse{}
+			res.Participants, err = protoJSONList(participants)
+			return res, err
+		}),
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestRemoveParticipants(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "lecture"},
+	})
+
+	store := service.NewLocalStore()
+	storeParticipants := func() {
+		for _, pi := range []*livekit.ParticipantInfo{
+			{Identity: "teacher", Sid: "PA_teacher", Metadata: `{"role":"teacher"}`},
+			{Identity: "student-1", Sid: "PA_student-1", Metadata: `{"role":"student","group":"a"}`},
+			{Identity: "student-2", Sid: "PA_student-2", Metadata: `{"role":"student","group":"b"}`},
+			{Identity: "student-3", Sid: "PA_student-3", Metadata: `{"role":"student","group":"a"}`},
+		} {
+			require.NoError(t, store.StoreParticipant(ctx, "lecture", pi))
+		}
+	}
+	router := &routingfakes.FakeRouter{}
+	router.WriteParticipantRTCCalls(func(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, _ *livekit.RTCNodeMessage) error {
+		return store.DeleteParticipant(ctx, roomName, identity)
+	})
+	roomService, err := service.NewRoomService(config.RoomConfig{}, config.APIConfig{ExecutionTimeout: time.Second, CheckInterval: time.Millisecond},
+		router, &servicefakes.FakeRoomAllocator{}, store, nil, nil)
+	require.NoError(t, err)
+	svc := service.NewParticipantRemovalService(roomService, router, store)
+
+	remove := func(filter service.ParticipantFilter) []string {
+		storeParticipants()
+		removed, err := svc.RemoveParticipants(ctx, "lecture", filter)
+		require.NoError(t, err)
+		identities := make([]string, 0, len(removed))
+		for _, pi := range removed {
+			identities = append(identities, pi.Identity)
+		}
+		sort.Strings(identities)
+
+		remaining, err := store.ListParticipants(ctx, "lecture")
+		require.NoError(t, err)
+		require.Len(t, remaining, 4-len(removed))
+		return identities
+	}
+
+	t.Run("requires admin permission of the room", func(t *testing.T) {
+		_, err := svc.RemoveParticipants(ctx, "other", service.ParticipantFilter{All: true})
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("requires a filter", func(t *testing.T) {
+		_, err := svc.RemoveParticipants(ctx, "lecture", service.ParticipantFilter{})
+		require.ErrorIs(t, err, service.ErrInvalidRemovalFilter)
+	})
+
+	t.Run("removes by identities", func(t *testing.T) {
+		require.Equal(t, []string{"student-1", "teacher"}, remove(service.ParticipantFilter{
+			Identities: []string{"teacher", "student-1", "unknown"},
+		}))
+	})
+
+	t.Run("removes by identity prefix and metadata", func(t *testing.T) {
+		require.Equal(t, []string{"student-1", "student-2", "student-3"}, remove(service.ParticipantFilter{
+			IdentityPrefix: "student-",
+		}))
+		require.Equal(t, []string{"student-1", "student-3"}, remove(service.ParticipantFilter{
+			IdentityPrefix:   "student-",
+			MetadataContains: `"group":"a"`,
+		}))
+	})
+
+	t.Run("removes all", func(t *testing.T) {
+		require.Len(t, remove(service.ParticipantFilter{All: true}), 4)
+	})
+}
@@ -0,0 +1,330 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+)
+
+// maxRequestOptionsSize is the size of JSON bodies whose options are read, options of larger requests are ignored
+const maxRequestOptionsSize = 1024 * 1024
+
+type requestFieldsKey struct{}
+
+// requestFields are the top level fields of a JSON request body. options of RoomService that its protos don't have
+// fields for are carried as additional fields of the JSON requests, which Twirp and the REST gateway ignore when
+// unmarshalling the request. protobuf requests carry them in headers
+type requestFields map[string]json.RawMessage
+
+// requestOption is an option of a RoomService method, carried by a field of JSON requests or a request header
+type requestOption struct {
+	field       string
+	header      string
+	description string
+	schema      map[string]interface{}
+}
+
+var (
+	stringOptionSchema = map[string]interface{}{"type": "string"}
+	int64OptionSchema  = map[string]interface{}{"type": "string", "format": "int64"}
+)
+
+var (
+	expectedRoomVersionOption = requestOption{
+		field:       "expected_version",
+		header:      roomVersionHeader,
+		description: "version of the room the update is based on, the update fails with aborted when the metadata of the room has changed since",
+		schema:      int64OptionSchema,
+	}
+	roomLabelsOption = requestOption{
+		field:       "labels",
+		header:      roomLabelsHeader,
+		description: "labels of the room, a comma separated list of key=value pairs",
+		schema:      stringOptionSchema,
+	}
+	roomTemplateOption = requestOption{
+		field:       "template",
+		header:      roomTemplateHeader,
+		description: "name of the template to create the room from",
+		schema:      stringOptionSchema,
+	}
+	roomExpiresAtOption = requestOption{
+		field:       "expires_at",
+		header:      roomExpiresAtHeader,
+		description: "expiry of the room in unix seconds, the room is closed and deleted when it expires",
+		schema:      int64OptionSchema,
+	}
+	roomStartsAtOption = requestOption{
+		field:       "starts_at",
+		header:      roomStartsAtHeader,
+		description: "start of the room in unix seconds, participants cannot join the room before it starts",
+		schema:      int64OptionSchema,
+	}
+	roomPasscodeOption = requestOption{
+		field:       "passcode",
+		header:      roomPasscodeHeader,
+		description: "passcode participants present to join the room",
+		schema:      stringOptionSchema,
+	}
+	roomAllowedCountriesOption = requestOption{
+		field:       "allowed_countries",
+		header:      roomAllowedCountriesHeader,
+		description: "countries joins of the room are allowed from, comma separated ISO 3166-1 alpha-2 codes",
+		schema:      stringOptionSchema,
+	}
+	roomDeniedCountriesOption = requestOption{
+		field:       "denied_countries",
+		header:      roomDeniedCountriesHeader,
+		description: "countries joins of the room are denied from, comma separated ISO 3166-1 alpha-2 codes",
+		schema:      stringOptionSchema,
+	}
+	roomE2EEOption = requestOption{
+		field:       "e2ee_required",
+		header:      roomE2EEHeader,
+		description: "requires end-to-end encryption of the tracks published to the room",
+		schema:      map[string]interface{}{"type": "boolean"},
+	}
+	roomDuplicateIdentityOption = requestOption{
+		field:       "duplicate_identity",
+		header:      roomDuplicateIdentityHeader,
+		description: "policy of joins with the identity of a participant in the room, replace, reject or suffix",
+		schema:      stringOptionSchema,
+	}
+	maxParticipantsOption = requestOption{
+		field:       "max_participants",
+		header:      maxParticipantsHeader,
+		description: "participant cap of the room, 0 removes the cap",
+		schema:      map[string]interface{}{"type": "integer", "format": "uint32"},
+	}
+	roomMetadataPatchOption = requestOption{
+		field:       "metadata_patch",
+		header:      roomMetadataPatchHeader,
+		description: "merge applies the metadata as a JSON merge patch to the metadata of the room",
+		schema:      stringOptionSchema,
+	}
+	deleteGracePeriodOption = requestOption{
+		field:       "delete_grace_period",
+		header:      deleteGracePeriodHeader,
+		description: "seconds participants are given before the room closes",
+		schema:      map[string]interface{}{"type": "integer"},
+	}
+	participantAttributesOption = requestOption{
+		field:       "attributes",
+		header:      participantAttributesHeader,
+		description: "attributes of the participant, those not in the object are kept and those with empty values are removed",
+		schema:      map[string]interface{}{"type": "object", "additionalProperties": stringOptionSchema},
+	}
+)
+
+var (
+	pageSizeOption = requestOption{
+		field:       "page_size",
+		header:      pageSizeHeader,
+		description: "number of rooms of a page, rooms are listed in pages in name order when set",
+		schema:      map[string]interface{}{"type": "integer"},
+	}
+	pageTokenOption = requestOption{
+		field:       "page_token",
+		header:      pageTokenHeader,
+		description: "token of the page to list, as returned with the previous page",
+		schema:      stringOptionSchema,
+	}
+	namePrefixOption = requestOption{
+		field:       "name_prefix",
+		header:      namePrefixHeader,
+		description: "lists rooms whose names start with the prefix",
+		schema:      stringOptionSchema,
+	}
+	createdAfterOption = requestOption{
+		field:       "created_after",
+		header:      createdAfterHeader,
+		description: "lists rooms created after the time in unix seconds",
+		schema:      int64OptionSchema,
+	}
+	createdBeforeOption = requestOption{
+		field:       "created_before",
+		header:      createdBeforeHeader,
+		description: "lists rooms created before the time in unix seconds",
+		schema:      int64OptionSchema,
+	}
+	metadataContainsOption = requestOption{
+		field:       "metadata_contains",
+		header:      metadataContainsHeader,
+		description: "lists rooms whose metadata contains the string",
+		schema:      stringOptionSchema,
+	}
+	labelSelectorOption = requestOption{
+		field:       "label_selector",
+		header:      labelSelectorHeader,
+		description: "lists rooms whose labels match the selector, comma separated requirements of key=value, key!=value, key or !key",
+		schema:      stringOptionSchema,
+	}
+)
+
+// createRoomOptions are the options of CreateRoom, which rooms of batch requests also carry
+var createRoomOptions = []requestOption{
+	roomLabelsOption,
+	roomTemplateOption,
+	roomExpiresAtOption,
+	roomStartsAtOption,
+	roomPasscodeOption,
+	roomAllowedCountriesOption,
+	roomDeniedCountriesOption,
+	roomE2EEOption,
+	roomDuplicateIdentityOption,
+}
+
+// requestOptions are the options of RoomService methods, by method
+var requestOptions = map[string][]requestOption{
+	"CreateRoom": createRoomOptions,
+	"DeleteRoom": {deleteGracePeriodOption},
+	"ListRooms": {
+		pageSizeOption, pageTokenOption, namePrefixOption, createdAfterOption, createdBeforeOption,
+		metadataContainsOption, labelSelectorOption,
+	},
+	"UpdateRoomMetadata": {expectedRoomVersionOption, roomLabelsOption, maxParticipantsOption, roomMetadataPatchOption},
+	"UpdateParticipant":  {participantAttributesOption},
+}
+
+func isRequestOption(method string, field string) bool {
+	for _, opt := range requestOptions[method] {
+		if opt.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// withRequestOptions makes the options of requests available to twirp handlers, from the fields of JSON requests
+// and from request headers
+func withRequestOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), requestHeaderKey{}, r.Header)
+		if fields, body, ok := readRequestFields(r); ok {
+			ctx = context.WithValue(ctx, requestFieldsKey{}, fields)
+			r.Body = body
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// readRequestFields returns the fields of a JSON request body, and a body to read the request from again
+func readRequestFields(r *http.Request) (requestFields, io.ReadCloser, bool) {
+	if r.Body == nil {
+		return nil, nil, false
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		return nil, nil, false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxRequestOptionsSize+1))
+	body := struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil || len(data) > maxRequestOptionsSize {
+		return nil, body, true
+	}
+	var fields requestFields
+	// invalid bodies are rejected by the handler
+	_ = json.Unmarshal(data, &fields)
+	return fields, body, true
+}
+
+// withoutRequestOptions is the context of requests made on behalf of a caller, whose options don't apply to them
+func withoutRequestOptions(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, requestHeaderKey{}, http.Header{})
+	return context.WithValue(ctx, requestFieldsKey{}, requestFields(nil))
+}
+
+// lookupRequestOption returns the option of the request, the field of its JSON body or else its header. string
+// fields are returned unquoted, others as their JSON
+func lookupRequestOption(ctx context.Context, opt requestOption) (string, bool) {
+	fields, _ := ctx.Value(requestFieldsKey{}).(requestFields)
+	if raw, ok := fields[opt.field]; ok && string(raw) != "null" {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s, true
+		}
+		return string(raw), true
+	}
+	return lookupRequestHeader(ctx, opt.header)
+}
+
+func getRequestOption(ctx context.Context, opt requestOption) string {
+	value, _ := lookupRequestOption(ctx, opt)
+	return value
+}
+
+type roomRequestFieldsKey struct{}
+
+// withRoomRequestFields makes the fields of each room of a batch request available to the CreateRoom request of the
+// room, by the name of the room in the batch
+func withRoomRequestFields(ctx context.Context, fields map[string]requestFields) context.Context {
+	return context.WithValue(ctx, roomRequestFieldsKey{}, fields)
+}
+
+// roomRequestContext is the context of the CreateRoom request of a room of a batch. the options of the room are its
+// fields, headers of the batch request apply to the options it does not set
+func roomRequestContext(ctx context.Context, name string) context.Context {
+	fields, ok := ctx.Value(roomRequestFieldsKey{}).(map[string]requestFields)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, requestFieldsKey{}, fields[name])
+}
+
+// parseCreateRoomRequests returns the CreateRoom requests of the rooms of a batch, and the fields of the rooms that
+// are options of CreateRoom by the name of the room
+func parseCreateRoomRequests(rooms []json.RawMessage) ([]*livekit.CreateRoomRequest, map[string]requestFields, error) {
+	reqs := make([]*livekit.CreateRoomRequest, 0, len(rooms))
+	options := make(map[string]requestFields, len(rooms))
+	for _, data := range rooms {
+		var fields requestFields
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, nil, psrpc.NewError(psrpc.InvalidArgument, err)
+		}
+		roomOptions := make(requestFields)
+		for name, value := range fields {
+			if isRequestOption("CreateRoom", name) {
+				roomOptions[name] = value
+				delete(fields, name)
+			}
+		}
+
+		// the request is strict about the fields that are not options
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, nil, err
+		}
+		req := &livekit.CreateRoomRequest{}
+		if err = protojson.Unmarshal(data, req); err != nil {
+			return nil, nil, psrpc.NewError(psrpc.InvalidArgument, err)
+		}
+		reqs = append(reqs, req)
+		options[req.Name] = roomOptions
+	}
+	return reqs, options, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestOptions(t *testing.T) {
+	lookup := func(r *http.Request) (string, bool, string) {
+		var value, body string
+		var ok bool
+		withRequestOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value, ok = lookupRequestOption(r.Context(), expectedRoomVersionOption)
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+		})).ServeHTTP(httptest.NewRecorder(), r)
+		return value, ok, body
+	}
+	newRequest := func(body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/UpdateRoomMetadata", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	t.Run("fields of JSON requests", func(t *testing.T) {
+		value, ok, body := lookup(newRequest(`{"room": "room", "metadata": "m", "expected_version": "3"}`))
+		require.True(t, ok)
+		require.Equal(t, "3", value)
+		// the handler reads the whole request
+		require.Equal(t, `{"room": "room", "metadata": "m", "expected_version": "3"}`, body)
+
+		value, ok, _ = lookup(newRequest(`{"room": "room", "expected_version": 4}`))
+		require.True(t, ok)
+		require.Equal(t, "4", value)
+
+		_, ok, _ = lookup(newRequest(`{"room": "room", "expected_version": null}`))
+		require.False(t, ok)
+	})
+
+	t.Run("headers of protobuf requests", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/UpdateRoomMetadata", strings.NewReader("\x0a\x04room"))
+		r.Header.Set("Content-Type", "application/protobuf")
+		r.Header.Set(roomVersionHeader, "5")
+		value, ok, body := lookup(r)
+		require.True(t, ok)
+		require.Equal(t, "5", value)
+		require.Equal(t, "\x0a\x04room", body)
+	})
+
+	t.Run("fields take precedence over headers", func(t *testing.T) {
+		r := newRequest(`{"expected_version": "6"}`)
+		r.Header.Set(roomVersionHeader, "5")
+		value, _, _ := lookup(r)
+		require.Equal(t, "6", value)
+	})
+}
+
+func TestParseCreateRoomRequests(t *testing.T) {
+	reqs, options, err := parseCreateRoomRequests([]json.RawMessage{
+		[]byte(`{"name": "main", "empty_timeout": 60, "labels": "team=a", "template": "standup"}`),
+		[]byte(`{"name": "breakout-1"}`),
+	})
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+	require.Equal(t, "main", reqs[0].Name)
+	require.Equal(t, uint32(60), reqs[0].EmptyTimeout)
+
+	header := http.Header{}
+	header.Set(roomTemplateHeader, "retro")
+	ctx := withRoomRequestFields(context.WithValue(context.Background(), requestHeaderKey{}, header), options)
+
+	// options of a room are its fields, headers apply to the options it does not set
+	labels, _ := lookupRequestOption(roomRequestContext(ctx, "main"), roomLabelsOption)
+	require.Equal(t, "team=a", labels)
+	template, _ := lookupRequestOption(roomRequestContext(ctx, "main"), roomTemplateOption)
+	require.Equal(t, "standup", template)
+	_, ok := lookupRequestOption(roomRequestContext(ctx, "breakout-1"), roomLabelsOption)
+	require.False(t, ok)
+	template, _ = lookupRequestOption(roomRequestContext(ctx, "breakout-1"), roomTemplateOption)
+	require.Equal(t, "retro", template)
+
+	// fields that are not options are not ignored
+	_, _, err = parseCreateRoomRequests([]json.RawMessage{[]byte(`{"name": "main", "unknown": 1}`)})
+	require.Error(t, err)
+}
@@ -0,0 +1,511 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/ctxsetters"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+	"github.com/livekit/livekit-server/version"
+)
+
+const (
+	restGatewayPrefix  = "/v1/"
+	restOpenAPIPath    = restGatewayPrefix + "openapi.json"
+	maxRESTRequestSize = 1024 * 1024
+)
+
+var (
+	restMarshaler   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	restUnmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// restRoute maps a REST operation to a RoomService method. path parameters are set to the request fields of the
+// same name, GET and DELETE requests take the other fields as query parameters, others as a JSON body
+type restRoute struct {
+	method  string
+	path    string
+	rpc     string
+	summary string
+}
+
+var restRoutes = []restRoute{
+	{http.MethodPost, "/v1/rooms", "CreateRoom", "Create a room"},
+	{http.MethodGet, "/v1/rooms", "ListRooms", "List active rooms"},
+	{http.MethodDelete, "/v1/rooms/{room}", "DeleteRoom", "Delete a room and disconnect its participants"},
+	{http.MethodPut, "/v1/rooms/{room}/metadata", "UpdateRoomMetadata", "Update the metadata of a room"},
+	{http.MethodPost, "/v1/rooms/{room}/data", "SendData", "Send a data packet to participants of a room"},
+	{http.MethodGet, "/v1/rooms/{room}/participants", "ListParticipants", "List the participants of a room"},
+	{http.MethodGet, "/v1/rooms/{room}/participants/{identity}", "GetParticipant", "Get a participant"},
+	{http.MethodPatch, "/v1/rooms/{room}/participants/{identity}", "UpdateParticipant", "Update the metadata, name or permissions of a participant"},
+	{http.MethodDelete, "/v1/rooms/{room}/participants/{identity}", "RemoveParticipant", "Remove a participant from a room"},
+	{http.MethodPost, "/v1/rooms/{room}/participants/{identity}/subscriptions", "UpdateSubscriptions", "Subscribe or unsubscribe a participant from tracks"},
+	{http.MethodPost, "/v1/rooms/{room}/participants/{identity}/tracks/{track_sid}/mute", "MutePublishedTrack", "Mute or unmute a track published by a participant"},
+}
+
+// match returns the path parameters when the route matches the request
+func (r *restRoute) match(method string, path string) (map[string]string, bool) {
+	if method != r.method {
+		return nil, false
+	}
+	segments := strings.Split(strings.Trim(r.path, "/"), "/")
+	values := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(values) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			if values[i] == "" {
+				return nil, false
+			}
+			params[strings.Trim(segment, "{}")] = values[i]
+		} else if segment != values[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// restRouteRPC returns the RoomService method of the route of a request, ok is false when no route matches it
+func restRouteRPC(method string, path string) (rpc string, ok bool) {
+	for i := range restRoutes {
+		if _, ok = restRoutes[i].match(method, path); ok {
+			return restRoutes[i].rpc, true
+		}
+	}
+	return "", false
+}
+
+func (r *restRoute) hasBody() bool {
+	return r.method != http.MethodGet && r.method != http.MethodDelete
+}
+
+// RESTGateway serves RoomService at /v1/ as a JSON REST API for callers that can't use Twirp or gRPC, and its
+// OpenAPI spec at /v1/openapi.json. requests are authenticated, scoped to projects and extended with headers as
+// Twirp requests, request and response bodies are the protos in JSON with their field names
+type RESTGateway struct {
+	methods map[string]*protoMethod
+	scope   twirp.Interceptor
+	logger  logger.Logger
+	spec    []byte
+}
+
+func NewRESTGateway(roomService livekit.RoomService) (*RESTGateway, error) {
+	methods, err := newProtoMethods("livekit.RoomService", roomService)
+	if err != nil {
+		return nil, err
+	}
+	g := &RESTGateway{
+		methods: make(map[string]*protoMethod, len(methods)),
+		scope:   ProjectScopeInterceptor(),
+		logger:  logger.GetLogger().WithComponent(utils.ComponentAPI),
+	}
+	for _, m := range methods {
+		g.methods[string(m.desc.Name())] = m
+	}
+	for _, route := range restRoutes {
+		if g.methods[route.rpc] == nil {
+			return nil, fmt.Errorf("RoomService has no method %s", route.rpc)
+		}
+	}
+
+	if g.spec, err = json.Marshal(g.openAPISpec()); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *RESTGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == restOpenAPIPath {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(g.spec)
+		return
+	}
+
+	pathMatched := false
+	for i := range restRoutes {
+		route := &restRoutes[i]
+		if _, ok := route.match(route.method, r.URL.Path); ok {
+			pathMatched = true
+		}
+		params, ok := route.match(r.Method, r.URL.Path)
+		if !ok {
+			continue
+		}
+		g.serveRoute(w, r, route, params)
+		return
+	}
+
+	if pathMatched {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	_ = twirp.WriteError(w, twirp.NotFoundError("no route for "+r.Method+" "+r.URL.Path))
+}
+
+func (g *RESTGateway) serveRoute(w http.ResponseWriter, r *http.Request, route *restRoute, params map[string]string) {
+	m := g.methods[route.rpc]
+	req := m.input.New().Interface()
+
+	// options of the method are fields of the body or query parameters, as for Twirp JSON requests
+	var fields requestFields
+	if route.hasBody() {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRESTRequestSize))
+		if err != nil {
+			_ = twirp.WriteError(w, twirp.InternalErrorWith(err))
+			return
+		}
+		if len(body) != 0 {
+			if err = restUnmarshaler.Unmarshal(body, req); err != nil {
+				_ = twirp.WriteError(w, twirp.NewError(twirp.Malformed, "invalid request body: "+err.Error()))
+				return
+			}
+			_ = json.Unmarshal(body, &fields)
+		}
+	} else {
+		for name, values := range r.URL.Query() {
+			if isRequestOption(route.rpc, name) {
+				if fields == nil {
+					fields = make(requestFields)
+				}
+				fields[name], _ = json.Marshal(values[0])
+				continue
+			}
+			if err := setRESTField(req, name, values); err != nil {
+				_ = twirp.WriteError(w, twirp.InvalidArgumentError(name, err.Error()))
+				return
+			}
+		}
+	}
+	for name, value := range params {
+		if err := setRESTField(req, name, []string{value}); err != nil {
+			_ = twirp.WriteError(w, twirp.InvalidArgumentError(name, err.Error()))
+			return
+		}
+	}
+
+	// services set response headers as they do for Twirp requests
+	ctx := ctxsetters.WithResponseWriter(r.Context(), w)
+	ctx = context.WithValue(ctx, requestHeaderKey{}, r.Header)
+	ctx = context.WithValue(ctx, requestFieldsKey{}, fields)
+	res, err := logAPIRequest(ctx, g.logger, "RoomService", route.rpc, "rest", func(ctx context.Context) (interface{}, error) {
+		return g.scope(m.call)(ctx, req)
+	})
+	if err != nil {
+		_ = twirp.WriteError(w, err)
+		return
+	}
+
+	b, err := restMarshaler.Marshal(res.(proto.Message))
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.InternalErrorWith(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// setRESTField sets the scalar or repeated scalar field of the request to the values of a path or query parameter
+func setRESTField(req proto.Message, name string, values []string) error {
+	msg := req.ProtoReflect()
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		return fmt.Errorf("unknown field")
+	}
+	if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+		return fmt.Errorf("only scalar fields can be set by parameters")
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseRESTValue(fd, s)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("cannot be repeated")
+	}
+	v, err := parseRESTValue(fd, values[0])
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+func parseRESTValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+	}
+}
+
+// openAPISpec generates the OpenAPI 3 spec of the routes from the protos of their methods
+func (g *RESTGateway) openAPISpec() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+	for _, route := range restRoutes {
+		m := g.methods[route.rpc]
+		params, _ := route.match(route.method, route.path)
+
+		var parameters []interface{}
+		for _, segment := range strings.Split(route.path, "/") {
+			if strings.HasPrefix(segment, "{") {
+				parameters = append(parameters, map[string]interface{}{
+					"name":     strings.Trim(segment, "{}"),
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		operation := map[string]interface{}{
+			"operationId": route.rpc,
+			"summary":     route.summary,
+			"tags":        []string{"RoomService"},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "OK",
+					"content":     openAPIJSONContent(openAPISchemaRef(m.desc.Output(), schemas)),
+				},
+				"default": map[string]interface{}{
+					"description": "Twirp error",
+					"content":     openAPIJSONContent(openAPITwirpErrorSchema),
+				},
+			},
+		}
+		if route.hasBody() {
+			var schema interface{} = openAPISchemaRef(m.desc.Input(), schemas)
+			if options := requestOptions[route.rpc]; len(options) != 0 {
+				properties := make(map[string]interface{}, len(options))
+				for _, opt := range options {
+					properties[opt.field] = openAPIOptionSchema(opt)
+				}
+				schema = map[string]interface{}{
+					"allOf": []interface{}{schema, map[string]interface{}{"type": "object", "properties": properties}},
+				}
+			}
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  openAPIJSONContent(schema),
+			}
+		} else {
+			for _, opt := range requestOptions[route.rpc] {
+				parameters = append(parameters, map[string]interface{}{
+					"name":   opt.field,
+					"in":     "query",
+					"schema": openAPIOptionSchema(opt),
+				})
+			}
+			fields := m.desc.Input().Fields()
+			for i := 0; i < fields.Len(); i++ {
+				fd := fields.Get(i)
+				if _, ok := params[string(fd.Name())]; ok || fd.IsMap() || fd.Kind() == protoreflect.MessageKind {
+					continue
+				}
+				parameters = append(parameters, map[string]interface{}{
+					"name":   string(fd.Name()),
+					"in":     "query",
+					"schema": openAPIFieldSchema(fd, schemas),
+				})
+			}
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+
+		if paths[route.path] == nil {
+			paths[route.path] = make(map[string]interface{})
+		}
+		paths[route.path][strings.ToLower(route.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "LiveKit RoomService",
+			"version": version.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+		},
+	}
+}
+
+var openAPITwirpErrorSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"code": map[string]interface{}{"type": "string"},
+		"msg":  map[string]interface{}{"type": "string"},
+		"meta": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+func openAPIOptionSchema(opt requestOption) map[string]interface{} {
+	schema := map[string]interface{}{"description": opt.description}
+	for key, value := range opt.schema {
+		schema[key] = value
+	}
+	return schema
+}
+
+func openAPIJSONContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// openAPISchemaRef adds the schema of the message and the messages it references to schemas, and returns a reference
+// to it
+func openAPISchemaRef(md protoreflect.MessageDescriptor, schemas map[string]interface{}) map[string]interface{} {
+	name := string(md.FullName())
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	if _, ok := schemas[name]; ok {
+		return ref
+	}
+	// added before its fields, messages can reference themselves
+	properties := make(map[string]interface{})
+	schemas[name] = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		properties[string(fd.Name())] = openAPIFieldSchema(fd, schemas)
+	}
+	return ref
+}
+
+func openAPIFieldSchema(fd protoreflect.FieldDescriptor, schemas map[string]interface{}) map[string]interface{} {
+	if fd.IsMap() {
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": openAPIValueSchema(fd.MapValue(), schemas),
+		}
+	}
+	if fd.IsList() {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": openAPIValueSchema(fd, schemas),
+		}
+	}
+	return openAPIValueSchema(fd, schemas)
+}
+
+// openAPIValueSchema is the schema of a single value of the field, as encoded by protojson
+func openAPIValueSchema(fd protoreflect.FieldDescriptor, schemas map[string]interface{}) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return openAPISchemaRef(fd.Message(), schemas)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64 bit integers are encoded as strings
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]interface{}{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
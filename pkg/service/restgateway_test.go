@@ -0,0 +1,99 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRESTGateway(t *testing.T) {
+	gateway, err := service.NewRESTGateway(&grpcTestRoomService{})
+	require.NoError(t, err)
+
+	serve := func(method string, target string, grant *auth.VideoGrant) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		if grant != nil {
+			r = r.WithContext(service.WithGrants(context.Background(), &auth.ClaimGrants{Video: grant}))
+		}
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("calls methods with query parameters", func(t *testing.T) {
+		w := serve(http.MethodGet, "/v1/rooms?names=standup&names=retro", &auth.VideoGrant{RoomList: true})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Rooms []struct {
+				Name string `json:"name"`
+			} `json:"rooms"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Rooms, 2)
+		require.Equal(t, "standup", res.Rooms[0].Name)
+		require.Equal(t, "retro", res.Rooms[1].Name)
+	})
+
+	t.Run("responds with twirp errors", func(t *testing.T) {
+		w := serve(http.MethodGet, "/v1/rooms", nil)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = serve(http.MethodDelete, "/v1/rooms/standup", &auth.VideoGrant{RoomCreate: true})
+		require.Equal(t, http.StatusNotFound, w.Code)
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, "not_found", res["code"])
+
+		w = serve(http.MethodGet, "/v1/rooms?unknown=1", &auth.VideoGrant{RoomList: true})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v1/recordings", nil).Code)
+		require.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/v1/rooms/standup", nil).Code)
+	})
+
+	t.Run("serves the OpenAPI spec", func(t *testing.T) {
+		w := serve(http.MethodGet, "/v1/openapi.json", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var spec struct {
+			Paths      map[string]map[string]interface{} `json:"paths"`
+			Components struct {
+				Schemas map[string]interface{} `json:"schemas"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+		require.Contains(t, spec.Paths["/v1/rooms"], "post")
+		require.Contains(t, spec.Paths["/v1/rooms"], "get")
+		for _, method := range []string{"get", "patch", "delete"} {
+			require.Contains(t, spec.Paths["/v1/rooms/{room}/participants/{identity}"], method)
+		}
+		for _, name := range []string{"livekit.CreateRoomRequest", "livekit.Room", "livekit.ParticipantInfo", "livekit.TrackInfo"} {
+			require.Contains(t, spec.Components.Schemas, name)
+		}
+	})
+}
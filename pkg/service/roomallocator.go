@@ -32,9 +32,10 @@ type StandardRoomAllocator struct {
 	router    routing.Router
 	selector  selector.NodeSelector
 	roomStore ObjectStore
+	keyUsage  KeyUsageStore
 }
 
-func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore) (RoomAllocator, error) {
+func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore, keyUsage KeyUsageStore) (RoomAllocator, error) {
 	ns, err := selector.CreateNodeSelector(conf)
 	if err != nil {
 		return nil, err
@@ -45,6 +46,7 @@ func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore
 		router:    router,
 		selector:  ns,
 		roomStore: rs,
+		keyUsage:  keyUsage,
 	}, nil
 }
 
@@ -61,7 +63,11 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 
 	// find existing room and update it
 	rm, internal, err := r.roomStore.LoadRoom(ctx, livekit.RoomName(req.Name), true)
-	if err == ErrRoomNotFound {
+	created := err == ErrRoomNotFound
+	if created {
+		if err = acquireRoomQuota(ctx, r.config.KeyQuotas, r.keyUsage, livekit.RoomName(req.Name)); err != nil {
+			return nil, err
+		}
 		rm = &livekit.Room{
 			Sid:          utils.NewGuid(utils.RoomPrefix),
 			Name:         req.Name,
@@ -98,8 +104,17 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 	}
 
 	if err = r.roomStore.StoreRoom(ctx, rm, internal); err != nil {
+		if created {
+			releaseRoomQuota(ctx, r.config.KeyQuotas, r.keyUsage, livekit.RoomName(rm.Name))
+		}
 		return nil, err
 	}
+	if created {
+		if err = setRoomQuotaKey(ctx, r.config.KeyQuotas, r.roomStore, livekit.RoomName(rm.Name)); err != nil {
+			releaseRoomQuota(ctx, r.config.KeyQuotas, r.keyUsage, livekit.RoomName(rm.Name))
+			return nil, err
+		}
+	}
 
 	// check if room already assigned
 	existing, err := r.router.GetNodeForRoom(ctx, livekit.RoomName(rm.Name))
@@ -0,0 +1,231 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	maxRoomBatchSize        = 100
+	maxRoomBatchRequestSize = 1024 * 1024
+
+	// rooms of a failed batch are locked while they are deleted
+	roomBatchLockDuration = 5 * time.Second
+)
+
+// RoomBatchService creates and deletes sets of related rooms at /room_batch, such as a main room and its
+// breakout rooms. either all rooms of a batch are created or deleted, or none of them.
+// POST creates the rooms of the body, each room carries the options of CreateRoom such as labels and templates.
+// DELETE ?room=&room= deletes the rooms
+type RoomBatchService struct {
+	roomService *RoomService
+	store       ObjectStore
+}
+
+func NewRoomBatchService(roomService *RoomService, store ObjectStore) *RoomBatchService {
+	return &RoomBatchService{
+		roomService: roomService,
+		store:       store,
+	}
+}
+
+// CreateRooms creates all of the rooms. rooms must not exist yet, rooms that this call has created are deleted
+// when one of them fails. the options of CreateRoom apply to each of the rooms
+func (s *RoomBatchService) CreateRooms(ctx context.Context, reqs []*livekit.CreateRoomRequest) ([]*livekit.Room, error) {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		names = append(names, req.Name)
+	}
+	roomNames, err := s.scopeRoomNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	// rooms that existed are never deleted when the batch fails
+	for _, roomName := range roomNames {
+		if _, _, err = s.store.LoadRoom(ctx, roomName, false); err == nil {
+			return nil, ErrRoomAlreadyExists
+		} else if err != ErrRoomNotFound {
+			return nil, err
+		}
+	}
+
+	created := make([]*livekit.Room, 0, len(reqs))
+	for i, req := range reqs {
+		req = proto.Clone(req).(*livekit.CreateRoomRequest)
+		req.Name = string(roomNames[i])
+
+		rm, err := s.roomService.CreateRoom(roomRequestContext(ctx, names[i]), req)
+		if err != nil {
+			logger.Warnw("could not create room of batch", err, "room", req.Name)
+			s.rollbackRooms(created)
+			return nil, err
+		}
+		created = append(created, rm)
+	}
+
+	rooms := make([]*livekit.Room, 0, len(created))
+	for _, rm := range created {
+		rooms = append(rooms, unscopeRoom(rm))
+	}
+	return rooms, nil
+}
+
+// rollbackRooms deletes the rooms of a failed batch. each room is deleted under its lock, and only while it is the
+// room that the batch created, rooms created again since by others are kept
+func (s *RoomBatchService) rollbackRooms(created []*livekit.Room) {
+	ctx := context.Background()
+	var roomNames []livekit.RoomName
+	for _, rm := range created {
+		roomName := livekit.RoomName(rm.Name)
+		token, err := s.store.LockRoom(ctx, roomName, roomBatchLockDuration)
+		if err != nil {
+			logger.Errorw("could not lock room of failed batch", err, "room", roomName)
+			continue
+		}
+		defer func() {
+			_ = s.store.UnlockRoom(ctx, roomName, token)
+		}()
+
+		if current, _, err := s.store.LoadRoom(ctx, roomName, false); err == nil && current.Sid == rm.Sid {
+			roomNames = append(roomNames, roomName)
+		}
+	}
+	if len(roomNames) == 0 {
+		return
+	}
+	if err := s.deleteRooms(ctx, roomNames); err != nil {
+		logger.Errorw("could not delete rooms of failed batch", err, "rooms", roomNames)
+	}
+}
+
+// DeleteRooms deletes all of the rooms, none are deleted when one of them does not exist
+func (s *RoomBatchService) DeleteRooms(ctx context.Context, names []string) error {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return err
+	}
+
+	roomNames, err := s.scopeRoomNames(ctx, names)
+	if err != nil {
+		return err
+	}
+	for _, roomName := range roomNames {
+		if _, _, err = s.store.LoadRoom(ctx, roomName, false); err != nil {
+			return err
+		}
+	}
+	return s.deleteRooms(ctx, roomNames)
+}
+
+func (s *RoomBatchService) scopeRoomNames(ctx context.Context, names []string) ([]livekit.RoomName, error) {
+	if len(names) == 0 || len(names) > maxRoomBatchSize {
+		return nil, ErrInvalidRoomBatch
+	}
+
+	project, scoped := GetProject(ctx)
+	roomNames := make([]livekit.RoomName, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			return nil, ErrInvalidRoomBatch
+		}
+		seen[name] = true
+
+		if scoped {
+			var err error
+			if name, err = scopeRoomName(project, name); err != nil {
+				return nil, err
+			}
+		}
+		roomNames = append(roomNames, livekit.RoomName(name))
+	}
+	return roomNames, nil
+}
+
+// deleteRooms removes the rooms from the store together, then has the nodes hosting them close them
+func (s *RoomBatchService) deleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	if err := deleteRooms(ctx, s.store, roomNames); err != nil {
+		return err
+	}
+
+	for _, roomName := range roomNames {
+		err := s.roomService.router.WriteRoomRTC(ctx, roomName, &livekit.RTCNodeMessage{
+			Message: &livekit.RTCNodeMessage_DeleteRoom{
+				DeleteRoom: &livekit.DeleteRoomRequest{Room: string(roomName)},
+			},
+		})
+		if err != nil {
+			logger.Warnw("could not close deleted room", err, "room", roomName)
+		}
+	}
+	return nil
+}
+
+// deleteRooms deletes the rooms atomically when the store supports it, one by one otherwise
+func deleteRooms(ctx context.Context, store ObjectStore, roomNames []livekit.RoomName) error {
+	if batchStore, ok := store.(RoomBatchStore); ok {
+		return batchStore.DeleteRooms(ctx, roomNames)
+	}
+	for _, roomName := range roomNames {
+		if err := store.DeleteRoom(ctx, roomName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rooms of batch requests are the JSON of CreateRoomRequest, with the options of CreateRoom as additional fields of
+// each room. options that a room does not set are taken from the headers of the request
+type roomBatchRequest struct {
+	Rooms []json.RawMessage `json:"rooms"`
+}
+
+type roomBatchResponse struct {
+	Rooms []json.RawMessage `json:"rooms"`
+}
+
+func (s *RoomBatchService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxRoomBatchRequestSize, func(ctx context.Context, batch *roomBatchRequest) (interface{}, error) {
+			reqs, options, err := parseCreateRoomRequests(batch.Rooms)
+			if err != nil {
+				return nil, err
+			}
+			rooms, err := s.CreateRooms(withRoomRequestFields(ctx, options), reqs)
+			if err != nil {
+				return nil, err
+			}
+			res := &roomBatchResponse{}
+			res.Rooms, err = protoJSONList(rooms)
+			return res, err
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			return nil, s.DeleteRooms(r.Context(), r.URL.Query()["room"])
+		},
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestRoomBatchService(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomCreate: true},
+	})
+
+	store := service.NewLocalStore()
+	router := &routingfakes.FakeRouter{}
+	router.StartParticipantSignalReturns("", &routingfakes.FakeMessageSink{}, &routingfakes.FakeMessageSource{}, nil)
+	allocator := &servicefakes.FakeRoomAllocator{}
+	allocator.CreateRoomStub = func(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+		switch req.Name {
+		case "breakout-2":
+			return nil, errors.New("no available nodes")
+		case "breakout-4":
+			// the room is deleted and created again by others while the batch is created
+			_ = store.StoreRoom(ctx, &livekit.Room{Name: "breakout-3", Sid: "RM_other"}, nil)
+			return nil, errors.New("no available nodes")
+		}
+		rm := &livekit.Room{Name: req.Name, Sid: "RM_" + req.Name}
+		return rm, store.StoreRoom(ctx, rm, nil)
+	}
+	roomService, err := service.NewRoomService(config.RoomConfig{}, config.APIConfig{ExecutionTimeout: time.Second},
+		router, allocator, store, nil, nil)
+	require.NoError(t, err)
+	svc := service.NewRoomBatchService(roomService, store)
+
+	listRooms := func() []*livekit.Room {
+		rooms, err := store.ListRooms(ctx, nil)
+		require.NoError(t, err)
+		return rooms
+	}
+
+	// rooms created before the failing room are deleted
+	_, err = svc.CreateRooms(ctx, []*livekit.CreateRoomRequest{{Name: "main"}, {Name: "breakout-1"}, {Name: "breakout-2"}})
+	require.Error(t, err)
+	require.Empty(t, listRooms())
+
+	rooms, err := svc.CreateRooms(ctx, []*livekit.CreateRoomRequest{{Name: "main"}, {Name: "breakout-1"}})
+	require.NoError(t, err)
+	require.Len(t, rooms, 2)
+	require.Len(t, listRooms(), 2)
+
+	_, err = svc.CreateRooms(ctx, []*livekit.CreateRoomRequest{{Name: "breakout-3"}, {Name: "main"}})
+	require.ErrorIs(t, err, service.ErrRoomAlreadyExists)
+	_, err = svc.CreateRooms(ctx, []*livekit.CreateRoomRequest{{Name: "breakout-3"}, {Name: "breakout-3"}})
+	require.ErrorIs(t, err, service.ErrInvalidRoomBatch)
+	require.Len(t, listRooms(), 2)
+
+	// only rooms that the batch created are deleted
+	_, err = svc.CreateRooms(ctx, []*livekit.CreateRoomRequest{{Name: "breakout-3"}, {Name: "breakout-4"}})
+	require.Error(t, err)
+	rm, _, err := store.LoadRoom(ctx, "breakout-3", false)
+	require.NoError(t, err)
+	require.Equal(t, "RM_other", rm.Sid)
+	require.NoError(t, store.DeleteRoom(ctx, "breakout-3"))
+	require.Len(t, listRooms(), 2)
+
+	// nothing is deleted when one of the rooms does not exist
+	require.ErrorIs(t, svc.DeleteRooms(ctx, []string{"main", "breakout-3"}), service.ErrRoomNotFound)
+	require.Len(t, listRooms(), 2)
+
+	writes := router.WriteRoomRTCCallCount()
+	require.NoError(t, svc.DeleteRooms(ctx, []string{"main", "breakout-1"}))
+	require.Empty(t, listRooms())
+	require.Equal(t, writes+2, router.WriteRoomRTCCallCount())
+
+	require.ErrorIs(t, svc.DeleteRooms(context.Background(), []string{"main"}), service.ErrPermissionDenied)
+}
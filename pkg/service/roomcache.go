@@ -0,0 +1,164 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// names of rooms written by any node are published to this channel
+const RoomCacheInvalidationChannel = "room_cache_invalidation"
+
+type cachedRoom struct {
+	room        *livekit.Room
+	internal    *livekit.RoomInternal
+	hasInternal bool
+	expiresAt   time.Time
+}
+
+// cachedStore reads rooms through an LRU cache. Rooms written through the store are invalidated locally,
+// and on other nodes through redis pubsub. Only LoadRoom is served from the cache, versioned reads and
+// listings always go to the store.
+type cachedStore struct {
+	ObjectStore
+
+	ttl   time.Duration
+	rc    redis.UniversalClient
+	lock  sync.Mutex
+	rooms *lru.Cache[livekit.RoomName, *cachedRoom]
+	// changes with every invalidation, rooms loaded across an invalidation are not cached
+	generation uint64
+}
+
+func newCachedStore(store ObjectStore, conf config.RoomCacheConfig, rc redis.UniversalClient) (*cachedStore, error) {
+	rooms, err := lru.New[livekit.RoomName, *cachedRoom](conf.Size)
+	if err != nil {
+		return nil, err
+	}
+	s := &cachedStore{
+		ObjectStore: store,
+		ttl:         conf.TTL,
+		rc:          rc,
+		rooms:       rooms,
+	}
+	if rc != nil {
+		go s.invalidationWorker(rc.Subscribe(context.Background(), RoomCacheInvalidationChannel))
+	}
+	return s, nil
+}
+
+func (s *cachedStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	s.lock.Lock()
+	cached, ok := s.rooms.Get(roomName)
+	generation := s.generation
+	s.lock.Unlock()
+
+	if ok && time.Now().Before(cached.expiresAt) && (!includeInternal || cached.hasInternal) {
+		prometheus.RecordStoreCacheLookup(true)
+		// callers may modify rooms they load
+		room := proto.Clone(cached.room).(*livekit.Room)
+		if !includeInternal || cached.internal == nil {
+			return room, nil, nil
+		}
+		return room, proto.Clone(cached.internal).(*livekit.RoomInternal), nil
+	}
+	prometheus.RecordStoreCacheLookup(false)
+
+	room, internal, err := s.ObjectStore.LoadRoom(ctx, roomName, includeInternal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &cachedRoom{
+		room:        proto.Clone(room).(*livekit.Room),
+		hasInternal: includeInternal,
+		expiresAt:   time.Now().Add(s.ttl),
+	}
+	if internal != nil {
+		entry.internal = proto.Clone(internal).(*livekit.RoomInternal)
+	}
+	s.lock.Lock()
+	if s.generation == generation {
+		s.rooms.Add(roomName, entry)
+	}
+	s.lock.Unlock()
+
+	return room, internal, nil
+}
+
+func (s *cachedStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	defer s.invalidate(ctx, livekit.RoomName(room.Name))
+	return s.ObjectStore.StoreRoom(ctx, room, internal)
+}
+
+func (s *cachedStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	defer s.invalidate(ctx, roomName)
+	return s.ObjectStore.DeleteRoom(ctx, roomName)
+}
+
+func (s *cachedStore) DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	defer func() {
+		for _, roomName := range roomNames {
+			s.invalidate(ctx, roomName)
+		}
+	}()
+	return deleteRooms(ctx, s.ObjectStore, roomNames)
+}
+
+func (s *cachedStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	defer s.invalidate(ctx, livekit.RoomName(room.Name))
+	return s.ObjectStore.UpdateRoom(ctx, room, expectedVersion)
+}
+
+func (s *cachedStore) unwrap() ObjectStore {
+	return s.ObjectStore
+}
+
+func (s *cachedStore) invalidate(ctx context.Context, roomName livekit.RoomName) {
+	s.invalidateLocal(roomName)
+	if s.rc != nil {
+		if err := s.rc.Publish(ctx, RoomCacheInvalidationChannel, string(roomName)).Err(); err != nil {
+			logger.Warnw("could not publish room cache invalidation", err, "room", roomName)
+		}
+	}
+}
+
+func (s *cachedStore) invalidateLocal(roomName livekit.RoomName) {
+	s.lock.Lock()
+	s.generation++
+	s.rooms.Remove(roomName)
+	s.lock.Unlock()
+}
+
+func (s *cachedStore) invalidationWorker(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		if msg == nil {
+			return
+		}
+		s.invalidateLocal(livekit.RoomName(msg.Payload))
+	}
+}
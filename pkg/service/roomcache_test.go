@@ -0,0 +1,80 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocalStore()
+	s, err := newCachedStore(local, config.RoomCacheConfig{Size: 10, TTL: time.Hour}, nil)
+	require.NoError(t, err)
+	require.Same(t, local, unwrapStore(s))
+
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_cached", Name: "cached", Metadata: "first"}, nil))
+	room, _, err := s.LoadRoom(ctx, "cached", false)
+	require.NoError(t, err)
+	require.Equal(t, "first", room.Metadata)
+
+	t.Run("rooms written to the store directly are served from the cache", func(t *testing.T) {
+		require.NoError(t, local.StoreRoom(ctx, &livekit.Room{Sid: "RM_cached", Name: "cached", Metadata: "direct"}, nil))
+		room, _, err := s.LoadRoom(ctx, "cached", false)
+		require.NoError(t, err)
+		require.Equal(t, "first", room.Metadata)
+
+		// cached rooms are copies
+		room.Metadata = "modified"
+		room, _, err = s.LoadRoom(ctx, "cached", false)
+		require.NoError(t, err)
+		require.Equal(t, "first", room.Metadata)
+	})
+
+	t.Run("internal state is loaded when not cached", func(t *testing.T) {
+		_, internal, err := s.LoadRoom(ctx, "cached", true)
+		require.NoError(t, err)
+		require.Nil(t, internal)
+	})
+
+	t.Run("writes invalidate the room", func(t *testing.T) {
+		require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_cached", Name: "cached", Metadata: "second"}, nil))
+		room, _, err := s.LoadRoom(ctx, "cached", false)
+		require.NoError(t, err)
+		require.Equal(t, "second", room.Metadata)
+
+		require.NoError(t, s.DeleteRoom(ctx, "cached"))
+		_, _, err = s.LoadRoom(ctx, "cached", false)
+		require.ErrorIs(t, err, ErrRoomNotFound)
+	})
+
+	t.Run("rooms expire", func(t *testing.T) {
+		s.ttl = 0
+		require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_expiring", Name: "expiring"}, nil))
+		_, _, err := s.LoadRoom(ctx, "expiring", false)
+		require.NoError(t, err)
+
+		require.NoError(t, local.DeleteRoom(ctx, "expiring"))
+		_, _, err = s.LoadRoom(ctx, "expiring", false)
+		require.ErrorIs(t, err, ErrRoomNotFound)
+	})
+}
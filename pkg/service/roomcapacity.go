@@ -0,0 +1,152 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// changes the participant cap of an active room on UpdateRoomMetadata requests, 0 removes the cap
+	maxParticipantsHeader = "X-Livekit-Max-Participants"
+
+	// the command changing the participant cap, its payload is the cap
+	setMaxParticipantsCommand routing.RoomCommandType = "set-max-participants"
+
+	// RoomFullReason identifies joins rejected by the participant cap of the room
+	RoomFullReason = "ROOM_FULL"
+)
+
+// RoomFullError rejects participants joining a room that has reached its participant cap
+type RoomFullError struct {
+	MaxParticipants uint32
+}
+
+func (e *RoomFullError) Error() string {
+	return fmt.Sprintf("%s, max participants %d", ErrRoomFull.Error(), e.MaxParticipants)
+}
+
+func (e *RoomFullError) Unwrap() error {
+	return ErrRoomFull
+}
+
+type roomFullResponse struct {
+	Error           string `json:"error"`
+	Reason          string `json:"reason"`
+	MaxParticipants uint32 `json:"max_participants"`
+}
+
+// write responds to the join with the reason of the rejection, so that clients can tell it from auth failures
+func (e *RoomFullError) write(w http.ResponseWriter, status int) {
+	b, err := json.Marshal(&roomFullResponse{
+		Error:           ErrRoomFull.Error(),
+		Reason:          RoomFullReason,
+		MaxParticipants: e.MaxParticipants,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func maxParticipantsFromRequest(ctx context.Context) (uint32, bool, error) {
+	value, ok := lookupRequestOption(ctx, maxParticipantsOption)
+	if !ok {
+		return 0, false, nil
+	}
+	maxParticipants, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false, ErrInvalidMaxParticipants
+	}
+	return uint32(maxParticipants), true, nil
+}
+
+// setMaxParticipants changes the participant cap of the room on the node hosting it. participants already in the
+// room are not removed when the cap is lowered below their number
+func (s *RoomService) setMaxParticipants(ctx context.Context, roomName livekit.RoomName, maxParticipants uint32) (*livekit.Room, error) {
+	AppendLogFields(ctx, "maxParticipants", maxParticipants)
+	err := s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:    setMaxParticipantsCommand,
+		Room:    roomName,
+		Payload: []byte(strconv.FormatUint(uint64(maxParticipants), 10)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var room *livekit.Room
+	err = s.confirmExecution(func() error {
+		room, _, err = s.roomStore.LoadRoom(ctx, roomName, false)
+		if err != nil {
+			return err
+		}
+		if room.MaxParticipants != maxParticipants {
+			return ErrOperationFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// ensureRoomNotFull rejects joining a room that has reached its participant cap. the cap is checked against the
+// participants of the store, so that it is enforced whichever node receives the join. recorders don't count
+// towards the cap, and participants already in the room can always rejoin
+func (s *RTCService) ensureRoomNotFull(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, recorder bool, reconnect bool) error {
+	if recorder || reconnect {
+		return nil
+	}
+	room, _, err := s.store.LoadRoom(ctx, roomName, false)
+	if err != nil {
+		if err == ErrRoomNotFound {
+			return nil
+		}
+		return err
+	}
+	if room.MaxParticipants == 0 {
+		return nil
+	}
+
+	participants, err := s.store.ListParticipants(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	numParticipants := uint32(0)
+	for _, pi := range participants {
+		if livekit.ParticipantIdentity(pi.Identity) == identity {
+			return nil
+		}
+		if !pi.GetPermission().GetRecorder() {
+			numParticipants++
+		}
+	}
+	if numParticipants >= room.MaxParticipants {
+		return &RoomFullError{MaxParticipants: room.MaxParticipants}
+	}
+	return nil
+}
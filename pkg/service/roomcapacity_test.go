@@ -0,0 +1,91 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestMaxParticipantsFromRequest(t *testing.T) {
+	ctx := func(value string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{maxParticipantsHeader: []string{value}})
+	}
+
+	_, ok, err := maxParticipantsFromRequest(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	maxParticipants, ok, err := maxParticipantsFromRequest(ctx("0"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Zero(t, maxParticipants)
+
+	maxParticipants, _, err = maxParticipantsFromRequest(ctx("25"))
+	require.NoError(t, err)
+	require.Equal(t, uint32(25), maxParticipants)
+
+	_, _, err = maxParticipantsFromRequest(ctx("-1"))
+	require.ErrorIs(t, err, ErrInvalidMaxParticipants)
+}
+
+func TestEnsureRoomNotFull(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "capped", MaxParticipants: 2}, nil))
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "open"}, nil))
+	for _, pi := range []*livekit.ParticipantInfo{
+		{Identity: "alice"},
+		{Identity: "bob"},
+		{Identity: "recorder", Permission: &livekit.ParticipantPermission{Recorder: true}},
+	} {
+		require.NoError(t, store.StoreParticipant(ctx, "capped", pi))
+		require.NoError(t, store.StoreParticipant(ctx, "open", pi))
+	}
+	s := &RTCService{store: store}
+
+	err := s.ensureRoomNotFull(ctx, "capped", "carol", false, false)
+	var full *RoomFullError
+	require.True(t, errors.As(err, &full))
+	require.ErrorIs(t, err, ErrRoomFull)
+	require.Equal(t, uint32(2), full.MaxParticipants)
+
+	// participants in the room, reconnects and recorders are not capped
+	require.NoError(t, s.ensureRoomNotFull(ctx, "capped", "alice", false, false))
+	require.NoError(t, s.ensureRoomNotFull(ctx, "capped", "carol", false, true))
+	require.NoError(t, s.ensureRoomNotFull(ctx, "capped", "carol", true, false))
+
+	require.NoError(t, s.ensureRoomNotFull(ctx, "open", "carol", false, false))
+	require.NoError(t, s.ensureRoomNotFull(ctx, "missing", "carol", false, false))
+}
+
+func TestRoomFullError(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleJoinError(w, http.StatusForbidden, &RoomFullError{MaxParticipants: 2})
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	var res roomFullResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, RoomFullReason, res.Reason)
+	require.Equal(t, uint32(2), res.MaxParticipants)
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// seconds that participants are given before the room closes, set on DeleteRoom requests. the room is deleted
+	// when the grace period ends, participants are notified of the remaining time and joins are rejected until then
+	deleteGracePeriodHeader = "X-Livekit-Delete-Grace-Period"
+
+	// the time the room closes in unix seconds, returned on responses to DeleteRoom requests with a grace period
+	roomClosesAtHeader = "X-Livekit-Room-Closes-At"
+
+	// the command closing a room after a grace period, its payload is the time the room closes in unix seconds
+	closeRoomCommand routing.RoomCommandType = "close-room"
+
+	// data packets with the remaining time are sent to participants on this topic
+	RoomClosingTopic = "livekit.room_closing"
+
+	maxDeleteGracePeriod = time.Hour
+)
+
+// RoomClosingNotice is the payload of data packets on RoomClosingTopic
+type RoomClosingNotice struct {
+	ClosesAt         int64 `json:"closes_at"`
+	RemainingSeconds int64 `json:"remaining_seconds"`
+}
+
+func deleteGracePeriodFromRequest(ctx context.Context) (time.Duration, error) {
+	value, ok := lookupRequestOption(ctx, deleteGracePeriodOption)
+	if !ok {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxDeleteGracePeriod {
+		return 0, ErrInvalidGracePeriod
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// deleteRoomAfter has the node hosting the room close it when the grace period ends, it returns without waiting
+// for the room to close
+func (s *RoomService) deleteRoomAfter(ctx context.Context, roomName livekit.RoomName, gracePeriod time.Duration) (*livekit.DeleteRoomResponse, error) {
+	closesAt := time.Now().Add(gracePeriod).Unix()
+	err := s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:    closeRoomCommand,
+		Room:    roomName,
+		Payload: []byte(strconv.FormatInt(closesAt, 10)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = twirp.SetHTTPResponseHeader(ctx, roomClosesAtHeader, strconv.FormatInt(closesAt, 10))
+	return &livekit.DeleteRoomResponse{}, nil
+}
+
+// closeRoomAt schedules the room to close, and notifies participants of the remaining time until it closes
+func (r *RoomManager) closeRoomAt(room *rtc.Room, closesAt time.Time) {
+	scheduled := !room.ClosesAt().IsZero()
+	if !room.SetClosesAt(closesAt) {
+		return
+	}
+	room.Logger.Infow("closing room after grace period", "closesAt", closesAt)
+	// the first notice is sent right away
+	sendRoomClosingNotice(room, time.Until(closesAt))
+	if scheduled {
+		// the countdown of the earlier schedule follows the new one
+		return
+	}
+
+	// notices of the countdown that have passed are skipped
+	_, next := nextRoomExpiryNotice(time.Until(closesAt), 0)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for !room.IsClosed() {
+			<-ticker.C
+			remaining := time.Until(room.ClosesAt())
+			if remaining <= 0 {
+				closeDeletedRoom(room)
+				return
+			}
+
+			var due int
+			due, next = nextRoomExpiryNotice(remaining, next)
+			if due >= 0 {
+				sendRoomClosingNotice(room, remaining)
+			}
+		}
+	}()
+}
+
+func sendRoomClosingNotice(room *rtc.Room, remaining time.Duration) {
+	payload, err := json.Marshal(&RoomClosingNotice{
+		ClosesAt:         room.ClosesAt().Unix(),
+		RemainingSeconds: int64((remaining + time.Second - 1) / time.Second),
+	})
+	if err != nil {
+		return
+	}
+	topic := RoomClosingTopic
+	room.SendDataPacket(&livekit.UserPacket{
+		Payload: payload,
+		Topic:   &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
+
+// closeDeletedRoom disconnects the participants of a room that has been deleted, and closes it
+func closeDeletedRoom(room *rtc.Room) {
+	room.Logger.Infow("deleting room")
+	for _, p := range room.GetParticipants() {
+		_ = p.Close(true, types.ParticipantCloseReasonServiceRequestDeleteRoom, false)
+	}
+	room.CloseWithReason(types.RoomCloseReasonServiceRequestDeleteRoom)
+}
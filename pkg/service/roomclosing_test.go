@@ -0,0 +1,43 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteGracePeriodFromRequest(t *testing.T) {
+	ctx := func(value string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{deleteGracePeriodHeader: []string{value}})
+	}
+
+	gracePeriod, err := deleteGracePeriodFromRequest(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, gracePeriod)
+
+	gracePeriod, err = deleteGracePeriodFromRequest(ctx("90"))
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Second, gracePeriod)
+
+	for _, value := range []string{"-1", "1m", "3601"} {
+		_, err = deleteGracePeriodFromRequest(ctx(value))
+		require.ErrorIs(t, err, ErrInvalidGracePeriod, value)
+	}
+}
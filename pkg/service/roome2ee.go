@@ -0,0 +1,74 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	// requires end-to-end encryption of the tracks published to the room, set on CreateRoom requests and returned on
+	// their responses. the requirement cannot be removed from a room
+	roomE2EEHeader = "X-Livekit-Room-E2ee-Required"
+
+	// the requirement is kept with the labels of the room, for the node hosting the room to find when starting it
+	roomE2EELabel = reservedLabelPrefix + "e2ee-required"
+
+	// the command requiring encryption of a room that is hosted already
+	requireE2EECommand routing.RoomCommandType = "require-e2ee"
+)
+
+func roomE2EEFromRequest(ctx context.Context) (bool, error) {
+	value, ok := lookupRequestOption(ctx, roomE2EEOption)
+	if !ok {
+		return false, nil
+	}
+	required, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, ErrInvalidRoomE2EE
+	}
+	return required, nil
+}
+
+// RoomE2EERequired returns whether the labels of a room require end-to-end encryption
+func RoomE2EERequired(labels RoomLabels) bool {
+	return labels[roomE2EELabel] == "true"
+}
+
+// requireRoomE2EE keeps the requirement with the labels of the room before it is started, and has the node hosting
+// the room enforce it when the room is started already
+func (s *RoomService) requireRoomE2EE(ctx context.Context, roomName livekit.RoomName) error {
+	_, err := changeRoomLabels(ctx, s.roomStore, roomName, func(labels RoomLabels) (bool, error) {
+		if RoomE2EERequired(labels) {
+			return false, nil
+		}
+		labels[roomE2EELabel] = "true"
+		return true, nil
+	})
+	return err
+}
+
+// notifyRoomE2EE has the node hosting the room enforce the requirement of its labels
+func (s *RoomService) notifyRoomE2EE(ctx context.Context, roomName livekit.RoomName) error {
+	return s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type: requireE2EECommand,
+		Room: roomName,
+	})
+}
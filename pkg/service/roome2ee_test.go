@@ -0,0 +1,52 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestRoomE2EE(t *testing.T) {
+	header := func(value string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{"X-Livekit-Room-E2ee-Required": {value}})
+	}
+	required, err := roomE2EEFromRequest(header("true"))
+	require.NoError(t, err)
+	require.True(t, required)
+
+	_, err = roomE2EEFromRequest(header("yes"))
+	require.ErrorIs(t, err, ErrInvalidRoomE2EE)
+
+	required, err = roomE2EEFromRequest(context.Background())
+	require.NoError(t, err)
+	require.False(t, required)
+
+	// the requirement is kept with the labels of the room
+	ctx := context.Background()
+	store := NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "private"}, nil))
+	s := &RoomService{roomStore: store}
+	require.NoError(t, s.requireRoomE2EE(ctx, "private"))
+	labels, err := store.LoadRoomLabels(ctx, "private")
+	require.NoError(t, err)
+	require.True(t, RoomE2EERequired(labels))
+	require.Empty(t, labels.withoutReserved())
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// number of events kept for subscribers resuming after a sequence
+	roomEventLogSize = 10000
+
+	RoomEventLogKey      = "{room_events}:log"
+	RoomEventSequenceKey = "{room_events}:sequence"
+)
+
+// RoomEvent is a webhook event with its sequence in the events of the server, sequences are increasing
+type RoomEvent struct {
+	Sequence uint64
+	Event    *livekit.WebhookEvent
+}
+
+// roomEventLog keeps the latest events of the server with their sequences
+type roomEventLog interface {
+	Append(ctx context.Context, event *livekit.WebhookEvent) (uint64, error)
+	// Read returns up to count events with sequences after after, ErrEventCursorExpired when some of them are no
+	// longer kept
+	Read(ctx context.Context, after uint64, count int) ([]*RoomEvent, error)
+	// Head returns the sequence of the latest event, 0 without events
+	Head(ctx context.Context) (uint64, error)
+}
+
+// localRoomEventLog keeps the events of a single node in memory
+type localRoomEventLog struct {
+	lock   sync.RWMutex
+	events []*RoomEvent
+	head   uint64
+}
+
+func newLocalRoomEventLog() *localRoomEventLog {
+	return &localRoomEventLog{
+		events: make([]*RoomEvent, roomEventLogSize),
+	}
+}
+
+func (l *localRoomEventLog) Append(_ context.Context, event *livekit.WebhookEvent) (uint64, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.head++
+	l.events[l.head%roomEventLogSize] = &RoomEvent{
+		Sequence: l.head,
+		Event:    event,
+	}
+	return l.head, nil
+}
+
+func (l *localRoomEventLog) Read(_ context.Context, after uint64, count int) ([]*RoomEvent, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if after >= l.head {
+		return nil, nil
+	}
+	if l.head-after > roomEventLogSize {
+		return nil, ErrEventCursorExpired
+	}
+	events := make([]*RoomEvent, 0, count)
+	for seq := after + 1; seq <= l.head && len(events) < count; seq++ {
+		events = append(events, l.events[seq%roomEventLogSize])
+	}
+	return events, nil
+}
+
+func (l *localRoomEventLog) Head(_ context.Context) (uint64, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.head, nil
+}
+
+// the sequence of an event is the ID of its stream entry, sequences are incremented with the entry added for them
+var appendRoomEventScript = redis.NewScript(`
+local seq = redis.call("INCR", KEYS[2])
+redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[1], seq .. "-0", "event", ARGV[2])
+return seq
+`)
+
+// redisRoomEventLog keeps the events of all nodes in a redis stream
+type redisRoomEventLog struct {
+	rc          redis.UniversalClient
+	logKey      string
+	sequenceKey string
+}
+
+func newRedisRoomEventLog(rc redis.UniversalClient, prefix string) *redisRoomEventLog {
+	return &redisRoomEventLog{
+		rc:          rc,
+		logKey:      prefix + RoomEventLogKey,
+		sequenceKey: prefix + RoomEventSequenceKey,
+	}
+}
+
+func (l *redisRoomEventLog) Append(ctx context.Context, event *livekit.WebhookEvent) (uint64, error) {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := appendRoomEventScript.Run(ctx, l.rc, []string{l.logKey, l.sequenceKey}, roomEventLogSize, data).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(seq), nil
+}
+
+func (l *redisRoomEventLog) Read(ctx context.Context, after uint64, count int) ([]*RoomEvent, error) {
+	msgs, err := l.rc.XRangeN(ctx, l.logKey, fmt.Sprintf("%d-0", after+1), "+", int64(count)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		head, err := l.Head(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if head > after {
+			return nil, ErrEventCursorExpired
+		}
+		return nil, nil
+	}
+
+	events := make([]*RoomEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		id, _, _ := strings.Cut(msg.ID, "-")
+		seq, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 && seq != after+1 {
+			// trimmed from the stream
+			return nil, ErrEventCursorExpired
+		}
+		data, _ := msg.Values["event"].(string)
+		event := &livekit.WebhookEvent{}
+		if err = proto.Unmarshal([]byte(data), event); err != nil {
+			return nil, err
+		}
+		events = append(events, &RoomEvent{
+			Sequence: seq,
+			Event:    event,
+		})
+	}
+	return events, nil
+}
+
+func (l *redisRoomEventLog) Head(ctx context.Context) (uint64, error) {
+	seq, err := l.rc.Get(ctx, l.sequenceKey).Uint64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return seq, err
+}
@@ -0,0 +1,355 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/thoas/go-funk"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	// the events of all nodes are published on this channel, for subscribers connected to any node
+	RoomEventsChannel = "room_events"
+
+	roomEventsBufferSize        = 256
+	roomEventsKeepaliveInterval = 15 * time.Second
+)
+
+// RoomEventBroker queues the webhook events of the server to the configured webhooks, and delivers them to the
+// subscribers of all nodes. events are kept in a log with their sequences, for subscribers resuming after one
+type RoomEventBroker struct {
+	// nil when webhooks are not configured
+	notifier *WebhookNotifier
+	rc       redis.UniversalClient
+	log      roomEventLog
+
+	lock        sync.RWMutex
+	subscribers map[*RoomEventSubscription]struct{}
+}
+
+func NewRoomEventBroker(conf *config.Config, provider auth.KeyProvider, rc redis.UniversalClient) (*RoomEventBroker, error) {
+	notifier, err := createWebhookNotifier(conf, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &RoomEventBroker{
+		notifier:    notifier,
+		rc:          rc,
+		log:         newLocalRoomEventLog(),
+		subscribers: make(map[*RoomEventSubscription]struct{}),
+	}
+	if rc != nil {
+		b.log = newRedisRoomEventLog(rc, conf.Redis.KeyPrefix)
+		go b.eventWorker(rc.Subscribe(context.Background(), RoomEventsChannel))
+	}
+	return b, nil
+}
+
+func (b *RoomEventBroker) QueueNotify(ctx context.Context, event *livekit.WebhookEvent) error {
+	var err error
+	if b.notifier != nil {
+		err = b.notifier.QueueNotify(ctx, event)
+	}
+	// appended before it's delivered, for subscribers reading the log when they are notified
+	if _, lerr := b.log.Append(ctx, event); lerr != nil {
+		logger.Warnw("could not append room event to log", lerr, "event", event.Event)
+	}
+
+	if b.rc == nil {
+		b.deliver(event)
+		return err
+	}
+	data, perr := proto.Marshal(event)
+	if perr != nil {
+		return perr
+	}
+	// delivered to subscribers of this node by the worker too
+	if perr = b.rc.Publish(ctx, RoomEventsChannel, data).Err(); perr != nil {
+		logger.Warnw("could not publish room event", perr, "event", event.Event)
+	}
+	return err
+}
+
+// ReadEvents returns up to count events with sequences after after, ErrEventCursorExpired when some of them are no
+// longer kept
+func (b *RoomEventBroker) ReadEvents(ctx context.Context, after uint64, count int) ([]*RoomEvent, error) {
+	return b.log.Read(ctx, after, count)
+}
+
+// LastSequence returns the sequence of the latest event
+func (b *RoomEventBroker) LastSequence(ctx context.Context) (uint64, error) {
+	return b.log.Head(ctx)
+}
+
+// findEvents returns the events kept in the log that match, in the order of their sequences
+func (b *RoomEventBroker) findEvents(ctx context.Context, match func(event *livekit.WebhookEvent) bool) ([]*livekit.WebhookEvent, error) {
+	head, err := b.log.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var after uint64
+	if head > roomEventLogSize {
+		after = head - roomEventLogSize
+	}
+
+	var events []*livekit.WebhookEvent
+	for after < head {
+		page, err := b.log.Read(ctx, after, redeliveryReadSize)
+		if err == ErrEventCursorExpired {
+			// trimmed by events appended since, later events are still kept
+			after += redeliveryReadSize
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, e := range page {
+			if match(e.Event) {
+				events = append(events, e.Event)
+			}
+		}
+		after = page[len(page)-1].Sequence
+	}
+	return events, nil
+}
+
+func (b *RoomEventBroker) eventWorker(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		if msg == nil {
+			return
+		}
+		event := &livekit.WebhookEvent{}
+		if err := proto.Unmarshal([]byte(msg.Payload), event); err != nil {
+			logger.Warnw("could not decode room event", err)
+			continue
+		}
+		b.deliver(event)
+	}
+}
+
+func (b *RoomEventBroker) deliver(event *livekit.WebhookEvent) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// subscribers that can't keep up miss events rather than holding up others
+			logger.Infow("dropping room event of slow subscriber", "event", event.Event)
+		}
+	}
+}
+
+// Subscribe returns a subscription to the events of the rooms, or of all rooms when rooms is empty. events with
+// names in eventNames are delivered, all events when it is empty
+func (b *RoomEventBroker) Subscribe(rooms []livekit.RoomName, eventNames []string) *RoomEventSubscription {
+	sub := &RoomEventSubscription{
+		broker:     b,
+		rooms:      rooms,
+		eventNames: eventNames,
+		events:     make(chan *livekit.WebhookEvent, roomEventsBufferSize),
+	}
+	b.lock.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.lock.Unlock()
+	return sub
+}
+
+type RoomEventSubscription struct {
+	broker     *RoomEventBroker
+	rooms      []livekit.RoomName
+	eventNames []string
+	// project the subscription is limited to when rooms is empty, nil when not limited
+	project *string
+	events  chan *livekit.WebhookEvent
+}
+
+func (s *RoomEventSubscription) Events() <-chan *livekit.WebhookEvent {
+	return s.events
+}
+
+func (s *RoomEventSubscription) Close() {
+	s.broker.lock.Lock()
+	delete(s.broker.subscribers, s)
+	s.broker.lock.Unlock()
+}
+
+func (s *RoomEventSubscription) matches(event *livekit.WebhookEvent) bool {
+	if len(s.eventNames) != 0 && !funk.ContainsString(s.eventNames, event.Event) {
+		return false
+	}
+
+	roomName := roomNameOfEvent(event)
+	if len(s.rooms) != 0 {
+		return funk.Contains(s.rooms, roomName)
+	}
+	if s.project != nil {
+		project, _ := utils.SplitProjectRoomName(roomName)
+		return project == *s.project
+	}
+	return true
+}
+
+func roomNameOfEvent(event *livekit.WebhookEvent) livekit.RoomName {
+	switch {
+	case event.Room != nil:
+		return livekit.RoomName(event.Room.Name)
+	case event.EgressInfo != nil:
+		return livekit.RoomName(event.EgressInfo.RoomName)
+	case event.IngressInfo != nil:
+		return livekit.RoomName(event.IngressInfo.RoomName)
+	}
+	return ""
+}
+
+// unscopeEvent returns the event with the room names of its project
+func unscopeEvent(event *livekit.WebhookEvent) *livekit.WebhookEvent {
+	event = proto.Clone(event).(*livekit.WebhookEvent)
+	if event.Room != nil {
+		_, name := utils.SplitProjectRoomName(livekit.RoomName(event.Room.Name))
+		event.Room.Name = string(name)
+	}
+	if event.EgressInfo != nil {
+		_, name := utils.SplitProjectRoomName(livekit.RoomName(event.EgressInfo.RoomName))
+		event.EgressInfo.RoomName = string(name)
+	}
+	if event.IngressInfo != nil {
+		_, name := utils.SplitProjectRoomName(livekit.RoomName(event.IngressInfo.RoomName))
+		event.IngressInfo.RoomName = string(name)
+	}
+	return event
+}
+
+// RoomEventsService streams the room, participant and track events of the server at /events as server-sent events,
+// such as for backends that can't receive webhooks. events are those sent to webhooks, in the same JSON encoding.
+// GET ?room=&event= streams the events of the rooms, of all rooms when no room is given. room and event can be
+// repeated
+type RoomEventsService struct {
+	broker *RoomEventBroker
+}
+
+func NewRoomEventsService(broker *RoomEventBroker) *RoomEventsService {
+	return &RoomEventsService{
+		broker: broker,
+	}
+}
+
+// subscribe returns a subscription to the events of the rooms, the events of all rooms require list and admin
+// permissions, the events of a room admin permission of the room
+func (s *RoomEventsService) subscribe(ctx context.Context, rooms []string, eventNames []string) (*RoomEventSubscription, error) {
+	project, scoped := GetProject(ctx)
+	roomNames := make([]livekit.RoomName, 0, len(rooms))
+	for _, room := range rooms {
+		if scoped {
+			var err error
+			if room, err = scopeRoomName(project, room); err != nil {
+				return nil, err
+			}
+		}
+		roomNames = append(roomNames, livekit.RoomName(room))
+	}
+
+	if len(roomNames) == 1 && EnsureAdminPermission(ctx, roomNames[0]) == nil {
+		return s.broker.Subscribe(roomNames, eventNames), nil
+	}
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, err
+	}
+	if claims := GetGrants(ctx); !claims.Video.RoomAdmin {
+		return nil, ErrPermissionDenied
+	}
+
+	sub := s.broker.Subscribe(roomNames, eventNames)
+	if scoped {
+		sub.project = &project
+	}
+	return sub, nil
+}
+
+func (s *RoomEventsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	query := r.URL.Query()
+	sub, err := s.subscribe(r.Context(), query["room"], query["event"])
+	if err != nil {
+		handleError(w, errorStatus(err), err)
+		return
+	}
+	defer sub.Close()
+	_, scoped := GetProject(r.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(roomEventsKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err = fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case event := <-sub.Events():
+			if scoped {
+				event = unscopeEvent(event)
+			}
+			data, err := protojson.Marshal(event)
+			if err != nil {
+				logger.Warnw("could not encode room event", err, "event", event.Event)
+				continue
+			}
+			if _, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Event, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
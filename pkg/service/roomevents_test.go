@@ -0,0 +1,113 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRoomEventBroker(t *testing.T) {
+	broker, err := service.NewRoomEventBroker(&config.Config{}, nil, nil)
+	require.NoError(t, err)
+
+	all := broker.Subscribe(nil, nil)
+	defer all.Close()
+	standup := broker.Subscribe([]livekit.RoomName{"standup"}, []string{webhook.EventParticipantJoined})
+	defer standup.Close()
+
+	ctx := context.Background()
+	require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventRoomStarted, Room: &livekit.Room{Name: "standup"}}))
+	require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventParticipantJoined, Room: &livekit.Room{Name: "retro"}}))
+	require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventParticipantJoined, Room: &livekit.Room{Name: "standup"}}))
+
+	require.Len(t, all.Events(), 3)
+	require.Len(t, standup.Events(), 1)
+	event := <-standup.Events()
+	require.Equal(t, webhook.EventParticipantJoined, event.Event)
+	require.Equal(t, "standup", event.Room.Name)
+
+	all.Close()
+	require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventRoomFinished, Room: &livekit.Room{Name: "standup"}}))
+	require.Len(t, all.Events(), 3)
+}
+
+func TestRoomEventsService(t *testing.T) {
+	broker, err := service.NewRoomEventBroker(&config.Config{}, nil, nil)
+	require.NoError(t, err)
+	svc := service.NewRoomEventsService(broker)
+
+	grant := &auth.VideoGrant{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		svc.ServeHTTP(w, r.WithContext(service.WithGrants(r.Context(), &auth.ClaimGrants{Video: grant})))
+	}))
+	defer server.Close()
+
+	t.Run("requires admin permission", func(t *testing.T) {
+		*grant = auth.VideoGrant{RoomAdmin: true, Room: "standup"}
+		res, err := http.Get(server.URL + "?room=retro")
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		*grant = auth.VideoGrant{RoomList: true}
+		res, err = http.Get(server.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("streams events of the room", func(t *testing.T) {
+		*grant = auth.VideoGrant{RoomAdmin: true, Room: "standup"}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?room=standup", nil)
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Id: "EV_retro", Event: webhook.EventRoomStarted, Room: &livekit.Room{Name: "retro"}}))
+		require.NoError(t, broker.QueueNotify(ctx, &livekit.WebhookEvent{Id: "EV_standup", Event: webhook.EventRoomStarted, Room: &livekit.Room{Name: "standup"}}))
+
+		reader := bufio.NewReader(res.Body)
+		var lines []string
+		for len(lines) < 3 {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		require.Equal(t, "id: EV_standup", lines[0])
+		require.Equal(t, "event: "+webhook.EventRoomStarted, lines[1])
+		require.True(t, strings.HasPrefix(lines[2], "data: {"))
+		require.Contains(t, lines[2], `"standup"`)
+	})
+}
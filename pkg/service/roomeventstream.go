@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/roomevents"
+)
+
+const (
+	roomEventsReadCount    = 100
+	roomEventsPollInterval = time.Second
+)
+
+// roomEventsGRPCServer serves the room events of RoomEventsService as roomevents.RoomEvents
+type roomEventsGRPCServer struct {
+	roomevents.UnimplementedRoomEventsServer
+	service *RoomEventsService
+}
+
+func (s *roomEventsGRPCServer) Subscribe(req *roomevents.SubscribeRequest, stream roomevents.RoomEvents_SubscribeServer) error {
+	return toGRPCError(s.service.subscribeStream(req, stream))
+}
+
+// subscribeStream sends the events of the requested rooms in the order of their sequences, from the log of the
+// broker. events are delivered at least once to clients resuming after the sequence of the last event they received,
+// streams fail with ErrEventCursorExpired when events after it are no longer kept
+func (s *RoomEventsService) subscribeStream(req *roomevents.SubscribeRequest, stream roomevents.RoomEvents_SubscribeServer) error {
+	ctx := stream.Context()
+	sub, err := s.subscribe(ctx, req.Rooms, req.Events)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	_, scoped := GetProject(ctx)
+
+	// subscribed before reading the latest sequence, events appended after it notify the subscription
+	after := req.ResumeAfter
+	if after == 0 {
+		if after, err = s.broker.LastSequence(ctx); err != nil {
+			return err
+		}
+	}
+
+	poll := time.NewTicker(roomEventsPollInterval)
+	defer poll.Stop()
+	for {
+		events, err := s.broker.ReadEvents(ctx, after, roomEventsReadCount)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			after = e.Sequence
+			if !sub.matches(e.Event) {
+				continue
+			}
+			event := e.Event
+			if scoped {
+				event = unscopeEvent(event)
+			}
+			if err = stream.Send(&roomevents.SubscribeResponse{Sequence: e.Sequence, Event: event}); err != nil {
+				return err
+			}
+		}
+		if len(events) == roomEventsReadCount {
+			continue
+		}
+
+		// subscriptions that miss notifications catch up when polling
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.Events():
+		case <-poll.C:
+		}
+	}
+}
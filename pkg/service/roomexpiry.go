@@ -0,0 +1,175 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// absolute expiry of the room in unix seconds, set on CreateRoom requests and returned on their responses.
+	// the room is closed and deleted when it expires
+	roomExpiresAtHeader = "X-Livekit-Room-Expires-At"
+
+	// the expiry is kept with the labels of the room, so that every store persists it
+	roomExpiresAtLabel = reservedLabelPrefix + "expires-at"
+
+	// data packets with the remaining time are sent to participants on this topic
+	RoomExpiryTopic = "livekit.room_expiry"
+
+	// expiries are read from the store at this interval, so that expiries set on rooms already hosted are found
+	roomExpiryRefreshInterval = 10 * time.Second
+)
+
+// participants are notified when the remaining time of the room reaches each of these
+var roomExpiryCountdown = []time.Duration{
+	5 * time.Minute,
+	time.Minute,
+	30 * time.Second,
+	10 * time.Second,
+	5 * time.Second,
+	4 * time.Second,
+	3 * time.Second,
+	2 * time.Second,
+	time.Second,
+}
+
+// RoomExpiryNotice is the payload of data packets on RoomExpiryTopic
+type RoomExpiryNotice struct {
+	ExpiresAt        int64 `json:"expires_at"`
+	RemainingSeconds int64 `json:"remaining_seconds"`
+}
+
+type roomExpiry struct {
+	expiresAt time.Time
+	// index into roomExpiryCountdown of the next notice
+	nextNotice int
+}
+
+func roomExpiryFromRequest(ctx context.Context) (time.Time, error) {
+	value, ok := lookupRequestOption(ctx, roomExpiresAtOption)
+	if !ok {
+		return time.Time{}, nil
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidRoomExpiry
+	}
+	expiresAt := time.Unix(unix, 0)
+	if !expiresAt.After(time.Now()) {
+		return time.Time{}, ErrInvalidRoomExpiry
+	}
+	return expiresAt, nil
+}
+
+// RoomExpiresAt returns the expiry kept with the labels of a room, zero if the room does not expire
+func RoomExpiresAt(labels RoomLabels) time.Time {
+	unix, err := strconv.ParseInt(labels[roomExpiresAtLabel], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
+
+// nextRoomExpiryNotice returns the countdown index of the notice due at the remaining time, and the index
+// of the following notice. due is -1 when no notice is due, only the latest of passed notices is due.
+func nextRoomExpiryNotice(remaining time.Duration, next int) (due int, following int) {
+	due = -1
+	for next < len(roomExpiryCountdown) && remaining <= roomExpiryCountdown[next] {
+		due = next
+		next++
+	}
+	return due, next
+}
+
+// CloseExpiredRooms notifies participants of rooms approaching their expiry, and closes rooms that have expired.
+// it is called periodically from a single worker
+func (r *RoomManager) CloseExpiredRooms() {
+	if time.Since(r.roomExpiryRefreshedAt) >= roomExpiryRefreshInterval {
+		r.refreshRoomSchedules(r.RoomNames())
+	}
+
+	now := time.Now()
+	for name, expiry := range r.roomExpiries {
+		room := r.GetRoom(context.Background(), name)
+		if room == nil {
+			delete(r.roomExpiries, name)
+			continue
+		}
+
+		remaining := expiry.expiresAt.Sub(now)
+		if remaining <= 0 {
+			room.Logger.Infow("room expired", "expiresAt", expiry.expiresAt)
+			delete(r.roomExpiries, name)
+			room.CloseWithReason(types.RoomCloseReasonExpired)
+			continue
+		}
+
+		var due int
+		due, expiry.nextNotice = nextRoomExpiryNotice(remaining, expiry.nextNotice)
+		if due < 0 {
+			continue
+		}
+		payload, err := json.Marshal(&RoomExpiryNotice{
+			ExpiresAt:        expiry.expiresAt.Unix(),
+			RemainingSeconds: int64((remaining + time.Second - 1) / time.Second),
+		})
+		if err != nil {
+			continue
+		}
+		topic := RoomExpiryTopic
+		room.SendDataPacket(&livekit.UserPacket{
+			Payload: payload,
+			Topic:   &topic,
+		}, livekit.DataPacket_RELIABLE)
+	}
+}
+
+// refreshRoomSchedules reads the start and expiry of the rooms from the store
+func (r *RoomManager) refreshRoomSchedules(names []livekit.RoomName) {
+	r.roomExpiryRefreshedAt = time.Now()
+	for _, name := range names {
+		labels, err := r.roomStore.LoadRoomLabels(context.Background(), name)
+		if err != nil {
+			if err != ErrRoomNotFound {
+				logger.Warnw("could not load room schedule", err, "room", name)
+			}
+			continue
+		}
+
+		if startsAt := RoomStartsAt(labels); !startsAt.IsZero() {
+			if room := r.GetRoom(context.Background(), name); room != nil {
+				room.SetStartsAt(startsAt)
+			}
+		}
+
+		expiresAt := RoomExpiresAt(labels)
+		if expiresAt.IsZero() {
+			delete(r.roomExpiries, name)
+			continue
+		}
+		if expiry := r.roomExpiries[name]; expiry == nil || !expiry.expiresAt.Equal(expiresAt) {
+			r.roomExpiries[name] = &roomExpiry{expiresAt: expiresAt}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRoomExpiryNotice(t *testing.T) {
+	// no notice before the countdown
+	due, next := nextRoomExpiryNotice(10*time.Minute, 0)
+	require.Equal(t, -1, due)
+	require.Equal(t, 0, next)
+
+	// a room found with less than a minute remaining gets a single notice
+	due, next = nextRoomExpiryNotice(45*time.Second, 0)
+	require.Equal(t, 1, due)
+	require.Equal(t, 2, next)
+
+	// notices are sent once
+	due, next = nextRoomExpiryNotice(40*time.Second, next)
+	require.Equal(t, -1, due)
+	require.Equal(t, 2, next)
+
+	due, next = nextRoomExpiryNotice(time.Second, next)
+	require.Equal(t, len(roomExpiryCountdown)-1, due)
+	require.Equal(t, len(roomExpiryCountdown), next)
+}
+
+func TestRoomExpiresAt(t *testing.T) {
+	require.True(t, RoomExpiresAt(RoomLabels{"product": "exam"}).IsZero())
+	require.Equal(t, int64(1700000000), RoomExpiresAt(RoomLabels{roomExpiresAtLabel: "1700000000"}).Unix())
+
+	// reserved labels are not set through the API
+	_, err := ParseRoomLabels(roomExpiresAtLabel + "=1700000000")
+	require.ErrorIs(t, err, ErrInvalidRoomLabels)
+
+	labels := RoomLabels{"product": "exam", roomExpiresAtLabel: "1700000000"}
+	require.Equal(t, "product=exam", labels.withoutReserved().String())
+}
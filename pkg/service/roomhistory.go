@@ -0,0 +1,137 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	defaultRoomHistoryLimit = 100
+	maxRoomHistoryLimit     = 1000
+)
+
+// RoomHistory is the record of a room that has ended
+type RoomHistory struct {
+	Name             string    `json:"name"`
+	Sid              string    `json:"sid"`
+	Metadata         string    `json:"metadata,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	EndedAt          time.Time `json:"ended_at"`
+	PeakParticipants uint32    `json:"peak_participants"`
+	EndReason        string    `json:"end_reason"`
+}
+
+func (h *RoomHistory) Duration() time.Duration {
+	return h.EndedAt.Sub(h.StartedAt)
+}
+
+// RoomHistoryService serves the history of ended rooms at /room_history
+type RoomHistoryService struct {
+	store RoomHistoryStore
+}
+
+func NewRoomHistoryService(store RoomHistoryStore) *RoomHistoryService {
+	return &RoomHistoryService{
+		store: store,
+	}
+}
+
+// ListRoomHistory returns rooms that have ended, the latest first. all rooms are returned when roomName is empty
+func (s *RoomHistoryService) ListRoomHistory(ctx context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error) {
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, err
+	}
+	if s.store == nil {
+		return nil, ErrRoomHistoryNotEnabled
+	}
+
+	if limit <= 0 {
+		limit = defaultRoomHistoryLimit
+	} else if limit > maxRoomHistoryLimit {
+		limit = maxRoomHistoryLimit
+	}
+
+	project, ok := GetProject(ctx)
+	if !ok {
+		return s.store.ListRoomHistory(ctx, roomName, limit)
+	}
+
+	if roomName != "" {
+		name, err := scopeRoomName(project, string(roomName))
+		if err != nil {
+			return nil, err
+		}
+		roomName = livekit.RoomName(name)
+	}
+	history, err := s.store.ListRoomHistory(ctx, roomName, limit)
+	if err != nil {
+		return nil, err
+	}
+	// rooms of other projects are left out, a page may hold fewer than limit rooms
+	scoped := make([]*RoomHistory, 0, len(history))
+	for _, h := range history {
+		if p, name := utils.SplitProjectRoomName(livekit.RoomName(h.Name)); p == project {
+			c := *h
+			c.Name = string(name)
+			scoped = append(scoped, &c)
+		}
+	}
+	return scoped, nil
+}
+
+type roomHistoryResponse struct {
+	Rooms []roomHistoryEntry `json:"rooms"`
+}
+
+type roomHistoryEntry struct {
+	*RoomHistory
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+func (s *RoomHistoryService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			var limit int
+			if v := r.URL.Query().Get("limit"); v != "" {
+				var err error
+				if limit, err = strconv.Atoi(v); err != nil {
+					return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+				}
+			}
+
+			history, err := s.ListRoomHistory(r.Context(), livekit.RoomName(r.URL.Query().Get("room")), limit)
+			if err != nil {
+				return nil, err
+			}
+			res := &roomHistoryResponse{Rooms: make([]roomHistoryEntry, 0, len(history))}
+			for _, h := range history {
+				res.Rooms = append(res.Rooms, roomHistoryEntry{
+					RoomHistory:     h,
+					DurationSeconds: int64(h.Duration().Seconds()),
+				})
+			}
+			return res, nil
+		},
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// labels of the room, a comma separated list of key=value pairs.
+	// set on CreateRoom and UpdateRoomMetadata requests, returned on their responses
+	roomLabelsHeader = "X-Livekit-Room-Labels"
+	// selector of ListRooms, comma separated requirements of key=value, key!=value, key or !key
+	labelSelectorHeader = "X-Livekit-Label-Selector"
+
+	maxRoomLabels = 32
+
+	// labels with this prefix are kept by the server, and cannot be set through the API
+	reservedLabelPrefix = "livekit.io/"
+
+	// time labels are updated under the lock of the room, as with rooms being created
+	roomLabelsLockDuration = 5 * time.Second
+)
+
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,62}[A-Za-z0-9])?$`)
+	labelValueRegexp = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+// RoomLabels are key/value pairs attached to a room by the API, for grouping rooms in listings
+type RoomLabels map[string]string
+
+func ParseRoomLabels(s string) (RoomLabels, error) {
+	labels := RoomLabels{}
+	if strings.TrimSpace(s) == "" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if !labelKeyRegexp.MatchString(key) || !labelValueRegexp.MatchString(value) || isReservedLabel(key) {
+			return nil, ErrInvalidRoomLabels
+		}
+		labels[key] = value
+	}
+	if len(labels) > maxRoomLabels {
+		return nil, ErrInvalidRoomLabels
+	}
+	return labels, nil
+}
+
+func (l RoomLabels) String() string {
+	pairs := make([]string, 0, len(l))
+	for key, value := range l {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// withoutReserved returns the labels set through the API
+func (l RoomLabels) withoutReserved() RoomLabels {
+	labels := make(RoomLabels, len(l))
+	for key, value := range l {
+		if !isReservedLabel(key) {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// changeRoomLabels has update change the labels of the room under the lock of the room, for updates of different
+// labels not to overwrite each other. labels are stored when update changed them
+func changeRoomLabels(
+	ctx context.Context,
+	store ObjectStore,
+	roomName livekit.RoomName,
+	update func(labels RoomLabels) (bool, error),
+) (RoomLabels, error) {
+	token, err := store.LockRoom(ctx, roomName, roomLabelsLockDuration)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = store.UnlockRoom(ctx, roomName, token)
+	}()
+
+	labels, err := store.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	if labels == nil {
+		labels = RoomLabels{}
+	}
+	changed, err := update(labels)
+	if err != nil || !changed {
+		return labels, err
+	}
+	if err = store.StoreRoomLabels(ctx, roomName, labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func isReservedLabel(key string) bool {
+	return strings.HasPrefix(key, reservedLabelPrefix)
+}
+
+func (l RoomLabels) Marshal() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+func UnmarshalRoomLabels(data []byte) (RoomLabels, error) {
+	labels := RoomLabels{}
+	if len(data) == 0 {
+		return labels, nil
+	}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+type LabelOperator string
+
+const (
+	LabelEquals       LabelOperator = "="
+	LabelNotEquals    LabelOperator = "!="
+	LabelExists       LabelOperator = "exists"
+	LabelDoesNotExist LabelOperator = "!exists"
+)
+
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Value    string
+}
+
+func (r LabelRequirement) Matches(labels RoomLabels) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case LabelEquals:
+		return ok && value == r.Value
+	case LabelNotEquals:
+		return !ok || value != r.Value
+	case LabelExists:
+		return ok
+	case LabelDoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+// LabelSelector matches rooms with labels meeting all of its requirements
+type LabelSelector []LabelRequirement
+
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var selector LabelSelector
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		var req LabelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			req.Key, req.Value, _ = strings.Cut(term, "!=")
+			req.Operator = LabelNotEquals
+		case strings.Contains(term, "="):
+			req.Key, req.Value, _ = strings.Cut(term, "=")
+			req.Operator = LabelEquals
+		case strings.HasPrefix(term, "!"):
+			req.Key = term[1:]
+			req.Operator = LabelDoesNotExist
+		default:
+			req.Key = term
+			req.Operator = LabelExists
+		}
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		// reserved labels keep the state of the server, rooms are not listed by them
+		if !labelKeyRegexp.MatchString(req.Key) || !labelValueRegexp.MatchString(req.Value) || isReservedLabel(req.Key) {
+			return nil, ErrInvalidLabelSelector
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+func (s LabelSelector) Matches(labels RoomLabels) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
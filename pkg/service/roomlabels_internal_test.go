@@ -0,0 +1,67 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestChangeRoomLabels(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room"}, nil))
+
+	// concurrent changes of different labels are all kept
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := changeRoomLabels(ctx, store, "room", func(labels RoomLabels) (bool, error) {
+				labels[fmt.Sprintf("label-%d", i)] = "true"
+				return true, nil
+			})
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	labels, err := store.LoadRoomLabels(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, labels, 20)
+
+	// labels are not stored when they are unchanged or the change fails
+	_, err = changeRoomLabels(ctx, store, "room", func(labels RoomLabels) (bool, error) {
+		labels["label-0"] = "false"
+		return false, nil
+	})
+	require.NoError(t, err)
+	_, err = changeRoomLabels(ctx, store, "room", func(labels RoomLabels) (bool, error) {
+		labels["label-1"] = "false"
+		return true, errors.New("failed")
+	})
+	require.Error(t, err)
+	labels, err = store.LoadRoomLabels(ctx, "room")
+	require.NoError(t, err)
+	require.Equal(t, "true", labels["label-0"])
+	require.Equal(t, "true", labels["label-1"])
+}
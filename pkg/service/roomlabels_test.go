@@ -0,0 +1,106 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestParseRoomLabels(t *testing.T) {
+	labels, err := service.ParseRoomLabels("product=meet, region=us-east-1,beta=")
+	require.NoError(t, err)
+	require.Equal(t, service.RoomLabels{"product": "meet", "region": "us-east-1", "beta": ""}, labels)
+	require.Equal(t, "beta=,product=meet,region=us-east-1", labels.String())
+
+	labels, err = service.ParseRoomLabels("")
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	for _, s := range []string{"=value", "key=a b", "-key=value", "key=value,,"} {
+		_, err = service.ParseRoomLabels(s)
+		require.ErrorIs(t, err, service.ErrInvalidRoomLabels, s)
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	labels := service.RoomLabels{"product": "meet", "region": "eu"}
+
+	for s, expected := range map[string]bool{
+		"":                          true,
+		"product=meet":              true,
+		"product=meet,region!=us":   true,
+		"product":                   true,
+		"!beta":                     true,
+		"product=webinar":           false,
+		"product=meet,region=us":    false,
+		"region!=eu":                false,
+		"beta":                      false,
+		"!product":                  false,
+		"product=meet,region,!beta": true,
+	} {
+		selector, err := service.ParseLabelSelector(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, selector.Matches(labels), s)
+	}
+
+	_, err := service.ParseLabelSelector("product=meet,=x")
+	require.ErrorIs(t, err, service.ErrInvalidLabelSelector)
+
+	// reserved labels cannot be selected
+	for _, s := range []string{"livekit.io/passcode", "!livekit.io/locked", "livekit.io/api-key=APIkey", "product=meet,livekit.io/e2ee!=true"} {
+		_, err = service.ParseLabelSelector(s)
+		require.ErrorIs(t, err, service.ErrInvalidLabelSelector, s)
+	}
+}
+
+func TestListRoomsByLabels(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewLocalStore()
+	for name, labels := range map[string]service.RoomLabels{
+		"meet-1":    {"product": "meet"},
+		"meet-2":    {"product": "meet", "tier": "free"},
+		"webinar-1": {"product": "webinar"},
+		"unlabeled": nil,
+	} {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: name}, nil))
+		if labels != nil {
+			require.NoError(t, store.StoreRoomLabels(ctx, livekit.RoomName(name), labels))
+		}
+	}
+
+	selector, err := service.ParseLabelSelector("product=meet,!tier")
+	require.NoError(t, err)
+	rooms, _, err := store.ListRoomsPage(ctx, service.ListRoomsOptions{LabelSelector: selector})
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	require.Equal(t, "meet-1", rooms[0].Name)
+
+	labels, err := store.LoadRoomLabels(ctx, "meet-2")
+	require.NoError(t, err)
+	require.Equal(t, service.RoomLabels{"product": "meet", "tier": "free"}, labels)
+
+	// labels are removed with the room
+	require.NoError(t, store.DeleteRoom(ctx, "meet-2"))
+	_, err = store.LoadRoomLabels(ctx, "meet-2")
+	require.ErrorIs(t, err, service.ErrRoomNotFound)
+	require.ErrorIs(t, store.StoreRoomLabels(ctx, "meet-2", service.RoomLabels{}), service.ErrRoomNotFound)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	// the lock is kept with the labels of the room, the value is the reason given to rejected participants
+	roomLockedLabel = reservedLabelPrefix + "locked"
+
+	defaultRoomLockReason = "room is locked"
+	maxRoomLockReasonSize = 256
+
+	maxLockRoomRequestSize = 64 * 1024
+)
+
+// RoomLockReason returns the reason new participants are rejected for, when the room is locked
+func RoomLockReason(labels RoomLabels) (string, bool) {
+	reason, ok := labels[roomLockedLabel]
+	return reason, ok
+}
+
+// roomLockedError returns the error rejecting a participant joining a locked room, it carries the reason of the lock
+func roomLockedError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrRoomLocked, reason)
+}
+
+// RoomLockService locks rooms at /lock_room, such as once a meeting has started. participants in the room stay
+// connected and can reconnect, new participants are rejected with the reason of the lock when they connect.
+// room_locked and room_unlocked webhooks are sent when the lock changes.
+// POST {"room": "", "reason": ""} locks the room and responds with it.
+// DELETE ?room= unlocks the room and responds with it
+type RoomLockService struct {
+	store     ObjectStore
+	telemetry telemetry.TelemetryService
+}
+
+func NewRoomLockService(store ObjectStore, telemetry telemetry.TelemetryService) *RoomLockService {
+	return &RoomLockService{
+		store:     store,
+		telemetry: telemetry,
+	}
+}
+
+// LockRoom rejects new participants of the room with the reason, until the room is unlocked
+func (s *RoomLockService) LockRoom(ctx context.Context, room string, reason string) (*livekit.Room, error) {
+	if len(reason) > maxRoomLockReasonSize {
+		return nil, ErrInvalidLockReason
+	}
+	if reason == "" {
+		reason = defaultRoomLockReason
+	}
+	return s.setLock(ctx, room, &reason)
+}
+
+// UnlockRoom has the room accept new participants again
+func (s *RoomLockService) UnlockRoom(ctx context.Context, room string) (*livekit.Room, error) {
+	return s.setLock(ctx, room, nil)
+}
+
+// setLock locks the room with the reason, or unlocks it when reason is nil
+func (s *RoomLockService) setLock(ctx context.Context, room string, reason *string) (*livekit.Room, error) {
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return nil, err
+		}
+	}
+	roomName := livekit.RoomName(room)
+	AppendLogFields(ctx, "room", roomName, "locked", reason != nil)
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, err
+	}
+
+	rm, _, err := s.store.LoadRoom(ctx, roomName, false)
+	if err != nil {
+		return nil, err
+	}
+	var locked, changed bool
+	_, err = changeRoomLabels(ctx, s.store, roomName, func(labels RoomLabels) (bool, error) {
+		var current string
+		current, locked = RoomLockReason(labels)
+		if reason == nil {
+			changed = locked
+			delete(labels, roomLockedLabel)
+		} else {
+			changed = !locked || current != *reason
+			labels[roomLockedLabel] = *reason
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return rm, nil
+	}
+
+	if reason == nil {
+		s.telemetry.RoomUnlocked(ctx, rm)
+	} else if !locked {
+		s.telemetry.RoomLocked(ctx, rm)
+	}
+	return rm, nil
+}
+
+type lockRoomRequest struct {
+	Room   string `json:"room"`
+	Reason string `json:"reason"`
+}
+
+func (s *RoomLockService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxLockRoomRequestSize, func(ctx context.Context, req *lockRoomRequest) (interface{}, error) {
+			return s.LockRoom(ctx, req.Room, req.Reason)
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			return s.UnlockRoom(r.Context(), r.URL.Query().Get("room"))
+		},
+	}.ServeHTTP(w, r)
+}
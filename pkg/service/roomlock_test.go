@@ -0,0 +1,87 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestRoomLock(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "meeting"},
+	})
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "meeting"}, nil))
+	require.NoError(t, store.StoreRoomLabels(ctx, "meeting", service.RoomLabels{"team": "sales"}))
+	telemetry := &telemetryfakes.FakeTelemetryService{}
+	svc := service.NewRoomLockService(store, telemetry)
+
+	lockReason := func() (string, bool) {
+		labels, err := store.LoadRoomLabels(ctx, "meeting")
+		require.NoError(t, err)
+		require.Equal(t, "sales", labels["team"])
+		return service.RoomLockReason(labels)
+	}
+
+	t.Run("requires admin permission of the room", func(t *testing.T) {
+		_, err := svc.LockRoom(ctx, "other", "")
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+	})
+
+	t.Run("rejects long reasons", func(t *testing.T) {
+		_, err := svc.LockRoom(ctx, "meeting", strings.Repeat("a", 1000))
+		require.ErrorIs(t, err, service.ErrInvalidLockReason)
+	})
+
+	t.Run("locks with a reason", func(t *testing.T) {
+		rm, err := svc.LockRoom(ctx, "meeting", "meeting has started")
+		require.NoError(t, err)
+		require.Equal(t, "meeting", rm.Name)
+		reason, locked := lockReason()
+		require.True(t, locked)
+		require.Equal(t, "meeting has started", reason)
+		require.Equal(t, 1, telemetry.RoomLockedCallCount())
+
+		// changing the reason does not notify again
+		_, err = svc.LockRoom(ctx, "meeting", "")
+		require.NoError(t, err)
+		reason, _ = lockReason()
+		require.Equal(t, "room is locked", reason)
+		require.Equal(t, 1, telemetry.RoomLockedCallCount())
+	})
+
+	t.Run("unlocks", func(t *testing.T) {
+		_, err := svc.UnlockRoom(ctx, "meeting")
+		require.NoError(t, err)
+		_, locked := lockReason()
+		require.False(t, locked)
+		require.Equal(t, 1, telemetry.RoomUnlockedCallCount())
+
+		_, err = svc.UnlockRoom(ctx, "meeting")
+		require.NoError(t, err)
+		require.Equal(t, 1, telemetry.RoomUnlockedCallCount())
+	})
+}
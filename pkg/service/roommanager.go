@@ -16,14 +16,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
-	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/livekit-server/version"
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
 	"github.com/livekit/protocol/auth"
@@ -37,6 +38,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -62,13 +64,22 @@ type RoomManager struct {
 	currentNode       routing.LocalNode
 	router            routing.Router
 	roomStore         ObjectStore
+	participantStore  ParticipantStore
+	roomHistoryStore  RoomHistoryStore
 	telemetry         telemetry.TelemetryService
 	clientConfManager clientconfiguration.ClientConfigurationManager
 	egressLauncher    rtc.EgressLauncher
 	versionGenerator  utils.TimedVersionGenerator
 	turnAuthHandler   *TURNAuthHandler
+	keyProvider       auth.KeyProvider
 
 	rooms map[livekit.RoomName]*rtc.Room
+	// rooms of participants that have been moved out of the room they joined, by participant SID
+	movedParticipants map[livekit.ParticipantID]*rtc.Room
+
+	// only accessed by CloseExpiredRooms
+	roomExpiries          map[livekit.RoomName]*roomExpiry
+	roomExpiryRefreshedAt time.Time
 
 	iceConfigCache map[livekit.ParticipantIdentity]*iceConfigCacheEntry
 }
@@ -76,6 +87,8 @@ type RoomManager struct {
 func NewLocalRoomManager(
 	conf *config.Config,
 	roomStore ObjectStore,
+	participantStore ParticipantStore,
+	roomHistoryStore RoomHistoryStore,
 	currentNode routing.LocalNode,
 	router routing.Router,
 	telemetry telemetry.TelemetryService,
@@ -83,6 +96,7 @@ func NewLocalRoomManager(
 	egressLauncher rtc.EgressLauncher,
 	versionGenerator utils.TimedVersionGenerator,
 	turnAuthHandler *TURNAuthHandler,
+	keyProvider auth.KeyProvider,
 ) (*RoomManager, error) {
 	rtcConf, err := rtc.NewWebRTCConfig(conf)
 	if err != nil {
@@ -95,13 +109,18 @@ func NewLocalRoomManager(
 		currentNode:       currentNode,
 		router:            router,
 		roomStore:         roomStore,
+		participantStore:  participantStore,
+		roomHistoryStore:  roomHistoryStore,
 		telemetry:         telemetry,
 		clientConfManager: clientConfManager,
 		egressLauncher:    egressLauncher,
 		versionGenerator:  versionGenerator,
 		turnAuthHandler:   turnAuthHandler,
+		keyProvider:       keyProvider,
 
-		rooms: make(map[livekit.RoomName]*rtc.Room),
+		rooms:             make(map[livekit.RoomName]*rtc.Room),
+		movedParticipants: make(map[livekit.ParticipantID]*rtc.Room),
+		roomExpiries:      make(map[livekit.RoomName]*roomExpiry),
 
 		iceConfigCache: make(map[livekit.ParticipantIdentity]*iceConfigCacheEntry),
 
@@ -117,6 +136,7 @@ func NewLocalRoomManager(
 	// hook up to router
 	router.OnNewParticipantRTC(r.StartSession)
 	router.OnRTCMessage(r.handleRTCMessage)
+	router.OnRoomCommand(r.handleRoomCommand)
 	return r, nil
 }
 
@@ -126,6 +146,30 @@ func (r *RoomManager) GetRoom(_ context.Context, roomName livekit.RoomName) *rtc
 	return r.rooms[roomName]
 }
 
+// RoomNames returns the names of rooms hosted by this node
+func (r *RoomManager) RoomNames() []livekit.RoomName {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]livekit.RoomName, 0, len(r.rooms))
+	for name := range r.rooms {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Rooms returns the rooms hosted by this node
+func (r *RoomManager) Rooms() []*rtc.Room {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
 // DeleteRoom completely deletes all room information, including active sessions, room store, and routing info
 func (r *RoomManager) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
 	logger.Infow("deleting room state", "room", roomName)
@@ -213,7 +257,7 @@ func (r *RoomManager) Stop() {
 		for _, p := range room.GetParticipants() {
 			_ = p.Close(true, types.ParticipantCloseReasonRoomManagerStop, false)
 		}
-		room.Close()
+		room.CloseWithReason(types.RoomCloseReasonRoomManagerStop)
 	}
 
 	if r.rtcConfig != nil {
@@ -249,9 +293,16 @@ func (r *RoomManager) StartSession(
 
 	// should not error out, error is logged in iceServersForParticipant even if it fails
 	// since this is used for TURN server credentials, we don't want to fail the request even if there's no TURN for the session
-	apiKey, _, _ := r.getFirstKeyPair()
+	apiKey, _, _ := r.getSigningKeyPair()
 
-	participant := room.GetParticipant(pi.Identity)
+	var participant types.LocalParticipant
+	if pi.Reconnect {
+		if participant = resumedParticipant(room, pi.Identity, pi.ID); participant != nil {
+			pi.Identity = participant.Identity()
+		}
+	} else {
+		participant = room.GetParticipant(pi.Identity)
+	}
 	if participant != nil {
 		// When reconnecting, it means WS has interrupted but underlying peer connection is still ok in this state,
 		// we'll keep the participant SID, and just swap the sink for the underlying connection
@@ -276,6 +327,7 @@ func (r *RoomManager) StartSession(
 						},
 					},
 				})
+				prometheus.RecordReconnect(prometheus.ReconnectTypeResume, "failure", pi.ReconnectReason.String())
 				return errors.New("could not restart closed participant")
 			}
 
@@ -301,16 +353,41 @@ func (r *RoomManager) StartSession(
 				pi.ReconnectReason,
 			); err != nil {
 				logger.Warnw("could not resume participant", err, "participant", pi.Identity)
+				prometheus.RecordReconnect(prometheus.ReconnectTypeResume, "failure", pi.ReconnectReason.String())
 				return err
 			}
+			prometheus.RecordReconnect(prometheus.ReconnectTypeResume, "success", pi.ReconnectReason.String())
 			r.telemetry.ParticipantResumed(ctx, room.ToProto(), participant.ToProto(), livekit.NodeID(r.currentNode.Id), pi.ReconnectReason)
 			go r.rtcSessionWorker(room, participant, requestSource)
 			return nil
 		}
 
-		// we need to clean up the existing participant, so a new one can join
-		participant.GetLogger().Infow("removing duplicate participant")
-		room.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonDuplicateIdentity)
+		policy, err := r.duplicateIdentityPolicy(ctx, roomName)
+		if err != nil {
+			return err
+		}
+		switch {
+		case policy == DuplicateIdentityReject && !participant.IsDisconnected():
+			participant.GetLogger().Infow("rejecting duplicate participant")
+			_ = responseSink.WriteMessage(&livekit.SignalResponse{
+				Message: &livekit.SignalResponse_Leave{
+					Leave: &livekit.LeaveRequest{
+						Reason: livekit.DisconnectReason_DUPLICATE_IDENTITY,
+					},
+				},
+			})
+			return ErrDuplicateIdentity
+		case policy == DuplicateIdentitySuffix:
+			// the participant in the room is kept, the new one joins with an identity of its own
+			pi.Identity = suffixedIdentity(room, pi.Identity)
+		default:
+			// we need to clean up the existing participant, so a new one can join
+			participant.GetLogger().Infow("removing duplicate participant")
+			// rejoining with the same identity while the previous session is still around, which may also be
+			// another client taking over the identity, so it is not counted as a successful reconnect
+			prometheus.RecordReconnect(prometheus.ReconnectTypeFull, "replaced", "")
+			room.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonDuplicateIdentity)
+		}
 	} else if pi.Reconnect {
 		// send leave request if participant is trying to reconnect without keep subscribe state
 		// but missing from the room
@@ -322,6 +399,7 @@ func (r *RoomManager) StartSession(
 				},
 			},
 		})
+		prometheus.RecordReconnect(prometheus.ReconnectTypeResume, "failure", pi.ReconnectReason.String())
 		return errors.New("could not restart participant")
 	}
 
@@ -372,6 +450,10 @@ func (r *RoomManager) StartSession(
 	if pi.SubscriberAllowPause != nil {
 		subscriberAllowPause = *pi.SubscriberAllowPause
 	}
+	// tracks are resolved in the room the participant is in, which changes when it is moved
+	trackResolver := func(subIdentity livekit.ParticipantIdentity, trackID livekit.TrackID) types.MediaResolverResult {
+		return r.participantRoom(room, sid).ResolveMediaTrackForSubscriber(subIdentity, trackID)
+	}
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
 		Identity:                pi.Identity,
 		Name:                    pi.Name,
@@ -395,7 +477,7 @@ func (r *RoomManager) StartSession(
 		AllowTCPFallback:        allowFallback,
 		TURNSEnabled:            r.config.IsTURNSEnabled(),
 		GetParticipantInfo: func(pID livekit.ParticipantID) *livekit.ParticipantInfo {
-			if p := room.GetParticipantByID(pID); p != nil {
+			if p := r.participantRoom(room, sid).GetParticipantByID(pID); p != nil {
 				return p.ToProto()
 			}
 			return nil
@@ -404,12 +486,17 @@ func (r *RoomManager) StartSession(
 		ReconnectOnSubscriptionError: reconnectOnSubscriptionError,
 		ReconnectOnDataChannelError:  reconnectOnDataChannelError,
 		VersionGenerator:             r.versionGenerator,
-		TrackResolver:                room.ResolveMediaTrackForSubscriber,
+		TrackResolver:                trackResolver,
 		SubscriberAllowPause:         subscriberAllowPause,
 		SubscriptionLimitAudio:       r.config.Limit.SubscriptionLimitAudio,
 		SubscriptionLimitVideo:       r.config.Limit.SubscriptionLimitVideo,
 		PlayoutDelay:                 roomInternal.GetPlayoutDelay(),
 		SyncStreams:                  roomInternal.GetSyncStreams(),
+		SRTPKeyLifetime:              r.config.RTC.SRTPKeyLifetime,
+		MaxSessionDuration:           pi.MaxSessionDuration,
+		MaxPublishResolution:         pi.MaxPublishResolution,
+		SessionWarning:               r.config.Limit.SessionWarning,
+		TokenRefresh:                 pi.TokenRefresh,
 	})
 	if err != nil {
 		return err
@@ -419,6 +506,7 @@ func (r *RoomManager) StartSession(
 	// join room
 	opts := rtc.ParticipantOptions{
 		AutoSubscribe: pi.AutoSubscribe,
+		Attributes:    pi.Attributes,
 	}
 	iceServers := r.iceServersForParticipant(apiKey, participant, iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS)
 	if err = room.Join(participant, requestSource, &opts, iceServers); err != nil {
@@ -429,8 +517,9 @@ func (r *RoomManager) StartSession(
 	if err = r.roomStore.StoreParticipant(ctx, roomName, participant.ToProto()); err != nil {
 		pLogger.Errorw("could not store participant", err)
 	}
+	r.storeParticipantSession(ctx, room, participant, time.Time{})
 
-	persistRoomForParticipantCount := func(proto *livekit.Room) {
+	persistRoomForParticipantCount := func(room *rtc.Room, proto *livekit.Room) {
 		if !participant.Hidden() {
 			err = r.roomStore.StoreRoom(ctx, proto, room.Internal())
 			if err != nil {
@@ -440,18 +529,24 @@ func (r *RoomManager) StartSession(
 	}
 
 	// update room store with new numParticipants
-	persistRoomForParticipantCount(room.ToProto())
+	persistRoomForParticipantCount(room, room.ToProto())
 
 	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region, Node: r.currentNode.Id}
 	r.telemetry.ParticipantJoined(ctx, protoRoom, participant.ToProto(), pi.Client, clientMeta, true)
 	participant.OnClose(func(p types.LocalParticipant) {
-		if err := r.roomStore.DeleteParticipant(ctx, roomName, p.Identity()); err != nil {
+		room := r.participantRoom(room, p.ID())
+		r.lock.Lock()
+		delete(r.movedParticipants, p.ID())
+		r.lock.Unlock()
+
+		if err := r.roomStore.DeleteParticipant(ctx, room.Name(), p.Identity()); err != nil {
 			pLogger.Errorw("could not delete participant", err)
 		}
+		r.storeParticipantSession(ctx, room, p, time.Now())
 
 		// update room store with new numParticipants
 		proto := room.ToProto()
-		persistRoomForParticipantCount(proto)
+		persistRoomForParticipantCount(room, proto)
 		r.telemetry.ParticipantLeft(ctx, proto, p.ToProto(), true)
 	})
 	participant.OnClaimsChanged(func(participant types.LocalParticipant) {
@@ -488,6 +583,11 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	if err != nil {
 		return nil, err
 	}
+	// a room requiring encryption is not started without its requirement
+	labels, err := r.roomStore.LoadRoomLabels(ctx, roomName)
+	if err != nil && err != ErrRoomNotFound {
+		return nil, err
+	}
 
 	r.lock.Lock()
 
@@ -505,11 +605,13 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 
 	// construct ice servers
 	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, &r.config.Audio, r.serverInfo, r.telemetry, r.egressLauncher)
+	newRoom.SetE2EERequired(RoomE2EERequired(labels))
 
 	newRoom.OnClose(func() {
 		roomInfo := newRoom.ToProto()
 		r.telemetry.RoomEnded(ctx, roomInfo)
-		prometheus.RoomEnded(time.Unix(roomInfo.CreationTime, 0))
+		r.storeRoomHistory(ctx, newRoom, roomInfo)
+		r.closeBreakoutRooms(ctx, roomName)
 		if err := r.DeleteRoom(ctx, roomName); err != nil {
 			newRoom.Logger.Errorw("could not delete room", err)
 		}
@@ -528,6 +630,7 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 			if err := r.roomStore.StoreParticipant(ctx, roomName, p.ToProto()); err != nil {
 				newRoom.Logger.Errorw("could not handle participant change", err)
 			}
+			r.storeParticipantSession(ctx, newRoom, p, time.Time{})
 		}
 	})
 
@@ -538,11 +641,78 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	newRoom.Hold()
 
 	r.telemetry.RoomStarted(ctx, newRoom.ToProto())
-	prometheus.RoomStarted()
+	go r.closeStaleParticipantSessions(ctx, newRoom)
 
 	return newRoom, nil
 }
 
+// storeRoomHistory keeps the record of a room that has ended
+func (r *RoomManager) storeRoomHistory(ctx context.Context, room *rtc.Room, roomInfo *livekit.Room) {
+	if r.roomHistoryStore == nil {
+		return
+	}
+
+	err := r.roomHistoryStore.StoreRoomHistory(ctx, &RoomHistory{
+		Name:             roomInfo.Name,
+		Sid:              roomInfo.Sid,
+		Metadata:         roomInfo.Metadata,
+		StartedAt:        time.Unix(roomInfo.CreationTime, 0),
+		EndedAt:          time.Now(),
+		PeakParticipants: room.PeakParticipants(),
+		EndReason:        room.CloseReason().String(),
+	}, r.config.Store.HistoryRetention)
+	if err != nil {
+		room.Logger.Errorw("could not store room history", err)
+	}
+}
+
+// storeParticipantSession records the participant's session, as ended when leftAt is set
+func (r *RoomManager) storeParticipantSession(ctx context.Context, room *rtc.Room, p types.LocalParticipant, leftAt time.Time) {
+	if r.participantStore == nil {
+		return
+	}
+
+	info := p.ToProto()
+	err := r.participantStore.StoreParticipantSession(ctx, &ParticipantSession{
+		RoomName:      room.Name(),
+		RoomID:        room.ID(),
+		ParticipantID: p.ID(),
+		Identity:      p.Identity(),
+		NodeID:        livekit.NodeID(r.currentNode.Id),
+		JoinedAt:      time.Unix(info.JoinedAt, 0),
+		LeftAt:        leftAt,
+		Info:          info,
+	})
+	if err != nil {
+		room.Logger.Errorw("could not store participant session", err, "participant", p.Identity())
+	}
+}
+
+// closeStaleParticipantSessions ends sessions left active by nodes that hosted the room before, after a crash
+func (r *RoomManager) closeStaleParticipantSessions(ctx context.Context, room *rtc.Room) {
+	if r.participantStore == nil {
+		return
+	}
+
+	sessions, err := r.participantStore.ListParticipantSessions(ctx, room.Name(), true)
+	if err != nil {
+		room.Logger.Errorw("could not list participant sessions", err)
+		return
+	}
+
+	now := time.Now()
+	for _, session := range sessions {
+		if session.NodeID == livekit.NodeID(r.currentNode.Id) {
+			continue
+		}
+		room.Logger.Infow("closing stale participant session", "participant", session.Identity, "nodeID", session.NodeID)
+		session.LeftAt = now
+		if err = r.participantStore.StoreParticipantSession(ctx, session); err != nil {
+			room.Logger.Errorw("could not close participant session", err, "participant", session.Identity)
+		}
+	}
+}
+
 // manages an RTC session for a participant, runs on the RTC node
 func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalParticipant, requestSource routing.MessageSource) {
 	pLogger := rtc.LoggerWithParticipant(
@@ -581,6 +751,9 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 				pLogger.Errorw("could not refresh token", err, "connID", requestSource.ConnectionID())
 			}
 		case obj := <-requestSource.ReadChan():
+			// the participant may have been moved to another room since the session started
+			room := r.participantRoom(room, participant.ID())
+
 			// In single node mode, the request source is directly tied to the signal message channel
 			// this means ICE restart isn't possible in single node mode
 			if obj == nil {
@@ -608,12 +781,7 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName livekit.Roo
 
 	if room == nil {
 		if _, ok := msg.Message.(*livekit.RTCNodeMessage_DeleteRoom); ok {
-			// special case of a non-RTC room e.g. room created but no participants joined
-			logger.Debugw("Deleting non-rtc room, loading from roomstore")
-			err := r.roomStore.DeleteRoom(ctx, roomName)
-			if err != nil {
-				logger.Debugw("Error deleting non-rtc room", "err", err)
-			}
+			r.deleteNonRTCRoom(ctx, roomName)
 			return
 		} else {
 			logger.Warnw("Could not find room", nil, "room", roomName)
@@ -663,11 +831,7 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName livekit.Roo
 			participant.SetPermission(rm.UpdateParticipant.Permission)
 		}
 	case *livekit.RTCNodeMessage_DeleteRoom:
-		room.Logger.Infow("deleting room")
-		for _, p := range room.GetParticipants() {
-			_ = p.Close(true, types.ParticipantCloseReasonServiceRequestDeleteRoom, false)
-		}
-		room.Close()
+		closeDeletedRoom(room)
 	case *livekit.RTCNodeMessage_UpdateSubscriptions:
 		if participant == nil {
 			return
@@ -694,6 +858,228 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName livekit.Roo
 	}
 }
 
+// deleteNonRTCRoom deletes a room that is not hosted by any node, e.g. a room created but no participants joined
+func (r *RoomManager) deleteNonRTCRoom(ctx context.Context, roomName livekit.RoomName) {
+	logger.Debugw("Deleting non-rtc room, loading from roomstore")
+	r.closeBreakoutRooms(ctx, roomName)
+	err := r.roomStore.DeleteRoom(ctx, roomName)
+	if err != nil {
+		logger.Debugw("Error deleting non-rtc room", "err", err)
+	}
+}
+
+// handles commands of the server on rooms hosted by this node
+func (r *RoomManager) handleRoomCommand(ctx context.Context, cmd *routing.RoomCommand) {
+	r.lock.RLock()
+	room := r.rooms[cmd.Room]
+	r.lock.RUnlock()
+
+	if room == nil {
+		// rooms closed with a grace period are deleted right away when they are not hosted
+		if cmd.Type == closeRoomCommand {
+			r.deleteNonRTCRoom(ctx, cmd.Room)
+		} else {
+			logger.Warnw("Could not find room", nil, "room", cmd.Room, "command", cmd.Type)
+		}
+		return
+	}
+
+	participant := room.GetParticipant(cmd.Identity)
+	var sid livekit.ParticipantID
+	if participant != nil {
+		sid = participant.ID()
+	}
+	pLogger := rtc.LoggerWithParticipant(
+		rtc.LoggerWithRoom(logger.GetLogger(), cmd.Room, room.ID()),
+		cmd.Identity,
+		sid,
+		false,
+	)
+
+	switch cmd.Type {
+	case moveParticipantCommand:
+		if participant == nil {
+			return
+		}
+		dstRoomName := livekit.RoomName(cmd.Payload)
+		pLogger.Infow("moving participant", "destinationRoom", dstRoomName)
+		if err := r.moveParticipant(ctx, room, participant, dstRoomName); err != nil {
+			pLogger.Warnw("could not move participant", err, "destinationRoom", dstRoomName)
+		}
+	case closeRoomCommand:
+		closesAt, err := strconv.ParseInt(string(cmd.Payload), 10, 64)
+		if err != nil {
+			room.Logger.Warnw("invalid room close time", err)
+			return
+		}
+		r.closeRoomAt(room, time.Unix(closesAt, 0))
+	case relayTracksCommand:
+		if participant == nil {
+			return
+		}
+		var relay relayTracks
+		if err := json.Unmarshal(cmd.Payload, &relay); err != nil {
+			pLogger.Warnw("could not decode track relay", err)
+			return
+		}
+		if err := r.relayTracks(ctx, room, participant, &relay); err != nil {
+			pLogger.Warnw("could not relay tracks", err, "destinationRoom", relay.DestinationRoom)
+		}
+	case pinSubscribedQualityCommand:
+		if participant == nil {
+			return
+		}
+		var pin pinnedQuality
+		if err := json.Unmarshal(cmd.Payload, &pin); err != nil {
+			pLogger.Warnw("could not decode quality pin", err)
+			return
+		}
+		var quality *livekit.VideoQuality
+		if pin.Quality != "" {
+			q := livekit.VideoQuality(livekit.VideoQuality_value[pin.Quality])
+			quality = &q
+		}
+		pLogger.Infow("pinning subscribed quality", "trackID", pin.TrackSid, "quality", pin.Quality)
+		participant.PinSubscribedQuality(livekit.TrackID(pin.TrackSid), quality)
+	case restartICECommand:
+		if participant == nil {
+			return
+		}
+		target := livekit.SignalTarget(livekit.SignalTarget_value[string(cmd.Payload)])
+		pLogger.Infow("restarting ICE", "target", target)
+		if target == livekit.SignalTarget_PUBLISHER {
+			// only clients can restart ICE of the publisher peer connection, they do when resuming
+			participant.CloseSignalConnection(types.SignallingCloseReasonICERestart)
+		} else {
+			participant.ICERestart(nil)
+		}
+	case updateParticipantAttributesCommand:
+		if participant == nil {
+			return
+		}
+		var attributes map[string]string
+		if err := json.Unmarshal(cmd.Payload, &attributes); err != nil {
+			pLogger.Warnw("could not decode participant attributes", err)
+			return
+		}
+		pLogger.Debugw("updating participant attributes", "attributes", attributes)
+		room.UpdateParticipantAttributes(participant, attributes)
+	case setMaxParticipantsCommand:
+		maxParticipants, err := strconv.ParseUint(string(cmd.Payload), 10, 32)
+		if err != nil {
+			pLogger.Warnw("could not decode max participants", err)
+			return
+		}
+		pLogger.Infow("setting max participants", "maxParticipants", maxParticipants)
+		room.SetMaxParticipants(uint32(maxParticipants))
+	case muteRoomCommand:
+		var muteRule roomMuteRule
+		if err := json.Unmarshal(cmd.Payload, &muteRule); err != nil {
+			pLogger.Warnw("could not decode mute rule", err)
+			return
+		}
+		if muteRule.Clear {
+			pLogger.Infow("clearing mute rule")
+			room.ClearMuteRule()
+			return
+		}
+		rule, err := muteRule.muteRule()
+		if err != nil {
+			pLogger.Warnw("invalid mute rule", err)
+			return
+		}
+		room.MuteTracks(rule, muteRule.MuteFuture)
+	case requireE2EECommand:
+		room.SetE2EERequired(true)
+	case patchRoomMetadataCommand:
+		pLogger.Debugw("patching room metadata", "size", len(cmd.Payload))
+		err := room.PatchMetadata(func(metadata string) (string, error) {
+			patched, err := mergeRoomMetadataPatch(metadata, string(cmd.Payload))
+			if err != nil {
+				return "", err
+			}
+			if maxMetadataSize := int(r.config.Room.MaxMetadataSize); maxMetadataSize > 0 && len(patched) > maxMetadataSize {
+				return "", ErrMetadataExceedsLimits
+			}
+			return patched, nil
+		})
+		if err != nil {
+			pLogger.Warnw("could not patch room metadata", err)
+		}
+	default:
+		pLogger.Warnw("unknown room command", nil, "command", cmd.Type)
+	}
+}
+
+// moveParticipant moves a participant to another room hosted by this node. the participant keeps its connection and
+// the tracks it publishes, it is put back into its room when the destination room does not accept it
+func (r *RoomManager) moveParticipant(ctx context.Context, room *rtc.Room, participant types.LocalParticipant, dstRoomName livekit.RoomName) error {
+	if dstRoomName == room.Name() {
+		return nil
+	}
+	dstRoom, err := r.getOrCreateRoom(ctx, dstRoomName)
+	if err != nil {
+		return err
+	}
+	defer dstRoom.Release()
+
+	p, requestSource, opts, err := room.DetachParticipant(participant.Identity())
+	if err != nil {
+		return err
+	}
+	r.setParticipantRoom(p.ID(), dstRoom)
+	if err = dstRoom.AttachParticipant(p, requestSource, opts); err != nil {
+		r.setParticipantRoom(p.ID(), room)
+		if rejoinErr := room.AttachParticipant(p, requestSource, opts); rejoinErr != nil {
+			p.GetLogger().Warnw("could not return participant to its room", rejoinErr)
+			_ = p.Close(true, types.ParticipantCloseReasonJoinFailed, false)
+		}
+		return err
+	}
+	p.MoveToRoom(dstRoomName)
+
+	if err = r.roomStore.DeleteParticipant(ctx, room.Name(), p.Identity()); err != nil {
+		p.GetLogger().Errorw("could not delete moved participant", err)
+	}
+	r.storeParticipantSession(ctx, room, p, time.Now())
+	r.storeParticipantSession(ctx, dstRoom, p, time.Time{})
+
+	// update room store with the numParticipants of both rooms
+	if !p.Hidden() {
+		for _, rm := range []*rtc.Room{room, dstRoom} {
+			if err = r.roomStore.StoreRoom(ctx, rm.ToProto(), rm.Internal()); err != nil {
+				rm.Logger.Errorw("could not store room", err)
+			}
+		}
+	}
+	// the room may be left empty, it is closed past its timeout
+	room.CloseIfEmpty()
+
+	r.telemetry.ParticipantLeft(ctx, room.ToProto(), p.ToProto(), true)
+	r.telemetry.ParticipantJoined(ctx, dstRoom.ToProto(), p.ToProto(), p.GetClientInfo(), &livekit.AnalyticsClientMeta{
+		Region: r.currentNode.Region,
+		Node:   r.currentNode.Id,
+	}, true)
+	return nil
+}
+
+// participantRoom returns the room a participant session is in, which is the room it joined unless it has been moved
+func (r *RoomManager) participantRoom(joined *rtc.Room, participantID livekit.ParticipantID) *rtc.Room {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if room, ok := r.movedParticipants[participantID]; ok {
+		return room
+	}
+	return joined
+}
+
+func (r *RoomManager) setParticipantRoom(participantID livekit.ParticipantID, room *rtc.Room) {
+	r.lock.Lock()
+	r.movedParticipants[participantID] = room
+	r.lock.Unlock()
+}
+
 func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.LocalParticipant, tlsOnly bool) []*livekit.ICEServer {
 	var iceServers []*livekit.ICEServer
 	rtcConf := r.config.RTC
@@ -733,22 +1119,9 @@ func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.
 
 	if len(rtcConf.TURNServers) > 0 {
 		hasSTUN = true
+		now := time.Now()
 		for _, s := range r.config.RTC.TURNServers {
-			scheme := "turn"
-			transport := "tcp"
-			if s.Protocol == "tls" {
-				scheme = "turns"
-			} else if s.Protocol == "udp" {
-				transport = "udp"
-			}
-			is := &livekit.ICEServer{
-				Urls: []string{
-					fmt.Sprintf("%s:%s:%d?transport=%s", scheme, s.Host, s.Port, transport),
-				},
-				Username:   s.Username,
-				Credential: s.Credential,
-			}
-			iceServers = append(iceServers, is)
+			iceServers = append(iceServers, externalTURNICEServer(s, string(participant.Identity()), now))
 		}
 	}
 
@@ -764,7 +1137,12 @@ func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.
 }
 
 func (r *RoomManager) refreshToken(participant types.LocalParticipant) error {
-	key, secret, err := r.getFirstKeyPair()
+	// sessions of clients that refresh their own tokens end with those tokens, refreshed tokens would outlive them
+	if r.config.TokenExpiry.Enforce && participant.SupportsTokenRefresh() {
+		return nil
+	}
+
+	key, secret, err := r.getSigningKeyPair()
 	if err != nil {
 		return err
 	}
@@ -807,11 +1185,18 @@ func (r *RoomManager) getIceConfig(participant types.LocalParticipant) *livekit.
 	return iceConfigCacheEntry.iceConfig
 }
 
-func (r *RoomManager) getFirstKeyPair() (string, string, error) {
-	for key, secret := range r.config.Keys {
-		return key, secret, nil
+// getSigningKeyPair returns the key pair tokens and TURN credentials are signed with, retired keys are not used
+func (r *RoomManager) getSigningKeyPair() (string, string, error) {
+	// keys rotated since the node started are those of the key provider
+	keys := r.config.Keys
+	if provider, ok := r.keyProvider.(*RotatingKeyProvider); ok {
+		keys = provider.Keys()
+	}
+	key, secret, ok := r.config.KeyRotation.SigningKeyPair(keys)
+	if !ok {
+		return "", "", errors.New("no API keys configured")
 	}
-	return "", "", errors.New("no API keys configured")
+	return key, secret, nil
 }
 
 // ------------------------------------
@@ -0,0 +1,297 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// passcode of the room, set on CreateRoom requests. it is never returned
+	roomPasscodeHeader = "X-Livekit-Room-Passcode"
+
+	// the argon2id hash of the passcode is kept with the labels of the room, as
+	// argon2id.<time>.<memory>.<threads>.<salt>.<hash>, so that short passcodes are slow to guess from a copy of the
+	// store
+	roomPasscodeLabel = reservedLabelPrefix + "passcode"
+
+	// participants present the passcode with this query parameter of the signal connection, or with the
+	// room_passcode claim of their token
+	roomPasscodeParam = "passcode"
+
+	minRoomPasscodeSize = 4
+	maxRoomPasscodeSize = 64
+
+	// argon2id parameters of new hashes, the parameters of a hash are kept with it
+	passcodeHashTime    = 2
+	passcodeHashMemory  = 19 * 1024
+	passcodeHashThreads = 1
+	passcodeHashSize    = 32
+
+	// buckets of clients that have not tried a passcode for this long are dropped
+	passcodeAttemptsIdleTimeout = 10 * time.Minute
+
+	// RoomPasscodeRequiredReason, RoomPasscodeInvalidReason and RoomPasscodeAttemptsReason identify joins rejected by
+	// the passcode of the room
+	RoomPasscodeRequiredReason = "PASSCODE_REQUIRED"
+	RoomPasscodeInvalidReason  = "PASSCODE_INVALID"
+	RoomPasscodeAttemptsReason = "PASSCODE_ATTEMPTS_EXCEEDED"
+)
+
+var (
+	ErrIncorrectRoomPasscode    = psrpc.NewErrorf(psrpc.PermissionDenied, "room passcode is incorrect")
+	ErrInvalidRoomPasscode      = psrpc.NewErrorf(psrpc.InvalidArgument, "room passcode must be 4 to 64 printable characters")
+	ErrPasscodeAttemptsExceeded = psrpc.NewErrorf(psrpc.ResourceExhausted, "too many room passcode attempts")
+	ErrRoomPasscodeRequired     = psrpc.NewErrorf(psrpc.PermissionDenied, "room passcode is required")
+)
+
+type tokenRoomPasscodeKey struct{}
+
+// RoomPasscodeError rejects participants joining a room with a passcode without presenting it
+type RoomPasscodeError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RoomPasscodeError) Error() string {
+	return e.Unwrap().Error()
+}
+
+func (e *RoomPasscodeError) Unwrap() error {
+	switch e.Reason {
+	case RoomPasscodeRequiredReason:
+		return ErrRoomPasscodeRequired
+	case RoomPasscodeAttemptsReason:
+		return ErrPasscodeAttemptsExceeded
+	default:
+		return ErrIncorrectRoomPasscode
+	}
+}
+
+type roomPasscodeResponse struct {
+	Error  string `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// write responds to the join with the reason of the rejection, so that clients can prompt for the passcode
+func (e *RoomPasscodeError) write(w http.ResponseWriter, status int) {
+	b, err := json.Marshal(&roomPasscodeResponse{
+		Error:  e.Error(),
+		Reason: e.Reason,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func roomPasscodeFromRequest(ctx context.Context) (string, error) {
+	passcode, ok := lookupRequestOption(ctx, roomPasscodeOption)
+	if !ok {
+		return "", nil
+	}
+	if len(passcode) < minRoomPasscodeSize || len(passcode) > maxRoomPasscodeSize {
+		return "", ErrInvalidRoomPasscode
+	}
+	for _, r := range passcode {
+		if !unicode.IsPrint(r) {
+			return "", ErrInvalidRoomPasscode
+		}
+	}
+	return passcode, nil
+}
+
+// hashRoomPasscode returns the passcode hashed with a new salt, as kept with the labels of the room
+func hashRoomPasscode(passcode string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey([]byte(passcode), salt, passcodeHashTime, passcodeHashMemory, passcodeHashThreads, passcodeHashSize)
+	return fmt.Sprintf("argon2id.%d.%d.%d.%s.%s",
+		passcodeHashTime,
+		passcodeHashMemory,
+		passcodeHashThreads,
+		base64.RawURLEncoding.EncodeToString(salt),
+		base64.RawURLEncoding.EncodeToString(hash),
+	), nil
+}
+
+// roomPasscodeMatches returns whether the passcode is the one of the hash kept with the labels of the room
+func roomPasscodeMatches(hash string, passcode string) bool {
+	parts := strings.Split(hash, ".")
+	if len(parts) != 6 || parts[0] != "argon2id" {
+		return false
+	}
+	iterations, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || iterations == 0 {
+		return false
+	}
+	memory, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return false
+	}
+	threads, err := strconv.ParseUint(parts[3], 10, 8)
+	if err != nil || threads == 0 {
+		return false
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawURLEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	actual := argon2.IDKey([]byte(passcode), salt, uint32(iterations), uint32(memory), uint8(threads), uint32(len(expected)))
+	return subtle.ConstantTimeCompare(actual, expected) == 1
+}
+
+// setRoomPasscode keeps the hash of the passcode with the labels of the room, before it is started
+func (s *RoomService) setRoomPasscode(ctx context.Context, roomName livekit.RoomName, passcode string) error {
+	hash, err := hashRoomPasscode(passcode)
+	if err != nil {
+		return err
+	}
+	_, err = changeRoomLabels(ctx, s.roomStore, roomName, func(labels RoomLabels) (bool, error) {
+		labels[roomPasscodeLabel] = hash
+		return true, nil
+	})
+	return err
+}
+
+func withTokenRoomPasscode(ctx context.Context, passcode string) context.Context {
+	return context.WithValue(ctx, tokenRoomPasscodeKey{}, passcode)
+}
+
+// tokenRoomPasscode returns the room passcode claim of the token of the request
+func tokenRoomPasscode(ctx context.Context) string {
+	passcode, _ := ctx.Value(tokenRoomPasscodeKey{}).(string)
+	return passcode
+}
+
+type passcodeAttemptsKey struct {
+	roomName livekit.RoomName
+	// empty for the attempts of all clients
+	clientIP string
+}
+
+// passcodeAttempts limits the passcodes each client, and all clients together, can try for a room, so that passcodes
+// cannot be guessed from one address or from many
+type passcodeAttempts struct {
+	clientLimit config.RateLimit
+	roomLimit   config.RateLimit
+
+	lock       sync.Mutex
+	buckets    map[passcodeAttemptsKey]*tokenBucket
+	lastPruned time.Time
+}
+
+func newPasscodeAttempts(clientLimit config.RateLimit, roomLimit config.RateLimit) *passcodeAttempts {
+	return &passcodeAttempts{
+		clientLimit: clientLimit,
+		roomLimit:   roomLimit,
+		buckets:     make(map[passcodeAttemptsKey]*tokenBucket),
+	}
+}
+
+// take counts an attempt of the client, or returns how long until it can try again. attempts of limited clients do
+// not count against the room
+func (a *passcodeAttempts) take(roomName livekit.RoomName, clientIP string, now time.Time) (time.Duration, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if now.Sub(a.lastPruned) > passcodeAttemptsIdleTimeout {
+		for key, bucket := range a.buckets {
+			if now.Sub(bucket.updated) > passcodeAttemptsIdleTimeout {
+				delete(a.buckets, key)
+			}
+		}
+		a.lastPruned = now
+	}
+	if retryAfter, ok := a.takeLocked(passcodeAttemptsKey{roomName: roomName, clientIP: clientIP}, a.clientLimit, now); !ok {
+		return retryAfter, false
+	}
+	return a.takeLocked(passcodeAttemptsKey{roomName: roomName}, a.roomLimit, now)
+}
+
+func (a *passcodeAttempts) takeLocked(key passcodeAttemptsKey, limit config.RateLimit, now time.Time) (time.Duration, bool) {
+	if limit.RequestsPerSec <= 0 {
+		return 0, true
+	}
+	bucket := a.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{}
+		a.buckets[key] = bucket
+	}
+	return bucket.take(limit, now)
+}
+
+// ensureRoomPasscode rejects joining a room with a passcode, unless the participant presents the passcode with the
+// signal connection or its token
+func (s *RTCService) ensureRoomPasscode(ctx context.Context, roomName livekit.RoomName, passcode string, clientIP string, reconnect bool) error {
+	if reconnect {
+		return nil
+	}
+	labels, err := s.store.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		if err == ErrRoomNotFound {
+			return nil
+		}
+		return err
+	}
+	hash, ok := labels[roomPasscodeLabel]
+	if !ok {
+		return nil
+	}
+
+	if passcode == "" {
+		passcode = tokenRoomPasscode(ctx)
+	}
+	if passcode == "" {
+		return &RoomPasscodeError{Reason: RoomPasscodeRequiredReason}
+	}
+	if retryAfter, ok := s.passcodes.take(roomName, clientIP, time.Now()); !ok {
+		return &RoomPasscodeError{Reason: RoomPasscodeAttemptsReason, RetryAfter: retryAfter}
+	}
+	if !roomPasscodeMatches(hash, passcode) {
+		return &RoomPasscodeError{Reason: RoomPasscodeInvalidReason}
+	}
+	return nil
+}
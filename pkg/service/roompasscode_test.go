@@ -0,0 +1,168 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestRoomPasscode(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "standup"}, nil))
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "open"}, nil))
+	hash, err := hashRoomPasscode("4821")
+	require.NoError(t, err)
+	require.NoError(t, store.StoreRoomLabels(ctx, "standup", RoomLabels{roomPasscodeLabel: hash}))
+
+	s := &RTCService{
+		store:     store,
+		passcodes: newPasscodeAttempts(config.RateLimit{RequestsPerSec: 0.001, Burst: 3}, config.RateLimit{}),
+	}
+	reason := func(err error) string {
+		passcodeErr, ok := err.(*RoomPasscodeError)
+		require.True(t, ok, err)
+		return passcodeErr.Reason
+	}
+
+	t.Run("rooms without a passcode", func(t *testing.T) {
+		require.NoError(t, s.ensureRoomPasscode(ctx, "open", "", "10.0.0.1", false))
+		require.NoError(t, s.ensureRoomPasscode(ctx, "not-created", "", "10.0.0.1", false))
+	})
+
+	t.Run("passcode of signal connection", func(t *testing.T) {
+		require.Equal(t, RoomPasscodeRequiredReason, reason(s.ensureRoomPasscode(ctx, "standup", "", "10.0.0.1", false)))
+		require.NoError(t, s.ensureRoomPasscode(ctx, "standup", "4821", "10.0.0.1", false))
+		require.ErrorIs(t, s.ensureRoomPasscode(ctx, "standup", "0000", "10.0.0.1", false), ErrIncorrectRoomPasscode)
+		// reconnecting participants have joined with the passcode
+		require.NoError(t, s.ensureRoomPasscode(ctx, "standup", "", "10.0.0.1", true))
+	})
+
+	t.Run("passcode of token", func(t *testing.T) {
+		token, err := auth.NewAccessToken("key", "secret").
+			AddGrant(&auth.VideoGrant{RoomJoin: true}).
+			SetIdentity("alice").
+			ToJWT()
+		require.NoError(t, err)
+		claims, ok := parseTokenClaims(token)
+		require.True(t, ok)
+		require.Empty(t, claims.RoomPasscode)
+		require.Equal(t, "4821", testTokenClaims(t, `{"sub":"alice","room_passcode":"4821"}`).RoomPasscode)
+
+		tokenCtx := withTokenRoomPasscode(ctx, "4821")
+		require.NoError(t, s.ensureRoomPasscode(tokenCtx, "standup", "", "10.0.0.2", false))
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.ErrorIs(t, s.ensureRoomPasscode(ctx, "standup", "0000", "10.0.0.3", false), ErrIncorrectRoomPasscode)
+		}
+		err := s.ensureRoomPasscode(ctx, "standup", "4821", "10.0.0.3", false)
+		require.Equal(t, RoomPasscodeAttemptsReason, reason(err))
+
+		w := httptest.NewRecorder()
+		handleJoinError(w, http.StatusTooManyRequests, err)
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.NotEmpty(t, w.Header().Get("Retry-After"))
+		var res roomPasscodeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, RoomPasscodeAttemptsReason, res.Reason)
+
+		// other clients are not limited
+		require.NoError(t, s.ensureRoomPasscode(ctx, "standup", "4821", "10.0.0.4", false))
+	})
+
+	t.Run("attempts of a room are limited", func(t *testing.T) {
+		a := newPasscodeAttempts(config.RateLimit{RequestsPerSec: 0.001, Burst: 2}, config.RateLimit{RequestsPerSec: 0.001, Burst: 3})
+		now := time.Now()
+		// clients of many addresses share the attempts of the room
+		for _, clientIP := range []string{"10.0.1.1", "10.0.1.2", "10.0.1.3"} {
+			_, ok := a.take("standup", clientIP, now)
+			require.True(t, ok)
+		}
+		retryAfter, ok := a.take("standup", "10.0.1.4", now)
+		require.False(t, ok)
+		require.Positive(t, retryAfter)
+		_, ok = a.take("open", "10.0.1.4", now)
+		require.True(t, ok)
+
+		// limited clients do not use up the attempts of the room
+		b := newPasscodeAttempts(config.RateLimit{RequestsPerSec: 0.001, Burst: 1}, config.RateLimit{RequestsPerSec: 0.001, Burst: 2})
+		_, ok = b.take("standup", "10.0.2.1", now)
+		require.True(t, ok)
+		for i := 0; i < 3; i++ {
+			_, ok = b.take("standup", "10.0.2.1", now)
+			require.False(t, ok)
+		}
+		_, ok = b.take("standup", "10.0.2.2", now)
+		require.True(t, ok)
+	})
+
+	t.Run("idle clients are dropped", func(t *testing.T) {
+		a := newPasscodeAttempts(config.RateLimit{RequestsPerSec: 1, Burst: 1}, config.RateLimit{})
+		now := time.Now()
+		_, ok := a.take("standup", "10.0.0.5", now)
+		require.True(t, ok)
+		_, ok = a.take("standup", "10.0.0.6", now.Add(passcodeAttemptsIdleTimeout+time.Second))
+		require.True(t, ok)
+		require.Len(t, a.buckets, 1)
+	})
+}
+
+func TestRoomPasscodeFromRequest(t *testing.T) {
+	header := func(value string) context.Context {
+		return context.WithValue(context.Background(), requestHeaderKey{}, http.Header{"X-Livekit-Room-Passcode": {value}})
+	}
+	passcode, err := roomPasscodeFromRequest(header("4821"))
+	require.NoError(t, err)
+	require.Equal(t, "4821", passcode)
+
+	_, err = roomPasscodeFromRequest(header("123"))
+	require.ErrorIs(t, err, ErrInvalidRoomPasscode)
+	_, err = roomPasscodeFromRequest(header("12\x0034"))
+	require.ErrorIs(t, err, ErrInvalidRoomPasscode)
+
+	passcode, err = roomPasscodeFromRequest(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, passcode)
+
+	// the hash is never the passcode itself, and each hash has its own salt
+	first, err := hashRoomPasscode("4821")
+	require.NoError(t, err)
+	second, err := hashRoomPasscode("4821")
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+	require.NotContains(t, first, "4821")
+	require.True(t, roomPasscodeMatches(second, "4821"))
+	require.False(t, roomPasscodeMatches(second, "4822"))
+
+	// the parameters of the hash are kept with it
+	require.Regexp(t, `^argon2id\.2\.19456\.1\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`, first)
+	require.False(t, roomPasscodeMatches(strings.Replace(first, "argon2id.2.", "argon2id.1.", 1), "4821"))
+	require.False(t, roomPasscodeMatches("salt.hash", "4821"))
+}
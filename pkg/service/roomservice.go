@@ -33,13 +33,20 @@ import (
 	"github.com/livekit/protocol/rpc"
 )
 
+const (
+	// version of the room, set on CreateRoom and UpdateRoomMetadata responses. the version changes with the metadata
+	// of the room, UpdateRoomMetadata requests with the expected_version option fail if it has changed since
+	roomVersionHeader = "X-Livekit-Room-Version"
+)
+
 // A rooms service that supports a single node
 type RoomService struct {
 	roomConf       config.RoomConfig
 	apiConf        config.APIConfig
 	router         routing.MessageRouter
 	roomAllocator  RoomAllocator
-	roomStore      ServiceStore
+	roomStore      ObjectStore
+	templateStore  RoomTemplateStore
 	egressLauncher rtc.EgressLauncher
 }
 
@@ -48,7 +55,8 @@ func NewRoomService(
 	apiConf config.APIConfig,
 	router routing.MessageRouter,
 	roomAllocator RoomAllocator,
-	serviceStore ServiceStore,
+	objectStore ObjectStore,
+	templateStore RoomTemplateStore,
 	egressLauncher rtc.EgressLauncher,
 ) (svc *RoomService, err error) {
 	svc = &RoomService{
@@ -56,7 +64,8 @@ func NewRoomService(
 		apiConf:        apiConf,
 		router:         router,
 		roomAllocator:  roomAllocator,
-		roomStore:      serviceStore,
+		roomStore:      objectStore,
+		templateStore:  templateStore,
 		egressLauncher: egressLauncher,
 	}
 	return
@@ -66,9 +75,50 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 	AppendLogFields(ctx, "room", req.Name, "request", req)
 	if err := EnsureCreatePermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
-	} else if req.Egress != nil && s.egressLauncher == nil {
+	}
+
+	labels, err := roomLabelsFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	if name, ok := lookupRequestOption(ctx, roomTemplateOption); ok {
+		template, err := loadRoomTemplate(ctx, s.templateStore, name)
+		if err != nil {
+			return nil, err
+		}
+		req = template.Apply(req)
+		labels = template.ApplyLabels(labels)
+	}
+	if req.Egress != nil && s.egressLauncher == nil {
 		return nil, ErrEgressNotConnected
 	}
+	expiresAt, err := roomExpiryFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	startsAt, err := roomStartFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	if !startsAt.IsZero() && !expiresAt.IsZero() && !startsAt.Before(expiresAt) {
+		return nil, twirp.NewError(twirp.InvalidArgument, ErrInvalidRoomStart.Error())
+	}
+	passcode, err := roomPasscodeFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	countries, restricted, err := roomCountryRestrictionFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	e2eeRequired, err := roomE2EEFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	duplicateIdentity, err := roomDuplicateIdentityFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
 
 	rm, err := s.roomAllocator.CreateRoom(ctx, req)
 	if err != nil {
@@ -76,6 +126,33 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 		return nil, err
 	}
 
+	// stored before the room is started, for the node hosting it to find
+	if !expiresAt.IsZero() || !startsAt.IsZero() {
+		if err = s.setRoomSchedule(ctx, livekit.RoomName(req.Name), startsAt, expiresAt); err != nil {
+			return nil, err
+		}
+	}
+	if passcode != "" {
+		if err = s.setRoomPasscode(ctx, livekit.RoomName(req.Name), passcode); err != nil {
+			return nil, err
+		}
+	}
+	if restricted {
+		if err = s.setRoomCountryRestriction(ctx, livekit.RoomName(req.Name), countries); err != nil {
+			return nil, err
+		}
+	}
+	if e2eeRequired {
+		if err = s.requireRoomE2EE(ctx, livekit.RoomName(req.Name)); err != nil {
+			return nil, err
+		}
+	}
+	if duplicateIdentity != "" {
+		if err = s.setRoomDuplicateIdentity(ctx, livekit.RoomName(req.Name), duplicateIdentity); err != nil {
+			return nil, err
+		}
+	}
+
 	// actually start the room on an RTC node, to ensure metadata & empty timeout functionality
 	_, sink, source, err := s.router.StartParticipantSignal(ctx,
 		livekit.RoomName(req.Name),
@@ -99,6 +176,17 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 	if err != nil {
 		return nil, err
 	}
+	if e2eeRequired {
+		// rooms started before their requirement was stored are told of it
+		if err = s.notifyRoomE2EE(ctx, livekit.RoomName(req.Name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.updateRoomLabels(ctx, livekit.RoomName(req.Name), labels); err != nil {
+		return nil, err
+	}
+	s.setRoomVersionHeader(ctx, livekit.RoomName(req.Name))
 
 	if req.Egress != nil && req.Egress.Room != nil {
 		egress := &rpc.StartEgressRequest{
@@ -120,14 +208,29 @@ func (s *RoomService) ListRooms(ctx context.Context, req *livekit.ListRoomsReque
 		return nil, twirpAuthError(err)
 	}
 
-	var names []livekit.RoomName
-	if len(req.Names) > 0 {
-		names = livekit.StringsAsIDs[livekit.RoomName](req.Names)
-	}
-	rooms, err := s.roomStore.ListRooms(ctx, names)
+	opts, err := listRoomsOptionsFromRequest(ctx, req)
 	if err != nil {
-		// TODO: translate error codes to Twirp
-		return nil, err
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+
+	var rooms []*livekit.Room
+	if opts.IsFiltered() {
+		var nextPageToken string
+		rooms, nextPageToken, err = s.roomStore.ListRoomsPage(ctx, opts)
+		if err == ErrInvalidPageToken {
+			return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+		} else if err != nil {
+			return nil, err
+		}
+		if nextPageToken != "" {
+			_ = twirp.SetHTTPResponseHeader(ctx, nextPageTokenHeader, nextPageToken)
+		}
+	} else {
+		rooms, err = s.roomStore.ListRooms(ctx, opts.Names)
+		if err != nil {
+			// TODO: translate error codes to Twirp
+			return nil, err
+		}
 	}
 
 	res := &livekit.ListRoomsResponse{
@@ -146,7 +249,15 @@ func (s *RoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomReq
 		return nil, twirp.NotFoundError("room not found")
 	}
 
-	err := s.router.WriteRoomRTC(ctx, livekit.RoomName(req.Room), &livekit.RTCNodeMessage{
+	gracePeriod, err := deleteGracePeriodFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	if gracePeriod > 0 {
+		return s.deleteRoomAfter(ctx, livekit.RoomName(req.Room), gracePeriod)
+	}
+
+	err = s.router.WriteRoomRTC(ctx, livekit.RoomName(req.Room), &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_DeleteRoom{
 			DeleteRoom: req,
 		},
@@ -288,8 +399,21 @@ func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.Update
 	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
 		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
 	}
+	attributes, encodedAttributes, err := participantAttributesFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.InvalidArgumentError(participantAttributesOption.field, err.Error())
+	}
+	if maxMetadataSize > 0 && len(encodedAttributes) > maxMetadataSize {
+		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
+	}
 
-	err := s.writeParticipantMessage(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), &livekit.RTCNodeMessage{
+	if attributes != nil {
+		err = s.updateParticipantAttributes(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), encodedAttributes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = s.writeParticipantMessage(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_UpdateParticipant{
 			UpdateParticipant: req,
 		},
@@ -347,6 +471,19 @@ func (s *RoomService) SendData(ctx context.Context, req *livekit.SendDataRequest
 	if err := EnsureAdminPermission(ctx, roomName); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if req.GetTopic() == rtc.ParticipantAttributesTopic {
+		return nil, twirp.InvalidArgumentError("topic", "is reserved")
+	}
+	if _, ok := livekit.DataPacket_Kind_name[int32(req.Kind)]; !ok {
+		return nil, twirp.InvalidArgumentError("kind", "must be RELIABLE or LOSSY")
+	}
+	maxDataPacketSize := int(s.roomConf.MaxDataPacketSize)
+	if maxDataPacketSize > 0 && len(req.Data) > maxDataPacketSize {
+		return nil, twirp.InvalidArgumentError(ErrDataExceedsLimits.Error(), strconv.Itoa(maxDataPacketSize))
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, roomName, false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
 
 	err := s.router.WriteRoomRTC(ctx, roomName, &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_SendData{
@@ -371,11 +508,49 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 		return nil, twirpAuthError(err)
 	}
 
+	labels, err := roomLabelsFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error())
+	}
+	maxParticipants, setMaxParticipants, err := maxParticipantsFromRequest(ctx)
+	if err != nil {
+		return nil, twirp.InvalidArgumentError(maxParticipantsOption.field, err.Error())
+	}
+
+	if v, ok := lookupRequestOption(ctx, roomMetadataPatchOption); ok {
+		if v != roomMetadataPatchMerge {
+			return nil, twirp.InvalidArgumentError(roomMetadataPatchOption.field, "must be "+roomMetadataPatchMerge)
+		}
+		room, err := s.patchRoomMetadata(ctx, req, labels)
+		if err != nil || !setMaxParticipants {
+			return room, err
+		}
+		if room, err = s.setMaxParticipants(ctx, livekit.RoomName(req.Room), maxParticipants); err != nil {
+			return nil, err
+		}
+		s.setRoomVersionHeader(ctx, livekit.RoomName(req.Room))
+		return room, nil
+	}
+
 	room, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
 	if err != nil {
 		return nil, err
 	}
 
+	if v, ok := lookupRequestOption(ctx, expectedRoomVersionOption); ok {
+		expectedVersion, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, twirp.InvalidArgumentError(expectedRoomVersionOption.field, "must be an integer")
+		}
+		updated := proto.Clone(room).(*livekit.Room)
+		updated.Metadata = req.Metadata
+		if _, err = s.roomStore.UpdateRoom(ctx, updated, expectedVersion); err == ErrRoomVersionConflict {
+			return nil, twirp.NewError(twirp.Aborted, err.Error())
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
 	// no one has joined the room, would not have been created on an RTC node.
 	// in this case, we'd want to run create again
 	_, err = s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{
@@ -409,9 +584,146 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 		return nil, err
 	}
 
+	if err = s.updateRoomLabels(ctx, livekit.RoomName(req.Room), labels); err != nil {
+		return nil, err
+	}
+	if setMaxParticipants {
+		if room, err = s.setMaxParticipants(ctx, livekit.RoomName(req.Room), maxParticipants); err != nil {
+			return nil, err
+		}
+	}
+	s.setRoomVersionHeader(ctx, livekit.RoomName(req.Room))
+
+	return room, nil
+}
+
+// patchRoomMetadata applies the metadata of the request as a merge patch. the patch is applied by the node hosting
+// the room, so that concurrent patches of different fields don't overwrite each other, and participants are
+// notified of the patched metadata once
+func (s *RoomService) patchRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest, labels RoomLabels) (*livekit.Room, error) {
+	if _, ok := lookupRequestOption(ctx, expectedRoomVersionOption); ok {
+		return nil, twirp.InvalidArgumentError(expectedRoomVersionOption.field, "cannot be used with "+roomMetadataPatchOption.field)
+	}
+
+	roomName := livekit.RoomName(req.Room)
+	room, _, err := s.roomStore.LoadRoom(ctx, roomName, false)
+	if err != nil {
+		return nil, err
+	}
+	// the size is checked again by the node, against the metadata the patch is applied to
+	patched, err := mergeRoomMetadataPatch(room.Metadata, req.Metadata)
+	if err != nil {
+		return nil, twirp.InvalidArgumentError("metadata", err.Error())
+	}
+	if maxMetadataSize := int(s.roomConf.MaxMetadataSize); maxMetadataSize > 0 && len(patched) > maxMetadataSize {
+		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
+	}
+
+	// the room would not have been created on an RTC node when no one has joined it
+	if _, err = s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: req.Room}); err != nil {
+		return nil, err
+	}
+
+	err = s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:    patchRoomMetadataCommand,
+		Room:    roomName,
+		Payload: []byte(req.Metadata),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// patches are idempotent, the patch has been applied once applying it again doesn't change the metadata
+	err = s.confirmExecution(func() error {
+		room, _, err = s.roomStore.LoadRoom(ctx, roomName, false)
+		if err != nil {
+			return err
+		}
+		if patched, err = mergeRoomMetadataPatch(room.Metadata, req.Metadata); err != nil || patched != room.Metadata {
+			return ErrOperationFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.updateRoomLabels(ctx, roomName, labels); err != nil {
+		return nil, err
+	}
+	s.setRoomVersionHeader(ctx, roomName)
+
 	return room, nil
 }
 
+func (s *RoomService) setRoomVersionHeader(ctx context.Context, roomName livekit.RoomName) {
+	if _, version, err := s.roomStore.LoadRoomVersion(ctx, roomName); err == nil {
+		_ = twirp.SetHTTPResponseHeader(ctx, roomVersionHeader, strconv.FormatInt(version, 10))
+	}
+}
+
+// roomLabelsFromRequest returns the labels set by the request, or nil when the request leaves labels unchanged
+func roomLabelsFromRequest(ctx context.Context) (RoomLabels, error) {
+	value, ok := lookupRequestOption(ctx, roomLabelsOption)
+	if !ok {
+		return nil, nil
+	}
+	return ParseRoomLabels(value)
+}
+
+// updateRoomLabels replaces the labels of the room unless labels is nil, and returns the labels on the response.
+// reserved labels are kept
+func (s *RoomService) updateRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	var err error
+	if labels == nil {
+		labels, err = s.roomStore.LoadRoomLabels(ctx, roomName)
+	} else {
+		labels, err = changeRoomLabels(ctx, s.roomStore, roomName, func(current RoomLabels) (bool, error) {
+			for key := range current {
+				if !isReservedLabel(key) {
+					delete(current, key)
+				}
+			}
+			for key, value := range labels {
+				current[key] = value
+			}
+			return true, nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	_ = twirp.SetHTTPResponseHeader(ctx, roomLabelsHeader, labels.withoutReserved().String())
+	if startsAt := RoomStartsAt(labels); !startsAt.IsZero() {
+		_ = twirp.SetHTTPResponseHeader(ctx, roomStartsAtHeader, strconv.FormatInt(startsAt.Unix(), 10))
+	}
+	if expiresAt := RoomExpiresAt(labels); !expiresAt.IsZero() {
+		_ = twirp.SetHTTPResponseHeader(ctx, roomExpiresAtHeader, strconv.FormatInt(expiresAt.Unix(), 10))
+	}
+	if RoomE2EERequired(labels) {
+		_ = twirp.SetHTTPResponseHeader(ctx, roomE2EEHeader, "true")
+	}
+	if policy := RoomDuplicateIdentity(labels); policy != DuplicateIdentityReplace {
+		_ = twirp.SetHTTPResponseHeader(ctx, roomDuplicateIdentityHeader, string(policy))
+	}
+	return nil
+}
+
+// setRoomSchedule keeps the start and expiry of the room with its labels, zero times are left unset
+func (s *RoomService) setRoomSchedule(ctx context.Context, roomName livekit.RoomName, startsAt time.Time, expiresAt time.Time) error {
+	_, err := changeRoomLabels(ctx, s.roomStore, roomName, func(labels RoomLabels) (bool, error) {
+		if !startsAt.IsZero() {
+			labels[roomStartsAtLabel] = strconv.FormatInt(startsAt.Unix(), 10)
+		}
+		if !expiresAt.IsZero() {
+			labels[roomExpiresAtLabel] = strconv.FormatInt(expiresAt.Unix(), 10)
+		}
+		return true, nil
+	})
+	return err
+}
+
 func (s *RoomService) writeParticipantMessage(ctx context.Context, room livekit.RoomName, identity livekit.ParticipantIdentity, msg *livekit.RTCNodeMessage) error {
 	if err := EnsureAdminPermission(ctx, room); err != nil {
 		return twirpAuthError(err)
@@ -122,13 +122,66 @@ func TestMetaDataLimits(t *testing.T) {
 	}
 }
 
+func TestSendData(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+	})
+	topic := "chat"
+	req := func(data string, kind livekit.DataPacket_Kind) *livekit.SendDataRequest {
+		return &livekit.SendDataRequest{
+			Room:                  "testroom",
+			Data:                  []byte(data),
+			Kind:                  kind,
+			DestinationIdentities: []string{"user"},
+			Topic:                 &topic,
+		}
+	}
+	requireCode := func(t *testing.T, code twirp.ErrorCode, err error) {
+		terr, ok := err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, code, terr.Code())
+	}
+
+	t.Run("sends to the room", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{MaxDataPacketSize: 5})
+		svc.store.LoadRoomReturns(&livekit.Room{Name: "testroom"}, nil, nil)
+		_, err := svc.SendData(ctx, req("abc", livekit.DataPacket_LOSSY))
+		require.NoError(t, err)
+		require.Equal(t, 1, svc.router.WriteRoomRTCCallCount())
+		_, roomName, msg := svc.router.WriteRoomRTCArgsForCall(0)
+		require.Equal(t, livekit.RoomName("testroom"), roomName)
+		require.Equal(t, []string{"user"}, msg.GetSendData().DestinationIdentities)
+		require.Equal(t, livekit.DataPacket_LOSSY, msg.GetSendData().Kind)
+	})
+
+	t.Run("data exceeds limits", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{MaxDataPacketSize: 5})
+		_, err := svc.SendData(ctx, req("abcdefg", livekit.DataPacket_RELIABLE))
+		requireCode(t, twirp.InvalidArgument, err)
+	})
+
+	t.Run("invalid kind", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		_, err := svc.SendData(ctx, req("abc", livekit.DataPacket_Kind(5)))
+		requireCode(t, twirp.InvalidArgument, err)
+	})
+
+	t.Run("room must exist", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.store.LoadRoomReturns(nil, nil, service.ErrRoomNotFound)
+		_, err := svc.SendData(ctx, req("abc", livekit.DataPacket_RELIABLE))
+		requireCode(t, twirp.NotFound, err)
+		require.Zero(t, svc.router.WriteRoomRTCCallCount())
+	})
+}
+
 func newTestRoomService(conf config.RoomConfig) *TestRoomService {
 	router := &routingfakes.FakeRouter{}
 	allocator := &servicefakes.FakeRoomAllocator{}
-	store := &servicefakes.FakeServiceStore{}
+	store := &servicefakes.FakeObjectStore{}
 	svc, err := service.NewRoomService(conf,
 		config.APIConfig{ExecutionTimeout: 2},
-		router, allocator, store, nil)
+		router, allocator, store, nil, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,316 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+// each node writes the rooms it hosts to <node id>.json.gz, replacing its previous snapshot
+const roomSnapshotSuffix = ".json.gz"
+
+// RoomSnapshot is the state of the rooms hosted by a node, as found in the store
+type RoomSnapshot struct {
+	NodeID  livekit.NodeID       `json:"node_id"`
+	TakenAt time.Time            `json:"taken_at"`
+	Rooms   []*RoomSnapshotEntry `json:"rooms"`
+}
+
+// RoomSnapshotEntry holds the room, its internal state and its participants in protobuf encoding
+type RoomSnapshotEntry struct {
+	Room         []byte     `json:"room"`
+	Internal     []byte     `json:"internal,omitempty"`
+	Labels       RoomLabels `json:"labels,omitempty"`
+	Participants [][]byte   `json:"participants,omitempty"`
+}
+
+func (s *RoomSnapshot) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func UnmarshalRoomSnapshot(data []byte) (*RoomSnapshot, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &RoomSnapshot{}
+	if err = json.Unmarshal(b, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// RoomSnapshotter periodically writes the rooms hosted by the node to snapshot storage, and on startup
+// restores rooms of nodes that are still running when the store has lost them
+type RoomSnapshotter struct {
+	conf        config.SnapshotConfig
+	storage     SnapshotStorage
+	store       ObjectStore
+	router      routing.Router
+	currentNode routing.LocalNode
+	roomManager *RoomManager
+	shutdown    chan struct{}
+}
+
+func NewRoomSnapshotter(
+	conf *config.Config,
+	store ObjectStore,
+	router routing.Router,
+	currentNode routing.LocalNode,
+	roomManager *RoomManager,
+) (*RoomSnapshotter, error) {
+	s := &RoomSnapshotter{
+		conf:        conf.Store.Snapshot,
+		store:       store,
+		router:      router,
+		currentNode: currentNode,
+		roomManager: roomManager,
+		shutdown:    make(chan struct{}),
+	}
+	if !s.conf.IsConfigured() {
+		return s, nil
+	}
+
+	storage, err := NewSnapshotStorage(context.Background(), s.conf)
+	if err != nil {
+		return nil, err
+	}
+	s.storage = storage
+	return s, nil
+}
+
+// Start restores rooms and starts taking snapshots, it is a no-op when snapshots are not configured
+func (s *RoomSnapshotter) Start() error {
+	if s.storage == nil {
+		return nil
+	}
+
+	if s.conf.RestoreMaxAge > 0 {
+		if err := s.Restore(context.Background()); err != nil {
+			logger.Warnw("could not restore rooms from snapshots", err)
+		}
+	}
+	go s.worker()
+	return nil
+}
+
+func (s *RoomSnapshotter) Stop() {
+	if s.storage == nil {
+		return
+	}
+	select {
+	case <-s.shutdown:
+	default:
+		close(s.shutdown)
+	}
+}
+
+func (s *RoomSnapshotter) worker() {
+	ticker := time.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(context.Background()); err != nil {
+				logger.Warnw("could not snapshot rooms", err)
+			}
+		}
+	}
+}
+
+// Snapshot writes the rooms hosted by the node. a node without rooms writes an empty snapshot,
+// so that rooms it no longer hosts are not restored
+func (s *RoomSnapshotter) Snapshot(ctx context.Context) error {
+	snapshot := &RoomSnapshot{
+		NodeID:  livekit.NodeID(s.currentNode.Id),
+		TakenAt: time.Now(),
+		Rooms:   make([]*RoomSnapshotEntry, 0),
+	}
+
+	for _, roomName := range s.roomManager.RoomNames() {
+		room, internal, err := s.store.LoadRoom(ctx, roomName, true)
+		if err == ErrRoomNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		entry := &RoomSnapshotEntry{}
+		if entry.Room, err = proto.Marshal(room); err != nil {
+			return err
+		}
+		if internal != nil {
+			if entry.Internal, err = proto.Marshal(internal); err != nil {
+				return err
+			}
+		}
+		if entry.Labels, err = s.store.LoadRoomLabels(ctx, roomName); err != nil && err != ErrRoomNotFound {
+			return err
+		}
+
+		participants, err := s.store.ListParticipants(ctx, roomName)
+		if err != nil {
+			return err
+		}
+		for _, pi := range participants {
+			data, err := proto.Marshal(pi)
+			if err != nil {
+				return err
+			}
+			entry.Participants = append(entry.Participants, data)
+		}
+
+		snapshot.Rooms = append(snapshot.Rooms, entry)
+	}
+
+	data, err := snapshot.Marshal()
+	if err != nil {
+		return err
+	}
+	return s.storage.Put(ctx, s.currentNode.Id+roomSnapshotSuffix, data)
+}
+
+// Restore stores rooms of recent snapshots that are missing from the store, with their participants
+// and their routing to the node hosting them. Rooms of nodes that are not running are not restored.
+func (s *RoomSnapshotter) Restore(ctx context.Context) error {
+	nodes, err := s.router.ListNodes()
+	if err != nil {
+		return err
+	}
+	running := make(map[livekit.NodeID]bool, len(nodes))
+	for _, node := range nodes {
+		running[livekit.NodeID(node.Id)] = true
+	}
+
+	names, err := s.storage.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	restored := 0
+	for _, name := range names {
+		if !strings.HasSuffix(name, roomSnapshotSuffix) {
+			continue
+		}
+
+		data, err := s.storage.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		snapshot, err := UnmarshalRoomSnapshot(data)
+		if err != nil {
+			logger.Warnw("could not read room snapshot", err, "snapshot", name)
+			continue
+		}
+
+		// rooms of this node ended when it stopped
+		if snapshot.NodeID == livekit.NodeID(s.currentNode.Id) || !running[snapshot.NodeID] {
+			continue
+		}
+		if time.Since(snapshot.TakenAt) > s.conf.RestoreMaxAge {
+			continue
+		}
+
+		for _, entry := range snapshot.Rooms {
+			ok, err := s.restoreRoom(ctx, snapshot.NodeID, entry)
+			if err != nil {
+				return err
+			}
+			if ok {
+				restored++
+			}
+		}
+	}
+
+	if restored > 0 {
+		logger.Infow("restored rooms from snapshots", "rooms", restored)
+	}
+	return nil
+}
+
+func (s *RoomSnapshotter) restoreRoom(ctx context.Context, nodeID livekit.NodeID, entry *RoomSnapshotEntry) (bool, error) {
+	room := &livekit.Room{}
+	if err := proto.Unmarshal(entry.Room, room); err != nil {
+		return false, err
+	}
+	roomName := livekit.RoomName(room.Name)
+
+	// rooms still in the store are up to date
+	if _, _, err := s.store.LoadRoom(ctx, roomName, false); err == nil {
+		return false, nil
+	} else if err != ErrRoomNotFound {
+		return false, err
+	}
+
+	var internal *livekit.RoomInternal
+	if entry.Internal != nil {
+		internal = &livekit.RoomInternal{}
+		if err := proto.Unmarshal(entry.Internal, internal); err != nil {
+			return false, err
+		}
+	}
+	if err := s.store.StoreRoom(ctx, room, internal); err != nil {
+		return false, err
+	}
+	if len(entry.Labels) != 0 {
+		if err := s.store.StoreRoomLabels(ctx, roomName, entry.Labels); err != nil {
+			return false, err
+		}
+	}
+	for _, data := range entry.Participants {
+		pi := &livekit.ParticipantInfo{}
+		if err := proto.Unmarshal(data, pi); err != nil {
+			return false, err
+		}
+		if err := s.store.StoreParticipant(ctx, roomName, pi); err != nil {
+			return false, err
+		}
+	}
+
+	if err := s.router.SetNodeForRoom(ctx, roomName, nodeID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRoomSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	conf := config.DefaultConfig
+	conf.Store.Snapshot.URL = "file://" + t.TempDir()
+
+	storage, err := service.NewSnapshotStorage(ctx, conf.Store.Snapshot)
+	require.NoError(t, err)
+
+	writeSnapshot := func(nodeID livekit.NodeID, takenAt time.Time, roomName string) {
+		room, err := proto.Marshal(&livekit.Room{Sid: "RM_" + roomName, Name: roomName})
+		require.NoError(t, err)
+		participant, err := proto.Marshal(&livekit.ParticipantInfo{Sid: "PA_" + roomName, Identity: "user"})
+		require.NoError(t, err)
+
+		snapshot := &service.RoomSnapshot{
+			NodeID:  nodeID,
+			TakenAt: takenAt,
+			Rooms: []*service.RoomSnapshotEntry{{
+				Room:         room,
+				Labels:       service.RoomLabels{"product": "meet"},
+				Participants: [][]byte{participant},
+			}},
+		}
+		data, err := snapshot.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, storage.Put(ctx, string(nodeID)+".json.gz", data))
+	}
+	writeSnapshot("ND_running", time.Now(), "restored")
+	writeSnapshot("ND_stale", time.Now().Add(-time.Hour), "stale")
+	writeSnapshot("ND_gone", time.Now(), "gone")
+	writeSnapshot("ND_current", time.Now(), "current")
+
+	router := &routingfakes.FakeRouter{}
+	router.ListNodesReturns([]*livekit.Node{{Id: "ND_running"}, {Id: "ND_stale"}, {Id: "ND_current"}}, nil)
+	store := service.NewLocalStore()
+	currentNode := &livekit.Node{Id: "ND_current"}
+
+	snapshotter, err := service.NewRoomSnapshotter(&conf, store, router, currentNode, &service.RoomManager{})
+	require.NoError(t, err)
+	require.NoError(t, snapshotter.Restore(ctx))
+
+	rooms, err := store.ListRooms(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	require.Equal(t, "RM_restored", rooms[0].Sid)
+
+	participants, err := store.ListParticipants(ctx, "restored")
+	require.NoError(t, err)
+	require.Len(t, participants, 1)
+	labels, err := store.LoadRoomLabels(ctx, "restored")
+	require.NoError(t, err)
+	require.Equal(t, service.RoomLabels{"product": "meet"}, labels)
+
+	require.Equal(t, 1, router.SetNodeForRoomCallCount())
+	_, roomName, nodeID := router.SetNodeForRoomArgsForCall(0)
+	require.Equal(t, livekit.RoomName("restored"), roomName)
+	require.Equal(t, livekit.NodeID("ND_running"), nodeID)
+
+	// a node without rooms replaces its snapshot with an empty one
+	require.NoError(t, snapshotter.Snapshot(ctx))
+	data, err := storage.Get(ctx, "ND_current.json.gz")
+	require.NoError(t, err)
+	snapshot, err := service.UnmarshalRoomSnapshot(data)
+	require.NoError(t, err)
+	require.Equal(t, livekit.NodeID("ND_current"), snapshot.NodeID)
+	require.Empty(t, snapshot.Rooms)
+}
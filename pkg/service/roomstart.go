@@ -0,0 +1,104 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// start of the room in unix seconds, set on CreateRoom requests and returned on their responses.
+	// participants cannot join the room before it starts
+	roomStartsAtHeader = "X-Livekit-Room-Starts-At"
+
+	// the start is kept with the labels of the room, so that every store persists it
+	roomStartsAtLabel = reservedLabelPrefix + "starts-at"
+)
+
+// RoomNotStartedError rejects participants joining a room before its start
+type RoomNotStartedError struct {
+	StartsAt time.Time
+}
+
+func (e *RoomNotStartedError) Error() string {
+	return fmt.Sprintf("%s, starts in %s", ErrRoomNotStarted.Error(), e.Remaining())
+}
+
+func (e *RoomNotStartedError) Unwrap() error {
+	return ErrRoomNotStarted
+}
+
+// Remaining returns the time until the room starts, rounded up to seconds
+func (e *RoomNotStartedError) Remaining() time.Duration {
+	remaining := time.Until(e.StartsAt)
+	if remaining < 0 {
+		return 0
+	}
+	return (remaining + time.Second - 1).Truncate(time.Second)
+}
+
+type roomNotStartedResponse struct {
+	Error            string `json:"error"`
+	StartsAt         int64  `json:"starts_at"`
+	RemainingSeconds int64  `json:"remaining_seconds"`
+}
+
+// write responds to the join with the start of the room, and when to retry
+func (e *RoomNotStartedError) write(w http.ResponseWriter, status int) {
+	remaining := int64(e.Remaining() / time.Second)
+	b, err := json.Marshal(&roomNotStartedResponse{
+		Error:            ErrRoomNotStarted.Error(),
+		StartsAt:         e.StartsAt.Unix(),
+		RemainingSeconds: remaining,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.FormatInt(remaining, 10))
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func roomStartFromRequest(ctx context.Context) (time.Time, error) {
+	value, ok := lookupRequestOption(ctx, roomStartsAtOption)
+	if !ok {
+		return time.Time{}, nil
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidRoomStart
+	}
+	startsAt := time.Unix(unix, 0)
+	if !startsAt.After(time.Now()) {
+		return time.Time{}, ErrInvalidRoomStart
+	}
+	return startsAt, nil
+}
+
+// RoomStartsAt returns the start kept with the labels of a room, zero if the room is not scheduled
+func RoomStartsAt(labels RoomLabels) time.Time {
+	unix, err := strconv.ParseInt(labels[roomStartsAtLabel], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
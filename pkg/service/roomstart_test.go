@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomStartsAt(t *testing.T) {
+	require.True(t, RoomStartsAt(RoomLabels{"product": "webinar"}).IsZero())
+	require.Equal(t, int64(1700000000), RoomStartsAt(RoomLabels{roomStartsAtLabel: "1700000000"}).Unix())
+
+	_, err := ParseRoomLabels(roomStartsAtLabel + "=1700000000")
+	require.ErrorIs(t, err, ErrInvalidRoomLabels)
+}
+
+func TestRoomNotStartedError(t *testing.T) {
+	startsAt := time.Now().Add(90 * time.Second)
+	var err error = &RoomNotStartedError{StartsAt: startsAt}
+	require.True(t, errors.Is(err, ErrRoomNotStarted))
+	require.Equal(t, 90*time.Second, err.(*RoomNotStartedError).Remaining())
+
+	w := httptest.NewRecorder()
+	handleJoinError(w, http.StatusTooEarly, err)
+	require.Equal(t, http.StatusTooEarly, w.Code)
+	require.Equal(t, "90", w.Header().Get("Retry-After"))
+
+	var res roomNotStartedResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, startsAt.Unix(), res.StartsAt)
+	require.Equal(t, int64(90), res.RemainingSeconds)
+
+	started := &RoomNotStartedError{StartsAt: time.Now().Add(-time.Second)}
+	require.Zero(t, started.Remaining())
+}
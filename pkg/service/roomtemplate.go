@@ -0,0 +1,269 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	// name of the template to create the room from, set on CreateRoom requests
+	roomTemplateHeader = "X-Livekit-Room-Template"
+
+	maxRoomTemplateSize = 64 * 1024
+)
+
+var roomTemplateNameRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,62}[A-Za-z0-9])?$`)
+
+// RoomTemplate is a named bundle of room settings. Rooms created from the template take the settings of
+// the template that are not set on the request.
+type RoomTemplate struct {
+	Name string
+	// settings of the room, the name of the request is not used
+	Request *livekit.CreateRoomRequest
+	// labels of the room, labels set on the request replace these
+	Labels RoomLabels
+}
+
+type roomTemplateJSON struct {
+	Name    string          `json:"name"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Labels  RoomLabels      `json:"labels,omitempty"`
+}
+
+func (t *RoomTemplate) MarshalJSON() ([]byte, error) {
+	v := roomTemplateJSON{
+		Name:   t.Name,
+		Labels: t.Labels,
+	}
+	if t.Request != nil {
+		var err error
+		if v.Request, err = protojson.Marshal(t.Request); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(v)
+}
+
+func (t *RoomTemplate) UnmarshalJSON(data []byte) error {
+	var v roomTemplateJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Name = v.Name
+	t.Labels = v.Labels
+	t.Request = &livekit.CreateRoomRequest{}
+	if len(v.Request) != 0 {
+		return protojson.Unmarshal(v.Request, t.Request)
+	}
+	return nil
+}
+
+func (t *RoomTemplate) clone() *RoomTemplate {
+	c := &RoomTemplate{Name: t.Name}
+	if t.Request != nil {
+		c.Request = proto.Clone(t.Request).(*livekit.CreateRoomRequest)
+	}
+	if t.Labels != nil {
+		c.Labels = make(RoomLabels, len(t.Labels))
+		for key, value := range t.Labels {
+			c.Labels[key] = value
+		}
+	}
+	return c
+}
+
+func (t *RoomTemplate) Validate() error {
+	if !roomTemplateNameRegexp.MatchString(t.Name) {
+		return ErrInvalidRoomTemplate
+	}
+	if t.Request != nil && t.Request.Name != "" {
+		return ErrInvalidRoomTemplate
+	}
+	for key, value := range t.Labels {
+		if !labelKeyRegexp.MatchString(key) || !labelValueRegexp.MatchString(value) || isReservedLabel(key) {
+			return ErrInvalidRoomTemplate
+		}
+	}
+	if len(t.Labels) > maxRoomLabels {
+		return ErrInvalidRoomTemplate
+	}
+	return nil
+}
+
+// Apply returns the request with settings of the template for fields the request does not set
+func (t *RoomTemplate) Apply(req *livekit.CreateRoomRequest) *livekit.CreateRoomRequest {
+	applied := &livekit.CreateRoomRequest{}
+	if t.Request != nil {
+		applied = proto.Clone(t.Request).(*livekit.CreateRoomRequest)
+	}
+	proto.Merge(applied, req)
+	return applied
+}
+
+// ApplyLabels returns the labels of the template, replaced by labels of the request unless it has none
+func (t *RoomTemplate) ApplyLabels(labels RoomLabels) RoomLabels {
+	if labels != nil || t.Labels == nil {
+		return labels
+	}
+	applied := make(RoomLabels, len(t.Labels))
+	for key, value := range t.Labels {
+		applied[key] = value
+	}
+	return applied
+}
+
+// RoomTemplateService manages room templates at /room_templates. templates are listed with GET, and
+// a single template with GET ?name=. PUT creates or replaces the template of the body, DELETE ?name= removes it
+type RoomTemplateService struct {
+	store RoomTemplateStore
+}
+
+func NewRoomTemplateService(store RoomTemplateStore) *RoomTemplateService {
+	return &RoomTemplateService{
+		store: store,
+	}
+}
+
+func (s *RoomTemplateService) StoreRoomTemplate(ctx context.Context, template *RoomTemplate) error {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return err
+	}
+	if s.store == nil {
+		return ErrRoomTemplatesNotEnabled
+	}
+	if err := template.Validate(); err != nil {
+		return err
+	}
+
+	scoped := *template
+	name, err := scopeRoomTemplateName(ctx, template.Name)
+	if err != nil {
+		return err
+	}
+	scoped.Name = name
+	return s.store.StoreRoomTemplate(ctx, &scoped)
+}
+
+func (s *RoomTemplateService) LoadRoomTemplate(ctx context.Context, name string) (*RoomTemplate, error) {
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, err
+	}
+	return loadRoomTemplate(ctx, s.store, name)
+}
+
+func (s *RoomTemplateService) ListRoomTemplates(ctx context.Context) ([]*RoomTemplate, error) {
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, err
+	}
+	if s.store == nil {
+		return nil, ErrRoomTemplatesNotEnabled
+	}
+
+	templates, err := s.store.ListRoomTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, _ := GetProject(ctx)
+	scoped := make([]*RoomTemplate, 0, len(templates))
+	for _, t := range templates {
+		if p, name := utils.SplitProjectRoomName(livekit.RoomName(t.Name)); p == project {
+			t.Name = string(name)
+			scoped = append(scoped, t)
+		}
+	}
+	sort.Slice(scoped, func(i, j int) bool {
+		return scoped[i].Name < scoped[j].Name
+	})
+	return scoped, nil
+}
+
+func (s *RoomTemplateService) DeleteRoomTemplate(ctx context.Context, name string) error {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return err
+	}
+	if s.store == nil {
+		return ErrRoomTemplatesNotEnabled
+	}
+	scoped, err := scopeRoomTemplateName(ctx, name)
+	if err != nil {
+		return err
+	}
+	return s.store.DeleteRoomTemplate(ctx, scoped)
+}
+
+// loadRoomTemplate loads the template of the project of the request, without checking permissions
+func loadRoomTemplate(ctx context.Context, store RoomTemplateStore, name string) (*RoomTemplate, error) {
+	if store == nil {
+		return nil, ErrRoomTemplatesNotEnabled
+	}
+	scoped, err := scopeRoomTemplateName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	template, err := store.LoadRoomTemplate(ctx, scoped)
+	if err != nil {
+		return nil, err
+	}
+	template.Name = name
+	return template, nil
+}
+
+// templates are kept apart by project like rooms
+func scopeRoomTemplateName(ctx context.Context, name string) (string, error) {
+	if project, ok := GetProject(ctx); ok {
+		return scopeRoomName(project, name)
+	}
+	return name, nil
+}
+
+type roomTemplatesResponse struct {
+	Templates []*RoomTemplate `json:"templates"`
+}
+
+func (s *RoomTemplateService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			if name := r.URL.Query().Get("name"); name != "" {
+				return s.LoadRoomTemplate(r.Context(), name)
+			}
+			templates, err := s.ListRoomTemplates(r.Context())
+			if err != nil {
+				return nil, err
+			}
+			return &roomTemplatesResponse{Templates: templates}, nil
+		},
+		http.MethodPut: jsonRequest(maxRoomTemplateSize, func(ctx context.Context, template *RoomTemplate) (interface{}, error) {
+			if err := s.StoreRoomTemplate(ctx, template); err != nil {
+				return nil, err
+			}
+			return template, nil
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			return nil, s.DeleteRoomTemplate(r.Context(), r.URL.Query().Get("name"))
+		},
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRoomTemplateApply(t *testing.T) {
+	template := &service.RoomTemplate{
+		Name: "interview",
+		Request: &livekit.CreateRoomRequest{
+			EmptyTimeout:    300,
+			MaxParticipants: 2,
+			Metadata:        "template",
+		},
+		Labels: service.RoomLabels{"product": "interview"},
+	}
+
+	req := template.Apply(&livekit.CreateRoomRequest{Name: "candidate-1", Metadata: "request"})
+	require.True(t, proto.Equal(&livekit.CreateRoomRequest{
+		Name:            "candidate-1",
+		EmptyTimeout:    300,
+		MaxParticipants: 2,
+		Metadata:        "request",
+	}, req))
+
+	require.Equal(t, service.RoomLabels{"product": "interview"}, template.ApplyLabels(nil))
+	require.Equal(t, service.RoomLabels{"tier": "free"}, template.ApplyLabels(service.RoomLabels{"tier": "free"}))
+
+	data, err := json.Marshal(template)
+	require.NoError(t, err)
+	decoded := &service.RoomTemplate{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Equal(t, template.Name, decoded.Name)
+	require.Equal(t, template.Labels, decoded.Labels)
+	require.True(t, proto.Equal(template.Request, decoded.Request))
+}
+
+func TestRoomTemplateService(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomCreate: true, RoomList: true},
+	})
+	svc := service.NewRoomTemplateService(service.NewLocalStore())
+
+	require.ErrorIs(t, svc.StoreRoomTemplate(ctx, &service.RoomTemplate{Name: "bad name"}), service.ErrInvalidRoomTemplate)
+	require.ErrorIs(t, svc.StoreRoomTemplate(ctx, &service.RoomTemplate{
+		Name:    "named",
+		Request: &livekit.CreateRoomRequest{Name: "room"},
+	}), service.ErrInvalidRoomTemplate)
+
+	require.NoError(t, svc.StoreRoomTemplate(ctx, &service.RoomTemplate{Name: "exam", Request: &livekit.CreateRoomRequest{EmptyTimeout: 60}}))
+	require.NoError(t, svc.StoreRoomTemplate(service.WithProject(ctx, "customer"), &service.RoomTemplate{Name: "exam"}))
+
+	template, err := svc.LoadRoomTemplate(ctx, "exam")
+	require.NoError(t, err)
+	require.Equal(t, uint32(60), template.Request.EmptyTimeout)
+
+	// templates are kept apart by project
+	templates, err := svc.ListRoomTemplates(service.WithProject(ctx, "customer"))
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, "exam", templates[0].Name)
+	require.Zero(t, templates[0].Request.GetEmptyTimeout())
+
+	require.NoError(t, svc.DeleteRoomTemplate(ctx, "exam"))
+	_, err = svc.LoadRoomTemplate(ctx, "exam")
+	require.ErrorIs(t, err, service.ErrRoomTemplateNotFound)
+	_, err = svc.LoadRoomTemplate(service.WithProject(ctx, "customer"), "exam")
+	require.NoError(t, err)
+
+	_, err = svc.ListRoomTemplates(context.Background())
+	require.ErrorIs(t, err, service.ErrPermissionDenied)
+}
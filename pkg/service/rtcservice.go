@@ -48,6 +48,10 @@ type RTCService struct {
 	router        routing.MessageRouter
 	roomAllocator RoomAllocator
 	store         ServiceStore
+	keyUsage      KeyUsageStore
+	joinPolicies  *JoinPolicies
+	passcodes     *passcodeAttempts
+	geoAccess     *GeoRestrictions
 	upgrader      websocket.Upgrader
 	currentNode   routing.LocalNode
 	config        *config.Config
@@ -56,6 +60,9 @@ type RTCService struct {
 	parser        *uaparser.Parser
 	telemetry     telemetry.TelemetryService
 
+	// verifies refreshed tokens of signal connections, nil when the server has no keys
+	tokenAuth *APIKeyAuthMiddleware
+
 	mu          sync.Mutex
 	connections map[*websocket.Conn]struct{}
 }
@@ -64,6 +71,9 @@ func NewRTCService(
 	conf *config.Config,
 	ra RoomAllocator,
 	store ServiceStore,
+	keyUsage KeyUsageStore,
+	joinPolicies *JoinPolicies,
+	geoRestrictions *GeoRestrictions,
 	router routing.MessageRouter,
 	currentNode routing.LocalNode,
 	telemetry telemetry.TelemetryService,
@@ -72,6 +82,10 @@ func NewRTCService(
 		router:        router,
 		roomAllocator: ra,
 		store:         store,
+		keyUsage:      keyUsage,
+		joinPolicies:  joinPolicies,
+		passcodes:     newPasscodeAttempts(conf.Room.PasscodeAttempts, conf.Room.PasscodeRoomAttempts),
+		geoAccess:     geoRestrictions,
 		upgrader:      websocket.Upgrader{},
 		currentNode:   currentNode,
 		config:        conf,
@@ -91,10 +105,56 @@ func NewRTCService(
 	return s
 }
 
+// ensureRoomJoinable rejects joining a room before its start, and joining a locked room
+// unless the participant is already in the room
+func (s *RTCService) ensureRoomJoinable(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, reconnect bool) error {
+	labels, err := s.store.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		// a room yet to be created cannot be scheduled or locked
+		if err == ErrRoomNotFound {
+			return nil
+		}
+		return err
+	}
+	if startsAt := RoomStartsAt(labels); time.Now().Before(startsAt) {
+		return &RoomNotStartedError{StartsAt: startsAt}
+	}
+
+	reason, locked := RoomLockReason(labels)
+	if !locked || reconnect {
+		return nil
+	}
+	if _, err = s.store.LoadParticipant(ctx, roomName, identity); err == nil {
+		return nil
+	}
+	return roomLockedError(reason)
+}
+
+// handleJoinError responds to a rejected join, rooms that have not started respond with their start, full rooms
+// with their participant cap and rooms with a passcode with the reason it was not accepted
+func handleJoinError(w http.ResponseWriter, status int, err error) {
+	var notStarted *RoomNotStartedError
+	if errors.As(err, &notStarted) {
+		notStarted.write(w, status)
+		return
+	}
+	var full *RoomFullError
+	if errors.As(err, &full) {
+		full.write(w, status)
+		return
+	}
+	var passcode *RoomPasscodeError
+	if errors.As(err, &passcode) {
+		passcode.write(w, status)
+		return
+	}
+	handleError(w, status, err)
+}
+
 func (s *RTCService) Validate(w http.ResponseWriter, r *http.Request) {
 	_, _, code, err := s.validate(r)
 	if err != nil {
-		handleError(w, code, err)
+		handleJoinError(w, code, err)
 		return
 	}
 	_, _ = w.Write([]byte("success"))
@@ -117,6 +177,9 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	if claims.Identity == "" {
 		return "", pi, http.StatusBadRequest, ErrIdentityEmpty
 	}
+	if err = s.ensureTokenBinding(r.Context(), GetClientIP(r), r.FormValue(tokenFingerprintParam)); err != nil {
+		return "", pi, http.StatusForbidden, err
+	}
 
 	roomName := livekit.RoomName(r.FormValue("room"))
 	reconnectParam := r.FormValue("reconnect")
@@ -152,6 +215,55 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 		}
 	}
 
+	if err = s.ensureRoomJoinable(r.Context(), roomName, livekit.ParticipantIdentity(claims.Identity), boolValue(reconnectParam)); err != nil {
+		switch {
+		case errors.Is(err, ErrRoomNotStarted):
+			return "", pi, http.StatusTooEarly, err
+		case errors.Is(err, ErrRoomLocked):
+			return "", pi, http.StatusForbidden, err
+		default:
+			return "", pi, http.StatusInternalServerError, err
+		}
+	}
+	if err = s.ensureRoomPasscode(r.Context(), roomName, r.FormValue(roomPasscodeParam), GetClientIP(r), boolValue(reconnectParam)); err != nil {
+		switch {
+		case errors.Is(err, ErrRoomPasscodeRequired):
+			return "", pi, http.StatusUnauthorized, err
+		case errors.Is(err, ErrIncorrectRoomPasscode):
+			return "", pi, http.StatusForbidden, err
+		case errors.Is(err, ErrPasscodeAttemptsExceeded):
+			return "", pi, http.StatusTooManyRequests, err
+		default:
+			return "", pi, http.StatusInternalServerError, err
+		}
+	}
+	if err = s.ensureRoomNotFull(r.Context(), roomName, livekit.ParticipantIdentity(claims.Identity), claims.Video.Recorder, boolValue(reconnectParam)); err != nil {
+		if errors.Is(err, ErrRoomFull) {
+			return "", pi, http.StatusForbidden, err
+		}
+		return "", pi, http.StatusInternalServerError, err
+	}
+	if err = s.ensureIdentityAvailable(r.Context(), roomName, livekit.ParticipantIdentity(claims.Identity), boolValue(reconnectParam)); err != nil {
+		if errors.Is(err, ErrDuplicateIdentity) {
+			return "", pi, http.StatusConflict, err
+		}
+		return "", pi, http.StatusInternalServerError, err
+	}
+	if !boolValue(reconnectParam) {
+		if err = s.ensureJoinAllowed(r.Context(), roomName, GetClientIP(r)); err != nil {
+			if errors.Is(err, ErrJoinDenied) {
+				return "", pi, http.StatusForbidden, err
+			}
+			return "", pi, http.StatusInternalServerError, err
+		}
+		if err = s.ensureJoinGeoAllowed(r.Context(), roomName, GetClientIP(r)); err != nil {
+			if errors.Is(err, ErrJoinGeoRestricted) {
+				return "", pi, http.StatusForbidden, err
+			}
+			return "", pi, http.StatusInternalServerError, err
+		}
+	}
+
 	region := ""
 	if router, ok := s.router.(routing.Router); ok {
 		region = router.GetRegion()
@@ -163,14 +275,18 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	}
 
 	pi = routing.ParticipantInit{
-		Reconnect:       boolValue(reconnectParam),
-		ReconnectReason: livekit.ReconnectReason(reconnectReason),
-		Identity:        livekit.ParticipantIdentity(claims.Identity),
-		Name:            livekit.ParticipantName(claims.Name),
-		AutoSubscribe:   true,
-		Client:          s.ParseClientInfo(r),
-		Grants:          claims,
-		Region:          region,
+		Reconnect:            boolValue(reconnectParam),
+		ReconnectReason:      livekit.ReconnectReason(reconnectReason),
+		Identity:             livekit.ParticipantIdentity(claims.Identity),
+		Name:                 livekit.ParticipantName(claims.Name),
+		AutoSubscribe:        true,
+		Client:               s.ParseClientInfo(r),
+		Grants:               claims,
+		Region:               region,
+		Attributes:           tokenAttributes(r.Context()),
+		MaxSessionDuration:   tokenMaxSessionDuration(r.Context()),
+		MaxPublishResolution: tokenMaxPublishResolution(r.Context()),
+		TokenRefresh:         boolValue(r.FormValue(tokenRefreshParam)),
 	}
 	if pi.Reconnect {
 		pi.ID = livekit.ParticipantID(participantID)
@@ -199,9 +315,21 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	roomName, pi, code, err := s.validate(r)
 	if err != nil {
-		handleError(w, code, err)
+		handleJoinError(w, code, err)
 		return
 	}
+	// joins count against the quotas of keys as they connect, hidden participants don't
+	countsAgainstQuotas := !pi.Reconnect && !pi.Grants.Video.Hidden
+	if countsAgainstQuotas {
+		if err = s.ensureJoinQuotas(r.Context(), roomName, pi.Identity); err != nil {
+			if errors.Is(err, ErrParticipantQuotaExceeded) {
+				handleJoinError(w, http.StatusTooManyRequests, err)
+			} else {
+				handleJoinError(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
 
 	// for logger
 	loggerFields := []interface{}{
@@ -221,7 +349,7 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		connectionTimeout := 3 * time.Second * time.Duration(i+1)
 		ctx := utils.ContextWithAttempt(r.Context(), i)
 		cr, initialResponse, err = s.startConnection(ctx, roomName, pi, connectionTimeout)
-		if err == nil {
+		if err == nil || errors.Is(err, ErrRoomQuotaExceeded) {
 			break
 		}
 		if i < 2 {
@@ -231,14 +359,27 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	if err != nil {
 		prometheus.IncrementParticipantJoinFail(1)
+		if countsAgainstQuotas {
+			s.releaseJoinQuotas(r.Context(), roomName, pi.Identity)
+		}
+		if errors.Is(err, ErrRoomQuotaExceeded) {
+			handleError(w, http.StatusTooManyRequests, err, loggerFields...)
+			return
+		}
 		handleError(w, http.StatusInternalServerError, err, loggerFields...)
 		return
 	}
 
 	prometheus.IncrementParticipantJoin(1)
 
+	if pi.Reconnect {
+		pi.Identity = s.resumedIdentity(r.Context(), roomName, pi.Identity, pi.ID)
+	}
+
 	if !pi.Reconnect && initialResponse.GetJoin() != nil {
 		pi.ID = livekit.ParticipantID(initialResponse.GetJoin().GetParticipant().GetSid())
+		// the identity is suffixed when the room has a participant with it
+		pi.Identity = livekit.ParticipantIdentity(initialResponse.GetJoin().GetParticipant().GetIdentity())
 	}
 
 	var signalStats *telemetry.BytesTrackStats
@@ -288,6 +429,17 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// websocket established
 	sigConn := NewWSSignalConnection(conn)
+	// clients that do not ask for token refresh rely on the server to refresh their tokens, and do not have their
+	// token expiry enforced
+	if pi.TokenRefresh {
+		session := newTokenSession(r, roomName)
+		sigConn.OnTokenRefresh(func(token string) {
+			s.handleTokenRefresh(r.Context(), sigConn, session, token, pLogger)
+		})
+		if s.config.TokenExpiry.Enforce {
+			go s.enforceTokenExpiry(session, pi.Identity, done, pLogger)
+		}
+	}
 	count, err := sigConn.WriteResponse(initialResponse)
 	if err != nil {
 		pLogger.Warnw("could not write initial response", err)
@@ -0,0 +1,388 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/pkg/errors"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	secretsTimeout = 30 * time.Second
+
+	defaultVaultMount = "secret"
+)
+
+// RotatingKeyProvider is the key provider of the server, whose secrets are replaced as they are rotated
+type RotatingKeyProvider struct {
+	lock sync.RWMutex
+	keys map[string]string
+}
+
+func NewRotatingKeyProvider(keys map[string]string) *RotatingKeyProvider {
+	p := &RotatingKeyProvider{}
+	p.SetKeys(keys)
+	return p
+}
+
+func (p *RotatingKeyProvider) GetSecret(key string) string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.keys[key]
+}
+
+func (p *RotatingKeyProvider) NumKeys() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.keys)
+}
+
+// Keys returns a copy of the keys and their secrets
+func (p *RotatingKeyProvider) Keys() map[string]string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	keys := make(map[string]string, len(p.keys))
+	for key, secret := range p.keys {
+		keys[key] = secret
+	}
+	return keys
+}
+
+// SetKeys replaces the keys of the provider, keys that are not set are revoked
+func (p *RotatingKeyProvider) SetKeys(keys map[string]string) {
+	updated := make(map[string]string, len(keys))
+	for key, secret := range keys {
+		updated[key] = secret
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.keys = updated
+}
+
+// ConfigSecrets resolves the values of a config that reference secrets of Vault or AWS Secrets Manager
+type ConfigSecrets struct {
+	conf config.SecretsConfig
+	// API secrets as configured, references are resolved again when secrets are rotated
+	keys    map[string]string
+	keyFile string
+	client  *http.Client
+}
+
+func NewConfigSecrets(conf *config.Config) *ConfigSecrets {
+	s := &ConfigSecrets{
+		conf:    conf.Secrets,
+		keys:    make(map[string]string, len(conf.Keys)),
+		keyFile: conf.KeyFile,
+		client:  &http.Client{Timeout: secretsTimeout},
+	}
+	for key, secret := range conf.Keys {
+		s.keys[key] = secret
+	}
+	return s
+}
+
+// Resolve replaces the references of the config with their secrets, before the config is used. secrets of files are
+// kept in memory, for the references to be read with config.ReadFile
+func (s *ConfigSecrets) Resolve(conf *config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	r := s.newResolver()
+	keys, err := s.resolveKeys(ctx, r)
+	if err != nil {
+		return err
+	}
+	conf.Keys = keys
+
+	values := []*string{
+		&conf.Redis.Password,
+		&conf.Redis.SentinelPassword,
+	}
+	for i := range conf.RTC.TURNServers {
+		values = append(values, &conf.RTC.TURNServers[i].Secret, &conf.RTC.TURNServers[i].Credential)
+	}
+	for _, value := range values {
+		if *value, err = r.resolve(ctx, *value); err != nil {
+			return err
+		}
+	}
+
+	files := []string{
+		conf.TURN.CertFile,
+		conf.TURN.KeyFile,
+		conf.Redis.TLS.CACertFile,
+		conf.Redis.TLS.ClientCertFile,
+		conf.Redis.TLS.ClientKeyFile,
+		conf.AdminMTLS.CertFile,
+		conf.AdminMTLS.KeyFile,
+		conf.AdminMTLS.ClientCAFile,
+		conf.WebHook.SigningKeyFile,
+	}
+	for _, cert := range conf.RTC.DTLS.Certificates {
+		files = append(files, cert.CertFile, cert.KeyFile)
+	}
+	for _, file := range files {
+		if err = r.resolveFile(ctx, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RotateKeys resolves the API keys again and replaces those of the key provider, for it to verify and sign tokens
+// with rotated secrets. keys removed from the config, the keys secret or the key file are revoked
+func (s *ConfigSecrets) RotateKeys(provider *RotatingKeyProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	keys, err := s.resolveKeys(ctx, s.newResolver())
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return config.ErrKeysNotSet
+	}
+	provider.SetKeys(keys)
+	return nil
+}
+
+func (s *ConfigSecrets) newResolver() *secretResolver {
+	return &secretResolver{
+		conf:    s.conf,
+		client:  s.client,
+		secrets: make(map[string]map[string]string),
+	}
+}
+
+// resolveKeys returns the API keys and their secrets, keys of the key file take precedence over those of the config,
+// which take precedence over those of the keys secret
+func (s *ConfigSecrets) resolveKeys(ctx context.Context, r *secretResolver) (map[string]string, error) {
+	keys := make(map[string]string, len(s.keys))
+	if s.conf.Keys != "" {
+		fields, err := r.fetch(ctx, s.conf.Keys)
+		if err != nil {
+			return nil, err
+		}
+		for key, secret := range fields {
+			// the whole secret of AWS Secrets Manager
+			if key != "" {
+				keys[key] = secret
+			}
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("secret %s has no keys", s.conf.Keys)
+		}
+	}
+	for key, value := range s.keys {
+		secret, err := r.resolve(ctx, value)
+		if err != nil {
+			return nil, errors.Wrap(err, key)
+		}
+		keys[key] = secret
+	}
+	if s.keyFile != "" {
+		if err := config.LoadKeyFile(s.keyFile, keys); err != nil {
+			return nil, errors.Wrap(err, "could not load key file")
+		}
+	}
+	return keys, nil
+}
+
+// secretResolver fetches each secret referenced once
+type secretResolver struct {
+	conf      config.SecretsConfig
+	client    *http.Client
+	awsClient *secretsmanager.Client
+	// fields of the secrets fetched, by reference without a field
+	secrets map[string]map[string]string
+}
+
+// resolve returns the secret a value references, or the value when it is not a reference. a reference without a
+// field is the whole secret of AWS Secrets Manager, or the only field of a secret
+func (r *secretResolver) resolve(ctx context.Context, value string) (string, error) {
+	if !config.IsSecretReference(value) {
+		return value, nil
+	}
+	ref, field, _ := strings.Cut(value, "#")
+	fields, err := r.fetch(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if field == "" {
+		if secret, ok := fields[""]; ok {
+			return secret, nil
+		}
+		if len(fields) == 1 {
+			for _, secret := range fields {
+				return secret, nil
+			}
+		}
+		return "", fmt.Errorf("secret %s has several fields, one must be referenced", ref)
+	}
+	secret, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %s", ref, field)
+	}
+	return secret, nil
+}
+
+// resolveFile keeps the secret a file of the config references in memory, the reference is read as the file
+func (r *secretResolver) resolveFile(ctx context.Context, path string) error {
+	if !config.IsSecretReference(path) {
+		return nil
+	}
+	secret, err := r.resolve(ctx, path)
+	if err != nil {
+		return err
+	}
+	config.SetSecretFile(path, []byte(secret))
+	return nil
+}
+
+func (r *secretResolver) fetch(ctx context.Context, ref string) (map[string]string, error) {
+	if fields, ok := r.secrets[ref]; ok {
+		return fields, nil
+	}
+
+	var fields map[string]string
+	var err error
+	if path, ok := strings.CutPrefix(ref, config.VaultSecretPrefix); ok {
+		fields, err = r.fetchVault(ctx, path)
+	} else if id, ok := strings.CutPrefix(ref, config.AWSSecretPrefix); ok {
+		fields, err = r.fetchAWS(ctx, id)
+	} else {
+		err = errors.New("unknown secret store")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch secret %s", ref)
+	}
+	r.secrets[ref] = fields
+	return fields, nil
+}
+
+// fetchVault reads a secret of the KV v2 secrets engine
+func (r *secretResolver) fetchVault(ctx context.Context, path string) (map[string]string, error) {
+	vc := r.conf.Vault
+	address := vc.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, config.ErrVaultAddressNotSet
+	}
+	token := vc.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		return nil, config.ErrVaultTokenNotSet
+	}
+	mount := vc.Mount
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+
+	url := strings.TrimSuffix(address, "/") + "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if vc.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vc.Namespace)
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded with %s", res.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "invalid vault response")
+	}
+	return secretFields(body.Data.Data), nil
+}
+
+// fetchAWS reads a secret of AWS Secrets Manager, its fields are those of a JSON object and the whole secret
+func (r *secretResolver) fetchAWS(ctx context.Context, id string) (map[string]string, error) {
+	if r.awsClient == nil {
+		var opts []func(*awsconfig.LoadOptions) error
+		if r.conf.AWS.Region != "" {
+			opts = append(opts, awsconfig.WithRegion(r.conf.AWS.Region))
+		}
+		awsConf, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load aws config")
+		}
+		r.awsClient = secretsmanager.NewFromConfig(awsConf)
+	}
+
+	res, err := r.awsClient.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return nil, err
+	}
+	secret := string(res.SecretBinary)
+	if res.SecretString != nil {
+		secret = aws.ToString(res.SecretString)
+	}
+
+	fields := map[string]string{}
+	var data map[string]interface{}
+	if json.Unmarshal([]byte(secret), &data) == nil {
+		fields = secretFields(data)
+	}
+	fields[""] = secret
+	return fields, nil
+}
+
+// secretFields returns the fields of a secret, values that are not strings are JSON encoded
+func secretFields(data map[string]interface{}) map[string]string {
+	fields := make(map[string]string, len(data))
+	for field, value := range data {
+		if s, ok := value.(string); ok {
+			fields[field] = s
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		fields[field] = string(encoded)
+	}
+	return fields
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type fakeVault struct {
+	lock    sync.Mutex
+	secrets map[string]map[string]interface{}
+	reads   int
+}
+
+func (v *fakeVault) set(path string, data map[string]interface{}) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.secrets[path] = data
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if r.Header.Get("X-Vault-Token") != "root" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	v.reads++
+	data, ok := v.secrets[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+}
+
+func TestConfigSecrets(t *testing.T) {
+	vault := &fakeVault{secrets: map[string]map[string]interface{}{
+		"/v1/secret/data/livekit/api":   {"key1": "rotating-secret-1", "key2": "secret-2"},
+		"/v1/secret/data/livekit/redis": {"password": "redis-pass"},
+		"/v1/secret/data/livekit/turn":  {"secret": "turn-secret"},
+		"/v1/secret/data/livekit/tls":   {"cert": "-----BEGIN CERTIFICATE-----", "port": 443},
+	}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	newConfig := func() *config.Config {
+		conf := &config.Config{
+			Keys: map[string]string{
+				"key1":   "vault:livekit/api#key1",
+				"inline": "inline-secret",
+			},
+			Secrets: config.SecretsConfig{
+				Vault: config.VaultSecretsConfig{Address: server.URL, Token: "root"},
+			},
+		}
+		conf.Redis.Password = "vault:livekit/redis"
+		conf.RTC.TURNServers = []config.TURNServer{{Host: "turn.example.com", Secret: "vault:livekit/turn#secret"}}
+		conf.TURN.CertFile = "vault:livekit/tls#cert"
+		conf.TURN.KeyFile = "/etc/livekit/turn.key"
+		return conf
+	}
+
+	t.Run("references are resolved", func(t *testing.T) {
+		conf := newConfig()
+		require.NoError(t, NewConfigSecrets(conf).Resolve(conf))
+		require.Equal(t, map[string]string{"key1": "rotating-secret-1", "inline": "inline-secret"}, conf.Keys)
+		// the only field of the secret
+		require.Equal(t, "redis-pass", conf.Redis.Password)
+		require.Equal(t, "turn-secret", conf.RTC.TURNServers[0].Secret)
+		require.Equal(t, "/etc/livekit/turn.key", conf.TURN.KeyFile)
+
+		// secrets of files are kept in memory
+		require.Equal(t, "vault:livekit/tls#cert", conf.TURN.CertFile)
+		cert, err := config.ReadFile(conf.TURN.CertFile)
+		require.NoError(t, err)
+		require.Equal(t, "-----BEGIN CERTIFICATE-----", string(cert))
+		_, err = config.ReadFile("vault:livekit/tls#key")
+		require.Error(t, err)
+	})
+
+	t.Run("secrets are fetched once", func(t *testing.T) {
+		conf := newConfig()
+		conf.Keys["key2"] = "vault:livekit/api#key2"
+		conf.TURN.CertFile = ""
+		vault.reads = 0
+		require.NoError(t, NewConfigSecrets(conf).Resolve(conf))
+		require.Equal(t, "secret-2", conf.Keys["key2"])
+		require.Equal(t, 3, vault.reads)
+	})
+
+	t.Run("keys of a secret", func(t *testing.T) {
+		conf := newConfig()
+		conf.Secrets.Keys = "vault:livekit/api"
+		conf.Keys = map[string]string{"key2": "inline-secret-2"}
+		conf.TURN.CertFile = ""
+		require.NoError(t, NewConfigSecrets(conf).Resolve(conf))
+		require.Equal(t, map[string]string{"key1": "rotating-secret-1", "key2": "inline-secret-2"}, conf.Keys)
+	})
+
+	t.Run("invalid references", func(t *testing.T) {
+		conf := newConfig()
+		conf.Keys["key1"] = "vault:livekit/api#key3"
+		require.Error(t, NewConfigSecrets(conf).Resolve(conf))
+
+		conf = newConfig()
+		conf.Keys["key1"] = "vault:livekit/api"
+		require.Error(t, NewConfigSecrets(conf).Resolve(conf), "a field of secrets with several must be referenced")
+
+		conf = newConfig()
+		conf.Redis.Password = "vault:livekit/missing#password"
+		require.Error(t, NewConfigSecrets(conf).Resolve(conf))
+
+		conf = newConfig()
+		conf.Secrets.Vault.Token = "other"
+		require.Error(t, NewConfigSecrets(conf).Resolve(conf))
+
+		t.Setenv("VAULT_TOKEN", "")
+		conf = newConfig()
+		conf.Secrets.Vault.Token = ""
+		require.ErrorIs(t, NewConfigSecrets(conf).Resolve(conf), config.ErrVaultTokenNotSet)
+	})
+
+	t.Run("API keys are rotated", func(t *testing.T) {
+		keyFile, err := os.CreateTemp(t.TempDir(), "keys-*.yaml")
+		require.NoError(t, err)
+		_, err = keyFile.WriteString("file: file-secret\n")
+		require.NoError(t, err)
+		require.NoError(t, keyFile.Close())
+
+		conf := newConfig()
+		conf.TURN.CertFile = ""
+		conf.Secrets.Keys = "vault:livekit/api"
+		conf.KeyFile = keyFile.Name()
+		secrets := NewConfigSecrets(conf)
+		require.NoError(t, secrets.Resolve(conf))
+		require.Equal(t, "file-secret", conf.Keys["file"])
+		provider := NewRotatingKeyProvider(conf.Keys)
+		require.Equal(t, 4, provider.NumKeys())
+
+		vault.set("/v1/secret/data/livekit/api", map[string]interface{}{"key1": "rotated-secret-1"})
+		require.NoError(t, secrets.RotateKeys(provider))
+		require.Equal(t, "rotated-secret-1", provider.GetSecret("key1"))
+		require.Equal(t, "inline-secret", provider.GetSecret("inline"))
+		require.Equal(t, "file-secret", provider.GetSecret("file"))
+		// key2 was removed from the keys secret
+		require.Empty(t, provider.GetSecret("key2"))
+		require.Equal(t, 3, provider.NumKeys())
+
+		// the provider keeps its keys when they cannot be resolved
+		vault.set("/v1/secret/data/livekit/api", map[string]interface{}{})
+		require.Error(t, secrets.RotateKeys(provider))
+		require.Equal(t, "rotated-secret-1", provider.GetSecret("key1"))
+		require.Equal(t, 3, provider.NumKeys())
+	})
+}
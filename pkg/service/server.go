@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
@@ -28,15 +29,17 @@ import (
 	"time"
 
 	"github.com/pion/turn/v2"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/twitchtv/twirp"
 	"github.com/urfave/negroni/v3"
 	"go.uber.org/atomic"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	sutils "github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/livekit-server/version"
 	"github.com/livekit/protocol/auth"
@@ -50,11 +53,17 @@ type LivekitServer struct {
 	rtcService   *RTCService
 	httpServer   *http.Server
 	promServer   *http.Server
+	grpcServer   *grpc.Server
 	router       routing.Router
 	roomManager  *RoomManager
+	snapshotter  *RoomSnapshotter
 	signalServer *SignalServer
 	turnServer   *turn.Server
+	keyProvider  auth.KeyProvider
 	currentNode  routing.LocalNode
+	analytics    telemetry.AnalyticsService
+	reporter     telemetry.StatsReporter
+	sink         telemetry.AnalyticsSink
 	running      atomic.Bool
 	doneChan     chan struct{}
 	closedChan   chan struct{}
@@ -62,6 +71,25 @@ type LivekitServer struct {
 
 func NewLivekitServer(conf *config.Config,
 	roomService livekit.RoomService,
+	idempotencyStore IdempotencyStore,
+	roomHistoryService *RoomHistoryService,
+	roomTemplateService *RoomTemplateService,
+	roomBatchService *RoomBatchService,
+	participantMoveService *ParticipantMoveService,
+	breakoutRoomService *BreakoutRoomService,
+	participantRemovalService *ParticipantRemovalService,
+	subscribedQualityService *SubscribedQualityService,
+	iceRestartService *ICERestartService,
+	trackRelayService *TrackRelayService,
+	roomMuteService *RoomMuteService,
+	roomLockService *RoomLockService,
+	participantListService *ParticipantListService,
+	participantStatsService *ParticipantStatsService,
+	roomEventsService *RoomEventsService,
+	webhookDeliveryService *WebhookDeliveryService,
+	tokenRevocationService *TokenRevocationService,
+	turnCredentialsService *TURNCredentialsService,
+	auditLog *AuditLog,
 	egressService *EgressService,
 	ingressService *IngressService,
 	ioService *IOInfoService,
@@ -69,9 +97,13 @@ func NewLivekitServer(conf *config.Config,
 	keyProvider auth.KeyProvider,
 	router routing.Router,
 	roomManager *RoomManager,
+	roomSnapshotter *RoomSnapshotter,
 	signalServer *SignalServer,
 	turnServer *turn.Server,
 	currentNode routing.LocalNode,
+	analytics telemetry.AnalyticsService,
+	reporter telemetry.StatsReporter,
+	sink telemetry.AnalyticsSink,
 ) (s *LivekitServer, err error) {
 	s = &LivekitServer{
 		config:       conf,
@@ -79,13 +111,20 @@ func NewLivekitServer(conf *config.Config,
 		rtcService:   rtcService,
 		router:       router,
 		roomManager:  roomManager,
+		snapshotter:  roomSnapshotter,
 		signalServer: signalServer,
 		// turn server starts automatically
 		turnServer:  turnServer,
+		keyProvider: keyProvider,
 		currentNode: currentNode,
+		analytics:   analytics,
+		reporter:    reporter,
+		sink:        sink,
 		closedChan:  make(chan struct{}),
 	}
 
+	roomService = NewIdempotentRoomService(roomService, idempotencyStore, conf.Store.IdempotencyWindow)
+
 	middlewares := []negroni.Handler{
 		// always first
 		negroni.NewRecovery(),
@@ -98,14 +137,39 @@ func NewLivekitServer(conf *config.Config,
 			// allow preflight to be cached for a day
 			MaxAge: 86400,
 		}),
+		// before the middlewares that limit or authorize clients by their address
+		NewClientIPMiddleware(conf.TrustedProxyNetworks()),
+	}
+	if conf.SignalRateLimit.Enabled() {
+		middlewares = append(middlewares, NewSignalRateLimitMiddleware(conf.SignalRateLimit))
+	}
+	if auditLog.Enabled() {
+		middlewares = append(middlewares, NewAuditMiddleware(auditLog))
+	}
+	if conf.AdminMTLS.Enabled() {
+		middlewares = append(middlewares, NewClientCertAuthMiddleware(conf.AdminMTLS, len(conf.Projects) != 0))
 	}
 	if keyProvider != nil {
-		middlewares = append(middlewares, NewAPIKeyAuthMiddleware(keyProvider))
+		authMiddleware := NewAPIKeyAuthMiddleware(
+			keyProvider,
+			conf.ProjectsByKey(),
+			conf.KeyScopes,
+			conf.KeyRotation.RetiredKeys,
+			NewOIDCVerifier(conf.OIDC),
+			conf.KeyAllowedNetworks(),
+			tokenRevocationService.store,
+		)
+		middlewares = append(middlewares, authMiddleware)
+		// refreshed tokens of signal connections are verified like the tokens of requests
+		rtcService.tokenAuth = authMiddleware
+		if conf.APIRateLimit.Enabled() {
+			middlewares = append(middlewares, NewAPIRateLimitMiddleware(conf.APIRateLimit))
+		}
 	}
 
 	twirpLoggingHook := TwirpLogger(logger.GetLogger().WithComponent(sutils.ComponentAPI))
 	twirpRequestStatusHook := TwirpRequestStatusReporter()
-	roomServer := livekit.NewRoomServiceServer(roomService, twirpLoggingHook)
+	roomServer := livekit.NewRoomServiceServer(roomService, twirpLoggingHook, twirp.WithServerInterceptors(ProjectScopeInterceptor()))
 	egressServer := livekit.NewEgressServer(egressService, twirp.WithServerHooks(
 		twirp.ChainHooks(
 			twirpLoggingHook,
@@ -113,6 +177,10 @@ func NewLivekitServer(conf *config.Config,
 		),
 	))
 	ingressServer := livekit.NewIngressServer(ingressService, twirpLoggingHook)
+	restGateway, err := NewRESTGateway(roomService)
+	if err != nil {
+		return
+	}
 
 	mux := http.NewServeMux()
 	if conf.Development {
@@ -121,7 +189,31 @@ func NewLivekitServer(conf *config.Config,
 		mux.HandleFunc("/debug/goroutine", s.debugGoroutines)
 		mux.HandleFunc("/debug/rooms", s.debugInfo)
 	}
-	mux.Handle(roomServer.PathPrefix(), roomServer)
+	mux.HandleFunc("/debug/stats", s.debugStats)
+	mux.Handle(roomServer.PathPrefix(), withRequestOptions(roomServer))
+	mux.Handle(restGatewayPrefix, restGateway)
+	mux.Handle("/room_history", roomHistoryService)
+	mux.Handle("/room_templates", roomTemplateService)
+	mux.Handle("/room_batch", withRequestHeaders(roomBatchService))
+	mux.Handle("/provision_rooms", withRequestHeaders(http.HandlerFunc(roomBatchService.ServeProvision)))
+	mux.Handle("/move_participant", participantMoveService)
+	mux.Handle("/breakout_rooms", withRequestHeaders(breakoutRoomService))
+	mux.HandleFunc("/breakout_rooms/move", breakoutRoomService.ServeMove)
+	mux.Handle("/remove_participants", participantRemovalService)
+	mux.Handle("/pin_subscribed_quality", subscribedQualityService)
+	mux.Handle("/restart_ice", iceRestartService)
+	mux.Handle("/relay_tracks", trackRelayService)
+	mux.Handle("/mute_room", roomMuteService)
+	mux.Handle("/lock_room", roomLockService)
+	mux.Handle("/participants", participantListService)
+	mux.Handle("/participant_stats", participantStatsService)
+	mux.Handle("/events", roomEventsService)
+	mux.Handle("/webhook_deliveries", webhookDeliveryService)
+	mux.HandleFunc("/webhook_deliveries/redeliver", webhookDeliveryService.ServeRedeliver)
+	mux.HandleFunc(WebhookKeysPath, webhookDeliveryService.ServeKeys)
+	mux.Handle("/revoke_tokens", tokenRevocationService)
+	mux.Handle("/turn_credentials", turnCredentialsService)
+	mux.Handle("/audit_log", auditLog)
 	mux.Handle(egressServer.PathPrefix(), egressServer)
 	mux.Handle(ingressServer.PathPrefix(), ingressServer)
 	mux.Handle("/rtc", rtcService)
@@ -131,10 +223,21 @@ func NewLivekitServer(conf *config.Config,
 	s.httpServer = &http.Server{
 		Handler: configureMiddlewares(mux, middlewares...),
 	}
+	if conf.AdminMTLS.Enabled() {
+		if s.httpServer.TLSConfig, err = newAdminTLSConfig(conf.AdminMTLS); err != nil {
+			return
+		}
+	}
 
 	if conf.PrometheusPort > 0 {
 		s.promServer = &http.Server{
-			Handler: promhttp.Handler(),
+			Handler: prometheus.Handler(),
+		}
+	}
+
+	if conf.GRPCPort > 0 {
+		if s.grpcServer, err = NewGRPCServer(roomService, egressService, ingressService, roomEventsService, keyProvider, conf.ProjectsByKey(), conf.KeyScopes, conf.KeyRotation.RetiredKeys, conf.KeyAllowedNetworks(), tokenRevocationService.store, auditLog); err != nil {
+			return
 		}
 	}
 
@@ -184,6 +287,10 @@ func (s *LivekitServer) Start() error {
 		return err
 	}
 
+	if err := s.snapshotter.Start(); err != nil {
+		return err
+	}
+
 	addresses := s.config.BindAddresses
 	if addresses == nil {
 		addresses = []string{""}
@@ -192,6 +299,7 @@ func (s *LivekitServer) Start() error {
 	// ensure we could listen
 	listeners := make([]net.Listener, 0)
 	promListeners := make([]net.Listener, 0)
+	grpcListeners := make([]net.Listener, 0)
 	for _, addr := range addresses {
 		ln, err := net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.Port))))
 		if err != nil {
@@ -206,6 +314,14 @@ func (s *LivekitServer) Start() error {
 			}
 			promListeners = append(promListeners, ln)
 		}
+
+		if s.grpcServer != nil {
+			ln, err = net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.GRPCPort))))
+			if err != nil {
+				return err
+			}
+			grpcListeners = append(grpcListeners, ln)
+		}
 	}
 
 	values := []interface{}{
@@ -230,6 +346,9 @@ func (s *LivekitServer) Start() error {
 	if s.config.PrometheusPort != 0 {
 		values = append(values, "portPrometheus", s.config.PrometheusPort)
 	}
+	if s.config.GRPCPort != 0 {
+		values = append(values, "portGrpc", s.config.GRPCPort)
+	}
 	if s.config.Region != "" {
 		values = append(values, "region", s.config.Region)
 	}
@@ -242,6 +361,15 @@ func (s *LivekitServer) Start() error {
 		go s.promServer.Serve(promLn)
 	}
 
+	for _, grpcLn := range grpcListeners {
+		ln := grpcLn
+		go func() {
+			if err := s.grpcServer.Serve(ln); err != nil {
+				logger.Errorw("could not serve gRPC", err)
+			}
+		}()
+	}
+
 	if err := s.signalServer.Start(); err != nil {
 		return err
 	}
@@ -250,6 +378,10 @@ func (s *LivekitServer) Start() error {
 	for _, ln := range listeners {
 		l := ln
 		httpGroup.Go(func() error {
+			if s.httpServer.TLSConfig != nil {
+				// the certificate is in the TLS config
+				return s.httpServer.ServeTLS(l, "", "")
+			}
 			return s.httpServer.Serve(l)
 		})
 	}
@@ -273,15 +405,37 @@ func (s *LivekitServer) Start() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	_ = s.httpServer.Shutdown(ctx)
+	if s.grpcServer != nil {
+		s.grpcServer.GracefulStop()
+	}
 
 	if s.turnServer != nil {
 		_ = s.turnServer.Close()
 	}
 
+	s.snapshotter.Stop()
 	s.roomManager.Stop()
 	s.signalServer.Stop()
 	s.ioService.Stop()
 
+	// after the rooms have ended, for their stats and events to be reported. batched analytics are sent to the sink
+	// before it is closed
+	if closer, ok := s.analytics.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Warnw("could not close analytics service", err)
+		}
+	}
+	if closer, ok := s.reporter.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Warnw("could not close stats reporter", err)
+		}
+	}
+	if closer, ok := s.sink.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Warnw("could not close analytics sink", err)
+		}
+	}
+
 	close(s.closedChan)
 	return nil
 }
@@ -313,17 +467,26 @@ func (s *LivekitServer) RoomManager() *RoomManager {
 	return s.roomManager
 }
 
+// RotateSecrets resolves the API keys of the config again, tokens are then verified and signed with the rotated keys.
+// the redis password, TURN server secrets and TLS files are only resolved at startup
+func (s *LivekitServer) RotateSecrets(secrets *ConfigSecrets) error {
+	provider, ok := s.keyProvider.(*RotatingKeyProvider)
+	if !ok {
+		return errors.New("key provider does not rotate secrets")
+	}
+	return secrets.RotateKeys(provider)
+}
+
 func (s *LivekitServer) debugGoroutines(w http.ResponseWriter, _ *http.Request) {
 	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
 }
 
 func (s *LivekitServer) debugInfo(w http.ResponseWriter, _ *http.Request) {
-	s.roomManager.lock.RLock()
-	info := make([]map[string]interface{}, 0, len(s.roomManager.rooms))
-	for _, room := range s.roomManager.rooms {
+	rooms := s.roomManager.Rooms()
+	info := make([]map[string]interface{}, 0, len(rooms))
+	for _, room := range rooms {
 		info = append(info, room.DebugInfo())
 	}
-	s.roomManager.lock.RUnlock()
 
 	b, err := json.Marshal(info)
 	if err != nil {
@@ -334,6 +497,61 @@ func (s *LivekitServer) debugInfo(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+type debugRoomStats struct {
+	Name             string `json:"name"`
+	Sid              string `json:"sid"`
+	CreatedAt        int64  `json:"created_at"`
+	Participants     int    `json:"participants"`
+	Publishers       int    `json:"publishers"`
+	TracksPublished  int    `json:"tracks_published"`
+	TracksSubscribed int    `json:"tracks_subscribed"`
+}
+
+type debugStats struct {
+	prometheus.StatsSnapshot
+	Rooms []debugRoomStats `json:"room_summaries"`
+}
+
+// debugStats serves the node counters and a summary of the rooms on this node,
+// it requires a token with the roomList grant
+func (s *LivekitServer) debugStats(w http.ResponseWriter, r *http.Request) {
+	if err := EnsureListPermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	hosted := s.roomManager.Rooms()
+	rooms := make([]debugRoomStats, 0, len(hosted))
+	for _, room := range hosted {
+		info := room.ToProto()
+		rs := debugRoomStats{
+			Name:      info.Name,
+			Sid:       info.Sid,
+			CreatedAt: info.CreationTime,
+		}
+		for _, p := range room.GetParticipants() {
+			rs.Participants++
+			if published := len(p.GetPublishedTracks()); published != 0 {
+				rs.Publishers++
+				rs.TracksPublished += published
+			}
+			rs.TracksSubscribed += len(p.GetSubscribedTracks())
+		}
+		rooms = append(rooms, rs)
+	}
+
+	b, err := json.Marshal(debugStats{
+		StatsSnapshot: prometheus.GetStatsSnapshot(),
+		Rooms:         rooms,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
 func (s *LivekitServer) defaultHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/" {
 		s.healthCheck(w, r)
@@ -367,6 +585,7 @@ func (s *LivekitServer) backgroundWorker() {
 			return
 		case <-roomTicker.C:
 			s.roomManager.CloseIdleRooms()
+			s.roomManager.CloseExpiredRooms()
 		}
 	}
 }
@@ -38,6 +38,22 @@ type FakeServiceStore struct {
 		result1 []*livekit.Room
 		result2 error
 	}
+	ListRoomsPageStub        func(context.Context, service.ListRoomsOptions) ([]*livekit.Room, string, error)
+	listRoomsPageMutex       sync.RWMutex
+	listRoomsPageArgsForCall []struct {
+		arg1 context.Context
+		arg2 service.ListRoomsOptions
+	}
+	listRoomsPageReturns struct {
+		result1 []*livekit.Room
+		result2 string
+		result3 error
+	}
+	listRoomsPageReturnsOnCall map[int]struct {
+		result1 []*livekit.Room
+		result2 string
+		result3 error
+	}
 	LoadParticipantStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error)
 	loadParticipantMutex       sync.RWMutex
 	loadParticipantArgsForCall []struct {
@@ -70,6 +86,64 @@ type FakeServiceStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomLabelsStub        func(context.Context, livekit.RoomName) (service.RoomLabels, error)
+	loadRoomLabelsMutex       sync.RWMutex
+	loadRoomLabelsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomLabelsReturns struct {
+		result1 service.RoomLabels
+		result2 error
+	}
+	loadRoomLabelsReturnsOnCall map[int]struct {
+		result1 service.RoomLabels
+		result2 error
+	}
+	LoadRoomVersionStub        func(context.Context, livekit.RoomName) (*livekit.Room, int64, error)
+	loadRoomVersionMutex       sync.RWMutex
+	loadRoomVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomVersionReturns struct {
+		result1 *livekit.Room
+		result2 int64
+		result3 error
+	}
+	loadRoomVersionReturnsOnCall map[int]struct {
+		result1 *livekit.Room
+		result2 int64
+		result3 error
+	}
+	StoreRoomLabelsStub        func(context.Context, livekit.RoomName, service.RoomLabels) error
+	storeRoomLabelsMutex       sync.RWMutex
+	storeRoomLabelsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 service.RoomLabels
+	}
+	storeRoomLabelsReturns struct {
+		result1 error
+	}
+	storeRoomLabelsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateRoomStub        func(context.Context, *livekit.Room, int64) (int64, error)
+	updateRoomMutex       sync.RWMutex
+	updateRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 int64
+	}
+	updateRoomReturns struct {
+		result1 int64
+		result2 error
+	}
+	updateRoomReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -209,6 +283,74 @@ func (fake *FakeServiceStore) ListRoomsReturnsOnCall(i int, result1 []*livekit.R
 	}{result1, result2}
 }
 
+func (fake *FakeServiceStore) ListRoomsPage(arg1 context.Context, arg2 service.ListRoomsOptions) ([]*livekit.Room, string, error) {
+	fake.listRoomsPageMutex.Lock()
+	ret, specificReturn := fake.listRoomsPageReturnsOnCall[len(fake.listRoomsPageArgsForCall)]
+	fake.listRoomsPageArgsForCall = append(fake.listRoomsPageArgsForCall, struct {
+		arg1 context.Context
+		arg2 service.ListRoomsOptions
+	}{arg1, arg2})
+	stub := fake.ListRoomsPageStub
+	fakeReturns := fake.listRoomsPageReturns
+	fake.recordInvocation("ListRoomsPage", []interface{}{arg1, arg2})
+	fake.listRoomsPageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeServiceStore) ListRoomsPageCallCount() int {
+	fake.listRoomsPageMutex.RLock()
+	defer fake.listRoomsPageMutex.RUnlock()
+	return len(fake.listRoomsPageArgsForCall)
+}
+
+func (fake *FakeServiceStore) ListRoomsPageCalls(stub func(context.Context, service.ListRoomsOptions) ([]*livekit.Room, string, error)) {
+	fake.listRoomsPageMutex.Lock()
+	defer fake.listRoomsPageMutex.Unlock()
+	fake.ListRoomsPageStub = stub
+}
+
+func (fake *FakeServiceStore) ListRoomsPageArgsForCall(i int) (context.Context, service.ListRoomsOptions) {
+	fake.listRoomsPageMutex.RLock()
+	defer fake.listRoomsPageMutex.RUnlock()
+	argsForCall := fake.listRoomsPageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) ListRoomsPageReturns(result1 []*livekit.Room, result2 string, result3 error) {
+	fake.listRoomsPageMutex.Lock()
+	defer fake.listRoomsPageMutex.Unlock()
+	fake.ListRoomsPageStub = nil
+	fake.listRoomsPageReturns = struct {
+		result1 []*livekit.Room
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeServiceStore) ListRoomsPageReturnsOnCall(i int, result1 []*livekit.Room, result2 string, result3 error) {
+	fake.listRoomsPageMutex.Lock()
+	defer fake.listRoomsPageMutex.Unlock()
+	fake.ListRoomsPageStub = nil
+	if fake.listRoomsPageReturnsOnCall == nil {
+		fake.listRoomsPageReturnsOnCall = make(map[int]struct {
+			result1 []*livekit.Room
+			result2 string
+			result3 error
+		})
+	}
+	fake.listRoomsPageReturnsOnCall[i] = struct {
+		result1 []*livekit.Room
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeServiceStore) LoadParticipant(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
 	fake.loadParticipantMutex.Lock()
 	ret, specificReturn := fake.loadParticipantReturnsOnCall[len(fake.loadParticipantArgsForCall)]
@@ -344,6 +486,268 @@ func (fake *FakeServiceStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room
 	}{result1, result2, result3}
 }
 
+func (fake *FakeServiceStore) LoadRoomLabels(arg1 context.Context, arg2 livekit.RoomName) (service.RoomLabels, error) {
+	fake.loadRoomLabelsMutex.Lock()
+	ret, specificReturn := fake.loadRoomLabelsReturnsOnCall[len(fake.loadRoomLabelsArgsForCall)]
+	fake.loadRoomLabelsArgsForCall = append(fake.loadRoomLabelsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomLabelsStub
+	fakeReturns := fake.loadRoomLabelsReturns
+	fake.recordInvocation("LoadRoomLabels", []interface{}{arg1, arg2})
+	fake.loadRoomLabelsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) LoadRoomLabelsCallCount() int {
+	fake.loadRoomLabelsMutex.RLock()
+	defer fake.loadRoomLabelsMutex.RUnlock()
+	return len(fake.loadRoomLabelsArgsForCall)
+}
+
+func (fake *FakeServiceStore) LoadRoomLabelsCalls(stub func(context.Context, livekit.RoomName) (service.RoomLabels, error)) {
+	fake.loadRoomLabelsMutex.Lock()
+	defer fake.loadRoomLabelsMutex.Unlock()
+	fake.LoadRoomLabelsStub = stub
+}
+
+func (fake *FakeServiceStore) LoadRoomLabelsArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomLabelsMutex.RLock()
+	defer fake.loadRoomLabelsMutex.RUnlock()
+	argsForCall := fake.loadRoomLabelsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) LoadRoomLabelsReturns(result1 service.RoomLabels, result2 error) {
+	fake.loadRoomLabelsMutex.Lock()
+	defer fake.loadRoomLabelsMutex.Unlock()
+	fake.LoadRoomLabelsStub = nil
+	fake.loadRoomLabelsReturns = struct {
+		result1 service.RoomLabels
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) LoadRoomLabelsReturnsOnCall(i int, result1 service.RoomLabels, result2 error) {
+	fake.loadRoomLabelsMutex.Lock()
+	defer fake.loadRoomLabelsMutex.Unlock()
+	fake.LoadRoomLabelsStub = nil
+	if fake.loadRoomLabelsReturnsOnCall == nil {
+		fake.loadRoomLabelsReturnsOnCall = make(map[int]struct {
+			result1 service.RoomLabels
+			result2 error
+		})
+	}
+	fake.loadRoomLabelsReturnsOnCall[i] = struct {
+		result1 service.RoomLabels
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) LoadRoomVersion(arg1 context.Context, arg2 livekit.RoomName) (*livekit.Room, int64, error) {
+	fake.loadRoomVersionMutex.Lock()
+	ret, specificReturn := fake.loadRoomVersionReturnsOnCall[len(fake.loadRoomVersionArgsForCall)]
+	fake.loadRoomVersionArgsForCall = append(fake.loadRoomVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomVersionStub
+	fakeReturns := fake.loadRoomVersionReturns
+	fake.recordInvocation("LoadRoomVersion", []interface{}{arg1, arg2})
+	fake.loadRoomVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeServiceStore) LoadRoomVersionCallCount() int {
+	fake.loadRoomVersionMutex.RLock()
+	defer fake.loadRoomVersionMutex.RUnlock()
+	return len(fake.loadRoomVersionArgsForCall)
+}
+
+func (fake *FakeServiceStore) LoadRoomVersionCalls(stub func(context.Context, livekit.RoomName) (*livekit.Room, int64, error)) {
+	fake.loadRoomVersionMutex.Lock()
+	defer fake.loadRoomVersionMutex.Unlock()
+	fake.LoadRoomVersionStub = stub
+}
+
+func (fake *FakeServiceStore) LoadRoomVersionArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomVersionMutex.RLock()
+	defer fake.loadRoomVersionMutex.RUnlock()
+	argsForCall := fake.loadRoomVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) LoadRoomVersionReturns(result1 *livekit.Room, result2 int64, result3 error) {
+	fake.loadRoomVersionMutex.Lock()
+	defer fake.loadRoomVersionMutex.Unlock()
+	fake.LoadRoomVersionStub = nil
+	fake.loadRoomVersionReturns = struct {
+		result1 *livekit.Room
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeServiceStore) LoadRoomVersionReturnsOnCall(i int, result1 *livekit.Room, result2 int64, result3 error) {
+	fake.loadRoomVersionMutex.Lock()
+	defer fake.loadRoomVersionMutex.Unlock()
+	fake.LoadRoomVersionStub = nil
+	if fake.loadRoomVersionReturnsOnCall == nil {
+		fake.loadRoomVersionReturnsOnCall = make(map[int]struct {
+			result1 *livekit.Room
+			result2 int64
+			result3 error
+		})
+	}
+	fake.loadRoomVersionReturnsOnCall[i] = struct {
+		result1 *livekit.Room
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeServiceStore) StoreRoomLabels(arg1 context.Context, arg2 livekit.RoomName, arg3 service.RoomLabels) error {
+	fake.storeRoomLabelsMutex.Lock()
+	ret, specificReturn := fake.storeRoomLabelsReturnsOnCall[len(fake.storeRoomLabelsArgsForCall)]
+	fake.storeRoomLabelsArgsForCall = append(fake.storeRoomLabelsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 service.RoomLabels
+	}{arg1, arg2, arg3})
+	stub := fake.StoreRoomLabelsStub
+	fakeReturns := fake.storeRoomLabelsReturns
+	fake.recordInvocation("StoreRoomLabels", []interface{}{arg1, arg2, arg3})
+	fake.storeRoomLabelsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeServiceStore) StoreRoomLabelsCallCount() int {
+	fake.storeRoomLabelsMutex.RLock()
+	defer fake.storeRoomLabelsMutex.RUnlock()
+	return len(fake.storeRoomLabelsArgsForCall)
+}
+
+func (fake *FakeServiceStore) StoreRoomLabelsCalls(stub func(context.Context, livekit.RoomName, service.RoomLabels) error) {
+	fake.storeRoomLabelsMutex.Lock()
+	defer fake.storeRoomLabelsMutex.Unlock()
+	fake.StoreRoomLabelsStub = stub
+}
+
+func (fake *FakeServiceStore) StoreRoomLabelsArgsForCall(i int) (context.Context, livekit.RoomName, service.RoomLabels) {
+	fake.storeRoomLabelsMutex.RLock()
+	defer fake.storeRoomLabelsMutex.RUnlock()
+	argsForCall := fake.storeRoomLabelsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeServiceStore) StoreRoomLabelsReturns(result1 error) {
+	fake.storeRoomLabelsMutex.Lock()
+	defer fake.storeRoomLabelsMutex.Unlock()
+	fake.StoreRoomLabelsStub = nil
+	fake.storeRoomLabelsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeServiceStore) StoreRoomLabelsReturnsOnCall(i int, result1 error) {
+	fake.storeRoomLabelsMutex.Lock()
+	defer fake.storeRoomLabelsMutex.Unlock()
+	fake.StoreRoomLabelsStub = nil
+	if fake.storeRoomLabelsReturnsOnCall == nil {
+		fake.storeRoomLabelsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomLabelsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeServiceStore) UpdateRoom(arg1 context.Context, arg2 *livekit.Room, arg3 int64) (int64, error) {
+	fake.updateRoomMutex.Lock()
+	ret, specificReturn := fake.updateRoomReturnsOnCall[len(fake.updateRoomArgsForCall)]
+	fake.updateRoomArgsForCall = append(fake.updateRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 int64
+	}{arg1, arg2, arg3})
+	stub := fake.UpdateRoomStub
+	fakeReturns := fake.updateRoomReturns
+	fake.recordInvocation("UpdateRoom", []interface{}{arg1, arg2, arg3})
+	fake.updateRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) UpdateRoomCallCount() int {
+	fake.updateRoomMutex.RLock()
+	defer fake.updateRoomMutex.RUnlock()
+	return len(fake.updateRoomArgsForCall)
+}
+
+func (fake *FakeServiceStore) UpdateRoomCalls(stub func(context.Context, *livekit.Room, int64) (int64, error)) {
+	fake.updateRoomMutex.Lock()
+	defer fake.updateRoomMutex.Unlock()
+	fake.UpdateRoomStub = stub
+}
+
+func (fake *FakeServiceStore) UpdateRoomArgsForCall(i int) (context.Context, *livekit.Room, int64) {
+	fake.updateRoomMutex.RLock()
+	defer fake.updateRoomMutex.RUnlock()
+	argsForCall := fake.updateRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeServiceStore) UpdateRoomReturns(result1 int64, result2 error) {
+	fake.updateRoomMutex.Lock()
+	defer fake.updateRoomMutex.Unlock()
+	fake.UpdateRoomStub = nil
+	fake.updateRoomReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) UpdateRoomReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.updateRoomMutex.Lock()
+	defer fake.updateRoomMutex.Unlock()
+	fake.UpdateRoomStub = nil
+	if fake.updateRoomReturnsOnCall == nil {
+		fake.updateRoomReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.updateRoomReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeServiceStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -351,10 +755,20 @@ func (fake *FakeServiceStore) Invocations() map[string][][]interface{} {
 	defer fake.listParticipantsMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
 	defer fake.listRoomsMutex.RUnlock()
+	fake.listRoomsPageMutex.RLock()
+	defer fake.listRoomsPageMutex.RUnlock()
 	fake.loadParticipantMutex.RLock()
 	defer fake.loadParticipantMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomLabelsMutex.RLock()
+	defer fake.loadRoomLabelsMutex.RUnlock()
+	fake.loadRoomVersionMutex.RLock()
+	defer fake.loadRoomVersionMutex.RUnlock()
+	fake.storeRoomLabelsMutex.RLock()
+	defer fake.storeRoomLabelsMutex.RUnlock()
+	fake.updateRoomMutex.RLock()
+	defer fake.updateRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,40 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+)
+
+type tokenMaxSessionDurationKey struct{}
+
+// maxSessionDuration returns the session limit claim of the token, 0 when it has none
+func (c *tokenClaims) maxSessionDuration() time.Duration {
+	if c.MaxSessionDuration <= 0 {
+		return 0
+	}
+	return time.Duration(c.MaxSessionDuration) * time.Second
+}
+
+func withTokenMaxSessionDuration(ctx context.Context, limit time.Duration) context.Context {
+	return context.WithValue(ctx, tokenMaxSessionDurationKey{}, limit)
+}
+
+// tokenMaxSessionDuration returns the session limit claim of the token of the request
+func tokenMaxSessionDuration(ctx context.Context) time.Duration {
+	limit, _ := ctx.Value(tokenMaxSessionDurationKey{}).(time.Duration)
+	return limit
+}
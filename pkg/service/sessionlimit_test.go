@@ -0,0 +1,28 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxSessionDurationOfToken(t *testing.T) {
+	require.Equal(t, 45*time.Minute, testTokenClaims(t, `{"sub":"alice","max_session_duration":2700}`).maxSessionDuration())
+	require.Zero(t, testTokenClaims(t, `{"sub":"alice"}`).maxSessionDuration())
+	require.Zero(t, testTokenClaims(t, `{"max_session_duration":-1}`).maxSessionDuration())
+}
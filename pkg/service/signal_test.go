@@ -31,7 +31,7 @@ import (
 )
 
 func init() {
-	prometheus.Init("node", livekit.NodeType_CONTROLLER, "test")
+	prometheus.Init("node", livekit.NodeType_CONTROLLER, "test", config.TelemetryConfig{})
 }
 
 func TestSignal(t *testing.T) {
@@ -0,0 +1,140 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	signalConnectionsLimit = "connections"
+	signalJoinsLimit       = "joins"
+
+	// buckets of addresses without requests for this long are dropped, they would be full again
+	signalRateLimitIdleTimeout = 10 * time.Minute
+)
+
+type signalBucketKey struct {
+	clientIP string
+	limit    string
+}
+
+// signal rate limiting middleware, it precedes the authentication middleware so that requests with invalid tokens
+// are limited too. it limits the requests to the signal endpoints of each client address, other requests are left
+// to the API rate limit
+type SignalRateLimitMiddleware struct {
+	config config.SignalRateLimitConfig
+	exempt []*net.IPNet
+
+	lock       sync.Mutex
+	buckets    map[signalBucketKey]*tokenBucket
+	lastPruned time.Time
+}
+
+func NewSignalRateLimitMiddleware(conf config.SignalRateLimitConfig) *SignalRateLimitMiddleware {
+	// exempt ranges are validated with the config
+	exempt, _ := config.ParseIPNetworks(conf.ExemptCIDRs)
+	return &SignalRateLimitMiddleware{
+		config:  conf,
+		exempt:  exempt,
+		buckets: make(map[signalBucketKey]*tokenBucket),
+	}
+}
+
+func (m *SignalRateLimitMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL == nil || !isPathOf(r.URL.Path, "/rtc") {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// forwarded headers are only honored from trusted proxies, for clients not to pick their bucket or an exempt address
+	clientIP := GetClientIP(r)
+	if m.isExempt(clientIP) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	now := time.Now()
+	limits := []string{signalConnectionsLimit}
+	if r.URL.Path == "/rtc" && !boolValue(r.FormValue("reconnect")) {
+		limits = append(limits, signalJoinsLimit)
+	}
+	for _, limit := range limits {
+		if retryAfter, ok := m.allow(clientIP, limit, now); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			logger.Infow("signal rate limit exceeded", "clientIP", clientIP, "limit", limit)
+			handleError(w, http.StatusTooManyRequests, ErrSignalRateLimited)
+			return
+		}
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+func (m *SignalRateLimitMiddleware) isExempt(clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, network := range m.exempt {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow takes a request from the bucket of the client address and limit, or returns how long until a request is
+// allowed
+func (m *SignalRateLimitMiddleware) allow(clientIP string, limit string, now time.Time) (time.Duration, bool) {
+	rateLimit := m.config.Connections
+	if limit == signalJoinsLimit {
+		rateLimit = m.config.Joins
+	}
+	if rateLimit.RequestsPerSec <= 0 {
+		return 0, true
+	}
+
+	m.lock.Lock()
+	if now.Sub(m.lastPruned) > signalRateLimitIdleTimeout {
+		for key, bucket := range m.buckets {
+			if now.Sub(bucket.updated) > signalRateLimitIdleTimeout {
+				delete(m.buckets, key)
+			}
+		}
+		m.lastPruned = now
+	}
+	key := signalBucketKey{clientIP: clientIP, limit: limit}
+	bucket := m.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{}
+		m.buckets[key] = bucket
+	}
+	retryAfter, ok := bucket.take(rateLimit, now)
+	m.lock.Unlock()
+
+	prometheus.RecordSignalRateLimit(limit, ok)
+	return retryAfter, ok
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestSignalRateLimitMiddleware(t *testing.T) {
+	m := NewSignalRateLimitMiddleware(config.SignalRateLimitConfig{
+		Connections: config.RateLimit{RequestsPerSec: 0.001, Burst: 3},
+		Joins:       config.RateLimit{RequestsPerSec: 0.001, Burst: 1},
+		ExemptCIDRs: []string{"10.0.0.0/8"},
+	})
+	serve := func(path string, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return w
+	}
+
+	// one join, and connections up to the burst
+	require.Equal(t, http.StatusOK, serve("/rtc?room=a", "203.0.113.1:4000").Code)
+	w := serve("/rtc?room=a", "203.0.113.1:4000")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, serve("/rtc?room=a&reconnect=1", "203.0.113.1:4000").Code)
+	require.Equal(t, http.StatusTooManyRequests, serve("/rtc/validate?room=a", "203.0.113.1:4000").Code)
+
+	// other addresses, exempt addresses and other endpoints are not limited
+	require.Equal(t, http.StatusOK, serve("/rtc?room=a", "198.51.100.1:4000").Code)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, serve("/rtc?room=a", "10.1.2.3:4000").Code)
+	}
+	require.Equal(t, http.StatusOK, serve("/twirp/livekit.RoomService/ListRooms", "203.0.113.1:4000").Code)
+
+	// forwarded headers of untrusted peers neither exempt them nor give them buckets of their own
+	spoofed := func(forwardedFor string) int {
+		r := httptest.NewRequest(http.MethodGet, "/rtc?room=a", nil)
+		r.RemoteAddr = "192.0.2.9:4000"
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		NewClientIPMiddleware(nil).ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, spoofed("10.1.2.3"))
+	require.Equal(t, http.StatusTooManyRequests, spoofed("10.1.2.4"))
+	require.Equal(t, http.StatusTooManyRequests, spoofed("198.51.100.7"))
+
+	// idle addresses are dropped
+	now := time.Now().Add(2 * signalRateLimitIdleTimeout)
+	_, ok := m.allow("192.0.2.1", signalConnectionsLimit, now)
+	require.True(t, ok)
+	require.Len(t, m.buckets, 1)
+}
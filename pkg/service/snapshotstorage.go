@@ -0,0 +1,219 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// SnapshotStorage keeps snapshot objects by name
+type SnapshotStorage interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	List(ctx context.Context) ([]string, error)
+}
+
+// NewSnapshotStorage selects the storage by the scheme of the snapshot url, the path is used as prefix of object names
+func NewSnapshotStorage(ctx context.Context, conf config.SnapshotConfig) (SnapshotStorage, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid snapshot url")
+	}
+
+	prefix := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		return newS3SnapshotStorage(ctx, conf, u.Host, prefix)
+	case "gs":
+		return newGCSSnapshotStorage(ctx, u.Host, prefix)
+	case "file":
+		return &fileSnapshotStorage{dir: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("no snapshot storage for scheme %q", u.Scheme)
+	}
+}
+
+type s3SnapshotStorage struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3SnapshotStorage(ctx context.Context, conf config.SnapshotConfig, bucket, prefix string) (*s3SnapshotStorage, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if conf.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(conf.Region))
+	}
+	awsConf, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load aws config")
+	}
+
+	client := s3.NewFromConfig(awsConf, func(o *s3.Options) {
+		if conf.Endpoint != "" {
+			// S3 compatible services, i.e. MinIO
+			o.EndpointResolver = s3.EndpointResolverFromURL(conf.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3SnapshotStorage{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *s3SnapshotStorage) Put(ctx context.Context, name string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + name),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s *s3SnapshotStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	res, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return io.ReadAll(res.Body)
+}
+
+func (s *s3SnapshotStorage) List(ctx context.Context) ([]string, error) {
+	var names []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			names = append(names, strings.TrimPrefix(aws.ToString(object.Key), s.prefix))
+		}
+	}
+	return names, nil
+}
+
+type gcsSnapshotStorage struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// credentials are taken from the Google Cloud environment of the node
+func newGCSSnapshotStorage(ctx context.Context, bucket, prefix string) (*gcsSnapshotStorage, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create gcs client")
+	}
+	return &gcsSnapshotStorage{
+		bucket: client.Bucket(bucket),
+		prefix: prefix,
+	}, nil
+}
+
+func (s *gcsSnapshotStorage) Put(ctx context.Context, name string, data []byte) error {
+	w := s.bucket.Object(s.prefix + name).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *gcsSnapshotStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	r, err := s.bucket.Object(s.prefix + name).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (s *gcsSnapshotStorage) List(ctx context.Context) ([]string, error) {
+	var names []string
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: s.prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		names = append(names, strings.TrimPrefix(attrs.Name, s.prefix))
+	}
+	return names, nil
+}
+
+// fileSnapshotStorage writes snapshots to a directory, i.e. a mounted network volume
+type fileSnapshotStorage struct {
+	dir string
+}
+
+func (s *fileSnapshotStorage) Put(_ context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	// readers never see a partially written snapshot
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, name))
+}
+
+func (s *fileSnapshotStorage) Get(_ context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, name))
+}
+
+func (s *fileSnapshotStorage) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// storeObserver reports latencies and errors of store operations, and logs slow operations
+type storeObserver struct {
+	name          string
+	slowThreshold time.Duration
+}
+
+func newStoreObserver(store ObjectStore, slowThreshold time.Duration) storeObserver {
+	var name string
+	switch unwrapStore(store).(type) {
+	case *LocalStore:
+		name = "memory"
+	case *RedisStore:
+		name = "redis"
+	case *PostgresStore:
+		name = "postgres"
+	case *EtcdStore:
+		name = "etcd"
+	case *DynamoDBStore:
+		name = "dynamodb"
+	case *MongoDBStore:
+		name = "mongodb"
+	default:
+		name = "custom"
+	}
+	return storeObserver{
+		name:          name,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (o storeObserver) observe(operation string, start time.Time, err error, keysAndValues ...interface{}) {
+	duration := time.Since(start)
+	prometheus.RecordStoreOperation(o.name, operation, duration, isStoreFailure(err))
+
+	if o.slowThreshold > 0 && duration >= o.slowThreshold {
+		keysAndValues = append(keysAndValues, "store", o.name, "operation", operation, "duration", duration)
+		logger.Warnw("slow store operation", err, keysAndValues...)
+	}
+}
+
+// isStoreFailure tells errors of the store apart from expected outcomes of operations
+func isStoreFailure(err error) bool {
+	switch err {
+	case nil, ErrRoomNotFound, ErrParticipantNotFound, ErrRoomVersionConflict, ErrRoomLockFailed, ErrRoomUnlockFailed:
+		return false
+	default:
+		return true
+	}
+}
+
+// instrumentedStore reports every operation of the store it wraps
+type instrumentedStore struct {
+	store    ObjectStore
+	observer storeObserver
+}
+
+func newInstrumentedStore(store ObjectStore, slowThreshold time.Duration) *instrumentedStore {
+	return &instrumentedStore{
+		store:    store,
+		observer: newStoreObserver(store, slowThreshold),
+	}
+}
+
+// wrappingStore is implemented by stores adding behavior to another store
+type wrappingStore interface {
+	unwrap() ObjectStore
+}
+
+// unwrapStore returns the innermost store, for stores implementing more than ObjectStore
+func unwrapStore(s ObjectStore) ObjectStore {
+	for {
+		store, ok := s.(wrappingStore)
+		if !ok {
+			return s
+		}
+		s = store.unwrap()
+	}
+}
+
+// findInstrumentedStore returns the instrumented store among the stores wrapped by s, if any
+func findInstrumentedStore(s ObjectStore) *instrumentedStore {
+	for {
+		switch store := s.(type) {
+		case *instrumentedStore:
+			return store
+		case wrappingStore:
+			s = store.unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *instrumentedStore) unwrap() ObjectStore {
+	return s.store
+}
+
+func (s *instrumentedStore) LockRoom(ctx context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	start := time.Now()
+	token, err := s.store.LockRoom(ctx, roomName, duration)
+	s.observer.observe("lock_room", start, err, "room", roomName)
+	return token, err
+}
+
+func (s *instrumentedStore) UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error {
+	start := time.Now()
+	err := s.store.UnlockRoom(ctx, roomName, uid)
+	s.observer.observe("unlock_room", start, err, "room", roomName)
+	return err
+}
+
+func (s *instrumentedStore) StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	start := time.Now()
+	err := s.store.StoreRoom(ctx, room, internal)
+	s.observer.observe("store_room", start, err, "room", room.Name)
+	return err
+}
+
+func (s *instrumentedStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	start := time.Now()
+	err := s.store.DeleteRoom(ctx, roomName)
+	s.observer.observe("delete_room", start, err, "room", roomName)
+	return err
+}
+
+func (s *instrumentedStore) DeleteRooms(ctx context.Context, roomNames []livekit.RoomName) error {
+	start := time.Now()
+	err := deleteRooms(ctx, s.store, roomNames)
+	s.observer.observe("delete_rooms", start, err, "rooms", roomNames)
+	return err
+}
+
+func (s *instrumentedStore) StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	start := time.Now()
+	err := s.store.StoreParticipant(ctx, roomName, participant)
+	s.observer.observe("store_participant", start, err, "room", roomName, "participant", participant.Identity)
+	return err
+}
+
+func (s *instrumentedStore) DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	start := time.Now()
+	err := s.store.DeleteParticipant(ctx, roomName, identity)
+	s.observer.observe("delete_participant", start, err, "room", roomName, "participant", identity)
+	return err
+}
+
+func (s *instrumentedStore) LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	start := time.Now()
+	room, internal, err := s.store.LoadRoom(ctx, roomName, includeInternal)
+	s.observer.observe("load_room", start, err, "room", roomName)
+	return room, internal, err
+}
+
+func (s *instrumentedStore) ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	start := time.Now()
+	rooms, err := s.store.ListRooms(ctx, roomNames)
+	s.observer.observe("list_rooms", start, err, "rooms", len(rooms))
+	return rooms, err
+}
+
+func (s *instrumentedStore) ListRoomsPage(ctx context.Context, opts ListRoomsOptions) ([]*livekit.Room, string, error) {
+	start := time.Now()
+	rooms, nextPageToken, err := s.store.ListRoomsPage(ctx, opts)
+	s.observer.observe("list_rooms_page", start, err, "rooms", len(rooms))
+	return rooms, nextPageToken, err
+}
+
+func (s *instrumentedStore) LoadRoomLabels(ctx context.Context, roomName livekit.RoomName) (RoomLabels, error) {
+	start := time.Now()
+	labels, err := s.store.LoadRoomLabels(ctx, roomName)
+	s.observer.observe("load_room_labels", start, err, "room", roomName)
+	return labels, err
+}
+
+func (s *instrumentedStore) StoreRoomLabels(ctx context.Context, roomName livekit.RoomName, labels RoomLabels) error {
+	start := time.Now()
+	err := s.store.StoreRoomLabels(ctx, roomName, labels)
+	s.observer.observe("store_room_labels", start, err, "room", roomName)
+	return err
+}
+
+func (s *instrumentedStore) LoadRoomVersion(ctx context.Context, roomName livekit.RoomName) (*livekit.Room, int64, error) {
+	start := time.Now()
+	room, version, err := s.store.LoadRoomVersion(ctx, roomName)
+	s.observer.observe("load_room_version", start, err, "room", roomName)
+	return room, version, err
+}
+
+func (s *instrumentedStore) UpdateRoom(ctx context.Context, room *livekit.Room, expectedVersion int64) (int64, error) {
+	start := time.Now()
+	version, err := s.store.UpdateRoom(ctx, room, expectedVersion)
+	s.observer.observe("update_room", start, err, "room", room.Name)
+	return version, err
+}
+
+func (s *instrumentedStore) LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	start := time.Now()
+	participant, err := s.store.LoadParticipant(ctx, roomName, identity)
+	s.observer.observe("load_participant", start, err, "room", roomName, "participant", identity)
+	return participant, err
+}
+
+func (s *instrumentedStore) ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	start := time.Now()
+	participants, err := s.store.ListParticipants(ctx, roomName)
+	s.observer.observe("list_participants", start, err, "room", roomName)
+	return participants, err
+}
+
+type instrumentedParticipantStore struct {
+	store    ParticipantStore
+	observer storeObserver
+}
+
+// instrumentParticipantStore reports operations of ps when s is instrumented
+func instrumentParticipantStore(s ObjectStore, ps ParticipantStore) ParticipantStore {
+	if store := findInstrumentedStore(s); store != nil {
+		return &instrumentedParticipantStore{store: ps, observer: store.observer}
+	}
+	return ps
+}
+
+func (s *instrumentedParticipantStore) StoreParticipantSession(ctx context.Context, session *ParticipantSession) error {
+	start := time.Now()
+	err := s.store.StoreParticipantSession(ctx, session)
+	s.observer.observe("store_participant_session", start, err, "room", session.RoomName, "participant", session.Identity)
+	return err
+}
+
+func (s *instrumentedParticipantStore) LoadParticipantSession(ctx context.Context, participantID livekit.ParticipantID) (*ParticipantSession, error) {
+	start := time.Now()
+	session, err := s.store.LoadParticipantSession(ctx, participantID)
+	s.observer.observe("load_participant_session", start, err, "participantID", participantID)
+	return session, err
+}
+
+func (s *instrumentedParticipantStore) ListParticipantSessions(ctx context.Context, roomName livekit.RoomName, active bool) ([]*ParticipantSession, error) {
+	start := time.Now()
+	sessions, err := s.store.ListParticipantSessions(ctx, roomName, active)
+	s.observer.observe("list_participant_sessions", start, err, "room", roomName)
+	return sessions, err
+}
+
+type instrumentedRoomHistoryStore struct {
+	store    RoomHistoryStore
+	observer storeObserver
+}
+
+// instrumentRoomHistoryStore reports operations of hs when s is instrumented
+func instrumentRoomHistoryStore(s ObjectStore, hs RoomHistoryStore) RoomHistoryStore {
+	if store := findInstrumentedStore(s); store != nil {
+		return &instrumentedRoomHistoryStore{store: hs, observer: store.observer}
+	}
+	return hs
+}
+
+func (s *instrumentedRoomHistoryStore) StoreRoomHistory(ctx context.Context, history *RoomHistory, retention time.Duration) error {
+	start := time.Now()
+	err := s.store.StoreRoomHistory(ctx, history, retention)
+	s.observer.observe("store_room_history", start, err, "room", history.Name)
+	return err
+}
+
+func (s *instrumentedRoomHistoryStore) ListRoomHistory(ctx context.Context, roomName livekit.RoomName, limit int) ([]*RoomHistory, error) {
+	start := time.Now()
+	history, err := s.store.ListRoomHistory(ctx, roomName, limit)
+	s.observer.observe("list_room_history", start, err, "room", roomName)
+	return history, err
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestInstrumentedStore(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocalStore()
+	s := newInstrumentedStore(local, time.Nanosecond)
+	require.Equal(t, "memory", s.observer.name)
+	require.Same(t, local, unwrapStore(s))
+
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_metrics", Name: "metrics"}, nil))
+	room, _, err := s.LoadRoom(ctx, "metrics", false)
+	require.NoError(t, err)
+	require.Equal(t, "RM_metrics", room.Sid)
+
+	_, _, err = s.LoadRoom(ctx, "unknown", false)
+	require.ErrorIs(t, err, ErrRoomNotFound)
+
+	conf := &config.Config{Store: config.StoreConfig{HistoryRetention: time.Hour}}
+	require.IsType(t, &instrumentedRoomHistoryStore{}, getRoomHistoryStore(conf, s))
+	require.Same(t, local, getRoomHistoryStore(conf, local))
+}
+
+func TestIsStoreFailure(t *testing.T) {
+	require.False(t, isStoreFailure(nil))
+	require.False(t, isStoreFailure(ErrRoomNotFound))
+	require.False(t, isStoreFailure(ErrRoomVersionConflict))
+	require.True(t, isStoreFailure(errors.New("connection refused")))
+}
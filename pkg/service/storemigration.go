@@ -0,0 +1,259 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// all records of room history are copied
+const migrationHistoryLimit = 1 << 20
+
+// StoreMigrationStats counts the records copied by MigrateStore
+type StoreMigrationStats struct {
+	Rooms               int
+	Participants        int
+	ParticipantSessions int
+	RoomHistory         int
+	Egress              int
+	Ingress             int
+}
+
+func (s *StoreMigrationStats) String() string {
+	return fmt.Sprintf("%d rooms, %d participants, %d participant sessions, %d room history records, %d egress, %d ingress",
+		s.Rooms, s.Participants, s.ParticipantSessions, s.RoomHistory, s.Egress, s.Ingress)
+}
+
+// MigrateStore copies rooms with their labels and participants, participant sessions, room history, egress and
+// ingress from one store to another, and verifies that the copies read back equal. Records the source store keeps
+// but the destination store does not support are an error, records the source store does not support are skipped.
+// Nodes should not write to the source store while it is migrated.
+func MigrateStore(ctx context.Context, conf *config.Config, from, to ObjectStore) (*StoreMigrationStats, error) {
+	stats := &StoreMigrationStats{}
+
+	rooms, err := from.ListRooms(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	roomNames := make([]livekit.RoomName, 0, len(rooms))
+	for _, r := range rooms {
+		roomName := livekit.RoomName(r.Name)
+		participants, err := migrateRoom(ctx, from, to, roomName)
+		if err == ErrRoomNotFound {
+			// ended while migrating
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("could not migrate room %s: %w", roomName, err)
+		}
+		roomNames = append(roomNames, roomName)
+		stats.Rooms++
+		stats.Participants += participants
+	}
+
+	if fromHistory := getRoomHistoryStore(conf, from); fromHistory != nil {
+		toHistory := getRoomHistoryStore(conf, to)
+		history, err := fromHistory.ListRoomHistory(ctx, "", migrationHistoryLimit)
+		if err != nil {
+			return nil, err
+		}
+		if len(history) != 0 && toHistory == nil {
+			return nil, fmt.Errorf("destination store does not keep room history")
+		}
+		// oldest first, keeping the order of listing
+		for i := len(history) - 1; i >= 0; i-- {
+			if err = toHistory.StoreRoomHistory(ctx, history[i], conf.Store.HistoryRetention); err != nil {
+				return nil, err
+			}
+			roomNames = append(roomNames, livekit.RoomName(history[i].Name))
+		}
+		stats.RoomHistory = len(history)
+	}
+
+	if fromParticipants := getParticipantStore(from); fromParticipants != nil {
+		n, err := migrateParticipantSessions(ctx, fromParticipants, getParticipantStore(to), roomNames)
+		if err != nil {
+			return nil, err
+		}
+		stats.ParticipantSessions = n
+	}
+
+	if fromEgress := getEgressStore(from); fromEgress != nil {
+		n, err := migrateEgress(ctx, fromEgress, getEgressStore(to))
+		if err != nil {
+			return nil, err
+		}
+		stats.Egress = n
+	}
+
+	if fromIngress := getIngressStore(from); fromIngress != nil {
+		n, err := migrateIngress(ctx, fromIngress, getIngressStore(to))
+		if err != nil {
+			return nil, err
+		}
+		stats.Ingress = n
+	}
+
+	return stats, nil
+}
+
+func migrateRoom(ctx context.Context, from, to ObjectStore, roomName livekit.RoomName) (int, error) {
+	room, internal, err := from.LoadRoom(ctx, roomName, true)
+	if err != nil {
+		return 0, err
+	}
+	labels, err := from.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		return 0, err
+	}
+	participants, err := from.ListParticipants(ctx, roomName)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = to.StoreRoom(ctx, room, internal); err != nil {
+		return 0, err
+	}
+	if len(labels) != 0 {
+		if err = to.StoreRoomLabels(ctx, roomName, labels); err != nil {
+			return 0, err
+		}
+	}
+	for _, pi := range participants {
+		if err = to.StoreParticipant(ctx, roomName, pi); err != nil {
+			return 0, err
+		}
+	}
+
+	// verify
+	copiedRoom, copiedInternal, err := to.LoadRoom(ctx, roomName, true)
+	if err != nil {
+		return 0, err
+	}
+	if !proto.Equal(room, copiedRoom) || (internal != nil && !proto.Equal(internal, copiedInternal)) {
+		return 0, fmt.Errorf("room differs after migration")
+	}
+	copiedLabels, err := to.LoadRoomLabels(ctx, roomName)
+	if err != nil {
+		return 0, err
+	}
+	if len(labels) != 0 && !reflect.DeepEqual(labels, copiedLabels) {
+		return 0, fmt.Errorf("room labels differ after migration")
+	}
+	for _, pi := range participants {
+		copied, err := to.LoadParticipant(ctx, roomName, livekit.ParticipantIdentity(pi.Identity))
+		if err != nil {
+			return 0, err
+		}
+		if !proto.Equal(pi, copied) {
+			return 0, fmt.Errorf("participant %s differs after migration", pi.Identity)
+		}
+	}
+	return len(participants), nil
+}
+
+// sessions are listed by room, sessions of rooms neither active nor in room history are not found
+func migrateParticipantSessions(ctx context.Context, from, to ParticipantStore, roomNames []livekit.RoomName) (int, error) {
+	migrated := 0
+	seen := make(map[livekit.RoomName]bool, len(roomNames))
+	for _, roomName := range roomNames {
+		if seen[roomName] {
+			continue
+		}
+		seen[roomName] = true
+
+		sessions, err := from.ListParticipantSessions(ctx, roomName, false)
+		if err != nil {
+			return 0, err
+		}
+		if len(sessions) != 0 && to == nil {
+			return 0, fmt.Errorf("destination store does not keep participant sessions")
+		}
+		for _, session := range sessions {
+			if err = to.StoreParticipantSession(ctx, session); err != nil {
+				return 0, err
+			}
+			copied, err := to.LoadParticipantSession(ctx, session.ParticipantID)
+			if err != nil {
+				return 0, err
+			}
+			if !session.JoinedAt.Equal(copied.JoinedAt) || !session.LeftAt.Equal(copied.LeftAt) ||
+				session.RoomName != copied.RoomName || session.Identity != copied.Identity {
+				return 0, fmt.Errorf("participant session %s differs after migration", session.ParticipantID)
+			}
+		}
+		migrated += len(sessions)
+	}
+	return migrated, nil
+}
+
+func migrateEgress(ctx context.Context, from, to EgressStore) (int, error) {
+	infos, err := from.ListEgress(ctx, "", false)
+	if err != nil {
+		return 0, err
+	}
+	if len(infos) != 0 && to == nil {
+		return 0, fmt.Errorf("destination store does not keep egress")
+	}
+	for _, info := range infos {
+		if err = to.StoreEgress(ctx, info); err != nil {
+			return 0, err
+		}
+		copied, err := to.LoadEgress(ctx, info.EgressId)
+		if err != nil {
+			return 0, err
+		}
+		if !proto.Equal(info, copied) {
+			return 0, fmt.Errorf("egress %s differs after migration", info.EgressId)
+		}
+	}
+	return len(infos), nil
+}
+
+func migrateIngress(ctx context.Context, from, to IngressStore) (int, error) {
+	infos, err := from.ListIngress(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	if len(infos) != 0 && to == nil {
+		return 0, fmt.Errorf("destination store does not keep ingress")
+	}
+	for _, info := range infos {
+		// state is stored apart from the ingress
+		if err = to.StoreIngress(ctx, info); err != nil {
+			return 0, err
+		}
+		if info.State != nil {
+			if err = to.UpdateIngressState(ctx, info.IngressId, info.State); err != nil {
+				return 0, err
+			}
+		}
+		copied, err := to.LoadIngress(ctx, info.IngressId)
+		if err != nil {
+			return 0, err
+		}
+		if !proto.Equal(info, copied) {
+			return 0, fmt.Errorf("ingress %s differs after migration", info.IngressId)
+		}
+	}
+	return len(infos), nil
+}
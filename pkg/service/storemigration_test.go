@@ -0,0 +1,64 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestMigrateStore(t *testing.T) {
+	ctx := context.Background()
+	conf := config.DefaultConfig
+
+	from := service.NewLocalStore()
+	require.NoError(t, from.StoreRoom(ctx, &livekit.Room{Sid: "RM_a", Name: "a", Metadata: "meta"}, &livekit.RoomInternal{}))
+	require.NoError(t, from.StoreRoomLabels(ctx, "a", service.RoomLabels{"product": "meet"}))
+	require.NoError(t, from.StoreParticipant(ctx, "a", &livekit.ParticipantInfo{Sid: "PA_1", Identity: "one"}))
+	require.NoError(t, from.StoreParticipant(ctx, "a", &livekit.ParticipantInfo{Sid: "PA_2", Identity: "two"}))
+	require.NoError(t, from.StoreRoom(ctx, &livekit.Room{Sid: "RM_b", Name: "b"}, nil))
+	require.NoError(t, from.StoreRoomHistory(ctx, &service.RoomHistory{
+		Name:      "ended",
+		Sid:       "RM_ended",
+		StartedAt: time.Now().Add(-time.Hour),
+		EndedAt:   time.Now(),
+	}, time.Hour))
+
+	to := service.NewLocalStore()
+	stats, err := service.MigrateStore(ctx, &conf, from, to)
+	require.NoError(t, err)
+	require.Equal(t, &service.StoreMigrationStats{Rooms: 2, Participants: 2, RoomHistory: 1}, stats)
+
+	room, _, err := to.LoadRoom(ctx, "a", false)
+	require.NoError(t, err)
+	require.Equal(t, "meta", room.Metadata)
+	labels, err := to.LoadRoomLabels(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, service.RoomLabels{"product": "meet"}, labels)
+	participants, err := to.ListParticipants(ctx, "a")
+	require.NoError(t, err)
+	require.Len(t, participants, 2)
+	history, err := to.ListRoomHistory(ctx, "ended", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+}
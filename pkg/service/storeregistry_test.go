@@ -0,0 +1,55 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestNewStoreFromURL(t *testing.T) {
+	conf := &config.Config{}
+
+	store, err := service.NewStoreFromURL("memory://", conf, nil)
+	require.NoError(t, err)
+	require.IsType(t, &service.LocalStore{}, store)
+
+	_, err = service.NewStoreFromURL("unknown://host", conf, nil)
+	require.Error(t, err)
+
+	// redis without a host needs the redis config
+	_, err = service.NewStoreFromURL("redis://", conf, nil)
+	require.Error(t, err)
+
+	// sentinel urls name the master
+	_, err = service.NewStoreFromURL("redis+sentinel://sentinel-0:26379,sentinel-1:26379", conf, nil)
+	require.Error(t, err)
+	store, err = service.NewStoreFromURL("redis+sentinel://sentinel-0:26379,sentinel-1:26379/mymaster/1", conf, nil)
+	require.NoError(t, err)
+	require.IsType(t, &service.RedisStore{}, store)
+
+	custom := service.NewLocalStore()
+	service.RegisterStore("Custom", func(params service.StoreParams) (service.ObjectStore, error) {
+		require.Equal(t, "bucket", params.URL.Host)
+		return custom, nil
+	})
+	store, err = service.NewStoreFromURL("custom://bucket", conf, nil)
+	require.NoError(t, err)
+	require.Same(t, custom, store)
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	// participants present the fingerprint their token is bound to with this query parameter of the signal connection
+	tokenFingerprintParam = "fingerprint"
+)
+
+type tokenBindingKey struct{}
+
+// TokenBinding binds a token to the networks or the device of the client, so that a leaked token cannot join from
+// elsewhere. the fingerprint is kept as the base64url SHA-256 hash of the fingerprint the client presents, so that it
+// cannot be read from the token
+type TokenBinding struct {
+	CIDRs       []string `json:"cidrs,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+}
+
+// HashTokenFingerprint returns the hash of a fingerprint, as set in the binding claim
+func HashTokenFingerprint(fingerprint string) string {
+	sum := sha256.Sum256([]byte(fingerprint))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// tokenBinding returns the binding claim of the token, nil when it has none
+func (c *tokenClaims) tokenBinding() *TokenBinding {
+	if c.Binding == nil || (len(c.Binding.CIDRs) == 0 && c.Binding.Fingerprint == "") {
+		return nil
+	}
+	return c.Binding
+}
+
+func withTokenBinding(ctx context.Context, binding *TokenBinding) context.Context {
+	return context.WithValue(ctx, tokenBindingKey{}, binding)
+}
+
+// tokenBinding returns the binding claim of the token of the request
+func tokenBinding(ctx context.Context) *TokenBinding {
+	binding, _ := ctx.Value(tokenBindingKey{}).(*TokenBinding)
+	return binding
+}
+
+// Check returns ErrTokenBindingMismatch unless the client address is in one of the networks and the client presents
+// the fingerprint, of the bindings that are set. the client address is as resolved from trusted proxies, forwarded
+// headers of others would let a leaked token claim the bound address. networks that cannot be parsed match no address
+func (b *TokenBinding) Check(clientIP string, fingerprint string) error {
+	if b == nil {
+		return nil
+	}
+	if len(b.CIDRs) != 0 {
+		ip := net.ParseIP(clientIP)
+		if ip == nil || !b.containsIP(ip) {
+			return fmt.Errorf("%w: client address is not allowed", ErrTokenBindingMismatch)
+		}
+	}
+	if b.Fingerprint != "" {
+		if fingerprint == "" || subtle.ConstantTimeCompare([]byte(HashTokenFingerprint(fingerprint)), []byte(b.Fingerprint)) != 1 {
+			return fmt.Errorf("%w: fingerprint does not match", ErrTokenBindingMismatch)
+		}
+	}
+	return nil
+}
+
+func (b *TokenBinding) containsIP(ip net.IP) bool {
+	for _, cidr := range b.CIDRs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(ip) {
+			return true
+		} else if exact := net.ParseIP(cidr); exact != nil && exact.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureTokenBinding rejects signal connections with a token bound to other networks or another device. reconnects
+// are checked too, the token of a reconnect is as exposed as the token of a join
+func (s *RTCService) ensureTokenBinding(ctx context.Context, clientIP string, fingerprint string) error {
+	if err := tokenBinding(ctx).Check(clientIP, fingerprint); err != nil {
+		claims := GetGrants(ctx)
+		logger.Infow("signal connection rejected by token binding", "participant", claims.Identity, "clientIP", clientIP, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBinding(t *testing.T) {
+	fingerprint := HashTokenFingerprint("device-1")
+
+	binding := testTokenClaims(t, `{"sub":"alice","binding":{"cidrs":["203.0.113.0/24","2001:db8::1"],"fingerprint":"`+fingerprint+`"}}`).tokenBinding()
+	require.Equal(t, &TokenBinding{CIDRs: []string{"203.0.113.0/24", "2001:db8::1"}, Fingerprint: fingerprint}, binding)
+	require.Nil(t, testTokenClaims(t, `{"sub":"alice"}`).tokenBinding())
+	require.Nil(t, testTokenClaims(t, `{"sub":"alice","binding":{}}`).tokenBinding())
+
+	t.Run("networks and fingerprint", func(t *testing.T) {
+		require.NoError(t, binding.Check("203.0.113.7", "device-1"))
+		require.NoError(t, binding.Check("2001:db8::1", "device-1"))
+		require.ErrorIs(t, binding.Check("198.51.100.1", "device-1"), ErrTokenBindingMismatch)
+		require.ErrorIs(t, binding.Check("203.0.113.7", "device-2"), ErrTokenBindingMismatch)
+		require.ErrorIs(t, binding.Check("203.0.113.7", ""), ErrTokenBindingMismatch)
+		require.ErrorIs(t, binding.Check("", "device-1"), ErrTokenBindingMismatch)
+	})
+
+	t.Run("forwarded addresses", func(t *testing.T) {
+		_, proxy, err := net.ParseCIDR("10.0.0.0/8")
+		require.NoError(t, err)
+		clientIP := func(remoteAddr string, forwardedFor string) string {
+			r := httptest.NewRequest(http.MethodGet, "/rtc", nil)
+			r.RemoteAddr = remoteAddr
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+			var ip string
+			NewClientIPMiddleware([]*net.IPNet{proxy}).ServeHTTP(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+				ip = GetClientIP(r)
+			})
+			return ip
+		}
+		require.NoError(t, binding.Check(clientIP("10.0.0.1:4000", "203.0.113.7"), "device-1"))
+		// a leaked token replayed with the bound address in a header of its own
+		require.ErrorIs(t, binding.Check(clientIP("198.51.100.1:4000", "203.0.113.7"), "device-1"), ErrTokenBindingMismatch)
+		require.ErrorIs(t, binding.Check(clientIP("10.0.0.1:4000", "203.0.113.7, 198.51.100.1"), "device-1"), ErrTokenBindingMismatch)
+	})
+
+	t.Run("partial bindings", func(t *testing.T) {
+		require.NoError(t, (&TokenBinding{Fingerprint: fingerprint}).Check("198.51.100.1", "device-1"))
+		require.NoError(t, (&TokenBinding{CIDRs: []string{"203.0.113.0/24"}}).Check("203.0.113.7", ""))
+		// networks that cannot be parsed match no address
+		require.ErrorIs(t, (&TokenBinding{CIDRs: []string{"203.0.113"}}).Check("203.0.113.7", ""), ErrTokenBindingMismatch)
+
+		var unbound *TokenBinding
+		require.NoError(t, unbound.Check("198.51.100.1", ""))
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+type tokenClaimsKey struct{}
+
+// tokenClaims are the JWT claims of a token that are not part of its grants. access tokens have nbf set to the
+// time they are issued, tokens of other issuers may set jti and iat
+type tokenClaims struct {
+	Issuer    string `json:"iss,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	Expiry    int64  `json:"exp,omitempty"`
+
+	// the initial attributes of the participant, alongside the grants
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// the passcode of the room the participant joins
+	RoomPasscode string `json:"room_passcode,omitempty"`
+	// the networks or the device the token is bound to
+	Binding *TokenBinding `json:"binding,omitempty"`
+	// limits the session of the participant, in seconds. the participant is warned before it is disconnected,
+	// rejoins with the token start a new session, so the expiry of the token bounds them
+	MaxSessionDuration int64 `json:"max_session_duration,omitempty"`
+	// limits video published by the participant, {"width": 1280, "height": 720}, in either orientation. tracks the
+	// sources of the video grant of the token do not allow are rejected regardless, the claim further limits those
+	// it allows
+	MaxPublishResolution *routing.VideoResolution `json:"max_publish_resolution,omitempty"`
+}
+
+// parseTokenClaims returns the claims of a verified token, the token is decoded once for all of its claims. ok is
+// false when the claims cannot be decoded, including claims of the wrong type
+func parseTokenClaims(authToken string) (*tokenClaims, bool) {
+	parts := strings.Split(authToken, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	claims := &tokenClaims{}
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// issuedAt returns when the token was issued, ok is false for tokens without iat or nbf
+func (c *tokenClaims) issuedAt() (time.Time, bool) {
+	switch {
+	case c.IssuedAt != 0:
+		return time.Unix(c.IssuedAt, 0), true
+	case c.NotBefore != 0:
+		return time.Unix(c.NotBefore, 0), true
+	}
+	return time.Time{}, false
+}
+
+// expiresAt returns when the token expires, ok is false for tokens without exp
+func (c *tokenClaims) expiresAt() (time.Time, bool) {
+	if c.Expiry == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(c.Expiry, 0), true
+}
+
+func withTokenClaims(ctx context.Context, claims *tokenClaims) context.Context {
+	return context.WithValue(ctx, tokenClaimsKey{}, claims)
+}
+
+// getTokenClaims returns the registered claims of the token of the request, nil for requests without a token
+func getTokenClaims(ctx context.Context) *tokenClaims {
+	claims, _ := ctx.Value(tokenClaimsKey{}).(*tokenClaims)
+	return claims
+}
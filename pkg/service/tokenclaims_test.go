@@ -0,0 +1,58 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenClaims returns the claims of a token with the payload
+func testTokenClaims(t *testing.T, payload string) *tokenClaims {
+	claims, ok := parseTokenClaims("header." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature")
+	require.True(t, ok)
+	return claims
+}
+
+func TestParseTokenClaims(t *testing.T) {
+	claims := testTokenClaims(t, `{"iss":"APIabc","sub":"alice","jti":"token1","nbf":1700000000,"exp":1700003600}`)
+	require.Equal(t, "APIabc", claims.Issuer)
+	require.Equal(t, "token1", claims.ID)
+	issuedAt, ok := claims.issuedAt()
+	require.True(t, ok)
+	require.Equal(t, time.Unix(1700000000, 0), issuedAt)
+	expiresAt, ok := claims.expiresAt()
+	require.True(t, ok)
+	require.Equal(t, time.Unix(1700003600, 0), expiresAt)
+
+	token := func(payload string) string {
+		return "header." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+	}
+	// claims of the wrong type reject the token rather than being ignored
+	for _, payload := range []string{
+		`{"attributes":"speaker"}`,
+		`{"binding":["203.0.113.0/24"]}`,
+		`{"max_session_duration":"45m"}`,
+		`{"max_publish_resolution":"720p"}`,
+	} {
+		_, ok = parseTokenClaims(token(payload))
+		require.False(t, ok, payload)
+	}
+	_, ok = parseTokenClaims("not-a-token")
+	require.False(t, ok)
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	// clients that present refreshed tokens over the signal connection connect with this query parameter set
+	tokenRefreshParam = "token_refresh"
+
+	defaultTokenExpiryGrace  = 30 * time.Second
+	tokenExpiryCheckInterval = time.Second
+)
+
+var (
+	ErrTokenRefreshNotEnabled = errors.New("token refresh is not enabled")
+	ErrTokenRefreshMismatch   = errors.New("refreshed token is not for the participant")
+)
+
+// signal connections of clients that connect with the token_refresh parameter carry token refreshes as JSON text
+// messages, next to the signal requests and responses. the fields are named like the JSON of signal messages.
+// connections of other clients never have their text messages taken for token refreshes
+type tokenRefreshMessage struct {
+	TokenRefresh *tokenRefresh `json:"tokenRefresh,omitempty"`
+}
+
+type tokenRefresh struct {
+	// refreshed token, set by clients
+	Token string `json:"token,omitempty"`
+	// time the session expires in unix seconds, set by the server when the token is accepted. 0 when the token
+	// does not expire
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+	// set by the server when the token is rejected
+	Error string `json:"error,omitempty"`
+}
+
+// parseTokenRefresh returns the token of a token refresh message, ok is false for other messages
+func parseTokenRefresh(payload []byte) (string, bool) {
+	if !bytes.Contains(payload, []byte(`"tokenRefresh"`)) {
+		return "", false
+	}
+	msg := &tokenRefreshMessage{}
+	if err := json.Unmarshal(payload, msg); err != nil || msg.TokenRefresh == nil {
+		return "", false
+	}
+	return msg.TokenRefresh.Token, true
+}
+
+// OnTokenRefresh sets the callback of token refresh messages, they are not returned by ReadRequest
+func (c *WSSignalConnection) OnTokenRefresh(callback func(token string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onTokenRefresh = callback
+}
+
+func (c *WSSignalConnection) handlesTokenRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.onTokenRefresh != nil
+}
+
+func (c *WSSignalConnection) handleTokenRefresh(token string) {
+	c.mu.Lock()
+	callback := c.onTokenRefresh
+	c.mu.Unlock()
+	if callback != nil {
+		callback(token)
+	}
+}
+
+// writeTokenRefresh answers a token refresh, always as JSON
+func (c *WSSignalConnection) writeTokenRefresh(res *tokenRefresh) error {
+	payload, err := json.Marshal(&tokenRefreshMessage{TokenRefresh: res})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// tokenSession is the token state of a signal connection, the session lasts until the latest token of the
+// participant expires
+type tokenSession struct {
+	identity string
+	roomName livekit.RoomName
+	project  string
+
+	lock      sync.Mutex
+	expiresAt time.Time
+}
+
+func newTokenSession(r *http.Request, roomName livekit.RoomName) *tokenSession {
+	session := &tokenSession{
+		roomName: roomName,
+	}
+	if grants := GetGrants(r.Context()); grants != nil {
+		session.identity = grants.Identity
+		// publish only connections join with an identity of their own, refreshed tokens are of the participant
+		if publishParam := r.FormValue("publish"); publishParam != "" {
+			session.identity = strings.TrimSuffix(session.identity, "#"+publishParam)
+		}
+	}
+	session.project, _ = GetProject(r.Context())
+	if claims := getTokenClaims(r.Context()); claims != nil {
+		session.expiresAt, _ = claims.expiresAt()
+	}
+	return session
+}
+
+func (t *tokenSession) ExpiresAt() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.expiresAt
+}
+
+// expired returns whether the token expired longer than the grace ago, sessions with tokens that do not expire
+// never do
+func (t *tokenSession) expired(grace time.Duration) bool {
+	expiresAt := t.ExpiresAt()
+	return !expiresAt.IsZero() && time.Since(expiresAt) > grace
+}
+
+// refreshSessionToken verifies a refreshed token of the participant of the session, and extends the session until
+// the token expires
+func (s *RTCService) refreshSessionToken(ctx context.Context, session *tokenSession, authToken string) (time.Time, error) {
+	if s.tokenAuth == nil {
+		return time.Time{}, ErrTokenRefreshNotEnabled
+	}
+	ctx, err := s.tokenAuth.authenticate(ctx, authToken)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	onlyName, err := EnsureJoinPermission(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	grants := GetGrants(ctx)
+	project, _ := GetProject(ctx)
+	if grants.Identity != session.identity || project != session.project ||
+		(onlyName != "" && onlyName != session.roomName) {
+		return time.Time{}, ErrTokenRefreshMismatch
+	}
+
+	var expiresAt time.Time
+	if claims := getTokenClaims(ctx); claims != nil {
+		expiresAt, _ = claims.expiresAt()
+	}
+	session.lock.Lock()
+	session.expiresAt = expiresAt
+	session.lock.Unlock()
+	return expiresAt, nil
+}
+
+// handleTokenRefresh answers a token refresh message of the signal connection
+func (s *RTCService) handleTokenRefresh(ctx context.Context, sigConn *WSSignalConnection, session *tokenSession, authToken string, pLogger logger.Logger) {
+	res := &tokenRefresh{}
+	expiresAt, err := s.refreshSessionToken(ctx, session, authToken)
+	if err != nil {
+		pLogger.Infow("rejected refreshed token", "error", err)
+		res.Error = err.Error()
+	} else {
+		pLogger.Debugw("refreshed token", "expiresAt", expiresAt)
+		if !expiresAt.IsZero() {
+			res.ExpiresAt = expiresAt.Unix()
+		}
+	}
+	if err = sigConn.writeTokenRefresh(res); err != nil {
+		pLogger.Warnw("could not write token refresh response", err)
+	}
+}
+
+// enforceTokenExpiry removes the participant from the room once its token has expired longer than the grace ago
+// without being refreshed, it returns when the signal connection is done
+func (s *RTCService) enforceTokenExpiry(session *tokenSession, identity livekit.ParticipantIdentity, done <-chan struct{}, pLogger logger.Logger) {
+	grace := s.config.TokenExpiry.Grace
+	if grace == 0 {
+		grace = defaultTokenExpiryGrace
+	}
+
+	ticker := time.NewTicker(tokenExpiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if !session.expired(grace) {
+				continue
+			}
+			pLogger.Infow("removing participant with expired token", "expiresAt", session.ExpiresAt())
+			err := s.router.WriteParticipantRTC(context.Background(), session.roomName, identity, &livekit.RTCNodeMessage{
+				Message: &livekit.RTCNodeMessage_RemoveParticipant{
+					RemoveParticipant: &livekit.RoomParticipantIdentity{
+						Room:     string(session.roomName),
+						Identity: string(identity),
+					},
+				},
+			})
+			if err != nil {
+				// tried again on the next check
+				pLogger.Warnw("could not remove participant with expired token", err)
+				continue
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestParseTokenRefresh(t *testing.T) {
+	token, ok := parseTokenRefresh([]byte(`{"tokenRefresh": {"token": "abc"}}`))
+	require.True(t, ok)
+	require.Equal(t, "abc", token)
+
+	_, ok = parseTokenRefresh([]byte(`{"ping": "1"}`))
+	require.False(t, ok)
+	_, ok = parseTokenRefresh([]byte(`{"tokenRefresh": `))
+	require.False(t, ok)
+}
+
+func TestSignalConnectionTokenRefresh(t *testing.T) {
+	refresh := []byte(`{"tokenRefresh": {"token": "abc"}}`)
+	leave := []byte(`{"leave": {}}`)
+
+	t.Run("supported", func(t *testing.T) {
+		conn := &typesfakes.FakeWebsocketClient{}
+		conn.ReadMessageReturnsOnCall(0, websocket.TextMessage, refresh, nil)
+		conn.ReadMessageReturnsOnCall(1, websocket.TextMessage, leave, nil)
+		sigConn := NewWSSignalConnection(conn)
+		var tokens []string
+		sigConn.OnTokenRefresh(func(token string) {
+			tokens = append(tokens, token)
+		})
+
+		req, _, err := sigConn.ReadRequest()
+		require.NoError(t, err)
+		require.NotNil(t, req.GetLeave())
+		require.Equal(t, []string{"abc"}, tokens)
+	})
+
+	t.Run("older clients", func(t *testing.T) {
+		conn := &typesfakes.FakeWebsocketClient{}
+		conn.ReadMessageReturns(websocket.TextMessage, refresh, nil)
+		sigConn := NewWSSignalConnection(conn)
+
+		// not taken for a token refresh, and not a signal request either
+		_, _, err := sigConn.ReadRequest()
+		require.Error(t, err)
+		require.Equal(t, 1, conn.ReadMessageCallCount())
+	})
+}
+
+func TestRefreshSessionToken(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+	s := &RTCService{tokenAuth: NewAPIKeyAuthMiddleware(provider, nil, nil, nil, nil, nil, nil)}
+
+	newToken := func(identity string, room string, validFor time.Duration) string {
+		token, err := auth.NewAccessToken(api, secret).
+			SetIdentity(identity).
+			SetValidFor(validFor).
+			AddGrant(&auth.VideoGrant{RoomJoin: true, Room: room}).
+			ToJWT()
+		require.NoError(t, err)
+		return token
+	}
+	newSession := func() *tokenSession {
+		return &tokenSession{
+			identity:  "alice",
+			roomName:  "room1",
+			expiresAt: time.Now().Add(-time.Second),
+		}
+	}
+
+	t.Run("extends the session", func(t *testing.T) {
+		session := newSession()
+		require.True(t, session.expired(0))
+
+		expiresAt, err := s.refreshSessionToken(context.Background(), session, newToken("alice", "room1", time.Hour))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, 2*time.Second)
+		require.Equal(t, expiresAt, session.ExpiresAt())
+		require.False(t, session.expired(0))
+	})
+
+	t.Run("rejects tokens of others", func(t *testing.T) {
+		session := newSession()
+		_, err := s.refreshSessionToken(context.Background(), session, newToken("bob", "room1", time.Hour))
+		require.ErrorIs(t, err, ErrTokenRefreshMismatch)
+		_, err = s.refreshSessionToken(context.Background(), session, newToken("alice", "room2", time.Hour))
+		require.ErrorIs(t, err, ErrTokenRefreshMismatch)
+		_, err = s.refreshSessionToken(context.Background(), session, "invalid token")
+		require.Error(t, err)
+		require.True(t, session.expired(0))
+	})
+
+	t.Run("grace", func(t *testing.T) {
+		session := newSession()
+		require.False(t, session.expired(time.Minute))
+		require.False(t, (&tokenSession{}).expired(0))
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		_, err := (&RTCService{}).refreshSessionToken(context.Background(), newSession(), newToken("alice", "room1", time.Hour))
+		require.ErrorIs(t, err, ErrTokenRefreshNotEnabled)
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	maxTokenRevocationRequestSize = 4 * 1024
+
+	// revocations are kept for as long as the tokens they revoke could be valid, a day unless the request sets it
+	defaultTokenRevocationTTL = 24 * time.Hour
+	maxTokenRevocationTTL     = 30 * 24 * time.Hour
+)
+
+// TokenRevocationService revokes tokens before they expire at /revoke_tokens, requests, joins and reconnects with
+// revoked tokens are rejected as they are authenticated. a token is revoked by its ID (jti), or all tokens of an identity issued up to the request
+// are revoked, tokens issued for the identity afterwards are valid.
+// POST {"token_id": "", "identity": "", "ttl": 0} keeps the revocation for ttl seconds, which should outlast the
+// tokens it revokes
+type TokenRevocationService struct {
+	store TokenRevocationStore
+}
+
+func NewTokenRevocationService(store TokenRevocationStore) *TokenRevocationService {
+	return &TokenRevocationService{
+		store: store,
+	}
+}
+
+// RevokeTokens revokes the token of the ID and the tokens of the identity issued until now, either may be empty
+func (s *TokenRevocationService) RevokeTokens(ctx context.Context, tokenID string, identity string, ttl time.Duration) error {
+	AppendLogFields(ctx, "tokenID", tokenID, "participant", identity)
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return err
+	}
+	if s.store == nil {
+		return ErrRevocationNotEnabled
+	}
+	if (tokenID == "" && identity == "") || ttl < 0 || ttl > maxTokenRevocationTTL {
+		return ErrInvalidRevocation
+	}
+	if ttl == 0 {
+		ttl = defaultTokenRevocationTTL
+	}
+
+	if tokenID != "" {
+		if err := s.store.RevokeToken(ctx, revocationKey(ctx, tokenID), ttl); err != nil {
+			return err
+		}
+	}
+	if identity != "" {
+		if err := s.store.RevokeIdentityTokens(ctx, revocationKey(ctx, identity), time.Now(), ttl); err != nil {
+			return err
+		}
+	}
+	logger.Infow("revoked tokens", "tokenID", tokenID, "participant", identity, "ttl", ttl)
+	return nil
+}
+
+// revocationKey scopes token IDs and identities to the project of the request, for projects not to revoke tokens
+// of each other
+func revocationKey(ctx context.Context, value string) string {
+	if project, ok := GetProject(ctx); ok && project != "" {
+		return project + utils.ProjectSeparator + value
+	}
+	return value
+}
+
+type revokeTokensRequest struct {
+	TokenID  string `json:"token_id"`
+	Identity string `json:"identity"`
+	// seconds
+	TTL int64 `json:"ttl"`
+}
+
+func (s *TokenRevocationService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxTokenRevocationRequestSize, func(ctx context.Context, req *revokeTokensRequest) (interface{}, error) {
+			if req.TTL < 0 || req.TTL > int64(maxTokenRevocationTTL/time.Second) {
+				return nil, ErrInvalidRevocation
+			}
+			return nil, s.RevokeTokens(ctx, req.TokenID, req.Identity, time.Duration(req.TTL)*time.Second)
+		}),
+	}.ServeHTTP(w, r)
+}
+
+// ensureTokenNotRevoked rejects tokens that have been revoked, as requests are authenticated. tokens without an issue
+// time are revoked by revocations of their identity
+func ensureTokenNotRevoked(ctx context.Context, revocations TokenRevocationStore, identity string) error {
+	if revocations == nil {
+		return nil
+	}
+	var tokenID string
+	var issuedAt time.Time
+	if claims := getTokenClaims(ctx); claims != nil {
+		if claims.ID != "" {
+			tokenID = revocationKey(ctx, claims.ID)
+		}
+		issuedAt, _ = claims.issuedAt()
+	}
+	if tokenID == "" && identity == "" {
+		// tokens of server APIs without an ID cannot be revoked
+		return nil
+	}
+
+	revoked, err := revocations.IsTokenRevoked(ctx, tokenID, revocationKey(ctx, identity), issuedAt)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+)
+
+func TestTokenRevocation(t *testing.T) {
+	store := NewLocalStore()
+	s := NewTokenRevocationService(store)
+	admin := WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomCreate: true}})
+	join := func(project string, tokenID string, issuedAt time.Time) context.Context {
+		ctx := withTokenClaims(context.Background(), &tokenClaims{ID: tokenID, NotBefore: issuedAt.Unix()})
+		if project != "" {
+			ctx = WithProject(ctx, project)
+		}
+		return ctx
+	}
+	issuedAt := time.Now().Add(-time.Minute)
+
+	require.ErrorIs(t, s.RevokeTokens(context.Background(), "token", "", 0), ErrPermissionDenied)
+	require.ErrorIs(t, s.RevokeTokens(admin, "", "", 0), ErrInvalidRevocation)
+	require.ErrorIs(t, s.RevokeTokens(admin, "token", "", -time.Second), ErrInvalidRevocation)
+
+	t.Run("by token ID", func(t *testing.T) {
+		require.NoError(t, s.RevokeTokens(admin, "token1", "", time.Hour))
+		require.ErrorIs(t, ensureTokenNotRevoked(join("", "token1", issuedAt), store, "alice"), ErrTokenRevoked)
+		require.NoError(t, ensureTokenNotRevoked(join("", "token2", issuedAt), store, "alice"))
+		// token IDs of other projects are not revoked
+		require.NoError(t, ensureTokenNotRevoked(join("customer", "token1", issuedAt), store, "alice"))
+	})
+
+	t.Run("by identity", func(t *testing.T) {
+		require.NoError(t, s.RevokeTokens(admin, "", "bob", time.Hour))
+		require.ErrorIs(t, ensureTokenNotRevoked(join("", "", issuedAt), store, "bob"), ErrTokenRevoked)
+		// tokens issued after the revocation are valid
+		require.NoError(t, ensureTokenNotRevoked(join("", "", time.Now().Add(time.Minute)), store, "bob"))
+		require.NoError(t, ensureTokenNotRevoked(join("", "", issuedAt), store, "carol"))
+	})
+
+	t.Run("requests are rejected as they are authenticated", func(t *testing.T) {
+		secret := "somesecretencodedinbase62"
+		provider := &authfakes.FakeKeyProvider{}
+		provider.GetSecretReturns(secret)
+		m := NewAPIKeyAuthMiddleware(provider, nil, nil, nil, nil, nil, store)
+		newToken := func(identity string) string {
+			token, err := auth.NewAccessToken("APIkey", secret).
+				SetIdentity(identity).
+				AddGrant(&auth.VideoGrant{RoomList: true}).
+				ToJWT()
+			require.NoError(t, err)
+			return token
+		}
+		serve := func(token string) int {
+			r := httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/ListRooms", nil)
+			SetAuthorizationToken(r, token)
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			return w.Code
+		}
+
+		revoked, other := newToken("dave"), newToken("erin")
+		require.NoError(t, s.RevokeTokens(admin, "", "dave", time.Hour))
+		require.Equal(t, http.StatusUnauthorized, serve(revoked))
+		require.Equal(t, http.StatusOK, serve(other))
+	})
+
+	t.Run("expired revocation", func(t *testing.T) {
+		require.NoError(t, store.RevokeToken(context.Background(), "token3", -time.Second))
+		require.NoError(t, ensureTokenNotRevoked(join("", "token3", issuedAt), store, "alice"))
+	})
+
+	t.Run("not kept by the store", func(t *testing.T) {
+		require.ErrorIs(t, NewTokenRevocationService(nil).RevokeTokens(admin, "token", "", 0), ErrRevocationNotEnabled)
+		require.NoError(t, ensureTokenNotRevoked(join("", "token1", issuedAt), nil, "alice"))
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// the command starting or stopping a relay, its payload is a relayTracks
+	relayTracksCommand routing.RoomCommandType = "relay-tracks"
+
+	maxRelayTracksRequestSize = 64 * 1024
+)
+
+// TrackRelayService relays tracks published in a room into another room at /relay_tracks, such as from a stage
+// room into overflow or audience rooms. participants of the destination room subscribe to the tracks as published
+// by a participant of identity relay/<room>/<identity>, the tracks are forwarded by the server without being
+// published again. the relay stops when the publisher leaves or is moved out of the room.
+// POST {"room": "", "identity": "", "destination_room": "", "track_sids": []} relays the tracks of the sids, all
+// tracks of the participant when there are none, and responds with the identity of the relay.
+// DELETE ?room=&identity=&destination_room= stops the relay.
+// both rooms have to be hosted by the same node, a destination room that isn't hosted yet is started on the node of
+// the source room
+type TrackRelayService struct {
+	router routing.Router
+	store  ObjectStore
+}
+
+func NewTrackRelayService(router routing.Router, store ObjectStore) *TrackRelayService {
+	return &TrackRelayService{
+		router: router,
+		store:  store,
+	}
+}
+
+// relayTracks is the payload of relay commands to the node hosting the rooms
+type relayTracks struct {
+	DestinationRoom string   `json:"destination_room"`
+	TrackSids       []string `json:"track_sids,omitempty"`
+	Stop            bool     `json:"stop,omitempty"`
+}
+
+// RelayTracks relays tracks of the participant into the destination room, and returns the identity publishing them
+// in the destination room
+func (s *TrackRelayService) RelayTracks(ctx context.Context, room string, identity string, destinationRoom string, trackSids []string) (livekit.ParticipantIdentity, error) {
+	AppendLogFields(ctx, "room", room, "participant", identity, "destinationRoom", destinationRoom, "trackIDs", trackSids)
+	roomName, dstRoomName, err := s.validate(ctx, room, identity, destinationRoom)
+	if err != nil {
+		return "", err
+	}
+
+	pi, err := s.store.LoadParticipant(ctx, roomName, livekit.ParticipantIdentity(identity))
+	if err != nil {
+		return "", err
+	}
+	for _, sid := range trackSids {
+		found := false
+		for _, ti := range pi.Tracks {
+			if ti.Sid == sid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", ErrTrackNotFound
+		}
+	}
+	if _, _, err = s.store.LoadRoom(ctx, dstRoomName, false); err != nil {
+		return "", err
+	}
+	sameNode, unassign, err := hostOnSameNode(ctx, s.router, roomName, dstRoomName)
+	if err != nil {
+		return "", err
+	}
+	if !sameNode {
+		return "", ErrRelayAcrossNodes
+	}
+
+	if err = s.writeRelayCommand(ctx, roomName, identity, &relayTracks{
+		DestinationRoom: string(dstRoomName),
+		TrackSids:       trackSids,
+	}); err != nil {
+		unassign()
+		return "", err
+	}
+	return rtc.RelayIdentity(roomName, livekit.ParticipantIdentity(identity)), nil
+}
+
+// StopRelay stops relaying tracks of the participant into the destination room
+func (s *TrackRelayService) StopRelay(ctx context.Context, room string, identity string, destinationRoom string) error {
+	AppendLogFields(ctx, "room", room, "participant", identity, "destinationRoom", destinationRoom)
+	roomName, dstRoomName, err := s.validate(ctx, room, identity, destinationRoom)
+	if err != nil {
+		return err
+	}
+	if _, err = s.store.LoadParticipant(ctx, roomName, livekit.ParticipantIdentity(identity)); err != nil {
+		return err
+	}
+
+	return s.writeRelayCommand(ctx, roomName, identity, &relayTracks{
+		DestinationRoom: string(dstRoomName),
+		Stop:            true,
+	})
+}
+
+func (s *TrackRelayService) validate(ctx context.Context, room string, identity string, destinationRoom string) (livekit.RoomName, livekit.RoomName, error) {
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return "", "", err
+	}
+	if identity == "" {
+		return "", "", ErrIdentityEmpty
+	}
+	if room == "" || destinationRoom == "" || room == destinationRoom {
+		return "", "", ErrInvalidTrackRelay
+	}
+
+	if project, ok := GetProject(ctx); ok {
+		var err error
+		if room, err = scopeRoomName(project, room); err != nil {
+			return "", "", err
+		}
+		if destinationRoom, err = scopeRoomName(project, destinationRoom); err != nil {
+			return "", "", err
+		}
+	}
+	return livekit.RoomName(room), livekit.RoomName(destinationRoom), nil
+}
+
+func (s *TrackRelayService) writeRelayCommand(ctx context.Context, roomName livekit.RoomName, identity string, relay *relayTracks) error {
+	data, err := json.Marshal(relay)
+	if err != nil {
+		return err
+	}
+	return s.router.WriteRoomCommand(ctx, &routing.RoomCommand{
+		Type:     relayTracksCommand,
+		Room:     roomName,
+		Identity: livekit.ParticipantIdentity(identity),
+		Payload:  data,
+	})
+}
+
+type relayTracksRequest struct {
+	Room            string   `json:"room"`
+	Identity        string   `json:"identity"`
+	DestinationRoom string   `json:"destination_room"`
+	TrackSids       []string `json:"track_sids"`
+}
+
+type relayTracksResponse struct {
+	Identity string `json:"identity"`
+}
+
+func (s *TrackRelayService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxRelayTracksRequestSize, func(ctx context.Context, req *relayTracksRequest) (interface{}, error) {
+			identity, err := s.RelayTracks(ctx, req.Room, req.Identity, req.DestinationRoom, req.TrackSids)
+			if err != nil {
+				return nil, err
+			}
+			return &relayTracksResponse{Identity: string(identity)}, nil
+		}),
+		http.MethodDelete: func(r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			return nil, s.StopRelay(r.Context(), query.Get("room"), query.Get("identity"), query.Get("destination_room"))
+		},
+	}.ServeHTTP(w, r)
+}
+
+// relayTracks starts or stops a relay of tracks of the participant, the destination room is started when needed
+func (r *RoomManager) relayTracks(ctx context.Context, room *rtc.Room, participant types.LocalParticipant, relay *relayTracks) error {
+	dstRoomName := livekit.RoomName(relay.DestinationRoom)
+	if relay.Stop {
+		if dstRoom := r.GetRoom(ctx, dstRoomName); dstRoom != nil {
+			room.StopRelay(dstRoom, participant.Identity())
+		}
+		return nil
+	}
+
+	dstRoom, err := r.getOrCreateRoom(ctx, dstRoomName)
+	if err != nil {
+		return err
+	}
+	defer dstRoom.Release()
+
+	return room.RelayTracks(dstRoom, participant.Identity(), livekit.StringsAsIDs[livekit.TrackID](relay.TrackSids))
+}
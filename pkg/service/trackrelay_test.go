@@ -0,0 +1,84 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestTrackRelay(t *testing.T) {
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomCreate: true},
+	})
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "stage"}, nil))
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "overflow"}, nil))
+	require.NoError(t, store.StoreParticipant(ctx, "stage", &livekit.ParticipantInfo{
+		Identity: "speaker",
+		Sid:      "PA_speaker",
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_camera"}, {Sid: "TR_microphone"}},
+	}))
+	router := &routingfakes.FakeRouter{}
+	nodes := map[livekit.RoomName]string{"stage": "node-1", "overflow": "node-1", "remote": "node-2"}
+	router.GetNodeForRoomCalls(func(ctx context.Context, roomName livekit.RoomName) (*livekit.Node, error) {
+		if id, ok := nodes[roomName]; ok {
+			return &livekit.Node{Id: id}, nil
+		}
+		return nil, routing.ErrNotFound
+	})
+	svc := service.NewTrackRelayService(router, store)
+
+	t.Run("rejects invalid relays", func(t *testing.T) {
+		_, err := svc.RelayTracks(context.Background(), "stage", "speaker", "overflow", nil)
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+		_, err = svc.RelayTracks(ctx, "stage", "speaker", "stage", nil)
+		require.ErrorIs(t, err, service.ErrInvalidTrackRelay)
+		_, err = svc.RelayTracks(ctx, "stage", "speaker", "overflow", []string{"TR_screen"})
+		require.ErrorIs(t, err, service.ErrTrackNotFound)
+		_, err = svc.RelayTracks(ctx, "stage", "audience", "overflow", nil)
+		require.ErrorIs(t, err, service.ErrParticipantNotFound)
+
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "remote"}, nil))
+		_, err = svc.RelayTracks(ctx, "stage", "speaker", "remote", nil)
+		require.ErrorIs(t, err, service.ErrRelayAcrossNodes)
+		require.Equal(t, 0, router.WriteRoomCommandCallCount())
+	})
+
+	t.Run("relays through the node of the publisher", func(t *testing.T) {
+		identity, err := svc.RelayTracks(ctx, "stage", "speaker", "overflow", []string{"TR_camera"})
+		require.NoError(t, err)
+		require.Equal(t, livekit.ParticipantIdentity("relay/stage/speaker"), identity)
+
+		require.NoError(t, svc.StopRelay(ctx, "stage", "speaker", "overflow"))
+		require.Equal(t, 2, router.WriteRoomCommandCallCount())
+		_, cmd := router.WriteRoomCommandArgsForCall(0)
+		require.Equal(t, livekit.RoomName("stage"), cmd.Room)
+		require.Equal(t, livekit.ParticipantIdentity("speaker"), cmd.Identity)
+		require.JSONEq(t, `{"destination_room":"overflow","track_sids":["TR_camera"]}`, string(cmd.Payload))
+		_, cmd = router.WriteRoomCommandArgsForCall(1)
+		require.JSONEq(t, `{"destination_room":"overflow","stop":true}`, string(cmd.Payload))
+	})
+}
@@ -84,7 +84,7 @@ func NewTurnServer(conf *config.Config, authHandler turn.AuthHandler, standalone
 		}
 
 		if !turnConf.ExternalTLS {
-			cert, err := tls.LoadX509KeyPair(turnConf.CertFile, turnConf.KeyFile)
+			cert, err := config.LoadX509KeyPair(turnConf.CertFile, turnConf.KeyFile)
 			if err != nil {
 				return nil, errors.Wrap(err, "TURN tls cert required")
 			}
@@ -0,0 +1,148 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+var (
+	ErrTURNServersNotConfigured = psrpc.NewErrorf(psrpc.Unimplemented, "external TURN servers are not configured")
+)
+
+// credentials of external TURN servers with a secret are valid this long unless the server sets it
+const defaultTURNCredentialTTL = 24 * time.Hour
+
+// turnRESTCredentials issues credentials with the TURN REST API scheme of coturn: the username is the unix time
+// the credentials expire at and the user, the credential is the base64 HMAC-SHA1 of the username keyed by the secret
+func turnRESTCredentials(secret string, user string, expiresAt time.Time) (username string, credential string) {
+	username = strconv.FormatInt(expiresAt.Unix(), 10) + ":" + user
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func turnCredentialTTL(s config.TURNServer) time.Duration {
+	if s.CredentialTTL > 0 {
+		return s.CredentialTTL
+	}
+	return defaultTURNCredentialTTL
+}
+
+func turnServerURL(s config.TURNServer) string {
+	scheme := "turn"
+	transport := "tcp"
+	if s.Protocol == "tls" {
+		scheme = "turns"
+	} else if s.Protocol == "udp" {
+		transport = "udp"
+	}
+	return fmt.Sprintf("%s:%s:%d?transport=%s", scheme, s.Host, s.Port, transport)
+}
+
+// externalTURNICEServer returns the ICE server of an external TURN server, with credentials issued to the user when
+// the server has a secret
+func externalTURNICEServer(s config.TURNServer, user string, now time.Time) *livekit.ICEServer {
+	is := &livekit.ICEServer{
+		Urls:       []string{turnServerURL(s)},
+		Username:   s.Username,
+		Credential: s.Credential,
+	}
+	if s.Secret != "" {
+		is.Username, is.Credential = turnRESTCredentials(s.Secret, user, now.Add(turnCredentialTTL(s)))
+	}
+	return is
+}
+
+// TURNCredentialsService issues credentials of the external TURN servers at /turn_credentials, for clients
+// connecting outside of the signal connection. the token of the request must be able to join a room, credentials
+// are issued to its identity.
+// GET returns {"ice_servers": [{"urls": [], "username": "", "credential": "", "ttl": 0}]}, ttl is the seconds issued
+// credentials are valid, 0 for static credentials
+type TURNCredentialsService struct {
+	servers []config.TURNServer
+}
+
+func NewTURNCredentialsService(conf *config.Config) *TURNCredentialsService {
+	return &TURNCredentialsService{
+		servers: conf.RTC.TURNServers,
+	}
+}
+
+type turnCredentialsResponse struct {
+	ICEServers []turnICEServer `json:"ice_servers"`
+}
+
+type turnICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username"`
+	Credential string   `json:"credential"`
+	// seconds
+	TTL int64 `json:"ttl"`
+}
+
+// IssueCredentials returns the external TURN servers with credentials issued to the identity of the token
+func (s *TURNCredentialsService) IssueCredentials(ctx context.Context) (*turnCredentialsResponse, error) {
+	if _, err := EnsureJoinPermission(ctx); err != nil {
+		return nil, err
+	}
+	grants := GetGrants(ctx)
+	if grants.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if len(s.servers) == 0 {
+		return nil, ErrTURNServersNotConfigured
+	}
+	AppendLogFields(ctx, "participant", grants.Identity)
+
+	now := time.Now()
+	res := &turnCredentialsResponse{ICEServers: make([]turnICEServer, 0, len(s.servers))}
+	for _, server := range s.servers {
+		is := externalTURNICEServer(server, grants.Identity, now)
+		var ttl int64
+		if server.Secret != "" {
+			ttl = int64(turnCredentialTTL(server) / time.Second)
+		}
+		res.ICEServers = append(res.ICEServers, turnICEServer{
+			URLs:       is.Urls,
+			Username:   is.Username,
+			Credential: is.Credential,
+			TTL:        ttl,
+		})
+	}
+	return res, nil
+}
+
+func (s *TURNCredentialsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// credentials are not to be kept by caches
+	w.Header().Set("Cache-Control", "no-store")
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			return s.IssueCredentials(r.Context())
+		},
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestTURNCredentials(t *testing.T) {
+	t.Run("credentials of the TURN REST API", func(t *testing.T) {
+		username, credential := turnRESTCredentials("north", "alice", time.Unix(1700000000, 0))
+		require.Equal(t, "1700000000:alice", username)
+		require.Equal(t, "Cd/49soE35ICqcJF/bCTn8Z4OyE=", credential)
+	})
+
+	s := NewTURNCredentialsService(&config.Config{
+		RTC: config.RTCConfig{
+			TURNServers: []config.TURNServer{
+				{Host: "turn.example.com", Port: 3478, Protocol: "udp", Secret: "north", CredentialTTL: time.Hour},
+				{Host: "static.example.com", Port: 443, Protocol: "tls", Username: "user", Credential: "pass"},
+			},
+		},
+	})
+	serve := func(grants *auth.ClaimGrants) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/turn_credentials", nil)
+		r = r.WithContext(WithGrants(context.Background(), grants))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("issued to the identity of the token", func(t *testing.T) {
+		w := serve(&auth.ClaimGrants{Identity: "alice", Video: &auth.VideoGrant{RoomJoin: true, Room: "standup"}})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		var res turnCredentialsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.ICEServers, 2)
+
+		issued := res.ICEServers[0]
+		require.Equal(t, []string{"turn:turn.example.com:3478?transport=udp"}, issued.URLs)
+		require.Equal(t, int64(3600), issued.TTL)
+		expiry, user, ok := strings.Cut(issued.Username, ":")
+		require.True(t, ok)
+		require.Equal(t, "alice", user)
+		expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+		require.NoError(t, err)
+		require.InDelta(t, time.Now().Add(time.Hour).Unix(), expiresAt, 5)
+		_, credential := turnRESTCredentials("north", "alice", time.Unix(expiresAt, 0))
+		require.Equal(t, credential, issued.Credential)
+
+		static := res.ICEServers[1]
+		require.Equal(t, []string{"turns:static.example.com:443?transport=tcp"}, static.URLs)
+		require.Equal(t, "user", static.Username)
+		require.Equal(t, "pass", static.Credential)
+		require.Zero(t, static.TTL)
+	})
+
+	t.Run("tokens must be able to join", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve(&auth.ClaimGrants{Identity: "alice", Video: &auth.VideoGrant{RoomList: true}}).Code)
+		require.Equal(t, http.StatusBadRequest, serve(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}).Code)
+	})
+
+	t.Run("without external servers", func(t *testing.T) {
+		disabled := NewTURNCredentialsService(&config.Config{})
+		_, err := disabled.IssueCredentials(WithGrants(context.Background(), &auth.ClaimGrants{
+			Identity: "alice",
+			Video:    &auth.VideoGrant{RoomJoin: true},
+		}))
+		require.ErrorIs(t, err, ErrTURNServersNotConfigured)
+	})
+}
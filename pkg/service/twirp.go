@@ -72,6 +72,8 @@ type requestLogger struct {
 }
 
 func AppendLogFields(ctx context.Context, fields ...interface{}) {
+	appendAuditFields(ctx, fields...)
+
 	r, ok := ctx.Value(loggerKey).(*requestLogger)
 	if !ok || r == nil {
 		return
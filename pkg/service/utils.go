@@ -15,7 +15,6 @@
 package service
 
 import (
-	"net"
 	"net/http"
 	"regexp"
 
@@ -38,17 +37,11 @@ func IsValidDomain(domain string) bool {
 	return domainRegexp.MatchString(domain)
 }
 
+// GetClientIP returns the address of the client of a request, as resolved by the ClientIPMiddleware. requests it did
+// not resolve are of their peer, as forwarded headers are not trusted
 func GetClientIP(r *http.Request) string {
-	// CF proxy typically is first thing the user reaches
-	if ip := r.Header.Get("CF-Connecting-IP"); ip != "" {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
 		return ip
 	}
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		return ip
-	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return ip
-	}
-	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
-	return ip
+	return remoteIP(r)
 }
@@ -0,0 +1,434 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/webhook"
+)
+
+const (
+	// number of delivery attempts kept for inspection
+	webhookDeliveryHistorySize = 1000
+
+	webhookQueueSize     = 1000
+	webhookMaxAttempts   = 3
+	webhookRetryInterval = time.Second
+	webhookTimeout       = 10 * time.Second
+	webhookTokenValidity = 5 * time.Minute
+
+	defaultWebhookDeliveriesLimit = 100
+	maxRedeliveryRequestSize      = 64 * 1024
+	// events are read from the event log in pages of this size when looking for events to redeliver
+	redeliveryReadSize = 500
+)
+
+// WebhookDelivery is the record of an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	EventID string `json:"event_id"`
+	Event   string `json:"event"`
+	URL     string `json:"url"`
+	// attempts of a delivery are numbered from 1, an event is delivered again after failed attempts
+	Attempt    int           `json:"attempt"`
+	Redelivery bool          `json:"redelivery"`
+	SentAt     time.Time     `json:"sent_at"`
+	Latency    time.Duration `json:"-"`
+	// 0 when no response was received
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (d *WebhookDelivery) Succeeded() bool {
+	return d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+type webhookRequest struct {
+	event      *livekit.WebhookEvent
+	redelivery bool
+}
+
+// WebhookNotifier delivers events to the configured webhooks in the order they are queued, retrying failed
+// deliveries, and keeps a record of the latest delivery attempts. requests are signed as by the webhook package, so
+// that receivers verify them the same way, and also with the webhook signing key when one is configured
+type WebhookNotifier struct {
+	// empty when requests are only signed with the signing key
+	apiKey string
+	// the secret of the key is looked up for each request, as it may be rotated
+	keyProvider auth.KeyProvider
+	signer      *WebhookSigner
+	// signatures of requests expire after the window, receivers reject requests signed outside of it
+	replayWindow time.Duration
+	client       *http.Client
+	queues       map[string]chan webhookRequest
+
+	lock       sync.RWMutex
+	deliveries []*WebhookDelivery
+	// index of the next record in deliveries, which wraps around once it holds webhookDeliveryHistorySize records
+	next int
+}
+
+func NewWebhookNotifier(
+	apiKey string,
+	keyProvider auth.KeyProvider,
+	signer *WebhookSigner,
+	replayWindow time.Duration,
+	urls []string,
+) *WebhookNotifier {
+	if replayWindow <= 0 {
+		replayWindow = webhook.DefaultReplayWindow
+	}
+	n := &WebhookNotifier{
+		apiKey:       apiKey,
+		keyProvider:  keyProvider,
+		signer:       signer,
+		replayWindow: replayWindow,
+		client:       &http.Client{Timeout: webhookTimeout},
+		queues:       make(map[string]chan webhookRequest, len(urls)),
+		deliveries:   make([]*WebhookDelivery, 0, webhookDeliveryHistorySize),
+	}
+	for _, url := range urls {
+		queue := make(chan webhookRequest, webhookQueueSize)
+		n.queues[url] = queue
+		go n.worker(url, queue)
+	}
+	return n
+}
+
+func (n *WebhookNotifier) QueueNotify(_ context.Context, event *livekit.WebhookEvent) error {
+	return n.queue(webhookRequest{event: event})
+}
+
+// Redeliver queues the event to be delivered again to all webhooks
+func (n *WebhookNotifier) Redeliver(event *livekit.WebhookEvent) error {
+	return n.queue(webhookRequest{event: event, redelivery: true})
+}
+
+func (n *WebhookNotifier) queue(req webhookRequest) error {
+	var err error
+	for url, queue := range n.queues {
+		select {
+		case queue <- req:
+		default:
+			logger.Warnw("dropping webhook event, queue is full", nil, "event", req.event.Event, "url", url)
+			err = ErrWebhookQueueFull
+		}
+	}
+	return err
+}
+
+// Deliveries returns up to limit delivery attempts, the latest first. attempts of all events are returned when
+// eventID is empty
+func (n *WebhookNotifier) Deliveries(eventID string, limit int) []*WebhookDelivery {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	deliveries := make([]*WebhookDelivery, 0, limit)
+	for i := 1; i <= len(n.deliveries) && len(deliveries) < limit; i++ {
+		d := n.deliveries[(n.next-i+len(n.deliveries))%len(n.deliveries)]
+		if eventID == "" || d.EventID == eventID {
+			c := *d
+			deliveries = append(deliveries, &c)
+		}
+	}
+	return deliveries
+}
+
+func (n *WebhookNotifier) worker(url string, queue <-chan webhookRequest) {
+	for req := range queue {
+		for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+			d := n.send(url, req.event)
+			d.Attempt = attempt
+			d.Redelivery = req.redelivery
+			n.record(d)
+			// client errors would be returned again
+			if d.Succeeded() || (d.StatusCode >= 400 && d.StatusCode < 500) {
+				break
+			}
+			if attempt < webhookMaxAttempts {
+				time.Sleep(webhookRetryInterval * time.Duration(attempt))
+			}
+		}
+	}
+}
+
+func (n *WebhookNotifier) send(url string, event *livekit.WebhookEvent) *WebhookDelivery {
+	d := &WebhookDelivery{
+		EventID: event.Id,
+		Event:   event.Event,
+		URL:     url,
+		SentAt:  time.Now(),
+	}
+	err := func() error {
+		encoded, err := protojson.Marshal(event)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(encoded)
+		encodedSum := base64.StdEncoding.EncodeToString(sum[:])
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(encoded))
+		if err != nil {
+			return err
+		}
+		// every attempt is signed anew, with a nonce of its own
+		signedAt := time.Now()
+		nonce, err := webhook.NewNonce()
+		if err != nil {
+			return err
+		}
+		req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+		req.Header.Set(webhook.NonceHeader, nonce)
+		if n.apiKey != "" {
+			apiSecret := n.keyProvider.GetSecret(n.apiKey)
+			token, err := auth.NewAccessToken(n.apiKey, apiSecret).
+				SetValidFor(n.replayWindow).
+				SetSha256(encodedSum).
+				ToJWT()
+			if err != nil {
+				return err
+			}
+			req.Header.Set(authorizationHeader, token)
+			req.Header.Set(webhook.ReplaySignatureHeader, webhook.ReplaySignature(apiSecret, signedAt, nonce, encodedSum))
+		}
+		if n.signer != nil {
+			signature, err := n.signer.Sign(encodedSum, signedAt, nonce, n.replayWindow)
+			if err != nil {
+				return err
+			}
+			req.Header.Set(webhookSignatureHeader, signature)
+		}
+		// a mime type of its own, so that receivers check the signature before parsing
+		req.Header.Set("Content-Type", "application/webhook+json")
+		res, err := n.client.Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		d.StatusCode = res.StatusCode
+		if !d.Succeeded() {
+			return fmt.Errorf("webhook responded with %s", res.Status)
+		}
+		return nil
+	}()
+	d.Latency = time.Since(d.SentAt)
+	if err != nil {
+		d.Error = err.Error()
+		logger.Warnw("failed to deliver webhook event", err, "event", event.Event, "url", url)
+	}
+	return d
+}
+
+func (n *WebhookNotifier) record(d *WebhookDelivery) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if len(n.deliveries) < webhookDeliveryHistorySize {
+		n.deliveries = append(n.deliveries, d)
+	} else {
+		n.deliveries[n.next] = d
+	}
+	n.next = (n.next + 1) % webhookDeliveryHistorySize
+}
+
+// WebhookDeliveryService inspects the deliveries of webhook events at /webhook_deliveries, and delivers events
+// again at /webhook_deliveries/redeliver, such as after an outage of a receiver. deliveries are those made by the
+// node serving the request, events to deliver again are read from the event log shared by all nodes.
+// GET ?event_id=&limit= responds with the latest delivery attempts, of the event when given.
+// POST /webhook_deliveries/redeliver {"event_id": ""} delivers the event again, {"from": 0, "to": 0} delivers the
+// events created within the range of unix timestamps again
+type WebhookDeliveryService struct {
+	broker *RoomEventBroker
+}
+
+func NewWebhookDeliveryService(broker *RoomEventBroker) *WebhookDeliveryService {
+	return &WebhookDeliveryService{
+		broker: broker,
+	}
+}
+
+// ListDeliveries returns up to limit delivery attempts, the latest first
+func (s *WebhookDeliveryService) ListDeliveries(ctx context.Context, eventID string, limit int) ([]*WebhookDelivery, error) {
+	if err := ensureWebhookPermission(ctx); err != nil {
+		return nil, err
+	}
+	if s.broker.notifier == nil {
+		return nil, ErrWebhooksNotEnabled
+	}
+	if limit <= 0 || limit > webhookDeliveryHistorySize {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	return s.broker.notifier.Deliveries(eventID, limit), nil
+}
+
+// RedeliverEvent delivers the event with the ID again, as long as it is kept in the event log
+func (s *WebhookDeliveryService) RedeliverEvent(ctx context.Context, eventID string) error {
+	AppendLogFields(ctx, "eventID", eventID)
+	if err := ensureWebhookPermission(ctx); err != nil {
+		return err
+	}
+	if s.broker.notifier == nil {
+		return ErrWebhooksNotEnabled
+	}
+	if eventID == "" {
+		return ErrInvalidRedelivery
+	}
+
+	events, err := s.broker.findEvents(ctx, func(event *livekit.WebhookEvent) bool {
+		return event.Id == eventID
+	})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return ErrWebhookEventNotFound
+	}
+	return s.broker.notifier.Redeliver(events[0])
+}
+
+// RedeliverEvents delivers the events created within [from, to] again, in the order they were created, and returns
+// the number of events
+func (s *WebhookDeliveryService) RedeliverEvents(ctx context.Context, from time.Time, to time.Time) (int, error) {
+	AppendLogFields(ctx, "from", from, "to", to)
+	if err := ensureWebhookPermission(ctx); err != nil {
+		return 0, err
+	}
+	if s.broker.notifier == nil {
+		return 0, ErrWebhooksNotEnabled
+	}
+	if from.IsZero() || to.Before(from) {
+		return 0, ErrInvalidRedelivery
+	}
+
+	events, err := s.broker.findEvents(ctx, func(event *livekit.WebhookEvent) bool {
+		return event.CreatedAt >= from.Unix() && event.CreatedAt <= to.Unix()
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		if err = s.broker.notifier.Redeliver(event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// webhooks are configured for the server, their deliveries are limited to keys not scoped to a project with list
+// and admin permissions
+func ensureWebhookPermission(ctx context.Context) error {
+	if _, scoped := GetProject(ctx); scoped {
+		return ErrPermissionDenied
+	}
+	if err := EnsureListPermission(ctx); err != nil {
+		return err
+	}
+	if claims := GetGrants(ctx); !claims.Video.RoomAdmin {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+type webhookDeliveriesResponse struct {
+	Deliveries []webhookDeliveryEntry `json:"deliveries"`
+}
+
+type webhookDeliveryEntry struct {
+	*WebhookDelivery
+	LatencyMs int64 `json:"latency_ms"`
+}
+
+func (s *WebhookDeliveryService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodGet: func(r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			var limit int
+			if v := query.Get("limit"); v != "" {
+				var err error
+				if limit, err = strconv.Atoi(v); err != nil {
+					return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+				}
+			}
+			deliveries, err := s.ListDeliveries(r.Context(), query.Get("event_id"), limit)
+			if err != nil {
+				return nil, err
+			}
+
+			res := &webhookDeliveriesResponse{Deliveries: make([]webhookDeliveryEntry, 0, len(deliveries))}
+			for _, d := range deliveries {
+				res.Deliveries = append(res.Deliveries, webhookDeliveryEntry{
+					WebhookDelivery: d,
+					LatencyMs:       d.Latency.Milliseconds(),
+				})
+			}
+			return res, nil
+		},
+	}.ServeHTTP(w, r)
+}
+
+type redeliveryRequest struct {
+	EventID string `json:"event_id"`
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+}
+
+type redeliveryResponse struct {
+	Events int `json:"events"`
+}
+
+// ServeRedeliver delivers events again at /webhook_deliveries/redeliver
+func (s *WebhookDeliveryService) ServeRedeliver(w http.ResponseWriter, r *http.Request) {
+	jsonHandler{
+		http.MethodPost: jsonRequest(maxRedeliveryRequestSize, func(ctx context.Context, req *redeliveryRequest) (interface{}, error) {
+			var err error
+			res := &redeliveryResponse{}
+			switch {
+			case req.EventID != "":
+				if err = s.RedeliverEvent(ctx, req.EventID); err == nil {
+					res.Events = 1
+				}
+			case req.From != 0:
+				to := time.Now()
+				if req.To != 0 {
+					to = time.Unix(req.To, 0)
+				}
+				res.Events, err = s.RedeliverEvents(ctx, time.Unix(req.From, 0), to)
+			default:
+				err = ErrInvalidRedelivery
+			}
+			if err != nil {
+				return nil, err
+			}
+			return res, nil
+		}),
+	}.ServeHTTP(w, r)
+}
@@ -0,0 +1,192 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	lkwebhook "github.com/livekit/livekit-server/pkg/webhook"
+)
+
+func TestWebhookDeliveryService(t *testing.T) {
+	provider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"key": "secret"})
+	var lock sync.Mutex
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, err := webhook.ReceiveWebhookEvent(r, provider)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if event.Event == webhook.EventRoomFinished {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lock.Lock()
+		received = append(received, event.Id)
+		lock.Unlock()
+	}))
+	defer receiver.Close()
+
+	broker, err := service.NewRoomEventBroker(&config.Config{
+		WebHook: config.WebHookConfig{URLs: []string{receiver.URL}, APIKey: "key"},
+	}, provider, nil)
+	require.NoError(t, err)
+	svc := service.NewWebhookDeliveryService(broker)
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomList: true, RoomAdmin: true},
+	})
+
+	createdAt := time.Now().Unix()
+	for _, event := range []*livekit.WebhookEvent{
+		{Id: "EV_started", Event: webhook.EventRoomStarted, Room: &livekit.Room{Name: "standup"}, CreatedAt: createdAt - 60},
+		{Id: "EV_joined", Event: webhook.EventParticipantJoined, Room: &livekit.Room{Name: "standup"}, CreatedAt: createdAt},
+		{Id: "EV_finished", Event: webhook.EventRoomFinished, Room: &livekit.Room{Name: "standup"}, CreatedAt: createdAt},
+	} {
+		require.NoError(t, broker.QueueNotify(context.Background(), event))
+	}
+	requireReceived := func(t *testing.T, expected ...string) {
+		require.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(received) == len(expected)
+		}, 5*time.Second, 10*time.Millisecond)
+		lock.Lock()
+		defer lock.Unlock()
+		require.Equal(t, expected, received)
+	}
+	requireReceived(t, "EV_started", "EV_joined")
+
+	t.Run("requires list and admin permissions", func(t *testing.T) {
+		listCtx := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomList: true}})
+		_, err := svc.ListDeliveries(listCtx, "", 0)
+		require.ErrorIs(t, err, service.ErrPermissionDenied)
+		require.ErrorIs(t, svc.RedeliverEvent(listCtx, "EV_joined"), service.ErrPermissionDenied)
+	})
+
+	t.Run("lists delivery attempts", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			deliveries, err := svc.ListDeliveries(ctx, "", 0)
+			return err == nil && len(deliveries) == 3
+		}, 5*time.Second, 10*time.Millisecond)
+
+		deliveries, err := svc.ListDeliveries(ctx, "EV_finished", 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, receiver.URL, deliveries[0].URL)
+		require.Equal(t, http.StatusBadRequest, deliveries[0].StatusCode)
+		require.NotEmpty(t, deliveries[0].Error)
+		require.False(t, deliveries[0].Succeeded())
+
+		deliveries, err = svc.ListDeliveries(ctx, "EV_joined", 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.True(t, deliveries[0].Succeeded())
+		require.Equal(t, 1, deliveries[0].Attempt)
+		require.False(t, deliveries[0].Redelivery)
+	})
+
+	t.Run("redelivers events", func(t *testing.T) {
+		require.ErrorIs(t, svc.RedeliverEvent(ctx, "EV_missing"), service.ErrWebhookEventNotFound)
+		require.NoError(t, svc.RedeliverEvent(ctx, "EV_started"))
+		requireReceived(t, "EV_started", "EV_joined", "EV_started")
+
+		n, err := svc.RedeliverEvents(ctx, time.Unix(createdAt-1, 0), time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		requireReceived(t, "EV_started", "EV_joined", "EV_started", "EV_joined")
+
+		require.Eventually(t, func() bool {
+			deliveries, err := svc.ListDeliveries(ctx, "EV_joined", 0)
+			return err == nil && len(deliveries) == 2 && deliveries[0].Redelivery
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, err = svc.RedeliverEvents(ctx, time.Now(), time.Unix(createdAt-60, 0))
+		require.ErrorIs(t, err, service.ErrInvalidRedelivery)
+	})
+}
+
+// the server signs deliveries for the replay guard of receivers
+func TestWebhookDeliveryReplaySignature(t *testing.T) {
+	provider := auth.NewFileBasedKeyProviderFromMap(map[string]string{"key": "secret"})
+	guard := lkwebhook.NewReplayGuard(time.Minute)
+
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{header: r.Header.Clone(), body: body}
+	}))
+	defer receiver.Close()
+
+	broker, err := service.NewRoomEventBroker(&config.Config{
+		WebHook: config.WebHookConfig{URLs: []string{receiver.URL}, APIKey: "key", ReplayWindow: time.Minute},
+	}, provider, nil)
+	require.NoError(t, err)
+	require.NoError(t, broker.QueueNotify(context.Background(), &livekit.WebhookEvent{
+		Id:    "EV_started",
+		Event: webhook.EventRoomStarted,
+		Room:  &livekit.Room{Name: "standup"},
+	}))
+	var d delivery
+	select {
+	case d = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not received")
+	}
+
+	request := func(header http.Header, body []byte) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header = header.Clone()
+		return r
+	}
+
+	// the token is valid for the window
+	_, err = webhook.ReceiveWebhookEvent(request(d.header, d.body), provider)
+	require.NoError(t, err)
+
+	require.NoError(t, guard.VerifyRequest(request(d.header, d.body), "secret", d.body))
+	require.ErrorIs(t, guard.VerifyRequest(request(d.header, d.body), "secret", d.body), lkwebhook.ErrReplayed)
+
+	t.Run("signature covers the timestamp and nonce", func(t *testing.T) {
+		header := d.header.Clone()
+		header.Set(lkwebhook.NonceHeader, "another")
+		require.ErrorIs(t, guard.VerifyRequest(request(header, d.body), "secret", d.body), lkwebhook.ErrReplaySignatureInvalid)
+
+		header = d.header.Clone()
+		header.Set(lkwebhook.TimestampHeader, "1700000000")
+		require.ErrorIs(t, guard.VerifyRequest(request(header, d.body), "secret", d.body), lkwebhook.ErrReplaySignatureInvalid)
+
+		require.ErrorIs(t, guard.VerifyRequest(request(d.header, d.body), "other", d.body), lkwebhook.ErrReplaySignatureInvalid)
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// webhook requests carry a JWT signed with the webhook signing key, with the sha256 claim of the body as with
+	// the Authorization token
+	webhookSignatureHeader = "X-Livekit-Webhook-Signature"
+
+	// the public keys of webhook signatures are served as a JWKS at this path
+	WebhookKeysPath = "/.well-known/webhook-jwks.json"
+)
+
+var ErrWebhookSigningNotEnabled = errors.New("webhook signing key is not configured")
+
+// WebhookSigner signs webhook requests with an Ed25519 or RSA key, for receivers to verify them with its public key
+// rather than with the API secret
+type WebhookSigner struct {
+	signer jose.Signer
+	public jose.JSONWebKey
+}
+
+// LoadWebhookSigner loads the private key of a PEM file, PKCS #8 keys and PKCS #1 RSA keys are supported
+func LoadWebhookSigner(keyFile string) (*WebhookSigner, error) {
+	b, err := config.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read webhook signing key: %v", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in webhook signing key file %s", keyFile)
+	}
+	var key interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse webhook signing key: %v", err)
+	}
+	return NewWebhookSigner(key)
+}
+
+func NewWebhookSigner(key interface{}) (*WebhookSigner, error) {
+	var alg jose.SignatureAlgorithm
+	var public crypto.PublicKey
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		alg, public = jose.EdDSA, k.Public()
+	case *rsa.PrivateKey:
+		alg, public = jose.RS256, k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported webhook signing key %T, Ed25519 and RSA keys are supported", key)
+	}
+
+	// keys are identified by their thumbprint, for receivers to tell keys apart when they are rotated
+	jwk := jose.JSONWebKey{Key: public, Algorithm: string(alg), Use: "sig"}
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: key, KeyID: jwk.KeyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookSigner{
+		signer: signer,
+		public: jwk,
+	}, nil
+}
+
+type webhookSignatureClaims struct {
+	jwt.Claims
+	Sha256 string `json:"sha256"`
+}
+
+// Sign returns the signature of a webhook request with the sha256 sum of its body, encoded as by the Authorization
+// token. the time it is signed at and the nonce of the request are the iat and jti claims, it expires after validFor
+func (s *WebhookSigner) Sign(sha256 string, signedAt time.Time, nonce string, validFor time.Duration) (string, error) {
+	return jwt.Signed(s.signer).Claims(&webhookSignatureClaims{
+		Claims: jwt.Claims{
+			ID:       nonce,
+			IssuedAt: jwt.NewNumericDate(signedAt),
+			Expiry:   jwt.NewNumericDate(signedAt.Add(validFor)),
+		},
+		Sha256: sha256,
+	}).CompactSerialize()
+}
+
+// Keys returns the JWKS of the public key
+func (s *WebhookSigner) Keys() *jose.JSONWebKeySet {
+	return &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{s.public}}
+}
+
+// ServeKeys serves the public keys of webhook signatures at WebhookKeysPath, it does not require a token
+func (s *WebhookDeliveryService) ServeKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.broker.notifier == nil || s.broker.notifier.signer == nil {
+		handleError(w, http.StatusNotFound, ErrWebhookSigningNotEnabled)
+		return
+	}
+
+	b, err := json.Marshal(s.broker.notifier.signer.Keys())
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	keyFile := filepath.Join(t.TempDir(), "webhook-key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return keyFile
+}
+
+func TestWebhookSignatures(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyFile := writePEM(t, "PRIVATE KEY", der)
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	// webhooks signed with the signing key alone do not need an API key
+	broker, err := service.NewRoomEventBroker(&config.Config{
+		WebHook: config.WebHookConfig{URLs: []string{receiver.URL}, SigningKeyFile: keyFile},
+	}, auth.NewFileBasedKeyProviderFromMap(map[string]string{}), nil)
+	require.NoError(t, err)
+	svc := service.NewWebhookDeliveryService(broker)
+
+	w := httptest.NewRecorder()
+	svc.ServeKeys(w, httptest.NewRequest(http.MethodGet, service.WebhookKeysPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	keys := &jose.JSONWebKeySet{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), keys))
+	require.Len(t, keys.Keys, 1)
+	require.Equal(t, string(jose.EdDSA), keys.Keys[0].Algorithm)
+
+	require.NoError(t, broker.QueueNotify(context.Background(), &livekit.WebhookEvent{
+		Id:    "EV_started",
+		Event: webhook.EventRoomStarted,
+		Room:  &livekit.Room{Name: "standup"},
+	}))
+	var r *http.Request
+	select {
+	case r = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not received")
+	}
+	body := <-bodies
+	require.Empty(t, r.Header.Get("Authorization"))
+
+	token, err := jwt.ParseSigned(r.Header.Get("X-Livekit-Webhook-Signature"))
+	require.NoError(t, err)
+	require.Len(t, token.Headers, 1)
+	publicKeys := keys.Key(token.Headers[0].KeyID)
+	require.Len(t, publicKeys, 1)
+	var claims jwt.Claims
+	var signed struct {
+		Sha256 string `json:"sha256"`
+	}
+	require.NoError(t, token.Claims(publicKeys[0].Key, &claims, &signed))
+	require.NoError(t, claims.Validate(jwt.Expected{Time: time.Now()}))
+	// the timestamp and nonce of the request are signed with it
+	require.Equal(t, r.Header.Get("X-Livekit-Webhook-Nonce"), claims.ID)
+	require.Equal(t, r.Header.Get("X-Livekit-Webhook-Timestamp"), strconv.FormatInt(claims.IssuedAt.Time().Unix(), 10))
+	require.Empty(t, r.Header.Get("X-Livekit-Webhook-Replay-Signature"))
+	sum := sha256.Sum256(body)
+	require.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), signed.Sha256)
+}
+
+func TestLoadWebhookSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer, err := service.LoadWebhookSigner(writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)))
+	require.NoError(t, err)
+	require.Equal(t, string(jose.RS256), signer.Keys().Keys[0].Algorithm)
+
+	_, err = service.LoadWebhookSigner(writePEM(t, "PRIVATE KEY", []byte("not a key")))
+	require.Error(t, err)
+
+	// webhooks without a signing key are not served keys
+	broker, err := service.NewRoomEventBroker(&config.Config{}, auth.NewFileBasedKeyProviderFromMap(map[string]string{}), nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	service.NewWebhookDeliveryService(broker).ServeKeys(w, httptest.NewRequest(http.MethodGet, service.WebhookKeysPath, nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
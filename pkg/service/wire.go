@@ -31,9 +31,12 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/kafka"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/pkg/telemetry/statsd"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
-	redisLiveKit "github.com/livekit/protocol/redis"
+	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
 	"github.com/livekit/protocol/webhook"
@@ -44,22 +47,49 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	wire.Build(
 		getNodeID,
 		createRedisClient,
+		routing.NewRedisHealth,
 		createStore,
 		wire.Bind(new(ServiceStore), new(ObjectStore)),
 		createKeyProvider,
-		createWebhookNotifier,
+		NewRoomEventBroker,
+		wire.Bind(new(webhook.QueuedNotifier), new(*RoomEventBroker)),
 		createClientConfiguration,
 		routing.CreateRouter,
 		getRoomConf,
 		config.DefaultAPIConfig,
 		wire.Bind(new(routing.MessageRouter), new(routing.Router)),
 		wire.Bind(new(livekit.RoomService), new(*RoomService)),
+		createAnalyticsSink,
 		telemetry.NewAnalyticsService,
+		createStatsReporter,
 		telemetry.NewTelemetryService,
 		getMessageBus,
 		NewIOInfoService,
 		rpc.NewEgressClient,
 		getEgressStore,
+		getParticipantStore,
+		getRoomHistoryStore,
+		NewRoomHistoryService,
+		getRoomTemplateStore,
+		NewRoomTemplateService,
+		getIdempotencyStore,
+		getTokenRevocationStore,
+		NewTokenRevocationService,
+		getKeyUsageStore,
+		NewTURNCredentialsService,
+		NewRoomBatchService,
+		NewParticipantMoveService,
+		NewBreakoutRoomService,
+		NewParticipantRemovalService,
+		NewSubscribedQualityService,
+		NewICERestartService,
+		NewTrackRelayService,
+		NewRoomMuteService,
+		NewRoomLockService,
+		NewParticipantListService,
+		NewParticipantStatsService,
+		NewRoomEventsService,
+		NewWebhookDeliveryService,
 		NewEgressLauncher,
 		NewEgressService,
 		rpc.NewIngressClient,
@@ -68,11 +98,15 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		NewIngressService,
 		NewRoomAllocator,
 		NewRoomService,
+		NewJoinPolicies,
+		NewGeoRestrictions,
 		NewRTCService,
 		getSignalRelayConfig,
 		NewDefaultSignalServer,
 		routing.NewSignalClient,
 		NewLocalRoomManager,
+		NewRoomSnapshotter,
+		NewAuditLog,
 		NewTURNAuthHandler,
 		getTURNAuthHandlerFunc,
 		newInProcessTurnServer,
@@ -85,6 +119,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routing.Router, error) {
 	wire.Build(
 		createRedisClient,
+		routing.NewRedisHealth,
 		getNodeID,
 		getMessageBus,
 		getSignalRelayConfig,
@@ -125,45 +160,112 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
 	}
 
-	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
+	return NewRotatingKeyProvider(conf.Keys), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (*WebhookNotifier, error) {
 	wc := conf.WebHook
 	if len(wc.URLs) == 0 {
 		return nil, nil
 	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+	var signer *WebhookSigner
+	if wc.SigningKeyFile != "" {
+		var err error
+		if signer, err = LoadWebhookSigner(wc.SigningKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	// requests signed with the signing key do not need an API key
+	if wc.APIKey != "" || signer == nil {
+		if provider.GetSecret(wc.APIKey) == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+	}
+
+	return NewWebhookNotifier(wc.APIKey, provider, signer, wc.ReplayWindow, wc.URLs), nil
+}
+
+func createStatsReporter(conf *config.Config, currentNode routing.LocalNode) (telemetry.StatsReporter, error) {
+	reporters := []telemetry.StatsReporter{prometheus.StatsReporter{}}
+	if conf.Telemetry.StatsD.Enabled {
+		reporter, err := statsd.NewStatsReporter(conf.Telemetry.StatsD, currentNode.Id, currentNode.Type, conf.Environment)
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, reporter)
 	}
+	return telemetry.NewMultiStatsReporter(reporters...), nil
+}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+func createAnalyticsSink(conf *config.Config) (telemetry.AnalyticsSink, error) {
+	if !conf.Telemetry.Kafka.Enabled {
+		return nil, nil
+	}
+	return kafka.NewAnalyticsSink(conf.Telemetry.Kafka)
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.Redis.IsConfigured() {
 		return nil, nil
 	}
-	return redisLiveKit.GetRedisClient(&conf.Redis)
+	return NewRedisClient(&conf.Redis)
+}
+
+func createStore(conf *config.Config, rc redis.UniversalClient, health *routing.RedisHealth) (ObjectStore, error) {
+	store, err := selectStore(conf, rc)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Store.Encryption.IsConfigured() {
+		if store, err = newEncryptedStore(store, conf.Store.Encryption); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := unwrapStore(store).(*RedisStore); ok && health != nil {
+		store = newDegradedStore(store, health)
+	}
+	instrumented := newInstrumentedStore(store, conf.Store.SlowOperationThreshold)
+	if !conf.Store.Cache.IsConfigured() {
+		return instrumented, nil
+	}
+	cached, err := newCachedStore(instrumented, conf.Store.Cache, newPrefixedPubSubClient(rc, conf.Redis.KeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return cached, nil
 }
 
-func createStore(rc redis.UniversalClient) ObjectStore {
+func selectStore(conf *config.Config, rc redis.UniversalClient) (ObjectStore, error) {
+	if conf.Store.URL != "" {
+		return NewStoreFromURL(conf.Store.URL, conf, rc)
+	}
+	if conf.Store.Postgres.IsConfigured() {
+		return NewPostgresStore(conf.Store.Postgres)
+	}
+	if conf.Store.Etcd.IsConfigured() {
+		return NewEtcdStore(conf.Store.Etcd)
+	}
+	if conf.Store.DynamoDB.IsConfigured() {
+		return NewDynamoDBStore(conf.Store.DynamoDB)
+	}
+	if conf.Store.MongoDB.IsConfigured() {
+		return NewMongoDBStore(conf.Store.MongoDB)
+	}
 	if rc != nil {
-		return NewRedisStore(rc)
+		return NewRedisStore(rc, conf.Redis), nil
 	}
-	return NewLocalStore()
+	return NewLocalStore(), nil
 }
 
-func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
+func getMessageBus(rc redis.UniversalClient, conf *config.Config) psrpc.MessageBus {
 	if rc == nil {
 		return psrpc.NewLocalMessageBus()
 	}
-	return psrpc.NewRedisMessageBus(rc)
+	return psrpc.NewRedisMessageBus(newPrefixedPubSubClient(rc, conf.Redis.KeyPrefix))
 }
 
 func getEgressStore(s ObjectStore) EgressStore {
-	switch store := s.(type) {
+	switch store := unwrapStore(s).(type) {
 	case *RedisStore:
 		return store
 	default:
@@ -172,10 +274,91 @@ func getEgressStore(s ObjectStore) EgressStore {
 }
 
 func getIngressStore(s ObjectStore) IngressStore {
-	switch store := s.(type) {
+	switch store := unwrapStore(s).(type) {
+	case *RedisStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getParticipantStore(s ObjectStore) ParticipantStore {
+	switch store := unwrapStore(s).(type) {
+	case *RedisStore:
+		return instrumentParticipantStore(s, encryptParticipantStore(s, store))
+	case *PostgresStore:
+		return instrumentParticipantStore(s, encryptParticipantStore(s, store))
+	default:
+		return nil
+	}
+}
+
+func getRoomHistoryStore(conf *config.Config, s ObjectStore) RoomHistoryStore {
+	if conf.Store.HistoryRetention <= 0 {
+		return nil
+	}
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return instrumentRoomHistoryStore(s, encryptRoomHistoryStore(s, store))
+	case *RedisStore:
+		return instrumentRoomHistoryStore(s, encryptRoomHistoryStore(s, store))
+	case *PostgresStore:
+		return instrumentRoomHistoryStore(s, encryptRoomHistoryStore(s, store))
+	default:
+		return nil
+	}
+}
+func getRoomTemplateStore(s ObjectStore) RoomTemplateStore {
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
+	case *RedisStore:
+		return store
+	case *PostgresStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getIdempotencyStore(conf *config.Config, s ObjectStore) IdempotencyStore {
+	if conf.Store.IdempotencyWindow <= 0 {
+		return nil
+	}
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
+	case *RedisStore:
+		return store
+	case *PostgresStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getTokenRevocationStore(s ObjectStore) TokenRevocationStore {
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
+	case *RedisStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getKeyUsageStore(conf *config.Config, s ObjectStore) KeyUsageStore {
+	if len(conf.KeyQuotas) == 0 {
+		return nil
+	}
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
 	case *RedisStore:
 		return store
 	default:
+		logger.Warnw("key quotas are not enforced, they require the local or redis store", nil)
 		return nil
 	}
 }
@@ -12,12 +12,14 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/kafka"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/pkg/telemetry/statsd"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
-	redis2 "github.com/livekit/protocol/redis"
+	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
-	"github.com/livekit/protocol/webhook"
 	"github.com/livekit/psrpc"
 	"github.com/pion/turn/v2"
 	"github.com/pkg/errors"
@@ -40,15 +42,20 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		return nil, err
 	}
 	nodeID := getNodeID(currentNode)
-	messageBus := getMessageBus(universalClient)
+	messageBus := getMessageBus(universalClient, conf)
 	signalRelayConfig := getSignalRelayConfig(conf)
 	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
 	if err != nil {
 		return nil, err
 	}
-	router := routing.CreateRouter(conf, universalClient, currentNode, signalClient)
-	objectStore := createStore(universalClient)
-	roomAllocator, err := NewRoomAllocator(conf, router, objectStore)
+	redisHealth := routing.NewRedisHealth(conf, universalClient)
+	router := routing.CreateRouter(conf, universalClient, redisHealth, currentNode, signalClient)
+	objectStore, err := createStore(conf, universalClient, redisHealth)
+	if err != nil {
+		return nil, err
+	}
+	keyUsageStore := getKeyUsageStore(conf, objectStore)
+	roomAllocator, err := NewRoomAllocator(conf, router, objectStore, keyUsageStore)
 	if err != nil {
 		return nil, err
 	}
@@ -61,18 +68,27 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	queuedNotifier, err := createWebhookNotifier(conf, keyProvider)
+	roomEventBroker, err := NewRoomEventBroker(conf, keyProvider, universalClient)
 	if err != nil {
 		return nil, err
 	}
-	analyticsService := telemetry.NewAnalyticsService(conf, currentNode)
-	telemetryService := telemetry.NewTelemetryService(queuedNotifier, analyticsService)
+	analyticsSink, err := createAnalyticsSink(conf)
+	if err != nil {
+		return nil, err
+	}
+	analyticsService := telemetry.NewAnalyticsService(conf, currentNode, analyticsSink)
+	statsReporter, err := createStatsReporter(conf, currentNode)
+	if err != nil {
+		return nil, err
+	}
+	telemetryService := telemetry.NewTelemetryService(roomEventBroker, analyticsService, statsReporter)
 	rtcEgressLauncher := NewEgressLauncher(egressClient, egressStore, telemetryService)
-	roomService, err := NewRoomService(roomConfig, apiConfig, router, roomAllocator, objectStore, rtcEgressLauncher)
+	roomTemplateStore := getRoomTemplateStore(objectStore)
+	roomService, err := NewRoomService(roomConfig, apiConfig, router, roomAllocator, objectStore, roomTemplateStore, rtcEgressLauncher)
 	if err != nil {
 		return nil, err
 	}
-	egressService := NewEgressService(egressClient, objectStore, egressStore, roomService, telemetryService, rtcEgressLauncher)
+	egressService := NewEgressService(conf, egressClient, objectStore, egressStore, keyUsageStore, roomService, telemetryService, rtcEgressLauncher)
 	ingressConfig := getIngressConfig(conf)
 	ingressClient, err := rpc.NewIngressClient(nodeID, messageBus)
 	if err != nil {
@@ -84,11 +100,22 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, telemetryService)
+	tokenRevocationStore := getTokenRevocationStore(objectStore)
+	joinPolicies, err := NewJoinPolicies(conf)
+	if err != nil {
+		return nil, err
+	}
+	geoRestrictions, err := NewGeoRestrictions(conf)
+	if err != nil {
+		return nil, err
+	}
+	rtcService := NewRTCService(conf, roomAllocator, objectStore, keyUsageStore, joinPolicies, geoRestrictions, router, currentNode, telemetryService)
 	clientConfigurationManager := createClientConfiguration()
 	timedVersionGenerator := utils.NewDefaultTimedVersionGenerator()
 	turnAuthHandler := NewTURNAuthHandler(keyProvider)
-	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, telemetryService, clientConfigurationManager, rtcEgressLauncher, timedVersionGenerator, turnAuthHandler)
+	participantStore := getParticipantStore(objectStore)
+	roomHistoryStore := getRoomHistoryStore(conf, objectStore)
+	roomManager, err := NewLocalRoomManager(conf, objectStore, participantStore, roomHistoryStore, currentNode, router, telemetryService, clientConfigurationManager, rtcEgressLauncher, timedVersionGenerator, turnAuthHandler, keyProvider)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +128,33 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	livekitServer, err := NewLivekitServer(conf, roomService, egressService, ingressService, ioInfoService, rtcService, keyProvider, router, roomManager, signalServer, server, currentNode)
+	roomHistoryService := NewRoomHistoryService(roomHistoryStore)
+	roomTemplateService := NewRoomTemplateService(roomTemplateStore)
+	roomBatchService := NewRoomBatchService(roomService, objectStore)
+	participantMoveService := NewParticipantMoveService(roomService, router, objectStore)
+	breakoutRoomService := NewBreakoutRoomService(roomService, roomBatchService, participantMoveService, router, objectStore)
+	participantRemovalService := NewParticipantRemovalService(roomService, router, objectStore)
+	subscribedQualityService := NewSubscribedQualityService(router, objectStore)
+	iceRestartService := NewICERestartService(router, objectStore)
+	trackRelayService := NewTrackRelayService(router, objectStore)
+	roomMuteService := NewRoomMuteService(router, objectStore)
+	roomLockService := NewRoomLockService(objectStore, telemetryService)
+	participantListService := NewParticipantListService(objectStore)
+	participantStatsService := NewParticipantStatsService(apiConfig, router, roomManager, currentNode, telemetryService, universalClient)
+	roomEventsService := NewRoomEventsService(roomEventBroker)
+	webhookDeliveryService := NewWebhookDeliveryService(roomEventBroker)
+	tokenRevocationService := NewTokenRevocationService(tokenRevocationStore)
+	turnCredentialsService := NewTURNCredentialsService(conf)
+	idempotencyStore := getIdempotencyStore(conf, objectStore)
+	roomSnapshotter, err := NewRoomSnapshotter(conf, objectStore, router, currentNode, roomManager)
+	if err != nil {
+		return nil, err
+	}
+	auditLog, err := NewAuditLog(conf, keyProvider, universalClient, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	livekitServer, err := NewLivekitServer(conf, roomService, idempotencyStore, roomHistoryService, roomTemplateService, roomBatchService, participantMoveService, breakoutRoomService, participantRemovalService, subscribedQualityService, iceRestartService, trackRelayService, roomMuteService, roomLockService, participantListService, participantStatsService, roomEventsService, webhookDeliveryService, tokenRevocationService, turnCredentialsService, auditLog, egressService, ingressService, ioInfoService, rtcService, keyProvider, router, roomManager, roomSnapshotter, signalServer, server, currentNode, analyticsService, statsReporter, analyticsSink)
 	if err != nil {
 		return nil, err
 	}
@@ -114,13 +167,14 @@ func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routi
 		return nil, err
 	}
 	nodeID := getNodeID(currentNode)
-	messageBus := getMessageBus(universalClient)
+	messageBus := getMessageBus(universalClient, conf)
 	signalRelayConfig := getSignalRelayConfig(conf)
 	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
 	if err != nil {
 		return nil, err
 	}
-	router := routing.CreateRouter(conf, universalClient, currentNode, signalClient)
+	redisHealth := routing.NewRedisHealth(conf, universalClient)
+	router := routing.CreateRouter(conf, universalClient, redisHealth, currentNode, signalClient)
 	return router, nil
 }
 
@@ -156,45 +210,112 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
 	}
 
-	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
+	return NewRotatingKeyProvider(conf.Keys), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (*WebhookNotifier, error) {
 	wc := conf.WebHook
 	if len(wc.URLs) == 0 {
 		return nil, nil
 	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+	var signer *WebhookSigner
+	if wc.SigningKeyFile != "" {
+		var err error
+		if signer, err = LoadWebhookSigner(wc.SigningKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	// requests signed with the signing key do not need an API key
+	if wc.APIKey != "" || signer == nil {
+		if provider.GetSecret(wc.APIKey) == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+	}
+
+	return NewWebhookNotifier(wc.APIKey, provider, signer, wc.ReplayWindow, wc.URLs), nil
+}
+
+func createStatsReporter(conf *config.Config, currentNode routing.LocalNode) (telemetry.StatsReporter, error) {
+	reporters := []telemetry.StatsReporter{prometheus.StatsReporter{}}
+	if conf.Telemetry.StatsD.Enabled {
+		reporter, err := statsd.NewStatsReporter(conf.Telemetry.StatsD, currentNode.Id, currentNode.Type, conf.Environment)
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, reporter)
 	}
+	return telemetry.NewMultiStatsReporter(reporters...), nil
+}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+func createAnalyticsSink(conf *config.Config) (telemetry.AnalyticsSink, error) {
+	if !conf.Telemetry.Kafka.Enabled {
+		return nil, nil
+	}
+	return kafka.NewAnalyticsSink(conf.Telemetry.Kafka)
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.Redis.IsConfigured() {
 		return nil, nil
 	}
-	return redis2.GetRedisClient(&conf.Redis)
+	return NewRedisClient(&conf.Redis)
+}
+
+func createStore(conf *config.Config, rc redis.UniversalClient, health *routing.RedisHealth) (ObjectStore, error) {
+	store, err := selectStore(conf, rc)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Store.Encryption.IsConfigured() {
+		if store, err = newEncryptedStore(store, conf.Store.Encryption); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := unwrapStore(store).(*RedisStore); ok && health != nil {
+		store = newDegradedStore(store, health)
+	}
+	instrumented := newInstrumentedStore(store, conf.Store.SlowOperationThreshold)
+	if !conf.Store.Cache.IsConfigured() {
+		return instrumented, nil
+	}
+	cached, err := newCachedStore(instrumented, conf.Store.Cache, newPrefixedPubSubClient(rc, conf.Redis.KeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return cached, nil
 }
 
-func createStore(rc redis.UniversalClient) ObjectStore {
+func selectStore(conf *config.Config, rc redis.UniversalClient) (ObjectStore, error) {
+	if conf.Store.URL != "" {
+		return NewStoreFromURL(conf.Store.URL, conf, rc)
+	}
+	if conf.Store.Postgres.IsConfigured() {
+		return NewPostgresStore(conf.Store.Postgres)
+	}
+	if conf.Store.Etcd.IsConfigured() {
+		return NewEtcdStore(conf.Store.Etcd)
+	}
+	if conf.Store.DynamoDB.IsConfigured() {
+		return NewDynamoDBStore(conf.Store.DynamoDB)
+	}
+	if conf.Store.MongoDB.IsConfigured() {
+		return NewMongoDBStore(conf.Store.MongoDB)
+	}
 	if rc != nil {
-		return NewRedisStore(rc)
+		return NewRedisStore(rc, conf.Redis), nil
 	}
-	return NewLocalStore()
+	return NewLocalStore(), nil
 }
 
-func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
+func getMessageBus(rc redis.UniversalClient, conf *config.Config) psrpc.MessageBus {
 	if rc == nil {
 		return psrpc.NewLocalMessageBus()
 	}
-	return psrpc.NewRedisMessageBus(rc)
+	return psrpc.NewRedisMessageBus(newPrefixedPubSubClient(rc, conf.Redis.KeyPrefix))
 }
 
 func getEgressStore(s ObjectStore) EgressStore {
-	switch store := s.(type) {
+	switch store := unwrapStore(s).(type) {
 	case *RedisStore:
 		return store
 	default:
@@ -203,10 +324,91 @@ func getEgressStore(s ObjectStore) EgressStore {
 }
 
 func getIngressStore(s ObjectStore) IngressStore {
-	switch store := s.(type) {
+	switch store := unwrapStore(s).(type) {
+	case *RedisStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getParticipantStore(s ObjectStore) ParticipantStore {
+	switch store := unwrapStore(s).(type) {
+	case *RedisStore:
+		return instrumentParticipantStore(s, encryptParticipantStore(s, store))
+	case *PostgresStore:
+		return instrumentParticipantStore(s, encryptParticipantStore(s, store))
+	default:
+		return nil
+	}
+}
+
+func getRoomHistoryStore(conf *config.Config, s ObjectStore) RoomHistoryStore {
+	if conf.Store.HistoryRetention <= 0 {
+		return nil
+	}
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return instrumentRoomHistoryStore(s, encryptRoomHistoryStore(s, store))
+	case *RedisStore:
+		return instrumentRoomHistoryStore(s, encryptRoomHistoryStore(s, store))
+	case *PostgresStore:
+		return instrumentRoomHistoryStore(s, encryptRoomHistoryStore(s, store))
+	default:
+		return nil
+	}
+}
+func getRoomTemplateStore(s ObjectStore) RoomTemplateStore {
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
+	case *RedisStore:
+		return store
+	case *PostgresStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getIdempotencyStore(conf *config.Config, s ObjectStore) IdempotencyStore {
+	if conf.Store.IdempotencyWindow <= 0 {
+		return nil
+	}
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
+	case *RedisStore:
+		return store
+	case *PostgresStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getTokenRevocationStore(s ObjectStore) TokenRevocationStore {
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
+	case *RedisStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getKeyUsageStore(conf *config.Config, s ObjectStore) KeyUsageStore {
+	if len(conf.KeyQuotas) == 0 {
+		return nil
+	}
+	switch store := unwrapStore(s).(type) {
+	case *LocalStore:
+		return store
 	case *RedisStore:
 		return store
 	default:
+		logger.Warnw("key quotas are not enforced, they require the local or redis store", nil)
 		return nil
 	}
 }
@@ -37,6 +37,8 @@ type WSSignalConnection struct {
 	conn    types.WebsocketClient
 	mu      sync.Mutex
 	useJSON bool
+
+	onTokenRefresh func(token string)
 }
 
 func NewWSSignalConnection(conn types.WebsocketClient) *WSSignalConnection {
@@ -70,6 +72,14 @@ func (c *WSSignalConnection) ReadRequest() (*livekit.SignalRequest, int, error)
 			err := proto.Unmarshal(payload, msg)
 			return msg, len(payload), err
 		case websocket.TextMessage:
+			// token refreshes are not signal requests, they do not switch the connection to JSON. only connections
+			// of clients that support token refresh send them
+			if c.handlesTokenRefresh() {
+				if token, ok := parseTokenRefresh(payload); ok {
+					c.handleTokenRefresh(token)
+					continue
+				}
+			}
 			c.mu.Lock()
 			// json encoded, also write back JSON
 			c.useJSON = true
@@ -124,6 +124,9 @@ type Buffer struct {
 
 	packetNotFoundCount atomic.Uint32
 	packetTooOldCount   atomic.Uint32
+
+	// counts of the factory the buffer belongs to, if any
+	traffic *TrafficCounter
 }
 
 // NewBuffer constructs a new Buffer
@@ -276,6 +279,11 @@ func (b *Buffer) Write(pkt []byte) (n int, err error) {
 		return
 	}
 
+	if b.traffic != nil {
+		b.traffic.bytes.Add(uint64(len(pkt)))
+		b.traffic.packets.Inc()
+	}
+
 	if !b.bound {
 		packet := make([]byte, len(pkt))
 		copy(packet, pkt)
@@ -423,6 +431,9 @@ func (b *Buffer) calc(pkt []byte, arrivalTime time.Time) {
 	var rtpPacket rtp.Packet
 	if err := rtpPacket.Unmarshal(pkt); err != nil {
 		b.logger.Errorw("could not unmarshal RTP packet", err)
+		if b.traffic != nil {
+			b.traffic.malformed.Inc()
+		}
 		return
 	}
 
@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	"github.com/pion/transport/v2/packetio"
+	"go.uber.org/atomic"
 
 	"github.com/livekit/mediatransportutil/pkg/bucket"
 )
@@ -60,6 +61,20 @@ type Factory struct {
 	audioPool   *sync.Pool
 	rtpBuffers  map[uint32]*Buffer
 	rtcpReaders map[uint32]*RTCPReader
+	traffic     TrafficCounter
+}
+
+// TrafficCounter counts the RTP packets written to the buffers of a factory, that is the media received from a
+// publisher
+type TrafficCounter struct {
+	bytes     atomic.Uint64
+	packets   atomic.Uint64
+	malformed atomic.Uint64
+}
+
+// Load returns the bytes and packets received, and how many of the packets could not be parsed
+func (c *TrafficCounter) Load() (bytes uint64, packets uint64, malformed uint64) {
+	return c.bytes.Load(), c.packets.Load(), c.malformed.Load()
 }
 
 func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
@@ -83,6 +98,7 @@ func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io
 			return reader
 		}
 		buffer := NewBuffer(ssrc, f.videoPool, f.audioPool)
+		buffer.traffic = &f.traffic
 		f.rtpBuffers[ssrc] = buffer
 		buffer.OnClose(func() {
 			f.Lock()
@@ -111,3 +127,15 @@ func (f *Factory) GetRTCPReader(ssrc uint32) *RTCPReader {
 	defer f.RUnlock()
 	return f.rtcpReaders[ssrc]
 }
+
+// Traffic returns the counts of the RTP packets received by the buffers of the factory
+func (f *Factory) Traffic() *TrafficCounter {
+	return &f.traffic
+}
+
+// NumRTPStreams returns the number of RTP streams received at the moment
+func (f *Factory) NumRTPStreams() int {
+	f.RLock()
+	defer f.RUnlock()
+	return len(f.rtpBuffers)
+}
@@ -276,6 +276,9 @@ type DownTrack struct {
 	onMaxSubscribedLayerChanged func(dt *DownTrack, layer int32)
 	onRttUpdate                 func(dt *DownTrack, rtt uint32)
 	onCloseHandler              func(willBeResumed bool)
+	onFirstPacketSent           func(dt *DownTrack)
+
+	firstPacketSent atomic.Bool
 }
 
 // NewDownTrack returns a DownTrack.
@@ -743,6 +746,12 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		Pool:               PacketFactory,
 		PoolEntity:         poolEntity,
 	})
+
+	if !d.firstPacketSent.Load() && !d.firstPacketSent.Swap(true) {
+		if onFirstPacketSent := d.getOnFirstPacketSent(); onFirstPacketSent != nil {
+			onFirstPacketSent(d)
+		}
+	}
 	return nil
 }
 
@@ -1012,6 +1021,14 @@ func (d *DownTrack) MaxLayer() buffer.VideoLayer {
 	return d.forwarder.MaxLayer()
 }
 
+func (d *DownTrack) CurrentLayer() buffer.VideoLayer {
+	return d.forwarder.CurrentLayer()
+}
+
+func (d *DownTrack) TargetLayer() buffer.VideoLayer {
+	return d.forwarder.TargetLayer()
+}
+
 func (d *DownTrack) GetState() DownTrackState {
 	dts := DownTrackState{
 		RTPStats:                   d.rtpStats,
@@ -1133,6 +1150,21 @@ func (d *DownTrack) getOnRttUpdate() func(dt *DownTrack, rtt uint32) {
 	return d.onRttUpdate
 }
 
+// OnFirstPacketSent is called once, when the first media packet is forwarded on the DownTrack
+func (d *DownTrack) OnFirstPacketSent(fn func(dt *DownTrack)) {
+	d.cbMu.Lock()
+	defer d.cbMu.Unlock()
+
+	d.onFirstPacketSent = fn
+}
+
+func (d *DownTrack) getOnFirstPacketSent() func(dt *DownTrack) {
+	d.cbMu.RLock()
+	defer d.cbMu.RUnlock()
+
+	return d.onFirstPacketSent
+}
+
 func (d *DownTrack) OnMaxLayerChanged(fn func(dt *DownTrack, layer int32)) {
 	d.cbMu.Lock()
 	defer d.cbMu.Unlock()
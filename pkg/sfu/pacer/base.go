@@ -19,8 +19,11 @@ import (
 	"io"
 	"time"
 
+	"github.com/gammazero/deque"
 	"github.com/livekit/protocol/logger"
 	"github.com/pion/rtp"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 type Base struct {
@@ -43,11 +46,7 @@ func (b *Base) SetBitrate(_bitrate int) {
 }
 
 func (b *Base) SendPacket(p *Packet) (int, error) {
-	defer func() {
-		if p.Pool != nil && p.PoolEntity != nil {
-			p.Pool.Put(p.PoolEntity)
-		}
-	}()
+	defer releasePacket(p)
 
 	_, err := b.writeRTPHeaderExtensions(p)
 	if err != nil {
@@ -99,4 +98,24 @@ func (b *Base) writeRTPHeaderExtensions(p *Packet) (time.Time, error) {
 	return sendingAt, nil
 }
 
+// drainQueue discards the packets left in a queue, caller must hold the queue lock
+func drainQueue(packets *deque.Deque[Packet]) {
+	for packets.Len() != 0 {
+		p := packets.PopFront()
+		prometheus.AddPacerQueued(-1, -len(p.Payload))
+		releasePacket(&p)
+	}
+}
+
+func (b *Base) DropPacket(p *Packet) {
+	releasePacket(p)
+	prometheus.IncrementPacerDropped()
+}
+
+func releasePacket(p *Packet) {
+	if p.Pool != nil && p.PoolEntity != nil {
+		p.Pool.Put(p.PoolEntity)
+	}
+}
+
 // ------------------------------------------------
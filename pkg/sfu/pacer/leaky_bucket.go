@@ -20,6 +20,8 @@ import (
 
 	"github.com/gammazero/deque"
 	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -73,6 +75,7 @@ func (l *LeakyBucket) Stop() {
 	}
 
 	l.isStopped = true
+	drainQueue(&l.packets)
 	l.lock.Unlock()
 }
 
@@ -80,9 +83,16 @@ func (l *LeakyBucket) Enqueue(p Packet) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	if !l.isStopped {
-		l.packets.PushBack(p)
+	if l.isStopped {
+		return
+	}
+
+	if l.packets.Len() >= MaxQueuedPackets {
+		l.Base.DropPacket(&p)
+		return
 	}
+	l.packets.PushBack(p)
+	prometheus.AddPacerQueued(1, len(p.Payload))
 }
 
 func (l *LeakyBucket) sendWorker() {
@@ -135,6 +145,7 @@ func (l *LeakyBucket) sendWorker() {
 			}
 			p := l.packets.PopFront()
 			l.lock.Unlock()
+			prometheus.AddPacerQueued(-1, -len(p.Payload))
 
 			written, _ := l.Base.SendPacket(&p)
 			toSendBytes -= written
@@ -19,6 +19,8 @@ import (
 
 	"github.com/gammazero/deque"
 	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 type NoQueue struct {
@@ -53,6 +55,7 @@ func (n *NoQueue) Stop() {
 
 	close(n.wake)
 	n.isStopped = true
+	drainQueue(&n.packets)
 	n.lock.Unlock()
 }
 
@@ -60,8 +63,17 @@ func (n *NoQueue) Enqueue(p Packet) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
 
+	if n.isStopped {
+		return
+	}
+
+	if n.packets.Len() >= MaxQueuedPackets {
+		n.Base.DropPacket(&p)
+		return
+	}
 	n.packets.PushBack(p)
-	if n.packets.Len() == 1 && !n.isStopped {
+	prometheus.AddPacerQueued(1, len(p.Payload))
+	if n.packets.Len() == 1 {
 		select {
 		case n.wake <- struct{}{}:
 		default:
@@ -85,6 +97,7 @@ func (n *NoQueue) sendWorker() {
 			}
 			p := n.packets.PopFront()
 			n.lock.Unlock()
+			prometheus.AddPacerQueued(-1, -len(p.Payload))
 
 			n.Base.SendPacket(&p)
 		}
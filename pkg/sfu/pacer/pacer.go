@@ -38,6 +38,9 @@ type Packet struct {
 	PoolEntity         *[]byte
 }
 
+// MaxQueuedPackets bounds the send queue of queueing pacers, packets beyond that are dropped
+const MaxQueuedPackets = 2048
+
 type Pacer interface {
 	Enqueue(p Packet)
 	Stop()
@@ -0,0 +1,70 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"testing"
+	"time"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+func TestLeakyBucketQueueDepth(t *testing.T) {
+	registry := promclient.NewRegistry()
+	prometheus.Reset()
+	prometheus.InitWithRegisterer(registry, "node", livekit.NodeType_SERVER, "test", config.TelemetryConfig{})
+	t.Cleanup(prometheus.Reset)
+
+	// packets are not sent within the test, they stay queued until the pacer is stopped
+	l := NewLeakyBucket(logger.GetLogger(), time.Hour, 1_000_000)
+	for i := 0; i < MaxQueuedPackets+1; i++ {
+		l.Enqueue(Packet{Payload: make([]byte, 10)})
+	}
+	require.Equal(t, float64(MaxQueuedPackets), gatheredValue(t, registry, "livekit_pacer_queued_packets"))
+	require.Equal(t, float64(MaxQueuedPackets*10), gatheredValue(t, registry, "livekit_pacer_backlog_bytes"))
+	require.Equal(t, float64(1), gatheredValue(t, registry, "livekit_pacer_dropped_packets_total"))
+
+	l.Stop()
+	require.Zero(t, gatheredValue(t, registry, "livekit_pacer_queued_packets"))
+	require.Zero(t, gatheredValue(t, registry, "livekit_pacer_backlog_bytes"))
+
+	// packets of a stopped pacer are not queued
+	l.Enqueue(Packet{Payload: make([]byte, 10)})
+	require.Zero(t, gatheredValue(t, registry, "livekit_pacer_queued_packets"))
+}
+
+func gatheredValue(t *testing.T, registry *promclient.Registry, name string) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		metric := family.GetMetric()[0]
+		if metric.GetGauge() != nil {
+			return metric.GetGauge().GetValue()
+		}
+		return metric.GetCounter().GetValue()
+	}
+	t.Fatalf("metric %s not gathered", name)
+	return 0
+}
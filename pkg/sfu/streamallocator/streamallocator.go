@@ -31,6 +31,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -279,6 +280,21 @@ func (s *StreamAllocator) SetTrackPriority(downTrack *sfu.DownTrack, priority ui
 	s.videoTracksMu.Unlock()
 }
 
+func (s *StreamAllocator) SetTrackPinned(downTrack *sfu.DownTrack, pinned bool) {
+	s.videoTracksMu.Lock()
+	if track := s.videoTracks[livekit.TrackID(downTrack.ID())]; track != nil {
+		changed := track.SetPinned(pinned)
+		if changed && !s.isAllocateAllPending {
+			// pinning moves the track in or out of the managed set, re-allocate everything
+			s.isAllocateAllPending = true
+			s.postEvent(Event{
+				Signal: streamAllocatorSignalAllocateAllTracks,
+			})
+		}
+	}
+	s.videoTracksMu.Unlock()
+}
+
 func (s *StreamAllocator) SetAllowPause(allowPause bool) {
 	s.postEvent(Event{
 		Signal: streamAllocatorSignalSetAllowPause,
@@ -570,6 +586,12 @@ func (s *StreamAllocator) processEvents() {
 	}
 
 	s.probeController.StopProbe()
+
+	// remove this subscriber from node congestion stats
+	prometheus.AddEstimatedBandwidth(-s.lastReceivedEstimate)
+	if s.state == streamAllocatorStateDeficient {
+		prometheus.SubDeficientSubscriber()
+	}
 }
 
 func (s *StreamAllocator) ping() {
@@ -646,6 +668,7 @@ func (s *StreamAllocator) handleSignalAdjustState(event *Event) {
 
 func (s *StreamAllocator) handleSignalEstimate(event *Event) {
 	receivedEstimate, _ := event.Data.(int64)
+	prometheus.AddEstimatedBandwidth(receivedEstimate - s.lastReceivedEstimate)
 	s.lastReceivedEstimate = receivedEstimate
 	s.monitorRate(receivedEstimate)
 
@@ -762,6 +785,11 @@ func (s *StreamAllocator) setState(state streamAllocatorState) {
 
 	s.params.Logger.Infow("stream allocator: state change", "from", s.state, "to", state)
 	s.state = state
+	if state == streamAllocatorStateDeficient {
+		prometheus.AddDeficientSubscriber()
+	} else {
+		prometheus.SubDeficientSubscriber()
+	}
 
 	// reset probe to enforce a delay after state change before probing
 	s.probeController.Reset()
@@ -1480,6 +1508,10 @@ func updateStreamStateChange(track *Track, allocation sfu.VideoAllocation, updat
 		updated = track.SetStreamState(streamState)
 	}
 
+	if track.SetTargetLayer(allocation) {
+		prometheus.IncrementLayerDowngrade()
+	}
+
 	if updated {
 		update.HandleStreamingChange(track, streamState)
 	}
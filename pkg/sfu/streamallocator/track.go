@@ -33,10 +33,12 @@ type Track struct {
 	source      livekit.TrackSource
 	isSimulcast bool
 	priority    uint8
+	pinned      bool
 	publisherID livekit.ParticipantID
 	logger      logger.Logger
 
-	maxLayer buffer.VideoLayer
+	maxLayer    buffer.VideoLayer
+	targetLayer buffer.VideoLayer
 
 	totalPackets       uint32
 	totalRepeatedNacks uint32
@@ -76,6 +78,7 @@ func NewTrack(
 		nackHistory:           make([]string, 0, 10),
 		receiverReportHistory: make([]string, 0, 10),
 		streamState:           StreamStateInactive,
+		targetLayer:           buffer.InvalidLayer,
 	}
 	t.SetPriority(0)
 	t.SetMaxLayer(downTrack.MaxLayer())
@@ -101,6 +104,21 @@ func (t *Track) SetStreamState(streamState StreamState) bool {
 	return true
 }
 
+// SetTargetLayer records the target layer of a committed allocation and
+// returns true if the allocation lowered or paused the track for lack of bandwidth.
+func (t *Track) SetTargetLayer(allocation sfu.VideoAllocation) bool {
+	prev := t.targetLayer
+	t.targetLayer = allocation.TargetLayer
+	if !prev.IsValid() {
+		return false
+	}
+
+	if allocation.PauseReason == sfu.VideoPauseReasonBandwidth {
+		return true
+	}
+	return allocation.IsDeficient && prev.GreaterThan(allocation.TargetLayer)
+}
+
 func (t *Track) IsSubscribeMutable() bool {
 	return t.streamState != StreamStatePaused
 }
@@ -127,12 +145,27 @@ func (t *Track) Priority() uint8 {
 	return t.priority
 }
 
+// SetPinned takes a track out of (or returns it to) allocation,
+// a pinned track is always given its optimal allocation
+func (t *Track) SetPinned(pinned bool) bool {
+	if t.pinned == pinned {
+		return false
+	}
+
+	t.pinned = pinned
+	return true
+}
+
+func (t *Track) IsPinned() bool {
+	return t.pinned
+}
+
 func (t *Track) DownTrack() *sfu.DownTrack {
 	return t.downTrack
 }
 
 func (t *Track) IsManaged() bool {
-	return t.source != livekit.TrackSource_SCREEN_SHARE || t.isSimulcast
+	return !t.pinned && (t.source != livekit.TrackSource_SCREEN_SHARE || t.isSimulcast)
 }
 
 func (t *Track) ID() livekit.TrackID {
@@ -16,12 +16,17 @@ package telemetry
 
 import (
 	"context"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . AnalyticsService
@@ -30,44 +35,206 @@ type AnalyticsService interface {
 	SendEvent(ctx context.Context, events *livekit.AnalyticsEvent)
 }
 
+// AnalyticsSink receives analytics stats and events in addition to the analytics recorder service
+type AnalyticsSink interface {
+	SendStats(ctx context.Context, stats []*livekit.AnalyticsStat)
+	SendEvent(ctx context.Context, event *livekit.AnalyticsEvent)
+}
+
 type analyticsService struct {
-	analyticsKey string
-	nodeID       string
+	analyticsKey   string
+	nodeID         string
+	conf           config.AnalyticsConfig
+	disabledEvents map[livekit.AnalyticsEventType]bool
 
 	events livekit.AnalyticsRecorderService_IngestEventsClient
 	stats  livekit.AnalyticsRecorderService_IngestStatsClient
+	sink   AnalyticsSink
+
+	// participant stats updates waiting to be sent as one batch
+	batchLock    sync.Mutex
+	batch        []*livekit.AnalyticsStat
+	batchUpdates int
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
 }
 
-func NewAnalyticsService(_ *config.Config, currentNode routing.LocalNode) AnalyticsService {
-	return &analyticsService{
-		analyticsKey: "", // TODO: conf.AnalyticsKey
-		nodeID:       currentNode.Id,
+// NewAnalyticsService creates the analytics service, sink is optional
+func NewAnalyticsService(conf *config.Config, currentNode routing.LocalNode, sink AnalyticsSink) AnalyticsService {
+	a := &analyticsService{
+		analyticsKey:   "", // TODO: conf.AnalyticsKey
+		nodeID:         currentNode.Id,
+		conf:           conf.Telemetry.Analytics,
+		disabledEvents: make(map[livekit.AnalyticsEventType]bool),
+		sink:           sink,
+		done:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	for _, event := range conf.Telemetry.Analytics.DisabledEvents {
+		if eventType, ok := livekit.AnalyticsEventType_value[strings.ToUpper(event)]; ok {
+			a.disabledEvents[livekit.AnalyticsEventType(eventType)] = true
+		}
 	}
+
+	if a.isBatching() {
+		go a.batchWorker()
+	} else {
+		close(a.stopped)
+	}
+
+	return a
+}
+
+// Close stops the batch worker and sends the stats left in the batch
+func (a *analyticsService) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.done)
+		<-a.stopped
+
+		a.batchLock.Lock()
+		batch := a.takeBatchLocked()
+		a.batchLock.Unlock()
+
+		if len(batch) != 0 {
+			a.sendStats(context.Background(), batch)
+		}
+	})
+	return nil
 }
 
-func (a *analyticsService) SendStats(_ context.Context, stats []*livekit.AnalyticsStat) {
-	if a.stats == nil {
+func (a *analyticsService) SendStats(ctx context.Context, stats []*livekit.AnalyticsStat) {
+	if a.stats == nil && a.sink == nil {
 		return
 	}
 
+	stats = a.sampleStats(stats)
+	if len(stats) == 0 {
+		return
+	}
+
+	if !a.isBatching() {
+		a.sendStats(ctx, stats)
+		return
+	}
+
+	a.batchLock.Lock()
+	a.batch = append(a.batch, stats...)
+	a.batchUpdates++
+	if a.batchUpdates < a.conf.BatchSize {
+		a.batchLock.Unlock()
+		return
+	}
+	batch := a.takeBatchLocked()
+	a.batchLock.Unlock()
+
+	a.sendStats(ctx, batch)
+}
+
+func (a *analyticsService) sendStats(ctx context.Context, stats []*livekit.AnalyticsStat) {
 	for _, stat := range stats {
 		stat.AnalyticsKey = a.analyticsKey
 		stat.Node = a.nodeID
 	}
-	if err := a.stats.Send(&livekit.AnalyticsStats{Stats: stats}); err != nil {
-		logger.Errorw("failed to send stats", err)
+	if a.stats != nil {
+		if err := a.stats.Send(&livekit.AnalyticsStats{Stats: stats}); err != nil {
+			logger.Errorw("failed to send stats", err)
+		}
+	}
+	if a.sink != nil {
+		a.sink.SendStats(ctx, stats)
+	}
+}
+
+func (a *analyticsService) isBatching() bool {
+	return a.conf.BatchSize > 1 && a.conf.BatchInterval > 0
+}
+
+// batchWorker sends partial batches, so that updates are not held longer than the batch interval
+func (a *analyticsService) batchWorker() {
+	defer close(a.stopped)
+
+	ticker := time.NewTicker(a.conf.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+		}
+
+		a.batchLock.Lock()
+		batch := a.takeBatchLocked()
+		a.batchLock.Unlock()
+
+		if len(batch) != 0 {
+			a.sendStats(context.Background(), batch)
+		}
+	}
+}
+
+func (a *analyticsService) takeBatchLocked() []*livekit.AnalyticsStat {
+	batch := a.batch
+	a.batch = nil
+	a.batchUpdates = 0
+	return batch
+}
+
+// sampleStats drops the stats of rooms that are not sampled, whole rooms are kept or dropped
+// so that the stats of a sampled room are complete
+func (a *analyticsService) sampleStats(stats []*livekit.AnalyticsStat) []*livekit.AnalyticsStat {
+	if a.conf.Sampling.RoomThreshold <= 0 {
+		return stats
 	}
+
+	rate := samplingRate(a.conf.Sampling, int(prometheus.GetStatsSnapshot().Rooms))
+	if rate >= 1 {
+		return stats
+	}
+
+	sampled := make([]*livekit.AnalyticsStat, 0, len(stats))
+	for _, stat := range stats {
+		if isRoomSampled(livekit.RoomID(stat.RoomId), rate) {
+			sampled = append(sampled, stat)
+		}
+	}
+	return sampled
 }
 
-func (a *analyticsService) SendEvent(_ context.Context, event *livekit.AnalyticsEvent) {
-	if a.events == nil {
+func samplingRate(conf config.AnalyticsSamplingConfig, numRooms int) float64 {
+	if conf.RoomThreshold <= 0 || numRooms <= conf.RoomThreshold {
+		return 1
+	}
+
+	rate := float64(conf.RoomThreshold) / float64(numRooms)
+	if rate < conf.MinRate {
+		rate = conf.MinRate
+	}
+	return rate
+}
+
+func isRoomSampled(roomID livekit.RoomID, rate float64) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(roomID))
+	return float64(h.Sum32()%10000) < rate*10000
+}
+
+func (a *analyticsService) SendEvent(ctx context.Context, event *livekit.AnalyticsEvent) {
+	if (a.events == nil && a.sink == nil) || a.disabledEvents[event.Type] {
 		return
 	}
 
 	event.AnalyticsKey = a.analyticsKey
-	if err := a.events.Send(&livekit.AnalyticsEvents{
-		Events: []*livekit.AnalyticsEvent{event},
-	}); err != nil {
-		logger.Errorw("failed to send event", err, "eventType", event.Type.String())
+	if a.events != nil {
+		if err := a.events.Send(&livekit.AnalyticsEvents{
+			Events: []*livekit.AnalyticsEvent{event},
+		}); err != nil {
+			logger.Errorw("failed to send event", err, "eventType", event.Type.String())
+		}
+	}
+	if a.sink != nil {
+		a.sink.SendEvent(ctx, event)
 	}
 }
@@ -0,0 +1,118 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestSamplingRate(t *testing.T) {
+	conf := config.AnalyticsSamplingConfig{RoomThreshold: 100, MinRate: 0.1}
+
+	require.Equal(t, 1.0, samplingRate(config.AnalyticsSamplingConfig{}, 10000))
+	require.Equal(t, 1.0, samplingRate(conf, 100))
+	require.Equal(t, 0.5, samplingRate(conf, 200))
+	require.Equal(t, 0.1, samplingRate(conf, 10000))
+}
+
+func TestIsRoomSampled(t *testing.T) {
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		roomID := livekit.RoomID(fmt.Sprintf("RM_%d", i))
+		// decision is stable for a room
+		require.Equal(t, isRoomSampled(roomID, 0.25), isRoomSampled(roomID, 0.25))
+		require.True(t, isRoomSampled(roomID, 1))
+		if isRoomSampled(roomID, 0.25) {
+			sampled++
+		}
+	}
+	require.InDelta(t, 250, sampled, 60)
+}
+
+func TestAnalyticsBatching(t *testing.T) {
+	newService := func(batchSize int, batchInterval time.Duration) (*analyticsService, *testAnalyticsSink) {
+		conf := &config.Config{}
+		conf.Telemetry.Analytics.BatchSize = batchSize
+		conf.Telemetry.Analytics.BatchInterval = batchInterval
+		sink := &testAnalyticsSink{}
+		a := NewAnalyticsService(conf, routing.LocalNode(&livekit.Node{Id: "ND_test"}), sink).(*analyticsService)
+		t.Cleanup(func() { _ = a.Close() })
+		return a, sink
+	}
+
+	t.Run("flushes by size", func(t *testing.T) {
+		a, sink := newService(3, time.Hour)
+		for i := 0; i < 2; i++ {
+			a.SendStats(context.Background(), []*livekit.AnalyticsStat{{RoomId: "RM_test"}})
+		}
+		require.Empty(t, sink.batches())
+
+		a.SendStats(context.Background(), []*livekit.AnalyticsStat{{RoomId: "RM_test"}})
+		batches := sink.batches()
+		require.Len(t, batches, 1)
+		require.Len(t, batches[0], 3)
+		require.Equal(t, "ND_test", batches[0][0].Node)
+	})
+
+	t.Run("flushes by interval", func(t *testing.T) {
+		a, sink := newService(100, 10*time.Millisecond)
+		a.SendStats(context.Background(), []*livekit.AnalyticsStat{{RoomId: "RM_test"}})
+
+		require.Eventually(t, func() bool {
+			return len(sink.batches()) == 1
+		}, time.Second, 5*time.Millisecond)
+		require.Len(t, sink.batches()[0], 1)
+	})
+
+	t.Run("flushes on close", func(t *testing.T) {
+		a, sink := newService(100, time.Hour)
+		a.SendStats(context.Background(), []*livekit.AnalyticsStat{{RoomId: "RM_test"}})
+		require.Empty(t, sink.batches())
+
+		require.NoError(t, a.Close())
+		require.Len(t, sink.batches(), 1)
+		// closing again is a no-op
+		require.NoError(t, a.Close())
+	})
+}
+
+type testAnalyticsSink struct {
+	lock  sync.Mutex
+	stats [][]*livekit.AnalyticsStat
+}
+
+func (s *testAnalyticsSink) SendStats(_ context.Context, stats []*livekit.AnalyticsStat) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stats = append(s.stats, stats)
+}
+
+func (s *testAnalyticsSink) SendEvent(_ context.Context, _ *livekit.AnalyticsEvent) {}
+
+func (s *testAnalyticsSink) batches() [][]*livekit.AnalyticsStat {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([][]*livekit.AnalyticsStat(nil), s.stats...)
+}
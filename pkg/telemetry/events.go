@@ -16,17 +16,25 @@ package telemetry
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
 
-	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils"
 	"github.com/livekit/protocol/webhook"
 )
 
+const (
+	// webhook events of the server, in addition to those of the webhook package
+	EventRoomLocked                        = "room_locked"
+	EventRoomUnlocked                      = "room_unlocked"
+	EventParticipantMediaThrottled         = "participant_media_throttled"
+	EventParticipantMediaFloodDisconnected = "participant_media_flood_disconnected"
+)
+
 func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.WebhookEvent) {
 	if t.notifier == nil {
 		return
@@ -42,6 +50,8 @@ func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.Webho
 
 func (t *telemetryService) RoomStarted(ctx context.Context, room *livekit.Room) {
 	t.enqueue(func() {
+		t.reporter.RoomStarted(livekit.RoomName(room.Name))
+
 		t.NotifyEvent(ctx, &livekit.WebhookEvent{
 			Event: webhook.EventRoomStarted,
 			Room:  room,
@@ -57,6 +67,8 @@ func (t *telemetryService) RoomStarted(ctx context.Context, room *livekit.Room)
 
 func (t *telemetryService) RoomEnded(ctx context.Context, room *livekit.Room) {
 	t.enqueue(func() {
+		t.reporter.RoomEnded(livekit.RoomName(room.Name), livekit.RoomID(room.Sid), time.Unix(room.CreationTime, 0))
+
 		t.NotifyEvent(ctx, &livekit.WebhookEvent{
 			Event: webhook.EventRoomFinished,
 			Room:  room,
@@ -71,6 +83,24 @@ func (t *telemetryService) RoomEnded(ctx context.Context, room *livekit.Room) {
 	})
 }
 
+func (t *telemetryService) RoomLocked(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomLocked,
+			Room:  room,
+		})
+	})
+}
+
+func (t *telemetryService) RoomUnlocked(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomUnlocked,
+			Room:  room,
+		})
+	})
+}
+
 func (t *telemetryService) ParticipantJoined(
 	ctx context.Context,
 	room *livekit.Room,
@@ -80,8 +110,8 @@ func (t *telemetryService) ParticipantJoined(
 	shouldSendEvent bool,
 ) {
 	t.enqueue(func() {
-		prometheus.IncrementParticipantRtcConnected(1)
-		prometheus.AddParticipant()
+		t.reporter.IncrementParticipantRtcConnected(1)
+		t.reporter.AddParticipant(livekit.RoomName(room.Name))
 
 		t.createWorker(
 			ctx,
@@ -130,7 +160,7 @@ func (t *telemetryService) ParticipantActive(
 			)
 
 			// need to also account for participant count
-			prometheus.AddParticipant()
+			t.reporter.AddParticipant(livekit.RoomName(room.Name))
 		}
 		worker.SetConnected()
 
@@ -169,11 +199,25 @@ func (t *telemetryService) ParticipantLeft(ctx context.Context,
 			hasWorker = true
 			isConnected = worker.IsConnected()
 			worker.Close()
+
+			traffic := worker.Traffic()
+			t.reporter.RecordParticipantTraffic(livekit.RoomName(room.Name), traffic.PublisherBytes, traffic.SubscriberBytes)
+			// totals are sent regardless of shouldSendEvent, so that usage on this node is accounted for after a migration
+			if stats := worker.TrafficStats(); len(stats) != 0 {
+				t.SendStats(ctx, stats)
+			}
 		}
 
 		if hasWorker {
 			// signifies we had incremented participant count
-			prometheus.SubParticipant()
+			t.reporter.SubParticipant(livekit.RoomName(room.Name))
+		}
+
+		// tracks are not always unsubscribed before the participant leaves
+		for key := range t.subscribedCodecs {
+			if key.participantID == livekit.ParticipantID(participant.Sid) {
+				delete(t.subscribedCodecs, key)
+			}
 		}
 
 		if isConnected && shouldSendEvent {
@@ -188,6 +232,24 @@ func (t *telemetryService) ParticipantLeft(ctx context.Context,
 	})
 }
 
+func (t *telemetryService) ParticipantMediaFlood(ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	disconnected bool,
+) {
+	t.enqueue(func() {
+		event := EventParticipantMediaThrottled
+		if disconnected {
+			event = EventParticipantMediaFloodDisconnected
+		}
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       event,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
 func (t *telemetryService) TrackPublishRequested(
 	ctx context.Context,
 	participantID livekit.ParticipantID,
@@ -195,8 +257,8 @@ func (t *telemetryService) TrackPublishRequested(
 	track *livekit.TrackInfo,
 ) {
 	t.enqueue(func() {
-		prometheus.AddPublishAttempt(track.Type.String())
 		room := t.getRoomDetails(participantID)
+		t.reporter.AddPublishAttempt(getRoomName(room), track.Type.String())
 		ev := newTrackEvent(livekit.AnalyticsEventType_TRACK_PUBLISH_REQUESTED, room, participantID, track)
 		if ev.Participant != nil {
 			ev.Participant.Identity = string(identity)
@@ -212,10 +274,12 @@ func (t *telemetryService) TrackPublished(
 	track *livekit.TrackInfo,
 ) {
 	t.enqueue(func() {
-		prometheus.AddPublishedTrack(track.Type.String())
-		prometheus.AddPublishSuccess(track.Type.String())
-
 		room := t.getRoomDetails(participantID)
+		codec := getCodec(track)
+		t.publishedCodecs[livekit.TrackID(track.Sid)] = codec
+		t.reporter.AddPublishedTrack(getRoomName(room), track.Type.String(), codec)
+		t.reporter.AddPublishSuccess(getRoomName(room), track.Type.String())
+
 		participant := &livekit.ParticipantInfo{
 			Sid:      string(participantID),
 			Identity: string(identity),
@@ -262,9 +326,9 @@ func (t *telemetryService) TrackSubscribeRequested(
 	track *livekit.TrackInfo,
 ) {
 	t.enqueue(func() {
-		prometheus.RecordTrackSubscribeAttempt()
-
 		room := t.getRoomDetails(participantID)
+		t.reporter.RecordTrackSubscribeAttempt(getRoomName(room))
+
 		ev := newTrackEvent(livekit.AnalyticsEventType_TRACK_SUBSCRIBE_REQUESTED, room, participantID, track)
 		t.SendEvent(ctx, ev)
 	})
@@ -278,13 +342,15 @@ func (t *telemetryService) TrackSubscribed(
 	shouldSendEvent bool,
 ) {
 	t.enqueue(func() {
-		prometheus.RecordTrackSubscribeSuccess(track.Type.String())
+		room := t.getRoomDetails(participantID)
+		codec := getCodec(track)
+		t.subscribedCodecs[subscriptionKey{participantID, livekit.TrackID(track.Sid)}] = codec
+		t.reporter.RecordTrackSubscribeSuccess(getRoomName(room), track.Type.String(), codec)
 
 		if !shouldSendEvent {
 			return
 		}
 
-		room := t.getRoomDetails(participantID)
 		ev := newTrackEvent(livekit.AnalyticsEventType_TRACK_SUBSCRIBED, room, participantID, track)
 		ev.Publisher = publisher
 		t.SendEvent(ctx, ev)
@@ -296,12 +362,13 @@ func (t *telemetryService) TrackSubscribeFailed(
 	participantID livekit.ParticipantID,
 	trackID livekit.TrackID,
 	err error,
+	reason string,
 	isUserError bool,
 ) {
 	t.enqueue(func() {
-		prometheus.RecordTrackSubscribeFailure(err, isUserError)
-
 		room := t.getRoomDetails(participantID)
+		t.reporter.RecordTrackSubscribeFailure(getRoomName(room), reason, isUserError)
+
 		ev := newTrackEvent(livekit.AnalyticsEventType_TRACK_SUBSCRIBE_FAILED, room, participantID, &livekit.TrackInfo{
 			Sid: string(trackID),
 		})
@@ -317,10 +384,16 @@ func (t *telemetryService) TrackUnsubscribed(
 	shouldSendEvent bool,
 ) {
 	t.enqueue(func() {
-		prometheus.RecordTrackUnsubscribed(track.Type.String())
+		room := t.getRoomDetails(participantID)
+		key := subscriptionKey{participantID, livekit.TrackID(track.Sid)}
+		codec, ok := t.subscribedCodecs[key]
+		if !ok {
+			codec = getCodec(track)
+		}
+		delete(t.subscribedCodecs, key)
+		t.reporter.RecordTrackUnsubscribed(getRoomName(room), track.Type.String(), codec)
 
 		if shouldSendEvent {
-			room := t.getRoomDetails(participantID)
 			t.SendEvent(ctx, newTrackEvent(livekit.AnalyticsEventType_TRACK_UNSUBSCRIBED, room, participantID, track))
 		}
 	})
@@ -334,12 +407,17 @@ func (t *telemetryService) TrackUnpublished(
 	shouldSendEvent bool,
 ) {
 	t.enqueue(func() {
-		prometheus.SubPublishedTrack(track.Type.String())
+		room := t.getRoomDetails(participantID)
+		codec, ok := t.publishedCodecs[livekit.TrackID(track.Sid)]
+		if !ok {
+			codec = getCodec(track)
+		}
+		delete(t.publishedCodecs, livekit.TrackID(track.Sid))
+		t.reporter.SubPublishedTrack(getRoomName(room), track.Type.String(), codec)
 		if !shouldSendEvent {
 			return
 		}
 
-		room := t.getRoomDetails(participantID)
 		participant := &livekit.ParticipantInfo{
 			Sid:      string(participantID),
 			Identity: string(identity),
@@ -500,6 +578,28 @@ func (t *telemetryService) getRoomDetails(participantID livekit.ParticipantID) *
 	return nil
 }
 
+func getRoomName(room *livekit.Room) livekit.RoomName {
+	if room == nil {
+		return ""
+	}
+	return livekit.RoomName(room.Name)
+}
+
+// getCodec returns the primary codec of a track without the media type, i.e. "vp8" for "video/VP8"
+func getCodec(track *livekit.TrackInfo) string {
+	mimeType := track.MimeType
+	if mimeType == "" && len(track.Codecs) != 0 {
+		mimeType = track.Codecs[0].MimeType
+	}
+	if mimeType == "" {
+		return "unknown"
+	}
+	if i := strings.IndexByte(mimeType, '/'); i >= 0 {
+		mimeType = mimeType[i+1:]
+	}
+	return strings.ToLower(mimeType)
+}
+
 func newRoomEvent(event livekit.AnalyticsEventType, room *livekit.Room) *livekit.AnalyticsEvent {
 	ev := &livekit.AnalyticsEvent{
 		Type:      event,
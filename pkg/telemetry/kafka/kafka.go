@@ -0,0 +1,141 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	EncodingProtobuf = "protobuf"
+	EncodingJSON     = "json"
+
+	headerType = "type"
+	typeEvent  = "event"
+	typeStat   = "stat"
+)
+
+// AnalyticsSink publishes analytics events and stats to a Kafka topic.
+// Messages are keyed by room ID, so that the messages of a room stay in order in a single partition.
+type AnalyticsSink struct {
+	writer  *kafka.Writer
+	marshal func(m proto.Message) ([]byte, error)
+}
+
+func NewAnalyticsSink(conf config.KafkaConfig) (*AnalyticsSink, error) {
+	var marshal func(m proto.Message) ([]byte, error)
+	switch conf.Encoding {
+	case EncodingProtobuf, "":
+		marshal = proto.Marshal
+	case EncodingJSON:
+		marshal = protojson.Marshal
+	default:
+		return nil, fmt.Errorf("unsupported kafka encoding: %s", conf.Encoding)
+	}
+
+	transport := &kafka.Transport{}
+	if conf.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if conf.Username != "" {
+		transport.SASL = plain.Mechanism{
+			Username: conf.Username,
+			Password: conf.Password,
+		}
+	}
+
+	return &AnalyticsSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(conf.Brokers...),
+			Topic:        conf.Topic,
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: conf.BatchTimeout,
+			RequiredAcks: kafka.RequireOne,
+			Async:        true,
+			Transport:    transport,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					logger.Errorw("failed to publish analytics to kafka", err, "messages", len(messages))
+				}
+			},
+		},
+		marshal: marshal,
+	}, nil
+}
+
+func (s *AnalyticsSink) SendStats(ctx context.Context, stats []*livekit.AnalyticsStat) {
+	messages := make([]kafka.Message, 0, len(stats))
+	for _, stat := range stats {
+		value, err := s.marshal(stat)
+		if err != nil {
+			logger.Errorw("failed to marshal analytics stat", err)
+			continue
+		}
+		messages = append(messages, newMessage(stat.RoomId, typeStat, value))
+	}
+	s.write(ctx, messages)
+}
+
+func (s *AnalyticsSink) SendEvent(ctx context.Context, event *livekit.AnalyticsEvent) {
+	value, err := s.marshal(event)
+	if err != nil {
+		logger.Errorw("failed to marshal analytics event", err, "eventType", event.Type.String())
+		return
+	}
+
+	roomID := event.RoomId
+	if roomID == "" {
+		roomID = event.Room.GetSid()
+	}
+	s.write(ctx, []kafka.Message{newMessage(roomID, typeEvent, value)})
+}
+
+// Close sends the buffered messages
+func (s *AnalyticsSink) Close() error {
+	return s.writer.Close()
+}
+
+func (s *AnalyticsSink) write(ctx context.Context, messages []kafka.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	// async writes return once the messages are buffered, errors are reported by Completion
+	if err := s.writer.WriteMessages(ctx, messages...); err != nil {
+		logger.Errorw("failed to publish analytics to kafka", err, "messages", len(messages))
+	}
+}
+
+func newMessage(roomID string, messageType string, value []byte) kafka.Message {
+	m := kafka.Message{
+		Value:   value,
+		Headers: []kafka.Header{{Key: headerType, Value: []byte(messageType)}},
+	}
+	// events without a room, i.e. egress and ingress, are spread across partitions
+	if roomID != "" {
+		m.Key = []byte(roomID)
+	}
+	return m
+}
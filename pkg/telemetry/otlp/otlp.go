@@ -0,0 +1,111 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/version"
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+
+	serviceName = "livekit-server"
+)
+
+var ErrUnsupportedProtocol = errors.New("unsupported otlp protocol")
+
+// NewMeterProvider creates a meter provider that periodically exports everything gathered from the
+// prometheus registry to an OTLP collector. The provider is also set as the global
+// OpenTelemetry meter provider.
+func NewMeterProvider(ctx context.Context, conf config.OTLPConfig, gatherer prometheus.Gatherer, nodeID string, nodeType livekit.NodeType, env string) (*sdkmetric.MeterProvider, error) {
+	exporter, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", version.Version),
+		attribute.String("service.instance.id", nodeID),
+		attribute.String("node_type", nodeType.String()),
+		attribute.String("env", env),
+	)
+
+	readerOpts := []sdkmetric.PeriodicReaderOption{
+		sdkmetric.WithProducer(newGathererProducer(gatherer)),
+	}
+	if conf.ExportInterval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(conf.ExportInterval))
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+	)
+	otel.SetMeterProvider(provider)
+
+	return provider, nil
+}
+
+func newExporter(ctx context.Context, conf config.OTLPConfig) (sdkmetric.Exporter, error) {
+	switch conf.Protocol {
+	case ProtocolGRPC, "":
+		var opts []otlpmetricgrpc.Option
+		if conf.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(conf.Endpoint))
+		}
+		if conf.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(conf.Headers) != 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(conf.Headers))
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+
+	case ProtocolHTTP:
+		var opts []otlpmetrichttp.Option
+		if conf.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(conf.Endpoint))
+		}
+		if conf.URLPath != "" {
+			opts = append(opts, otlpmetrichttp.WithURLPath(conf.URLPath))
+		}
+		if conf.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(conf.Headers) != 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(conf.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProtocol, conf.Protocol)
+	}
+}
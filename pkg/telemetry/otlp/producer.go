@@ -0,0 +1,167 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/livekit/livekit-server/version"
+	"github.com/livekit/protocol/logger"
+)
+
+const scopeName = "github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+
+var _ sdkmetric.Producer = (*gathererProducer)(nil)
+
+// gathererProducer converts metrics gathered from prometheus into OpenTelemetry metric data,
+// so the same counters, gauges and histograms are available on both endpoints
+type gathererProducer struct {
+	gatherer  prometheus.Gatherer
+	startTime time.Time
+}
+
+func newGathererProducer(gatherer prometheus.Gatherer) *gathererProducer {
+	return &gathererProducer{
+		gatherer:  gatherer,
+		startTime: time.Now(),
+	}
+}
+
+func (p *gathererProducer) Produce(_ context.Context) ([]metricdata.ScopeMetrics, error) {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		if len(families) == 0 {
+			return nil, err
+		}
+		// gather returns as many metrics as possible along with the error
+		logger.Warnw("could not gather all prometheus metrics", err)
+	}
+
+	now := time.Now()
+	metrics := make([]metricdata.Metrics, 0, len(families))
+	for _, family := range families {
+		var data metricdata.Aggregation
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			data = p.convertCounter(family, now)
+		case dto.MetricType_GAUGE:
+			data = p.convertGauge(family, now)
+		case dto.MetricType_HISTOGRAM:
+			data = p.convertHistogram(family, now)
+		default:
+			continue
+		}
+
+		metrics = append(metrics, metricdata.Metrics{
+			Name:        family.GetName(),
+			Description: family.GetHelp(),
+			Data:        data,
+		})
+	}
+
+	return []metricdata.ScopeMetrics{
+		{
+			Scope: instrumentation.Scope{
+				Name:    scopeName,
+				Version: version.Version,
+			},
+			Metrics: metrics,
+		},
+	}, nil
+}
+
+func (p *gathererProducer) convertCounter(family *dto.MetricFamily, now time.Time) metricdata.Sum[float64] {
+	points := make([]metricdata.DataPoint[float64], 0, len(family.GetMetric()))
+	for _, m := range family.GetMetric() {
+		points = append(points, metricdata.DataPoint[float64]{
+			Attributes: convertLabels(m.GetLabel()),
+			StartTime:  p.startTime,
+			Time:       now,
+			Value:      m.GetCounter().GetValue(),
+		})
+	}
+
+	return metricdata.Sum[float64]{
+		DataPoints:  points,
+		Temporality: metricdata.CumulativeTemporality,
+		IsMonotonic: true,
+	}
+}
+
+func (p *gathererProducer) convertGauge(family *dto.MetricFamily, now time.Time) metricdata.Gauge[float64] {
+	points := make([]metricdata.DataPoint[float64], 0, len(family.GetMetric()))
+	for _, m := range family.GetMetric() {
+		points = append(points, metricdata.DataPoint[float64]{
+			Attributes: convertLabels(m.GetLabel()),
+			Time:       now,
+			Value:      m.GetGauge().GetValue(),
+		})
+	}
+
+	return metricdata.Gauge[float64]{
+		DataPoints: points,
+	}
+}
+
+func (p *gathererProducer) convertHistogram(family *dto.MetricFamily, now time.Time) metricdata.Histogram[float64] {
+	points := make([]metricdata.HistogramDataPoint[float64], 0, len(family.GetMetric()))
+	for _, m := range family.GetMetric() {
+		h := m.GetHistogram()
+
+		// prometheus buckets are cumulative, OTLP expects a count per bucket
+		// with one more bucket than bounds for the values above the last bound
+		buckets := h.GetBucket()
+		bounds := make([]float64, 0, len(buckets))
+		counts := make([]uint64, 0, len(buckets)+1)
+		var prev uint64
+		for _, b := range buckets {
+			bounds = append(bounds, b.GetUpperBound())
+			counts = append(counts, b.GetCumulativeCount()-prev)
+			prev = b.GetCumulativeCount()
+		}
+		counts = append(counts, h.GetSampleCount()-prev)
+
+		points = append(points, metricdata.HistogramDataPoint[float64]{
+			Attributes:   convertLabels(m.GetLabel()),
+			StartTime:    p.startTime,
+			Time:         now,
+			Count:        h.GetSampleCount(),
+			Bounds:       bounds,
+			BucketCounts: counts,
+			Sum:          h.GetSampleSum(),
+		})
+	}
+
+	return metricdata.Histogram[float64]{
+		DataPoints:  points,
+		Temporality: metricdata.CumulativeTemporality,
+	}
+}
+
+func convertLabels(labels []*dto.LabelPair) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(labels))
+	for _, l := range labels {
+		kvs = append(kvs, attribute.String(l.GetName(), l.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
@@ -0,0 +1,42 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promAPIKeyIPRejected *prometheus.CounterVec
+)
+
+func initAPIKeyIPStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promAPIKeyIPRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "api_key_ip",
+		Name:        "rejected_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Requests and joins rejected for coming from outside the IP allowlist of their API key.",
+	}, []string{"api_key", "request"})
+
+	mustRegister(promAPIKeyIPRejected)
+}
+
+// RecordAPIKeyIPRejected counts a request rejected by the IP allowlist of its API key, request is rpc or join
+func RecordAPIKeyIPRejected(apiKey string, request string) {
+	promAPIKeyIPRejected.WithLabelValues(apiKey, request).Inc()
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promAPIRateLimitRequests *prometheus.CounterVec
+)
+
+func initAPIRateLimitStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promAPIRateLimitRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "api_rate_limit",
+		Name:        "requests_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Rate limited API requests by API key and limit, the limit is the RPC with its own limit or default.",
+	}, []string{"api_key", "limit", "result"})
+
+	mustRegister(promAPIRateLimitRequests)
+}
+
+// RecordAPIRateLimit counts an API request checked against a rate limit, result is allowed or limited
+func RecordAPIRateLimit(apiKey string, limit string, allowed bool) {
+	result := "allowed"
+	if !allowed {
+		result = "limited"
+	}
+	promAPIRateLimitRequests.WithLabelValues(apiKey, limit, result).Inc()
+}
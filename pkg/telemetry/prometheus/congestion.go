@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promCongestionEstimatedBandwidth prometheus.Gauge
+	promCongestionDeficient          prometheus.Gauge
+	promCongestionLayerDowngrades    prometheus.Counter
+)
+
+func initCongestionStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promCongestionEstimatedBandwidth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "congestion",
+		Name:        "estimated_bandwidth_bps",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Sum of the latest downstream bandwidth estimates of all subscribers on the node.",
+	})
+	promCongestionDeficient = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "congestion",
+		Name:        "deficient_subscribers",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Number of subscribers whose stream allocator cannot fit all tracks at their desired layers.",
+	})
+	promCongestionLayerDowngrades = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "congestion",
+		Name:        "layer_downgrades_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Video layer downgrades and pauses applied by the stream allocator for lack of bandwidth.",
+	})
+
+	mustRegister(promCongestionEstimatedBandwidth)
+	mustRegister(promCongestionDeficient)
+	mustRegister(promCongestionLayerDowngrades)
+}
+
+// AddEstimatedBandwidth applies the change in a subscriber's bandwidth estimate to the node total
+func AddEstimatedBandwidth(delta int64) {
+	promCongestionEstimatedBandwidth.Add(float64(delta))
+}
+
+func AddDeficientSubscriber() {
+	promCongestionDeficient.Add(1)
+}
+
+func SubDeficientSubscriber() {
+	promCongestionDeficient.Sub(1)
+}
+
+func IncrementLayerDowngrade() {
+	promCongestionLayerDowngrades.Add(1)
+}
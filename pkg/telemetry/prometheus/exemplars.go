@@ -0,0 +1,47 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves the registry the metrics are registered with. OpenMetrics is negotiated with scrapers that accept it,
+// which is required for exemplars to be exposed.
+func Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		getRegisterer(),
+		promhttp.HandlerFor(Gatherer(), promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+}
+
+// observeWithExemplar attaches the non-empty labels as an exemplar to the observation.
+// The total length of exemplar labels is limited to 128 runes, keep them to IDs.
+func observeWithExemplar(o prometheus.Observer, v float64, labels prometheus.Labels) {
+	for k, l := range labels {
+		if l == "" {
+			delete(labels, k)
+		}
+	}
+
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && len(labels) != 0 {
+		eo.ObserveWithExemplar(v, labels)
+		return
+	}
+	o.Observe(v)
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveWithExemplar(t *testing.T) {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_ms",
+		Buckets: []float64{10, 100},
+	})
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(h))
+
+	observeWithExemplar(h, 50, prometheus.Labels{"room_sid": "RM_1234", "participant_sid": ""})
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	buckets := families[0].GetMetric()[0].GetHistogram().GetBucket()
+	require.Nil(t, buckets[0].GetExemplar())
+
+	exemplar := buckets[1].GetExemplar()
+	require.NotNil(t, exemplar)
+	require.Equal(t, 50.0, exemplar.GetValue())
+	// empty labels are dropped
+	require.Len(t, exemplar.GetLabel(), 1)
+	require.Equal(t, "room_sid", exemplar.GetLabel()[0].GetName())
+	require.Equal(t, "RM_1234", exemplar.GetLabel()[0].GetValue())
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promKeyQuotaChecks *prometheus.CounterVec
+	promKeyQuotaUsage  *prometheus.GaugeVec
+)
+
+func initKeyQuotaStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promKeyQuotaChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "key_quota",
+		Name:        "checks_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Rooms, joins and egress checked against the quotas of their API key, the quota is rooms, participants or egress.",
+	}, []string{"api_key", "quota", "result"})
+	promKeyQuotaUsage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "key_quota",
+		Name:        "usage",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Resources of API keys counting against their quotas, as of their last check on this node.",
+	}, []string{"api_key", "quota"})
+
+	mustRegister(promKeyQuotaChecks)
+	mustRegister(promKeyQuotaUsage)
+}
+
+// RecordKeyQuota counts a check of a quota of an API key with the resources in use, result is allowed or exceeded
+func RecordKeyQuota(apiKey string, quota string, usage int, allowed bool) {
+	result := "allowed"
+	if !allowed {
+		result = "exceeded"
+	}
+	promKeyQuotaChecks.WithLabelValues(apiKey, quota, result).Inc()
+	promKeyQuotaUsage.WithLabelValues(apiKey, quota).Set(float64(usage))
+}
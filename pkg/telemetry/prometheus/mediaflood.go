@@ -0,0 +1,46 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promMediaFloods *prometheus.CounterVec
+)
+
+func initMediaFloodStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promMediaFloods = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "media_flood",
+		Name:        "total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Publishers flooding the node with media, the kind is bitrate, malformed_rtp or streams, the action is throttled or disconnected.",
+	}, []string{"kind", "action"})
+
+	mustRegister(promMediaFloods)
+}
+
+// RecordMediaFlood counts a publisher throttled or disconnected for flooding the node with media
+func RecordMediaFlood(kind string, disconnected bool) {
+	action := "throttled"
+	if disconnected {
+		action = "disconnected"
+	}
+	promMediaFloods.WithLabelValues(kind, action).Inc()
+}
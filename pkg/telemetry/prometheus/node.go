@@ -15,11 +15,11 @@
 package prometheus
 
 import (
+	"sync"
 	"time"
 
 	"github.com/mackerelio/go-osstat/memory"
 	"github.com/prometheus/client_golang/prometheus"
-	"go.uber.org/atomic"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/protocol/livekit"
@@ -30,7 +30,13 @@ const (
 )
 
 var (
-	initialized atomic.Bool
+	initLock    sync.Mutex
+	initialized bool
+	registerer  prometheus.Registerer
+	gatherer    prometheus.Gatherer
+	registered  []prometheus.Collector
+	// closed on Reset to stop the stats workers
+	done chan struct{}
 
 	MessageCounter            *prometheus.CounterVec
 	ServiceOperationCounter   *prometheus.CounterVec
@@ -42,10 +48,29 @@ var (
 	promSysDroppedPacketPctGauge prometheus.Gauge
 )
 
-func Init(nodeID string, nodeType livekit.NodeType, env string) {
-	if initialized.Swap(true) {
+// Init registers the metrics with the default prometheus registerer
+func Init(nodeID string, nodeType livekit.NodeType, env string, conf config.TelemetryConfig) {
+	InitWithRegisterer(prometheus.DefaultRegisterer, nodeID, nodeType, env, conf)
+}
+
+// InitWithRegisterer registers the metrics with reg, it is a no-op if the metrics are already initialized.
+// Use Reset before initializing again, e.g. with a new registry for every embedded server or test.
+func InitWithRegisterer(reg prometheus.Registerer, nodeID string, nodeType livekit.NodeType, env string, conf config.TelemetryConfig) {
+	initLock.Lock()
+	defer initLock.Unlock()
+
+	if initialized {
 		return
 	}
+	initialized = true
+	registerer = reg
+	gatherer = prometheus.DefaultGatherer
+	if g, ok := reg.(prometheus.Gatherer); ok {
+		gatherer = g
+	}
+	done = make(chan struct{})
+
+	roomLabels.init(conf.RoomLabels)
 
 	MessageCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -98,18 +123,86 @@ func Init(nodeID string, nodeType livekit.NodeType, env string) {
 		},
 	)
 
-	prometheus.MustRegister(MessageCounter)
-	prometheus.MustRegister(ServiceOperationCounter)
-	prometheus.MustRegister(TwirpRequestStatusCounter)
-	prometheus.MustRegister(promSysPacketGauge)
-	prometheus.MustRegister(promSysDroppedPacketPctGauge)
+	mustRegister(MessageCounter)
+	mustRegister(ServiceOperationCounter)
+	mustRegister(TwirpRequestStatusCounter)
+	mustRegister(promSysPacketGauge)
+	mustRegister(promSysDroppedPacketPctGauge)
 
 	sysPacketsStart, sysDroppedPacketsStart, _ = getTCStats()
 
-	initPacketStats(nodeID, nodeType, env)
-	initRoomStats(nodeID, nodeType, env)
-	initPSRPCStats(nodeID, nodeType, env)
-	initQualityStats(nodeID, nodeType, env)
+	initPacketStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initRoomStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initPSRPCStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initStoreStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initQualityStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initStreamQualityStats(nodeID, nodeType, env)
+	initSignalingStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initReconnectStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initSystemStats(nodeID, nodeType, env)
+	initPublishedTrackStats(nodeID, nodeType, env, conf.HistogramBuckets)
+	initCongestionStats(nodeID, nodeType, env)
+	initPacerStats(nodeID, nodeType, env)
+	initAPIRateLimitStats(nodeID, nodeType, env)
+	initAPIKeyIPStats(nodeID, nodeType, env)
+	initSignalRateLimitStats(nodeID, nodeType, env)
+	initMediaFloodStats(nodeID, nodeType, env)
+	initKeyQuotaStats(nodeID, nodeType, env)
+}
+
+// Reset stops the stats workers and unregisters all metrics from the registerer they were registered with.
+// Node stats counters keep their values.
+func Reset() {
+	initLock.Lock()
+	defer initLock.Unlock()
+
+	if !initialized {
+		return
+	}
+	close(done)
+	for _, c := range registered {
+		registerer.Unregister(c)
+	}
+	registered = nil
+	registerer = nil
+	gatherer = nil
+	roomLabels.reset()
+	initialized = false
+}
+
+// Gatherer returns the gatherer of the registry the metrics are registered with, for the metrics to be served and
+// exported from the same registry. it is the default gatherer when the registerer does not gather
+func Gatherer() prometheus.Gatherer {
+	initLock.Lock()
+	defer initLock.Unlock()
+
+	if gatherer == nil {
+		return prometheus.DefaultGatherer
+	}
+	return gatherer
+}
+
+func getRegisterer() prometheus.Registerer {
+	initLock.Lock()
+	defer initLock.Unlock()
+
+	if registerer == nil {
+		return prometheus.DefaultRegisterer
+	}
+	return registerer
+}
+
+func mustRegister(cs ...prometheus.Collector) {
+	registerer.MustRegister(cs...)
+	registered = append(registered, cs...)
+}
+
+// getBuckets returns the configured buckets of a histogram, falling back to the defaults
+func getBuckets(histogramBuckets map[string][]float64, subsystem string, name string, defaults []float64) []float64 {
+	if buckets := histogramBuckets[subsystem+"_"+name]; len(buckets) != 0 {
+		return buckets
+	}
+	return defaults
 }
 
 func GetUpdatedNodeStats(prev *livekit.NodeStats, prevAverage *livekit.NodeStats) (*livekit.NodeStats, bool, error) {
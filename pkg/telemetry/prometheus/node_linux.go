@@ -18,7 +18,11 @@
 package prometheus
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/florianl/go-tc"
 )
@@ -44,3 +48,81 @@ func getTCStats() (packets, drops uint32, err error) {
 
 	return
 }
+
+func getNICStats() (map[string]nicStats, error) {
+	f, err := os.Open("/proc/net/dev")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]nicStats)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Inter-|   Receive                                                |  Transmit
+		//  face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets ...
+		//   eth0: 1234    56      0    0    0    0     0          0         7890     12      ...
+		name, values, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(values)
+		if len(fields) < 16 {
+			continue
+		}
+		rxBytes, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		txBytes, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			continue
+		}
+		stats[strings.TrimSpace(name)] = nicStats{rxBytes: rxBytes, txBytes: txBytes}
+	}
+	return stats, scanner.Err()
+}
+
+func getUDPStats() (stats udpStats, err error) {
+	f, err := os.Open("/proc/net/snmp")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// UDP stats are two lines, a header with the names followed by the values
+	//   Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors ...
+	//   Udp: 1234 5 6 7890 6 0 ...
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "Udp:" {
+			continue
+		}
+		if names == nil {
+			names = fields
+			continue
+		}
+
+		for i := 1; i < len(fields) && i < len(names); i++ {
+			value, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch names[i] {
+			case "InErrors":
+				stats.inErrors = value
+			case "RcvbufErrors":
+				stats.rcvbufErrors = value
+			case "SndbufErrors":
+				stats.sndbufErrors = value
+			}
+		}
+		return stats, nil
+	}
+	if err = scanner.Err(); err == nil {
+		err = fmt.Errorf("udp stats not found")
+	}
+	return
+}
@@ -20,3 +20,13 @@ func getTCStats() (packets, drops uint32, err error) {
 	// linux only
 	return
 }
+
+func getNICStats() (map[string]nicStats, error) {
+	// linux only
+	return nil, nil
+}
+
+func getUDPStats() (stats udpStats, err error) {
+	// linux only
+	return
+}
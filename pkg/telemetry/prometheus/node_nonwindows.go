@@ -26,29 +26,37 @@ import (
 	"github.com/mackerelio/go-osstat/loadavg"
 )
 
-var (
-	cpuStatsLock              sync.RWMutex
-	lastCPUTotal, lastCPUIdle uint64
-)
+// cpuStatsTracker computes CPU load over the time between two calls
+type cpuStatsTracker struct {
+	lock                sync.Mutex
+	lastTotal, lastIdle uint64
+}
+
+// nodeCPUStats is used for node load reporting
+var nodeCPUStats cpuStatsTracker
 
 func getLoadAvg() (*loadavg.Stats, error) {
 	return loadavg.Get()
 }
 
 func getCPUStats() (cpuLoad float32, numCPUs uint32, err error) {
+	return nodeCPUStats.get()
+}
+
+func (c *cpuStatsTracker) get() (cpuLoad float32, numCPUs uint32, err error) {
 	cpuInfo, err := cpu.Get()
 	if err != nil {
 		return
 	}
 
-	cpuStatsLock.Lock()
-	if lastCPUTotal > 0 && lastCPUTotal < cpuInfo.Total {
-		cpuLoad = 1 - float32(cpuInfo.Idle-lastCPUIdle)/float32(cpuInfo.Total-lastCPUTotal)
+	c.lock.Lock()
+	if c.lastTotal > 0 && c.lastTotal < cpuInfo.Total {
+		cpuLoad = 1 - float32(cpuInfo.Idle-c.lastIdle)/float32(cpuInfo.Total-c.lastTotal)
 	}
 
-	lastCPUTotal = cpuInfo.Total
-	lastCPUIdle = cpuInfo.Idle
-	cpuStatsLock.Unlock()
+	c.lastTotal = cpuInfo.Total
+	c.lastIdle = cpuInfo.Idle
+	c.lock.Unlock()
 
 	numCPUs = uint32(runtime.NumCPU())
 
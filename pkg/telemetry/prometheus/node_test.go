@@ -0,0 +1,58 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestInitWithRegisterer(t *testing.T) {
+	t.Cleanup(Reset)
+	conf := config.TelemetryConfig{RoomLabels: config.RoomLabelsConfig{Enabled: true}}
+
+	first := prometheus.NewRegistry()
+	InitWithRegisterer(first, "node", livekit.NodeType_SERVER, "test", conf)
+	// initializing again is a no-op
+	InitWithRegisterer(prometheus.NewRegistry(), "node", livekit.NodeType_SERVER, "test", conf)
+
+	families, err := first.Gather()
+	require.NoError(t, err)
+	require.NotEmpty(t, families)
+	// metrics are exported from the registry they are registered with
+	require.Same(t, first, Gatherer())
+	vecs := len(roomLabels.vecs)
+	require.NotZero(t, vecs)
+
+	Reset()
+	families, err = first.Gather()
+	require.NoError(t, err)
+	require.Empty(t, families)
+	require.Empty(t, roomLabels.vecs)
+
+	second := prometheus.NewRegistry()
+	InitWithRegisterer(second, "node", livekit.NodeType_SERVER, "test", conf)
+	families, err = second.Gather()
+	require.NoError(t, err)
+	require.NotEmpty(t, families)
+	require.Same(t, second, Gatherer())
+	// vectors of the first registry are no longer kept
+	require.Len(t, roomLabels.vecs, vecs)
+}
@@ -24,6 +24,12 @@ func getLoadAvg() (*loadavg.Stats, error) {
 	return &loadavg.Stats{}, nil
 }
 
+type cpuStatsTracker struct{}
+
 func getCPUStats() (cpuLoad float32, numCPUs uint32, err error) {
 	return 1, 1, nil
 }
+
+func (c *cpuStatsTracker) get() (cpuLoad float32, numCPUs uint32, err error) {
+	return getCPUStats()
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promPacerQueuedPackets  prometheus.Gauge
+	promPacerBacklogBytes   prometheus.Gauge
+	promPacerDroppedPackets prometheus.Counter
+)
+
+func initPacerStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promPacerQueuedPackets = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "pacer",
+		Name:        "queued_packets",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Media packets waiting in send queues of all subscribers on the node.",
+	})
+	promPacerBacklogBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "pacer",
+		Name:        "backlog_bytes",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "RTP payload bytes waiting in send queues of all subscribers on the node.",
+	})
+	promPacerDroppedPackets = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "pacer",
+		Name:        "dropped_packets_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Media packets dropped because a send queue was full.",
+	})
+
+	mustRegister(promPacerQueuedPackets)
+	mustRegister(promPacerBacklogBytes)
+	mustRegister(promPacerDroppedPackets)
+}
+
+// AddPacerQueued applies a change in queued packets and bytes, negative when packets leave a queue
+func AddPacerQueued(packets int, bytes int) {
+	promPacerQueuedPackets.Add(float64(packets))
+	promPacerBacklogBytes.Add(float64(bytes))
+}
+
+func IncrementPacerDropped() {
+	promPacerDroppedPackets.Add(1)
+}
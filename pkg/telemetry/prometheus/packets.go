@@ -36,6 +36,8 @@ var (
 	packetsIn                  atomic.Uint64
 	packetsOut                 atomic.Uint64
 	nackTotal                  atomic.Uint64
+	pliTotal                   atomic.Uint64
+	firTotal                   atomic.Uint64
 	retransmitBytes            atomic.Uint64
 	retransmitPackets          atomic.Uint64
 	participantSignalConnected atomic.Uint64
@@ -56,6 +58,10 @@ var (
 	promRTT             *prometheus.HistogramVec
 	promParticipantJoin *prometheus.CounterVec
 	promConnections     *prometheus.GaugeVec
+	promForwardLabels   = []string{"direction", "kind"}
+	promForwardPackets  *prometheus.CounterVec
+	promForwardBytes    *prometheus.CounterVec
+	promICEConnections  *prometheus.CounterVec
 
 	promPacketTotalIncomingInitial    prometheus.Counter
 	promPacketTotalIncomingRetransmit prometheus.Counter
@@ -67,7 +73,7 @@ var (
 	promPacketBytesOutgoingRetransmit prometheus.Counter
 )
 
-func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
+func initPacketStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
 	promPacketTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "packet",
@@ -85,18 +91,21 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Subsystem:   "nack",
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "NACKs by media direction, incoming are sent to publishers, outgoing are received from subscribers.",
 	}, promRTCPLabels)
 	promPliTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "pli",
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "PLIs by media direction, incoming are sent to publishers, outgoing are received from subscribers.",
 	}, promRTCPLabels)
 	promFirTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "fir",
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "FIRs by media direction, incoming are sent to publishers, outgoing are received from subscribers.",
 	}, promRTCPLabels)
 	promPacketLossTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
@@ -109,21 +118,21 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Subsystem:   "packet_loss",
 		Name:        "percent",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{0.0, 0.1, 0.3, 0.5, 0.7, 1, 5, 10, 40, 100},
+		Buckets:     getBuckets(histogramBuckets, "packet_loss", "percent", []float64{0.0, 0.1, 0.3, 0.5, 0.7, 1, 5, 10, 40, 100}),
 	}, promStreamLabels)
 	promJitter = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "jitter",
 		Name:        "us",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{100, 500, 1500, 3000, 6000, 12000, 24000, 48000, 96000, 192000},
+		Buckets:     getBuckets(histogramBuckets, "jitter", "us", []float64{100, 500, 1500, 3000, 6000, 12000, 24000, 48000, 96000, 192000}),
 	}, promStreamLabels)
 	promRTT = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "rtt",
 		Name:        "ms",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{50, 100, 150, 200, 250, 500, 750, 1000, 5000, 10000},
+		Buckets:     getBuckets(histogramBuckets, "rtt", "ms", []float64{50, 100, 150, 200, 250, 500, 750, 1000, 5000, 10000}),
 	}, promStreamLabels)
 	promParticipantJoin = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
@@ -137,18 +146,43 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"kind"})
+	promForwardPackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "forwarded",
+		Name:        "packets",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "RTP packets forwarded, including retransmissions and padding.",
+	}, promForwardLabels)
+	promForwardBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "forwarded",
+		Name:        "bytes",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "RTP bytes forwarded, including retransmissions and padding.",
+	}, promForwardLabels)
 
-	prometheus.MustRegister(promPacketTotal)
-	prometheus.MustRegister(promPacketBytes)
-	prometheus.MustRegister(promNackTotal)
-	prometheus.MustRegister(promPliTotal)
-	prometheus.MustRegister(promFirTotal)
-	prometheus.MustRegister(promPacketLossTotal)
-	prometheus.MustRegister(promPacketLoss)
-	prometheus.MustRegister(promJitter)
-	prometheus.MustRegister(promRTT)
-	prometheus.MustRegister(promParticipantJoin)
-	prometheus.MustRegister(promConnections)
+	promICEConnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "ice_connection",
+		Name:        "total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Established peer connections by the selected remote candidate type and protocol.",
+	}, []string{"transport", "candidate_type", "protocol"})
+
+	mustRegister(promPacketTotal)
+	mustRegister(promPacketBytes)
+	mustRegister(promNackTotal)
+	mustRegister(promPliTotal)
+	mustRegister(promFirTotal)
+	mustRegister(promPacketLossTotal)
+	mustRegister(promPacketLoss)
+	mustRegister(promJitter)
+	mustRegister(promRTT)
+	mustRegister(promParticipantJoin)
+	mustRegister(promConnections)
+	mustRegister(promForwardPackets)
+	mustRegister(promForwardBytes)
+	mustRegister(promICEConnections)
 
 	promPacketTotalIncomingInitial = promPacketTotal.WithLabelValues(string(Incoming), transmissionInitial)
 	promPacketTotalIncomingRetransmit = promPacketTotal.WithLabelValues(string(Incoming), transmissionRetransmit)
@@ -210,6 +244,15 @@ func IncrementBytes(direction Direction, count uint64, retransmit bool) {
 	}
 }
 
+func IncrementForwarded(direction Direction, kind string, packets uint64, bytes uint64) {
+	if packets > 0 {
+		promForwardPackets.WithLabelValues(string(direction), kind).Add(float64(packets))
+	}
+	if bytes > 0 {
+		promForwardBytes.WithLabelValues(string(direction), kind).Add(float64(bytes))
+	}
+}
+
 func IncrementRTCP(direction Direction, nack, pli, fir uint32) {
 	if nack > 0 {
 		promNackTotal.WithLabelValues(string(direction)).Add(float64(nack))
@@ -217,15 +260,18 @@ func IncrementRTCP(direction Direction, nack, pli, fir uint32) {
 	}
 	if pli > 0 {
 		promPliTotal.WithLabelValues(string(direction)).Add(float64(pli))
+		pliTotal.Add(uint64(pli))
 	}
 	if fir > 0 {
 		promFirTotal.WithLabelValues(string(direction)).Add(float64(fir))
+		firTotal.Add(uint64(fir))
 	}
 }
 
 func RecordPacketLoss(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, lost, total uint32) {
 	if total > 0 {
 		promPacketLoss.WithLabelValues(string(direction), trackSource.String(), trackType.String()).Observe(float64(lost) / float64(total) * 100)
+		addStreamPacketLoss(direction, lost, total)
 	}
 	if lost > 0 {
 		promPacketLossTotal.WithLabelValues(string(direction), trackSource.String(), trackType.String()).Add(float64(lost))
@@ -235,12 +281,14 @@ func RecordPacketLoss(direction Direction, trackSource livekit.TrackSource, trac
 func RecordJitter(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, jitter uint32) {
 	if jitter > 0 {
 		promJitter.WithLabelValues(string(direction), trackSource.String(), trackType.String()).Observe(float64(jitter))
+		addStreamJitter(direction, jitter)
 	}
 }
 
 func RecordRTT(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, rtt uint32) {
 	if rtt > 0 {
 		promRTT.WithLabelValues(string(direction), trackSource.String(), trackType.String()).Observe(float64(rtt))
+		addStreamRTT(direction, rtt)
 	}
 }
 
@@ -278,3 +326,7 @@ func AddConnection(direction Direction) {
 func SubConnection(direction Direction) {
 	promConnections.WithLabelValues(string(direction)).Sub(1)
 }
+
+func IncrementICEConnection(transport string, candidateType string, protocol string) {
+	promICEConnections.WithLabelValues(transport, candidateType, protocol).Inc()
+}
@@ -32,7 +32,7 @@ var (
 	psrpcErrorTotal         *prometheus.CounterVec
 )
 
-func initPSRPCStats(nodeID string, nodeType livekit.NodeType, env string) {
+func initPSRPCStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
 	labels := []string{"role", "kind", "service", "method"}
 	streamLabels := []string{"role", "service", "method"}
 
@@ -41,14 +41,14 @@ func initPSRPCStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Subsystem:   "psrpc",
 		Name:        "request_time_ms",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000},
+		Buckets:     getBuckets(histogramBuckets, "psrpc", "request_time_ms", []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000}),
 	}, labels)
 	psrpcStreamSendTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "psrpc",
 		Name:        "stream_send_time_ms",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000},
+		Buckets:     getBuckets(histogramBuckets, "psrpc", "stream_send_time_ms", []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000}),
 	}, streamLabels)
 	psrpcStreamReceiveTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
@@ -69,11 +69,11 @@ func initPSRPCStats(nodeID string, nodeType livekit.NodeType, env string) {
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, labels)
 
-	prometheus.MustRegister(psrpcRequestTime)
-	prometheus.MustRegister(psrpcStreamSendTime)
-	prometheus.MustRegister(psrpcStreamReceiveTotal)
-	prometheus.MustRegister(psrpcStreamCurrent)
-	prometheus.MustRegister(psrpcErrorTotal)
+	mustRegister(psrpcRequestTime)
+	mustRegister(psrpcStreamSendTime)
+	mustRegister(psrpcStreamReceiveTotal)
+	mustRegister(psrpcStreamCurrent)
+	mustRegister(psrpcErrorTotal)
 }
 
 var _ middleware.MetricsObserver = PSRPCMetricsObserver{}
@@ -0,0 +1,58 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promPublishedTrackBitrate   *prometheus.HistogramVec
+	promPublishedTrackFramerate *prometheus.HistogramVec
+)
+
+func initPublishedTrackStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
+	promPublishedTrackBitrate = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "published_bitrate_bps",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Incoming media bitrate of published tracks, all simulcast layers combined, observed every receiver stats update.",
+		Buckets: getBuckets(histogramBuckets, "track", "published_bitrate_bps", []float64{
+			16_000, 32_000, 64_000, 128_000, 256_000, 512_000, 1_000_000, 2_000_000, 3_000_000, 5_000_000, 8_000_000,
+		}),
+	}, []string{"kind", "source"})
+	promPublishedTrackFramerate = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "published_fps",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Incoming framerate of published video tracks, of the highest framerate simulcast layer.",
+		Buckets:     getBuckets(histogramBuckets, "track", "published_fps", []float64{1, 5, 10, 15, 20, 25, 30, 45, 60}),
+	}, []string{"source"})
+
+	mustRegister(promPublishedTrackBitrate)
+	mustRegister(promPublishedTrackFramerate)
+}
+
+func RecordPublishedTrackBitrate(kind livekit.TrackType, source livekit.TrackSource, bitrate float64) {
+	promPublishedTrackBitrate.WithLabelValues(kind.String(), source.String()).Observe(bitrate)
+}
+
+func RecordPublishedTrackFramerate(source livekit.TrackSource, fps float64) {
+	promPublishedTrackFramerate.WithLabelValues(source.String()).Observe(fps)
+}
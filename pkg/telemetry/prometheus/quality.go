@@ -15,6 +15,8 @@
 package prometheus
 
 import (
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/livekit/protocol/livekit"
@@ -24,22 +26,24 @@ var (
 	qualityRating prometheus.Histogram
 	qualityScore  prometheus.Histogram
 	qualityDrop   *prometheus.CounterVec
+
+	promParticipantQuality *prometheus.GaugeVec
 )
 
-func initQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
+func initQualityStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
 	qualityRating = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "quality",
 		Name:        "rating",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{0, 1, 2},
+		Buckets:     getBuckets(histogramBuckets, "quality", "rating", []float64{0, 1, 2}),
 	})
 	qualityScore = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "quality",
 		Name:        "score",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets:     []float64{1.0, 2.0, 2.5, 3.0, 3.25, 3.5, 3.75, 4.0, 4.25, 4.5},
+		Buckets:     getBuckets(histogramBuckets, "quality", "score", []float64{1.0, 2.0, 2.5, 3.0, 3.25, 3.5, 3.75, 4.0, 4.25, 4.5}),
 	})
 	qualityDrop = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
@@ -47,10 +51,22 @@ func initQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "drop",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"direction"})
+	promParticipantQuality = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "quality",
+		Name:        "participant_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Current participants by their last evaluated connection quality.",
+	}, []string{"quality"})
+
+	mustRegister(qualityRating)
+	mustRegister(qualityScore)
+	mustRegister(qualityDrop)
+	mustRegister(promParticipantQuality)
 
-	prometheus.MustRegister(qualityRating)
-	prometheus.MustRegister(qualityScore)
-	prometheus.MustRegister(qualityDrop)
+	for _, q := range livekit.ConnectionQuality_name {
+		promParticipantQuality.WithLabelValues(strings.ToLower(q)).Set(0)
+	}
 }
 
 func RecordQuality(rating livekit.ConnectionQuality, score float32, numUpDrops int, numDownDrops int) {
@@ -59,3 +75,15 @@ func RecordQuality(rating livekit.ConnectionQuality, score float32, numUpDrops i
 	qualityDrop.WithLabelValues("up").Add(float64(numUpDrops))
 	qualityDrop.WithLabelValues("down").Add(float64(numDownDrops))
 }
+
+func AddParticipantQuality(quality livekit.ConnectionQuality) {
+	promParticipantQuality.WithLabelValues(qualityLabel(quality)).Add(1)
+}
+
+func SubParticipantQuality(quality livekit.ConnectionQuality) {
+	promParticipantQuality.WithLabelValues(qualityLabel(quality)).Sub(1)
+}
+
+func qualityLabel(quality livekit.ConnectionQuality) string {
+	return strings.ToLower(quality.String())
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type ReconnectType string
+
+const (
+	// client kept its session and resumed on the existing participant
+	ReconnectTypeResume ReconnectType = "resume"
+	// client joined again with a new session
+	ReconnectTypeFull ReconnectType = "full"
+)
+
+var (
+	promReconnects *prometheus.CounterVec
+	promResumeTime prometheus.Histogram
+)
+
+func initReconnectStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
+	promReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant_reconnect",
+		Name:        "total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Participant reconnects by type. Full reconnects are either requested by the server or replaced when a participant rejoins with the identity of a session still in the room.",
+	}, []string{"type", "result", "reason"})
+	promResumeTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant_reconnect",
+		Name:        "resume_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Time from the last signal message seen before the interruption to the session being resumed.",
+		Buckets:     getBuckets(histogramBuckets, "participant_reconnect", "resume_ms", []float64{250, 500, 1000, 2000, 3000, 5000, 7500, 10000, 15000, 20000, 30000}),
+	})
+
+	mustRegister(promReconnects)
+	mustRegister(promResumeTime)
+}
+
+// RecordReconnect counts a reconnect, result is one of requested, replaced, success or failure
+func RecordReconnect(reconnectType ReconnectType, result string, reason string) {
+	promReconnects.WithLabelValues(string(reconnectType), result, reason).Inc()
+}
+
+func RecordResumeTime(d time.Duration) {
+	promResumeTime.Observe(float64(d.Milliseconds()))
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// StatsReporter implements telemetry.StatsReporter on top of the package level metrics,
+// Init must be called before it is used
+type StatsReporter struct{}
+
+func (StatsReporter) RoomStarted(roomName livekit.RoomName) {
+	RoomStarted(roomName)
+}
+
+func (StatsReporter) RoomEnded(roomName livekit.RoomName, roomID livekit.RoomID, startedAt time.Time) {
+	RoomEnded(roomName, roomID, startedAt)
+}
+
+func (StatsReporter) AddParticipant(roomName livekit.RoomName) {
+	AddParticipant(roomName)
+}
+
+func (StatsReporter) SubParticipant(roomName livekit.RoomName) {
+	SubParticipant(roomName)
+}
+
+func (StatsReporter) IncrementParticipantRtcConnected(join uint32) {
+	IncrementParticipantRtcConnected(join)
+}
+
+func (StatsReporter) RecordParticipantTraffic(roomName livekit.RoomName, publisherBytes uint64, subscriberBytes uint64) {
+	RecordParticipantTraffic(roomName, publisherBytes, subscriberBytes)
+}
+
+func (StatsReporter) AddPublishAttempt(roomName livekit.RoomName, kind string) {
+	AddPublishAttempt(roomName, kind)
+}
+
+func (StatsReporter) AddPublishSuccess(roomName livekit.RoomName, kind string) {
+	AddPublishSuccess(roomName, kind)
+}
+
+func (StatsReporter) AddPublishedTrack(roomName livekit.RoomName, kind string, codec string) {
+	AddPublishedTrack(roomName, kind, codec)
+}
+
+func (StatsReporter) SubPublishedTrack(roomName livekit.RoomName, kind string, codec string) {
+	SubPublishedTrack(roomName, kind, codec)
+}
+
+func (StatsReporter) RecordTrackSubscribeAttempt(roomName livekit.RoomName) {
+	RecordTrackSubscribeAttempt(roomName)
+}
+
+func (StatsReporter) RecordTrackSubscribeSuccess(roomName livekit.RoomName, kind string, codec string) {
+	RecordTrackSubscribeSuccess(roomName, kind, codec)
+}
+
+func (StatsReporter) RecordTrackSubscribeFailure(roomName livekit.RoomName, reason string, isUserError bool) {
+	RecordTrackSubscribeFailure(roomName, reason, isUserError)
+}
+
+func (StatsReporter) RecordTrackUnsubscribed(roomName livekit.RoomName, kind string, codec string) {
+	RecordTrackUnsubscribed(roomName, kind, codec)
+}
+
+func (StatsReporter) IncrementPackets(direction Direction, count uint64, retransmit bool) {
+	IncrementPackets(direction, count, retransmit)
+}
+
+func (StatsReporter) IncrementBytes(direction Direction, count uint64, retransmit bool) {
+	IncrementBytes(direction, count, retransmit)
+}
+
+func (StatsReporter) IncrementForwarded(direction Direction, kind string, packets uint64, bytes uint64) {
+	IncrementForwarded(direction, kind, packets, bytes)
+}
+
+func (StatsReporter) IncrementRTCP(direction Direction, nack, pli, fir uint32) {
+	IncrementRTCP(direction, nack, pli, fir)
+}
+
+func (StatsReporter) RecordPacketLoss(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, lost, total uint32) {
+	RecordPacketLoss(direction, trackSource, trackType, lost, total)
+}
+
+func (StatsReporter) RecordRTT(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, rtt uint32) {
+	RecordRTT(direction, trackSource, trackType, rtt)
+}
+
+func (StatsReporter) RecordJitter(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, jitter uint32) {
+	RecordJitter(direction, trackSource, trackType, jitter)
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	roomLabel         = "room"
+	roomLabelOverflow = "other"
+)
+
+var roomLabels = &roomLabeler{}
+
+// roomLabeler keeps track of the label value each room is counted under. Rooms are admitted when they start and
+// released when they end, rooms started while the limit is reached are counted under roomLabelOverflow for their
+// lifetime. Gauges of a room are changed in the series it was counted in, the series of an admitted room are removed
+// as it ends, and what an overflow room still counts is taken off roomLabelOverflow.
+type roomLabeler struct {
+	enabled  bool
+	maxRooms int
+
+	lock     sync.Mutex
+	rooms    map[livekit.RoomName]string
+	admitted int
+	// what the rooms counted under roomLabelOverflow add to its gauges
+	overflow map[livekit.RoomName]map[string]*overflowGauge
+	vecs     []*prometheus.MetricVec
+}
+
+type overflowGauge struct {
+	vec    *prometheus.GaugeVec
+	values []string
+	value  float64
+}
+
+func (r *roomLabeler) init(conf config.RoomLabelsConfig) {
+	r.enabled = conf.Enabled
+	r.maxRooms = conf.MaxRooms
+	r.rooms = make(map[livekit.RoomName]string)
+	r.admitted = 0
+	r.overflow = make(map[livekit.RoomName]map[string]*overflowGauge)
+}
+
+// reset forgets the rooms and vectors, the vectors are registered again as the metrics are initialized
+func (r *roomLabeler) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.rooms = make(map[livekit.RoomName]string)
+	r.admitted = 0
+	r.overflow = make(map[livekit.RoomName]map[string]*overflowGauge)
+	r.vecs = nil
+}
+
+// labels prepends the room label to the given label names when room labels are enabled
+func (r *roomLabeler) labels(labels ...string) []string {
+	if !r.enabled {
+		return labels
+	}
+	return append([]string{roomLabel}, labels...)
+}
+
+// values prepends the label value for the room to the given label values when room labels are enabled, rooms that
+// are not started are counted under roomLabelOverflow
+func (r *roomLabeler) values(roomName livekit.RoomName, values ...string) []string {
+	if !r.enabled {
+		return values
+	}
+
+	r.lock.Lock()
+	label, ok := r.rooms[roomName]
+	r.lock.Unlock()
+	if !ok {
+		label = roomLabelOverflow
+	}
+	return append([]string{label}, values...)
+}
+
+// addGauge adds delta to the gauge of the room in the series the room is counted in. changes of rooms that have
+// ended are dropped, as their counts have been removed with them
+func (r *roomLabeler) addGauge(vec *prometheus.GaugeVec, roomName livekit.RoomName, delta float64, values ...string) {
+	if !r.enabled {
+		vec.WithLabelValues(values...).Add(delta)
+		return
+	}
+
+	r.lock.Lock()
+	label, ok := r.rooms[roomName]
+	if !ok {
+		r.lock.Unlock()
+		return
+	}
+	labelValues := append([]string{label}, values...)
+	if label == roomLabelOverflow {
+		gauges := r.overflow[roomName]
+		if gauges == nil {
+			gauges = make(map[string]*overflowGauge)
+			r.overflow[roomName] = gauges
+		}
+		key := fmt.Sprintf("%p|%s", vec, strings.Join(values, "|"))
+		g := gauges[key]
+		if g == nil {
+			g = &overflowGauge{vec: vec, values: labelValues}
+			gauges[key] = g
+		}
+		g.value += delta
+	}
+	// changed under the lock, for the count of an overflow room to be taken off exactly once as it ends
+	vec.WithLabelValues(labelValues...).Add(delta)
+	r.lock.Unlock()
+}
+
+// register records a vector whose room series should be removed when a room ends
+func (r *roomLabeler) register(vec *prometheus.MetricVec) {
+	if !r.enabled {
+		return
+	}
+
+	r.lock.Lock()
+	r.vecs = append(r.vecs, vec)
+	r.lock.Unlock()
+}
+
+func (r *roomLabeler) add(roomName livekit.RoomName) {
+	if !r.enabled || roomName == "" {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.rooms[roomName]; ok {
+		return
+	}
+	if r.maxRooms > 0 && r.admitted >= r.maxRooms {
+		r.rooms[roomName] = roomLabelOverflow
+		return
+	}
+	r.rooms[roomName] = string(roomName)
+	r.admitted++
+}
+
+func (r *roomLabeler) remove(roomName livekit.RoomName) {
+	if !r.enabled {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	label, ok := r.rooms[roomName]
+	if !ok {
+		return
+	}
+	delete(r.rooms, roomName)
+
+	if label == roomLabelOverflow {
+		for _, g := range r.overflow[roomName] {
+			g.vec.WithLabelValues(g.values...).Sub(g.value)
+		}
+		delete(r.overflow, roomName)
+		return
+	}
+
+	r.admitted--
+	for _, vec := range r.vecs {
+		vec.DeletePartialMatch(prometheus.Labels{roomLabel: string(roomName)})
+	}
+}
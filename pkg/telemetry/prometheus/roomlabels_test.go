@@ -0,0 +1,94 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestRoomLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	Reset()
+	InitWithRegisterer(registry, "node", livekit.NodeType_SERVER, "test", config.TelemetryConfig{
+		RoomLabels: config.RoomLabelsConfig{Enabled: true, MaxRooms: 1},
+	})
+	t.Cleanup(Reset)
+
+	participants := func() map[string]float64 {
+		return gatherRoomGauge(t, registry, "livekit_participant_total")
+	}
+
+	RoomStarted("admitted")
+	RoomStarted("overflow")
+	AddParticipant("admitted")
+	AddParticipant("overflow")
+	AddParticipant("overflow")
+	AddPublishedTrack("overflow", "video", "vp8")
+	require.Equal(t, map[string]float64{"admitted": 1, roomLabelOverflow: 2}, participants())
+
+	t.Run("overflow rooms take their counts off as they end", func(t *testing.T) {
+		SubParticipant("overflow")
+		RoomEnded("overflow", "RM_overflow", time.Time{})
+		require.Equal(t, map[string]float64{"admitted": 1, roomLabelOverflow: 0}, participants())
+		require.Equal(t, map[string]float64{roomLabelOverflow: 0}, gatherRoomGauge(t, registry, "livekit_track_published_total"))
+
+		// participants leaving after the room has ended are not counted again
+		SubParticipant("overflow")
+		SubPublishedTrack("overflow", "video", "vp8")
+		require.Equal(t, map[string]float64{"admitted": 1, roomLabelOverflow: 0}, participants())
+		require.Equal(t, map[string]float64{roomLabelOverflow: 0}, gatherRoomGauge(t, registry, "livekit_track_published_total"))
+	})
+
+	t.Run("series of admitted rooms are removed as they end", func(t *testing.T) {
+		RoomEnded("admitted", "RM_admitted", time.Time{})
+		SubParticipant("admitted")
+		require.Equal(t, map[string]float64{roomLabelOverflow: 0}, participants())
+	})
+
+	t.Run("rooms are admitted after others have ended", func(t *testing.T) {
+		RoomStarted("next")
+		AddParticipant("next")
+		require.Equal(t, map[string]float64{"next": 1, roomLabelOverflow: 0}, participants())
+		RoomEnded("next", "RM_next", time.Time{})
+	})
+}
+
+// gatherRoomGauge returns the values of the gauge by room label
+func gatherRoomGauge(t *testing.T, registry *prometheus.Registry, name string) map[string]float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == roomLabel {
+					values[label.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
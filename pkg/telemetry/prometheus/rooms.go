@@ -21,6 +21,8 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/utils"
 )
 
 var (
@@ -38,14 +40,19 @@ var (
 
 	promRoomCurrent            prometheus.Gauge
 	promRoomDuration           prometheus.Histogram
-	promParticipantCurrent     prometheus.Gauge
+	promParticipantCurrent     *prometheus.GaugeVec
+	promParticipantTraffic     *prometheus.HistogramVec
 	promTrackPublishedCurrent  *prometheus.GaugeVec
 	promTrackSubscribedCurrent *prometheus.GaugeVec
 	promTrackPublishCounter    *prometheus.CounterVec
 	promTrackSubscribeCounter  *prometheus.CounterVec
+
+	// rooms and participants by project, rooms of the default project are reported with an empty project
+	promProjectRoomCurrent        *prometheus.GaugeVec
+	promProjectParticipantCurrent *prometheus.GaugeVec
 )
 
-func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
+func initRoomStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
 	promRoomCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "room",
@@ -57,116 +64,166 @@ func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Subsystem:   "room",
 		Name:        "duration_seconds",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-		Buckets: []float64{
+		Buckets: getBuckets(histogramBuckets, "room", "duration_seconds", []float64{
 			5, 10, 60, 5 * 60, 10 * 60, 30 * 60, 60 * 60, 2 * 60 * 60, 5 * 60 * 60, 10 * 60 * 60,
-		},
+		}),
 	})
-	promParticipantCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
+	promParticipantCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "participant",
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-	})
+	}, roomLabels.labels())
+	promParticipantTraffic = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant",
+		Name:        "traffic_bytes",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Total traffic of a participant observed when it leaves, publisher is received from and subscriber is sent to the participant.",
+		// 100KB to 10GB
+		Buckets: getBuckets(histogramBuckets, "participant", "traffic_bytes", prometheus.ExponentialBuckets(100_000, 10, 6)),
+	}, roomLabels.labels("direction"))
 	promTrackPublishedCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "track",
 		Name:        "published_total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-	}, []string{"kind"})
+	}, roomLabels.labels("kind", "codec"))
 	promTrackSubscribedCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "track",
 		Name:        "subscribed_total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-	}, []string{"kind"})
+	}, roomLabels.labels("kind", "codec"))
 	promTrackPublishCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "track",
 		Name:        "publish_counter",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-	}, []string{"kind", "state"})
+	}, roomLabels.labels("kind", "state"))
 	promTrackSubscribeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "track",
 		Name:        "subscribe_counter",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
-	}, []string{"state", "error"})
-
-	prometheus.MustRegister(promRoomCurrent)
-	prometheus.MustRegister(promRoomDuration)
-	prometheus.MustRegister(promParticipantCurrent)
-	prometheus.MustRegister(promTrackPublishedCurrent)
-	prometheus.MustRegister(promTrackSubscribedCurrent)
-	prometheus.MustRegister(promTrackPublishCounter)
-	prometheus.MustRegister(promTrackSubscribeCounter)
+	}, roomLabels.labels("state", "error"))
+	promProjectRoomCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "project",
+		Name:        "room_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"project"})
+	promProjectParticipantCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "project",
+		Name:        "participant_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"project"})
+
+	mustRegister(promRoomCurrent)
+	mustRegister(promRoomDuration)
+	mustRegister(promParticipantCurrent)
+	mustRegister(promParticipantTraffic)
+	mustRegister(promTrackPublishedCurrent)
+	mustRegister(promTrackSubscribedCurrent)
+	mustRegister(promTrackPublishCounter)
+	mustRegister(promTrackSubscribeCounter)
+	mustRegister(promProjectRoomCurrent)
+	mustRegister(promProjectParticipantCurrent)
+
+	roomLabels.register(promParticipantCurrent.MetricVec)
+	roomLabels.register(promTrackPublishedCurrent.MetricVec)
+	roomLabels.register(promTrackSubscribedCurrent.MetricVec)
+	roomLabels.register(promTrackPublishCounter.MetricVec)
+	roomLabels.register(promTrackSubscribeCounter.MetricVec)
 }
 
-func RoomStarted() {
+func RoomStarted(roomName livekit.RoomName) {
+	roomLabels.add(roomName)
+
 	promRoomCurrent.Add(1)
+	promProjectRoomCurrent.WithLabelValues(projectOf(roomName)).Add(1)
 	roomCurrent.Inc()
 }
 
-func RoomEnded(startedAt time.Time) {
+func RoomEnded(roomName livekit.RoomName, roomID livekit.RoomID, startedAt time.Time) {
 	if !startedAt.IsZero() {
-		promRoomDuration.Observe(float64(time.Since(startedAt)) / float64(time.Second))
+		observeWithExemplar(promRoomDuration, float64(time.Since(startedAt))/float64(time.Second), prometheus.Labels{"room_sid": string(roomID)})
 	}
 	promRoomCurrent.Sub(1)
+	promProjectRoomCurrent.WithLabelValues(projectOf(roomName)).Sub(1)
 	roomCurrent.Dec()
+
+	roomLabels.remove(roomName)
 }
 
-func AddParticipant() {
-	promParticipantCurrent.Add(1)
+func AddParticipant(roomName livekit.RoomName) {
+	roomLabels.addGauge(promParticipantCurrent, roomName, 1)
+	promProjectParticipantCurrent.WithLabelValues(projectOf(roomName)).Add(1)
 	participantCurrent.Inc()
 }
 
-func SubParticipant() {
-	promParticipantCurrent.Sub(1)
+func SubParticipant(roomName livekit.RoomName) {
+	roomLabels.addGauge(promParticipantCurrent, roomName, -1)
+	promProjectParticipantCurrent.WithLabelValues(projectOf(roomName)).Sub(1)
 	participantCurrent.Dec()
 }
 
-func AddPublishedTrack(kind string) {
-	promTrackPublishedCurrent.WithLabelValues(kind).Add(1)
+func projectOf(roomName livekit.RoomName) string {
+	project, _ := utils.SplitProjectRoomName(roomName)
+	return project
+}
+
+func RecordParticipantTraffic(roomName livekit.RoomName, publisherBytes uint64, subscriberBytes uint64) {
+	promParticipantTraffic.WithLabelValues(roomLabels.values(roomName, "publisher")...).Observe(float64(publisherBytes))
+	promParticipantTraffic.WithLabelValues(roomLabels.values(roomName, "subscriber")...).Observe(float64(subscriberBytes))
+}
+
+func AddPublishedTrack(roomName livekit.RoomName, kind string, codec string) {
+	roomLabels.addGauge(promTrackPublishedCurrent, roomName, 1, kind, codec)
 	trackPublishedCurrent.Inc()
 }
 
-func SubPublishedTrack(kind string) {
-	promTrackPublishedCurrent.WithLabelValues(kind).Sub(1)
+func SubPublishedTrack(roomName livekit.RoomName, kind string, codec string) {
+	roomLabels.addGauge(promTrackPublishedCurrent, roomName, -1, kind, codec)
 	trackPublishedCurrent.Dec()
 }
 
-func AddPublishAttempt(kind string) {
+func AddPublishAttempt(roomName livekit.RoomName, kind string) {
 	trackPublishAttempts.Inc()
-	promTrackPublishCounter.WithLabelValues(kind, "attempt").Inc()
+	promTrackPublishCounter.WithLabelValues(roomLabels.values(roomName, kind, "attempt")...).Inc()
 }
 
-func AddPublishSuccess(kind string) {
+func AddPublishSuccess(roomName livekit.RoomName, kind string) {
 	trackPublishSuccess.Inc()
-	promTrackPublishCounter.WithLabelValues(kind, "success").Inc()
+	promTrackPublishCounter.WithLabelValues(roomLabels.values(roomName, kind, "success")...).Inc()
 }
 
-func RecordTrackSubscribeSuccess(kind string) {
+func RecordTrackSubscribeSuccess(roomName livekit.RoomName, kind string, codec string) {
 	// modify both current and total counters
-	promTrackSubscribedCurrent.WithLabelValues(kind).Add(1)
+	roomLabels.addGauge(promTrackSubscribedCurrent, roomName, 1, kind, codec)
 	trackSubscribedCurrent.Inc()
 
-	promTrackSubscribeCounter.WithLabelValues("success", "").Inc()
+	promTrackSubscribeCounter.WithLabelValues(roomLabels.values(roomName, "success", "")...).Inc()
 	trackSubscribeSuccess.Inc()
 }
 
-func RecordTrackUnsubscribed(kind string) {
+func RecordTrackUnsubscribed(roomName livekit.RoomName, kind string, codec string) {
 	// unsubscribed modifies current counter, but we leave the total values alone since they
 	// are used to compute rate
-	promTrackSubscribedCurrent.WithLabelValues(kind).Sub(1)
+	roomLabels.addGauge(promTrackSubscribedCurrent, roomName, -1, kind, codec)
 	trackSubscribedCurrent.Dec()
 }
 
-func RecordTrackSubscribeAttempt() {
+func RecordTrackSubscribeAttempt(roomName livekit.RoomName) {
 	trackSubscribeAttempts.Inc()
-	promTrackSubscribeCounter.WithLabelValues("attempt", "").Inc()
+	promTrackSubscribeCounter.WithLabelValues(roomLabels.values(roomName, "attempt", "")...).Inc()
 }
 
-func RecordTrackSubscribeFailure(err error, isUserError bool) {
-	promTrackSubscribeCounter.WithLabelValues("failure", err.Error()).Inc()
+// RecordTrackSubscribeFailure counts a failed subscription, reason must come from a bounded set
+// as it is used as a label, i.e. rtc.SubscribeErrorReason
+func RecordTrackSubscribeFailure(roomName livekit.RoomName, reason string, isUserError bool) {
+	promTrackSubscribeCounter.WithLabelValues(roomLabels.values(roomName, "failure", reason)...).Inc()
 
 	if isUserError {
 		trackSubscribeUserError.Inc()
@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promJoinConnectedTime       prometheus.Histogram
+	promOfferAnswerTime         prometheus.Histogram
+	promSubscribeFirstFrameTime *prometheus.HistogramVec
+)
+
+func initSignalingStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
+	promJoinConnectedTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant_join",
+		Name:        "connected_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Time from the participant joining to the participant becoming active.",
+		Buckets:     getBuckets(histogramBuckets, "participant_join", "connected_ms", []float64{100, 250, 500, 750, 1000, 1500, 2000, 3000, 5000, 10000, 20000}),
+	})
+	promOfferAnswerTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "signal",
+		Name:        "offer_answer_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Time from sending a subscriber offer to receiving the client's answer.",
+		Buckets:     getBuckets(histogramBuckets, "signal", "offer_answer_ms", []float64{25, 50, 100, 200, 300, 500, 750, 1000, 2000, 5000, 10000}),
+	})
+	promSubscribeFirstFrameTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "subscribe_first_frame_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Time from a track subscription request to forwarding the first packet. Video is forwarded from a key frame.",
+		Buckets:     getBuckets(histogramBuckets, "track", "subscribe_first_frame_ms", []float64{100, 250, 500, 750, 1000, 1500, 2000, 3000, 5000, 10000, 20000}),
+	}, []string{"kind"})
+
+	mustRegister(promJoinConnectedTime)
+	mustRegister(promOfferAnswerTime)
+	mustRegister(promSubscribeFirstFrameTime)
+}
+
+func RecordJoinConnectedTime(roomID livekit.RoomID, participantID livekit.ParticipantID, d time.Duration) {
+	observeWithExemplar(promJoinConnectedTime, float64(d.Milliseconds()), prometheus.Labels{
+		"room_sid":        string(roomID),
+		"participant_sid": string(participantID),
+	})
+}
+
+func RecordOfferAnswerTime(d time.Duration) {
+	promOfferAnswerTime.Observe(float64(d.Milliseconds()))
+}
+
+func RecordSubscribeFirstFrameTime(kind livekit.TrackType, participantID livekit.ParticipantID, trackID livekit.TrackID, d time.Duration) {
+	observeWithExemplar(promSubscribeFirstFrameTime.WithLabelValues(kind.String()), float64(d.Milliseconds()), prometheus.Labels{
+		"participant_sid": string(participantID),
+		"track_sid":       string(trackID),
+	})
+}
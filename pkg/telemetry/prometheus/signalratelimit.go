@@ -0,0 +1,46 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promSignalRateLimitRequests *prometheus.CounterVec
+)
+
+func initSignalRateLimitStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promSignalRateLimitRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "signal_rate_limit",
+		Name:        "requests_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Signal requests checked against the rate limits of their client address, the limit is connections or joins.",
+	}, []string{"limit", "result"})
+
+	mustRegister(promSignalRateLimitRequests)
+}
+
+// RecordSignalRateLimit counts a signal request checked against a rate limit, result is allowed or limited
+func RecordSignalRateLimit(limit string, allowed bool) {
+	result := "allowed"
+	if !allowed {
+		result = "limited"
+	}
+	promSignalRateLimitRequests.WithLabelValues(limit, result).Inc()
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+// StatsSnapshot is a point in time copy of the in-memory node counters
+type StatsSnapshot struct {
+	Rooms                      int32   `json:"rooms"`
+	Participants               int32   `json:"participants"`
+	TracksPublished            int32   `json:"tracks_published"`
+	TracksSubscribed           int32   `json:"tracks_subscribed"`
+	TrackPublishAttempts       int32   `json:"track_publish_attempts"`
+	TrackPublishSuccess        int32   `json:"track_publish_success"`
+	TrackPublishSuccessRate    float64 `json:"track_publish_success_rate"`
+	TrackSubscribeAttempts     int32   `json:"track_subscribe_attempts"`
+	TrackSubscribeSuccess      int32   `json:"track_subscribe_success"`
+	TrackSubscribeUserErrors   int32   `json:"track_subscribe_user_errors"`
+	TrackSubscribeSuccessRate  float64 `json:"track_subscribe_success_rate"`
+	ParticipantSignalConnected uint64  `json:"participant_signal_connected"`
+	ParticipantRTCInit         uint64  `json:"participant_rtc_init"`
+	ParticipantRTCConnected    uint64  `json:"participant_rtc_connected"`
+	BytesIn                    uint64  `json:"bytes_in"`
+	BytesOut                   uint64  `json:"bytes_out"`
+	PacketsIn                  uint64  `json:"packets_in"`
+	PacketsOut                 uint64  `json:"packets_out"`
+	NackTotal                  uint64  `json:"nack_total"`
+	PliTotal                   uint64  `json:"pli_total"`
+	FirTotal                   uint64  `json:"fir_total"`
+	RetransmitBytes            uint64  `json:"retransmit_bytes"`
+	RetransmitPackets          uint64  `json:"retransmit_packets"`
+}
+
+func GetStatsSnapshot() StatsSnapshot {
+	s := StatsSnapshot{
+		Rooms:                      roomCurrent.Load(),
+		Participants:               participantCurrent.Load(),
+		TracksPublished:            trackPublishedCurrent.Load(),
+		TracksSubscribed:           trackSubscribedCurrent.Load(),
+		TrackPublishAttempts:       trackPublishAttempts.Load(),
+		TrackPublishSuccess:        trackPublishSuccess.Load(),
+		TrackSubscribeAttempts:     trackSubscribeAttempts.Load(),
+		TrackSubscribeSuccess:      trackSubscribeSuccess.Load(),
+		TrackSubscribeUserErrors:   trackSubscribeUserError.Load(),
+		ParticipantSignalConnected: participantSignalConnected.Load(),
+		ParticipantRTCInit:         participantRTCInit.Load(),
+		ParticipantRTCConnected:    participantRTCConnected.Load(),
+		BytesIn:                    bytesIn.Load(),
+		BytesOut:                   bytesOut.Load(),
+		PacketsIn:                  packetsIn.Load(),
+		PacketsOut:                 packetsOut.Load(),
+		NackTotal:                  nackTotal.Load(),
+		PliTotal:                   pliTotal.Load(),
+		FirTotal:                   firTotal.Load(),
+		RetransmitBytes:            retransmitBytes.Load(),
+		RetransmitPackets:          retransmitPackets.Load(),
+	}
+	s.TrackPublishSuccessRate = successRate(s.TrackPublishSuccess, s.TrackPublishAttempts)
+	s.TrackSubscribeSuccessRate = successRate(s.TrackSubscribeSuccess, s.TrackSubscribeAttempts)
+	return s
+}
+
+func successRate(success, attempts int32) float64 {
+	if attempts <= 0 {
+		return 0
+	}
+	return float64(success) / float64(attempts)
+}
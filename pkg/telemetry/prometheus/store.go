@@ -0,0 +1,74 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	storeOperationTime       *prometheus.HistogramVec
+	storeOperationErrorTotal *prometheus.CounterVec
+	storeCacheLookupTotal    *prometheus.CounterVec
+)
+
+func initStoreStats(nodeID string, nodeType livekit.NodeType, env string, histogramBuckets map[string][]float64) {
+	labels := []string{"store", "operation"}
+
+	storeOperationTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "store",
+		Name:        "operation_time_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     getBuckets(histogramBuckets, "store", "operation_time_ms", []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500}),
+	}, labels)
+	storeOperationErrorTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "store",
+		Name:        "operation_error_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, labels)
+	storeCacheLookupTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "store",
+		Name:        "cache_lookup_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"result"})
+
+	mustRegister(storeOperationTime)
+	mustRegister(storeOperationErrorTotal)
+	mustRegister(storeCacheLookupTotal)
+}
+
+// RecordStoreOperation records the latency of a store operation, failed is set for errors other than
+// expected outcomes such as a room not being found
+func RecordStoreOperation(store string, operation string, duration time.Duration, failed bool) {
+	storeOperationTime.WithLabelValues(store, operation).Observe(float64(duration.Milliseconds()))
+	if failed {
+		storeOperationErrorTotal.WithLabelValues(store, operation).Inc()
+	}
+}
+
+func RecordStoreCacheLookup(hit bool) {
+	if hit {
+		storeCacheLookupTotal.WithLabelValues("hit").Inc()
+	} else {
+		storeCacheLookupTotal.WithLabelValues("miss").Inc()
+	}
+}
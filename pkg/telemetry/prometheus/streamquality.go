@@ -0,0 +1,145 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+// streamQualityWindow accumulates the RTCP derived stats of all streams in one direction
+// between two updates of the node level gauges
+type streamQualityWindow struct {
+	rttSum      uint64
+	rttCount    uint64
+	jitterSum   uint64
+	jitterCount uint64
+	lost        uint64
+	total       uint64
+}
+
+var (
+	streamQualityLock    sync.Mutex
+	streamQualityWindows = map[Direction]*streamQualityWindow{
+		Incoming: {},
+		Outgoing: {},
+	}
+
+	promNodeRTT        *prometheus.GaugeVec
+	promNodeJitter     *prometheus.GaugeVec
+	promNodePacketLoss *prometheus.GaugeVec
+)
+
+func initStreamQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promNodeRTT = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "rtt_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Average RTT of all streams on the node over the last update interval.",
+	}, promRTCPLabels)
+	promNodeJitter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "jitter_us",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Average jitter of all streams on the node over the last update interval.",
+	}, promRTCPLabels)
+	promNodePacketLoss = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "packet_loss_percent",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Packet loss percentage of all streams on the node over the last update interval.",
+	}, promRTCPLabels)
+
+	mustRegister(promNodeRTT)
+	mustRegister(promNodeJitter)
+	mustRegister(promNodePacketLoss)
+
+	go streamQualityWorker(done)
+}
+
+func streamQualityWorker(done <-chan struct{}) {
+	ticker := time.NewTicker(config.StatsUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			updateStreamQualityStats()
+		}
+	}
+}
+
+// updateStreamQualityStats sets the node level gauges from the stats accumulated since the last update.
+// Gauges drop to 0 when no stream reported in a direction during the interval.
+func updateStreamQualityStats() {
+	streamQualityLock.Lock()
+	defer streamQualityLock.Unlock()
+
+	for direction, w := range streamQualityWindows {
+		var rtt, jitter, loss float64
+		if w.rttCount > 0 {
+			rtt = float64(w.rttSum) / float64(w.rttCount)
+		}
+		if w.jitterCount > 0 {
+			jitter = float64(w.jitterSum) / float64(w.jitterCount)
+		}
+		if w.total > 0 {
+			loss = float64(w.lost) / float64(w.total) * 100
+		}
+
+		promNodeRTT.WithLabelValues(string(direction)).Set(rtt)
+		promNodeJitter.WithLabelValues(string(direction)).Set(jitter)
+		promNodePacketLoss.WithLabelValues(string(direction)).Set(loss)
+
+		*w = streamQualityWindow{}
+	}
+}
+
+func addStreamPacketLoss(direction Direction, lost, total uint32) {
+	streamQualityLock.Lock()
+	if w := streamQualityWindows[direction]; w != nil {
+		w.lost += uint64(lost)
+		w.total += uint64(total)
+	}
+	streamQualityLock.Unlock()
+}
+
+func addStreamJitter(direction Direction, jitter uint32) {
+	streamQualityLock.Lock()
+	if w := streamQualityWindows[direction]; w != nil {
+		w.jitterSum += uint64(jitter)
+		w.jitterCount++
+	}
+	streamQualityLock.Unlock()
+}
+
+func addStreamRTT(direction Direction, rtt uint32) {
+	streamQualityLock.Lock()
+	if w := streamQualityWindows[direction]; w != nil {
+		w.rttSum += uint64(rtt)
+		w.rttCount++
+	}
+	streamQualityLock.Unlock()
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/mackerelio/go-osstat/memory"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/livekit"
+)
+
+type nicStats struct {
+	rxBytes uint64
+	txBytes uint64
+}
+
+type udpStats struct {
+	inErrors     uint64
+	rcvbufErrors uint64
+	sndbufErrors uint64
+}
+
+var (
+	// sysCPUStats is separate from nodeCPUStats so that both keep their own sampling window
+	sysCPUStats    cpuStatsTracker
+	sysNICLast     map[string]nicStats
+	sysNICLastAt   time.Time
+	sysUDPStart    udpStats
+	sysUDPStartErr error
+
+	promSysCPULoad       prometheus.Gauge
+	promSysNumCPUs       prometheus.Gauge
+	promSysMemory        *prometheus.GaugeVec
+	promSysLoadAvg       *prometheus.GaugeVec
+	promSysNICThroughput *prometheus.GaugeVec
+	promSysUDPErrors     *prometheus.GaugeVec
+)
+
+func initSystemStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promSysCPULoad = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "cpu_load",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "CPU load of the node over the last update interval, between 0 and 1.",
+	})
+	promSysNumCPUs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "num_cpus",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	})
+	promSysMemory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "memory_bytes",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"type"})
+	promSysLoadAvg = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "load_avg",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"window"})
+	promSysNICThroughput = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "nic_bytes_per_sec",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Network interface throughput over the last update interval, linux only.",
+	}, []string{"interface", "direction"})
+	promSysUDPErrors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "node",
+		Name:        "udp_errors",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "System level UDP socket errors, linux only. Count starts at 0 when service is first started.",
+	}, []string{"type"})
+
+	mustRegister(promSysCPULoad)
+	mustRegister(promSysNumCPUs)
+	mustRegister(promSysMemory)
+	mustRegister(promSysLoadAvg)
+	mustRegister(promSysNICThroughput)
+	mustRegister(promSysUDPErrors)
+
+	// take the baselines, the first update reports usage since start
+	_, _, _ = sysCPUStats.get()
+	sysNICLast, _ = getNICStats()
+	sysNICLastAt = time.Now()
+	sysUDPStart, sysUDPStartErr = getUDPStats()
+
+	go systemStatsWorker(done)
+}
+
+func systemStatsWorker(done <-chan struct{}) {
+	ticker := time.NewTicker(config.StatsUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			updateSystemStats()
+		}
+	}
+}
+
+// updateSystemStats samples the same sources as node load reporting.
+// Sources that are not available on the platform are skipped.
+func updateSystemStats() {
+	if cpuLoad, numCPUs, err := sysCPUStats.get(); err == nil {
+		promSysCPULoad.Set(float64(cpuLoad))
+		promSysNumCPUs.Set(float64(numCPUs))
+	}
+
+	if memInfo, err := memory.Get(); err == nil && memInfo != nil {
+		promSysMemory.WithLabelValues("total").Set(float64(memInfo.Total))
+		promSysMemory.WithLabelValues("used").Set(float64(memInfo.Used))
+	}
+
+	if loadAvg, err := getLoadAvg(); err == nil {
+		promSysLoadAvg.WithLabelValues("1m").Set(loadAvg.Loadavg1)
+		promSysLoadAvg.WithLabelValues("5m").Set(loadAvg.Loadavg5)
+		promSysLoadAvg.WithLabelValues("15m").Set(loadAvg.Loadavg15)
+	}
+
+	if nics, err := getNICStats(); err == nil && len(nics) != 0 {
+		now := time.Now()
+		elapsed := now.Sub(sysNICLastAt).Seconds()
+		for name, curr := range nics {
+			prev, ok := sysNICLast[name]
+			// skip new interfaces and counter resets
+			if !ok || elapsed <= 0 || curr.rxBytes < prev.rxBytes || curr.txBytes < prev.txBytes {
+				continue
+			}
+			promSysNICThroughput.WithLabelValues(name, "in").Set(float64(curr.rxBytes-prev.rxBytes) / elapsed)
+			promSysNICThroughput.WithLabelValues(name, "out").Set(float64(curr.txBytes-prev.txBytes) / elapsed)
+		}
+		sysNICLast = nics
+		sysNICLastAt = now
+	}
+
+	if sysUDPStartErr == nil {
+		if udp, err := getUDPStats(); err == nil {
+			promSysUDPErrors.WithLabelValues("in_errors").Set(float64(udp.inErrors - sysUDPStart.inErrors))
+			promSysUDPErrors.WithLabelValues("rcvbuf_errors").Set(float64(udp.rcvbufErrors - sysUDPStart.rcvbufErrors))
+			promSysUDPErrors.WithLabelValues("sndbuf_errors").Set(float64(udp.sndbufErrors - sysUDPStart.sndbufErrors))
+		}
+	}
+}
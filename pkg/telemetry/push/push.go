@@ -0,0 +1,133 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	ModePushgateway = "pushgateway"
+	ModeRemoteWrite = "remote_write"
+
+	pushTimeout = 10 * time.Second
+)
+
+type pushFunc func(ctx context.Context) error
+
+// Pusher periodically pushes everything gathered from the prometheus registry
+type Pusher struct {
+	interval time.Duration
+	push     pushFunc
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+func NewPusher(conf config.PushConfig, gatherer prometheus.Gatherer, nodeID string) (*Pusher, error) {
+	header := make(http.Header)
+	for name, value := range conf.Headers {
+		header.Set(name, value)
+	}
+
+	var fn pushFunc
+	switch conf.Mode {
+	case ModePushgateway, "":
+		p := push.New(conf.URL, conf.Job).
+			Gatherer(gatherer).
+			Grouping("instance", nodeID).
+			Header(header)
+		for name, value := range conf.Labels {
+			p = p.Grouping(name, value)
+		}
+		if conf.Username != "" {
+			p = p.BasicAuth(conf.Username, conf.Password)
+		}
+		fn = p.PushContext
+
+	case ModeRemoteWrite:
+		labels := map[string]string{
+			"job":      conf.Job,
+			"instance": nodeID,
+		}
+		for name, value := range conf.Labels {
+			labels[name] = value
+		}
+		w := &remoteWriter{
+			url:      conf.URL,
+			username: conf.Username,
+			password: conf.Password,
+			header:   header,
+			labels:   labels,
+			gatherer: gatherer,
+			client:   &http.Client{Timeout: pushTimeout},
+		}
+		fn = w.write
+
+	default:
+		return nil, fmt.Errorf("unsupported push mode: %s", conf.Mode)
+	}
+
+	return &Pusher{
+		interval: conf.Interval,
+		push:     fn,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}, nil
+}
+
+func (p *Pusher) Start() {
+	go p.worker()
+}
+
+// Stop pushes the final values and stops the worker
+func (p *Pusher) Stop() {
+	close(p.done)
+	<-p.stopped
+}
+
+func (p *Pusher) worker() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.pushOnce()
+		case <-p.done:
+			p.pushOnce()
+			return
+		}
+	}
+}
+
+func (p *Pusher) pushOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	if err := p.push(ctx); err != nil {
+		logger.Warnw("could not push metrics", err)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type timeSeries struct {
+	labels []label
+	value  float64
+}
+
+// remoteWriter sends gathered metrics using the Prometheus remote_write protocol (v1),
+// a snappy compressed protobuf WriteRequest
+type remoteWriter struct {
+	url      string
+	username string
+	password string
+	header   http.Header
+	labels   map[string]string
+	gatherer prometheus.Gatherer
+	client   *http.Client
+}
+
+func (w *remoteWriter) write(ctx context.Context) error {
+	families, err := w.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return err
+	}
+
+	series := w.toTimeSeries(families)
+	if len(series) == 0 {
+		return nil
+	}
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(series, time.Now().UnixMilli()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range w.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("remote write failed with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (w *remoteWriter) toTimeSeries(families []*dto.MetricFamily) []timeSeries {
+	var series []timeSeries
+	for _, family := range families {
+		name := family.GetName()
+		for _, m := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				series = append(series, w.newTimeSeries(name, m.GetLabel(), m.GetCounter().GetValue()))
+			case dto.MetricType_GAUGE:
+				series = append(series, w.newTimeSeries(name, m.GetLabel(), m.GetGauge().GetValue()))
+			case dto.MetricType_UNTYPED:
+				series = append(series, w.newTimeSeries(name, m.GetLabel(), m.GetUntyped().GetValue()))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					series = append(series, w.newTimeSeries(name+"_bucket", m.GetLabel(), float64(b.GetCumulativeCount()),
+						label{"le", formatFloat(b.GetUpperBound())}))
+				}
+				series = append(series,
+					w.newTimeSeries(name+"_bucket", m.GetLabel(), float64(h.GetSampleCount()), label{"le", "+Inf"}),
+					w.newTimeSeries(name+"_sum", m.GetLabel(), h.GetSampleSum()),
+					w.newTimeSeries(name+"_count", m.GetLabel(), float64(h.GetSampleCount())),
+				)
+			}
+		}
+	}
+	return series
+}
+
+func (w *remoteWriter) newTimeSeries(name string, pairs []*dto.LabelPair, value float64, extra ...label) timeSeries {
+	labels := make([]label, 0, len(pairs)+len(w.labels)+len(extra)+1)
+	labels = append(labels, label{"__name__", name})
+	for n, v := range w.labels {
+		labels = append(labels, label{n, v})
+	}
+	for _, p := range pairs {
+		labels = append(labels, label{p.GetName(), p.GetValue()})
+	}
+	labels = append(labels, extra...)
+
+	// remote_write requires labels sorted by name
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+	return timeSeries{labels: labels, value: value}
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest encodes the series as prometheus.WriteRequest:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []timeSeries, timestamp int64) []byte {
+	var buf []byte
+	for _, ts := range series {
+		var tsBuf []byte
+		for _, l := range ts.labels {
+			var lBuf []byte
+			lBuf = protowire.AppendTag(lBuf, 1, protowire.BytesType)
+			lBuf = protowire.AppendString(lBuf, l.name)
+			lBuf = protowire.AppendTag(lBuf, 2, protowire.BytesType)
+			lBuf = protowire.AppendString(lBuf, l.value)
+
+			tsBuf = protowire.AppendTag(tsBuf, 1, protowire.BytesType)
+			tsBuf = protowire.AppendBytes(tsBuf, lBuf)
+		}
+
+		var sBuf []byte
+		sBuf = protowire.AppendTag(sBuf, 1, protowire.Fixed64Type)
+		sBuf = protowire.AppendFixed64(sBuf, math.Float64bits(ts.value))
+		sBuf = protowire.AppendTag(sBuf, 2, protowire.VarintType)
+		sBuf = protowire.AppendVarint(sBuf, uint64(timestamp))
+
+		tsBuf = protowire.AppendTag(tsBuf, 2, protowire.BytesType)
+		tsBuf = protowire.AppendBytes(tsBuf, sBuf)
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, tsBuf)
+	}
+	return buf
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteWriter_TimeSeries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"kind"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_ms", Buckets: []float64{10, 100}})
+	registry.MustRegister(counter, histogram)
+
+	counter.WithLabelValues("audio").Add(3)
+	histogram.Observe(5)
+	histogram.Observe(50)
+	histogram.Observe(500)
+
+	w := &remoteWriter{
+		labels:   map[string]string{"job": "livekit", "instance": "node"},
+		gatherer: registry,
+	}
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, ts := range w.toTimeSeries(families) {
+		for i := 1; i < len(ts.labels); i++ {
+			require.Less(t, ts.labels[i-1].name, ts.labels[i].name)
+		}
+
+		var key string
+		for _, l := range ts.labels {
+			if l.name == "job" || l.name == "instance" {
+				continue
+			}
+			key += l.name + "=" + l.value + ","
+		}
+		values[key] = ts.value
+	}
+
+	require.Equal(t, map[string]float64{
+		"__name__=test_total,kind=audio,":  3,
+		"__name__=test_ms_bucket,le=10,":   1,
+		"__name__=test_ms_bucket,le=100,":  2,
+		"__name__=test_ms_bucket,le=+Inf,": 3,
+		"__name__=test_ms_sum,":            555,
+		"__name__=test_ms_count,":          3,
+	}, values)
+
+	require.NotEmpty(t, encodeWriteRequest(w.toTimeSeries(families), 0))
+}
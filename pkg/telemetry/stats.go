@@ -76,19 +76,23 @@ func (t *telemetryService) TrackStats(key StatsKey, stat *livekit.AnalyticsStat)
 				bytes += stream.RetransmitBytes
 			}
 			if key.track {
-				prometheus.RecordPacketLoss(direction, key.trackSource, key.trackType, stream.PacketsLost, stream.PrimaryPackets+stream.PaddingPackets)
-				prometheus.RecordRTT(direction, key.trackSource, key.trackType, stream.Rtt)
-				prometheus.RecordJitter(direction, key.trackSource, key.trackType, stream.Jitter)
+				t.reporter.RecordPacketLoss(direction, key.trackSource, key.trackType, stream.PacketsLost, stream.PrimaryPackets+stream.PaddingPackets)
+				t.reporter.RecordRTT(direction, key.trackSource, key.trackType, stream.Rtt)
+				t.reporter.RecordJitter(direction, key.trackSource, key.trackType, stream.Jitter)
 			}
 		}
-		prometheus.IncrementRTCP(direction, nacks, plis, firs)
-		prometheus.IncrementPackets(direction, uint64(packets), false)
-		prometheus.IncrementBytes(direction, bytes, false)
+		t.reporter.IncrementRTCP(direction, nacks, plis, firs)
+		t.reporter.IncrementPackets(direction, uint64(packets), false)
+		t.reporter.IncrementBytes(direction, bytes, false)
 		if retransmitPackets != 0 {
-			prometheus.IncrementPackets(direction, uint64(retransmitPackets), true)
+			t.reporter.IncrementPackets(direction, uint64(retransmitPackets), true)
 		}
 		if retransmitBytes != 0 {
-			prometheus.IncrementBytes(direction, retransmitBytes, true)
+			t.reporter.IncrementBytes(direction, retransmitBytes, true)
+		}
+		if key.track {
+			// data channel and signal bytes are not RTP, and are not counted as forwarded media
+			t.reporter.IncrementForwarded(direction, key.trackType.String(), uint64(packets+retransmitPackets), bytes+retransmitBytes)
 		}
 
 		if worker, ok := t.getWorker(key.participantID); ok {
@@ -21,6 +21,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/livekit"
 
@@ -29,7 +30,7 @@ import (
 )
 
 func init() {
-	prometheus.Init("test", livekit.NodeType_SERVER, "test")
+	prometheus.Init("test", livekit.NodeType_SERVER, "test", config.TelemetryConfig{})
 }
 
 type telemetryServiceFixture struct {
@@ -40,7 +41,7 @@ type telemetryServiceFixture struct {
 func createFixture() *telemetryServiceFixture {
 	fixture := &telemetryServiceFixture{}
 	fixture.analytics = &telemetryfakes.FakeAnalyticsService{}
-	fixture.sut = telemetry.NewTelemetryService(nil, fixture.analytics)
+	fixture.sut = telemetry.NewTelemetryService(nil, fixture.analytics, prometheus.StatsReporter{})
 	return fixture
 }
 
@@ -496,6 +497,47 @@ func Test_AnalyticsSentWhenParticipantLeaves(t *testing.T) {
 	require.Equal(t, 0, fixture.analytics.SendStatsCallCount())
 }
 
+func Test_ParticipantTrafficSentWhenParticipantLeaves(t *testing.T) {
+	fixture := createFixture()
+
+	// prepare
+	room := &livekit.Room{Sid: "RoomSid", Name: "RoomName"}
+	partSID := livekit.ParticipantID("part1")
+	participantInfo := &livekit.ParticipantInfo{Sid: string(partSID)}
+	fixture.sut.ParticipantJoined(context.Background(), room, participantInfo, nil, nil, true)
+
+	// do
+	fixture.sut.TrackStats(telemetry.StatsKeyForData(livekit.StreamType_UPSTREAM, partSID, "track1"), &livekit.AnalyticsStat{
+		Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 100, PrimaryPackets: 2, RetransmitBytes: 20, RetransmitPackets: 1}},
+	})
+	fixture.sut.TrackStats(telemetry.StatsKeyForData(livekit.StreamType_DOWNSTREAM, partSID, "track2"), &livekit.AnalyticsStat{
+		Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 300, PrimaryPackets: 3, PaddingBytes: 50, PaddingPackets: 1}},
+	})
+	time.Sleep(time.Millisecond * 500)
+
+	traffic, ok := fixture.sut.GetParticipantTraffic(partSID)
+	require.True(t, ok)
+	require.Equal(t, telemetry.ParticipantTraffic{
+		PublisherBytes:    120,
+		PublisherPackets:  3,
+		SubscriberBytes:   350,
+		SubscriberPackets: 4,
+	}, traffic)
+
+	fixture.sut.ParticipantLeft(context.Background(), room, participantInfo, true)
+	time.Sleep(time.Millisecond * 500)
+
+	// per track stats flushed on close, followed by the participant totals
+	require.Equal(t, 2, fixture.analytics.SendStatsCallCount())
+	_, stats := fixture.analytics.SendStatsArgsForCall(1)
+	require.Equal(t, 2, len(stats))
+	require.Equal(t, livekit.StreamType_UPSTREAM, stats[0].Kind)
+	require.Equal(t, "", stats[0].TrackId)
+	require.Equal(t, uint64(120), stats[0].Streams[0].PrimaryBytes)
+	require.Equal(t, livekit.StreamType_DOWNSTREAM, stats[1].Kind)
+	require.Equal(t, uint64(350), stats[1].Streams[0].PrimaryBytes)
+}
+
 func Test_AddUpTrack(t *testing.T) {
 	fixture := createFixture()
 
@@ -0,0 +1,119 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type metricType string
+
+const (
+	metricCount  metricType = "c"
+	metricGauge  metricType = "g"
+	metricTiming metricType = "ms"
+)
+
+// client writes metrics in the StatsD line protocol over UDP, one datagram per metric.
+// When dogStatsD is set, tags are appended using the DogStatsD extension.
+type client struct {
+	conn      net.Conn
+	prefix    string
+	dogStatsD bool
+	tags      string
+
+	lock   sync.Mutex
+	gauges map[string]int64
+}
+
+func newClient(address string, prefix string, dogStatsD bool, tags map[string]string) (*client, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		conn:      conn,
+		prefix:    prefix,
+		dogStatsD: dogStatsD,
+		tags:      formatTags(tags),
+		gauges:    make(map[string]int64),
+	}, nil
+}
+
+func (c *client) count(name string, value int64, tags ...string) {
+	c.send(name, strconv.FormatInt(value, 10), metricCount, tags)
+}
+
+func (c *client) timing(name string, d time.Duration, tags ...string) {
+	c.send(name, strconv.FormatInt(d.Milliseconds(), 10), metricTiming, tags)
+}
+
+// addGauge adjusts a gauge by delta and sends the resulting absolute value,
+// as relative gauge updates are not supported by DogStatsD
+func (c *client) addGauge(name string, delta int64, tags ...string) {
+	key := name + "|" + strings.Join(tags, ",")
+	c.lock.Lock()
+	value := c.gauges[key] + delta
+	c.gauges[key] = value
+	c.lock.Unlock()
+
+	c.send(name, strconv.FormatInt(value, 10), metricGauge, tags)
+}
+
+func (c *client) send(name string, value string, typ metricType, tags []string) {
+	var sb strings.Builder
+	sb.WriteString(c.prefix)
+	sb.WriteString(name)
+	sb.WriteByte(':')
+	sb.WriteString(value)
+	sb.WriteByte('|')
+	sb.WriteString(string(typ))
+	if c.dogStatsD && (c.tags != "" || len(tags) != 0) {
+		sb.WriteString("|#")
+		sb.WriteString(c.tags)
+		for i, tag := range tags {
+			if i > 0 || c.tags != "" {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(tag)
+		}
+	}
+
+	// metrics are best effort, an unreachable agent must not affect the server
+	_, _ = c.conn.Write([]byte(sb.String()))
+}
+
+func (c *client) close() error {
+	return c.conn.Close()
+}
+
+func tag(name, value string) string {
+	return name + ":" + value
+}
+
+func formatTags(tags map[string]string) string {
+	formatted := make([]string, 0, len(tags))
+	for name, value := range tags {
+		formatted = append(formatted, tag(name, value))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ",")
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/protocol/livekit"
+)
+
+// StatsReporter implements telemetry.StatsReporter for room, participant and track counters,
+// and forwarded traffic. Other stream level stats are left to prometheus.
+type StatsReporter struct {
+	client *client
+}
+
+func NewStatsReporter(conf config.StatsDConfig, nodeID string, nodeType livekit.NodeType, env string) (*StatsReporter, error) {
+	// same as the const labels used for prometheus
+	tags := map[string]string{
+		"node_id":   nodeID,
+		"node_type": nodeType.String(),
+		"env":       env,
+	}
+	for name, value := range conf.Tags {
+		tags[name] = value
+	}
+
+	c, err := newClient(conf.Address, conf.Prefix, conf.DogStatsD, tags)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsReporter{client: c}, nil
+}
+
+// Close closes the connection to the agent, metrics are no longer sent after
+func (r *StatsReporter) Close() error {
+	return r.client.close()
+}
+
+func (r *StatsReporter) RoomStarted(_ livekit.RoomName) {
+	r.client.addGauge("room.total", 1)
+	r.client.count("room.started", 1)
+}
+
+func (r *StatsReporter) RoomEnded(_ livekit.RoomName, _ livekit.RoomID, startedAt time.Time) {
+	if !startedAt.IsZero() {
+		r.client.timing("room.duration", time.Since(startedAt))
+	}
+	r.client.addGauge("room.total", -1)
+}
+
+func (r *StatsReporter) AddParticipant(_ livekit.RoomName) {
+	r.client.addGauge("participant.total", 1)
+}
+
+func (r *StatsReporter) SubParticipant(_ livekit.RoomName) {
+	r.client.addGauge("participant.total", -1)
+}
+
+func (r *StatsReporter) IncrementParticipantRtcConnected(_ uint32) {}
+
+func (r *StatsReporter) RecordParticipantTraffic(_ livekit.RoomName, publisherBytes uint64, subscriberBytes uint64) {
+	r.client.count("participant.traffic_bytes", int64(publisherBytes), tag("direction", "publisher"))
+	r.client.count("participant.traffic_bytes", int64(subscriberBytes), tag("direction", "subscriber"))
+}
+
+func (r *StatsReporter) AddPublishAttempt(_ livekit.RoomName, kind string) {
+	r.client.count("track.publish_counter", 1, tag("kind", kind), tag("state", "attempt"))
+}
+
+func (r *StatsReporter) AddPublishSuccess(_ livekit.RoomName, kind string) {
+	r.client.count("track.publish_counter", 1, tag("kind", kind), tag("state", "success"))
+}
+
+func (r *StatsReporter) AddPublishedTrack(_ livekit.RoomName, kind string, codec string) {
+	r.client.addGauge("track.published_total", 1, tag("kind", kind), tag("codec", codec))
+}
+
+func (r *StatsReporter) SubPublishedTrack(_ livekit.RoomName, kind string, codec string) {
+	r.client.addGauge("track.published_total", -1, tag("kind", kind), tag("codec", codec))
+}
+
+func (r *StatsReporter) RecordTrackSubscribeAttempt(_ livekit.RoomName) {
+	r.client.count("track.subscribe_counter", 1, tag("state", "attempt"))
+}
+
+func (r *StatsReporter) RecordTrackSubscribeSuccess(_ livekit.RoomName, kind string, codec string) {
+	r.client.addGauge("track.subscribed_total", 1, tag("kind", kind), tag("codec", codec))
+	r.client.count("track.subscribe_counter", 1, tag("state", "success"))
+}
+
+func (r *StatsReporter) RecordTrackSubscribeFailure(_ livekit.RoomName, reason string, isUserError bool) {
+	r.client.count("track.subscribe_counter", 1, tag("state", "failure"), tag("error", reason), tag("user_error", strconv.FormatBool(isUserError)))
+}
+
+func (r *StatsReporter) RecordTrackUnsubscribed(_ livekit.RoomName, kind string, codec string) {
+	r.client.addGauge("track.subscribed_total", -1, tag("kind", kind), tag("codec", codec))
+}
+
+func (r *StatsReporter) IncrementPackets(_ prometheus.Direction, _ uint64, _ bool) {}
+
+func (r *StatsReporter) IncrementBytes(_ prometheus.Direction, _ uint64, _ bool) {}
+
+func (r *StatsReporter) IncrementForwarded(direction prometheus.Direction, kind string, packets uint64, bytes uint64) {
+	if packets > 0 {
+		r.client.count("forwarded.packets", int64(packets), tag("direction", string(direction)), tag("kind", kind))
+	}
+	if bytes > 0 {
+		r.client.count("forwarded.bytes", int64(bytes), tag("direction", string(direction)), tag("kind", kind))
+	}
+}
+
+func (r *StatsReporter) IncrementRTCP(direction prometheus.Direction, nack, pli, fir uint32) {
+	if nack > 0 {
+		r.client.count("rtcp.nack", int64(nack), tag("direction", string(direction)))
+	}
+	if pli > 0 {
+		r.client.count("rtcp.pli", int64(pli), tag("direction", string(direction)))
+	}
+	if fir > 0 {
+		r.client.count("rtcp.fir", int64(fir), tag("direction", string(direction)))
+	}
+}
+
+func (r *StatsReporter) RecordPacketLoss(_ prometheus.Direction, _ livekit.TrackSource, _ livekit.TrackType, _, _ uint32) {
+}
+
+func (r *StatsReporter) RecordRTT(_ prometheus.Direction, _ livekit.TrackSource, _ livekit.TrackType, _ uint32) {
+}
+
+func (r *StatsReporter) RecordJitter(_ prometheus.Direction, _ livekit.TrackSource, _ livekit.TrackType, _ uint32) {
+}
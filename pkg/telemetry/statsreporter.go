@@ -0,0 +1,207 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/protocol/livekit"
+)
+
+// StatsReporter receives the room, participant, track and stream stats handled by the telemetry service.
+// prometheus.StatsReporter is used by default, NewMultiStatsReporter can be used to report to several sinks.
+type StatsReporter interface {
+	RoomStarted(roomName livekit.RoomName)
+	RoomEnded(roomName livekit.RoomName, roomID livekit.RoomID, startedAt time.Time)
+
+	AddParticipant(roomName livekit.RoomName)
+	SubParticipant(roomName livekit.RoomName)
+	IncrementParticipantRtcConnected(join uint32)
+	RecordParticipantTraffic(roomName livekit.RoomName, publisherBytes uint64, subscriberBytes uint64)
+
+	AddPublishAttempt(roomName livekit.RoomName, kind string)
+	AddPublishSuccess(roomName livekit.RoomName, kind string)
+	AddPublishedTrack(roomName livekit.RoomName, kind string, codec string)
+	SubPublishedTrack(roomName livekit.RoomName, kind string, codec string)
+
+	RecordTrackSubscribeAttempt(roomName livekit.RoomName)
+	RecordTrackSubscribeSuccess(roomName livekit.RoomName, kind string, codec string)
+	RecordTrackSubscribeFailure(roomName livekit.RoomName, reason string, isUserError bool)
+	RecordTrackUnsubscribed(roomName livekit.RoomName, kind string, codec string)
+
+	IncrementPackets(direction prometheus.Direction, count uint64, retransmit bool)
+	IncrementBytes(direction prometheus.Direction, count uint64, retransmit bool)
+	IncrementForwarded(direction prometheus.Direction, kind string, packets uint64, bytes uint64)
+	IncrementRTCP(direction prometheus.Direction, nack, pli, fir uint32)
+	RecordPacketLoss(direction prometheus.Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, lost, total uint32)
+	RecordRTT(direction prometheus.Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, rtt uint32)
+	RecordJitter(direction prometheus.Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, jitter uint32)
+}
+
+var _ StatsReporter = prometheus.StatsReporter{}
+
+// ----------------------------------------------
+
+type multiStatsReporter []StatsReporter
+
+// NewMultiStatsReporter returns a StatsReporter that forwards all stats to each of the given reporters
+func NewMultiStatsReporter(reporters ...StatsReporter) StatsReporter {
+	if len(reporters) == 1 {
+		return reporters[0]
+	}
+	return multiStatsReporter(reporters)
+}
+
+// Close closes the reporters that hold resources, such as connections
+func (m multiStatsReporter) Close() error {
+	var errs []error
+	for _, r := range m {
+		if c, ok := r.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiStatsReporter) RoomStarted(roomName livekit.RoomName) {
+	for _, r := range m {
+		r.RoomStarted(roomName)
+	}
+}
+
+func (m multiStatsReporter) RoomEnded(roomName livekit.RoomName, roomID livekit.RoomID, startedAt time.Time) {
+	for _, r := range m {
+		r.RoomEnded(roomName, roomID, startedAt)
+	}
+}
+
+func (m multiStatsReporter) AddParticipant(roomName livekit.RoomName) {
+	for _, r := range m {
+		r.AddParticipant(roomName)
+	}
+}
+
+func (m multiStatsReporter) SubParticipant(roomName livekit.RoomName) {
+	for _, r := range m {
+		r.SubParticipant(roomName)
+	}
+}
+
+func (m multiStatsReporter) IncrementParticipantRtcConnected(join uint32) {
+	for _, r := range m {
+		r.IncrementParticipantRtcConnected(join)
+	}
+}
+
+func (m multiStatsReporter) RecordParticipantTraffic(roomName livekit.RoomName, publisherBytes uint64, subscriberBytes uint64) {
+	for _, r := range m {
+		r.RecordParticipantTraffic(roomName, publisherBytes, subscriberBytes)
+	}
+}
+
+func (m multiStatsReporter) AddPublishAttempt(roomName livekit.RoomName, kind string) {
+	for _, r := range m {
+		r.AddPublishAttempt(roomName, kind)
+	}
+}
+
+func (m multiStatsReporter) AddPublishSuccess(roomName livekit.RoomName, kind string) {
+	for _, r := range m {
+		r.AddPublishSuccess(roomName, kind)
+	}
+}
+
+func (m multiStatsReporter) AddPublishedTrack(roomName livekit.RoomName, kind string, codec string) {
+	for _, r := range m {
+		r.AddPublishedTrack(roomName, kind, codec)
+	}
+}
+
+func (m multiStatsReporter) SubPublishedTrack(roomName livekit.RoomName, kind string, codec string) {
+	for _, r := range m {
+		r.SubPublishedTrack(roomName, kind, codec)
+	}
+}
+
+func (m multiStatsReporter) RecordTrackSubscribeAttempt(roomName livekit.RoomName) {
+	for _, r := range m {
+		r.RecordTrackSubscribeAttempt(roomName)
+	}
+}
+
+func (m multiStatsReporter) RecordTrackSubscribeSuccess(roomName livekit.RoomName, kind string, codec string) {
+	for _, r := range m {
+		r.RecordTrackSubscribeSuccess(roomName, kind, codec)
+	}
+}
+
+func (m multiStatsReporter) RecordTrackSubscribeFailure(roomName livekit.RoomName, reason string, isUserError bool) {
+	for _, r := range m {
+		r.RecordTrackSubscribeFailure(roomName, reason, isUserError)
+	}
+}
+
+func (m multiStatsReporter) RecordTrackUnsubscribed(roomName livekit.RoomName, kind string, codec string) {
+	for _, r := range m {
+		r.RecordTrackUnsubscribed(roomName, kind, codec)
+	}
+}
+
+func (m multiStatsReporter) IncrementPackets(direction prometheus.Direction, count uint64, retransmit bool) {
+	for _, r := range m {
+		r.IncrementPackets(direction, count, retransmit)
+	}
+}
+
+func (m multiStatsReporter) IncrementBytes(direction prometheus.Direction, count uint64, retransmit bool) {
+	for _, r := range m {
+		r.IncrementBytes(direction, count, retransmit)
+	}
+}
+
+func (m multiStatsReporter) IncrementForwarded(direction prometheus.Direction, kind string, packets uint64, bytes uint64) {
+	for _, r := range m {
+		r.IncrementForwarded(direction, kind, packets, bytes)
+	}
+}
+
+func (m multiStatsReporter) IncrementRTCP(direction prometheus.Direction, nack, pli, fir uint32) {
+	for _, r := range m {
+		r.IncrementRTCP(direction, nack, pli, fir)
+	}
+}
+
+func (m multiStatsReporter) RecordPacketLoss(direction prometheus.Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, lost, total uint32) {
+	for _, r := range m {
+		r.RecordPacketLoss(direction, trackSource, trackType, lost, total)
+	}
+}
+
+func (m multiStatsReporter) RecordRTT(direction prometheus.Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, rtt uint32) {
+	for _, r := range m {
+		r.RecordRTT(direction, trackSource, trackType, rtt)
+	}
+}
+
+func (m multiStatsReporter) RecordJitter(direction prometheus.Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, jitter uint32) {
+	for _, r := range m {
+		r.RecordJitter(direction, trackSource, trackType, jitter)
+	}
+}
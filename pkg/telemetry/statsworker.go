@@ -40,9 +40,19 @@ type StatsWorker struct {
 	lock             sync.RWMutex
 	outgoingPerTrack map[livekit.TrackID][]*livekit.AnalyticsStat
 	incomingPerTrack map[livekit.TrackID][]*livekit.AnalyticsStat
+	traffic          ParticipantTraffic
 	closedAt         time.Time
 }
 
+// ParticipantTraffic is the traffic of a participant on this node since it joined, including data channels.
+// The publisher leg is received from the participant, the subscriber leg is sent to the participant.
+type ParticipantTraffic struct {
+	PublisherBytes    uint64
+	PublisherPackets  uint64
+	SubscriberBytes   uint64
+	SubscriberPackets uint64
+}
+
 func newStatsWorker(
 	ctx context.Context,
 	t TelemetryService,
@@ -65,15 +75,64 @@ func newStatsWorker(
 }
 
 func (s *StatsWorker) OnTrackStat(trackID livekit.TrackID, direction livekit.StreamType, stat *livekit.AnalyticsStat) {
+	var bytes, packets uint64
+	for _, stream := range stat.Streams {
+		bytes += stream.PrimaryBytes + stream.RetransmitBytes + stream.PaddingBytes
+		packets += uint64(stream.PrimaryPackets + stream.RetransmitPackets + stream.PaddingPackets)
+	}
+
 	s.lock.Lock()
 	if direction == livekit.StreamType_DOWNSTREAM {
 		s.outgoingPerTrack[trackID] = append(s.outgoingPerTrack[trackID], stat)
+		s.traffic.SubscriberBytes += bytes
+		s.traffic.SubscriberPackets += packets
 	} else {
 		s.incomingPerTrack[trackID] = append(s.incomingPerTrack[trackID], stat)
+		s.traffic.PublisherBytes += bytes
+		s.traffic.PublisherPackets += packets
 	}
 	s.lock.Unlock()
 }
 
+func (s *StatsWorker) Traffic() ParticipantTraffic {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.traffic
+}
+
+// TrafficStats returns the participant's total traffic per direction, without a track ID
+func (s *StatsWorker) TrafficStats() []*livekit.AnalyticsStat {
+	traffic := s.Traffic()
+	ts := timestamppb.Now()
+
+	var stats []*livekit.AnalyticsStat
+	for _, leg := range []struct {
+		kind    livekit.StreamType
+		bytes   uint64
+		packets uint64
+	}{
+		{livekit.StreamType_UPSTREAM, traffic.PublisherBytes, traffic.PublisherPackets},
+		{livekit.StreamType_DOWNSTREAM, traffic.SubscriberBytes, traffic.SubscriberPackets},
+	} {
+		if leg.bytes == 0 {
+			continue
+		}
+		stats = append(stats, &livekit.AnalyticsStat{
+			Kind:          leg.kind,
+			TimeStamp:     ts,
+			RoomId:        string(s.roomID),
+			RoomName:      string(s.roomName),
+			ParticipantId: string(s.participantID),
+			Streams: []*livekit.AnalyticsStream{{
+				PrimaryBytes:   leg.bytes,
+				PrimaryPackets: uint32(leg.packets),
+			}},
+		})
+	}
+	return stats
+}
+
 func (s *StatsWorker) ParticipantID() livekit.ParticipantID {
 	return s.participantID
 }
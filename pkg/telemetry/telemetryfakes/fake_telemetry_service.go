@@ -32,6 +32,19 @@ type FakeTelemetryService struct {
 	flushStatsMutex       sync.RWMutex
 	flushStatsArgsForCall []struct {
 	}
+	GetParticipantTrafficStub        func(livekit.ParticipantID) (telemetry.ParticipantTraffic, bool)
+	getParticipantTrafficMutex       sync.RWMutex
+	getParticipantTrafficArgsForCall []struct {
+		arg1 livekit.ParticipantID
+	}
+	getParticipantTrafficReturns struct {
+		result1 telemetry.ParticipantTraffic
+		result2 bool
+	}
+	getParticipantTrafficReturnsOnCall map[int]struct {
+		result1 telemetry.ParticipantTraffic
+		result2 bool
+	}
 	IngressCreatedStub        func(context.Context, *livekit.IngressInfo)
 	ingressCreatedMutex       sync.RWMutex
 	ingressCreatedArgsForCall []struct {
@@ -95,6 +108,14 @@ type FakeTelemetryService struct {
 		arg3 *livekit.ParticipantInfo
 		arg4 bool
 	}
+	ParticipantMediaFloodStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool)
+	participantMediaFloodMutex       sync.RWMutex
+	participantMediaFloodArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 bool
+	}
 	ParticipantResumedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, livekit.NodeID, livekit.ReconnectReason)
 	participantResumedMutex       sync.RWMutex
 	participantResumedArgsForCall []struct {
@@ -110,12 +131,24 @@ type FakeTelemetryService struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 	}
+	RoomLockedStub        func(context.Context, *livekit.Room)
+	roomLockedMutex       sync.RWMutex
+	roomLockedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
 	RoomStartedStub        func(context.Context, *livekit.Room)
 	roomStartedMutex       sync.RWMutex
 	roomStartedArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 	}
+	RoomUnlockedStub        func(context.Context, *livekit.Room)
+	roomUnlockedMutex       sync.RWMutex
+	roomUnlockedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
 	SendEventStub        func(context.Context, *livekit.AnalyticsEvent)
 	sendEventMutex       sync.RWMutex
 	sendEventArgsForCall []struct {
@@ -183,14 +216,15 @@ type FakeTelemetryService struct {
 		arg1 telemetry.StatsKey
 		arg2 *livekit.AnalyticsStat
 	}
-	TrackSubscribeFailedStub        func(context.Context, livekit.ParticipantID, livekit.TrackID, error, bool)
+	TrackSubscribeFailedStub        func(context.Context, livekit.ParticipantID, livekit.TrackID, error, string, bool)
 	trackSubscribeFailedMutex       sync.RWMutex
 	trackSubscribeFailedArgsForCall []struct {
 		arg1 context.Context
 		arg2 livekit.ParticipantID
 		arg3 livekit.TrackID
 		arg4 error
-		arg5 bool
+		arg5 string
+		arg6 bool
 	}
 	TrackSubscribeRTPStatsStub        func(context.Context, livekit.ParticipantID, livekit.TrackID, string, *livekit.RTPStats)
 	trackSubscribeRTPStatsMutex       sync.RWMutex
@@ -368,6 +402,70 @@ func (fake *FakeTelemetryService) FlushStatsCalls(stub func()) {
 	fake.FlushStatsStub = stub
 }
 
+func (fake *FakeTelemetryService) GetParticipantTraffic(arg1 livekit.ParticipantID) (telemetry.ParticipantTraffic, bool) {
+	fake.getParticipantTrafficMutex.Lock()
+	ret, specificReturn := fake.getParticipantTrafficReturnsOnCall[len(fake.getParticipantTrafficArgsForCall)]
+	fake.getParticipantTrafficArgsForCall = append(fake.getParticipantTrafficArgsForCall, struct {
+		arg1 livekit.ParticipantID
+	}{arg1})
+	stub := fake.GetParticipantTrafficStub
+	fakeReturns := fake.getParticipantTrafficReturns
+	fake.recordInvocation("GetParticipantTraffic", []interface{}{arg1})
+	fake.getParticipantTrafficMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTelemetryService) GetParticipantTrafficCallCount() int {
+	fake.getParticipantTrafficMutex.RLock()
+	defer fake.getParticipantTrafficMutex.RUnlock()
+	return len(fake.getParticipantTrafficArgsForCall)
+}
+
+func (fake *FakeTelemetryService) GetParticipantTrafficCalls(stub func(livekit.ParticipantID) (telemetry.ParticipantTraffic, bool)) {
+	fake.getParticipantTrafficMutex.Lock()
+	defer fake.getParticipantTrafficMutex.Unlock()
+	fake.GetParticipantTrafficStub = stub
+}
+
+func (fake *FakeTelemetryService) GetParticipantTrafficArgsForCall(i int) livekit.ParticipantID {
+	fake.getParticipantTrafficMutex.RLock()
+	defer fake.getParticipantTrafficMutex.RUnlock()
+	argsForCall := fake.getParticipantTrafficArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTelemetryService) GetParticipantTrafficReturns(result1 telemetry.ParticipantTraffic, result2 bool) {
+	fake.getParticipantTrafficMutex.Lock()
+	defer fake.getParticipantTrafficMutex.Unlock()
+	fake.GetParticipantTrafficStub = nil
+	fake.getParticipantTrafficReturns = struct {
+		result1 telemetry.ParticipantTraffic
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeTelemetryService) GetParticipantTrafficReturnsOnCall(i int, result1 telemetry.ParticipantTraffic, result2 bool) {
+	fake.getParticipantTrafficMutex.Lock()
+	defer fake.getParticipantTrafficMutex.Unlock()
+	fake.GetParticipantTrafficStub = nil
+	if fake.getParticipantTrafficReturnsOnCall == nil {
+		fake.getParticipantTrafficReturnsOnCall = make(map[int]struct {
+			result1 telemetry.ParticipantTraffic
+			result2 bool
+		})
+	}
+	fake.getParticipantTrafficReturnsOnCall[i] = struct {
+		result1 telemetry.ParticipantTraffic
+		result2 bool
+	}{result1, result2}
+}
+
 func (fake *FakeTelemetryService) IngressCreated(arg1 context.Context, arg2 *livekit.IngressInfo) {
 	fake.ingressCreatedMutex.Lock()
 	fake.ingressCreatedArgsForCall = append(fake.ingressCreatedArgsForCall, struct {
@@ -658,6 +756,8 @@ func (fake *FakeTelemetryService) ParticipantLeft(arg1 context.Context, arg2 *li
 func (fake *FakeTelemetryService) ParticipantLeftCallCount() int {
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
+	fake.participantMediaFloodMutex.RLock()
+	defer fake.participantMediaFloodMutex.RUnlock()
 	return len(fake.participantLeftArgsForCall)
 }
 
@@ -670,10 +770,47 @@ func (fake *FakeTelemetryService) ParticipantLeftCalls(stub func(context.Context
 func (fake *FakeTelemetryService) ParticipantLeftArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, bool) {
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
+	fake.participantMediaFloodMutex.RLock()
+	defer fake.participantMediaFloodMutex.RUnlock()
 	argsForCall := fake.participantLeftArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
+func (fake *FakeTelemetryService) ParticipantMediaFlood(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 bool) {
+	fake.participantMediaFloodMutex.Lock()
+	fake.participantMediaFloodArgsForCall = append(fake.participantMediaFloodArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ParticipantMediaFloodStub
+	fake.recordInvocation("ParticipantMediaFlood", []interface{}{arg1, arg2, arg3, arg4})
+	fake.participantMediaFloodMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantMediaFloodStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantMediaFloodCallCount() int {
+	fake.participantMediaFloodMutex.RLock()
+	defer fake.participantMediaFloodMutex.RUnlock()
+	return len(fake.participantMediaFloodArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantMediaFloodCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool)) {
+	fake.participantMediaFloodMutex.Lock()
+	defer fake.participantMediaFloodMutex.Unlock()
+	fake.ParticipantMediaFloodStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantMediaFloodArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, bool) {
+	fake.participantMediaFloodMutex.RLock()
+	defer fake.participantMediaFloodMutex.RUnlock()
+	argsForCall := fake.participantMediaFloodArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) ParticipantResumed(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 livekit.NodeID, arg5 livekit.ReconnectReason) {
 	fake.participantResumedMutex.Lock()
 	fake.participantResumedArgsForCall = append(fake.participantResumedArgsForCall, struct {
@@ -743,6 +880,39 @@ func (fake *FakeTelemetryService) RoomEndedArgsForCall(i int) (context.Context,
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) RoomLocked(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomLockedMutex.Lock()
+	fake.roomLockedArgsForCall = append(fake.roomLockedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomLockedStub
+	fake.recordInvocation("RoomLocked", []interface{}{arg1, arg2})
+	fake.roomLockedMutex.Unlock()
+	if stub != nil {
+		fake.RoomLockedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomLockedCallCount() int {
+	fake.roomLockedMutex.RLock()
+	defer fake.roomLockedMutex.RUnlock()
+	return len(fake.roomLockedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomLockedCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomLockedMutex.Lock()
+	defer fake.roomLockedMutex.Unlock()
+	fake.RoomLockedStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomLockedArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomLockedMutex.RLock()
+	defer fake.roomLockedMutex.RUnlock()
+	argsForCall := fake.roomLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeTelemetryService) RoomStarted(arg1 context.Context, arg2 *livekit.Room) {
 	fake.roomStartedMutex.Lock()
 	fake.roomStartedArgsForCall = append(fake.roomStartedArgsForCall, struct {
@@ -776,6 +946,39 @@ func (fake *FakeTelemetryService) RoomStartedArgsForCall(i int) (context.Context
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) RoomUnlocked(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomUnlockedMutex.Lock()
+	fake.roomUnlockedArgsForCall = append(fake.roomUnlockedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomUnlockedStub
+	fake.recordInvocation("RoomUnlocked", []interface{}{arg1, arg2})
+	fake.roomUnlockedMutex.Unlock()
+	if stub != nil {
+		fake.RoomUnlockedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomUnlockedCallCount() int {
+	fake.roomUnlockedMutex.RLock()
+	defer fake.roomUnlockedMutex.RUnlock()
+	return len(fake.roomUnlockedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomUnlockedCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomUnlockedMutex.Lock()
+	defer fake.roomUnlockedMutex.Unlock()
+	fake.RoomUnlockedStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomUnlockedArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomUnlockedMutex.RLock()
+	defer fake.roomUnlockedMutex.RUnlock()
+	argsForCall := fake.roomUnlockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeTelemetryService) SendEvent(arg1 context.Context, arg2 *livekit.AnalyticsEvent) {
 	fake.sendEventMutex.Lock()
 	fake.sendEventArgsForCall = append(fake.sendEventArgsForCall, struct {
@@ -1091,20 +1294,21 @@ func (fake *FakeTelemetryService) TrackStatsArgsForCall(i int) (telemetry.StatsK
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeTelemetryService) TrackSubscribeFailed(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.TrackID, arg4 error, arg5 bool) {
+func (fake *FakeTelemetryService) TrackSubscribeFailed(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.TrackID, arg4 error, arg5 string, arg6 bool) {
 	fake.trackSubscribeFailedMutex.Lock()
 	fake.trackSubscribeFailedArgsForCall = append(fake.trackSubscribeFailedArgsForCall, struct {
 		arg1 context.Context
 		arg2 livekit.ParticipantID
 		arg3 livekit.TrackID
 		arg4 error
-		arg5 bool
-	}{arg1, arg2, arg3, arg4, arg5})
+		arg5 string
+		arg6 bool
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
 	stub := fake.TrackSubscribeFailedStub
-	fake.recordInvocation("TrackSubscribeFailed", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("TrackSubscribeFailed", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
 	fake.trackSubscribeFailedMutex.Unlock()
 	if stub != nil {
-		fake.TrackSubscribeFailedStub(arg1, arg2, arg3, arg4, arg5)
+		fake.TrackSubscribeFailedStub(arg1, arg2, arg3, arg4, arg5, arg6)
 	}
 }
 
@@ -1114,17 +1318,17 @@ func (fake *FakeTelemetryService) TrackSubscribeFailedCallCount() int {
 	return len(fake.trackSubscribeFailedArgsForCall)
 }
 
-func (fake *FakeTelemetryService) TrackSubscribeFailedCalls(stub func(context.Context, livekit.ParticipantID, livekit.TrackID, error, bool)) {
+func (fake *FakeTelemetryService) TrackSubscribeFailedCalls(stub func(context.Context, livekit.ParticipantID, livekit.TrackID, error, string, bool)) {
 	fake.trackSubscribeFailedMutex.Lock()
 	defer fake.trackSubscribeFailedMutex.Unlock()
 	fake.TrackSubscribeFailedStub = stub
 }
 
-func (fake *FakeTelemetryService) TrackSubscribeFailedArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.TrackID, error, bool) {
+func (fake *FakeTelemetryService) TrackSubscribeFailedArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.TrackID, error, string, bool) {
 	fake.trackSubscribeFailedMutex.RLock()
 	defer fake.trackSubscribeFailedMutex.RUnlock()
 	argsForCall := fake.trackSubscribeFailedArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
 }
 
 func (fake *FakeTelemetryService) TrackSubscribeRTPStats(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.TrackID, arg4 string, arg5 *livekit.RTPStats) {
@@ -1349,6 +1553,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.egressUpdatedMutex.RUnlock()
 	fake.flushStatsMutex.RLock()
 	defer fake.flushStatsMutex.RUnlock()
+	fake.getParticipantTrafficMutex.RLock()
+	defer fake.getParticipantTrafficMutex.RUnlock()
 	fake.ingressCreatedMutex.RLock()
 	defer fake.ingressCreatedMutex.RUnlock()
 	fake.ingressDeletedMutex.RLock()
@@ -1367,12 +1573,18 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.participantJoinedMutex.RUnlock()
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
+	fake.participantMediaFloodMutex.RLock()
+	defer fake.participantMediaFloodMutex.RUnlock()
 	fake.participantResumedMutex.RLock()
 	defer fake.participantResumedMutex.RUnlock()
 	fake.roomEndedMutex.RLock()
 	defer fake.roomEndedMutex.RUnlock()
+	fake.roomLockedMutex.RLock()
+	defer fake.roomLockedMutex.RUnlock()
 	fake.roomStartedMutex.RLock()
 	defer fake.roomStartedMutex.RUnlock()
+	fake.roomUnlockedMutex.RLock()
+	defer fake.roomUnlockedMutex.RUnlock()
 	fake.sendEventMutex.RLock()
 	defer fake.sendEventMutex.RUnlock()
 	fake.sendStatsMutex.RLock()
@@ -33,6 +33,9 @@ type TelemetryService interface {
 	// events
 	RoomStarted(ctx context.Context, room *livekit.Room)
 	RoomEnded(ctx context.Context, room *livekit.Room)
+	// RoomLocked - the room stopped accepting new participants
+	RoomLocked(ctx context.Context, room *livekit.Room)
+	RoomUnlocked(ctx context.Context, room *livekit.Room)
 	// ParticipantJoined - a participant establishes signal connection to a room
 	ParticipantJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientInfo *livekit.ClientInfo, clientMeta *livekit.AnalyticsClientMeta, shouldSendEvent bool)
 	// ParticipantActive - a participant establishes media connection
@@ -41,6 +44,8 @@ type TelemetryService interface {
 	ParticipantResumed(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, nodeID livekit.NodeID, reason livekit.ReconnectReason)
 	// ParticipantLeft - the participant leaves the room, only sent if ParticipantActive has been called before
 	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, shouldSendEvent bool)
+	// ParticipantMediaFlood - the participant floods the node with media, it is throttled, or disconnected when it kept flooding
+	ParticipantMediaFlood(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, disconnected bool)
 	// TrackPublishRequested - a publication attempt has been received
 	TrackPublishRequested(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo)
 	// TrackPublished - a publication attempt has been successful
@@ -53,8 +58,8 @@ type TelemetryService interface {
 	TrackSubscribed(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo, publisher *livekit.ParticipantInfo, shouldSendEvent bool)
 	// TrackUnsubscribed - a participant unsubscribed from a track successfully
 	TrackUnsubscribed(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo, shouldSendEvent bool)
-	// TrackSubscribeFailed - failure to subscribe to a track
-	TrackSubscribeFailed(ctx context.Context, participantID livekit.ParticipantID, trackID livekit.TrackID, err error, isUserError bool)
+	// TrackSubscribeFailed - failure to subscribe to a track, reason is a bounded classification of err used for metrics
+	TrackSubscribeFailed(ctx context.Context, participantID livekit.ParticipantID, trackID livekit.TrackID, err error, reason string, isUserError bool)
 	// TrackMuted - the publisher has muted the Track
 	TrackMuted(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo)
 	// TrackUnmuted - the publisher has muted the Track
@@ -78,6 +83,8 @@ type TelemetryService interface {
 	AnalyticsService
 	NotifyEvent(ctx context.Context, event *livekit.WebhookEvent)
 	FlushStats()
+	// GetParticipantTraffic returns the traffic of a participant connected to this node
+	GetParticipantTraffic(participantID livekit.ParticipantID) (ParticipantTraffic, bool)
 }
 
 const (
@@ -89,19 +96,34 @@ type telemetryService struct {
 	AnalyticsService
 
 	notifier webhook.QueuedNotifier
+	reporter StatsReporter
 	jobsChan chan func()
 
 	lock    sync.RWMutex
 	workers map[livekit.ParticipantID]*StatsWorker
+
+	// codecs reported for current tracks, so that removals are reported with the same label
+	// even if the track's mime type changed. only accessed from the job queue.
+	publishedCodecs  map[livekit.TrackID]string
+	subscribedCodecs map[subscriptionKey]string
+}
+
+type subscriptionKey struct {
+	participantID livekit.ParticipantID
+	trackID       livekit.TrackID
 }
 
-func NewTelemetryService(notifier webhook.QueuedNotifier, analytics AnalyticsService) TelemetryService {
+func NewTelemetryService(notifier webhook.QueuedNotifier, analytics AnalyticsService, reporter StatsReporter) TelemetryService {
 	t := &telemetryService{
 		AnalyticsService: analytics,
 
 		notifier: notifier,
+		reporter: reporter,
 		jobsChan: make(chan func(), jobQueueBufferSize),
 		workers:  make(map[livekit.ParticipantID]*StatsWorker),
+
+		publishedCodecs:  make(map[livekit.TrackID]string),
+		subscribedCodecs: make(map[subscriptionKey]string),
 	}
 
 	go t.run()
@@ -118,6 +140,14 @@ func (t *telemetryService) FlushStats() {
 	}
 }
 
+func (t *telemetryService) GetParticipantTraffic(participantID livekit.ParticipantID) (ParticipantTraffic, bool) {
+	worker, ok := t.getWorker(participantID)
+	if !ok {
+		return ParticipantTraffic{}, false
+	}
+	return worker.Traffic(), true
+}
+
 func (t *telemetryService) run() {
 	ticker := time.NewTicker(config.TelemetryStatsUpdateInterval)
 	defer ticker.Stop()
@@ -0,0 +1,44 @@
+/*
+ * Copyright 2023 LiveKit, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// ProjectSeparator separates the project from the room name in stored room names.
+// names given by clients cannot contain it when projects are configured
+const ProjectSeparator = "|"
+
+// ProjectRoomName scopes a room name to a project, rooms of the default project ("") are not scoped
+func ProjectRoomName(project string, roomName livekit.RoomName) livekit.RoomName {
+	if project == "" {
+		return roomName
+	}
+	return livekit.RoomName(project + ProjectSeparator + string(roomName))
+}
+
+// SplitProjectRoomName returns the project of a scoped room name and the name as the project's clients know it
+func SplitProjectRoomName(roomName livekit.RoomName) (string, livekit.RoomName) {
+	project, name, found := strings.Cut(string(roomName), ProjectSeparator)
+	if !found {
+		return "", roomName
+	}
+	return project, livekit.RoomName(name)
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook verifies webhook requests of the server on the side of receivers.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// webhook requests carry the unix time they were signed at and a nonce unique to the request, for receivers to
+	// reject requests outside of the replay window and requests they have seen already
+	TimestampHeader = "X-Livekit-Webhook-Timestamp"
+	NonceHeader     = "X-Livekit-Webhook-Nonce"
+
+	// the timestamp and nonce are signed with the API secret in this header, as the base64 HMAC-SHA256 of
+	// "<timestamp>.<nonce>.<sha256 of the body>". requests signed with the signing key also have them in the iat and
+	// jti claims of X-Livekit-Webhook-Signature
+	ReplaySignatureHeader = "X-Livekit-Webhook-Replay-Signature"
+
+	// DefaultReplayWindow is the replay window of the server when none is configured
+	DefaultReplayWindow = 5 * time.Minute
+)
+
+var (
+	ErrReplaySignatureInvalid = errors.New("webhook replay signature is missing or invalid")
+	ErrOutsideReplayWindow    = errors.New("webhook was signed outside of the replay window")
+	ErrReplayed               = errors.New("webhook nonce was seen already")
+)
+
+// NewNonce returns a random nonce for a webhook request
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ReplaySignature returns the signature of the timestamp and nonce of a request with the API secret, sha256Sum is the
+// base64 SHA-256 of the body of the request
+func ReplaySignature(apiSecret string, signedAt time.Time, nonce string, sha256Sum string) string {
+	mac := hmac.New(sha256.New, []byte(apiSecret))
+	mac.Write([]byte(strconv.FormatInt(signedAt.Unix(), 10) + "." + nonce + "." + sha256Sum))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ReplayGuard verifies the timestamp and nonce of webhook requests for receivers. requests are accepted when
+// they were signed within the replay window of the clock of the receiver, either way, and their nonce was not seen
+// within the window. the window of receivers should match the replay_window of the server
+type ReplayGuard struct {
+	window time.Duration
+
+	lock sync.Mutex
+	// nonces seen within the window, by the time they were signed at
+	seen map[string]time.Time
+}
+
+func NewReplayGuard(window time.Duration) *ReplayGuard {
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+	return &ReplayGuard{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// VerifyRequest verifies the replay signature of a request with the API secret and the body of the request, then
+// checks its timestamp and nonce. the Authorization token is verified separately, as by the webhook package of the
+// protocol
+func (g *ReplayGuard) VerifyRequest(r *http.Request, apiSecret string, body []byte) error {
+	unix, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return ErrReplaySignatureInvalid
+	}
+	signedAt := time.Unix(unix, 0)
+	nonce := r.Header.Get(NonceHeader)
+	if nonce == "" {
+		return ErrReplaySignatureInvalid
+	}
+
+	sum := sha256.Sum256(body)
+	expected := ReplaySignature(apiSecret, signedAt, nonce, base64.StdEncoding.EncodeToString(sum[:]))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(ReplaySignatureHeader))) {
+		return ErrReplaySignatureInvalid
+	}
+	return g.Check(signedAt, nonce, time.Now())
+}
+
+// Check rejects requests signed outside of the window around now and nonces seen already. receivers verifying the
+// signature with the signing key call it with the iat and jti claims
+func (g *ReplayGuard) Check(signedAt time.Time, nonce string, now time.Time) error {
+	if signedAt.Before(now.Add(-g.window)) || signedAt.After(now.Add(g.window)) {
+		return ErrOutsideReplayWindow
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	// nonces signed outside of the window are rejected by their timestamp already
+	for n, at := range g.seen {
+		if at.Before(now.Add(-g.window)) {
+			delete(g.seen, n)
+		}
+	}
+	if _, ok := g.seen[nonce]; ok {
+		return ErrReplayed
+	}
+	g.seen[nonce] = signedAt
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/webhook"
+)
+
+func TestReplayGuard(t *testing.T) {
+	guard := webhook.NewReplayGuard(time.Minute)
+	results := make(chan error, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		results <- guard.VerifyRequest(r, "secret", body)
+	}))
+	defer receiver.Close()
+
+	body := []byte(`{"event":"room_started"}`)
+	sum := sha256.Sum256(body)
+	encodedSum := base64.StdEncoding.EncodeToString(sum[:])
+
+	// signs the request as the server does, then has the receiver verify it
+	send := func(t *testing.T, secret string, signedAt time.Time, nonce string, tamper func(http.Header)) error {
+		req, err := http.NewRequest(http.MethodPost, receiver.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+		req.Header.Set(webhook.NonceHeader, nonce)
+		req.Header.Set(webhook.ReplaySignatureHeader, webhook.ReplaySignature(secret, signedAt, nonce, encodedSum))
+		if tamper != nil {
+			tamper(req.Header)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		return <-results
+	}
+
+	t.Run("accepts a request once", func(t *testing.T) {
+		nonce, err := webhook.NewNonce()
+		require.NoError(t, err)
+		require.NoError(t, send(t, "secret", time.Now(), nonce, nil))
+		require.ErrorIs(t, send(t, "secret", time.Now(), nonce, nil), webhook.ErrReplayed)
+	})
+
+	t.Run("signature covers the timestamp and nonce", func(t *testing.T) {
+		err := send(t, "secret", time.Now(), "nonce", func(h http.Header) {
+			h.Set(webhook.NonceHeader, "another")
+		})
+		require.ErrorIs(t, err, webhook.ErrReplaySignatureInvalid)
+
+		err = send(t, "secret", time.Now(), "nonce", func(h http.Header) {
+			h.Set(webhook.TimestampHeader, "1700000000")
+		})
+		require.ErrorIs(t, err, webhook.ErrReplaySignatureInvalid)
+
+		err = send(t, "secret", time.Now(), "nonce", func(h http.Header) {
+			h.Del(webhook.ReplaySignatureHeader)
+		})
+		require.ErrorIs(t, err, webhook.ErrReplaySignatureInvalid)
+
+		require.ErrorIs(t, send(t, "other", time.Now(), "nonce", nil), webhook.ErrReplaySignatureInvalid)
+	})
+
+	t.Run("rejects requests signed outside of the window", func(t *testing.T) {
+		require.ErrorIs(t, send(t, "secret", time.Now().Add(-2*time.Minute), "old", nil), webhook.ErrOutsideReplayWindow)
+		require.ErrorIs(t, send(t, "secret", time.Now().Add(2*time.Minute), "future", nil), webhook.ErrOutsideReplayWindow)
+
+		// nonces are forgotten once requests with them are outside of the window
+		now := time.Now()
+		require.NoError(t, guard.Check(now, "forgotten", now))
+		require.ErrorIs(t, guard.Check(now, "forgotten", now.Add(30*time.Second)), webhook.ErrReplayed)
+		require.ErrorIs(t, guard.Check(now, "forgotten", now.Add(2*time.Minute)), webhook.ErrOutsideReplayWindow)
+	})
+}